@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cotai-pdf-processor/pkg/client"
+)
+
+// maxStatusJobs is the most jobs the API reports the status of at once.
+const maxStatusJobs = 1000
+
+// artifacts are the files jobs may produce besides their result, by the
+// name download's -artifacts flag takes, with their file extension.
+var artifacts = map[string]struct {
+	ext   string
+	fetch func(c *client.Client, ctx context.Context, id string) (io.ReadCloser, error)
+}{
+	"report":     {".pdf", (*client.Client).GetJobReport},
+	"archive":    {".pdf", (*client.Client).GetJobArchive},
+	"searchable": {".pdf", (*client.Client).GetJobSearchable},
+	"redacted":   {".pdf", (*client.Client).GetJobRedacted},
+	"annotated":  {".pdf", (*client.Client).GetJobAnnotated},
+	// The format of the OCR layout depends on the job's options
+	"ocr-layout": {"", (*client.Client).GetJobOCRLayout},
+}
+
+func isFinished(status string) bool {
+	return status == "completed" || status == "failed" || status == "cancelled"
+}
+
+// status prints a line of the status of each job.
+func status(ctx context.Context, c *client.Client, args []string) error {
+	flags := flag.NewFlagSet("status", flag.ContinueOnError)
+	if err := parseFlags(flags, "<job-id>...", args); err != nil {
+		return err
+	}
+
+	failed := false
+	for ids := flags.Args(); len(ids) > 0; {
+		batch := ids[:min(len(ids), maxStatusJobs)]
+		ids = ids[len(batch):]
+
+		resp, err := c.GetJobStatuses(ctx, &client.JobStatusRequest{JobIDs: batch})
+		if err != nil {
+			return err
+		}
+		for _, update := range resp.Jobs {
+			fmt.Println(formatUpdate(update))
+		}
+		for _, id := range resp.NotFound {
+			log.Printf("%s: job not found", id)
+			failed = true
+		}
+	}
+	if failed {
+		return errFailed
+	}
+	return nil
+}
+
+func watchInterval(flags *flag.FlagSet) *time.Duration {
+	return flags.Duration("interval", 2*time.Second, "how often to check on the jobs")
+}
+
+// watch follows jobs until they finish.
+func watch(ctx context.Context, c *client.Client, args []string) error {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	interval := watchInterval(flags)
+	if err := parseFlags(flags, "[flags] <job-id>...", args); err != nil {
+		return err
+	}
+	return watchJobs(ctx, c, flags.Args(), *interval)
+}
+
+// watchJobs polls the status of jobs, printing a line whenever a job's
+// status or progress changes, until they all finish. It fails when one
+// does not complete.
+func watchJobs(ctx context.Context, c *client.Client, ids []string, interval time.Duration) error {
+	printed := make(map[string]string)
+	pending := append([]string(nil), ids...)
+	failed := false
+	for {
+		var next []string
+		for ids := pending; len(ids) > 0; {
+			batch := ids[:min(len(ids), maxStatusJobs)]
+			ids = ids[len(batch):]
+
+			resp, err := c.GetJobStatuses(ctx, &client.JobStatusRequest{JobIDs: batch})
+			if err != nil {
+				return err
+			}
+			for _, update := range resp.Jobs {
+				if line := formatUpdate(update); line != printed[update.JobID] {
+					fmt.Println(line)
+					printed[update.JobID] = line
+				}
+				switch {
+				case !isFinished(update.Status):
+					next = append(next, update.JobID)
+				case update.Status != "completed":
+					failed = true
+				}
+			}
+			for _, id := range resp.NotFound {
+				log.Printf("%s: job not found", id)
+				failed = true
+			}
+		}
+
+		pending = next
+		if len(pending) == 0 {
+			break
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if failed {
+		return errFailed
+	}
+	return nil
+}
+
+// formatUpdate writes a line of a job's ID, status, progress and error.
+func formatUpdate(update client.JobUpdate) string {
+	fields := []string{update.JobID, update.Status}
+	if update.Progress != nil && !isFinished(update.Status) {
+		progress := fmt.Sprintf("%d%%", update.Progress.Percent)
+		if update.Progress.Stage != "" {
+			progress = update.Progress.Stage + " " + progress
+		}
+		fields = append(fields, progress)
+	}
+	if update.NextRetryAt != nil {
+		fields = append(fields, "retrying at "+update.NextRetryAt.Local().Format(time.RFC3339))
+	}
+	if update.Error != "" {
+		fields = append(fields, update.Error)
+	}
+	return strings.Join(fields, "\t")
+}
+
+// download writes the result of each job to <dir>/<job-id>.json, and the
+// artifacts asked for to <dir>/<job-id>-<artifact>.
+func download(ctx context.Context, c *client.Client, args []string) error {
+	flags := flag.NewFlagSet("download", flag.ContinueOnError)
+	dir := flags.String("o", ".", "directory to write the files to")
+	names := flags.String("artifacts", "", "comma-separated artifacts to download too: report, archive, searchable, redacted, annotated or ocr-layout")
+	if err := parseFlags(flags, "[flags] <job-id>...", args); err != nil {
+		return err
+	}
+
+	var wanted []string
+	for _, name := range strings.Split(*names, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if _, ok := artifacts[name]; !ok {
+			return fmt.Errorf("unknown artifact %q", name)
+		}
+		wanted = append(wanted, name)
+	}
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		return err
+	}
+
+	failed := false
+	for _, id := range flags.Args() {
+		job, err := c.GetJob(ctx, id)
+		if err != nil {
+			log.Printf("%s: %v", id, err)
+			failed = true
+			continue
+		}
+		if job.Result == nil {
+			log.Printf("%s: no result, the job is %s", id, job.Status)
+			failed = true
+			continue
+		}
+		data, err := json.MarshalIndent(job.Result, "", "  ")
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(*dir, id+".json")
+		if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+			return err
+		}
+		fmt.Println(path)
+
+		for _, name := range wanted {
+			path := filepath.Join(*dir, id+"-"+name+artifacts[name].ext)
+			if err := downloadArtifact(ctx, c, id, name, path); err != nil {
+				log.Printf("%s: %s: %v", id, name, err)
+				failed = true
+				continue
+			}
+			fmt.Println(path)
+		}
+	}
+	if failed {
+		return errFailed
+	}
+	return nil
+}
+
+func downloadArtifact(ctx context.Context, c *client.Client, id, name, path string) error {
+	body, err := artifacts[name].fetch(c, ctx, id)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// cancel cancels each job, printing its status.
+func cancel(ctx context.Context, c *client.Client, args []string) error {
+	flags := flag.NewFlagSet("cancel", flag.ContinueOnError)
+	if err := parseFlags(flags, "<job-id>...", args); err != nil {
+		return err
+	}
+
+	failed := false
+	for _, id := range flags.Args() {
+		resp, err := c.CancelJob(ctx, id)
+		if err != nil {
+			log.Printf("%s: %v", id, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s\t%s\n", resp.JobID, resp.Status)
+	}
+	if failed {
+		return errFailed
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Command cotai-pdf submits documents to the PDF processor and follows
+// their jobs from a shell, for debugging and backfills:
+//
+//	cotai-pdf submit [flags] <file|directory|URL>...
+//	cotai-pdf status <job-id>...
+//	cotai-pdf watch [flags] <job-id>...
+//	cotai-pdf download [flags] <job-id>...
+//	cotai-pdf cancel <job-id>...
+//
+// The processor is the one at -server, or $COTAI_PDF_URL when set; the
+// token or API key in $COTAI_PDF_TOKEN, if any, is sent as the bearer of
+// requests.
+// Build it with go build -o cotai-pdf ./cmd/cli.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"cotai-pdf-processor/pkg/client"
+)
+
+const defaultServer = "http://localhost:8080"
+
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, c *client.Client, args []string) error
+}
+
+var commands = []command{
+	{"submit", "submit documents, printing the job of each", submit},
+	{"status", "print the status of jobs", status},
+	{"watch", "follow jobs until they finish", watch},
+	{"download", "download the results and artifacts of jobs", download},
+	{"cancel", "cancel jobs", cancel},
+}
+
+var (
+	// errUsage reports invalid arguments, the usage already printed.
+	errUsage = errors.New("invalid arguments")
+
+	// errFailed reports that some of the work failed, its errors already
+	// printed.
+	errFailed = errors.New("failed")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("cotai-pdf: ")
+
+	server := defaultServer
+	if env := os.Getenv("COTAI_PDF_URL"); env != "" {
+		server = env
+	}
+	flag.StringVar(&server, "server", server, "URL of the PDF processor")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == flag.Arg(0) {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		log.Printf("unknown command %q", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	c := client.New(server, nil)
+	if token := os.Getenv("COTAI_PDF_TOKEN"); token != "" {
+		c.Header.Set("Authorization", "Bearer "+token)
+	}
+	err := cmd.run(ctx, c, flag.Args()[1:])
+	switch {
+	case err == nil:
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	case errors.Is(err, errFailed):
+		os.Exit(1)
+	default:
+		log.Print(err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: cotai-pdf [-server URL] <command> [arguments]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+// parseFlags parses the arguments of a command, which must leave at least
+// one argument. synopsis describes the arguments in the usage.
+func parseFlags(fs *flag.FlagSet, synopsis string, args []string) error {
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: cotai-pdf %s %s\n", fs.Name(), synopsis)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
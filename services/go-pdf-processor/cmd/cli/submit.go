@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"cotai-pdf-processor/pkg/client"
+)
+
+type submission struct {
+	source string
+	jobID  string
+	err    error
+}
+
+// submit uploads each file, the files of each directory and the document
+// at each URL as a job of its own, a few at a time, and prints a line of
+// the source and job ID of each.
+func submit(ctx context.Context, c *client.Client, args []string) error {
+	flags := flag.NewFlagSet("submit", flag.ContinueOnError)
+	fields := make(map[string]*string)
+	for _, field := range []struct{ name, flag, usage string }{
+		{"tenant_id", "tenant", "tenant of the jobs"},
+		{"user_id", "user", "user of the jobs"},
+		{"tender_id", "tender", "tender the documents belong to"},
+		{"interest_profile_id", "interest-profile", "interest profile to match the documents against"},
+		{"profile", "profile", "processing profile"},
+		{"priority", "priority", "priority of the jobs: high, normal or low"},
+		{"options", "options", "processing options, as JSON"},
+		{"callback_url", "callback", "URL to post each finished job to"},
+	} {
+		fields[field.name] = flags.String(field.flag, "", field.usage)
+	}
+	ext := flags.String("ext", ".pdf", "comma-separated extensions of the files submitted from directories")
+	concurrency := flags.Int("concurrency", 4, "uploads at once")
+	wait := flags.Bool("wait", false, "watch the jobs until they finish")
+	interval := watchInterval(flags)
+	if err := parseFlags(flags, "[flags] <file|directory|URL>...", args); err != nil {
+		return err
+	}
+
+	form := make(map[string]string)
+	for name, value := range fields {
+		if *value != "" {
+			form[name] = *value
+		}
+	}
+	sources, err := collectSources(flags.Args(), strings.Split(*ext, ","))
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("no files with extension %s found", *ext)
+	}
+
+	pending := make(chan string)
+	results := make(chan submission)
+	for i := 0; i < min(max(*concurrency, 1), len(sources)); i++ {
+		go func() {
+			for source := range pending {
+				jobID, err := submitSource(ctx, c, source, form)
+				results <- submission{source: source, jobID: jobID, err: err}
+			}
+		}()
+	}
+	go func() {
+		defer close(pending)
+		for _, source := range sources {
+			select {
+			case pending <- source:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var jobIDs []string
+	failed := false
+	for i := 0; i < len(sources); i++ {
+		var s submission
+		select {
+		case s = <-results:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if s.err != nil {
+			log.Printf("%s: %v", s.source, s.err)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s\t%s\n", s.source, s.jobID)
+		jobIDs = append(jobIDs, s.jobID)
+	}
+
+	if *wait && len(jobIDs) > 0 {
+		if err := watchJobs(ctx, c, jobIDs, *interval); err != nil {
+			return err
+		}
+	}
+	if failed {
+		return errFailed
+	}
+	return nil
+}
+
+// collectSources expands the directories among args into the files under
+// them with one of the extensions, in lexical order. Files named and URLs
+// are kept whatever their extension.
+func collectSources(args []string, extensions []string) ([]string, error) {
+	wanted := make(map[string]bool)
+	for _, ext := range extensions {
+		if ext = strings.ToLower(strings.TrimSpace(ext)); ext != "" {
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			wanted[ext] = true
+		}
+	}
+
+	var sources []string
+	for _, arg := range args {
+		if isURL(arg) {
+			sources = append(sources, arg)
+			continue
+		}
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			sources = append(sources, arg)
+			continue
+		}
+		err = filepath.WalkDir(arg, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.Type().IsRegular() && wanted[strings.ToLower(filepath.Ext(path))] {
+				sources = append(sources, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sources, nil
+}
+
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// submitSource uploads a local file, or streams the document at a URL
+// through to the processor, and returns the job's ID.
+func submitSource(ctx context.Context, c *client.Client, source string, fields map[string]string) (string, error) {
+	var file client.File
+	if isURL(source) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("download failed: %s", resp.Status)
+		}
+		file = client.File{Name: urlFileName(resp.Request.URL), Content: resp.Body}
+		if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+			file.ContentType = mediaType
+		}
+	} else {
+		f, err := os.Open(source)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		file = client.File{Name: filepath.Base(source), Content: f}
+	}
+
+	resp, err := c.UploadDocument(ctx, file, fields)
+	if err != nil {
+		return "", err
+	}
+	return resp.JobID, nil
+}
+
+// urlFileName names the document at a URL after the last element of its
+// path.
+func urlFileName(u *url.URL) string {
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		return "document"
+	}
+	return name
+}
@@ -4,10 +4,15 @@ go 1.21
 
 require (
     github.com/gin-gonic/gin v1.9.1
+    github.com/gorilla/websocket v1.5.1
     github.com/ledongthuc/pdf v0.0.0-20220302134840-0c2507a12d80
     github.com/otiai10/gosseract/v2 v2.4.1
+    github.com/rabbitmq/amqp091-go v1.9.0
     github.com/redis/go-redis/v9 v9.3.0
+    github.com/segmentio/kafka-go v0.4.47
     github.com/lib/pq v1.10.9
+    github.com/minio/minio-go/v7 v7.0.66
+    github.com/nats-io/nats.go v1.31.0
     github.com/joho/godotenv v1.5.1
     github.com/spf13/viper v1.17.0
     go.opentelemetry.io/otel v1.21.0
@@ -15,5 +20,8 @@ require (
     go.opentelemetry.io/otel/exporters/jaeger v1.17.0
     go.uber.org/zap v1.26.0
     golang.org/x/sync v0.5.0
+    golang.org/x/crypto v0.16.0
     github.com/google/uuid v1.4.0
+    github.com/klauspost/compress v1.17.4
+    gopkg.in/yaml.v3 v3.0.1
 )
\ No newline at end of file
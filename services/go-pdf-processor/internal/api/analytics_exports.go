@@ -0,0 +1,83 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultAnalyticsExportLimit = 20
+	maxAnalyticsExportLimit     = 200
+)
+
+// analyticsExportRequest backfills the jobs completed in [From, To); an
+// empty body exports those completed since the last incremental export.
+type analyticsExportRequest struct {
+	From *time.Time `json:"from"`
+	To   *time.Time `json:"to"`
+}
+
+func (h *Handler) listAnalyticsExports(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultAnalyticsExportLimit)))
+	if err != nil || limit < 1 || limit > maxAnalyticsExportLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxAnalyticsExportLimit)})
+		return
+	}
+
+	exports, err := h.processor.ListAnalyticsExports(c.Request.Context(), limit)
+	if err != nil {
+		analyticsExportError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"exports": exports})
+}
+
+func (h *Handler) getAnalyticsExport(c *gin.Context) {
+	export, err := h.processor.GetAnalyticsExport(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		analyticsExportError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, export)
+}
+
+// startAnalyticsExport exports results as Parquet in the background; the
+// export is polled for its outcome.
+func (h *Handler) startAnalyticsExport(c *gin.Context) {
+	var req analyticsExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	export, err := h.processor.StartAnalyticsExport(c.Request.Context(), req.From, req.To)
+	if err != nil {
+		analyticsExportError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, export)
+}
+
+func analyticsExportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, processor.ErrAnalyticsExportNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, processor.ErrInvalidAnalyticsExport):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, processor.ErrAnalyticsExportRunning):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, processor.ErrAnalyticsExportDisabled):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Analytics export request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to access analytics exports"})
+	}
+}
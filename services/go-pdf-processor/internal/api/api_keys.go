@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cotai-pdf-processor/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultUsageDays = 30
+	maxUsageDays     = 366
+)
+
+type apiKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes"` // read only when empty
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (h *Handler) listAPIKeys(c *gin.Context) {
+	keys, err := h.apiKeys.List(c.Request.Context(), c.Param("tenant"))
+	if err != nil {
+		apiKeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// issueAPIKey issues a key to the tenant, of scopes the caller holds; the
+// key is only shown in this response.
+func (h *Handler) issueAPIKey(c *gin.Context) {
+	var req apiKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	principal := auth.FromContext(c.Request.Context())
+	if principal != nil {
+		for _, scope := range req.Scopes {
+			if scope = strings.ToLower(strings.TrimSpace(scope)); !h.hasScope(principal, scope) {
+				c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("cannot issue a key of the %s scope, not held", scope)})
+				return
+			}
+		}
+	}
+
+	key := &auth.APIKey{
+		TenantID:  c.Param("tenant"),
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if principal != nil {
+		key.CreatedBy = principal.UserID
+	}
+	if err := h.apiKeys.Issue(c.Request.Context(), key); err != nil {
+		apiKeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, key)
+}
+
+func (h *Handler) getAPIKey(c *gin.Context) {
+	key, err := h.apiKeys.Get(c.Request.Context(), c.Param("tenant"), c.Param("id"))
+	if err != nil {
+		apiKeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, key)
+}
+
+// rotateAPIKey gives a key a new secret, shown in the response; the
+// previous one keeps working for API_KEY_ROTATION_GRACE.
+func (h *Handler) rotateAPIKey(c *gin.Context) {
+	key, err := h.apiKeys.Rotate(c.Request.Context(), c.Param("tenant"), c.Param("id"))
+	if err != nil {
+		apiKeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, key)
+}
+
+func (h *Handler) revokeAPIKey(c *gin.Context) {
+	key, err := h.apiKeys.Revoke(c.Request.Context(), c.Param("tenant"), c.Param("id"))
+	if err != nil {
+		apiKeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, key)
+}
+
+// getAPIKeyUsage reports the requests made with a key by day, over the
+// last days given.
+func (h *Handler) getAPIKeyUsage(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultUsageDays)))
+	if err != nil || days < 1 || days > maxUsageDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and " + strconv.Itoa(maxUsageDays)})
+		return
+	}
+
+	usage, err := h.apiKeys.Usage(c.Request.Context(), c.Param("tenant"), c.Param("id"), days)
+	if err != nil {
+		apiKeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"usage": usage})
+}
+
+func apiKeyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, auth.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, auth.ErrAPIKeyRevoked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, auth.ErrInvalidAPIKey):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("API key request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to access API keys"})
+	}
+}
@@ -0,0 +1,298 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"cotai-pdf-processor/internal/auth"
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// publicRoutes are the routes authenticate leaves open.
+var publicRoutes = map[string]bool{
+	"/api/v1/openapi.json":                    true,
+	"/api/v1/presigned-uploads/notifications": true,
+}
+
+// authenticate requires a bearer token of the identity provider, or an
+// API key, on the routes of the API, setting the principal it names in
+// the request's context. The OpenAPI document and CORS or tus OPTIONS
+// requests, which cannot carry credentials, are left open, as are the
+// object store's bucket notifications, which carry a token of their own
+// (see handleBucketNotification). Browsers cannot set headers
+// on WebSockets and event streams, whose token may be passed as the
+// access_token query parameter instead. API keys are always checked;
+// other requests are only let through without a token when AUTH_DISABLED
+// is set, for development. With
+// OIDC_ISSUER, operator routes take tokens of that provider instead (see
+// authorize for the roles they need).
+func (h *Handler) authenticate() gin.HandlerFunc {
+	var verifier *auth.Verifier
+	switch {
+	case h.cfg.AuthJWKSURL == "" && h.cfg.AuthDisabled:
+		log.Printf("WARNING: authentication disabled by AUTH_DISABLED; the API is open")
+	case h.cfg.AuthJWKSURL == "":
+		log.Printf("Token authentication unavailable: AUTH_JWKS_URL is not set; only API keys are accepted")
+	default:
+		verifier = auth.NewVerifier(auth.NewKeySet(h.cfg.AuthJWKSURL, h.cfg.AuthJWKSRefresh), auth.VerifierConfig{
+			Issuer:      h.cfg.AuthIssuer,
+			Audience:    h.cfg.AuthAudience,
+			UserClaim:   h.cfg.AuthUserClaim,
+			TenantClaim: h.cfg.AuthTenantClaim,
+			RolesClaim:  h.cfg.AuthRolesClaim,
+			Leeway:      h.cfg.AuthClockSkew,
+		})
+	}
+
+	var operators *auth.Verifier
+	if h.cfg.OIDCIssuer == "" {
+		log.Printf("OIDC for operator routes disabled: OIDC_ISSUER is not set")
+	} else {
+		operators = auth.NewVerifier(auth.NewDiscoveredKeySet(h.cfg.OIDCIssuer, h.cfg.AuthJWKSRefresh), auth.VerifierConfig{
+			Issuer:     h.cfg.OIDCIssuer,
+			ClientID:   h.cfg.OIDCClientID,
+			UserClaim:  h.cfg.AuthUserClaim,
+			RolesClaim: h.cfg.OIDCRolesClaim,
+			Leeway:     h.cfg.AuthClockSkew,
+		})
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodOptions || publicRoutes[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		scope := routeScope(c.Request.Method, c.FullPath())
+		tokens := verifier
+		if scope == scopeOperator && operators != nil {
+			tokens = operators
+		}
+
+		token := bearerToken(c)
+		if key := c.GetHeader("X-API-Key"); key != "" {
+			token = key
+		}
+
+		var principal *auth.Principal
+		var err error
+		switch {
+		case auth.IsAPIKey(token):
+			principal, err = h.apiKeys.Authenticate(c.Request.Context(), token)
+		case tokens == nil && h.cfg.AuthDisabled:
+			c.Next()
+			return
+		case tokens == nil:
+			unauthorized(c, "token authentication is not configured", "")
+			return
+		case token == "":
+			unauthorized(c, "missing bearer token", "")
+			return
+		default:
+			principal, err = tokens.Verify(c.Request.Context(), token)
+		}
+		if errors.Is(err, auth.ErrInvalidToken) {
+			unauthorized(c, err.Error(), "invalid_token")
+			return
+		}
+		if errors.Is(err, auth.ErrKeysUnavailable) {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to authenticate request: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to authenticate request"})
+			return
+		}
+
+		if !h.authorize(c, principal, scope) {
+			return
+		}
+		if principal.APIKeyID != "" {
+			h.apiKeys.RecordUsage(principal.APIKeyID, scope == auth.ScopeSubmit)
+		}
+
+		ctx := auth.WithPrincipal(c.Request.Context(), principal)
+		if access, restricted := h.jobAccess(principal, scope); restricted {
+			ctx = processor.WithJobAccess(ctx, access)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// jobAccess returns the jobs a principal may see: operators all of them,
+// tenant admins and admin API keys those of their tenant, and other users
+// and keys those they submitted.
+func (h *Handler) jobAccess(principal *auth.Principal, scope string) (processor.JobAccess, bool) {
+	switch {
+	case scope == scopeOperator, principal.HasRole(h.cfg.AuthOperatorRole):
+		return processor.JobAccess{}, false
+	case principal.TenantID == "":
+		// Tenant admins of no tenant are left their own jobs
+	case principal.HasRole(h.cfg.AuthTenantAdminRole),
+		principal.APIKeyID != "" && slices.Contains(principal.Scopes, auth.ScopeAdmin):
+		return processor.JobAccess{TenantID: principal.TenantID}, true
+	}
+	return processor.JobAccess{TenantID: principal.TenantID, UserID: principal.UserID}, true
+}
+
+// scopeOperator is the scope of the routes running the service, which no
+// API key is given.
+const scopeOperator = "operator"
+
+// submitRoutes are the routes API keys need the submit scope for: those
+// submitting, uploading and cancelling jobs.
+var submitRoutes = map[string]bool{
+	"POST /api/v1/documents":                             true,
+	"POST /api/v1/tenders":                               true,
+	"DELETE /api/v1/jobs/:id":                            true,
+	"POST /api/v1/jobs/:id/webhooks/:delivery/redeliver": true,
+	"POST /api/v1/uploads":                               true,
+	"PATCH /api/v1/uploads/:id":                          true,
+	"DELETE /api/v1/uploads/:id":                         true,
+	"POST /api/v1/presigned-uploads":                     true,
+	"POST /api/v1/presigned-uploads/:id/complete":        true,
+}
+
+// readRoutes are the routes other than GET and HEAD ones API keys need
+// the read scope for: queries sent in request bodies.
+var readRoutes = map[string]bool{
+	"POST /api/v1/jobs/status":        true,
+	"POST /api/v1/graphql":            true,
+	"POST /api/v1/risk-rules/dry-run": true,
+}
+
+// routeScope returns the scope a route needs. Changes to the settings of a
+// tenant, its API keys and risk rules among them, need the admin scope;
+// those of the service's own, its workers, caches and dead letters, are
+// left to operators, as are the global risk rules (see riskRuleTenant).
+func routeScope(method, route string) string {
+	switch {
+	case strings.HasPrefix(route, "/api/v1/admin/"),
+		strings.HasPrefix(route, "/api/v1/dead-letters"),
+		strings.HasPrefix(route, "/api/v1/result-cache"):
+		return scopeOperator
+	case strings.Contains(route, "/api-keys"):
+		return auth.ScopeAdmin
+	case submitRoutes[method+" "+route]:
+		return auth.ScopeSubmit
+	case method == http.MethodGet, method == http.MethodHead, readRoutes[method+" "+route]:
+		return auth.ScopeRead
+	}
+	return auth.ScopeAdmin
+}
+
+// operatorRole returns the role operator routes need: OIDC_ADMIN_ROLE of
+// the OpenID provider when there is one, AUTH_OPERATOR_ROLE otherwise.
+func (h *Handler) operatorRole() string {
+	if h.cfg.OIDCIssuer != "" {
+		return h.cfg.OIDCAdminRole
+	}
+	return h.cfg.AuthOperatorRole
+}
+
+// hasScope reports whether a principal holds a scope: API keys those they
+// were issued, and users of the identity provider all but the admin scope,
+// held by tenant admins and operators only.
+func (h *Handler) hasScope(principal *auth.Principal, scope string) bool {
+	if principal.APIKeyID != "" {
+		return principal.HasScope(scope)
+	}
+	return scope != auth.ScopeAdmin ||
+		principal.HasRole(h.cfg.AuthTenantAdminRole) || principal.HasRole(h.cfg.AuthOperatorRole)
+}
+
+// authorize refuses requests the principal may not make: operator routes
+// but to users given the operator role, those outside the scopes of its
+// API key, and those of tenants other than its own, which principals of no
+// tenant have none of, unless it is an operator.
+func (h *Handler) authorize(c *gin.Context, principal *auth.Principal, scope string) bool {
+	if scope == scopeOperator && principal.APIKeyID != "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API keys cannot call operator routes"})
+		return false
+	}
+	if role := h.operatorRole(); scope == scopeOperator && !principal.HasRole(role) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("operator routes need the %s role", role)})
+		return false
+	}
+	if scope != scopeOperator && !h.hasScope(principal, scope) {
+		message := fmt.Sprintf("API key lacks the %s scope", scope)
+		if principal.APIKeyID == "" {
+			message = fmt.Sprintf("the %s scope needs the %s role", scope, h.cfg.AuthTenantAdminRole)
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": message})
+		return false
+	}
+	tenant := c.Param("tenant")
+	if tenant != "" && scope != scopeOperator && tenant != principal.TenantID && !principal.HasRole(h.cfg.AuthOperatorRole) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("not allowed for tenant %q", tenant)})
+		return false
+	}
+	return true
+}
+
+func bearerToken(c *gin.Context) string {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	streaming := c.GetHeader("Upgrade") != "" || strings.Contains(c.GetHeader("Accept"), "text/event-stream")
+	if c.Request.Method == http.MethodGet && streaming {
+		return c.Query("access_token")
+	}
+	return ""
+}
+
+// unauthorized writes a 401 response with the challenge of RFC 6750.
+func unauthorized(c *gin.Context, message, code string) {
+	challenge := `Bearer realm="cotai-pdf-processor"`
+	if code != "" {
+		challenge += fmt.Sprintf(`, error="%s"`, code)
+	}
+	c.Header("WWW-Authenticate", challenge)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": message})
+}
+
+// requestTenant returns the tenant a request reads from: that of its
+// principal, or the tenant_id query parameter for operators and
+// unauthenticated requests.
+func (h *Handler) requestTenant(c *gin.Context) string {
+	principal := auth.FromContext(c.Request.Context())
+	if principal == nil {
+		return c.Query("tenant_id")
+	}
+	if tenant := c.Query("tenant_id"); tenant != "" && principal.HasRole(h.cfg.AuthOperatorRole) {
+		return tenant
+	}
+	return principal.TenantID
+}
+
+// claimIdentity makes the user and tenant of a submission those of its
+// token, refusing a tenant other than the token's. Operators may submit
+// for any tenant. Without authentication they are left as submitted.
+func (h *Handler) claimIdentity(c *gin.Context, userID, tenantID *string) bool {
+	principal := auth.FromContext(c.Request.Context())
+	if principal == nil {
+		return true
+	}
+	*userID = principal.UserID
+	if principal.HasRole(h.cfg.AuthOperatorRole) {
+		if *tenantID == "" {
+			*tenantID = principal.TenantID
+		}
+		return true
+	}
+	if *tenantID != "" && *tenantID != principal.TenantID {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("token is not of tenant %q", *tenantID)})
+		return false
+	}
+	*tenantID = principal.TenantID
+	return true
+}
@@ -0,0 +1,249 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cotai-pdf-processor/internal/auth"
+	"cotai-pdf-processor/internal/config"
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testHandler() *Handler {
+	return &Handler{cfg: &config.Config{
+		AuthOperatorRole:    "operator",
+		AuthTenantAdminRole: "tenant-admin",
+	}}
+}
+
+// testContext returns the context of a request of principal to a route of
+// tenant, and the recorder of its response.
+func testContext(principal *auth.Principal, tenant string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+	if principal != nil {
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+	}
+	if tenant != "" {
+		c.Params = gin.Params{{Key: "tenant", Value: tenant}}
+	}
+	return c, w
+}
+
+func TestAuthorize(t *testing.T) {
+	user := &auth.Principal{UserID: "u1", TenantID: "t1"}
+	tenantAdmin := &auth.Principal{UserID: "u2", TenantID: "t1", Roles: []string{"tenant-admin"}}
+	operator := &auth.Principal{UserID: "u3", TenantID: "t1", Roles: []string{"operator"}}
+	noTenant := &auth.Principal{UserID: "u4"}
+	readKey := &auth.Principal{UserID: "k1", TenantID: "t1", APIKeyID: "k1", Scopes: []string{auth.ScopeRead}}
+	adminKey := &auth.Principal{UserID: "k2", TenantID: "t1", APIKeyID: "k2", Scopes: []string{auth.ScopeAdmin}}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		scope     string
+		tenant    string
+		want      bool
+	}{
+		{"user reads", user, auth.ScopeRead, "", true},
+		{"user submits", user, auth.ScopeSubmit, "", true},
+		{"user administers", user, auth.ScopeAdmin, "", false},
+		{"tenant admin administers", tenantAdmin, auth.ScopeAdmin, "t1", true},
+		{"user calls operator route", user, scopeOperator, "", false},
+		{"tenant admin calls operator route", tenantAdmin, scopeOperator, "", false},
+		{"operator calls operator route", operator, scopeOperator, "", true},
+		{"key calls operator route", adminKey, scopeOperator, "", false},
+		{"key within its scope", readKey, auth.ScopeRead, "", true},
+		{"key outside its scope", readKey, auth.ScopeSubmit, "", false},
+		{"admin key submits", adminKey, auth.ScopeSubmit, "", true},
+		{"user of the tenant", user, auth.ScopeRead, "t1", true},
+		{"user of another tenant", user, auth.ScopeRead, "t2", false},
+		{"key of another tenant", adminKey, auth.ScopeAdmin, "t2", false},
+		{"user of no tenant", noTenant, auth.ScopeRead, "t1", false},
+		{"operator of another tenant", operator, auth.ScopeAdmin, "t2", true},
+	}
+
+	h := testHandler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := testContext(tt.principal, tt.tenant)
+			if got := h.authorize(c, tt.principal, tt.scope); got != tt.want {
+				t.Fatalf("authorize() = %v, want %v", got, tt.want)
+			}
+			if !tt.want && w.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusForbidden)
+			}
+		})
+	}
+}
+
+func TestAuthorizeOIDCOperators(t *testing.T) {
+	h := testHandler()
+	h.cfg.OIDCIssuer = "https://id.example.com"
+	h.cfg.OIDCAdminRole = "oidc-admin"
+
+	for _, tt := range []struct {
+		roles []string
+		want  bool
+	}{
+		{[]string{"operator"}, false},
+		{[]string{"oidc-admin"}, true},
+	} {
+		principal := &auth.Principal{UserID: "u1", Roles: tt.roles}
+		c, _ := testContext(principal, "")
+		if got := h.authorize(c, principal, scopeOperator); got != tt.want {
+			t.Errorf("authorize() with roles %v = %v, want %v", tt.roles, got, tt.want)
+		}
+	}
+}
+
+func TestJobAccess(t *testing.T) {
+	tests := []struct {
+		name           string
+		principal      *auth.Principal
+		scope          string
+		want           processor.JobAccess
+		wantRestricted bool
+	}{
+		{
+			name:      "operator",
+			principal: &auth.Principal{UserID: "u1", TenantID: "t1", Roles: []string{"operator"}},
+			scope:     auth.ScopeRead,
+		},
+		{
+			name:      "operator route",
+			principal: &auth.Principal{UserID: "u1", TenantID: "t1", Roles: []string{"oidc-admin"}},
+			scope:     scopeOperator,
+		},
+		{
+			name:           "tenant admin",
+			principal:      &auth.Principal{UserID: "u1", TenantID: "t1", Roles: []string{"tenant-admin"}},
+			scope:          auth.ScopeRead,
+			want:           processor.JobAccess{TenantID: "t1"},
+			wantRestricted: true,
+		},
+		{
+			name:           "admin key",
+			principal:      &auth.Principal{UserID: "k1", TenantID: "t1", APIKeyID: "k1", Scopes: []string{auth.ScopeAdmin}},
+			scope:          auth.ScopeRead,
+			want:           processor.JobAccess{TenantID: "t1"},
+			wantRestricted: true,
+		},
+		{
+			name:           "user",
+			principal:      &auth.Principal{UserID: "u1", TenantID: "t1"},
+			scope:          auth.ScopeRead,
+			want:           processor.JobAccess{TenantID: "t1", UserID: "u1"},
+			wantRestricted: true,
+		},
+		{
+			name:           "read key",
+			principal:      &auth.Principal{UserID: "k1", TenantID: "t1", APIKeyID: "k1", Scopes: []string{auth.ScopeRead}},
+			scope:          auth.ScopeRead,
+			want:           processor.JobAccess{TenantID: "t1", UserID: "k1"},
+			wantRestricted: true,
+		},
+		{
+			name:           "tenant admin of no tenant",
+			principal:      &auth.Principal{UserID: "u1", Roles: []string{"tenant-admin"}},
+			scope:          auth.ScopeRead,
+			want:           processor.JobAccess{UserID: "u1"},
+			wantRestricted: true,
+		},
+	}
+
+	h := testHandler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, restricted := h.jobAccess(tt.principal, tt.scope)
+			if got != tt.want || restricted != tt.wantRestricted {
+				t.Errorf("jobAccess() = %+v, %v, want %+v, %v", got, restricted, tt.want, tt.wantRestricted)
+			}
+		})
+	}
+}
+
+func TestClaimIdentity(t *testing.T) {
+	user := &auth.Principal{UserID: "u1", TenantID: "t1"}
+	operator := &auth.Principal{UserID: "u2", TenantID: "t1", Roles: []string{"operator"}}
+
+	tests := []struct {
+		name       string
+		principal  *auth.Principal
+		userID     string
+		tenantID   string
+		want       bool
+		wantUser   string
+		wantTenant string
+	}{
+		{"unauthenticated keeps submitted", nil, "x", "t9", true, "x", "t9"},
+		{"user gets its identity", user, "", "", true, "u1", "t1"},
+		{"user cannot submit for another user", user, "x", "t1", true, "u1", "t1"},
+		{"user cannot submit for another tenant", user, "", "t2", false, "", ""},
+		{"operator submits for another tenant", operator, "x", "t2", true, "u2", "t2"},
+		{"operator defaults to its tenant", operator, "", "", true, "u2", "t1"},
+	}
+
+	h := testHandler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := testContext(tt.principal, "")
+			userID, tenantID := tt.userID, tt.tenantID
+			got := h.claimIdentity(c, &userID, &tenantID)
+			if got != tt.want {
+				t.Fatalf("claimIdentity() = %v, want %v", got, tt.want)
+			}
+			if !got {
+				if w.Code != http.StatusForbidden {
+					t.Errorf("status = %d, want %d", w.Code, http.StatusForbidden)
+				}
+				return
+			}
+			if userID != tt.wantUser || tenantID != tt.wantTenant {
+				t.Errorf("identity = %q, %q, want %q, %q", userID, tenantID, tt.wantUser, tt.wantTenant)
+			}
+		})
+	}
+}
+
+func TestRiskRuleTenant(t *testing.T) {
+	user := &auth.Principal{UserID: "u1", TenantID: "t1", Roles: []string{"tenant-admin"}}
+	operator := &auth.Principal{UserID: "u2", TenantID: "t1", Roles: []string{"operator"}}
+	noTenant := &auth.Principal{UserID: "u3", Roles: []string{"tenant-admin"}}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		query     string
+		change    bool
+		want      string
+		wantOK    bool
+	}{
+		{"user manages its tenant's", user, "", true, "t1", true},
+		{"user cannot name another tenant", user, "t2", true, "t1", true},
+		{"operator manages the global rules", operator, "", true, "", true},
+		{"operator manages another tenant's", operator, "t2", true, "t2", true},
+		{"user of no tenant reads the global rules", noTenant, "", false, "", true},
+		{"user of no tenant cannot change the global rules", noTenant, "", true, "", false},
+	}
+
+	h := testHandler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := testContext(tt.principal, "")
+			c.Request.URL.RawQuery = "tenant_id=" + tt.query
+			got, ok := h.riskRuleTenant(c, tt.change)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("riskRuleTenant() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+			if !ok && w.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusForbidden)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultBillingExportLimit = 20
+	maxBillingExportLimit     = 200
+)
+
+// billingExportRequest exports again the records of the jobs metered in
+// [From, To); an empty body exports those metered since the last
+// incremental export.
+type billingExportRequest struct {
+	From *time.Time `json:"from"`
+	To   *time.Time `json:"to"`
+}
+
+func (h *Handler) listBillingExports(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultBillingExportLimit)))
+	if err != nil || limit < 1 || limit > maxBillingExportLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxBillingExportLimit)})
+		return
+	}
+
+	exports, err := h.processor.ListBillingExports(c.Request.Context(), limit)
+	if err != nil {
+		billingExportError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"exports": exports})
+}
+
+func (h *Handler) getBillingExport(c *gin.Context) {
+	export, err := h.processor.GetBillingExport(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		billingExportError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, export)
+}
+
+// startBillingExport exports usage records as CSV in the background; the
+// export is polled for its outcome.
+func (h *Handler) startBillingExport(c *gin.Context) {
+	var req billingExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	export, err := h.processor.StartBillingExport(c.Request.Context(), req.From, req.To)
+	if err != nil {
+		billingExportError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, export)
+}
+
+func billingExportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, processor.ErrBillingExportNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, processor.ErrInvalidBillingExport):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, processor.ErrBillingExportRunning):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, processor.ErrBillingExportDisabled):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Billing export request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to access billing exports"})
+	}
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+// minCompressSize is the smallest body compressed; smaller ones would
+// hardly shrink, or grow.
+const minCompressSize = 1024
+
+var (
+	gzipWriters = sync.Pool{New: func() interface{} {
+		return gzip.NewWriter(io.Discard)
+	}}
+	zstdWriters = sync.Pool{New: func() interface{} {
+		// Responses are compressed concurrently already
+		w, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderConcurrency(1))
+		return w
+	}}
+)
+
+// compressResponses compresses the responses of clients that accept zstd
+// or gzip, preferring zstd. Results run to megabytes of JSON, which
+// compress several times over. Small bodies, event streams, WebSockets
+// and media that are compressed already are sent as they are.
+func compressResponses() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead || c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{ResponseWriter: c.Writer, encoding: encoding}
+		c.Writer = w
+		defer w.finish()
+		c.Next()
+	}
+}
+
+// negotiateEncoding returns the encoding of an Accept-Encoding header the
+// response is compressed with, or "" for none.
+func negotiateEncoding(header string) string {
+	best, bestQuality := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "zstd" && name != "gzip" {
+			continue
+		}
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(value, 64); err == nil {
+				quality = q
+			}
+		}
+		if quality > bestQuality || quality == bestQuality && name == "zstd" {
+			best, bestQuality = name, quality
+		}
+	}
+	return best
+}
+
+// compressible reports whether a media type is worth compressing.
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "text/event-stream":
+		// Events must reach the client as they are written
+		return false
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "json"),
+		strings.HasSuffix(mediaType, "xml"),
+		mediaType == "application/javascript":
+		return true
+	}
+	return false
+}
+
+// compressWriter holds the start of a body until it can tell whether to
+// compress it: once minCompressSize bytes are written, the body is
+// flushed or the handler returns.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+
+	buf     []byte
+	decided bool
+	encoder io.WriteCloser
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		if !w.eligible() {
+			w.decide(false)
+		} else if len(w.buf)+len(data) < minCompressSize {
+			w.buf = append(w.buf, data...)
+			return len(data), nil
+		} else {
+			w.decide(true)
+		}
+		if err := w.writeBuffered(); err != nil {
+			return 0, err
+		}
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow sends the headers of a response without a body, which is
+// not compressed.
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.decide(false)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Flush sends what was written, compressing it when the response is worth
+// it whatever its size so far: a streamed body is flushed as it goes.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(w.eligible())
+		if err := w.writeBuffered(); err != nil {
+			return
+		}
+	}
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		if err := flusher.Flush(); err != nil {
+			return
+		}
+	}
+	w.ResponseWriter.Flush()
+}
+
+// eligible reports whether the response, as its headers stand, may be
+// compressed.
+func (w *compressWriter) eligible() bool {
+	header := w.Header()
+	return w.Status() == http.StatusOK &&
+		header.Get("Content-Encoding") == "" &&
+		compressible(header.Get("Content-Type"))
+}
+
+// decide settles whether to compress, setting the headers that say so.
+func (w *compressWriter) decide(compress bool) {
+	w.decided = true
+	if !compress {
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Encoding", w.encoding)
+	header.Del("Content-Length")
+	// The compressed body is not byte for byte the one tagged
+	if etag := header.Get("ETag"); strings.HasPrefix(etag, `"`) {
+		header.Set("ETag", "W/"+etag)
+	}
+
+	switch w.encoding {
+	case "zstd":
+		encoder := zstdWriters.Get().(*zstd.Encoder)
+		encoder.Reset(w.ResponseWriter)
+		w.encoder = encoder
+	default:
+		encoder := gzipWriters.Get().(*gzip.Writer)
+		encoder.Reset(w.ResponseWriter)
+		w.encoder = encoder
+	}
+}
+
+func (w *compressWriter) writeBuffered() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	buf := w.buf
+	w.buf = nil
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// finish writes what remains of the body once the handler has returned,
+// and returns the encoder to its pool.
+func (w *compressWriter) finish() {
+	if !w.decided {
+		w.decide(false)
+	}
+	w.writeBuffered()
+	switch encoder := w.encoder.(type) {
+	case *zstd.Encoder:
+		encoder.Close()
+		encoder.Reset(io.Discard)
+		zstdWriters.Put(encoder)
+	case *gzip.Writer:
+		encoder.Close()
+		encoder.Reset(io.Discard)
+		gzipWriters.Put(encoder)
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultDeadLetterLimit = 50
+	maxDeadLetterLimit     = 500
+)
+
+// listDeadLetters pages through the jobs that failed for good, latest
+// first.
+func (h *Handler) listDeadLetters(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultDeadLetterLimit)))
+	if err != nil || limit < 1 || limit > maxDeadLetterLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxDeadLetterLimit)})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+		return
+	}
+
+	jobs, total, err := h.processor.ListDeadLetters(c.Request.Context(), offset, limit)
+	if err != nil {
+		deadLetterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"jobs": jobs, "total": total})
+}
+
+func (h *Handler) getDeadLetter(c *gin.Context) {
+	job, err := h.processor.GetDeadLetter(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		deadLetterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// replayDeadLetter queues a dead-letter job again under its ID.
+func (h *Handler) replayDeadLetter(c *gin.Context) {
+	job, err := h.processor.DeadLetterReplay(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		deadLetterError(c, err)
+		return
+	}
+	if !h.enqueueJob(c, job) {
+		return
+	}
+	if err := h.processor.DeleteDeadLetter(c.Request.Context(), job.ID); err != nil && !errors.Is(err, processor.ErrDeadLetterNotFound) {
+		log.Printf("Failed to remove replayed job %s from the dead-letter store: %v", job.ID, err)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id": job.ID,
+		"status": job.Status,
+	})
+}
+
+func (h *Handler) deleteDeadLetter(c *gin.Context) {
+	if err := h.processor.DeleteDeadLetter(c.Request.Context(), c.Param("id")); err != nil {
+		deadLetterError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func deadLetterError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, processor.ErrDeadLetterNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Dead-letter request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to access dead-letter jobs"})
+	}
+}
@@ -0,0 +1,342 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cotai-pdf-processor/internal/processor"
+	"cotai-pdf-processor/internal/webhook"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// multipartOverhead is the allowance for form fields and part headers on
+// top of MaxFileSize when capping the request body.
+const multipartOverhead = 1 << 20
+
+// uploadDocument accepts a multipart/form-data request with a "file" part
+// and optional "tender_id", "user_id", "tenant_id", "interest_profile_id",
+// "priority", "profile", "options" (JSON), "callback_url", "run_at" (RFC
+// 3339) and "depends_on" (comma-separated job IDs) fields, streams the file
+// to the upload directory and enqueues a processing job for it, scheduled
+// for run_at and held until the jobs it depends on complete.
+// The request body is capped by limitRequestSize.
+func (h *Handler) uploadDocument(c *gin.Context) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expected multipart/form-data request"})
+		return
+	}
+
+	jobID := uuid.New().String()
+	job := newJob(jobID)
+
+	var storedPath, profile, runAt string
+	var options []byte
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			removeUpload(storedPath)
+			h.uploadError(c, err)
+			return
+		}
+
+		if part.FormName() == "file" {
+			if storedPath != "" {
+				part.Close()
+				removeUpload(storedPath)
+				c.JSON(http.StatusBadRequest, gin.H{"error": "only one file per request is supported"})
+				return
+			}
+
+			contentType := partContentType(part)
+			if !h.isAllowedType(contentType) {
+				part.Close()
+				h.unsupportedType(c, contentType)
+				return
+			}
+
+			path, size, err := h.storeUpload(jobID, part)
+			part.Close()
+			if err != nil {
+				h.uploadError(c, err)
+				return
+			}
+
+			// Don't trust the declared type: a renamed executable or an
+			// HTML page would only fail later in a worker
+			if sniffed, err := processor.VerifyContent(path, contentType); err != nil {
+				removeUpload(path)
+				h.unsupportedType(c, sniffed)
+				return
+			}
+
+			storedPath = path
+			job.Metadata["original_filename"] = part.FileName()
+			job.Metadata["content_type"] = contentType
+			job.Metadata["file_size"] = size
+			continue
+		}
+
+		value, err := io.ReadAll(io.LimitReader(part, multipartOverhead))
+		part.Close()
+		if err != nil {
+			removeUpload(storedPath)
+			h.uploadError(c, err)
+			return
+		}
+
+		switch part.FormName() {
+		case "tender_id":
+			job.TenderID = string(value)
+		case "user_id":
+			job.UserID = string(value)
+		case "tenant_id":
+			job.TenantID = string(value)
+		case "interest_profile_id":
+			job.Metadata["interest_profile_id"] = string(value)
+		case "priority":
+			job.Priority = string(value)
+		case "profile":
+			profile = string(value)
+		case "options":
+			options = value
+		case "callback_url":
+			job.CallbackURL = string(value)
+		case "run_at":
+			runAt = string(value)
+		case "depends_on":
+			job.DependsOn = append(job.DependsOn, splitJobIDs(string(value))...)
+		}
+	}
+
+	if storedPath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing file part"})
+		return
+	}
+	job.FileURL = storedPath
+
+	if !h.claimIdentity(c, &job.UserID, &job.TenantID) {
+		removeUpload(storedPath)
+		return
+	}
+	if size, _ := job.Metadata["file_size"].(int64); !h.checkQuota(c, job.TenantID, size) {
+		removeUpload(storedPath)
+		return
+	}
+	if err := processor.ValidatePriority(job.Priority); err != nil {
+		removeUpload(storedPath)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := webhook.ValidateURL(c.Request.Context(), job.CallbackURL); err != nil {
+		removeUpload(storedPath)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if job.RunAt, err = processor.ParseRunAt(runAt); err != nil {
+		removeUpload(storedPath)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.validateDependencies(c, job.DependsOn) {
+		removeUpload(storedPath)
+		return
+	}
+
+	// Resolved once all fields are read, as the profile may follow the options
+	if !h.resolveOptions(c, job, profile, options) {
+		removeUpload(storedPath)
+		return
+	}
+
+	if !h.enqueueJob(c, job) {
+		removeUpload(storedPath)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id": job.ID,
+		"status": job.Status,
+	})
+}
+
+func newJob(id string) *processor.ProcessingJob {
+	return &processor.ProcessingJob{
+		ID:        id,
+		Status:    "queued",
+		CreatedAt: time.Now(),
+		Options:   processor.DefaultProcessingOptions(),
+		Metadata:  make(map[string]interface{}),
+	}
+}
+
+// resolveOptions sets the job's options from the submitted profile and
+// options, responding with an error when they are not valid.
+func (h *Handler) resolveOptions(c *gin.Context, job *processor.ProcessingJob, profile string, options []byte) bool {
+	err := h.processor.ResolveOptions(c.Request.Context(), job, profile, options)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, processor.ErrProcessingProfileNotFound), errors.Is(err, processor.ErrPipelineNotFound),
+		errors.Is(err, processor.ErrInvalidOptions):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Failed to resolve options of job %s: %v", job.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to resolve processing profile"})
+	}
+	return false
+}
+
+// splitJobIDs splits a comma-separated list of job IDs.
+func splitJobIDs(value string) []string {
+	var ids []string
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// validateDependencies checks the jobs a submitted job depends on,
+// responding with an error when they are not valid.
+func (h *Handler) validateDependencies(c *gin.Context, ids []string) bool {
+	err := h.processor.ValidateDependencies(c.Request.Context(), ids)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, processor.ErrInvalidDependencies):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Failed to validate job dependencies: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check job dependencies"})
+	}
+	return false
+}
+
+// enqueueJob persists and submits a job, writing an error response and
+// returning false if the worker pool refuses it, when the job is recorded
+// as failed.
+func (h *Handler) enqueueJob(c *gin.Context, job *processor.ProcessingJob) bool {
+	if err := h.processor.SaveJob(c.Request.Context(), job); err != nil {
+		log.Printf("Failed to save job %s: %v", job.ID, err)
+	}
+
+	if err := h.workerPool.SubmitJob(job); err != nil {
+		h.processor.RejectJob(context.WithoutCancel(c.Request.Context()), job, err)
+		if errors.Is(err, processor.ErrResourcesExhausted) {
+			h.overloaded(c, err)
+			return false
+		}
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// storeUpload streams an uploaded file into the upload directory, failing
+// once more than MaxFileSize bytes have been read.
+func (h *Handler) storeUpload(jobID string, part *multipart.Part) (string, int64, error) {
+	if err := os.MkdirAll(h.cfg.UploadDir, 0o750); err != nil {
+		return "", 0, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	name := jobID + strings.ToLower(filepath.Ext(part.FileName()))
+	path := filepath.Join(h.cfg.UploadDir, name)
+
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create upload file: %w", err)
+	}
+
+	written, err := io.Copy(out, io.LimitReader(part, h.cfg.MaxFileSize+1))
+	closeErr := out.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil && written > h.cfg.MaxFileSize {
+		err = errFileTooLarge
+	}
+	if err != nil {
+		removeUpload(path)
+		return "", 0, err
+	}
+
+	return path, written, nil
+}
+
+func (h *Handler) isAllowedType(contentType string) bool {
+	for _, allowed := range h.cfg.AllowedTypes {
+		if contentType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) uploadError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.Is(err, errFileTooLarge) || errors.As(err, &maxBytesErr) {
+		h.fileTooLarge(c)
+		return
+	}
+
+	log.Printf("Upload failed: %v", err)
+	c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read upload"})
+}
+
+// partContentType returns the declared media type of a file part, falling
+// back to the file extension when the client sent none.
+func partContentType(part *multipart.Part) string {
+	if mediaType, _, err := mime.ParseMediaType(part.Header.Get("Content-Type")); err == nil && mediaType != "application/octet-stream" {
+		return mediaType
+	}
+	return contentTypeFromName(part.FileName())
+}
+
+// officeTypes covers extensions missing from the system MIME table on
+// minimal container images.
+var officeTypes = map[string]string{
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".odt":  "application/vnd.oasis.opendocument.text",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".csv":  "text/csv",
+	".zip":  "application/zip",
+}
+
+func contentTypeFromName(name string) string {
+	if officeType, ok := officeTypes[strings.ToLower(filepath.Ext(name))]; ok {
+		return officeType
+	}
+	if byExt := mime.TypeByExtension(strings.ToLower(filepath.Ext(name))); byExt != "" {
+		mediaType, _, _ := mime.ParseMediaType(byExt)
+		return mediaType
+	}
+	return "application/octet-stream"
+}
+
+func removeUpload(path string) {
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove upload %s: %v", path, err)
+	}
+}
+
+var errFileTooLarge = errors.New("file exceeds maximum allowed size")
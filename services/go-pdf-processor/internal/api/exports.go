@@ -0,0 +1,148 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"cotai-pdf-processor/internal/processor"
+	"cotai-pdf-processor/internal/spreadsheet"
+
+	"github.com/gin-gonic/gin"
+)
+
+type exportSheet struct {
+	name  string
+	sheet func(*processor.ProcessingResult) spreadsheet.Sheet
+}
+
+// exportSheets are the tables of findings a job's result is exported as,
+// in the order of a workbook's sheets.
+var exportSheets = []exportSheet{
+	{"entities", entitiesSheet},
+	{"risks", risksSheet},
+	{"items", itemsSheet},
+}
+
+// exportJob renders findings of a job's result for spreadsheets: the
+// entities, risks or items, or all of them, as the route names them. The
+// format query is csv, the default, or xlsx; only workbooks hold all.
+func (h *Handler) exportJob(c *gin.Context) {
+	name, format := c.Param("sheet"), c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return
+	}
+	if name == "all" && format != "xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exporting all findings needs format=xlsx"})
+		return
+	}
+	var exports []exportSheet
+	for _, export := range exportSheets {
+		if name == "all" || name == export.name {
+			exports = append(exports, export)
+		}
+	}
+	if len(exports) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("unknown export %q: entities, risks, items or all", name)})
+		return
+	}
+
+	result := h.loadResult(c)
+	if result == nil {
+		return
+	}
+	sheets := make([]spreadsheet.Sheet, len(exports))
+	for i, export := range exports {
+		sheets[i] = export.sheet(result)
+	}
+
+	var write func(io.Writer) error
+	if format == "xlsx" {
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		write = func(w io.Writer) error { return spreadsheet.WriteXLSX(w, sheets) }
+	} else {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		write = func(w io.Writer) error { return spreadsheet.WriteCSV(w, sheets[0]) }
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.%s"`, c.Param("id"), name, format))
+	c.Status(http.StatusOK)
+	if err := write(c.Writer); err != nil {
+		log.Printf("Failed to export %s of job %s: %v", name, c.Param("id"), err)
+	}
+}
+
+func entitiesSheet(result *processor.ProcessingResult) spreadsheet.Sheet {
+	sheet := spreadsheet.Sheet{
+		Name:    "Entities",
+		Columns: []string{"Type", "Value", "Date", "Amount (R$)", "Company", "Confidence", "Page", "Start", "End"},
+	}
+	for _, entity := range result.Entities {
+		var date, amount, company interface{}
+		if entity.Normalized != nil {
+			if entity.Normalized.Date != "" {
+				date = entity.Normalized.Date
+			}
+			if entity.Normalized.Cents != nil {
+				amount = reais(*entity.Normalized.Cents)
+			}
+		}
+		if entity.Company != nil {
+			company = entity.Company.RazaoSocial
+		}
+		sheet.Rows = append(sheet.Rows, []interface{}{
+			entity.Type, entity.Value, date, amount, company, entity.Confidence,
+			pageCell(entity.Page), entity.StartPos, entity.EndPos,
+		})
+	}
+	return sheet
+}
+
+func risksSheet(result *processor.ProcessingResult) spreadsheet.Sheet {
+	sheet := spreadsheet.Sheet{
+		Name:    "Risks",
+		Columns: []string{"Category", "Severity", "Description", "Impact", "Confidence", "Page", "Location", "Snippet"},
+	}
+	for _, risk := range result.RiskAnalysis.IdentifiedRisks {
+		sheet.Rows = append(sheet.Rows, []interface{}{
+			risk.Category, risk.Severity, risk.Description, risk.Impact, risk.Confidence,
+			pageCell(risk.Page), risk.Location, strings.TrimSpace(risk.Snippet),
+		})
+	}
+	return sheet
+}
+
+func itemsSheet(result *processor.ProcessingResult) spreadsheet.Sheet {
+	sheet := spreadsheet.Sheet{
+		Name:    "Items",
+		Columns: []string{"Item", "Description", "Unit", "Quantity", "Estimated unit price (R$)", "Estimated total (R$)", "Page", "Source"},
+	}
+	for _, item := range result.Items {
+		var unitPrice, total interface{}
+		if item.EstimatedUnitCents != 0 {
+			unitPrice = reais(item.EstimatedUnitCents)
+		}
+		if item.EstimatedTotalCents != 0 {
+			total = reais(item.EstimatedTotalCents)
+		}
+		sheet.Rows = append(sheet.Rows, []interface{}{
+			item.Item, item.Description, item.Unit, item.Quantity, unitPrice, total,
+			pageCell(item.Page), item.Source,
+		})
+	}
+	return sheet
+}
+
+func reais(cents int64) float64 {
+	return float64(cents) / 100
+}
+
+// pageCell leaves the cell of an unknown page, 0, empty.
+func pageCell(n int) interface{} {
+	if n == 0 {
+		return nil
+	}
+	return n
+}
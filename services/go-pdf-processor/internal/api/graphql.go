@@ -0,0 +1,197 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"cotai-pdf-processor/internal/graphql"
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The GraphQL endpoint queries jobs and their results with the fields
+// clients select. Its root fields are:
+//
+//	job(id)                 a job, as GET /jobs/:id responds with it
+//	jobs(status, tender_id, tenant_id, user_id, error_code, q, from, to,
+//	     sort, first, offset)
+//	                        {total, items} of the job history, filtered as
+//	                        GET /jobs is, each item with its result
+//	result(job_id)          the result of a job, kept or in the history
+//
+// Lists inside results, such as entities, risk_analysis.identified_risks
+// and tables, take the filters of the graphql package, for instance
+// entities(type: "cnpj", confidence_gte: 0.8, first: 10).
+
+// maxGraphQLRequest bounds the body of GraphQL requests.
+const maxGraphQLRequest = 1 << 20
+
+func (h *Handler) graphQL(c *gin.Context) {
+	var req graphql.Request
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				graphQLError(c, "variables must be a JSON object")
+				return
+			}
+		}
+	} else {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxGraphQLRequest)
+		if err := c.ShouldBindJSON(&req); err != nil {
+			graphQLError(c, err.Error())
+			return
+		}
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		graphQLError(c, "query is required")
+		return
+	}
+
+	c.JSON(http.StatusOK, graphql.Execute(c.Request.Context(), h.graphQLRoot(), req))
+}
+
+// graphQLError responds to a request that cannot be executed.
+func graphQLError(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, graphql.Response{Errors: []*graphql.Error{{Message: message}}})
+}
+
+func (h *Handler) graphQLRoot() map[string]interface{} {
+	return map[string]interface{}{
+		"job":    graphql.Resolver(h.resolveJob),
+		"jobs":   graphql.Resolver(h.resolveJobs),
+		"result": graphql.Resolver(h.resolveResult),
+	}
+}
+
+func (h *Handler) resolveJob(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	id, err := graphql.StringArgument(args, "id")
+	if err != nil {
+		return nil, err
+	}
+	job, err := h.processor.GetJob(ctx, id)
+	if errors.Is(err, processor.ErrJobNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Failed to load job %s: %v", id, err)
+		return nil, errors.New("failed to load job")
+	}
+	if job.Status == "processing" {
+		if job.Progress, err = h.processor.JobProgress(ctx, job.ID); err != nil {
+			log.Printf("Failed to load progress of job %s: %v", job.ID, err)
+		}
+	}
+	return job, nil
+}
+
+func (h *Handler) resolveJobs(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	var filter processor.JobFilter
+	var err error
+	if filter.Statuses, err = graphql.StringsArgument(args, "status"); err != nil {
+		return nil, err
+	}
+	for name, value := range map[string]*string{
+		"tender_id":  &filter.TenderID,
+		"tenant_id":  &filter.TenantID,
+		"user_id":    &filter.UserID,
+		"error_code": &filter.ErrorCode,
+	} {
+		if *value, err = graphql.StringArgument(args, name); err != nil {
+			return nil, err
+		}
+	}
+	text, err := graphql.StringArgument(args, "q")
+	if err != nil {
+		return nil, err
+	}
+	filter.Text = strings.TrimSpace(text)
+
+	from, err := graphql.StringArgument(args, "from")
+	if err != nil {
+		return nil, err
+	}
+	to, err := graphql.StringArgument(args, "to")
+	if err != nil {
+		return nil, err
+	}
+	if filter.From, err = parseTime("from", from, false); err != nil {
+		return nil, err
+	}
+	if filter.To, err = parseTime("to", to, true); err != nil {
+		return nil, err
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, errors.New("from must be before to")
+	}
+
+	sort, err := graphql.StringArgument(args, "sort")
+	if err != nil {
+		return nil, err
+	}
+	if sort == "" {
+		sort = processor.DefaultJobSort
+	}
+	first, err := graphql.IntArgument(args, "first", defaultJobPageSize)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := graphql.IntArgument(args, "offset", 0)
+	if err != nil {
+		return nil, err
+	}
+	if first < 1 || first > maxJobPageSize || offset < 0 {
+		return nil, errors.New("first must be between 1 and " + strconv.Itoa(maxJobPageSize) + " and offset not negative")
+	}
+
+	jobs, total, err := h.processor.ListJobs(ctx, filter, sort, offset, first)
+	if errors.Is(err, processor.ErrInvalidJobFilter) {
+		return nil, err
+	}
+	if err != nil {
+		log.Printf("Failed to list jobs: %v", err)
+		return nil, errors.New("failed to list jobs")
+	}
+
+	items := make([]interface{}, len(jobs))
+	for i, job := range jobs {
+		id := job.ID
+		items[i], err = graphql.Extend(job, map[string]interface{}{
+			"result": graphql.Resolver(func(ctx context.Context, _ map[string]interface{}) (interface{}, error) {
+				return h.jobResult(ctx, id)
+			}),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return map[string]interface{}{"total": total, "items": items}, nil
+}
+
+func (h *Handler) resolveResult(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	id, err := graphql.StringArgument(args, "job_id")
+	if err != nil {
+		return nil, err
+	}
+	return h.jobResult(ctx, id)
+}
+
+// jobResult loads the result of a job, nil for unknown jobs.
+func (h *Handler) jobResult(ctx context.Context, id string) (interface{}, error) {
+	result, err := h.processor.JobResult(ctx, id)
+	if errors.Is(err, processor.ErrJobNotFound) || result == nil && err == nil {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Failed to load result of job %s: %v", id, err)
+		return nil, errors.New("failed to load result")
+	}
+	return result, nil
+}
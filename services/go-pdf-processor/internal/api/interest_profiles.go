@@ -0,0 +1,103 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// interestProfileRequest is the body accepted when creating or replacing a
+// tenant's interest profile.
+type interestProfileRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Keywords      []string `json:"keywords"`
+	CNAECodes     []string `json:"cnae_codes"`
+	Regions       []string `json:"regions"`
+	MinValueCents int64    `json:"min_value_cents"`
+	MaxValueCents int64    `json:"max_value_cents"`
+}
+
+func (r interestProfileRequest) toProfile(tenantID string) *processor.InterestProfile {
+	return &processor.InterestProfile{
+		TenantID:      tenantID,
+		Name:          r.Name,
+		Keywords:      r.Keywords,
+		CNAECodes:     r.CNAECodes,
+		Regions:       r.Regions,
+		MinValueCents: r.MinValueCents,
+		MaxValueCents: r.MaxValueCents,
+	}
+}
+
+func (h *Handler) listInterestProfiles(c *gin.Context) {
+	profiles, err := h.processor.ListInterestProfiles(c.Request.Context(), c.Param("tenant"))
+	if err != nil {
+		interestProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"profiles": profiles})
+}
+
+func (h *Handler) getInterestProfile(c *gin.Context) {
+	profile, err := h.processor.GetInterestProfile(c.Request.Context(), c.Param("tenant"), c.Param("id"))
+	if err != nil {
+		interestProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *Handler) createInterestProfile(c *gin.Context) {
+	var req interestProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile := req.toProfile(c.Param("tenant"))
+	if err := h.processor.CreateInterestProfile(c.Request.Context(), profile); err != nil {
+		interestProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, profile)
+}
+
+func (h *Handler) updateInterestProfile(c *gin.Context) {
+	var req interestProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile := req.toProfile(c.Param("tenant"))
+	profile.ID = c.Param("id")
+	if err := h.processor.UpdateInterestProfile(c.Request.Context(), profile); err != nil {
+		interestProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *Handler) deleteInterestProfile(c *gin.Context) {
+	if err := h.processor.DeleteInterestProfile(c.Request.Context(), c.Param("tenant"), c.Param("id")); err != nil {
+		interestProfileError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func interestProfileError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, processor.ErrInterestProfileNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, processor.ErrInvalidInterestProfile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Interest profile request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to access interest profiles"})
+	}
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// eventsKeepAlive is how often an idle event stream is written to, so
+	// proxies keep it open.
+	eventsKeepAlive = 15 * time.Second
+
+	// eventsWriteTimeout bounds a write to a WebSocket client.
+	eventsWriteTimeout = 10 * time.Second
+)
+
+var eventsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// getJobEvents streams a job's status and progress updates until it
+// finishes: as Server-Sent Events, or as JSON messages over a WebSocket when
+// the request asks to upgrade. The first update is the job's current state.
+func (h *Handler) getJobEvents(c *gin.Context) {
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	updates, err := h.processor.WatchJob(ctx, c.Param("id"))
+	if errors.Is(err, processor.ErrJobNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to follow job %s: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to follow job"})
+		return
+	}
+
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		h.streamJobWebSocket(c, cancel, updates)
+		return
+	}
+	h.streamJobSSE(c, updates)
+}
+
+// jobEventName is the SSE event of an update: "progress" or "status".
+func jobEventName(update processor.JobUpdate) string {
+	if update.Progress != nil {
+		return "progress"
+	}
+	return "status"
+}
+
+func (h *Handler) streamJobSSE(c *gin.Context, updates <-chan processor.JobUpdate) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Stop nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return false
+			}
+			c.SSEvent(jobEventName(update), update)
+			return true
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		}
+	})
+}
+
+// streamJobWebSocket sends each update as a JSON message and closes the
+// connection once the job has finished. The client sends nothing; its
+// closing the connection stops the stream.
+func (h *Handler) streamJobWebSocket(c *gin.Context, cancel context.CancelFunc, updates <-chan processor.JobUpdate) {
+	conn, err := eventsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has answered the request
+		return
+	}
+	defer conn.Close()
+
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+					time.Now().Add(eventsWriteTimeout))
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(eventsWriteTimeout))
+			if err := conn.WriteJSON(update); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventsWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
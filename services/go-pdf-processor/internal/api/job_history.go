@@ -0,0 +1,137 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The job history is browsed and searched with the query parameters
+// status (a comma separated list), tenant_id, user_id, error_code, q (text
+// of the error message or file name), from and to (creation times, RFC
+// 3339 or dates, to included), sort, page and page_size.
+
+const (
+	defaultJobPageSize = 50
+	maxJobPageSize     = 500
+)
+
+// listJobs pages through the job history, also filtered by tender_id.
+func (h *Handler) listJobs(c *gin.Context) {
+	filter, ok := jobFilter(c)
+	if !ok {
+		return
+	}
+	filter.TenderID = c.Query("tender_id")
+	h.respondJobs(c, filter)
+}
+
+// searchJobs finds jobs of a tender or created in a time range, failed
+// ones and their errors included, for triage.
+func (h *Handler) searchJobs(c *gin.Context) {
+	filter, ok := jobFilter(c)
+	if !ok {
+		return
+	}
+	filter.TenderID = c.Query("tender_id")
+	if filter.TenderID == "" && filter.From == nil && filter.To == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tender_id or a from/to range is required"})
+		return
+	}
+	h.respondJobs(c, filter)
+}
+
+// listTenderJobs pages through the jobs of a tender.
+func (h *Handler) listTenderJobs(c *gin.Context) {
+	filter, ok := jobFilter(c)
+	if !ok {
+		return
+	}
+	filter.TenderID = c.Param("tender_id")
+	h.respondJobs(c, filter)
+}
+
+// jobFilter reads the filter of the job history from the query, responding
+// with an error when it is not valid.
+func jobFilter(c *gin.Context) (processor.JobFilter, bool) {
+	filter := processor.JobFilter{
+		TenantID:  c.Query("tenant_id"),
+		UserID:    c.Query("user_id"),
+		ErrorCode: c.Query("error_code"),
+		Text:      strings.TrimSpace(c.Query("q")),
+	}
+	if status := c.Query("status"); status != "" {
+		filter.Statuses = strings.Split(status, ",")
+	}
+
+	var err error
+	if filter.From, err = queryTime(c, "from", false); err == nil {
+		filter.To, err = queryTime(c, "to", true)
+	}
+	if err == nil && filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		err = errors.New("from must be before to")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, false
+	}
+	return filter, true
+}
+
+// respondJobs responds with the page of the job history asked for.
+func (h *Handler) respondJobs(c *gin.Context, filter processor.JobFilter) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page"})
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultJobPageSize)))
+	if err != nil || pageSize < 1 || pageSize > maxJobPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page_size must be between 1 and " + strconv.Itoa(maxJobPageSize)})
+		return
+	}
+
+	jobs, total, err := h.processor.ListJobs(c.Request.Context(), filter, c.DefaultQuery("sort", processor.DefaultJobSort), (page-1)*pageSize, pageSize)
+	if errors.Is(err, processor.ErrInvalidJobFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to list jobs: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list jobs"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"jobs": jobs, "total": total, "page": page, "page_size": pageSize})
+}
+
+// queryTime parses a time query parameter, either RFC 3339 or a date. A
+// date ending a range includes its whole day.
+func queryTime(c *gin.Context, name string, end bool) (*time.Time, error) {
+	return parseTime(name, c.Query(name), end)
+}
+
+// parseTime parses a time filter named name; empty is none.
+func parseTime(name, value string, end bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 time or a date", name)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getJob returns a job, tagged with an ETag for conditional requests.
+func (h *Handler) getJob(c *gin.Context) {
+	if job := h.loadJob(c); job != nil {
+		respondTagged(c, job)
+	}
+}
+
+// getJobV2 returns a job with its result by page.
+func (h *Handler) getJobV2(c *gin.Context) {
+	if job := h.loadJob(c); job != nil {
+		respondTagged(c, newJobV2(job))
+	}
+}
+
+// loadJob loads the job of the request with its progress, or responds
+// with an error and returns nil.
+func (h *Handler) loadJob(c *gin.Context) *processor.ProcessingJob {
+	job, err := h.processor.GetJob(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, processor.ErrJobNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil
+	}
+	if err != nil {
+		log.Printf("Failed to load job %s: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load job"})
+		return nil
+	}
+	if job.Status == "processing" {
+		if job.Progress, err = h.processor.JobProgress(c.Request.Context(), job.ID); err != nil {
+			log.Printf("Failed to load progress of job %s: %v", job.ID, err)
+		}
+	}
+	return job
+}
+
+// maxStatusJobs bounds the jobs of a bulk status request.
+const maxStatusJobs = 1000
+
+type jobStatusRequest struct {
+	JobIDs []string `json:"job_ids" binding:"required"`
+}
+
+// getJobStatuses reports the status of many jobs at once, in the order
+// asked, with the IDs of unknown jobs listed apart.
+func (h *Handler) getJobStatuses(c *gin.Context) {
+	var req jobStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.JobIDs) > maxStatusJobs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at most " + strconv.Itoa(maxStatusJobs) + " job_ids per request"})
+		return
+	}
+
+	ids := make([]string, 0, len(req.JobIDs))
+	seen := make(map[string]bool, len(req.JobIDs))
+	for _, id := range req.JobIDs {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	statuses, notFound, err := h.processor.JobStatuses(c.Request.Context(), ids)
+	if err != nil {
+		log.Printf("Failed to load job statuses: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load job statuses"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"jobs": statuses, "not_found": notFound})
+}
+
+// cancelJob cancels a job that has not finished. Jobs not started are
+// cancelled at once; running ones are stopped and reported "cancelled"
+// shortly after.
+func (h *Handler) cancelJob(c *gin.Context) {
+	job, err := h.processor.CancelJob(c.Request.Context(), c.Param("id"))
+	switch {
+	case errors.Is(err, processor.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, processor.ErrJobFinished):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": job.Status})
+		return
+	case err != nil:
+		log.Printf("Failed to cancel job %s: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel job"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id": job.ID,
+		"status": job.Status,
+	})
+}
+
+// getJobReport redirects to a fresh download URL for the job's report.
+func (h *Handler) getJobReport(c *gin.Context) {
+	artifact, err := h.processor.ReportURL(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, processor.ErrJobNotFound) || errors.Is(err, processor.ErrReportNotAvailable) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to sign report of job %s: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load report"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, artifact.URL)
+}
+
+// getJobArchive redirects to a fresh download URL for the job's PDF/A copy.
+func (h *Handler) getJobArchive(c *gin.Context) {
+	artifact, err := h.processor.ArchiveURL(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, processor.ErrJobNotFound) || errors.Is(err, processor.ErrArchiveNotAvailable) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to sign archival copy of job %s: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load archival copy"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, artifact.URL)
+}
+
+// getJobSearchable redirects to a fresh download URL for the job's
+// searchable PDF.
+func (h *Handler) getJobSearchable(c *gin.Context) {
+	artifact, err := h.processor.SearchableURL(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, processor.ErrJobNotFound) || errors.Is(err, processor.ErrSearchableNotAvailable) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to sign searchable PDF of job %s: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load searchable PDF"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, artifact.URL)
+}
+
+// getJobOCRLayout redirects to a fresh download URL for the OCR words of
+// the job's scan.
+func (h *Handler) getJobOCRLayout(c *gin.Context) {
+	artifact, err := h.processor.OCRLayoutURL(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, processor.ErrJobNotFound) || errors.Is(err, processor.ErrOCRLayoutNotAvailable) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to sign OCR layout of job %s: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load OCR layout"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, artifact.URL)
+}
+
+// getJobRedacted redirects to a fresh download URL for the job's redacted
+// copy.
+func (h *Handler) getJobRedacted(c *gin.Context) {
+	artifact, err := h.processor.RedactedURL(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, processor.ErrJobNotFound) || errors.Is(err, processor.ErrRedactedNotAvailable) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to sign redacted copy of job %s: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load redacted copy"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, artifact.URL)
+}
+
+// getJobAnnotated redirects to a fresh download URL for the job's annotated
+// copy.
+func (h *Handler) getJobAnnotated(c *gin.Context) {
+	artifact, err := h.processor.AnnotatedURL(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, processor.ErrJobNotFound) || errors.Is(err, processor.ErrAnnotatedNotAvailable) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to sign annotated copy of job %s: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load annotated copy"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, artifact.URL)
+}
+
+// getPageImage renders page n of a job's document for the viewer.
+func (h *Handler) getPageImage(c *gin.Context) {
+	n, err := strconv.Atoi(c.Param("n"))
+	if err != nil || n < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page number"})
+		return
+	}
+	width := processor.DefaultPageImageWidth
+	if value := c.Query("width"); value != "" {
+		if width, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid width"})
+			return
+		}
+	}
+	format, contentType := processor.PageImagePNG, "image/png"
+	switch c.DefaultQuery("format", "png") {
+	case "png":
+	case "jpeg", "jpg":
+		format, contentType = processor.PageImageJPEG, "image/jpeg"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be png or jpeg"})
+		return
+	}
+
+	data, err := h.processor.PageImage(c.Request.Context(), c.Param("id"), n, width, format)
+	switch {
+	case errors.Is(err, processor.ErrJobNotFound) || errors.Is(err, processor.ErrPageNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, processor.ErrInvalidPageWidth):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, processor.ErrRenderingUnsupported):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": processor.ErrRenderingUnsupported.Error()})
+		return
+	case err != nil:
+		log.Printf("Failed to render page %d of job %s: %v", n, c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render page"})
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.Data(http.StatusOK, contentType, data)
+}
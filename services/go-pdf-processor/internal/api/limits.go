@@ -0,0 +1,102 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// admitJobs turns uploads away while the worker pool refuses new jobs, so
+// their documents are not read only to be dropped.
+func (h *Handler) admitJobs(c *gin.Context) {
+	err := h.workerPool.Admit()
+	if errors.Is(err, processor.ErrPoolDraining) {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": err.Error(), "code": "draining"})
+		return
+	}
+	if err != nil {
+		h.overloaded(c, err)
+		return
+	}
+	c.Next()
+}
+
+// limitRequestSize rejects requests whose declared Content-Length exceeds
+// limit and caps the body of the rest, so oversized uploads fail before
+// they are read in full.
+func (h *Handler) limitRequestSize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			h.fileTooLarge(c)
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// fileTooLarge writes the structured 413 response shared by all upload paths.
+func (h *Handler) fileTooLarge(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":    fmt.Sprintf("file exceeds maximum size of %d bytes", h.cfg.MaxFileSize),
+		"code":     "file_too_large",
+		"max_size": h.cfg.MaxFileSize,
+	})
+}
+
+// overloaded writes the structured 429 response for jobs refused while the
+// service runs short of memory or disk.
+func (h *Handler) overloaded(c *gin.Context, err error) {
+	retryAfter := int(math.Ceil(h.cfg.BackpressureRetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error":       err.Error(),
+		"code":        "overloaded",
+		"retry_after": retryAfter,
+	})
+}
+
+// checkQuota refuses submissions of a tenant that used up a monthly quota,
+// writing a structured 429 response with a Retry-After until the quota
+// resets. size is that of the document, when known.
+func (h *Handler) checkQuota(c *gin.Context, tenantID string, size int64) bool {
+	err := h.processor.CheckQuota(c.Request.Context(), tenantID, size)
+	var quotaErr *processor.QuotaError
+	switch {
+	case err == nil:
+		return true
+	case errors.As(err, &quotaErr):
+		retryAfter := int(math.Ceil(time.Until(quotaErr.ResetsAt).Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"error":     err.Error(),
+			"code":      "quota_exceeded",
+			"metric":    quotaErr.Metric,
+			"used":      quotaErr.Used,
+			"limit":     quotaErr.Limit,
+			"resets_at": quotaErr.ResetsAt,
+		})
+	default:
+		log.Printf("Failed to check quota of tenant %s: %v", tenantID, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check quota"})
+	}
+	return false
+}
+
+// unsupportedType writes the structured 415 response shared by all upload paths.
+func (h *Handler) unsupportedType(c *gin.Context, contentType string) {
+	c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{
+		"error":         fmt.Sprintf("file type %q is not allowed", contentType),
+		"code":          "unsupported_type",
+		"content_type":  contentType,
+		"allowed_types": h.cfg.AllowedTypes,
+	})
+}
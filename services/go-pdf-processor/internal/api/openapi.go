@@ -0,0 +1,818 @@
+package api
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"cotai-pdf-processor/internal/auth"
+	"cotai-pdf-processor/internal/processor"
+	"cotai-pdf-processor/internal/queue"
+	"cotai-pdf-processor/internal/risk"
+	"cotai-pdf-processor/internal/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The OpenAPI 3 document of the API is built from apiOperations, which
+// annotate the routes SetupRoutes registers. Schemas are derived from the
+// Go types the handlers bind and respond with, as JSON encodes them, so
+// they follow the code; responses written as gin.H are annotated with a
+// gin.H of the same keys. The document is served at
+// GET /api/v1/openapi.json, with the routes this replica registered, and
+// pkg/client is generated from it.
+
+// openAPIVersion is the version of the API the document describes, the
+// latest of those under /api.
+const openAPIVersion = "2.0.0"
+
+// apiOperation annotates a route.
+type apiOperation struct {
+	// ID names the operation; generated clients name their methods after it
+	ID      string
+	Summary string
+
+	// Path types path parameters other than strings
+	Path  []apiParam
+	Query []apiParam
+
+	// Request is a value of the JSON body's type; Form lists the fields of
+	// a multipart body, "file" being a file
+	Request interface{}
+	Form    []apiParam
+
+	// Status is the status of success, 200 by default. Response is a value
+	// of the JSON response's type, and Content the media type of others
+	Status   int
+	Response interface{}
+	Content  string
+
+	// Tagged responses carry an ETag, and requests whose If-None-Match
+	// has it get 304 Not Modified
+	Tagged bool
+
+	// Internal operations are not for clients to call: resumable uploads
+	// follow the tus protocol, and bucket notifications come from the
+	// object store
+	Internal bool
+}
+
+type apiParam struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+func stringParam(name, description string) apiParam {
+	return apiParam{Name: name, Type: "string", Description: description}
+}
+
+func intParam(name, description string) apiParam {
+	return apiParam{Name: name, Type: "integer", Description: description}
+}
+
+var (
+	jobFilterParams = []apiParam{
+		stringParam("status", "Comma separated statuses"),
+		stringParam("tenant_id", ""),
+		stringParam("user_id", ""),
+		stringParam("error_code", ""),
+		stringParam("q", "Text of the error message or file name"),
+		stringParam("from", "Created at or after, RFC 3339 or a date"),
+		stringParam("to", "Created before, RFC 3339 or a date, included"),
+		stringParam("sort", "Field to sort by, descending when prefixed with -"),
+		intParam("page", "Page, from 1"),
+		intParam("page_size", ""),
+	}
+	listJobsParams = append([]apiParam{stringParam("tender_id", "")}, jobFilterParams...)
+	pageParams     = []apiParam{intParam("limit", ""), intParam("offset", "")}
+	tenantParam    = []apiParam{stringParam("tenant_id", "Operators only, the global rules when omitted; others manage their own tenant's")}
+
+	operatorTenantParam = []apiParam{stringParam("tenant_id", "Operators only; others read their own tenant's")}
+
+	jobAccepted = gin.H{"job_id": "", "status": ""}
+	jobPage     = gin.H{"jobs": []processor.JobRecord(nil), "total": 0, "page": 0, "page_size": 0}
+)
+
+// artifactRedirect documents the routes redirecting to a download URL.
+func artifactRedirect(id, artifact string) apiOperation {
+	return apiOperation{
+		ID:      id,
+		Summary: "Redirect to a download URL of the job's " + artifact,
+		Status:  http.StatusFound,
+	}
+}
+
+var apiOperations = map[string]apiOperation{
+	"GET /api/v1/openapi.json": {ID: "getOpenAPI", Summary: "The OpenAPI document of the API", Response: json.RawMessage(nil)},
+
+	"POST /api/v1/documents": {
+		ID:      "uploadDocument",
+		Summary: "Upload a document to be processed",
+		Form: []apiParam{
+			{Name: "file", Type: "file"},
+			stringParam("tender_id", ""),
+			stringParam("user_id", ""),
+			stringParam("tenant_id", ""),
+			stringParam("interest_profile_id", ""),
+			stringParam("priority", ""),
+			stringParam("profile", "Processing profile the options are resolved from"),
+			stringParam("options", "Processing options, as JSON"),
+			stringParam("callback_url", ""),
+			stringParam("run_at", "When to process the job, RFC 3339"),
+			stringParam("depends_on", "Comma separated IDs of the jobs to wait for"),
+		},
+		Status:   http.StatusAccepted,
+		Response: jobAccepted,
+	},
+	"GET /api/v1/jobs":        {ID: "listJobs", Summary: "Page through the job history", Query: listJobsParams, Response: jobPage},
+	"GET /api/v1/jobs/search": {ID: "searchJobs", Summary: "Find the jobs of a tender or of a time range", Query: listJobsParams, Response: jobPage},
+	"GET /api/v1/graphql": {
+		ID:      "getGraphQL",
+		Summary: "Query jobs and results with GraphQL",
+		Query: []apiParam{
+			stringParam("query", ""),
+			stringParam("operationName", ""),
+			stringParam("variables", "Variables, as a JSON object"),
+		},
+		Response: graphQLResponse,
+	},
+	"POST /api/v1/graphql": {ID: "postGraphQL", Summary: "Query jobs and results with GraphQL", Request: graphQLRequest, Response: graphQLResponse},
+	"POST /api/v1/tenders": {
+		ID:       "submitTender",
+		Summary:  "Submit the files of a tender to be processed as one job",
+		Request:  tenderRequest{},
+		Status:   http.StatusAccepted,
+		Response: gin.H{"job_id": "", "tender_id": "", "status": "", "file_job_ids": []string(nil)},
+	},
+	"GET /api/v1/tenders/:tender_id":      {ID: "getTender", Summary: "The latest tender job of a tender", Query: operatorTenantParam, Response: processor.ProcessingJob{}, Tagged: true},
+	"GET /api/v2/tenders/:tender_id":      {ID: "getTenderV2", Summary: "The latest tender job of a tender, its result by page", Query: operatorTenantParam, Response: jobV2{}, Tagged: true},
+	"GET /api/v1/tenders/:tender_id/jobs": {ID: "listTenderJobs", Summary: "Page through the jobs of a tender", Query: jobFilterParams, Response: jobPage},
+	"POST /api/v1/jobs/status": {
+		ID:       "getJobStatuses",
+		Summary:  "The status of many jobs at once",
+		Request:  jobStatusRequest{},
+		Response: gin.H{"jobs": []processor.JobUpdate(nil), "not_found": []string(nil)},
+	},
+	"GET /api/v1/jobs/:id":            {ID: "getJob", Summary: "A job, with its result once completed", Response: processor.ProcessingJob{}, Tagged: true},
+	"GET /api/v2/jobs/:id":            {ID: "getJobV2", Summary: "A job, with its result by page once completed", Response: jobV2{}, Tagged: true},
+	"DELETE /api/v1/jobs/:id":         {ID: "cancelJob", Summary: "Cancel a job", Status: http.StatusAccepted, Response: jobAccepted},
+	"GET /api/v1/jobs/:id/events":     {ID: "getJobEvents", Summary: "Stream the updates of a job as Server-Sent Events, or over a WebSocket", Content: "text/event-stream"},
+	"GET /api/v1/jobs/:id/report":     artifactRedirect("getJobReport", "report"),
+	"GET /api/v1/jobs/:id/archive":    artifactRedirect("getJobArchive", "archive"),
+	"GET /api/v1/jobs/:id/searchable": artifactRedirect("getJobSearchable", "searchable PDF"),
+	"GET /api/v1/jobs/:id/ocr-layout": artifactRedirect("getJobOCRLayout", "OCR layout"),
+	"GET /api/v1/jobs/:id/redacted":   artifactRedirect("getJobRedacted", "redacted PDF"),
+	"GET /api/v1/jobs/:id/annotated":  artifactRedirect("getJobAnnotated", "annotated PDF"),
+	"GET /api/v1/jobs/:id/pages/:n/image": {
+		ID:      "getPageImage",
+		Summary: "Render a page of a job's document",
+		Path:    []apiParam{intParam("n", "Page, from 1")},
+		Query:   []apiParam{intParam("width", "Width in pixels"), stringParam("format", "png or jpeg")},
+		Content: "image/*",
+	},
+	"GET /api/v1/jobs/:id/result/stream": {
+		ID:      "streamJobResult",
+		Summary: "Stream the result of a job as newline-delimited JSON records",
+		Content: "application/x-ndjson",
+	},
+	"GET /api/v1/jobs/:id/export/:sheet": {
+		ID:      "exportJob",
+		Summary: "Export the entities, risks or items of a job's result, or all of them, for spreadsheets",
+		Path:    []apiParam{stringParam("sheet", "entities, risks, items, or all for a workbook")},
+		Query:   []apiParam{stringParam("format", "csv, the default, or xlsx")},
+		Content: "application/octet-stream",
+	},
+	"GET /api/v1/admin/analytics-exports": {
+		ID:       "listAnalyticsExports",
+		Summary:  "The latest exports of results to the data warehouse",
+		Query:    []apiParam{intParam("limit", "")},
+		Response: gin.H{"exports": []processor.AnalyticsExport(nil)},
+	},
+	"POST /api/v1/admin/analytics-exports": {
+		ID:       "startAnalyticsExport",
+		Summary:  "Export results as Parquet, those completed since the last export or in a range",
+		Request:  analyticsExportRequest{},
+		Status:   http.StatusAccepted,
+		Response: processor.AnalyticsExport{},
+	},
+	"GET /api/v1/admin/analytics-exports/:id": {
+		ID:       "getAnalyticsExport",
+		Summary:  "An export of results to the data warehouse",
+		Response: processor.AnalyticsExport{},
+	},
+	"GET /api/v1/admin/billing-exports": {
+		ID:       "listBillingExports",
+		Summary:  "The latest exports of usage records for billing",
+		Query:    []apiParam{intParam("limit", "")},
+		Response: gin.H{"exports": []processor.BillingExport(nil)},
+	},
+	"POST /api/v1/admin/billing-exports": {
+		ID:       "startBillingExport",
+		Summary:  "Export usage records as CSV, those metered since the last export or in a range",
+		Request:  billingExportRequest{},
+		Status:   http.StatusAccepted,
+		Response: processor.BillingExport{},
+	},
+	"GET /api/v1/admin/billing-exports/:id": {
+		ID:       "getBillingExport",
+		Summary:  "An export of usage records for billing",
+		Response: processor.BillingExport{},
+	},
+	"GET /api/v1/tenants/:tenant/api-keys": {
+		ID:       "listAPIKeys",
+		Summary:  "The API keys of a tenant, revoked ones included",
+		Response: gin.H{"api_keys": []auth.APIKey(nil)},
+	},
+	"POST /api/v1/tenants/:tenant/api-keys": {
+		ID:       "issueAPIKey",
+		Summary:  "Issue an API key, shown only in the response",
+		Request:  apiKeyRequest{},
+		Status:   http.StatusCreated,
+		Response: auth.APIKey{},
+	},
+	"GET /api/v1/tenants/:tenant/api-keys/:id": {
+		ID:       "getAPIKey",
+		Summary:  "An API key, without its secret",
+		Response: auth.APIKey{},
+	},
+	"DELETE /api/v1/tenants/:tenant/api-keys/:id": {
+		ID:       "revokeAPIKey",
+		Summary:  "Revoke an API key",
+		Response: auth.APIKey{},
+	},
+	"POST /api/v1/tenants/:tenant/api-keys/:id/rotate": {
+		ID:       "rotateAPIKey",
+		Summary:  "Give an API key a new secret, the previous one working for a grace period",
+		Response: auth.APIKey{},
+	},
+	"GET /api/v1/tenants/:tenant/api-keys/:id/usage": {
+		ID:       "getAPIKeyUsage",
+		Summary:  "The requests made with an API key by day",
+		Query:    []apiParam{intParam("days", "")},
+		Response: gin.H{"usage": []auth.APIKeyUsage(nil)},
+	},
+
+	"GET /api/v1/tenants/:tenant/usage": {
+		ID:       "getTenantUsage",
+		Summary:  "What a tenant processed in a month, against its quota",
+		Query:    []apiParam{stringParam("month", "YYYY-MM, the current month by default")},
+		Response: processor.TenantUsage{},
+	},
+	"GET /api/v1/tenants/:tenant/usage/tenders": {
+		ID:       "getTenderUsage",
+		Summary:  "What a tenant processed for each tender in a month",
+		Query:    []apiParam{stringParam("month", "YYYY-MM, the current month by default")},
+		Response: gin.H{"tenant_id": "", "month": "", "tenders": []processor.TenderUsage(nil)},
+	},
+	"GET /api/v1/admin/tenants/:tenant/quota": {
+		ID:       "getTenantQuota",
+		Summary:  "The monthly quota set for a tenant",
+		Response: processor.TenantQuota{},
+	},
+	"PUT /api/v1/admin/tenants/:tenant/quota": {
+		ID:       "setTenantQuota",
+		Summary:  "Set the monthly quota of a tenant, null fields keeping the defaults",
+		Request:  processor.TenantQuota{},
+		Response: processor.TenantQuota{},
+	},
+
+	"GET /api/v1/admin/workers":          {ID: "getWorkers", Summary: "Statistics of the workers of this replica", Response: processor.PoolStats{}},
+	"PATCH /api/v1/admin/workers":        {ID: "resizeWorkers", Summary: "Change the number of workers of this replica", Request: resizeWorkersRequest{}, Response: processor.PoolStats{}},
+	"GET /api/v1/admin/workers/drain":    {ID: "getDrain", Summary: "Progress of draining this replica", Response: processor.DrainProgress{}},
+	"POST /api/v1/admin/workers/drain":   {ID: "drainWorkers", Summary: "Stop taking jobs and finish those running", Status: http.StatusAccepted, Response: processor.DrainProgress{}},
+	"DELETE /api/v1/admin/workers/drain": {ID: "resumeWorkers", Summary: "Take jobs again after draining", Response: processor.DrainProgress{}},
+	"GET /api/v1/admin/instances":        {ID: "listInstances", Summary: "The replicas sharing the job queue", Response: gin.H{"instance_id": "", "instances": []queue.Instance(nil)}},
+
+	"DELETE /api/v1/result-cache":         {ID: "clearResultCache", Summary: "Remove all cached results", Response: gin.H{"invalidated": 0}},
+	"DELETE /api/v1/result-cache/:sha256": {ID: "invalidateCachedResults", Summary: "Remove the cached results of a document", Response: gin.H{"invalidated": 0}},
+
+	"GET /api/v1/dead-letters":             {ID: "listDeadLetters", Summary: "Page through the jobs that exhausted their retries", Query: pageParams, Response: gin.H{"jobs": []processor.ProcessingJob(nil), "total": int64(0)}},
+	"GET /api/v1/dead-letters/:id":         {ID: "getDeadLetter", Summary: "A dead-lettered job", Response: processor.ProcessingJob{}},
+	"POST /api/v1/dead-letters/:id/replay": {ID: "replayDeadLetter", Summary: "Queue a dead-lettered job again", Status: http.StatusAccepted, Response: jobAccepted},
+	"DELETE /api/v1/dead-letters/:id":      {ID: "deleteDeadLetter", Summary: "Remove a dead-lettered job", Status: http.StatusNoContent},
+
+	"GET /api/v1/tenants/:tenant/entity-patterns":        {ID: "listEntityPatterns", Summary: "The entity patterns of a tenant", Response: gin.H{"patterns": []processor.EntityPattern(nil)}},
+	"POST /api/v1/tenants/:tenant/entity-patterns":       {ID: "createEntityPattern", Summary: "Add an entity pattern", Request: entityPatternRequest{}, Status: http.StatusCreated, Response: processor.EntityPattern{}},
+	"GET /api/v1/tenants/:tenant/entity-patterns/:id":    {ID: "getEntityPattern", Summary: "An entity pattern", Response: processor.EntityPattern{}},
+	"PUT /api/v1/tenants/:tenant/entity-patterns/:id":    {ID: "updateEntityPattern", Summary: "Replace an entity pattern", Request: entityPatternRequest{}, Response: processor.EntityPattern{}},
+	"DELETE /api/v1/tenants/:tenant/entity-patterns/:id": {ID: "deleteEntityPattern", Summary: "Remove an entity pattern", Status: http.StatusNoContent},
+
+	"GET /api/v1/tenants/:tenant/risk-profiles":        {ID: "listRiskProfiles", Summary: "The risk profiles of a tenant", Response: gin.H{"profiles": []processor.RiskProfile(nil)}},
+	"POST /api/v1/tenants/:tenant/risk-profiles":       {ID: "createRiskProfile", Summary: "Add a risk profile", Request: riskProfileRequest{}, Status: http.StatusCreated, Response: processor.RiskProfile{}},
+	"GET /api/v1/tenants/:tenant/risk-profiles/:id":    {ID: "getRiskProfile", Summary: "A risk profile", Response: processor.RiskProfile{}},
+	"PUT /api/v1/tenants/:tenant/risk-profiles/:id":    {ID: "updateRiskProfile", Summary: "Replace a risk profile", Request: riskProfileRequest{}, Response: processor.RiskProfile{}},
+	"DELETE /api/v1/tenants/:tenant/risk-profiles/:id": {ID: "deleteRiskProfile", Summary: "Remove a risk profile", Status: http.StatusNoContent},
+
+	"GET /api/v1/tenants/:tenant/interest-profiles":        {ID: "listInterestProfiles", Summary: "The interest profiles of a tenant", Response: gin.H{"profiles": []processor.InterestProfile(nil)}},
+	"POST /api/v1/tenants/:tenant/interest-profiles":       {ID: "createInterestProfile", Summary: "Add an interest profile", Request: interestProfileRequest{}, Status: http.StatusCreated, Response: processor.InterestProfile{}},
+	"GET /api/v1/tenants/:tenant/interest-profiles/:id":    {ID: "getInterestProfile", Summary: "An interest profile", Response: processor.InterestProfile{}},
+	"PUT /api/v1/tenants/:tenant/interest-profiles/:id":    {ID: "updateInterestProfile", Summary: "Replace an interest profile", Request: interestProfileRequest{}, Response: processor.InterestProfile{}},
+	"DELETE /api/v1/tenants/:tenant/interest-profiles/:id": {ID: "deleteInterestProfile", Summary: "Remove an interest profile", Status: http.StatusNoContent},
+
+	"GET /api/v1/tenants/:tenant/processing-profiles":        {ID: "listProcessingProfiles", Summary: "The processing profiles of a tenant", Response: gin.H{"profiles": []processor.ProcessingProfile(nil)}},
+	"POST /api/v1/tenants/:tenant/processing-profiles":       {ID: "createProcessingProfile", Summary: "Add a processing profile", Request: processingProfileRequest{}, Status: http.StatusCreated, Response: processor.ProcessingProfile{}},
+	"GET /api/v1/tenants/:tenant/processing-profiles/:id":    {ID: "getProcessingProfile", Summary: "A processing profile", Response: processor.ProcessingProfile{}},
+	"PUT /api/v1/tenants/:tenant/processing-profiles/:id":    {ID: "updateProcessingProfile", Summary: "Replace a processing profile", Request: processingProfileRequest{}, Response: processor.ProcessingProfile{}},
+	"DELETE /api/v1/tenants/:tenant/processing-profiles/:id": {ID: "deleteProcessingProfile", Summary: "Remove a processing profile", Status: http.StatusNoContent},
+
+	"GET /api/v1/tenants/:tenant/pipelines":        {ID: "listPipelines", Summary: "The pipelines of a tenant, and the stages they can run", Response: gin.H{"pipelines": []processor.Pipeline(nil), "stages": []string(nil)}},
+	"POST /api/v1/tenants/:tenant/pipelines":       {ID: "createPipeline", Summary: "Add a pipeline", Request: pipelineRequest{}, Status: http.StatusCreated, Response: processor.Pipeline{}},
+	"GET /api/v1/tenants/:tenant/pipelines/:id":    {ID: "getPipeline", Summary: "A pipeline", Response: processor.Pipeline{}},
+	"PUT /api/v1/tenants/:tenant/pipelines/:id":    {ID: "updatePipeline", Summary: "Replace a pipeline", Request: pipelineRequest{}, Response: processor.Pipeline{}},
+	"DELETE /api/v1/tenants/:tenant/pipelines/:id": {ID: "deletePipeline", Summary: "Remove a pipeline", Status: http.StatusNoContent},
+
+	"GET /api/v1/tenants/:tenant/reprocessing-policies":          {ID: "listReprocessingPolicies", Summary: "The reprocessing policies of a tenant", Response: gin.H{"policies": []processor.ReprocessingPolicy(nil)}},
+	"POST /api/v1/tenants/:tenant/reprocessing-policies":         {ID: "createReprocessingPolicy", Summary: "Add a reprocessing policy", Request: reprocessingPolicyRequest{}, Status: http.StatusCreated, Response: processor.ReprocessingPolicy{}},
+	"GET /api/v1/tenants/:tenant/reprocessing-policies/:id":      {ID: "getReprocessingPolicy", Summary: "A reprocessing policy", Response: processor.ReprocessingPolicy{}},
+	"PUT /api/v1/tenants/:tenant/reprocessing-policies/:id":      {ID: "updateReprocessingPolicy", Summary: "Replace a reprocessing policy", Request: reprocessingPolicyRequest{}, Response: processor.ReprocessingPolicy{}},
+	"DELETE /api/v1/tenants/:tenant/reprocessing-policies/:id":   {ID: "deleteReprocessingPolicy", Summary: "Remove a reprocessing policy", Status: http.StatusNoContent},
+	"POST /api/v1/tenants/:tenant/reprocessing-policies/:id/run": {ID: "runReprocessingPolicy", Summary: "Run a reprocessing policy now", Response: processor.ReprocessingRun{}},
+
+	"GET /api/v1/jobs/:id/webhooks":                           {ID: "listJobWebhooks", Summary: "The webhook deliveries of a job", Response: gin.H{"deliveries": []webhook.Delivery(nil)}},
+	"POST /api/v1/jobs/:id/webhooks/:delivery/redeliver":      {ID: "redeliverJobWebhook", Summary: "Send a webhook delivery of a job again", Status: http.StatusAccepted, Response: webhook.Delivery{}},
+	"GET /api/v1/tenants/:tenant/webhooks":                    {ID: "listWebhookEndpoints", Summary: "The webhook endpoints of a tenant", Response: gin.H{"endpoints": []webhook.Endpoint(nil)}},
+	"POST /api/v1/tenants/:tenant/webhooks":                   {ID: "createWebhookEndpoint", Summary: "Add a webhook endpoint", Request: webhookEndpointRequest{}, Status: http.StatusCreated, Response: webhook.Endpoint{}},
+	"GET /api/v1/tenants/:tenant/webhooks/:id":                {ID: "getWebhookEndpoint", Summary: "A webhook endpoint", Response: webhook.Endpoint{}},
+	"PUT /api/v1/tenants/:tenant/webhooks/:id":                {ID: "updateWebhookEndpoint", Summary: "Replace a webhook endpoint", Request: webhookEndpointRequest{}, Response: webhook.Endpoint{}},
+	"DELETE /api/v1/tenants/:tenant/webhooks/:id":             {ID: "deleteWebhookEndpoint", Summary: "Remove a webhook endpoint", Status: http.StatusNoContent},
+	"POST /api/v1/tenants/:tenant/webhooks/:id/rotate-secret": {ID: "rotateWebhookSecret", Summary: "Give a webhook endpoint a new signing secret", Response: webhook.Endpoint{}},
+	"GET /api/v1/tenants/:tenant/webhooks/:id/deliveries":     {ID: "listWebhookDeliveries", Summary: "Page through the deliveries of a webhook endpoint", Query: pageParams, Response: gin.H{"deliveries": []webhook.Delivery(nil), "total": 0}},
+	"POST /api/v1/tenants/:tenant/webhooks/:id/deliveries/:delivery/redeliver": {
+		ID:       "redeliverWebhook",
+		Summary:  "Send a delivery of a webhook endpoint again",
+		Status:   http.StatusAccepted,
+		Response: webhook.Delivery{},
+	},
+
+	"POST /api/v1/risk-rules/dry-run": {
+		ID:       "dryRunRiskRules",
+		Summary:  "Evaluate risk rules against a processed job's text",
+		Request:  dryRunRequest{},
+		Response: gin.H{"job_id": "", "risk_analysis": processor.RiskAnalysis{}, "matches": []gin.H{{"rule_id": "", "name": "", "position": 0, "end": 0}}},
+	},
+	"GET /api/v1/risk-rules":              {ID: "listRiskRules", Summary: "The risk rules of a tenant", Query: tenantParam, Response: gin.H{"rules": []risk.Rule(nil)}},
+	"POST /api/v1/risk-rules":             {ID: "createRiskRule", Summary: "Add a risk rule", Query: tenantParam, Request: riskRuleRequest{}, Status: http.StatusCreated, Response: risk.Rule{}},
+	"GET /api/v1/risk-rules/:id":          {ID: "getRiskRule", Summary: "A risk rule", Query: tenantParam, Response: risk.Rule{}},
+	"PUT /api/v1/risk-rules/:id":          {ID: "updateRiskRule", Summary: "Replace a risk rule, given the version it replaces", Query: tenantParam, Request: riskRuleRequest{}, Response: risk.Rule{}},
+	"DELETE /api/v1/risk-rules/:id":       {ID: "deleteRiskRule", Summary: "Remove a risk rule", Query: tenantParam, Status: http.StatusNoContent},
+	"GET /api/v1/risk-rules/:id/versions": {ID: "listRiskRuleVersions", Summary: "The versions of a risk rule", Query: tenantParam, Response: gin.H{"versions": []risk.RuleVersion(nil)}},
+
+	"OPTIONS /api/v1/uploads":    {ID: "tusOptions", Summary: "Resumable upload capabilities", Status: http.StatusNoContent, Internal: true},
+	"POST /api/v1/uploads":       {ID: "tusCreate", Summary: "Start a resumable upload", Status: http.StatusCreated, Internal: true},
+	"HEAD /api/v1/uploads/:id":   {ID: "tusHead", Summary: "Offset of a resumable upload", Internal: true},
+	"PATCH /api/v1/uploads/:id":  {ID: "tusPatch", Summary: "Append to a resumable upload", Status: http.StatusNoContent, Internal: true},
+	"DELETE /api/v1/uploads/:id": {ID: "tusDelete", Summary: "Abandon a resumable upload", Status: http.StatusNoContent, Internal: true},
+
+	"POST /api/v1/presigned-uploads": {
+		ID:       "createPresignedUpload",
+		Summary:  "Create a job whose document is uploaded to a presigned URL",
+		Request:  presignRequest{},
+		Status:   http.StatusCreated,
+		Response: gin.H{"job_id": "", "upload_url": "", "method": "", "expires_at": time.Time{}},
+	},
+	"POST /api/v1/presigned-uploads/:id/complete":  {ID: "completePresignedUpload", Summary: "Queue the job of a finished presigned upload", Status: http.StatusAccepted, Response: jobAccepted},
+	"POST /api/v1/presigned-uploads/notifications": {ID: "handleBucketNotification", Summary: "Queue the jobs of uploads the object store reports", Request: bucketNotification{}, Response: gin.H{"queued": 0}, Internal: true},
+}
+
+var (
+	graphQLRequest  = gin.H{"query": "", "operationName": "", "variables": map[string]interface{}(nil)}
+	graphQLResponse = gin.H{
+		"data": nil,
+		"errors": []gin.H{{
+			"message":   "",
+			"locations": []gin.H{{"line": 0, "column": 0}},
+			"path":      []interface{}(nil),
+		}},
+	}
+)
+
+// OpenAPI returns the OpenAPI document of every annotated operation.
+func OpenAPI() ([]byte, error) {
+	return json.MarshalIndent(buildOpenAPI(nil), "", "  ")
+}
+
+// openAPIFor returns the OpenAPI document of the routes registered,
+// logging those not annotated.
+func openAPIFor(routes gin.RoutesInfo) ([]byte, error) {
+	registered := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		if _, ok := apiOperations[key]; !ok {
+			log.Printf("Route %s is missing from the OpenAPI document", key)
+		}
+	}
+	return json.MarshalIndent(buildOpenAPI(registered), "", "  ")
+}
+
+func (h *Handler) getOpenAPI(c *gin.Context) {
+	if h.openAPI == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "the OpenAPI document is not available"})
+		return
+	}
+	c.Data(http.StatusOK, "application/json", h.openAPI)
+}
+
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+	Security   []openAPISecurity                       `json:"security"`
+}
+
+// openAPISecurity names the security schemes a request may use.
+type openAPISecurity map[string][]string
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*openAPISchema         `json:"schemas"`
+	SecuritySchemes map[string]*openAPISecurityScheme `json:"securitySchemes"`
+}
+
+type openAPISecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+type openAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Summary     string                      `json:"summary,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []openAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *openAPIBody                `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+	Internal    bool                        `json:"x-internal,omitempty"`
+
+	// Security is empty for the routes left open, which need no token
+	Security *[]openAPISecurity `json:"security,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *openAPISchema `json:"schema"`
+}
+
+type openAPIBody struct {
+	Required bool                         `json:"required,omitempty"`
+	Content  map[string]*openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+// buildOpenAPI documents the annotated operations, only those registered
+// unless registered is nil.
+func buildOpenAPI(registered map[string]bool) *openAPIDocument {
+	doc := &openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    openAPIInfo{Title: "Cotai PDF Processor API", Version: openAPIVersion},
+		Paths:   make(map[string]map[string]*openAPIOperation),
+		// Requests carry a JWT of the identity provider or an API key; see
+		// authenticate
+		Security: []openAPISecurity{{"bearerAuth": {}}, {"apiKeyAuth": {}}},
+	}
+	schemas := newSchemaBuilder()
+	schemas.schemas["Error"] = &openAPISchema{
+		Type: "object",
+		Properties: map[string]*openAPISchema{
+			"error": {Type: "string"},
+			"code":  {Type: "string"},
+		},
+		Required: []string{"error"},
+	}
+
+	// Sorted, so schemas are named the same each time
+	keys := make([]string, 0, len(apiOperations))
+	for key := range apiOperations {
+		if registered == nil || registered[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		method, route, _ := strings.Cut(key, " ")
+		op := apiOperations[key]
+		pattern, params := openAPIPath(route, op.Path)
+		if doc.Paths[pattern] == nil {
+			doc.Paths[pattern] = make(map[string]*openAPIOperation)
+		}
+		operation := schemas.operation(op, route, params)
+		if publicRoutes[route] {
+			operation.Security = &[]openAPISecurity{}
+		}
+		doc.Paths[pattern][strings.ToLower(method)] = operation
+	}
+	doc.Components.Schemas = schemas.schemas
+	doc.Components.SecuritySchemes = map[string]*openAPISecurityScheme{
+		"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+		"apiKeyAuth": {Type: "apiKey", In: "header", Name: "X-API-Key"},
+	}
+	return doc
+}
+
+// openAPIPath turns the :name parameters of a route into {name} ones.
+func openAPIPath(route string, typed []apiParam) (string, []openAPIParameter) {
+	var params []openAPIParameter
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		param := stringParam(segment[1:], "")
+		for _, p := range typed {
+			if p.Name == param.Name {
+				param = p
+			}
+		}
+		params = append(params, openAPIParameter{
+			Name:        param.Name,
+			In:          "path",
+			Description: param.Description,
+			Required:    true,
+			Schema:      &openAPISchema{Type: param.Type},
+		})
+		segments[i] = "{" + param.Name + "}"
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// openAPITag groups routes by the resource under the version, or under
+// the tenant for tenant resources.
+func openAPITag(route string) string {
+	segments := strings.Split(strings.TrimPrefix(route, "/api/"), "/")[1:]
+	if segments[0] == "tenants" && len(segments) > 2 {
+		return segments[2]
+	}
+	return segments[0]
+}
+
+type schemaBuilder struct {
+	schemas map[string]*openAPISchema
+	names   map[reflect.Type]string
+	types   map[string]reflect.Type
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{
+		schemas: make(map[string]*openAPISchema),
+		names:   make(map[reflect.Type]string),
+		types:   make(map[string]reflect.Type),
+	}
+}
+
+func (b *schemaBuilder) operation(op apiOperation, route string, params []openAPIParameter) *openAPIOperation {
+	out := &openAPIOperation{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Tags:        []string{openAPITag(route)},
+		Parameters:  params,
+		Responses:   make(map[string]*openAPIResponse),
+		Internal:    op.Internal,
+	}
+	for _, param := range op.Query {
+		out.Parameters = append(out.Parameters, openAPIParameter{
+			Name:        param.Name,
+			In:          "query",
+			Description: param.Description,
+			Schema:      &openAPISchema{Type: param.Type},
+		})
+	}
+
+	switch {
+	case op.Request != nil:
+		out.RequestBody = &openAPIBody{
+			Required: true,
+			Content:  map[string]*openAPIMediaType{"application/json": {Schema: b.value(op.Request)}},
+		}
+	case op.Form != nil:
+		form := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+		for _, field := range op.Form {
+			schema := &openAPISchema{Type: field.Type, Description: field.Description}
+			if field.Type == "file" {
+				schema = &openAPISchema{Type: "string", Format: "binary"}
+				form.Required = append(form.Required, field.Name)
+			}
+			form.Properties[field.Name] = schema
+		}
+		out.RequestBody = &openAPIBody{
+			Required: true,
+			Content:  map[string]*openAPIMediaType{"multipart/form-data": {Schema: form}},
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := &openAPIResponse{Description: http.StatusText(status)}
+	switch {
+	case status == http.StatusFound:
+		response.Description = "Redirect to the Location header"
+	case op.Content != "":
+		response.Content = map[string]*openAPIMediaType{op.Content: {Schema: &openAPISchema{Type: "string", Format: "binary"}}}
+	case op.Response != nil:
+		response.Content = map[string]*openAPIMediaType{"application/json": {Schema: b.value(op.Response)}}
+	}
+	out.Responses[fmt.Sprint(status)] = response
+	if op.Tagged {
+		out.Parameters = append(out.Parameters, openAPIParameter{
+			Name:        "If-None-Match",
+			In:          "header",
+			Description: "ETag of the response the client has",
+			Schema:      &openAPISchema{Type: "string"},
+		})
+		out.Responses[fmt.Sprint(http.StatusNotModified)] = &openAPIResponse{Description: "The response has the ETag of If-None-Match"}
+	}
+	out.Responses["default"] = &openAPIResponse{
+		Description: "Error",
+		Content:     map[string]*openAPIMediaType{"application/json": {Schema: &openAPISchema{Ref: "#/components/schemas/Error"}}},
+	}
+	return out
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	rawMessageType    = reflect.TypeOf(json.RawMessage(nil))
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// value returns the schema of an annotation: a value of a type, or a gin.H
+// of values of the types of its fields.
+func (b *schemaBuilder) value(v interface{}) *openAPISchema {
+	switch v := v.(type) {
+	case nil:
+		return &openAPISchema{}
+	case gin.H:
+		object := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+		for name, field := range v {
+			object.Properties[name] = b.value(field)
+		}
+		return object
+	case []gin.H:
+		items := &openAPISchema{Type: "object"}
+		if len(v) > 0 {
+			items = b.value(v[0])
+		}
+		return &openAPISchema{Type: "array", Items: items}
+	}
+	return b.typeOf(reflect.TypeOf(v))
+}
+
+func (b *schemaBuilder) typeOf(t reflect.Type) *openAPISchema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t, nullable = t.Elem(), true
+	}
+	schema := b.nonNull(t)
+	if nullable && schema.Ref == "" {
+		schema.Nullable = true
+	}
+	return schema
+}
+
+func (b *schemaBuilder) nonNull(t reflect.Type) *openAPISchema {
+	switch {
+	case t == timeType:
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	case t == durationType:
+		return &openAPISchema{Type: "integer", Format: "int64", Description: "Nanoseconds"}
+	case t == rawMessageType:
+		return &openAPISchema{}
+	case t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+		return &openAPISchema{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return &openAPISchema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int64, reflect.Uint64:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Int32, reflect.Uint32:
+		return &openAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Uint, reflect.Uint8, reflect.Uint16:
+		return &openAPISchema{Type: "integer"}
+	case reflect.Float32:
+		return &openAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &openAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &openAPISchema{Type: "string", Format: "byte"}
+		}
+		return &openAPISchema{Type: "array", Items: b.typeOf(t.Elem())}
+	case reflect.Map:
+		return &openAPISchema{Type: "object", AdditionalProperties: b.typeOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		return b.ref(t)
+	}
+	return &openAPISchema{}
+}
+
+// ref refers to the component schema of a named type, named after it and,
+// when another package has a type of the same name, its package.
+func (b *schemaBuilder) ref(t reflect.Type) *openAPISchema {
+	name, ok := b.names[t]
+	if !ok {
+		name = exportedName(t.Name())
+		if other, taken := b.types[name]; taken && other != t {
+			name = exportedName(path.Base(t.PkgPath())) + name
+		}
+		b.names[t], b.types[name] = name, t
+		b.schemas[name] = b.object(t)
+	}
+	return &openAPISchema{Ref: "#/components/schemas/" + name}
+}
+
+// object describes the fields of a struct as JSON encodes them; those
+// bound as required are required.
+func (b *schemaBuilder) object(t reflect.Type) *openAPISchema {
+	object := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+	b.fields(t, object)
+	return object
+}
+
+func (b *schemaBuilder) fields(t reflect.Type, object *openAPISchema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.fields(embedded, object)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		object.Properties[name] = b.typeOf(field.Type)
+		if strings.Contains(field.Tag.Get("binding"), "required") {
+			object.Required = append(object.Required, name)
+		}
+	}
+}
+
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+	return string(unicode.ToUpper(rune(name[0]))) + name[1:]
+}
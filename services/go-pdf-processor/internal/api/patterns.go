@@ -0,0 +1,99 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// entityPatternRequest is the body accepted when creating or replacing a
+// tenant's entity pattern.
+type entityPatternRequest struct {
+	Name       string  `json:"name" binding:"required"`
+	EntityType string  `json:"entity_type" binding:"required"`
+	Pattern    string  `json:"pattern" binding:"required"`
+	Confidence float64 `json:"confidence"`
+}
+
+func (r entityPatternRequest) toPattern(tenantID string) *processor.EntityPattern {
+	return &processor.EntityPattern{
+		TenantID:   tenantID,
+		Name:       r.Name,
+		EntityType: r.EntityType,
+		Pattern:    r.Pattern,
+		Confidence: r.Confidence,
+	}
+}
+
+func (h *Handler) listEntityPatterns(c *gin.Context) {
+	patterns, err := h.processor.ListEntityPatterns(c.Request.Context(), c.Param("tenant"))
+	if err != nil {
+		entityPatternError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"patterns": patterns})
+}
+
+func (h *Handler) getEntityPattern(c *gin.Context) {
+	pattern, err := h.processor.GetEntityPattern(c.Request.Context(), c.Param("tenant"), c.Param("id"))
+	if err != nil {
+		entityPatternError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pattern)
+}
+
+func (h *Handler) createEntityPattern(c *gin.Context) {
+	var req entityPatternRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pattern := req.toPattern(c.Param("tenant"))
+	if err := h.processor.CreateEntityPattern(c.Request.Context(), pattern); err != nil {
+		entityPatternError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, pattern)
+}
+
+func (h *Handler) updateEntityPattern(c *gin.Context) {
+	var req entityPatternRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pattern := req.toPattern(c.Param("tenant"))
+	pattern.ID = c.Param("id")
+	if err := h.processor.UpdateEntityPattern(c.Request.Context(), pattern); err != nil {
+		entityPatternError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pattern)
+}
+
+func (h *Handler) deleteEntityPattern(c *gin.Context) {
+	if err := h.processor.DeleteEntityPattern(c.Request.Context(), c.Param("tenant"), c.Param("id")); err != nil {
+		entityPatternError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func entityPatternError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, processor.ErrPatternNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, processor.ErrInvalidPattern):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Entity pattern request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to access entity patterns"})
+	}
+}
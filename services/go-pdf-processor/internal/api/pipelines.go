@@ -0,0 +1,97 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pipelineRequest is the body accepted when creating or replacing a
+// tenant's pipeline.
+type pipelineRequest struct {
+	Name        string                    `json:"name" binding:"required"`
+	Description string                    `json:"description"`
+	Stages      []processor.PipelineStage `json:"stages" binding:"required"`
+}
+
+func (r pipelineRequest) toPipeline(tenantID string) *processor.Pipeline {
+	return &processor.Pipeline{
+		TenantID:    tenantID,
+		Name:        r.Name,
+		Description: r.Description,
+		Stages:      r.Stages,
+	}
+}
+
+func (h *Handler) listPipelines(c *gin.Context) {
+	pipelines, err := h.processor.ListPipelines(c.Request.Context(), c.Param("tenant"))
+	if err != nil {
+		pipelineError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pipelines": pipelines, "stages": processor.StageNames()})
+}
+
+func (h *Handler) getPipeline(c *gin.Context) {
+	pipeline, err := h.processor.GetPipeline(c.Request.Context(), c.Param("tenant"), c.Param("id"))
+	if err != nil {
+		pipelineError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pipeline)
+}
+
+func (h *Handler) createPipeline(c *gin.Context) {
+	var req pipelineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pipeline := req.toPipeline(c.Param("tenant"))
+	if err := h.processor.CreatePipeline(c.Request.Context(), pipeline); err != nil {
+		pipelineError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, pipeline)
+}
+
+func (h *Handler) updatePipeline(c *gin.Context) {
+	var req pipelineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pipeline := req.toPipeline(c.Param("tenant"))
+	pipeline.ID = c.Param("id")
+	if err := h.processor.UpdatePipeline(c.Request.Context(), pipeline); err != nil {
+		pipelineError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pipeline)
+}
+
+func (h *Handler) deletePipeline(c *gin.Context) {
+	if err := h.processor.DeletePipeline(c.Request.Context(), c.Param("tenant"), c.Param("id")); err != nil {
+		pipelineError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func pipelineError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, processor.ErrPipelineNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, processor.ErrInvalidPipeline):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Pipeline request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to access pipelines"})
+	}
+}
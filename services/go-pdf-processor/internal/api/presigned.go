@@ -0,0 +1,271 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cotai-pdf-processor/internal/processor"
+	"cotai-pdf-processor/internal/storage"
+	"cotai-pdf-processor/internal/webhook"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Presigned uploads let clients PUT documents straight into the upload
+// bucket. The job is created in "awaiting_upload" state and queued once the
+// client calls the complete endpoint or the bucket notification arrives.
+
+const statusAwaitingUpload = "awaiting_upload"
+
+// presignClaimTTL bounds how long queueing a presigned job holds its
+// claim, should the replica queueing it die.
+const presignClaimTTL = time.Minute
+
+type presignRequest struct {
+	Filename          string          `json:"filename" binding:"required"`
+	ContentType       string          `json:"content_type"`
+	Size              int64           `json:"size"`
+	TenderID          string          `json:"tender_id"`
+	UserID            string          `json:"user_id"`
+	TenantID          string          `json:"tenant_id"`
+	InterestProfileID string          `json:"interest_profile_id"`
+	Priority          string          `json:"priority"`
+	Profile           string          `json:"profile"`
+	Options           json.RawMessage `json:"options"`
+	CallbackURL       string          `json:"callback_url"`
+	RunAt             string          `json:"run_at"`
+	DependsOn         []string        `json:"depends_on"`
+}
+
+// bucketNotification is the subset of the S3/MinIO event payload we use.
+type bucketNotification struct {
+	Records []struct {
+		EventName string `json:"eventName"`
+		S3        struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key string `json:"key"`
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+}
+
+func (h *Handler) createPresignedUpload(c *gin.Context) {
+	var req presignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.claimIdentity(c, &req.UserID, &req.TenantID) || !h.checkQuota(c, req.TenantID, req.Size) {
+		return
+	}
+
+	if req.ContentType == "" {
+		req.ContentType = contentTypeFromName(req.Filename)
+	}
+	if !h.isAllowedType(req.ContentType) {
+		h.unsupportedType(c, req.ContentType)
+		return
+	}
+	if req.Size > h.cfg.MaxFileSize {
+		h.fileTooLarge(c)
+		return
+	}
+	if err := processor.ValidatePriority(req.Priority); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := webhook.ValidateURL(c.Request.Context(), req.CallbackURL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	runAt, err := processor.ParseRunAt(req.RunAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.validateDependencies(c, req.DependsOn) {
+		return
+	}
+
+	job := newJob(uuid.New().String())
+	job.Status = statusAwaitingUpload
+	job.TenderID = req.TenderID
+	job.UserID = req.UserID
+	job.TenantID = req.TenantID
+	job.Priority = req.Priority
+	job.CallbackURL = req.CallbackURL
+	job.RunAt = runAt
+	job.DependsOn = req.DependsOn
+	if req.InterestProfileID != "" {
+		job.Metadata["interest_profile_id"] = req.InterestProfileID
+	}
+	job.Metadata["original_filename"] = req.Filename
+	job.Metadata["content_type"] = req.ContentType
+	if !h.resolveOptions(c, job, req.Profile, req.Options) {
+		return
+	}
+
+	key := path.Join("uploads", job.ID+strings.ToLower(filepath.Ext(req.Filename)))
+	job.FileURL = fmt.Sprintf("s3://%s/%s", h.cfg.UploadBucket, key)
+
+	uploadURL, err := h.objects.PresignedPut(c.Request.Context(), h.cfg.UploadBucket, key, h.cfg.PresignExpiry)
+	if err != nil {
+		log.Printf("Failed to presign upload for job %s: %v", job.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create upload URL"})
+		return
+	}
+
+	if err := h.processor.SaveJob(c.Request.Context(), job); err != nil {
+		log.Printf("Failed to save job %s: %v", job.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create job"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"job_id":     job.ID,
+		"upload_url": uploadURL,
+		"method":     http.MethodPut,
+		"expires_at": time.Now().Add(h.cfg.PresignExpiry),
+	})
+}
+
+func (h *Handler) completePresignedUpload(c *gin.Context) {
+	status, err := h.queuePresignedJob(c, c.Param("id"))
+	if status == http.StatusRequestEntityTooLarge {
+		h.fileTooLarge(c)
+		return
+	}
+	if status == http.StatusTooManyRequests {
+		h.overloaded(c, err)
+		return
+	}
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id": c.Param("id"),
+		"status": "queued",
+	})
+}
+
+// handleBucketNotification accepts s3:ObjectCreated events for the upload
+// bucket and queues the matching jobs. The route is left open by
+// authenticate, the object store sending PRESIGN_NOTIFICATION_TOKEN as its
+// bearer token instead; without one notifications are refused.
+func (h *Handler) handleBucketNotification(c *gin.Context) {
+	if h.cfg.PresignNotificationToken == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "bucket notifications are not enabled"})
+		return
+	}
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.cfg.PresignNotificationToken)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid notification token"})
+		return
+	}
+
+	var event bucketNotification
+	if err := c.ShouldBindJSON(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	queued := []string{}
+	for _, record := range event.Records {
+		if !strings.HasPrefix(record.EventName, "s3:ObjectCreated") || record.S3.Bucket.Name != h.cfg.UploadBucket {
+			continue
+		}
+
+		key, err := url.QueryUnescape(record.S3.Object.Key)
+		if err != nil || !strings.HasPrefix(key, "uploads/") {
+			continue
+		}
+		jobID := strings.TrimSuffix(path.Base(key), path.Ext(key))
+
+		if _, err := h.queuePresignedJob(c, jobID); err != nil {
+			log.Printf("Bucket notification for %s ignored: %v", key, err)
+			continue
+		}
+		queued = append(queued, jobID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"queued": queued})
+}
+
+// queuePresignedJob verifies the uploaded object and submits the job. It
+// returns the HTTP status to report alongside any error. The job is
+// claimed first, so of a completion and a notification arriving together
+// one queues it and the other finds it queued.
+func (h *Handler) queuePresignedJob(c *gin.Context, jobID string) (int, error) {
+	ctx := c.Request.Context()
+
+	release, err := h.processor.ClaimJob(ctx, jobID, presignClaimTTL)
+	if errors.Is(err, processor.ErrJobClaimed) {
+		return http.StatusConflict, err
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer release()
+
+	job, err := h.processor.GetJob(ctx, jobID)
+	if errors.Is(err, processor.ErrJobNotFound) {
+		return http.StatusNotFound, err
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if job.Status != statusAwaitingUpload {
+		return http.StatusConflict, fmt.Errorf("job %s is already %s", jobID, job.Status)
+	}
+
+	u, err := url.Parse(job.FileURL)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	info, err := h.objects.Stat(ctx, u.Host, strings.TrimPrefix(u.Path, "/"))
+	if errors.Is(err, storage.ErrNotFound) {
+		return http.StatusConflict, fmt.Errorf("file for job %s has not been uploaded", jobID)
+	}
+	if err != nil {
+		return http.StatusBadGateway, fmt.Errorf("failed to check upload: %w", err)
+	}
+	if info.Size > h.cfg.MaxFileSize {
+		return http.StatusRequestEntityTooLarge, fmt.Errorf("file exceeds maximum size of %d bytes", h.cfg.MaxFileSize)
+	}
+
+	job.Status = "queued"
+	job.Metadata["file_size"] = info.Size
+	if err := h.processor.SaveJob(ctx, job); err != nil {
+		// Unsaved, the job could be queued again by the next request
+		log.Printf("Failed to save job %s: %v", job.ID, err)
+		return http.StatusInternalServerError, fmt.Errorf("failed to queue job %s", jobID)
+	}
+	if err := h.workerPool.SubmitJob(job); err != nil {
+		// Left to be completed again
+		job.Status = statusAwaitingUpload
+		if err := h.processor.SaveJob(ctx, job); err != nil {
+			log.Printf("Failed to save job %s: %v", job.ID, err)
+		}
+		if errors.Is(err, processor.ErrResourcesExhausted) {
+			return http.StatusTooManyRequests, err
+		}
+		return http.StatusServiceUnavailable, err
+	}
+
+	return http.StatusAccepted, nil
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// processingProfileRequest is the body accepted when creating or replacing
+// a tenant's processing profile.
+type processingProfileRequest struct {
+	Name    string                      `json:"name" binding:"required"`
+	Options processor.ProcessingOptions `json:"options"`
+}
+
+func (r processingProfileRequest) toProfile(tenantID string) *processor.ProcessingProfile {
+	return &processor.ProcessingProfile{
+		TenantID: tenantID,
+		Name:     r.Name,
+		Options:  r.Options,
+	}
+}
+
+func (h *Handler) listProcessingProfiles(c *gin.Context) {
+	profiles, err := h.processor.ListProcessingProfiles(c.Request.Context(), c.Param("tenant"))
+	if err != nil {
+		processingProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"profiles": profiles})
+}
+
+func (h *Handler) getProcessingProfile(c *gin.Context) {
+	profile, err := h.processor.GetProcessingProfile(c.Request.Context(), c.Param("tenant"), c.Param("id"))
+	if err != nil {
+		processingProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *Handler) createProcessingProfile(c *gin.Context) {
+	var req processingProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile := req.toProfile(c.Param("tenant"))
+	if err := h.processor.CreateProcessingProfile(c.Request.Context(), profile); err != nil {
+		processingProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, profile)
+}
+
+func (h *Handler) updateProcessingProfile(c *gin.Context) {
+	var req processingProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile := req.toProfile(c.Param("tenant"))
+	profile.ID = c.Param("id")
+	if err := h.processor.UpdateProcessingProfile(c.Request.Context(), profile); err != nil {
+		processingProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *Handler) deleteProcessingProfile(c *gin.Context) {
+	if err := h.processor.DeleteProcessingProfile(c.Request.Context(), c.Param("tenant"), c.Param("id")); err != nil {
+		processingProfileError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func processingProfileError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, processor.ErrProcessingProfileNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, processor.ErrInvalidProcessingProfile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Processing profile request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to access processing profiles"})
+	}
+}
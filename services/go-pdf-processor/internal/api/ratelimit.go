@@ -0,0 +1,85 @@
+package api
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cotai-pdf-processor/internal/auth"
+	"cotai-pdf-processor/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// submissionRoutes are the routes submitting documents, rate limited by
+// RATE_LIMIT_SUBMIT_*; uploading the chunks of a document is not.
+var submissionRoutes = map[string]bool{
+	"POST /api/v1/documents":         true,
+	"POST /api/v1/tenders":           true,
+	"POST /api/v1/uploads":           true,
+	"POST /api/v1/presigned-uploads": true,
+}
+
+// rateLimit refuses requests beyond the rate their API key, their user of
+// its tenant, or their client IP for unauthenticated ones, may submit
+// documents or read results at, with
+// a structured 429 response. Allowed requests are told what is left of
+// their limit in RateLimit-* headers. Requests are let through while Redis
+// cannot be reached.
+func (h *Handler) rateLimit() gin.HandlerFunc {
+	submit := ratelimit.Limit{PerMinute: h.cfg.RateLimitSubmitPerMinute, Burst: h.cfg.RateLimitSubmitBurst}
+	read := ratelimit.Limit{PerMinute: h.cfg.RateLimitReadPerMinute, Burst: h.cfg.RateLimitReadBurst}
+
+	return func(c *gin.Context) {
+		var class string
+		var limit ratelimit.Limit
+		switch {
+		case h.limiter == nil || c.Request.Method == http.MethodOptions:
+		case submissionRoutes[c.Request.Method+" "+c.FullPath()]:
+			class, limit = "submit", submit
+		case routeScope(c.Request.Method, c.FullPath()) == auth.ScopeRead:
+			class, limit = "read", read
+		}
+		if class == "" || limit.PerMinute <= 0 || limit.Burst <= 0 {
+			c.Next()
+			return
+		}
+
+		caller := "ip:" + c.ClientIP()
+		if principal := auth.FromContext(c.Request.Context()); principal != nil && principal.APIKeyID != "" {
+			caller = "key:" + principal.APIKeyID
+		} else if principal != nil {
+			caller = "user:" + principal.TenantID + ":" + principal.UserID
+		}
+		result, err := h.limiter.Allow(c.Request.Context(), class+":"+caller, limit)
+		if err != nil {
+			log.Printf("Rate limiting disabled for request: %v", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Policy", strconv.Itoa(limit.Burst)+";w="+strconv.Itoa(seconds(limit.Window())))
+		c.Header("RateLimit-Limit", strconv.Itoa(limit.Burst))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+		if !result.Allowed {
+			retryAfter := seconds(result.RetryAfter)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       "rate limit exceeded",
+				"code":        "rate_limited",
+				"limit":       limit.Burst,
+				"retry_after": retryAfter,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// seconds rounds d up to whole seconds, as the RateLimit headers take.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
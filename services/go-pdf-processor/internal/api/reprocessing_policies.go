@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reprocessingPolicyRequest is the body accepted when creating or
+// replacing a tenant's reprocessing policy. Policies are enabled unless
+// enabled is false.
+type reprocessingPolicyRequest struct {
+	Name     string                       `json:"name" binding:"required"`
+	Schedule string                       `json:"schedule" binding:"required"`
+	Timezone string                       `json:"timezone"`
+	Filter   processor.ReprocessingFilter `json:"filter"`
+	Profile  string                       `json:"profile"`
+	Options  json.RawMessage              `json:"options"`
+	Priority string                       `json:"priority"`
+	Enabled  *bool                        `json:"enabled"`
+}
+
+func (r reprocessingPolicyRequest) toPolicy(tenantID string) *processor.ReprocessingPolicy {
+	return &processor.ReprocessingPolicy{
+		TenantID: tenantID,
+		Name:     r.Name,
+		Schedule: r.Schedule,
+		Timezone: r.Timezone,
+		Filter:   r.Filter,
+		Profile:  r.Profile,
+		Options:  r.Options,
+		Priority: r.Priority,
+		Enabled:  r.Enabled == nil || *r.Enabled,
+	}
+}
+
+func (h *Handler) listReprocessingPolicies(c *gin.Context) {
+	policies, err := h.processor.ListReprocessingPolicies(c.Request.Context(), c.Param("tenant"))
+	if err != nil {
+		reprocessingPolicyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"policies": policies})
+}
+
+func (h *Handler) getReprocessingPolicy(c *gin.Context) {
+	policy, err := h.processor.GetReprocessingPolicy(c.Request.Context(), c.Param("tenant"), c.Param("id"))
+	if err != nil {
+		reprocessingPolicyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+func (h *Handler) createReprocessingPolicy(c *gin.Context) {
+	var req reprocessingPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy := req.toPolicy(c.Param("tenant"))
+	if err := h.processor.CreateReprocessingPolicy(c.Request.Context(), policy); err != nil {
+		reprocessingPolicyError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, policy)
+}
+
+func (h *Handler) updateReprocessingPolicy(c *gin.Context) {
+	var req reprocessingPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy := req.toPolicy(c.Param("tenant"))
+	policy.ID = c.Param("id")
+	if err := h.processor.UpdateReprocessingPolicy(c.Request.Context(), policy); err != nil {
+		reprocessingPolicyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+func (h *Handler) deleteReprocessingPolicy(c *gin.Context) {
+	if err := h.processor.DeleteReprocessingPolicy(c.Request.Context(), c.Param("tenant"), c.Param("id")); err != nil {
+		reprocessingPolicyError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// runReprocessingPolicy runs a policy now, whatever its schedule.
+func (h *Handler) runReprocessingPolicy(c *gin.Context) {
+	policy, err := h.processor.GetReprocessingPolicy(c.Request.Context(), c.Param("tenant"), c.Param("id"))
+	if err != nil {
+		reprocessingPolicyError(c, err)
+		return
+	}
+
+	run, err := h.workerPool.RunReprocessingPolicy(c.Request.Context(), policy)
+	if err != nil {
+		if errors.Is(err, processor.ErrResourcesExhausted) {
+			h.overloaded(c, err)
+			return
+		}
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error(), "run": run})
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+func reprocessingPolicyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, processor.ErrReprocessingPolicyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, processor.ErrInvalidReprocessingPolicy):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Reprocessing policy request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to access reprocessing policies"})
+	}
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// invalidateCachedResults removes the cached results of a document, by the
+// SHA-256 reported as content_sha256 in its results, so it is processed
+// again when next submitted.
+func (h *Handler) invalidateCachedResults(c *gin.Context) {
+	removed, err := h.processor.InvalidateResults(c.Request.Context(), c.Param("sha256"))
+	if errors.Is(err, processor.ErrInvalidContentHash) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to invalidate cached results of %s: %v", c.Param("sha256"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to invalidate cached results"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"invalidated": removed})
+}
+
+// clearResultCache removes every cached result.
+func (h *Handler) clearResultCache(c *gin.Context) {
+	removed, err := h.processor.InvalidateAllResults(c.Request.Context())
+	if err != nil {
+		log.Printf("Failed to clear the result cache: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to clear the result cache", "invalidated": removed})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"invalidated": removed})
+}
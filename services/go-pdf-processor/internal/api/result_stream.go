@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamFlushEvery is how many records are written between flushes, so
+// consumers get them as they go.
+const streamFlushEvery = 100
+
+// Records of a streamed result, told apart by their record field: one
+// "result" record of the totals, then a "page" record of the text of each
+// page, an "entity" record of each entity and a "risk" record of each
+// identified risk.
+type (
+	resultRecord struct {
+		Record         string  `json:"record"`
+		JobID          string  `json:"job_id"`
+		PageCount      int     `json:"page_count"`
+		FileSize       int64   `json:"file_size"`
+		OverallRisk    string  `json:"overall_risk"`
+		RiskScore      float64 `json:"risk_score"`
+		RelevanceScore float64 `json:"relevance_score"`
+		Entities       int     `json:"entities"`
+		Risks          int     `json:"risks"`
+	}
+
+	// pageRecord has no page number when the result's pages are not
+	// known, its text being the whole text
+	pageRecord struct {
+		Record string `json:"record"`
+		Page   int    `json:"page,omitempty"`
+		Text   string `json:"text"`
+	}
+
+	entityRecord struct {
+		Record string `json:"record"`
+		processor.ExtractedEntity
+	}
+
+	riskRecord struct {
+		Record string `json:"record"`
+		processor.IdentifiedRisk
+	}
+)
+
+// streamJobResult writes a job's result as newline-delimited JSON records,
+// for consumers to process as they read rather than decode a document of
+// megabytes at once.
+func (h *Handler) streamJobResult(c *gin.Context) {
+	id := c.Param("id")
+	result := h.loadResult(c)
+	if result == nil {
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	written := 0
+	write := func(record interface{}) bool {
+		if err := encoder.Encode(record); err != nil {
+			// The client went away
+			return false
+		}
+		if written++; written%streamFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+		return true
+	}
+
+	ok := write(resultRecord{
+		Record:         "result",
+		JobID:          id,
+		PageCount:      result.PageCount,
+		FileSize:       result.FileSize,
+		OverallRisk:    result.RiskAnalysis.OverallRisk,
+		RiskScore:      result.RiskAnalysis.RiskScore,
+		RelevanceScore: result.RelevanceScore,
+		Entities:       len(result.Entities),
+		Risks:          len(result.RiskAnalysis.IdentifiedRisks),
+	})
+	if len(result.PageOffsets) > 0 {
+		for i := range result.PageOffsets {
+			ok = ok && write(pageRecord{Record: "page", Page: i + 1, Text: pageText(result.ExtractedText, result.PageOffsets, i)})
+		}
+	} else if result.ExtractedText != "" {
+		ok = ok && write(pageRecord{Record: "page", Text: result.ExtractedText})
+	}
+	for _, entity := range result.Entities {
+		ok = ok && write(entityRecord{Record: "entity", ExtractedEntity: entity})
+	}
+	for _, risk := range result.RiskAnalysis.IdentifiedRisks {
+		ok = ok && write(riskRecord{Record: "risk", IdentifiedRisk: risk})
+	}
+	if ok {
+		c.Writer.Flush()
+	}
+}
+
+// loadResult loads the result of the request's job, or responds with an
+// error and returns nil.
+func (h *Handler) loadResult(c *gin.Context) *processor.ProcessingResult {
+	result, err := h.processor.JobResult(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, processor.ErrJobNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil
+	}
+	if err != nil {
+		log.Printf("Failed to load result of job %s: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load result"})
+		return nil
+	}
+	if result == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job has no result"})
+	}
+	return result
+}
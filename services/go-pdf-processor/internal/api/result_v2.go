@@ -0,0 +1,155 @@
+package api
+
+import (
+	"cotai-pdf-processor/internal/classify"
+	"cotai-pdf-processor/internal/processor"
+	"cotai-pdf-processor/internal/report"
+	"cotai-pdf-processor/internal/signature"
+)
+
+// The API is versioned by path. /api/v1 serves results as they are stored,
+// a flat ProcessingResult; /api/v2 serves them by page, as resultV2. Only
+// the routes whose responses differ between versions are registered under
+// /api/v2, the others being served under /api/v1 alone, and v1 keeps its
+// schema while breaking changes ship under v2.
+
+// jobV2 is a job as version 2 of the API represents it: its result is by
+// page.
+type jobV2 struct {
+	*processor.ProcessingJob
+	Result *resultV2 `json:"result,omitempty"`
+}
+
+// resultV2 is a processing result by page: the text, entities, tables and
+// layout of each page are in its entry of Pages, and the artifacts are
+// grouped. Findings spanning pages, such as sections, items and the risk
+// analysis, stay at the top.
+type resultV2 struct {
+	PageCount        int                       `json:"page_count"`
+	FileSize         int64                     `json:"file_size"`
+	ProcessingTimeMs int64                     `json:"processing_time_ms"`
+	Pages            []pageV2                  `json:"pages"`
+	RiskAnalysis     processor.RiskAnalysis    `json:"risk_analysis"`
+	RelevanceScore   float64                   `json:"relevance_score"`
+	Relevance        *processor.RelevanceMatch `json:"relevance,omitempty"`
+	QualityMetrics   processor.QualityMetrics  `json:"quality_metrics"`
+	Classification   *classify.Result          `json:"classification,omitempty"`
+	Sections         []processor.Section       `json:"sections,omitempty"`
+	Items            []processor.BiddingItem   `json:"items,omitempty"`
+	Zones            []processor.ZoneText      `json:"zones,omitempty"`
+	Attachments      []processor.Attachment    `json:"attachments,omitempty"`
+	Signatures       []signature.Signature     `json:"signatures,omitempty"`
+	Artifacts        artifactsV2               `json:"artifacts"`
+	Metadata         map[string]interface{}    `json:"metadata"`
+	ContentSHA256    string                    `json:"content_sha256,omitempty"`
+
+	// Unpaged holds the text and findings of no known page
+	Unpaged *pageV2 `json:"unpaged,omitempty"`
+
+	// DeduplicatedFrom is the earlier job of an identical document the
+	// result was copied from, if any
+	DeduplicatedFrom string `json:"deduplicated_from,omitempty"`
+}
+
+// pageV2 is what a result holds of one page, numbered from 1.
+type pageV2 struct {
+	Number   int                         `json:"number,omitempty"`
+	Text     string                      `json:"text"`
+	Entities []processor.ExtractedEntity `json:"entities"`
+	Tables   []processor.ExtractedTable  `json:"tables,omitempty"`
+	Blocks   []processor.TextBlock       `json:"blocks,omitempty"`
+}
+
+// artifactsV2 are the files generated for a job.
+type artifactsV2 struct {
+	Report     *report.Artifact `json:"report,omitempty"`
+	Archive    *report.Artifact `json:"archive,omitempty"`
+	Searchable *report.Artifact `json:"searchable_pdf,omitempty"`
+	OCRLayout  *report.Artifact `json:"ocr_layout,omitempty"`
+	Redacted   *report.Artifact `json:"redacted_pdf,omitempty"`
+	Annotated  *report.Artifact `json:"annotated_pdf,omitempty"`
+}
+
+func newJobV2(job *processor.ProcessingJob) *jobV2 {
+	v2 := &jobV2{ProcessingJob: job}
+	if job.Result != nil {
+		v2.Result = newResultV2(job.Result)
+	}
+	return v2
+}
+
+// newResultV2 splits a result by page. The text of results stored without
+// page offsets, and the findings of no known page, go to Unpaged.
+func newResultV2(result *processor.ProcessingResult) *resultV2 {
+	v2 := &resultV2{
+		PageCount:        result.PageCount,
+		FileSize:         result.FileSize,
+		ProcessingTimeMs: result.ProcessingTime.Milliseconds(),
+		Pages:            make([]pageV2, max(result.PageCount, len(result.PageOffsets))),
+		RiskAnalysis:     result.RiskAnalysis,
+		RelevanceScore:   result.RelevanceScore,
+		Relevance:        result.Relevance,
+		QualityMetrics:   result.QualityMetrics,
+		Classification:   result.Classification,
+		Sections:         result.Sections,
+		Items:            result.Items,
+		Zones:            result.Zones,
+		Attachments:      result.Attachments,
+		Signatures:       result.Signatures,
+		Artifacts: artifactsV2{
+			Report:     result.Report,
+			Archive:    result.Archive,
+			Searchable: result.Searchable,
+			OCRLayout:  result.OCRLayout,
+			Redacted:   result.Redacted,
+			Annotated:  result.Annotated,
+		},
+		Metadata:         result.Metadata,
+		ContentSHA256:    result.ContentSHA256,
+		DeduplicatedFrom: result.DeduplicatedFrom,
+	}
+	for i := range v2.Pages {
+		v2.Pages[i] = pageV2{Number: i + 1, Entities: []processor.ExtractedEntity{}}
+	}
+
+	page := func(number int) *pageV2 {
+		if number >= 1 && number <= len(v2.Pages) {
+			return &v2.Pages[number-1]
+		}
+		if v2.Unpaged == nil {
+			v2.Unpaged = &pageV2{Entities: []processor.ExtractedEntity{}}
+		}
+		return v2.Unpaged
+	}
+
+	if len(result.PageOffsets) > 0 {
+		for i := range result.PageOffsets {
+			v2.Pages[i].Text = pageText(result.ExtractedText, result.PageOffsets, i)
+		}
+	} else if result.ExtractedText != "" {
+		page(0).Text = result.ExtractedText
+	}
+	for _, entity := range result.Entities {
+		p := page(entity.Page)
+		p.Entities = append(p.Entities, entity)
+	}
+	for _, table := range result.Tables {
+		p := page(table.Page)
+		p.Tables = append(p.Tables, table)
+	}
+	for _, block := range result.Blocks {
+		p := page(block.Page)
+		p.Blocks = append(p.Blocks, block)
+	}
+	return v2
+}
+
+// pageText returns the text of page i, from 0, of text whose pages start
+// at offsets.
+func pageText(text string, offsets []int, i int) string {
+	start, end := min(offsets[i], len(text)), len(text)
+	if i+1 < len(offsets) {
+		end = min(offsets[i+1], len(text))
+	}
+	return text[start:max(start, end)]
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// riskProfileRequest is the body accepted when creating or replacing a
+// tenant's risk weighting profile.
+type riskProfileRequest struct {
+	Name       string             `json:"name" binding:"required"`
+	Categories map[string]float64 `json:"categories"`
+	Rules      map[string]float64 `json:"rules"`
+}
+
+func (r riskProfileRequest) toProfile(tenantID string) *processor.RiskProfile {
+	return &processor.RiskProfile{
+		TenantID:   tenantID,
+		Name:       r.Name,
+		Categories: r.Categories,
+		Rules:      r.Rules,
+	}
+}
+
+func (h *Handler) listRiskProfiles(c *gin.Context) {
+	profiles, err := h.processor.ListRiskProfiles(c.Request.Context(), c.Param("tenant"))
+	if err != nil {
+		riskProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"profiles": profiles})
+}
+
+func (h *Handler) getRiskProfile(c *gin.Context) {
+	profile, err := h.processor.GetRiskProfile(c.Request.Context(), c.Param("tenant"), c.Param("id"))
+	if err != nil {
+		riskProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *Handler) createRiskProfile(c *gin.Context) {
+	var req riskProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile := req.toProfile(c.Param("tenant"))
+	if err := h.processor.CreateRiskProfile(c.Request.Context(), profile); err != nil {
+		riskProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, profile)
+}
+
+func (h *Handler) updateRiskProfile(c *gin.Context) {
+	var req riskProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile := req.toProfile(c.Param("tenant"))
+	profile.ID = c.Param("id")
+	if err := h.processor.UpdateRiskProfile(c.Request.Context(), profile); err != nil {
+		riskProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *Handler) deleteRiskProfile(c *gin.Context) {
+	if err := h.processor.DeleteRiskProfile(c.Request.Context(), c.Param("tenant"), c.Param("id")); err != nil {
+		riskProfileError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func riskProfileError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, processor.ErrProfileNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, processor.ErrInvalidProfile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Risk profile request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to access risk profiles"})
+	}
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"cotai-pdf-processor/internal/auth"
+	"cotai-pdf-processor/internal/processor"
+	"cotai-pdf-processor/internal/risk"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Risk rules are those of the caller's tenant. Operators choose the tenant
+// with the tenant_id query parameter; without it they manage the global
+// rules, applied to every tenant.
+
+type riskRuleRequest struct {
+	Name           string           `json:"name" binding:"required"`
+	Category       string           `json:"category"`
+	Severity       string           `json:"severity"`
+	Impact         string           `json:"impact"`
+	Weight         float64          `json:"weight"`
+	Conditions     []risk.Condition `json:"conditions" binding:"required"`
+	Recommendation string           `json:"recommendation"`
+	Enabled        *bool            `json:"enabled"`
+	Version        int              `json:"version"` // required on update
+}
+
+func (r riskRuleRequest) toRule(tenantID string) *risk.Rule {
+	enabled := r.Enabled == nil || *r.Enabled
+	return &risk.Rule{
+		TenantID:       tenantID,
+		Version:        r.Version,
+		Name:           r.Name,
+		Category:       r.Category,
+		Severity:       r.Severity,
+		Impact:         r.Impact,
+		Weight:         r.Weight,
+		Conditions:     r.Conditions,
+		Recommendation: r.Recommendation,
+		Enabled:        enabled,
+	}
+}
+
+type dryRunRequest struct {
+	JobID string `json:"job_id" binding:"required"`
+	// Rules to evaluate; when omitted the job tenant's current rules are used
+	Rules []risk.Rule `json:"rules"`
+}
+
+// riskRuleTenant returns the tenant whose rules a request manages, refusing
+// changes to the global rules but by operators.
+func (h *Handler) riskRuleTenant(c *gin.Context, change bool) (string, bool) {
+	principal := auth.FromContext(c.Request.Context())
+	if principal == nil || (principal.APIKeyID == "" && principal.HasRole(h.operatorRole())) {
+		return c.Query("tenant_id"), true
+	}
+	if change && principal.TenantID == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "global risk rules are managed by operators"})
+		return "", false
+	}
+	return principal.TenantID, true
+}
+
+func (h *Handler) listRiskRules(c *gin.Context) {
+	tenantID, _ := h.riskRuleTenant(c, false)
+	rules, err := h.riskRules.List(c.Request.Context(), tenantID)
+	if err != nil {
+		riskRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+func (h *Handler) getRiskRule(c *gin.Context) {
+	tenantID, _ := h.riskRuleTenant(c, false)
+	rule, err := h.riskRules.Get(c.Request.Context(), tenantID, c.Param("id"))
+	if err != nil {
+		riskRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *Handler) listRiskRuleVersions(c *gin.Context) {
+	tenantID, _ := h.riskRuleTenant(c, false)
+	versions, err := h.riskRules.Versions(c.Request.Context(), tenantID, c.Param("id"))
+	if err != nil {
+		riskRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"versions": versions})
+}
+
+func (h *Handler) createRiskRule(c *gin.Context) {
+	tenantID, ok := h.riskRuleTenant(c, true)
+	if !ok {
+		return
+	}
+	var req riskRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := req.toRule(tenantID)
+	if err := h.riskRules.Create(c.Request.Context(), rule); err != nil {
+		riskRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+func (h *Handler) updateRiskRule(c *gin.Context) {
+	tenantID, ok := h.riskRuleTenant(c, true)
+	if !ok {
+		return
+	}
+	var req riskRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "version is required"})
+		return
+	}
+
+	rule := req.toRule(tenantID)
+	rule.ID = c.Param("id")
+	if err := h.riskRules.Update(c.Request.Context(), rule); err != nil {
+		riskRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *Handler) deleteRiskRule(c *gin.Context) {
+	tenantID, ok := h.riskRuleTenant(c, true)
+	if !ok {
+		return
+	}
+	if err := h.riskRules.Delete(c.Request.Context(), tenantID, c.Param("id")); err != nil {
+		riskRuleError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// dryRunRiskRules evaluates rules against a processed job's text and returns
+// the analysis they would produce.
+func (h *Handler) dryRunRiskRules(c *gin.Context) {
+	var req dryRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	analysis, matches, err := h.processor.DryRunRiskRules(c.Request.Context(), req.JobID, req.Rules)
+	switch {
+	case errors.Is(err, processor.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, processor.ErrJobNotProcessed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		riskRuleError(c, err)
+		return
+	}
+
+	matched := make([]gin.H, 0, len(matches))
+	for _, match := range matches {
+		matched = append(matched, gin.H{
+			"rule_id":  match.Rule.ID,
+			"name":     match.Rule.Name,
+			"position": match.Position,
+			"end":      match.End,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"job_id":        req.JobID,
+		"risk_analysis": analysis,
+		"matches":       matched,
+	})
+}
+
+func riskRuleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, risk.ErrRuleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, risk.ErrInvalidRule):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, risk.ErrVersionConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Risk rule request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to access risk rules"})
+	}
+}
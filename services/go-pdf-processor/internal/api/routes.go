@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+	"time"
+
+	"cotai-pdf-processor/internal/auth"
+	"cotai-pdf-processor/internal/config"
+	"cotai-pdf-processor/internal/processor"
+	"cotai-pdf-processor/internal/ratelimit"
+	"cotai-pdf-processor/internal/risk"
+	"cotai-pdf-processor/internal/storage"
+	"cotai-pdf-processor/internal/upload"
+	"cotai-pdf-processor/internal/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	cfg        *config.Config
+	processor  *processor.PDFProcessor
+	workerPool *processor.WorkerPool
+	tus        *upload.TusStore
+	objects    *storage.ObjectStore
+	riskRules  *risk.Store
+	webhooks   *webhook.Dispatcher
+	apiKeys    *auth.KeyStore
+	limiter    *ratelimit.Limiter
+	openAPI    []byte
+}
+
+// SetupRoutes registers the API. riskRules may be nil when rules are not
+// kept in Postgres, which disables rule management.
+func SetupRoutes(router *gin.Engine, cfg *config.Config, pdfProcessor *processor.PDFProcessor, workerPool *processor.WorkerPool, riskRules *risk.Store, webhooks *webhook.Dispatcher, apiKeys *auth.KeyStore, limiter *ratelimit.Limiter) {
+	h := &Handler{
+		cfg:        cfg,
+		processor:  pdfProcessor,
+		workerPool: workerPool,
+		riskRules:  riskRules,
+		webhooks:   webhooks,
+		apiKeys:    apiKeys,
+		limiter:    limiter,
+	}
+
+	api := router.Group("/api", compressResponses(), h.authenticate(), h.rateLimit())
+
+	v1 := api.Group("/v1")
+	{
+		v1.GET("/openapi.json", h.getOpenAPI)
+		v1.POST("/documents", h.admitJobs, h.limitRequestSize(cfg.MaxFileSize+multipartOverhead), h.uploadDocument)
+		v1.GET("/jobs", h.listJobs)
+		v1.GET("/jobs/search", h.searchJobs)
+		v1.GET("/graphql", h.graphQL)
+		v1.POST("/graphql", h.graphQL)
+		v1.POST("/tenders", h.admitJobs, h.submitTender)
+		v1.GET("/tenders/:tender_id", h.getTender)
+		v1.GET("/tenders/:tender_id/jobs", h.listTenderJobs)
+		v1.POST("/jobs/status", h.getJobStatuses)
+		v1.GET("/jobs/:id", h.getJob)
+		v1.DELETE("/jobs/:id", h.cancelJob)
+		v1.GET("/jobs/:id/events", h.getJobEvents)
+		v1.GET("/jobs/:id/result/stream", h.streamJobResult)
+		v1.GET("/jobs/:id/export/:sheet", h.exportJob)
+		v1.GET("/jobs/:id/report", h.getJobReport)
+		v1.GET("/jobs/:id/archive", h.getJobArchive)
+		v1.GET("/jobs/:id/searchable", h.getJobSearchable)
+		v1.GET("/jobs/:id/ocr-layout", h.getJobOCRLayout)
+		v1.GET("/jobs/:id/redacted", h.getJobRedacted)
+		v1.GET("/jobs/:id/annotated", h.getJobAnnotated)
+		v1.GET("/jobs/:id/pages/:n/image", h.getPageImage)
+	}
+
+	// Routes whose responses changed in version 2; see result_v2.go
+	v2 := api.Group("/v2")
+	{
+		v2.GET("/jobs/:id", h.getJobV2)
+		v2.GET("/tenders/:tender_id", h.getTenderV2)
+	}
+
+	admin := v1.Group("/admin")
+	{
+		admin.GET("/workers", h.getWorkers)
+		admin.PATCH("/workers", h.resizeWorkers)
+		admin.GET("/workers/drain", h.getDrain)
+		admin.POST("/workers/drain", h.drainWorkers)
+		admin.DELETE("/workers/drain", h.resumeWorkers)
+		admin.GET("/instances", h.listInstances)
+		admin.GET("/analytics-exports", h.listAnalyticsExports)
+		admin.POST("/analytics-exports", h.startAnalyticsExport)
+		admin.GET("/analytics-exports/:id", h.getAnalyticsExport)
+		admin.GET("/billing-exports", h.listBillingExports)
+		admin.POST("/billing-exports", h.startBillingExport)
+		admin.GET("/billing-exports/:id", h.getBillingExport)
+		admin.GET("/tenants/:tenant/quota", h.getTenantQuota)
+		admin.PUT("/tenants/:tenant/quota", h.setTenantQuota)
+	}
+
+	v1.DELETE("/result-cache", h.clearResultCache)
+	v1.DELETE("/result-cache/:sha256", h.invalidateCachedResults)
+
+	deadLetters := v1.Group("/dead-letters")
+	{
+		deadLetters.GET("", h.listDeadLetters)
+		deadLetters.GET("/:id", h.getDeadLetter)
+		deadLetters.POST("/:id/replay", h.replayDeadLetter)
+		deadLetters.DELETE("/:id", h.deleteDeadLetter)
+	}
+
+	patterns := v1.Group("/tenants/:tenant/entity-patterns")
+	{
+		patterns.GET("", h.listEntityPatterns)
+		patterns.POST("", h.createEntityPattern)
+		patterns.GET("/:id", h.getEntityPattern)
+		patterns.PUT("/:id", h.updateEntityPattern)
+		patterns.DELETE("/:id", h.deleteEntityPattern)
+	}
+
+	profiles := v1.Group("/tenants/:tenant/risk-profiles")
+	{
+		profiles.GET("", h.listRiskProfiles)
+		profiles.POST("", h.createRiskProfile)
+		profiles.GET("/:id", h.getRiskProfile)
+		profiles.PUT("/:id", h.updateRiskProfile)
+		profiles.DELETE("/:id", h.deleteRiskProfile)
+	}
+
+	interests := v1.Group("/tenants/:tenant/interest-profiles")
+	{
+		interests.GET("", h.listInterestProfiles)
+		interests.POST("", h.createInterestProfile)
+		interests.GET("/:id", h.getInterestProfile)
+		interests.PUT("/:id", h.updateInterestProfile)
+		interests.DELETE("/:id", h.deleteInterestProfile)
+	}
+
+	presets := v1.Group("/tenants/:tenant/processing-profiles")
+	{
+		presets.GET("", h.listProcessingProfiles)
+		presets.POST("", h.createProcessingProfile)
+		presets.GET("/:id", h.getProcessingProfile)
+		presets.PUT("/:id", h.updateProcessingProfile)
+		presets.DELETE("/:id", h.deleteProcessingProfile)
+	}
+
+	pipelines := v1.Group("/tenants/:tenant/pipelines")
+	{
+		pipelines.GET("", h.listPipelines)
+		pipelines.POST("", h.createPipeline)
+		pipelines.GET("/:id", h.getPipeline)
+		pipelines.PUT("/:id", h.updatePipeline)
+		pipelines.DELETE("/:id", h.deletePipeline)
+	}
+
+	reprocessing := v1.Group("/tenants/:tenant/reprocessing-policies")
+	{
+		reprocessing.GET("", h.listReprocessingPolicies)
+		reprocessing.POST("", h.createReprocessingPolicy)
+		reprocessing.GET("/:id", h.getReprocessingPolicy)
+		reprocessing.PUT("/:id", h.updateReprocessingPolicy)
+		reprocessing.DELETE("/:id", h.deleteReprocessingPolicy)
+		reprocessing.POST("/:id/run", h.runReprocessingPolicy)
+	}
+
+	v1.GET("/jobs/:id/webhooks", h.listJobWebhooks)
+	v1.POST("/jobs/:id/webhooks/:delivery/redeliver", h.redeliverJobWebhook)
+
+	hooks := v1.Group("/tenants/:tenant/webhooks")
+	{
+		hooks.GET("", h.listWebhookEndpoints)
+		hooks.POST("", h.createWebhookEndpoint)
+		hooks.GET("/:id", h.getWebhookEndpoint)
+		hooks.PUT("/:id", h.updateWebhookEndpoint)
+		hooks.DELETE("/:id", h.deleteWebhookEndpoint)
+		hooks.POST("/:id/rotate-secret", h.rotateWebhookSecret)
+		hooks.GET("/:id/deliveries", h.listWebhookDeliveries)
+		hooks.POST("/:id/deliveries/:delivery/redeliver", h.redeliverWebhook)
+	}
+
+	v1.GET("/tenants/:tenant/usage", h.getTenantUsage)
+	v1.GET("/tenants/:tenant/usage/tenders", h.getTenderUsage)
+
+	keys := v1.Group("/tenants/:tenant/api-keys")
+	{
+		keys.GET("", h.listAPIKeys)
+		keys.POST("", h.issueAPIKey)
+		keys.GET("/:id", h.getAPIKey)
+		keys.DELETE("/:id", h.revokeAPIKey)
+		keys.POST("/:id/rotate", h.rotateAPIKey)
+		keys.GET("/:id/usage", h.getAPIKeyUsage)
+	}
+
+	v1.POST("/risk-rules/dry-run", h.dryRunRiskRules)
+	if riskRules == nil {
+		log.Printf("Risk rule management disabled: rules are not loaded from postgres")
+	} else {
+		rules := v1.Group("/risk-rules")
+		{
+			rules.GET("", h.listRiskRules)
+			rules.POST("", h.createRiskRule)
+			rules.GET("/:id", h.getRiskRule)
+			rules.PUT("/:id", h.updateRiskRule)
+			rules.DELETE("/:id", h.deleteRiskRule)
+			rules.GET("/:id/versions", h.listRiskRuleVersions)
+		}
+	}
+
+	tus, err := upload.NewTusStore(filepath.Join(cfg.UploadDir, "tus"), cfg.TusUploadExpiry)
+	if err != nil {
+		log.Printf("Resumable uploads disabled: %v", err)
+	} else {
+		h.tus = tus
+		tus.StartCleanup(context.Background(), time.Hour)
+
+		uploads := v1.Group("/uploads", tusResumable)
+		{
+			uploads.OPTIONS("", h.tusOptions)
+			uploads.POST("", h.admitJobs, h.tusCreate)
+			uploads.HEAD("/:id", h.tusHead)
+			uploads.PATCH("/:id", h.tusPatch)
+			uploads.DELETE("/:id", h.tusDelete)
+		}
+	}
+
+	objects, err := storage.NewObjectStore(storage.ObjectStoreConfig{
+		Endpoint:  cfg.ObjectStoreEndpoint,
+		Region:    cfg.ObjectStoreRegion,
+		AccessKey: cfg.ObjectStoreAccessKey,
+		SecretKey: cfg.ObjectStoreSecretKey,
+		UseSSL:    cfg.ObjectStoreUseSSL,
+	})
+	if err != nil {
+		log.Printf("Presigned uploads disabled: %v", err)
+	} else {
+		h.objects = objects
+
+		v1.POST("/presigned-uploads", h.createPresignedUpload)
+		v1.POST("/presigned-uploads/:id/complete", h.completePresignedUpload)
+		v1.POST("/presigned-uploads/notifications", h.handleBucketNotification)
+	}
+
+	h.openAPI, err = openAPIFor(router.Routes())
+	if err != nil {
+		log.Printf("OpenAPI document disabled: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"cotai-pdf-processor/internal/download"
+	"cotai-pdf-processor/internal/processor"
+	"cotai-pdf-processor/internal/webhook"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// tenderRequest submits the files of a tender to be processed as one job.
+type tenderRequest struct {
+	TenderID          string                 `json:"tender_id" binding:"required"`
+	TenantID          string                 `json:"tenant_id"`
+	UserID            string                 `json:"user_id"`
+	Files             []processor.TenderFile `json:"files" binding:"required"`
+	InterestProfileID string                 `json:"interest_profile_id"`
+	Priority          string                 `json:"priority"`
+	Profile           string                 `json:"profile"`
+	Options           json.RawMessage        `json:"options"`
+	CallbackURL       string                 `json:"callback_url"`
+	RunAt             string                 `json:"run_at"`
+}
+
+func (h *Handler) submitTender(c *gin.Context) {
+	var req tenderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.claimIdentity(c, &req.UserID, &req.TenantID) || !h.checkQuota(c, req.TenantID, 0) {
+		return
+	}
+	if err := processor.ValidatePriority(req.Priority); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := webhook.ValidateURL(c.Request.Context(), req.CallbackURL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Clients may only point at documents on the internet; local files
+	// and buckets are the service's
+	for _, file := range req.Files {
+		if err := download.ValidateURL(c.Request.Context(), file.URL, h.cfg.DownloadAllowPrivateNetworks); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	runAt, err := processor.ParseRunAt(req.RunAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tender := newJob(uuid.New().String())
+	tender.TenderID = req.TenderID
+	tender.TenantID = req.TenantID
+	tender.UserID = req.UserID
+	tender.Priority = req.Priority
+	tender.CallbackURL = req.CallbackURL
+	tender.RunAt = runAt
+	if req.InterestProfileID != "" {
+		tender.Metadata["interest_profile_id"] = req.InterestProfileID
+	}
+	if !h.resolveOptions(c, tender, req.Profile, req.Options) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	files, err := h.processor.CreateTenderJob(ctx, tender, req.Files)
+	if errors.Is(err, processor.ErrInvalidTender) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to create tender job for tender %s: %v", req.TenderID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create tender job"})
+		return
+	}
+
+	fileJobIDs := make([]string, len(files))
+	for i, file := range files {
+		fileJobIDs[i] = file.ID
+		if err := h.workerPool.SubmitJob(file); err != nil {
+			// Files queued already are cancelled with the tender job
+			if _, cancelErr := h.processor.CancelJob(ctx, tender.ID); cancelErr != nil {
+				log.Printf("Failed to cancel tender job %s: %v", tender.ID, cancelErr)
+			}
+			if errors.Is(err, processor.ErrResourcesExhausted) {
+				h.overloaded(c, err)
+				return
+			}
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id":       tender.ID,
+		"tender_id":    tender.TenderID,
+		"status":       tender.Status,
+		"file_job_ids": fileJobIDs,
+	})
+}
+
+// getTender returns the latest tender job of a tender.
+func (h *Handler) getTender(c *gin.Context) {
+	if job := h.loadTenderJob(c); job != nil {
+		respondTagged(c, job)
+	}
+}
+
+// getTenderV2 returns the latest tender job of a tender with its result
+// by page.
+func (h *Handler) getTenderV2(c *gin.Context) {
+	if job := h.loadTenderJob(c); job != nil {
+		respondTagged(c, newJobV2(job))
+	}
+}
+
+// loadTenderJob loads the latest tender job of the request's tender, or
+// responds with an error and returns nil.
+func (h *Handler) loadTenderJob(c *gin.Context) *processor.ProcessingJob {
+	job, err := h.processor.GetTenderJob(c.Request.Context(), h.requestTenant(c), c.Param("tender_id"))
+	if errors.Is(err, processor.ErrJobNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil
+	}
+	if err != nil {
+		log.Printf("Failed to load tender job of tender %s: %v", c.Param("tender_id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load tender job"})
+		return nil
+	}
+	return job
+}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"cotai-pdf-processor/internal/processor"
+	"cotai-pdf-processor/internal/upload"
+	"cotai-pdf-processor/internal/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Resumable uploads implement the tus 1.0.0 core protocol with the
+// creation, expiration and termination extensions. Once the last byte
+// arrives the upload becomes a processing job whose ID is the upload ID.
+// Recognized Upload-Metadata keys: filename, filetype, tender_id, user_id,
+// tenant_id, interest_profile_id, profile, options (JSON), callback_url,
+// run_at (RFC 3339) and depends_on (comma-separated job IDs).
+
+const tusVersion = "1.0.0"
+
+func tusResumable(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+
+	if c.Request.Method != http.MethodOptions && c.GetHeader("Tus-Resumable") != tusVersion {
+		c.Header("Tus-Version", tusVersion)
+		c.AbortWithStatus(http.StatusPreconditionFailed)
+		return
+	}
+	c.Next()
+}
+
+func (h *Handler) tusOptions(c *gin.Context) {
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", "creation,expiration,termination")
+	c.Header("Tus-Max-Size", strconv.FormatInt(h.cfg.MaxFileSize, 10))
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) tusCreate(c *gin.Context) {
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing or invalid Upload-Length"})
+		return
+	}
+	if length > h.cfg.MaxFileSize {
+		h.fileTooLarge(c)
+		return
+	}
+
+	metadata, err := parseTusMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, tenantID := metadata["user_id"], metadata["tenant_id"]
+	if !h.claimIdentity(c, &userID, &tenantID) || !h.checkQuota(c, tenantID, length) {
+		return
+	}
+	metadata["user_id"], metadata["tenant_id"] = userID, tenantID
+
+	contentType := metadata["filetype"]
+	if contentType == "" {
+		contentType = contentTypeFromName(metadata["filename"])
+	}
+	if !h.isAllowedType(contentType) {
+		h.unsupportedType(c, contentType)
+		return
+	}
+	metadata["filetype"] = contentType
+	if err := webhook.ValidateURL(c.Request.Context(), metadata["callback_url"]); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := processor.ParseRunAt(metadata["run_at"]); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.validateDependencies(c, splitJobIDs(metadata["depends_on"])) {
+		return
+	}
+
+	// Reject an unknown profile or bad options before any bytes are sent
+	probe := newJob("")
+	probe.TenantID = metadata["tenant_id"]
+	if !h.resolveOptions(c, probe, metadata["profile"], []byte(metadata["options"])) {
+		return
+	}
+
+	up, err := h.tus.Create(length, userID, tenantID, metadata)
+	if err != nil {
+		log.Printf("Failed to create tus upload: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create upload"})
+		return
+	}
+
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+up.ID)
+	c.Header("Upload-Expires", up.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusCreated)
+}
+
+// tusUpload returns the upload of the request's ID, aborting the request
+// unless it is found and the caller may see it: an upload is only resumed
+// by the user and tenant creating it, as their jobs are only read by them.
+// Expired uploads are returned too if expired is set.
+func (h *Handler) tusUpload(c *gin.Context, expired bool) (*upload.TusUpload, bool) {
+	up, err := h.tus.Get(c.Param("id"))
+	if up != nil && !processor.AccessAllows(c.Request.Context(), up.TenantID, up.UserID) {
+		// Told apart from missing uploads, others' would be known to exist
+		tusError(c, upload.ErrUploadNotFound)
+		return nil, false
+	}
+	if err != nil && !(expired && errors.Is(err, upload.ErrUploadExpired)) {
+		tusError(c, err)
+		return nil, false
+	}
+	return up, true
+}
+
+func (h *Handler) tusHead(c *gin.Context) {
+	up, ok := h.tusUpload(c, false)
+	if !ok {
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Offset", strconv.FormatInt(up.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(up.Length, 10))
+	c.Header("Upload-Expires", up.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) tusPatch(c *gin.Context) {
+	if c.GetHeader("Content-Type") != "application/offset+octet-stream" {
+		c.AbortWithStatus(http.StatusUnsupportedMediaType)
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing or invalid Upload-Offset"})
+		return
+	}
+
+	if _, ok := h.tusUpload(c, false); !ok {
+		return
+	}
+
+	up, err := h.tus.WriteChunk(c.Param("id"), offset, c.Request.Body)
+	if err != nil {
+		if up != nil && !errors.Is(err, upload.ErrOffsetMismatch) {
+			// Partial chunk was stored; the client resumes from HEAD.
+			log.Printf("tus upload %s interrupted at offset %d: %v", up.ID, up.Offset, err)
+		}
+		tusError(c, err)
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(up.Offset, 10))
+	c.Header("Upload-Expires", up.ExpiresAt.UTC().Format(http.TimeFormat))
+
+	if up.Complete() && !h.finalizeTusUpload(c, up) {
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) tusDelete(c *gin.Context) {
+	if _, ok := h.tusUpload(c, true); !ok {
+		return
+	}
+	if err := h.tus.Delete(c.Param("id")); err != nil {
+		tusError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// finalizeTusUpload turns a completed upload into a queued processing job.
+func (h *Handler) finalizeTusUpload(c *gin.Context, up *upload.TusUpload) bool {
+	job := newJob(up.ID)
+	job.TenderID = up.Metadata["tender_id"]
+	job.UserID = up.UserID
+	job.TenantID = up.TenantID
+	job.CallbackURL = up.Metadata["callback_url"]
+	// Checked when the upload was created, though it may have passed since
+	job.RunAt, _ = processor.ParseRunAt(up.Metadata["run_at"])
+	job.DependsOn = splitJobIDs(up.Metadata["depends_on"])
+	if id := up.Metadata["interest_profile_id"]; id != "" {
+		job.Metadata["interest_profile_id"] = id
+	}
+	job.Metadata["original_filename"] = up.Metadata["filename"]
+	job.Metadata["content_type"] = up.Metadata["filetype"]
+	job.Metadata["file_size"] = up.Length
+
+	if !h.resolveOptions(c, job, up.Metadata["profile"], []byte(up.Metadata["options"])) {
+		return false
+	}
+
+	dest := filepath.Join(h.cfg.UploadDir, up.ID+strings.ToLower(filepath.Ext(up.Metadata["filename"])))
+	if err := h.tus.Finalize(up.ID, dest); err != nil {
+		log.Printf("Failed to finalize tus upload %s: %v", up.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to finalize upload"})
+		return false
+	}
+	job.FileURL = dest
+
+	if sniffed, err := processor.VerifyContent(dest, up.Metadata["filetype"]); err != nil {
+		removeUpload(dest)
+		h.unsupportedType(c, sniffed)
+		return false
+	}
+
+	if !h.enqueueJob(c, job) {
+		removeUpload(dest)
+		return false
+	}
+
+	c.Header("X-Job-Id", job.ID)
+	return true
+}
+
+// parseTusMetadata decodes an Upload-Metadata header: comma-separated
+// "key base64value" pairs.
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if header == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Upload-Metadata value for %q", key)
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+func tusError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, upload.ErrUploadNotFound):
+		c.AbortWithStatus(http.StatusNotFound)
+	case errors.Is(err, upload.ErrUploadExpired):
+		c.AbortWithStatus(http.StatusGone)
+	case errors.Is(err, upload.ErrOffsetMismatch):
+		c.AbortWithStatus(http.StatusConflict)
+	case errors.Is(err, upload.ErrUploadLocked):
+		c.AbortWithStatus(http.StatusLocked)
+	default:
+		log.Printf("tus upload error: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
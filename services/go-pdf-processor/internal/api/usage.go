@@ -0,0 +1,100 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getTenantUsage reports what a tenant processed in a month, the current
+// one unless month is given as YYYY-MM, against its quota; for billing to
+// be reconciled with.
+func (h *Handler) getTenantUsage(c *gin.Context) {
+	month, ok := usageMonth(c)
+	if !ok {
+		return
+	}
+
+	usage, err := h.processor.TenantUsage(c.Request.Context(), c.Param("tenant"), month)
+	if err != nil {
+		quotaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
+
+// getTenderUsage reports what a tenant processed for each tender in a
+// month, as getTenantUsage does, for costs to be attributed to tenders.
+func (h *Handler) getTenderUsage(c *gin.Context) {
+	month, ok := usageMonth(c)
+	if !ok {
+		return
+	}
+
+	tenders, err := h.processor.TenderUsage(c.Request.Context(), c.Param("tenant"), month)
+	if err != nil {
+		quotaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"tenant_id": c.Param("tenant"),
+		"month":     processor.MonthOf(month).Format("2006-01"),
+		"tenders":   tenders,
+	})
+}
+
+// usageMonth returns the month asked for as YYYY-MM, the current one by
+// default, responding with an error when it is not valid.
+func usageMonth(c *gin.Context) (time.Time, bool) {
+	value := c.Query("month")
+	if value == "" {
+		return time.Now(), true
+	}
+	month, err := time.Parse("2006-01", value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "month must be given as YYYY-MM"})
+		return time.Time{}, false
+	}
+	return month, true
+}
+
+func (h *Handler) getTenantQuota(c *gin.Context) {
+	quota, err := h.processor.GetTenantQuota(c.Request.Context(), c.Param("tenant"))
+	if err != nil {
+		quotaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, quota)
+}
+
+// setTenantQuota replaces the quota of a tenant; fields left out, or
+// null, fall back to the service's defaults.
+func (h *Handler) setTenantQuota(c *gin.Context) {
+	var quota processor.TenantQuota
+	if err := c.ShouldBindJSON(&quota); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quota.TenantID = c.Param("tenant")
+	if err := h.processor.SetTenantQuota(c.Request.Context(), &quota); err != nil {
+		quotaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, quota)
+}
+
+func quotaError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, processor.ErrInvalidQuota):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Quota request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to access usage"})
+	}
+}
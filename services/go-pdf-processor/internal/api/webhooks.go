@@ -0,0 +1,190 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"cotai-pdf-processor/internal/processor"
+	"cotai-pdf-processor/internal/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+type webhookEndpointRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Description string   `json:"description"`
+	Events      []string `json:"events"` // all events when empty
+	Enabled     *bool    `json:"enabled"`
+}
+
+func (r webhookEndpointRequest) toEndpoint(tenantID string) *webhook.Endpoint {
+	return &webhook.Endpoint{
+		TenantID:    tenantID,
+		URL:         r.URL,
+		Description: r.Description,
+		Events:      r.Events,
+		Enabled:     r.Enabled == nil || *r.Enabled,
+	}
+}
+
+func (h *Handler) listWebhookEndpoints(c *gin.Context) {
+	endpoints, err := h.webhooks.ListEndpoints(c.Request.Context(), c.Param("tenant"))
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"endpoints": endpoints})
+}
+
+// createWebhookEndpoint registers an endpoint; its secret is only shown in
+// this response.
+func (h *Handler) createWebhookEndpoint(c *gin.Context) {
+	var req webhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	endpoint := req.toEndpoint(c.Param("tenant"))
+	if err := h.webhooks.CreateEndpoint(c.Request.Context(), endpoint); err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, endpoint)
+}
+
+func (h *Handler) getWebhookEndpoint(c *gin.Context) {
+	endpoint, err := h.webhooks.GetEndpoint(c.Request.Context(), c.Param("tenant"), c.Param("id"))
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, endpoint)
+}
+
+func (h *Handler) updateWebhookEndpoint(c *gin.Context) {
+	var req webhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	endpoint := req.toEndpoint(c.Param("tenant"))
+	endpoint.ID = c.Param("id")
+	if err := h.webhooks.UpdateEndpoint(c.Request.Context(), endpoint); err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, endpoint)
+}
+
+func (h *Handler) deleteWebhookEndpoint(c *gin.Context) {
+	if err := h.webhooks.DeleteEndpoint(c.Request.Context(), c.Param("tenant"), c.Param("id")); err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// rotateWebhookSecret gives an endpoint a new secret, shown in the
+// response; the previous one keeps signing for WEBHOOK_SECRET_GRACE.
+func (h *Handler) rotateWebhookSecret(c *gin.Context) {
+	endpoint, err := h.webhooks.RotateSecret(c.Request.Context(), c.Param("tenant"), c.Param("id"))
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// listWebhookDeliveries pages through the delivery log of an endpoint,
+// latest first.
+func (h *Handler) listWebhookDeliveries(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultDeliveryLimit)))
+	if err != nil || limit < 1 || limit > maxDeliveryLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxDeliveryLimit)})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, err := h.webhooks.GetEndpoint(ctx, c.Param("tenant"), c.Param("id")); err != nil {
+		webhookError(c, err)
+		return
+	}
+	deliveries, total, err := h.webhooks.EndpointDeliveries(ctx, c.Param("tenant"), c.Param("id"), offset, limit)
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries, "total": total})
+}
+
+// redeliverWebhook queues a logged delivery of an endpoint again.
+func (h *Handler) redeliverWebhook(c *gin.Context) {
+	delivery, err := h.webhooks.GetDelivery(c.Request.Context(), c.Param("delivery"))
+	if err == nil && (delivery.TenantID != c.Param("tenant") || delivery.EndpointID != c.Param("id")) {
+		err = webhook.ErrDeliveryNotFound
+	}
+	h.redeliver(c, delivery, err)
+}
+
+// listJobWebhooks reports the webhook deliveries of a job, to endpoints
+// and to its callback URL, and how each went.
+func (h *Handler) listJobWebhooks(c *gin.Context) {
+	ctx := c.Request.Context()
+	if _, err := h.processor.GetJob(ctx, c.Param("id")); err != nil {
+		webhookError(c, err)
+		return
+	}
+
+	deliveries, err := h.webhooks.JobDeliveries(ctx, c.Param("id"))
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// redeliverJobWebhook queues a logged delivery of a job again.
+func (h *Handler) redeliverJobWebhook(c *gin.Context) {
+	delivery, err := h.webhooks.GetDelivery(c.Request.Context(), c.Param("delivery"))
+	if err == nil && delivery.JobID != c.Param("id") {
+		err = webhook.ErrDeliveryNotFound
+	}
+	h.redeliver(c, delivery, err)
+}
+
+func (h *Handler) redeliver(c *gin.Context, delivery *webhook.Delivery, err error) {
+	if err == nil {
+		delivery, err = h.webhooks.Redeliver(c.Request.Context(), delivery)
+	}
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+func webhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, processor.ErrJobNotFound), errors.Is(err, webhook.ErrEndpointNotFound), errors.Is(err, webhook.ErrDeliveryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, webhook.ErrInvalidEndpoint):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Webhook request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to access webhooks"})
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"cotai-pdf-processor/internal/processor"
+	"cotai-pdf-processor/internal/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+type resizeWorkersRequest struct {
+	Workers int `json:"workers" binding:"required"`
+}
+
+func (h *Handler) getWorkers(c *gin.Context) {
+	c.JSON(http.StatusOK, h.workerPool.GetStats())
+}
+
+// listInstances lists the replicas sharing the job queue, dead ones whose
+// jobs are taken over included.
+func (h *Handler) listInstances(c *gin.Context) {
+	instances, err := h.workerPool.Instances(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if instances == nil {
+		instances = []queue.Instance{}
+	}
+	c.JSON(http.StatusOK, gin.H{"instance_id": h.cfg.InstanceID, "instances": instances})
+}
+
+func (h *Handler) getDrain(c *gin.Context) {
+	c.JSON(http.StatusOK, h.workerPool.DrainProgress())
+}
+
+// drainWorkers stops this replica taking jobs and lets its running jobs
+// finish, before a deployment replaces it. GET reports once it has
+// drained.
+func (h *Handler) drainWorkers(c *gin.Context) {
+	if err := h.workerPool.Drain(); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, h.workerPool.DrainProgress())
+}
+
+// resumeWorkers ends a drain, taking jobs again.
+func (h *Handler) resumeWorkers(c *gin.Context) {
+	if err := h.workerPool.Resume(); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, h.workerPool.DrainProgress())
+}
+
+// resizeWorkers changes the number of workers of this replica without
+// restarting it.
+func (h *Handler) resizeWorkers(c *gin.Context) {
+	var req resizeWorkersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.workerPool.Resize(req.Workers); err != nil {
+		if errors.Is(err, processor.ErrInvalidWorkerCount) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("workers must be between 1 and %d", h.cfg.MaxWorkerCount)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, h.workerPool.GetStats())
+}
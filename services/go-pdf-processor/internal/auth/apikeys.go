@@ -0,0 +1,473 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"cotai-pdf-processor/internal/storage"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Scopes an API key may be given. Admin keys may do whatever the others
+// may, and manage the keys of their tenant.
+const (
+	ScopeSubmit = "submit"
+	ScopeRead   = "read"
+	ScopeAdmin  = "admin"
+)
+
+var scopes = []string{ScopeSubmit, ScopeRead, ScopeAdmin}
+
+var (
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	ErrAPIKeyRevoked  = errors.New("API key is revoked")
+	ErrInvalidAPIKey  = errors.New("invalid API key")
+)
+
+// apiKeyPrefix starts every key, so keys are told apart from tokens and
+// found by secret scanners.
+const apiKeyPrefix = "cpk_"
+
+// displayPrefixLength is how much of a key is kept in the clear, for
+// users to tell their keys apart.
+const displayPrefixLength = 12
+
+// keyCacheTTL is how long a verified key is trusted without reading it
+// again, so a key revoked on another replica works for as long.
+const keyCacheTTL = 30 * time.Second
+
+// usageFlushInterval is how often the requests counted are written.
+const usageFlushInterval = time.Minute
+
+// APIKey is a key a tenant's programs call the API with, restricted to
+// its scopes.
+type APIKey struct {
+	ID        string     `json:"id"`
+	TenantID  string     `json:"tenant_id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Scopes    []string   `json:"scopes"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// Key is only shown when the key is issued and when it is rotated;
+	// only its digest is stored
+	Key       string     `json:"key,omitempty"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty"`
+
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// validate normalizes the key's fields; no scopes means read only.
+func (k *APIKey) validate() error {
+	k.Name = strings.TrimSpace(k.Name)
+	if k.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidAPIKey)
+	}
+	if k.ExpiresAt != nil && !k.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("%w: expires_at is in the past", ErrInvalidAPIKey)
+	}
+
+	if len(k.Scopes) == 0 {
+		k.Scopes = []string{ScopeRead}
+	}
+	granted := make([]string, 0, len(k.Scopes))
+	for _, scope := range k.Scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !slices.Contains(scopes, scope) {
+			return fmt.Errorf("%w: unknown scope %q, expected one of %s", ErrInvalidAPIKey, scope, strings.Join(scopes, ", "))
+		}
+		if !slices.Contains(granted, scope) {
+			granted = append(granted, scope)
+		}
+	}
+	k.Scopes = granted
+	return nil
+}
+
+// APIKeyUsage is what a key was used for on a day.
+type APIKeyUsage struct {
+	Day         string `json:"day"`
+	Requests    int64  `json:"requests"`
+	Submissions int64  `json:"submissions"`
+}
+
+// IsAPIKey reports whether a credential is an API key rather than a token.
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, apiKeyPrefix)
+}
+
+func newAPIKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+type cachedAPIKey struct {
+	key      *APIKey
+	cachedAt time.Time
+}
+
+type usageDay struct {
+	keyID string
+	day   string
+}
+
+type usageCount struct {
+	requests    int64
+	submissions int64
+	lastUsedAt  time.Time
+}
+
+// KeyStore keeps the API keys of tenants in Postgres, by the digests of
+// the keys, and counts the requests made with them.
+type KeyStore struct {
+	postgres *storage.PostgresClient
+	grace    time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedAPIKey
+	usage map[usageDay]*usageCount
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewKeyStore returns a store whose rotated keys still authenticate for
+// grace.
+func NewKeyStore(postgres *storage.PostgresClient, grace time.Duration) *KeyStore {
+	return &KeyStore{
+		postgres: postgres,
+		grace:    grace,
+		cache:    make(map[string]cachedAPIKey),
+		usage:    make(map[usageDay]*usageCount),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start writes the usage counted every usageFlushInterval.
+func (s *KeyStore) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(usageFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.flushUsage(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops counting usage, writing what was counted.
+func (s *KeyStore) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+	s.flushUsage(context.Background())
+}
+
+const apiKeyColumns = `id, tenant_id, name, prefix, scopes, created_by, expires_at, rotated_at,
+	revoked_at, last_used_at, created_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row rowScanner) (*APIKey, error) {
+	var k APIKey
+	err := row.Scan(&k.ID, &k.TenantID, &k.Name, &k.Prefix, pq.Array(&k.Scopes), &k.CreatedBy, &k.ExpiresAt,
+		&k.RotatedAt, &k.RevokedAt, &k.LastUsedAt, &k.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// List returns a tenant's keys, revoked ones included, oldest first.
+func (s *KeyStore) List(ctx context.Context, tenantID string) ([]APIKey, error) {
+	rows, err := s.postgres.Query(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE tenant_id = $1 ORDER BY created_at`,
+		tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read API key: %w", err)
+		}
+		keys = append(keys, *k)
+	}
+	return keys, rows.Err()
+}
+
+func (s *KeyStore) Get(ctx context.Context, tenantID, id string) (*APIKey, error) {
+	return scanAPIKey(s.postgres.QueryRow(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE tenant_id = $1 AND id = $2`,
+		tenantID, id))
+}
+
+// Issue saves a new key, returned in k.Key.
+func (s *KeyStore) Issue(ctx context.Context, k *APIKey) error {
+	if err := k.validate(); err != nil {
+		return err
+	}
+	key, err := newAPIKey()
+	if err != nil {
+		return err
+	}
+
+	k.ID = uuid.New().String()
+	k.Key = key
+	k.Prefix = key[:displayPrefixLength]
+	k.CreatedAt = time.Now()
+
+	err = s.postgres.Exec(ctx, `
+		INSERT INTO api_keys (id, tenant_id, name, prefix, key_hash, scopes, created_by, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, k.ID, k.TenantID, k.Name, k.Prefix, hashAPIKey(key), pq.Array(k.Scopes), k.CreatedBy, k.ExpiresAt, k.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to issue API key: %w", err)
+	}
+	return nil
+}
+
+// Rotate gives a key a new secret, returned in the key. The previous one
+// still authenticates for the store's grace period, or until the next
+// rotation.
+func (s *KeyStore) Rotate(ctx context.Context, tenantID, id string) (*APIKey, error) {
+	key, err := newAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	k, err := scanAPIKey(s.postgres.QueryRow(ctx, `
+		UPDATE api_keys
+		SET previous_key_hash = key_hash, previous_key_expires_at = $5, key_hash = $3, prefix = $4, rotated_at = $6
+		WHERE tenant_id = $1 AND id = $2 AND revoked_at IS NULL
+		RETURNING `+apiKeyColumns,
+		tenantID, id, hashAPIKey(key), key[:displayPrefixLength], now.Add(s.grace), now))
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		if _, err := s.Get(ctx, tenantID, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrAPIKeyRevoked
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate API key: %w", err)
+	}
+
+	s.forget(id)
+	k.Key = key
+	return k, nil
+}
+
+// Revoke stops a key from authenticating; it is kept, with its usage.
+func (s *KeyStore) Revoke(ctx context.Context, tenantID, id string) (*APIKey, error) {
+	k, err := scanAPIKey(s.postgres.QueryRow(ctx, `
+		UPDATE api_keys
+		SET revoked_at = COALESCE(revoked_at, $3), previous_key_hash = NULL, previous_key_expires_at = NULL
+		WHERE tenant_id = $1 AND id = $2
+		RETURNING `+apiKeyColumns,
+		tenantID, id, time.Now()))
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	s.forget(id)
+	return k, nil
+}
+
+// Usage returns what a key was used for by day over the last days, latest
+// first. Requests are counted in memory and written every minute.
+func (s *KeyStore) Usage(ctx context.Context, tenantID, id string, days int) ([]APIKeyUsage, error) {
+	if _, err := s.Get(ctx, tenantID, id); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.postgres.Query(ctx, `
+		SELECT to_char(day, 'YYYY-MM-DD'), requests, submissions
+		FROM api_key_usage
+		WHERE key_id = $1 AND day > current_date - $2::int
+		ORDER BY day DESC
+	`, id, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API key usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := []APIKeyUsage{}
+	for rows.Next() {
+		var u APIKeyUsage
+		if err := rows.Scan(&u.Day, &u.Requests, &u.Submissions); err != nil {
+			return nil, fmt.Errorf("failed to read API key usage: %w", err)
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+// Authenticate returns the principal of a key: the key itself, of its
+// tenant and with its scopes. Errors wrap ErrInvalidToken for keys that
+// are unknown, revoked or expired.
+func (s *KeyStore) Authenticate(ctx context.Context, key string) (*Principal, error) {
+	k, err := s.lookup(ctx, hashAPIKey(key))
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return nil, fmt.Errorf("%w: unknown API key", ErrInvalidToken)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API key: %w", err)
+	}
+	if k.RevokedAt != nil {
+		return nil, fmt.Errorf("%w: API key is revoked", ErrInvalidToken)
+	}
+	if k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt) {
+		return nil, fmt.Errorf("%w: API key expired", ErrInvalidToken)
+	}
+
+	return &Principal{
+		Subject:  "api-key:" + k.ID,
+		UserID:   "api-key:" + k.ID,
+		TenantID: k.TenantID,
+		APIKeyID: k.ID,
+		Scopes:   k.Scopes,
+	}, nil
+}
+
+// lookup returns the key of a digest, current or still in its grace
+// period after a rotation, from the cache when read lately.
+func (s *KeyStore) lookup(ctx context.Context, hash string) (*APIKey, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[hash]
+	s.mu.Unlock()
+	if ok && time.Since(cached.cachedAt) < keyCacheTTL {
+		return cached.key, nil
+	}
+
+	k, err := scanAPIKey(s.postgres.QueryRow(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE key_hash = $1 OR (previous_key_hash = $1 AND previous_key_expires_at > now())
+	`, hash))
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[hash] = cachedAPIKey{key: k, cachedAt: time.Now()}
+	s.mu.Unlock()
+	return k, nil
+}
+
+// forget drops a key from the cache, under any of its digests.
+func (s *KeyStore) forget(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, cached := range s.cache {
+		if cached.key.ID == id {
+			delete(s.cache, hash)
+		}
+	}
+}
+
+// RecordUsage counts a request made with a key, and whether it submitted
+// a job.
+func (s *KeyStore) RecordUsage(keyID string, submission bool) {
+	now := time.Now().UTC()
+	day := usageDay{keyID: keyID, day: now.Format("2006-01-02")}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := s.usage[day]
+	if count == nil {
+		count = &usageCount{}
+		s.usage[day] = count
+	}
+	count.requests++
+	if submission {
+		count.submissions++
+	}
+	count.lastUsedAt = now
+}
+
+// flushUsage adds the usage counted to the stored one. Counts that could
+// not be written are kept for the next flush.
+func (s *KeyStore) flushUsage(ctx context.Context) {
+	s.mu.Lock()
+	usage := s.usage
+	s.usage = make(map[usageDay]*usageCount)
+	s.mu.Unlock()
+
+	for day, count := range usage {
+		err := s.postgres.Exec(ctx, `
+			INSERT INTO api_key_usage (key_id, day, requests, submissions)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (key_id, day) DO UPDATE
+			SET requests = api_key_usage.requests + EXCLUDED.requests,
+				submissions = api_key_usage.submissions + EXCLUDED.submissions
+		`, day.keyID, day.day, count.requests, count.submissions)
+		if err != nil {
+			log.Printf("Failed to record usage of API key %s: %v", day.keyID, err)
+			s.restoreUsage(day, count)
+			continue
+		}
+
+		err = s.postgres.Exec(ctx,
+			`UPDATE api_keys SET last_used_at = GREATEST(last_used_at, $2) WHERE id = $1`,
+			day.keyID, count.lastUsedAt)
+		if err != nil {
+			log.Printf("Failed to record last use of API key %s: %v", day.keyID, err)
+		}
+	}
+}
+
+func (s *KeyStore) restoreUsage(day usageDay, count *usageCount) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current := s.usage[day]; current != nil {
+		current.requests += count.requests
+		current.submissions += count.submissions
+		return
+	}
+	s.usage[day] = count
+}
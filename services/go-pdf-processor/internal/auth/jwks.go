@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrKeysUnavailable is returned when the key set could not be fetched
+// and no keys are known, so no token can be verified.
+var ErrKeysUnavailable = errors.New("signing keys of the identity provider are unavailable")
+
+// keyRefetchInterval bounds how often a token signed with an unknown key
+// makes the key set be fetched again, for keys the provider rotated in.
+const keyRefetchInterval = 30 * time.Second
+
+// maxKeySetSize bounds the JWKS documents read.
+const maxKeySetSize = 1 << 20
+
+// publicKey is a signing key of the key set, and the algorithm it is
+// restricted to when the key says so.
+type publicKey struct {
+	alg string
+	key crypto.PublicKey
+}
+
+// KeySet is the JSON Web Key Set an identity provider publishes its
+// signing keys in. Keys are fetched when first needed, again once they are
+// older than the refresh interval, and when a token names a key not seen
+// yet; the keys known are kept when fetching fails.
+type KeySet struct {
+	url     string
+	issuer  string
+	refresh time.Duration
+	client  *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]publicKey
+	fetchedAt time.Time
+	triedAt   time.Time
+}
+
+func NewKeySet(url string, refresh time.Duration) *KeySet {
+	return &KeySet{
+		url:     url,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// NewDiscoveredKeySet returns the key set of an OpenID provider, whose URL
+// is read from the provider's discovery document when first needed.
+func NewDiscoveredKeySet(issuer string, refresh time.Duration) *KeySet {
+	s := NewKeySet("", refresh)
+	s.issuer = issuer
+	return s
+}
+
+// key returns the key of ID kid, or the only key when kid is empty.
+func (s *KeySet) key(ctx context.Context, kid string) (publicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	_, known := s.lookup(kid)
+	stale := s.keys == nil || now.Sub(s.fetchedAt) > s.refresh
+	if (stale || !known) && now.Sub(s.triedAt) > keyRefetchInterval {
+		s.triedAt = now
+		keys, err := s.fetch(ctx)
+		if err != nil {
+			log.Printf("Failed to fetch signing keys of %s: %v", s.source(), err)
+		} else {
+			s.keys, s.fetchedAt = keys, now
+		}
+	}
+
+	if s.keys == nil {
+		return publicKey{}, ErrKeysUnavailable
+	}
+	key, ok := s.lookup(kid)
+	if !ok {
+		return publicKey{}, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, kid)
+	}
+	return key, nil
+}
+
+func (s *KeySet) lookup(kid string) (publicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (s *KeySet) source() string {
+	if s.url == "" {
+		return s.issuer
+	}
+	return s.url
+}
+
+func (s *KeySet) fetch(ctx context.Context) (map[string]publicKey, error) {
+	if s.url == "" {
+		url, err := discoverKeySetURL(ctx, s.client, s.issuer)
+		if err != nil {
+			return nil, fmt.Errorf("discovery failed: %w", err)
+		}
+		s.url = url
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxKeySetSize)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("invalid key set: %w", err)
+	}
+
+	keys := make(map[string]publicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// Keys of other kinds may sit beside those used
+			log.Printf("Ignoring signing key %q of %s: %v", jwk.Kid, s.source(), err)
+			continue
+		}
+		keys[jwk.Kid] = publicKey{alg: jwk.Alg, key: key}
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("RSA exponent is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
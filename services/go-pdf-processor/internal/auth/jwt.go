@@ -0,0 +1,260 @@
+// Package auth authenticates the requests of the API by the JSON Web
+// Tokens of an identity provider, verified with the keys it publishes, or
+// by the API keys tenants issue their programs.
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, not signed by
+// the provider, expired or not meant for this service.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Principal is who a request was made by, as its token or API key says.
+type Principal struct {
+	Subject  string `json:"subject"`
+	UserID   string `json:"user_id"`
+	TenantID string `json:"tenant_id,omitempty"`
+
+	// Roles are those the identity provider gives the user
+	Roles []string `json:"roles,omitempty"`
+
+	// APIKeyID is the key of requests made with one, restricted to Scopes
+	APIKeyID string   `json:"api_key_id,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
+
+	// Claims are all the claims of the token
+	Claims map[string]interface{} `json:"-"`
+}
+
+// HasScope reports whether the principal may make requests of a scope.
+// Users of the identity provider are not restricted by scopes but by
+// their roles.
+func (p *Principal) HasScope(scope string) bool {
+	if p.APIKeyID == "" {
+		return true
+	}
+	return slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, ScopeAdmin)
+}
+
+// HasRole reports whether the identity provider gives the principal a
+// role.
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the principal of a request.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of a request, nil when requests are
+// not authenticated.
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// VerifierConfig is what tokens are checked for. Empty Issuer, Audience
+// and ClientID are not checked; tokens for an OpenID client name it in
+// their audience or as their authorized party. The user is the UserClaim
+// claim, or the subject when the token has none, the tenant the
+// TenantClaim claim and the roles the RolesClaim one. Leeway allows for
+// clocks a little apart.
+type VerifierConfig struct {
+	Issuer      string
+	Audience    string
+	ClientID    string
+	UserClaim   string
+	TenantClaim string
+	RolesClaim  string
+	Leeway      time.Duration
+}
+
+// Verifier verifies tokens signed with the keys of a key set.
+type Verifier struct {
+	keys *KeySet
+	cfg  VerifierConfig
+}
+
+func NewVerifier(keys *KeySet, cfg VerifierConfig) *Verifier {
+	return &Verifier{keys: keys, cfg: cfg}
+}
+
+// signingAlgorithms are the JWS algorithms accepted: those of public keys.
+// Symmetric algorithms and "none" are refused, so a token cannot be
+// signed with what is known of the provider.
+var signingAlgorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"PS256": crypto.SHA256, "PS384": crypto.SHA384, "PS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+	"EdDSA": 0,
+}
+
+// Verify checks a token's signature and claims, returning who it was
+// issued to. Errors wrap ErrInvalidToken, or ErrKeysUnavailable when the
+// token could not be checked.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: not a signed JWT", ErrInvalidToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	hash, ok := signingAlgorithms[header.Alg]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, header.Alg)
+	}
+	key, err := v.keys.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if key.alg != "" && key.alg != header.Alg {
+		return nil, fmt.Errorf("%w: key %q is not for %s", ErrInvalidToken, header.Kid, header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	if !verifySignature(header.Alg, hash, key.key, []byte(parts[0]+"."+parts[1]), signature) {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+
+	p := &Principal{Claims: claims}
+	p.Subject, _ = claims["sub"].(string)
+	p.UserID, _ = claims[v.cfg.UserClaim].(string)
+	if p.UserID == "" {
+		p.UserID = p.Subject
+	}
+	if p.UserID == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidToken)
+	}
+	p.TenantID, _ = claims[v.cfg.TenantClaim].(string)
+	p.Roles = claimStrings(claims, v.cfg.RolesClaim)
+	return p, nil
+}
+
+func (v *Verifier) checkClaims(claims map[string]interface{}) error {
+	now := time.Now()
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return fmt.Errorf("%w: no expiry", ErrInvalidToken)
+	}
+	if now.After(exp.Add(v.cfg.Leeway)) {
+		return fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(v.cfg.Leeway).Before(nbf) {
+		return fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+	if v.cfg.Issuer != "" && claims["iss"] != v.cfg.Issuer {
+		return fmt.Errorf("%w: not issued by %s", ErrInvalidToken, v.cfg.Issuer)
+	}
+	if v.cfg.Audience != "" && !hasAudience(claims["aud"], v.cfg.Audience) {
+		return fmt.Errorf("%w: not meant for %s", ErrInvalidToken, v.cfg.Audience)
+	}
+	if v.cfg.ClientID != "" && !hasAudience(claims["aud"], v.cfg.ClientID) && claims["azp"] != v.cfg.ClientID {
+		return fmt.Errorf("%w: not issued to client %s", ErrInvalidToken, v.cfg.ClientID)
+	}
+	return nil
+}
+
+// hasAudience reports whether the aud claim, a string or a list of them,
+// names audience.
+func hasAudience(aud interface{}, audience string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func numericDate(value interface{}) (time.Time, bool) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, 0).Add(time.Duration(seconds * float64(time.Second))), true
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func verifySignature(alg string, hash crypto.Hash, key crypto.PublicKey, signed, signature []byte) bool {
+	var digest []byte
+	if hash != 0 {
+		h := hash.New()
+		h.Write(signed)
+		digest = h.Sum(nil)
+	}
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			return rsa.VerifyPKCS1v15(key, hash, digest, signature) == nil
+		case "PS":
+			return rsa.VerifyPSS(key, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+	case *ecdsa.PublicKey:
+		// The curve goes with the algorithm: P-256 with ES256 and so on
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if alg[:2] != "ES" || len(signature) != 2*size || hash.Size()*8 != min(key.Curve.Params().BitSize, 512) {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(key, digest, r, s)
+	case ed25519.PublicKey:
+		return alg == "EdDSA" && ed25519.Verify(key, signed, signature)
+	}
+	return false
+}
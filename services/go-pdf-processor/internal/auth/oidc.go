@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxDiscoverySize bounds the discovery documents read.
+const maxDiscoverySize = 1 << 20
+
+// discoverKeySetURL reads where an OpenID provider publishes its signing
+// keys from its discovery document, which must be of the issuer named.
+func discoverKeySetURL(ctx context.Context, client *http.Client, issuer string) (string, error) {
+	url := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var doc struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDiscoverySize)).Decode(&doc); err != nil {
+		return "", fmt.Errorf("invalid discovery document: %w", err)
+	}
+	if doc.Issuer != issuer {
+		return "", fmt.Errorf("discovery document is of issuer %q", doc.Issuer)
+	}
+	if doc.JWKSURI == "" {
+		return "", fmt.Errorf("discovery document has no jwks_uri")
+	}
+	return doc.JWKSURI, nil
+}
+
+// claimStrings returns the strings of a claim, a list of them or one
+// space-separated string. Dots in the name reach into nested claims, as
+// realm_access.roles for the roles of Keycloak realms.
+func claimStrings(claims map[string]interface{}, name string) []string {
+	if name == "" {
+		return nil
+	}
+	var value interface{} = claims
+	for _, part := range strings.Split(name, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[part]
+	}
+
+	switch value := value.(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package classify
+
+import (
+	"context"
+	"log"
+	"math"
+	"regexp"
+	"strings"
+
+	"cotai-pdf-processor/internal/config"
+)
+
+// Document types
+const (
+	TypeEdital            = "edital"
+	TypeAtaRegistroPrecos = "ata_registro_precos"
+	TypeContrato          = "contrato"
+	TypeTermoReferencia   = "termo_referencia"
+	TypeAnexoTecnico      = "anexo_tecnico"
+	TypeAvisoLicitacao    = "aviso_licitacao"
+	TypePropostaComercial = "proposta_comercial"
+	TypeOutro             = "outro"
+)
+
+// Labels are the display names of the document types.
+var Labels = map[string]string{
+	TypeEdital:            "Edital",
+	TypeAtaRegistroPrecos: "Ata de registro de preços",
+	TypeContrato:          "Contrato",
+	TypeTermoReferencia:   "Termo de referência",
+	TypeAnexoTecnico:      "Anexo técnico",
+	TypeAvisoLicitacao:    "Aviso de licitação",
+	TypePropostaComercial: "Proposta comercial",
+	TypeOutro:             "Outro",
+}
+
+// Result is the type assigned to a document.
+type Result struct {
+	Type       string  `json:"type"`
+	Label      string  `json:"label"`
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source"` // "rules" or "model"
+}
+
+// headerLength is how much of the start of a document is searched for
+// titles; a title further in is usually a reference to another document.
+const headerLength = 3000
+
+// titleWeight and termWeight are what a title in the header and a
+// characteristic term anywhere add to a type's score. Titles outside the
+// header count as terms.
+const (
+	titleWeight = 3.0
+	termWeight  = 1.0
+
+	// strongScore is the score from which the rules are fully confident,
+	// given no other type scores.
+	strongScore = 6.0
+)
+
+type typeRules struct {
+	docType string
+	titles  []*regexp.Regexp
+	terms   []string
+}
+
+func mustCompileAll(patterns ...string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		compiled[i] = regexp.MustCompile(`(?i)` + p)
+	}
+	return compiled
+}
+
+var rules = []typeRules{
+	{
+		docType: TypeEdital,
+		titles: mustCompileAll(
+			`\bedital\s+(?:de\s+)?(?:licita[çc][ãa]o|preg[ãa]o|concorr[êe]ncia|chamamento|tomada)`,
+			`\bedital\s+n[º°o.]`,
+		),
+		terms: []string{"condições de participação", "da habilitação", "julgamento das propostas", "impugnação ao edital", "sessão pública", "critério de julgamento"},
+	},
+	{
+		docType: TypeAtaRegistroPrecos,
+		titles:  mustCompileAll(`\bata\s+de\s+registro\s+de\s+pre[çc]os`),
+		terms:   []string{"órgão gerenciador", "fornecedor beneficiário", "validade da ata", "preços registrados", "órgão participante"},
+	},
+	{
+		docType: TypeContrato,
+		titles: mustCompileAll(
+			`\b(?:termo\s+de\s+)?contrato\s+(?:administrativo\s+)?n[º°o.]`,
+			`\bextrato\s+(?:do\s+)?contrato`,
+		),
+		terms: []string{"contratante", "contratada", "cláusula primeira", "vigência do contrato", "dotação orçamentária", "rescisão contratual"},
+	},
+	{
+		docType: TypeTermoReferencia,
+		titles:  mustCompileAll(`\btermo\s+de\s+refer[êe]ncia`, `\bprojeto\s+b[áa]sico`),
+		terms:   []string{"justificativa da contratação", "obrigações da contratada", "estimativa de preços", "modelo de execução", "fundamentação da contratação"},
+	},
+	{
+		docType: TypeAnexoTecnico,
+		titles: mustCompileAll(
+			`\banexo\s+(?:t[ée]cnico|[ivxl]+\b)`,
+			`\bmemorial\s+descritivo`,
+			`\bplanilha\s+or[çc]ament[áa]ria`,
+		),
+		terms: []string{"especificações técnicas", "memorial descritivo", "quantitativos", "unidade de medida", "composição de custos"},
+	},
+	{
+		docType: TypeAvisoLicitacao,
+		titles:  mustCompileAll(`\baviso\s+de\s+(?:licita[çc][ãa]o|preg[ãa]o|abertura|dispensa)`),
+		terms:   []string{"data da sessão", "retirada do edital", "abertura das propostas", "diário oficial"},
+	},
+	{
+		docType: TypePropostaComercial,
+		titles:  mustCompileAll(`\bproposta\s+(?:comercial|de\s+pre[çc]os)`),
+		terms:   []string{"validade da proposta", "declaramos", "prazo de entrega", "valor global da proposta"},
+	},
+}
+
+// Model classifies documents with a trained backend.
+type Model interface {
+	Classify(ctx context.Context, text string) (Result, error)
+}
+
+// Classifier labels documents by type with keyword rules and, when one is
+// configured, a model whose answer wins unless it is less confident.
+type Classifier struct {
+	model Model
+}
+
+// NewClassifier creates a classifier backed by the model at
+// cfg.ClassifierURL, if set.
+func NewClassifier(cfg *config.Config) *Classifier {
+	c := &Classifier{}
+	if cfg.ClassifierURL != "" {
+		c.model = NewHTTPModel(cfg.ClassifierURL, cfg.ClassifierTimeout)
+	}
+	return c
+}
+
+// Classify labels text. Model failures are logged and the rules' answer is
+// used.
+func (c *Classifier) Classify(ctx context.Context, text string) Result {
+	result := ClassifyRules(text)
+	if c.model == nil {
+		return result
+	}
+
+	predicted, err := c.model.Classify(ctx, text)
+	if err != nil {
+		log.Printf("Document classification model failed: %v", err)
+		return result
+	}
+	if predicted.Confidence >= result.Confidence {
+		return predicted
+	}
+	return result
+}
+
+// ClassifyRules labels text using titles in its header and terms
+// characteristic of each document type.
+func ClassifyRules(text string) Result {
+	header := text
+	if len(header) > headerLength {
+		header = header[:headerLength]
+	}
+	lower := strings.ToLower(text)
+
+	best, bestScore, secondScore := TypeOutro, 0.0, 0.0
+	for _, r := range rules {
+		score := 0.0
+		for _, title := range r.titles {
+			if title.MatchString(header) {
+				score += titleWeight
+			} else if title.MatchString(text) {
+				score += termWeight
+			}
+		}
+		for _, term := range r.terms {
+			if strings.Contains(lower, term) {
+				score += termWeight
+			}
+		}
+
+		switch {
+		case score > bestScore:
+			best, bestScore, secondScore = r.docType, score, bestScore
+		case score > secondScore:
+			secondScore = score
+		}
+	}
+
+	if bestScore == 0 {
+		return Result{Type: TypeOutro, Label: Labels[TypeOutro], Confidence: 0.5, Source: "rules"}
+	}
+
+	// Confidence grows with the score and with the lead over the runner-up
+	margin := bestScore / (bestScore + secondScore)
+	strength := min(1, bestScore/strongScore)
+	confidence := min(0.95, margin*strength)
+	return Result{Type: best, Label: Labels[best], Confidence: math.Round(confidence*100) / 100, Source: "rules"}
+}
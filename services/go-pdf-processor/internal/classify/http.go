@@ -0,0 +1,87 @@
+package classify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxModelText bounds the text sent to the model; the type of a document
+// shows in its first pages.
+const maxModelText = 20000
+
+// HTTPModel calls a classification service exposing
+//
+//	POST <url> {"text": "..."}
+//	-> {"label": "edital", "confidence": 0.93}
+//
+// Labels should be one of the document types; others are passed through.
+type HTTPModel struct {
+	url  string
+	http *http.Client
+}
+
+type modelRequest struct {
+	Text string `json:"text"`
+}
+
+type modelResponse struct {
+	Label      string  `json:"label"`
+	Confidence float64 `json:"confidence"`
+}
+
+func NewHTTPModel(url string, timeout time.Duration) *HTTPModel {
+	return &HTTPModel{url: url, http: &http.Client{Timeout: timeout}}
+}
+
+func (m *HTTPModel) Classify(ctx context.Context, text string) (Result, error) {
+	if len(text) > maxModelText {
+		cut := maxModelText
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut]
+	}
+
+	body, err := json.Marshal(modelRequest{Text: text})
+	if err != nil {
+		return Result{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.url, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.http.Do(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("classification request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Result{}, fmt.Errorf("classification model returned status %d", resp.StatusCode)
+	}
+
+	var decoded modelResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&decoded); err != nil {
+		return Result{}, fmt.Errorf("failed to decode classification response: %w", err)
+	}
+	if decoded.Label == "" {
+		return Result{}, fmt.Errorf("classification response has no label")
+	}
+
+	docType := strings.ToLower(decoded.Label)
+	label, ok := Labels[docType]
+	if !ok {
+		label = decoded.Label
+	}
+	return Result{Type: docType, Label: label, Confidence: decoded.Confidence, Source: "model"}, nil
+}
@@ -0,0 +1,301 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"cotai-pdf-processor/internal/config"
+	"cotai-pdf-processor/internal/netguard"
+	"cotai-pdf-processor/internal/storage"
+)
+
+// Downloader fetches job documents from http(s), s3://, minio:// and gs://
+// URLs into local temp files. Plain paths and file:// URLs are used in place.
+// Only the sources the configuration allows are read: http(s) URLs of
+// public addresses, the allowed buckets, and files of the upload and temp
+// directories (see config.DownloadAllowLocalFiles).
+type Downloader struct {
+	httpClient *http.Client
+	s3         *storage.ObjectStore
+	minio      *storage.ObjectStore
+	gcs        *storage.ObjectStore
+	maxSize    int64
+	timeout    time.Duration
+	tempDir    string
+
+	buckets    []string
+	localDirs  []string
+	allowLocal bool
+}
+
+// File is a document available on the local filesystem. Call Cleanup once
+// processing is done to remove any temp file created for it.
+type File struct {
+	Path        string
+	Size        int64
+	ContentType string
+	SourceURL   string
+	temporary   bool
+}
+
+func (f *File) Cleanup() {
+	if !f.temporary {
+		return
+	}
+	if err := os.Remove(f.Path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove temp file %s: %v", f.Path, err)
+	}
+}
+
+func NewDownloader(cfg *config.Config) (*Downloader, error) {
+	s3, err := storage.NewObjectStore(storage.ObjectStoreConfig{
+		Endpoint:  cfg.ObjectStoreEndpoint,
+		Region:    cfg.ObjectStoreRegion,
+		AccessKey: cfg.ObjectStoreAccessKey,
+		SecretKey: cfg.ObjectStoreSecretKey,
+		UseSSL:    cfg.ObjectStoreUseSSL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	minio, err := storage.NewObjectStore(storage.ObjectStoreConfig{
+		Endpoint:  cfg.MinIOEndpoint,
+		Region:    cfg.ObjectStoreRegion,
+		AccessKey: cfg.ObjectStoreAccessKey,
+		SecretKey: cfg.ObjectStoreSecretKey,
+		UseSSL:    cfg.ObjectStoreUseSSL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gcs, err := storage.NewObjectStore(storage.ObjectStoreConfig{
+		Endpoint:  cfg.GCSEndpoint,
+		Region:    "auto",
+		AccessKey: cfg.GCSAccessKey,
+		SecretKey: cfg.GCSSecretKey,
+		UseSSL:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := &http.Client{}
+	if !cfg.DownloadAllowPrivateNetworks {
+		httpClient.Transport = netguard.Transport()
+	}
+
+	return &Downloader{
+		httpClient: httpClient,
+		s3:         s3,
+		minio:      minio,
+		gcs:        gcs,
+		maxSize:    cfg.MaxFileSize,
+		timeout:    cfg.DownloadTimeout,
+		tempDir:    cfg.TempDir,
+		buckets:    append([]string{cfg.UploadBucket, cfg.AttachmentBucket}, cfg.DownloadAllowedBuckets...),
+		localDirs:  []string{cfg.UploadDir, cfg.TempDir},
+		allowLocal: cfg.DownloadAllowLocalFiles,
+	}, nil
+}
+
+// ValidateURL refuses a document URL submitted by a client unless it is
+// an http(s) one of a public host, or of any host with allowPrivate. Local
+// files and buckets are the service's own, which clients may not name.
+func ValidateURL(ctx context.Context, rawURL string, allowPrivate bool) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %q: %v", ErrForbiddenURL, rawURL, err)
+	}
+	if scheme := strings.ToLower(u.Scheme); (scheme != "http" && scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("%w: %q is not an absolute http or https URL", ErrForbiddenURL, rawURL)
+	}
+	if allowPrivate {
+		return nil
+	}
+	if err := netguard.CheckHost(ctx, u.Hostname()); err != nil {
+		return fmt.Errorf("%w: %v", ErrForbiddenURL, err)
+	}
+	return nil
+}
+
+// Fetch makes the document at rawURL available locally, enforcing the
+// configured size limit and download timeout.
+func (d *Downloader) Fetch(ctx context.Context, rawURL string) (*File, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file URL %q: %w", rawURL, err)
+	}
+
+	if d.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "", "file":
+		if !d.localAllowed(u.Path) {
+			return nil, fmt.Errorf("%w: %s is outside the upload and temp directories", ErrForbiddenURL, u.Path)
+		}
+		return d.local(u.Path, rawURL)
+	case "http", "https":
+		return d.fetchHTTP(ctx, rawURL)
+	case "s3":
+		return d.fetchObject(ctx, d.s3, u, rawURL)
+	case "minio":
+		return d.fetchObject(ctx, d.minio, u, rawURL)
+	case "gs":
+		return d.fetchObject(ctx, d.gcs, u, rawURL)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedScheme, u.Scheme)
+	}
+}
+
+// localAllowed reports whether the file at path may be read: any with
+// DOWNLOAD_ALLOW_LOCAL_FILES, else those of the upload and temp
+// directories.
+func (d *Downloader) localAllowed(path string) bool {
+	if d.allowLocal {
+		return true
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, dir := range d.localDirs {
+		if dir == "" {
+			continue
+		}
+		root, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(root, abs); err == nil && rel != "." && filepath.IsLocal(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Downloader) local(path, rawURL string) (*File, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if d.maxSize > 0 && info.Size() > d.maxSize {
+		return nil, ErrFileTooLarge
+	}
+
+	return &File{Path: path, Size: info.Size(), SourceURL: rawURL}, nil
+}
+
+func (d *Downloader) fetchHTTP(ctx context.Context, rawURL string) (*File, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{URL: rawURL, StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	if d.maxSize > 0 && resp.ContentLength > d.maxSize {
+		return nil, ErrFileTooLarge
+	}
+
+	return d.writeTemp(resp.Body, resp.Header.Get("Content-Type"), rawURL)
+}
+
+// fetchObject reads bucket/key from an object store URL of the form
+// scheme://bucket/path/to/key.
+func (d *Downloader) fetchObject(ctx context.Context, store *storage.ObjectStore, u *url.URL, rawURL string) (*File, error) {
+	bucket := u.Host
+	key := strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("invalid object URL %q: expected %s://bucket/key", rawURL, u.Scheme)
+	}
+	if !slices.Contains(d.buckets, bucket) {
+		return nil, fmt.Errorf("%w: bucket %s is not allowed", ErrForbiddenURL, bucket)
+	}
+
+	body, info, err := store.Get(ctx, bucket, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", rawURL, err)
+	}
+	defer body.Close()
+
+	if d.maxSize > 0 && info.Size > d.maxSize {
+		return nil, ErrFileTooLarge
+	}
+
+	return d.writeTemp(body, info.ContentType, rawURL)
+}
+
+func (d *Downloader) writeTemp(body io.Reader, contentType, rawURL string) (*File, error) {
+	tmp, err := os.CreateTemp(d.tempDir, "cotai-doc-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	file := &File{Path: tmp.Name(), ContentType: contentType, SourceURL: rawURL, temporary: true}
+
+	// Read one byte past the limit so oversized bodies without a
+	// Content-Length are still detected.
+	reader := body
+	if d.maxSize > 0 {
+		reader = io.LimitReader(body, d.maxSize+1)
+	}
+
+	written, err := io.Copy(tmp, reader)
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		file.Cleanup()
+		return nil, fmt.Errorf("failed to write %s to disk: %w", rawURL, err)
+	}
+	if d.maxSize > 0 && written > d.maxSize {
+		file.Cleanup()
+		return nil, ErrFileTooLarge
+	}
+
+	file.Size = written
+	return file, nil
+}
+
+// StatusError is returned when the server answers a download with a
+// status other than 200 OK.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("failed to download %s: unexpected status %s", e.URL, e.Status)
+}
+
+// Custom errors
+var (
+	ErrFileTooLarge      = errors.New("file exceeds maximum allowed size")
+	ErrUnsupportedScheme = errors.New("unsupported file URL scheme")
+	ErrForbiddenURL      = errors.New("file URL not allowed")
+)
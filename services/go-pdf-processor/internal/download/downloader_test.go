@@ -0,0 +1,143 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cotai-pdf-processor/internal/netguard"
+)
+
+// testDownloader returns a downloader of the upload and temp directories
+// it creates, with a file in the upload directory and one outside both.
+func testDownloader(t *testing.T) (d *Downloader, inside, outside string) {
+	t.Helper()
+	uploadDir, tempDir, otherDir := t.TempDir(), t.TempDir(), t.TempDir()
+	inside = filepath.Join(uploadDir, "doc.pdf")
+	outside = filepath.Join(otherDir, "secret.pdf")
+	for _, path := range []string{inside, outside} {
+		if err := os.WriteFile(path, []byte("%PDF-1.4"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d = &Downloader{
+		httpClient: &http.Client{Transport: netguard.Transport()},
+		tempDir:    tempDir,
+		buckets:    []string{"uploads", "attachments"},
+		localDirs:  []string{uploadDir, tempDir},
+	}
+	return d, inside, outside
+}
+
+func TestFetchSchemes(t *testing.T) {
+	d, inside, outside := testDownloader(t)
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{"upload directory", inside, nil},
+		{"upload directory file URL", "file://" + inside, nil},
+		{"outside the directories", outside, ErrForbiddenURL},
+		{"outside file URL", "file://" + outside, ErrForbiddenURL},
+		{"system file", "/etc/passwd", ErrForbiddenURL},
+		{"traversal", filepath.Join(filepath.Dir(inside), "..", filepath.Base(filepath.Dir(outside)), "secret.pdf"), ErrForbiddenURL},
+		{"directory itself", filepath.Dir(inside), ErrForbiddenURL},
+		{"bucket not allowed", "s3://other/doc.pdf", ErrForbiddenURL},
+		{"minio bucket not allowed", "minio://other/doc.pdf", ErrForbiddenURL},
+		{"gcs bucket not allowed", "gs://other/doc.pdf", ErrForbiddenURL},
+		{"loopback", "http://127.0.0.1:1/doc.pdf", netguard.ErrForbiddenAddress},
+		{"private", "http://10.0.0.1:1/doc.pdf", netguard.ErrForbiddenAddress},
+		{"metadata service", "http://169.254.169.254/latest/meta-data/", netguard.ErrForbiddenAddress},
+		{"ftp", "ftp://example.com/doc.pdf", ErrUnsupportedScheme},
+		{"gopher", "gopher://example.com/doc.pdf", ErrUnsupportedScheme},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := d.Fetch(context.Background(), tt.url)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Fetch(%q) error = %v", tt.url, err)
+				}
+				file.Cleanup()
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Fetch(%q) error = %v, want %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFetchAllowLocalFiles(t *testing.T) {
+	d, _, outside := testDownloader(t)
+	d.allowLocal = true
+
+	file, err := d.Fetch(context.Background(), outside)
+	if err != nil {
+		t.Fatalf("Fetch(%q) error = %v", outside, err)
+	}
+	file.Cleanup()
+}
+
+func TestFetchAllowPrivateNetworks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("%PDF-1.4"))
+	}))
+	defer server.Close()
+
+	d, _, _ := testDownloader(t)
+	if _, err := d.Fetch(context.Background(), server.URL); !errors.Is(err, netguard.ErrForbiddenAddress) {
+		t.Fatalf("Fetch(%q) error = %v, want %v", server.URL, err, netguard.ErrForbiddenAddress)
+	}
+
+	d.httpClient = server.Client()
+	file, err := d.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch(%q) with private networks allowed error = %v", server.URL, err)
+	}
+	defer file.Cleanup()
+	if file.Size != int64(len("%PDF-1.4")) || file.ContentType != "application/pdf" {
+		t.Errorf("Fetch() = %+v", file)
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url          string
+		allowPrivate bool
+		wantErr      bool
+	}{
+		{"https://93.184.216.34/doc.pdf", false, false},
+		{"http://93.184.216.34/doc.pdf", false, false},
+		{"https://127.0.0.1/doc.pdf", false, true},
+		{"https://127.0.0.1/doc.pdf", true, false},
+		{"http://192.168.1.10/doc.pdf", false, true},
+		{"http://[::1]/doc.pdf", false, true},
+		{"http://169.254.169.254/", false, true},
+		{"/var/uploads/doc.pdf", false, true},
+		{"file:///etc/passwd", true, true},
+		{"s3://uploads/doc.pdf", true, true},
+		{"minio://uploads/doc.pdf", true, true},
+		{"ftp://93.184.216.34/doc.pdf", false, true},
+		{"http:///doc.pdf", true, true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateURL(context.Background(), tt.url, tt.allowPrivate)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateURL(%q, %v) error = %v, want error %v", tt.url, tt.allowPrivate, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrForbiddenURL) {
+			t.Errorf("ValidateURL(%q) error = %v, want %v", tt.url, err, ErrForbiddenURL)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package enrichment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"cotai-pdf-processor/internal/config"
+	"cotai-pdf-processor/internal/storage"
+)
+
+// notFoundCacheTTL is how long an unknown CNPJ is remembered; the registry
+// may simply not have caught up with a new company yet.
+const notFoundCacheTTL = 24 * time.Hour
+
+// Custom errors
+var (
+	ErrCompanyNotFound = errors.New("company not found")
+	ErrLookupDisabled  = errors.New("CNPJ lookup is not configured")
+)
+
+// Company is the registry data attached to a CNPJ entity.
+type Company struct {
+	CNPJ              string `json:"cnpj"`
+	RazaoSocial       string `json:"razao_social"`
+	NomeFantasia      string `json:"nome_fantasia,omitempty"`
+	SituacaoCadastral string `json:"situacao_cadastral"`
+	CNAE              string `json:"cnae"`
+	CNAEDescricao     string `json:"cnae_descricao"`
+}
+
+// registryResponse is the subset of the BrasilAPI CNPJ payload we use. The
+// same shape is served by Receita Federal mirrors such as minhareceita.
+type registryResponse struct {
+	CNPJ                       string      `json:"cnpj"`
+	RazaoSocial                string      `json:"razao_social"`
+	NomeFantasia               string      `json:"nome_fantasia"`
+	DescricaoSituacaoCadastral string      `json:"descricao_situacao_cadastral"`
+	CNAEFiscal                 json.Number `json:"cnae_fiscal"`
+	CNAEFiscalDescricao        string      `json:"cnae_fiscal_descricao"`
+}
+
+// CNPJClient looks companies up in a BrasilAPI-compatible registry. Results,
+// including misses, are cached in Redis and requests are spaced out to stay
+// within the registry's rate limit.
+type CNPJClient struct {
+	baseURL  string
+	http     *http.Client
+	redis    *storage.RedisClient
+	cacheTTL time.Duration
+
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func NewCNPJClient(cfg *config.Config, redis *storage.RedisClient) *CNPJClient {
+	var interval time.Duration
+	if cfg.CNPJLookupRate > 0 {
+		interval = time.Duration(float64(time.Second) / cfg.CNPJLookupRate)
+	}
+
+	return &CNPJClient{
+		baseURL:  strings.TrimSuffix(cfg.CNPJLookupURL, "/"),
+		http:     &http.Client{Timeout: cfg.CNPJLookupTimeout},
+		redis:    redis,
+		cacheTTL: cfg.CNPJCacheTTL,
+		interval: interval,
+	}
+}
+
+// Lookup returns the registry data of a CNPJ, given with or without
+// punctuation.
+func (c *CNPJClient) Lookup(ctx context.Context, cnpj string) (*Company, error) {
+	if c == nil || c.baseURL == "" {
+		return nil, ErrLookupDisabled
+	}
+
+	digits := onlyDigits(cnpj)
+	if len(digits) != 14 {
+		return nil, fmt.Errorf("invalid CNPJ %q", cnpj)
+	}
+
+	key := "cnpj:" + digits
+	if data, err := c.redis.Get(ctx, key); err == nil {
+		if string(data) == "null" {
+			return nil, ErrCompanyNotFound
+		}
+		var company Company
+		if err := json.Unmarshal(data, &company); err == nil {
+			return &company, nil
+		}
+	}
+
+	company, err := c.fetch(ctx, digits)
+	if errors.Is(err, ErrCompanyNotFound) {
+		c.redis.Set(ctx, key, []byte("null"), notFoundCacheTTL)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(company); err == nil {
+		c.redis.Set(ctx, key, data, c.cacheTTL)
+	}
+	return company, nil
+}
+
+func (c *CNPJClient) fetch(ctx context.Context, digits string) (*Company, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/"+digits, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("CNPJ lookup failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrCompanyNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("CNPJ lookup returned status %d", resp.StatusCode)
+	}
+
+	var body registryResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode CNPJ lookup: %w", err)
+	}
+
+	return &Company{
+		CNPJ:              digits,
+		RazaoSocial:       body.RazaoSocial,
+		NomeFantasia:      body.NomeFantasia,
+		SituacaoCadastral: body.DescricaoSituacaoCadastral,
+		CNAE:              body.CNAEFiscal.String(),
+		CNAEDescricao:     body.CNAEFiscalDescricao,
+	}, nil
+}
+
+// wait blocks until the next request slot, spacing requests by interval
+// across all callers.
+func (c *CNPJClient) wait(ctx context.Context) error {
+	if c.interval <= 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	now := time.Now()
+	if c.next.Before(now) {
+		c.next = now
+	}
+	delay := c.next.Sub(now)
+	c.next = c.next.Add(c.interval)
+	c.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,689 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Queries run against plain values rather than a typed schema. The root
+// is a map of fields, each a value or a Resolver called with the field's
+// arguments; other values are walked through their JSON encoding, so
+// their fields are named by their JSON keys, and fields a value does not
+// have are null. List fields without a resolver take filter arguments
+// (see filterList), at any depth of the query.
+
+// maxDepth bounds the nesting of the fields of a query.
+const maxDepth = 20
+
+// Resolver resolves a field from its arguments.
+type Resolver func(ctx context.Context, args map[string]interface{}) (interface{}, error)
+
+// Request is a GraphQL request as posted by clients.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Response holds the data of an executed query and the errors of the
+// fields that could not be resolved; a query that could not be executed
+// has no data.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Error is an error of a query, located in it and, for fields, by the path
+// of the field in the response.
+type Error struct {
+	Message   string        `json:"message"`
+	Locations []Location    `json:"locations,omitempty"`
+	Path      []interface{} `json:"path,omitempty"`
+}
+
+// Location is a line and column of a query, counted from 1.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Execute runs the operation of a request against root.
+func Execute(ctx context.Context, root map[string]interface{}, req Request) *Response {
+	doc, err := Parse(req.Query)
+	if err != nil {
+		var syntaxErr *SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return &Response{Errors: []*Error{{
+				Message:   "syntax error: " + syntaxErr.Message,
+				Locations: []Location{{Line: syntaxErr.Line, Column: syntaxErr.Column}},
+			}}}
+		}
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+	vars, err := coerceVariables(op, req.Variables)
+	if err == nil {
+		err = checkVariables(doc, op)
+	}
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	e := &executor{src: req.Query, doc: doc, vars: vars}
+	data := e.executeSelections(ctx, root, op.selections, nil)
+	return &Response{Data: data, Errors: e.errors}
+}
+
+func (d *Document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, errors.New("operationName is required for documents with several operations")
+		}
+		return d.operations[0], nil
+	}
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+// coerceVariables applies the defaults of the variables not given and
+// checks that the required ones are.
+func coerceVariables(op *operation, given map[string]interface{}) (map[string]interface{}, error) {
+	vars := make(map[string]interface{})
+	for _, definition := range op.variables {
+		value, ok := given[definition.name]
+		switch {
+		case ok && value == nil && definition.nonNull:
+			return nil, fmt.Errorf("variable $%s must not be null", definition.name)
+		case ok:
+			vars[definition.name] = value
+		case definition.hasDefault:
+			vars[definition.name] = constantValue(definition.defaultValue)
+		case definition.nonNull:
+			return nil, fmt.Errorf("variable $%s is required", definition.name)
+		}
+	}
+	return vars, nil
+}
+
+// checkVariables refuses operations using variables they do not define.
+func checkVariables(doc *Document, op *operation) error {
+	defined := make(map[string]bool, len(op.variables))
+	for _, definition := range op.variables {
+		defined[definition.name] = true
+	}
+
+	var undefined error
+	var checkValue func(value interface{})
+	checkValue = func(value interface{}) {
+		switch v := value.(type) {
+		case variable:
+			if !defined[string(v)] && undefined == nil {
+				undefined = fmt.Errorf("variable $%s is not defined", v)
+			}
+		case []interface{}:
+			for _, item := range v {
+				checkValue(item)
+			}
+		case map[string]interface{}:
+			for _, item := range v {
+				checkValue(item)
+			}
+		}
+	}
+	checkDirectives := func(directives []*directive) {
+		for _, d := range directives {
+			for _, arg := range d.arguments {
+				checkValue(arg.value)
+			}
+		}
+	}
+
+	visited := make(map[string]bool)
+	var checkSelections func(selections []selection)
+	checkSelections = func(selections []selection) {
+		for _, s := range selections {
+			switch s := s.(type) {
+			case *field:
+				for _, arg := range s.arguments {
+					checkValue(arg.value)
+				}
+				checkDirectives(s.directives)
+				checkSelections(s.selections)
+			case *fragmentSpread:
+				checkDirectives(s.directives)
+				if f, ok := doc.fragments[s.name]; ok && !visited[s.name] {
+					visited[s.name] = true
+					checkSelections(f.selections)
+				}
+			case *inlineFragment:
+				checkDirectives(s.directives)
+				checkSelections(s.selections)
+			}
+		}
+	}
+	checkSelections(op.selections)
+	return undefined
+}
+
+// constantValue turns the enum values of a parsed constant into strings.
+func constantValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case enumValue:
+		return string(v)
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = constantValue(item)
+		}
+		return list
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			object[key] = constantValue(item)
+		}
+		return object
+	}
+	return value
+}
+
+type executor struct {
+	src    string
+	doc    *Document
+	vars   map[string]interface{}
+	errors []*Error
+}
+
+func (e *executor) addError(err error, pos int, path []interface{}) {
+	line, column := location(e.src, pos)
+	e.errors = append(e.errors, &Error{
+		Message:   err.Error(),
+		Locations: []Location{{Line: line, Column: column}},
+		Path:      path,
+	})
+}
+
+// orderedObject is an object of the response, with its fields in the
+// order they were asked for.
+type orderedObject struct {
+	keys   []string
+	values []interface{}
+}
+
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(encodedKey)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// fieldGroups are the fields of a selection set by response key, fields
+// asked for twice being merged.
+type fieldGroups struct {
+	keys   []string
+	fields map[string][]*field
+}
+
+func (e *executor) collectFields(selections []selection, groups *fieldGroups, visited map[string]bool) {
+	for _, s := range selections {
+		switch s := s.(type) {
+		case *field:
+			if !e.included(s.directives) {
+				continue
+			}
+			key := s.responseKey()
+			if _, ok := groups.fields[key]; !ok {
+				groups.keys = append(groups.keys, key)
+			}
+			groups.fields[key] = append(groups.fields[key], s)
+		case *fragmentSpread:
+			if !e.included(s.directives) || visited[s.name] {
+				continue
+			}
+			visited[s.name] = true
+			f, ok := e.doc.fragments[s.name]
+			if !ok {
+				e.addError(fmt.Errorf("unknown fragment %q", s.name), s.pos, nil)
+				continue
+			}
+			e.collectFields(f.selections, groups, visited)
+		case *inlineFragment:
+			if e.included(s.directives) {
+				e.collectFields(s.selections, groups, visited)
+			}
+		}
+	}
+}
+
+// included applies the @skip and @include directives.
+func (e *executor) included(directives []*directive) bool {
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			continue
+		}
+		args, err := e.arguments(d.arguments)
+		if err != nil {
+			continue
+		}
+		condition, _ := args["if"].(bool)
+		if d.name == "skip" && condition || d.name == "include" && !condition {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *executor) executeSelections(ctx context.Context, source map[string]interface{}, selections []selection, path []interface{}) *orderedObject {
+	groups := &fieldGroups{fields: make(map[string][]*field)}
+	e.collectFields(selections, groups, make(map[string]bool))
+
+	out := &orderedObject{}
+	for _, key := range groups.keys {
+		fields := groups.fields[key]
+		fieldPath := append(append([]interface{}{}, path...), key)
+		var subselections []selection
+		for _, f := range fields {
+			subselections = append(subselections, f.selections...)
+		}
+
+		out.keys = append(out.keys, key)
+		value, err := e.resolveField(ctx, source, fields[0])
+		if err != nil {
+			e.addError(err, fields[0].pos, fieldPath)
+			out.values = append(out.values, nil)
+			continue
+		}
+		out.values = append(out.values, e.completeValue(ctx, value, fields[0], subselections, fieldPath))
+	}
+	return out
+}
+
+func (e *executor) resolveField(ctx context.Context, source map[string]interface{}, f *field) (interface{}, error) {
+	if f.name == "__typename" {
+		if typename, ok := source["__typename"].(string); ok {
+			return typename, nil
+		}
+		return "Object", nil
+	}
+	args, err := e.arguments(f.arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	switch value := source[f.name].(type) {
+	case Resolver:
+		return value(ctx, args)
+	case nil:
+		return nil, nil
+	default:
+		if len(args) == 0 {
+			return value, nil
+		}
+		list, err := normalize(value)
+		if err != nil {
+			return nil, err
+		}
+		return filterList(list, args)
+	}
+}
+
+func (e *executor) completeValue(ctx context.Context, value interface{}, f *field, selections []selection, path []interface{}) interface{} {
+	if len(path) > maxDepth {
+		e.addError(errors.New("the query is nested too deeply"), f.pos, path)
+		return nil
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		if len(selections) == 0 {
+			e.addError(fmt.Errorf("field %q is an object and needs a selection of its fields", f.name), f.pos, path)
+			return nil
+		}
+		return e.executeSelections(ctx, v, selections, path)
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = e.completeValue(ctx, item, f, selections, append(append([]interface{}{}, path...), i))
+		}
+		return list
+	case string, bool, float64, int, int64, json.Number:
+		if len(selections) > 0 {
+			e.addError(fmt.Errorf("field %q is a scalar and has no fields", f.name), f.pos, path)
+			return nil
+		}
+		return v
+	}
+
+	normalized, err := normalize(value)
+	if err != nil {
+		e.addError(err, f.pos, path)
+		return nil
+	}
+	return e.completeValue(ctx, normalized, f, selections, path)
+}
+
+// Extend returns the fields of value, as JSON encodes them, along with
+// fields, such as resolvers of fields loaded apart.
+func Extend(value interface{}, fields map[string]interface{}) (map[string]interface{}, error) {
+	normalized, err := normalize(value)
+	if err != nil {
+		return nil, err
+	}
+	object, ok := normalized.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot extend a %T", value)
+	}
+	for name, field := range fields {
+		object[name] = field
+	}
+	return object, nil
+}
+
+// normalize turns a value into its JSON form: maps, slices, strings,
+// json.Numbers, booleans and nil.
+func normalize(value interface{}) (interface{}, error) {
+	switch value.(type) {
+	case nil, map[string]interface{}, []interface{}, string, bool, json.Number:
+		return value, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var normalized interface{}
+	if err := decoder.Decode(&normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// arguments resolves the values of a field's arguments. Arguments set to
+// variables that were not given are left out.
+func (e *executor) arguments(arguments []*argument) (map[string]interface{}, error) {
+	args := make(map[string]interface{}, len(arguments))
+	for _, arg := range arguments {
+		if name, ok := arg.value.(variable); ok {
+			if _, given := e.vars[string(name)]; !given {
+				continue
+			}
+		}
+		value, err := e.value(arg.value)
+		if err != nil {
+			return nil, err
+		}
+		args[arg.name] = value
+	}
+	return args, nil
+}
+
+func (e *executor) value(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case variable:
+		return e.vars[string(v)], nil
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved, err := e.value(item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = resolved
+		}
+		return list, nil
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			resolved, err := e.value(item)
+			if err != nil {
+				return nil, err
+			}
+			object[key] = resolved
+		}
+		return object, nil
+	}
+	return constantValue(value), nil
+}
+
+// Filters of list fields: an argument named after a field of the items
+// keeps the items whose field equals it, or one of its values when it is
+// a list, strings compared regardless of case. Suffixes compare instead:
+// _contains for text containing the value, and _gt, _gte, _lt and _lte
+// for numbers and times. order_by sorts the items by a field, descending
+// when it starts with "-", and offset and first page through them.
+var filterOperators = []string{"_contains", "_gte", "_lte", "_gt", "_lt"}
+
+func filterList(value interface{}, args map[string]interface{}) (interface{}, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New("only list fields take arguments")
+	}
+
+	first, err := IntArgument(args, "first", -1)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := IntArgument(args, "offset", 0)
+	if err != nil {
+		return nil, err
+	}
+	if first < -1 || offset < 0 {
+		return nil, errors.New("first and offset must not be negative")
+	}
+	orderBy, err := StringArgument(args, "order_by")
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []interface{}{}
+	for _, item := range list {
+		if matches(item, args) {
+			filtered = append(filtered, item)
+		}
+	}
+	if orderBy != "" {
+		key, descending := strings.TrimPrefix(orderBy, "-"), strings.HasPrefix(orderBy, "-")
+		sort.SliceStable(filtered, func(i, j int) bool {
+			a, b := fieldOf(filtered[i], key), fieldOf(filtered[j], key)
+			if a == nil || b == nil {
+				return a != nil
+			}
+			c, ok := compare(a, b)
+			if descending {
+				return ok && c > 0
+			}
+			return ok && c < 0
+		})
+	}
+
+	filtered = filtered[min(offset, len(filtered)):]
+	if first >= 0 && first < len(filtered) {
+		filtered = filtered[:first]
+	}
+	return filtered, nil
+}
+
+func fieldOf(item interface{}, name string) interface{} {
+	if object, ok := item.(map[string]interface{}); ok {
+		return object[name]
+	}
+	return nil
+}
+
+func matches(item interface{}, args map[string]interface{}) bool {
+	for name, want := range args {
+		if name == "first" || name == "offset" || name == "order_by" {
+			continue
+		}
+
+		key, operator := name, ""
+		for _, suffix := range filterOperators {
+			if strings.HasSuffix(name, suffix) && len(name) > len(suffix) {
+				key, operator = strings.TrimSuffix(name, suffix), suffix
+				break
+			}
+		}
+		got := fieldOf(item, key)
+
+		switch operator {
+		case "":
+			if !equal(got, want) {
+				return false
+			}
+		case "_contains":
+			text, ok := got.(string)
+			pattern, _ := want.(string)
+			if !ok || !strings.Contains(strings.ToLower(text), strings.ToLower(pattern)) {
+				return false
+			}
+		default:
+			c, ok := compare(got, want)
+			if !ok {
+				return false
+			}
+			if operator == "_gt" && c <= 0 || operator == "_gte" && c < 0 ||
+				operator == "_lt" && c >= 0 || operator == "_lte" && c > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func equal(got, want interface{}) bool {
+	if values, ok := want.([]interface{}); ok {
+		for _, value := range values {
+			if equal(got, value) {
+				return true
+			}
+		}
+		return false
+	}
+	if a, ok := number(got); ok {
+		b, ok := number(want)
+		return ok && a == b
+	}
+	if a, ok := got.(string); ok {
+		b, ok := want.(string)
+		return ok && strings.EqualFold(a, b)
+	}
+	return got == want
+}
+
+// compare orders two numbers, or two strings such as RFC 3339 times.
+func compare(a, b interface{}) (int, bool) {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+	x, ok := a.(string)
+	y, ok2 := b.(string)
+	if !ok || !ok2 {
+		return 0, false
+	}
+	return strings.Compare(x, y), true
+}
+
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// IntArgument returns an integer argument, or fallback when it is not
+// given.
+func IntArgument(args map[string]interface{}, name string, fallback int) (int, error) {
+	value, ok := args[name]
+	if !ok || value == nil {
+		return fallback, nil
+	}
+	f, ok := number(value)
+	if !ok || f != math.Trunc(f) || math.Abs(f) > math.MaxInt32 {
+		return 0, fmt.Errorf("argument %q must be an integer", name)
+	}
+	return int(f), nil
+}
+
+// StringArgument returns a string argument, or "" when it is not given.
+func StringArgument(args map[string]interface{}, name string) (string, error) {
+	value, ok := args[name]
+	if !ok || value == nil {
+		return "", nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("argument %q must be a string", name)
+	}
+	return s, nil
+}
+
+// StringsArgument returns an argument given as a string or a list of
+// strings.
+func StringsArgument(args map[string]interface{}, name string) ([]string, error) {
+	switch value := args[name].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{value}, nil
+	case []interface{}:
+		values := make([]string, len(value))
+		for i, item := range value {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("argument %q must be a list of strings", name)
+			}
+			values[i] = s
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("argument %q must be a string or a list of strings", name)
+}
@@ -0,0 +1,226 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testRoot() map[string]interface{} {
+	jobs := []interface{}{
+		map[string]interface{}{"id": "j1", "status": "completed", "pages": 3, "tender": map[string]interface{}{"id": "t1"}},
+		map[string]interface{}{"id": "j2", "status": "failed", "pages": 1, "tender": map[string]interface{}{"id": "t1"}},
+		map[string]interface{}{"id": "j3", "status": "completed", "pages": 8, "tender": map[string]interface{}{"id": "t2"}},
+	}
+	return map[string]interface{}{
+		"jobs": jobs,
+		"job": Resolver(func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			id, err := StringArgument(args, "id")
+			if err != nil {
+				return nil, err
+			}
+			for _, job := range jobs {
+				if job.(map[string]interface{})["id"] == id {
+					return job, nil
+				}
+			}
+			return nil, errors.New("job not found")
+		}),
+	}
+}
+
+// run executes a query against testRoot and returns its response as JSON.
+func run(t *testing.T, query string, variables map[string]interface{}) string {
+	t.Helper()
+	resp := Execute(context.Background(), testRoot(), Request{Query: query, Variables: variables})
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr string
+	}{
+		{"empty document", "", "the document has no query"},
+		{"unclosed selection", "{ jobs { id }", "unexpected"},
+		{"mutation", "mutation { deleteJob(id: 1) }", "only queries are supported"},
+		{"subscription", "subscription { jobs { id } }", "only queries are supported"},
+		{"duplicate fragment", "{ jobs { ...f } } fragment f on Job { id } fragment f on Job { id }", `fragment "f" is defined twice`},
+		{"unterminated string", `{ job(id: "j1) { id } }`, "string"},
+		{"missing argument value", "{ job(id:) { id } }", "unexpected"},
+		{"stray token", "{ jobs { id } } }", "unexpected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse() error = %v, want a syntax error", err)
+			}
+			if !strings.Contains(syntaxErr.Message, tt.wantErr) {
+				t.Errorf("Parse() error = %q, want it to mention %q", syntaxErr.Message, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseErrorLocation(t *testing.T) {
+	_, err := Parse("{\n  jobs {\n    id\n  }\n  mutation\n}}")
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("Parse() error = %v, want a syntax error", err)
+	}
+	if syntaxErr.Line != 6 || syntaxErr.Column != 2 {
+		t.Errorf("Parse() error at %d:%d, want 6:2", syntaxErr.Line, syntaxErr.Column)
+	}
+
+	got := run(t, "{ jobs { id } ", nil)
+	if !strings.Contains(got, `"errors":[{"message":"syntax error: `) || !strings.Contains(got, `"locations":[{"line":1,"column":`) || strings.Contains(got, `"data"`) {
+		t.Errorf("Execute() = %s", got)
+	}
+}
+
+func TestExecuteNesting(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "nested fields",
+			query: `{ job(id: "j1") { id tender { id } } }`,
+			want:  `{"data":{"job":{"id":"j1","tender":{"id":"t1"}}}}`,
+		},
+		{
+			name:  "aliases",
+			query: `{ first: job(id: "j1") { id } last: job(id: "j3") { key: id } }`,
+			want:  `{"data":{"first":{"id":"j1"},"last":{"key":"j3"}}}`,
+		},
+		{
+			name:  "fragments",
+			query: `{ job(id: "j2") { ...fields ... on Job { tender { id } } } } fragment fields on Job { id status }`,
+			want:  `{"data":{"job":{"id":"j2","status":"failed","tender":{"id":"t1"}}}}`,
+		},
+		{
+			name:  "filtered list",
+			query: `{ jobs(status: "completed", order_by: "-pages") { id } }`,
+			want:  `{"data":{"jobs":[{"id":"j3"},{"id":"j1"}]}}`,
+		},
+		{
+			name:  "paginated list",
+			query: `{ jobs(first: 1, offset: 1) { id } }`,
+			want:  `{"data":{"jobs":[{"id":"j2"}]}}`,
+		},
+		{
+			name:  "skipped field",
+			query: `{ job(id: "j1") { id status @skip(if: true) } }`,
+			want:  `{"data":{"job":{"id":"j1"}}}`,
+		},
+		{
+			name:  "resolver error",
+			query: `{ job(id: "j9") { id } }`,
+			want:  `{"data":{"job":null},"errors":[{"message":"job not found","locations":[{"line":1,"column":3}],"path":["job"]}]}`,
+		},
+		{
+			name:  "object without a selection",
+			query: `{ job(id: "j1") { tender } }`,
+			want:  `{"data":{"job":{"tender":null}},"errors":[{"message":"field \"tender\" is an object and needs a selection of its fields","locations":[{"line":1,"column":19}],"path":["job","tender"]}]}`,
+		},
+		{
+			name:  "scalar with a selection",
+			query: `{ job(id: "j1") { id { value } } }`,
+			want:  `{"data":{"job":{"id":null}},"errors":[{"message":"field \"id\" is a scalar and has no fields","locations":[{"line":1,"column":19}],"path":["job","id"]}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := run(t, tt.query, nil); got != tt.want {
+				t.Errorf("Execute() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteMaxDepth(t *testing.T) {
+	nested := map[string]interface{}{"id": "leaf"}
+	for i := 0; i < maxDepth+5; i++ {
+		nested = map[string]interface{}{"child": nested}
+	}
+	query := "{ root " + strings.Repeat("{ child ", maxDepth+5) + "{ id }" + strings.Repeat(" }", maxDepth+5) + " }"
+
+	resp := Execute(context.Background(), map[string]interface{}{"root": nested}, Request{Query: query})
+	if len(resp.Errors) != 1 || resp.Errors[0].Message != "the query is nested too deeply" {
+		t.Fatalf("Execute() errors = %+v", resp.Errors)
+	}
+	if len(resp.Errors[0].Path) != maxDepth+1 {
+		t.Errorf("error path has %d fields, want %d", len(resp.Errors[0].Path), maxDepth+1)
+	}
+}
+
+func TestExecuteVariables(t *testing.T) {
+	query := `query Job($id: String!, $skip: Boolean = false) { job(id: $id) { id status @skip(if: $skip) } }`
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		want      string
+	}{
+		{
+			name:      "given",
+			query:     query,
+			variables: map[string]interface{}{"id": "j3"},
+			want:      `{"data":{"job":{"id":"j3","status":"completed"}}}`,
+		},
+		{
+			name:      "overriding a default",
+			query:     query,
+			variables: map[string]interface{}{"id": "j3", "skip": true},
+			want:      `{"data":{"job":{"id":"j3"}}}`,
+		},
+		{
+			name:  "required missing",
+			query: query,
+			want:  `{"errors":[{"message":"variable $id is required"}]}`,
+		},
+		{
+			name:      "required null",
+			query:     query,
+			variables: map[string]interface{}{"id": nil},
+			want:      `{"errors":[{"message":"variable $id must not be null"}]}`,
+		},
+		{
+			name:  "undefined",
+			query: `query { job(id: $id) { id } }`,
+			want:  `{"errors":[{"message":"variable $id is not defined"}]}`,
+		},
+		{
+			name:  "undefined in a fragment",
+			query: `query Job($id: String) { job(id: $id) { ...f } } fragment f on Job { status @include(if: $show) }`,
+			want:  `{"errors":[{"message":"variable $show is not defined"}]}`,
+		},
+		{
+			name:      "in a list filter",
+			query:     `query Jobs($status: String) { jobs(status: $status) { id } }`,
+			variables: map[string]interface{}{"status": "failed"},
+			want:      `{"data":{"jobs":[{"id":"j2"}]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := run(t, tt.query, tt.variables); got != tt.want {
+				t.Errorf("Execute() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
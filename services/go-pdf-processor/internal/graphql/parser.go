@@ -0,0 +1,494 @@
+package graphql
+
+import (
+	"strconv"
+)
+
+// Document is a parsed query document: its operations and the fragments
+// they may spread.
+type Document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	name       string
+	variables  []*variableDefinition
+	selections []selection
+}
+
+type variableDefinition struct {
+	name         string
+	nonNull      bool
+	defaultValue interface{}
+	hasDefault   bool
+}
+
+// selection is a *field, *fragmentSpread or *inlineFragment.
+type selection interface{}
+
+type field struct {
+	alias      string
+	name       string
+	arguments  []*argument
+	directives []*directive
+	selections []selection
+	pos        int
+}
+
+// responseKey is the key the field is reported under.
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type argument struct {
+	name  string
+	value interface{}
+}
+
+type directive struct {
+	name      string
+	arguments []*argument
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+	pos        int
+}
+
+type inlineFragment struct {
+	directives []*directive
+	selections []selection
+}
+
+type fragment struct {
+	name       string
+	selections []selection
+}
+
+// Values are parsed into Go values: int64, float64, string, bool, nil,
+// []interface{} and map[string]interface{}, with variables left as
+// variable and enum values as enumValue until the query is executed.
+type variable string
+
+type enumValue string
+
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+// Parse parses a query document. Only queries are supported; mutations
+// and subscriptions are refused.
+func Parse(query string) (*Document, error) {
+	p := &parser{lex: &lexer{src: query}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	doc := &Document{fragments: make(map[string]*fragment)}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.is("{"):
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{selections: selections})
+		case p.isName("query"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.isName("fragment"):
+			f, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[f.name]; ok {
+				return nil, p.lex.errorAt(p.tok.pos, "fragment %q is defined twice", f.name)
+			}
+			doc.fragments[f.name] = f
+		case p.isName("mutation"), p.isName("subscription"):
+			return nil, p.lex.errorAt(p.tok.pos, "only queries are supported")
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, p.lex.errorAt(p.tok.pos, "the document has no query")
+	}
+	return doc, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+// is reports whether the current token is the punctuator value.
+func (p *parser) is(value string) bool {
+	return p.tok.kind == tokenPunctuator && p.tok.value == value
+}
+
+func (p *parser) isName(value string) bool {
+	return p.tok.kind == tokenName && p.tok.value == value
+}
+
+func (p *parser) unexpected() error {
+	return p.lex.errorAt(p.tok.pos, "unexpected %s", p.tok)
+}
+
+// expect consumes the punctuator value.
+func (p *parser) expect(value string) error {
+	if !p.is(value) {
+		return p.lex.errorAt(p.tok.pos, "expected %q, found %s", value, p.tok)
+	}
+	return p.advance()
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.lex.errorAt(p.tok.pos, "expected a name, found %s", p.tok)
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+func (p *parser) operation() (*operation, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	op := &operation{}
+	if p.tok.kind == tokenName {
+		op.name = p.tok.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if p.is("(") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		for !p.is(")") {
+			definition, err := p.variableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			op.variables = append(op.variables, definition)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = selections
+	return op, nil
+}
+
+func (p *parser) variableDefinition() (*variableDefinition, error) {
+	if err := p.expect("$"); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	nonNull, err := p.typeReference()
+	if err != nil {
+		return nil, err
+	}
+
+	definition := &variableDefinition{name: name, nonNull: nonNull}
+	if p.is("=") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if definition.defaultValue, err = p.value(true); err != nil {
+			return nil, err
+		}
+		definition.hasDefault = true
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	return definition, nil
+}
+
+// typeReference skips a type, as values are not checked against types,
+// and reports whether it is non-null.
+func (p *parser) typeReference() (bool, error) {
+	if p.is("[") {
+		if err := p.advance(); err != nil {
+			return false, err
+		}
+		if _, err := p.typeReference(); err != nil {
+			return false, err
+		}
+		if err := p.expect("]"); err != nil {
+			return false, err
+		}
+	} else if _, err := p.name(); err != nil {
+		return false, err
+	}
+	if p.is("!") {
+		return true, p.advance()
+	}
+	return false, nil
+}
+
+func (p *parser) fragment() (*fragment, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.isName("on") {
+		return nil, p.unexpected()
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isName("on") {
+		return nil, p.lex.errorAt(p.tok.pos, "expected \"on\", found %s", p.tok)
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if _, err := p.name(); err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &fragment{name: name, selections: selections}, nil
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var selections []selection
+	for !p.is("}") {
+		if p.tok.kind == tokenEOF {
+			return nil, p.unexpected()
+		}
+		s, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, s)
+	}
+	if len(selections) == 0 {
+		return nil, p.lex.errorAt(p.tok.pos, "empty selection set")
+	}
+	return selections, p.advance()
+}
+
+func (p *parser) selection() (selection, error) {
+	if !p.is("...") {
+		return p.field()
+	}
+
+	pos := p.tok.pos
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokenName && !p.isName("on") {
+		spread := &fragmentSpread{name: p.tok.value, pos: pos}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		var err error
+		spread.directives, err = p.directives()
+		return spread, err
+	}
+
+	// Type conditions are not checked: values have no types
+	if p.isName("on") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if _, err := p.name(); err != nil {
+			return nil, err
+		}
+	}
+	inline := &inlineFragment{}
+	var err error
+	if inline.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	inline.selections, err = p.selectionSet()
+	return inline, err
+}
+
+func (p *parser) field() (*field, error) {
+	f := &field{pos: p.tok.pos}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	f.name = name
+	if p.is(":") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		f.alias = name
+		if f.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if f.arguments, err = p.arguments(); err != nil {
+		return nil, err
+	}
+	if f.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.is("{") {
+		if f.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) arguments() ([]*argument, error) {
+	if !p.is("(") {
+		return nil, nil
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var arguments []*argument
+	for !p.is(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.value(false)
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, &argument{name: name, value: value})
+	}
+	return arguments, p.advance()
+}
+
+func (p *parser) directives() ([]*directive, error) {
+	var directives []*directive
+	for p.is("@") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		arguments, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, &directive{name: name, arguments: arguments})
+	}
+	return directives, nil
+}
+
+// value parses a value; constant values, such as variable defaults, may
+// not refer to variables.
+func (p *parser) value(constant bool) (interface{}, error) {
+	tok := p.tok
+	switch {
+	case p.is("$") && !constant:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		return variable(name), err
+	case p.is("["):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		list := []interface{}{}
+		for !p.is("]") {
+			if p.tok.kind == tokenEOF {
+				return nil, p.unexpected()
+			}
+			item, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, p.advance()
+	case p.is("{"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		object := map[string]interface{}{}
+		for !p.is("}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if object[name], err = p.value(constant); err != nil {
+				return nil, err
+			}
+		}
+		return object, p.advance()
+	case tok.kind == tokenInt:
+		n, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, p.lex.errorAt(tok.pos, "integer %s out of range", tok.value)
+		}
+		return n, p.advance()
+	case tok.kind == tokenFloat:
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, p.lex.errorAt(tok.pos, "float %s out of range", tok.value)
+		}
+		return f, p.advance()
+	case tok.kind == tokenString:
+		return tok.value, p.advance()
+	case tok.kind == tokenName:
+		var value interface{}
+		switch tok.value {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+			value = nil
+		default:
+			value = enumValue(tok.value)
+		}
+		return value, p.advance()
+	}
+	return nil, p.unexpected()
+}
@@ -0,0 +1,253 @@
+package amqp
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"cotai-pdf-processor/internal/config"
+	"cotai-pdf-processor/internal/intake"
+	"cotai-pdf-processor/internal/processor"
+
+	amqp091 "github.com/rabbitmq/amqp091-go"
+)
+
+// New-tender events are consumed from AMQP_QUEUE with manual
+// acknowledgment. A message is acknowledged once all its jobs are
+// processed, and rejected to the dead-letter exchange if it cannot become
+// jobs or one of them fails; the broker delivers at most WORKER_COUNT
+// unacknowledged messages at a time. Messages in hand when the connection
+// drops are delivered again.
+
+const (
+	// jobPollInterval is how often the status of a message's jobs is read
+	// while waiting for them; they may run on another replica.
+	jobPollInterval = 2 * time.Second
+
+	// reconnectDelay is the wait before connecting again after the broker
+	// connection is lost.
+	reconnectDelay = 5 * time.Second
+
+	// minRetryDelay and maxRetryDelay bound the wait before submitting a
+	// message again after the worker pool refused it.
+	minRetryDelay = time.Second
+	maxRetryDelay = time.Minute
+)
+
+// Consumer feeds AMQP messages to the worker pool.
+type Consumer struct {
+	cfg       *config.Config
+	processor *processor.PDFProcessor
+	submitter *intake.Submitter
+	done      chan struct{}
+	cancel    context.CancelFunc
+}
+
+// NewConsumer creates a consumer of cfg.AMQPQueue at cfg.AMQPURL.
+func NewConsumer(cfg *config.Config, pdfProcessor *processor.PDFProcessor, submitter *intake.Submitter) (*Consumer, error) {
+	if cfg.AMQPQueue == "" {
+		return nil, errors.New("amqp intake needs AMQP_QUEUE")
+	}
+	return &Consumer{cfg: cfg, processor: pdfProcessor, submitter: submitter, done: make(chan struct{})}, nil
+}
+
+// Start consumes messages until Stop is called, reconnecting when the
+// connection is lost.
+func (c *Consumer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	go func() {
+		defer close(c.done)
+		for {
+			if err := c.consume(ctx); err != nil {
+				log.Printf("AMQP intake interrupted: %v", err)
+			}
+			select {
+			case <-time.After(reconnectDelay):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	log.Printf("AMQP intake consuming %s", c.cfg.AMQPQueue)
+}
+
+// Stop stops consuming; messages whose jobs are still running are
+// delivered again to the next consumer.
+func (c *Consumer) Stop() {
+	if c.cancel == nil {
+		return
+	}
+	c.cancel()
+	<-c.done
+	log.Println("AMQP intake stopped")
+}
+
+// consume runs one connection until it drops or ctx is done.
+func (c *Consumer) consume(ctx context.Context) error {
+	conn, err := amqp091.Dial(c.cfg.AMQPURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open channel: %w", err)
+	}
+	if err := c.declare(ch); err != nil {
+		return err
+	}
+	if err := ch.Qos(c.cfg.WorkerCount, 0, false); err != nil {
+		return fmt.Errorf("failed to set prefetch: %w", err)
+	}
+	deliveries, err := ch.Consume(c.cfg.AMQPQueue, c.cfg.ServiceName, false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to consume %s: %w", c.cfg.AMQPQueue, err)
+	}
+
+	// Handlers are waited for before the connection closes, so their
+	// acknowledgments are sent; those still waiting for jobs stop, as the
+	// broker delivers their messages again once the connection is gone
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for {
+		select {
+		case d, ok := <-deliveries:
+			if !ok {
+				return errors.New("delivery channel closed")
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c.handle(connCtx, d)
+			}()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// declare creates the queue, and the dead-letter exchange with a queue
+// bound to it when AMQP_DEAD_LETTER_EXCHANGE is set.
+func (c *Consumer) declare(ch *amqp091.Channel) error {
+	var args amqp091.Table
+	if dlx := c.cfg.AMQPDeadLetterExchange; dlx != "" {
+		if err := ch.ExchangeDeclare(dlx, "fanout", true, false, false, false, nil); err != nil {
+			return fmt.Errorf("failed to declare exchange %s: %w", dlx, err)
+		}
+		dead := c.cfg.AMQPQueue + ".dead"
+		if _, err := ch.QueueDeclare(dead, true, false, false, false, nil); err != nil {
+			return fmt.Errorf("failed to declare queue %s: %w", dead, err)
+		}
+		if err := ch.QueueBind(dead, "", dlx, false, nil); err != nil {
+			return fmt.Errorf("failed to bind queue %s: %w", dead, err)
+		}
+		args = amqp091.Table{"x-dead-letter-exchange": dlx}
+	}
+	if _, err := ch.QueueDeclare(c.cfg.AMQPQueue, true, false, false, false, args); err != nil {
+		return fmt.Errorf("failed to declare queue %s: %w", c.cfg.AMQPQueue, err)
+	}
+	return nil
+}
+
+// handle submits the message's jobs, waits for them and settles the
+// message. Messages left unsettled when ctx is done are delivered again.
+func (c *Consumer) handle(ctx context.Context, d amqp091.Delivery) {
+	// Messages without an ID are identified by their content, so a
+	// redelivered message queues the same jobs
+	id := d.MessageId
+	if id == "" {
+		id = fmt.Sprintf("%x", sha256.Sum256(d.Body))
+	}
+	source := fmt.Sprintf("amqp:%s/%s", c.cfg.AMQPQueue, id)
+
+	event, err := intake.Decode(d.Body)
+	if err != nil {
+		c.reject(d, source, err)
+		return
+	}
+
+	var jobs []*processor.ProcessingJob
+	for delay := minRetryDelay; ; delay = min(delay*2, maxRetryDelay) {
+		jobs, err = c.submitter.Submit(ctx, event, source)
+		if err == nil {
+			break
+		}
+		if errors.Is(err, intake.ErrInvalidEvent) {
+			c.reject(d, source, err)
+			return
+		}
+		if errors.Is(err, processor.ErrPoolClosed) {
+			return
+		}
+		log.Printf("Failed to queue jobs of AMQP message %s, retrying in %v: %v", source, delay, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	failed, err := c.wait(ctx, jobs)
+	if err != nil {
+		return
+	}
+	if failed != nil {
+		c.reject(d, source, fmt.Errorf("job %s failed: %s", failed.ID, failed.Error))
+		return
+	}
+	if err := d.Ack(false); err != nil {
+		log.Printf("Failed to acknowledge AMQP message %s: %v", source, err)
+	}
+}
+
+// wait polls the jobs until all are finished, returning the first that
+// failed, if any. Cancelled jobs count as handled.
+func (c *Consumer) wait(ctx context.Context, jobs []*processor.ProcessingJob) (*processor.ProcessingJob, error) {
+	pending := jobs
+	for len(pending) > 0 {
+		select {
+		case <-time.After(jobPollInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		var running []*processor.ProcessingJob
+		for _, job := range pending {
+			current, err := c.processor.GetJob(ctx, job.ID)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				log.Printf("Failed to read status of job %s: %v", job.ID, err)
+				running = append(running, job)
+				continue
+			}
+			switch current.Status {
+			case "completed", "cancelled":
+			case "failed":
+				return current, nil
+			default:
+				running = append(running, job)
+			}
+		}
+		pending = running
+	}
+	return nil, nil
+}
+
+// reject sends the message to the dead-letter exchange, or drops it when
+// there is none.
+func (c *Consumer) reject(d amqp091.Delivery, source string, reason error) {
+	log.Printf("Rejecting AMQP message %s: %v", source, reason)
+	if err := d.Nack(false, false); err != nil {
+		log.Printf("Failed to reject AMQP message %s: %v", source, err)
+	}
+}
@@ -0,0 +1,157 @@
+package intake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"cotai-pdf-processor/internal/config"
+	"cotai-pdf-processor/internal/download"
+	"cotai-pdf-processor/internal/processor"
+	"cotai-pdf-processor/internal/webhook"
+
+	"github.com/google/uuid"
+)
+
+// Besides the HTTP API, jobs arrive as events from the platform's message
+// brokers. Each broker consumer decodes its messages into Events and hands
+// them to a Submitter, which queues one job per document.
+
+// ErrInvalidEvent is returned for messages that can never become jobs, so
+// consumers drop them rather than retry.
+var ErrInvalidEvent = errors.New("invalid intake event")
+
+// Event announces a tender whose documents are to be processed.
+type Event struct {
+	EventID   string          `json:"event_id,omitempty"`
+	TenderID  string          `json:"tender_id"`
+	TenantID  string          `json:"tenant_id,omitempty"`
+	UserID    string          `json:"user_id,omitempty"`
+	Documents []Document      `json:"documents"`
+	Priority  string          `json:"priority,omitempty"`
+	Profile   string          `json:"profile,omitempty"`
+	Options   json.RawMessage `json:"options,omitempty"`
+
+	// CallbackURL is posted the summary of each of the event's jobs
+	CallbackURL string `json:"callback_url,omitempty"`
+
+	// RunAt, an RFC 3339 time, schedules the event's jobs
+	RunAt string `json:"run_at,omitempty"`
+}
+
+// Document is a file of a tender, fetched from its URL by the downloader.
+// DependsOn lists earlier documents of the event, by index, whose jobs
+// must complete before its job runs, e.g. the edital of an anexo.
+type Document struct {
+	URL       string `json:"url"`
+	Name      string `json:"name,omitempty"`
+	DependsOn []int  `json:"depends_on,omitempty"`
+}
+
+// Decode parses an event, failing with ErrInvalidEvent for malformed ones.
+func Decode(data []byte) (*Event, error) {
+	var event Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	if len(event.Documents) == 0 {
+		return nil, fmt.Errorf("%w: no documents", ErrInvalidEvent)
+	}
+	for i, doc := range event.Documents {
+		if doc.URL == "" {
+			return nil, fmt.Errorf("%w: document %d has no url", ErrInvalidEvent, i)
+		}
+		for _, dep := range doc.DependsOn {
+			if dep < 0 || dep >= i {
+				return nil, fmt.Errorf("%w: document %d may only depend on earlier documents", ErrInvalidEvent, i)
+			}
+		}
+	}
+	if err := processor.ValidatePriority(event.Priority); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	if err := webhook.ValidateURL(context.Background(), event.CallbackURL); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	if _, err := processor.ParseRunAt(event.RunAt); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	return &event, nil
+}
+
+// Submitter turns events into jobs and queues them.
+type Submitter struct {
+	cfg        *config.Config
+	processor  *processor.PDFProcessor
+	workerPool *processor.WorkerPool
+}
+
+func NewSubmitter(cfg *config.Config, pdfProcessor *processor.PDFProcessor, workerPool *processor.WorkerPool) *Submitter {
+	return &Submitter{cfg: cfg, processor: pdfProcessor, workerPool: workerPool}
+}
+
+// Submit queues a job for each document of the event. The job IDs derive
+// from source, which identifies the message, so a message delivered again
+// after a partial submission queues the same jobs again rather than new
+// ones. Document URLs are held to those the API accepts.
+// Errors other than ErrInvalidEvent are worth retrying.
+func (s *Submitter) Submit(ctx context.Context, event *Event, source string) ([]*processor.ProcessingJob, error) {
+	runAt, err := processor.ParseRunAt(event.RunAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	for i, doc := range event.Documents {
+		if err := download.ValidateURL(ctx, doc.URL, s.cfg.DownloadAllowPrivateNetworks); err != nil {
+			return nil, fmt.Errorf("%w: document %d: %v", ErrInvalidEvent, i, err)
+		}
+	}
+
+	jobs := make([]*processor.ProcessingJob, len(event.Documents))
+	for i, doc := range event.Documents {
+		job := &processor.ProcessingJob{
+			ID:          uuid.NewSHA1(uuid.NameSpaceURL, []byte(fmt.Sprintf("%s#%d", source, i))).String(),
+			FileURL:     doc.URL,
+			TenderID:    event.TenderID,
+			UserID:      event.UserID,
+			TenantID:    event.TenantID,
+			Priority:    event.Priority,
+			CallbackURL: event.CallbackURL,
+			RunAt:       runAt,
+			Status:      "queued",
+			CreatedAt:   time.Now(),
+			Options:     processor.DefaultProcessingOptions(),
+			Metadata:    map[string]interface{}{"source": source},
+		}
+		if doc.Name != "" {
+			job.Metadata["original_filename"] = doc.Name
+		}
+		if event.EventID != "" {
+			job.Metadata["event_id"] = event.EventID
+		}
+		for _, dep := range doc.DependsOn {
+			job.DependsOn = append(job.DependsOn, jobs[dep].ID)
+		}
+
+		err := s.processor.ResolveOptions(ctx, job, event.Profile, event.Options)
+		if errors.Is(err, processor.ErrProcessingProfileNotFound) || errors.Is(err, processor.ErrInvalidOptions) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		jobs[i] = job
+	}
+
+	for _, job := range jobs {
+		if err := s.processor.SaveJob(ctx, job); err != nil {
+			log.Printf("Failed to save job %s: %v", job.ID, err)
+		}
+		if err := s.workerPool.SubmitJob(job); err != nil {
+			return nil, err
+		}
+	}
+	return jobs, nil
+}
@@ -0,0 +1,165 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cotai-pdf-processor/internal/config"
+	"cotai-pdf-processor/internal/intake"
+	"cotai-pdf-processor/internal/processor"
+
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// New-tender events are read from KAFKA_TOPIC in the KAFKA_GROUP_ID
+// consumer group. A message's offset is committed once all its jobs are
+// queued, so a crash before that delivers it again; messages that cannot
+// become jobs are logged and committed.
+
+const (
+	// minRetryDelay and maxRetryDelay bound the wait before submitting a
+	// message again after the worker pool refused it.
+	minRetryDelay = time.Second
+	maxRetryDelay = time.Minute
+
+	// commitTimeout bounds an offset commit, which is retried on failure.
+	commitTimeout = 10 * time.Second
+)
+
+// Consumer feeds Kafka messages to the worker pool.
+type Consumer struct {
+	reader    *kafkago.Reader
+	submitter *intake.Submitter
+	done      chan struct{}
+	cancel    context.CancelFunc
+}
+
+// NewConsumer creates a consumer of cfg.KafkaTopic on the brokers in
+// cfg.KafkaBrokers.
+func NewConsumer(cfg *config.Config, submitter *intake.Submitter) (*Consumer, error) {
+	if cfg.KafkaTopic == "" || cfg.KafkaGroupID == "" {
+		return nil, errors.New("kafka intake needs KAFKA_TOPIC and KAFKA_GROUP_ID")
+	}
+	reader := kafkago.NewReader(kafkago.ReaderConfig{
+		Brokers: strings.Split(cfg.KafkaBrokers, ","),
+		GroupID: cfg.KafkaGroupID,
+		Topic:   cfg.KafkaTopic,
+		// Offsets are committed explicitly, one message at a time
+		CommitInterval: 0,
+		MaxWait:        time.Second,
+	})
+	return &Consumer{reader: reader, submitter: submitter, done: make(chan struct{})}, nil
+}
+
+// Start consumes messages until Stop is called.
+func (c *Consumer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	go func() {
+		defer close(c.done)
+		c.run(ctx)
+	}()
+	log.Printf("Kafka intake consuming %s", c.reader.Config().Topic)
+}
+
+// Stop stops consuming and leaves the consumer group; uncommitted messages
+// go to the next member.
+func (c *Consumer) Stop() {
+	if c.cancel == nil {
+		return
+	}
+	c.cancel()
+	<-c.done
+	if err := c.reader.Close(); err != nil {
+		log.Printf("Failed to close Kafka reader: %v", err)
+	}
+	log.Println("Kafka intake stopped")
+}
+
+func (c *Consumer) run(ctx context.Context) {
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Failed to fetch Kafka message: %v", err)
+			if !sleep(ctx, minRetryDelay) {
+				return
+			}
+			continue
+		}
+
+		if !c.handle(ctx, msg) {
+			return
+		}
+		if !c.commit(ctx, msg) {
+			return
+		}
+	}
+}
+
+// handle submits the message's jobs, retrying while the worker pool refuses
+// them. It returns false when the consumer is stopping with the message
+// not submitted.
+func (c *Consumer) handle(ctx context.Context, msg kafkago.Message) bool {
+	source := fmt.Sprintf("kafka:%s/%d/%d", msg.Topic, msg.Partition, msg.Offset)
+
+	event, err := intake.Decode(msg.Value)
+	if err != nil {
+		log.Printf("Dropping Kafka message %s: %v", source, err)
+		return true
+	}
+
+	delay := minRetryDelay
+	for {
+		jobs, err := c.submitter.Submit(ctx, event, source)
+		switch {
+		case err == nil:
+			log.Printf("Kafka message %s queued %d jobs for tender %s", source, len(jobs), event.TenderID)
+			return true
+		case errors.Is(err, intake.ErrInvalidEvent):
+			log.Printf("Dropping Kafka message %s: %v", source, err)
+			return true
+		case errors.Is(err, processor.ErrPoolClosed):
+			return false
+		}
+
+		log.Printf("Failed to queue jobs of Kafka message %s, retrying in %v: %v", source, delay, err)
+		if !sleep(ctx, delay) {
+			return false
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+// commit commits the message's offset, retrying until it succeeds or the
+// consumer stops.
+func (c *Consumer) commit(ctx context.Context, msg kafkago.Message) bool {
+	for {
+		commitCtx, cancel := context.WithTimeout(ctx, commitTimeout)
+		err := c.reader.CommitMessages(commitCtx, msg)
+		cancel()
+		if err == nil {
+			return true
+		}
+		log.Printf("Failed to commit Kafka offset %d of %s/%d: %v", msg.Offset, msg.Topic, msg.Partition, err)
+		if !sleep(ctx, minRetryDelay) {
+			return false
+		}
+	}
+}
+
+// sleep waits for d, returning false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,115 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"cotai-pdf-processor/internal/intake"
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// submitTimeout bounds the submission of one message's jobs.
+	submitTimeout = 30 * time.Second
+
+	// maxRedeliveryDelay bounds the wait before a message the worker pool
+	// refused is delivered again; the wait doubles with each delivery.
+	maxRedeliveryDelay = time.Minute
+)
+
+// Consumer feeds the messages of the intake stream to the worker pool
+// through the NATS_DURABLE consumer. A message is acknowledged once its
+// jobs are queued and terminated if it cannot become jobs; otherwise it is
+// delivered again after a delay.
+type Consumer struct {
+	client    *Client
+	submitter *intake.Submitter
+	consumer  jetstream.Consumer
+	consuming jetstream.ConsumeContext
+}
+
+// NewConsumer creates the intake stream unless it exists, and the durable
+// consumer on it, shared by all replicas.
+func NewConsumer(ctx context.Context, client *Client, submitter *intake.Submitter) (*Consumer, error) {
+	cfg := client.cfg
+	ctx, cancel := context.WithTimeout(ctx, setupTimeout)
+	defer cancel()
+
+	if err := client.ensureStream(ctx, cfg.NATSIntakeStream, cfg.NATSIntakeSubject); err != nil {
+		return nil, err
+	}
+	consumer, err := client.js.CreateOrUpdateConsumer(ctx, cfg.NATSIntakeStream, jetstream.ConsumerConfig{
+		Durable:       cfg.NATSDurable,
+		FilterSubject: cfg.NATSIntakeSubject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		MaxAckPending: cfg.WorkerCount * 2,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up NATS consumer %s: %w", cfg.NATSDurable, err)
+	}
+	return &Consumer{client: client, submitter: submitter, consumer: consumer}, nil
+}
+
+// Start consumes messages until Stop is called.
+func (c *Consumer) Start() error {
+	consuming, err := c.consumer.Consume(c.handle, jetstream.ConsumeErrHandler(func(_ jetstream.ConsumeContext, err error) {
+		log.Printf("NATS intake error: %v", err)
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to consume %s: %w", c.client.cfg.NATSIntakeStream, err)
+	}
+	c.consuming = consuming
+	log.Printf("NATS intake consuming %s", c.client.cfg.NATSIntakeSubject)
+	return nil
+}
+
+// Stop stops consuming; unacknowledged messages are delivered again.
+func (c *Consumer) Stop() {
+	if c.consuming == nil {
+		return
+	}
+	c.consuming.Stop()
+	log.Println("NATS intake stopped")
+}
+
+func (c *Consumer) handle(msg jetstream.Msg) {
+	meta, err := msg.Metadata()
+	if err != nil {
+		log.Printf("Dropping NATS message on %s: %v", msg.Subject(), err)
+		msg.Term()
+		return
+	}
+	source := fmt.Sprintf("nats:%s/%d", meta.Stream, meta.Sequence.Stream)
+
+	event, err := intake.Decode(msg.Data())
+	if err != nil {
+		log.Printf("Dropping NATS message %s: %v", source, err)
+		msg.Term()
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), submitTimeout)
+	defer cancel()
+	jobs, err := c.submitter.Submit(ctx, event, source)
+	switch {
+	case err == nil:
+		log.Printf("NATS message %s queued %d jobs for tender %s", source, len(jobs), event.TenderID)
+		if err := msg.Ack(); err != nil {
+			log.Printf("Failed to acknowledge NATS message %s: %v", source, err)
+		}
+	case errors.Is(err, intake.ErrInvalidEvent):
+		log.Printf("Dropping NATS message %s: %v", source, err)
+		msg.Term()
+	case errors.Is(err, processor.ErrPoolClosed):
+		msg.Nak()
+	default:
+		delay := min(time.Second<<min(meta.NumDelivered, 6), maxRedeliveryDelay)
+		log.Printf("Failed to queue jobs of NATS message %s, redelivering in %v: %v", source, delay, err)
+		msg.NakWithDelay(delay)
+	}
+}
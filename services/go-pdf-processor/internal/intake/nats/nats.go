@@ -0,0 +1,74 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"cotai-pdf-processor/internal/config"
+
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATS JetStream is both a source of new-tender events, read by a durable
+// consumer, and the destination of finished-job events, so other nCotAi
+// services learn about results without polling Redis.
+
+// setupTimeout bounds the stream and consumer lookups made on startup.
+const setupTimeout = 30 * time.Second
+
+// Client is a JetStream connection.
+type Client struct {
+	cfg  *config.Config
+	conn *natsgo.Conn
+	js   jetstream.JetStream
+}
+
+// Connect connects to cfg.NATSURL and creates the result stream unless it
+// exists.
+func Connect(ctx context.Context, cfg *config.Config) (*Client, error) {
+	conn, err := natsgo.Connect(cfg.NATSURL, natsgo.Name(cfg.ServiceName), natsgo.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c := &Client{cfg: cfg, conn: conn, js: js}
+	ctx, cancel := context.WithTimeout(ctx, setupTimeout)
+	defer cancel()
+	if err := c.ensureStream(ctx, cfg.NATSResultStream, cfg.NATSResultSubject+".>"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// ensureStream creates a stream on subject unless one named name exists;
+// existing streams are left as configured.
+func (c *Client) ensureStream(ctx context.Context, name, subject string) error {
+	_, err := c.js.Stream(ctx, name)
+	if errors.Is(err, jetstream.ErrStreamNotFound) {
+		_, err = c.js.CreateStream(ctx, jetstream.StreamConfig{Name: name, Subjects: []string{subject}})
+		if err == nil {
+			log.Printf("Created NATS stream %s on %s", name, subject)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set up NATS stream %s: %w", name, err)
+	}
+	return nil
+}
+
+// Close sends pending publications and closes the connection.
+func (c *Client) Close() {
+	if err := c.conn.Drain(); err != nil {
+		log.Printf("Failed to drain NATS connection: %v", err)
+	}
+}
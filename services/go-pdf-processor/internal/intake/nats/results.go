@@ -0,0 +1,43 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cotai-pdf-processor/internal/processor"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// publishTimeout bounds the publication of a finished job.
+const publishTimeout = 10 * time.Second
+
+// JobEvent is published on NATS_RESULT_SUBJECT.<status> when a job
+// finishes.
+type JobEvent = processor.JobSummary
+
+// ResultPublisher publishes finished jobs to JetStream.
+type ResultPublisher struct {
+	client *Client
+}
+
+func NewResultPublisher(client *Client) *ResultPublisher {
+	return &ResultPublisher{client: client}
+}
+
+// JobFinished publishes the job's event. The message ID is the job and
+// status, so JetStream drops the duplicates of a status stored twice.
+func (p *ResultPublisher) JobFinished(ctx context.Context, job *processor.ProcessingJob) error {
+	data, err := json.Marshal(processor.SummarizeJob(job))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+	subject := fmt.Sprintf("%s.%s", p.client.cfg.NATSResultSubject, job.Status)
+	_, err = p.client.js.Publish(ctx, subject, data, jetstream.WithMsgID(job.ID+":"+job.Status))
+	return err
+}
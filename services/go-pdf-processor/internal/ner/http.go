@@ -0,0 +1,146 @@
+package ner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxChunkSize bounds the text sent per request; longer documents are split
+// at line breaks and the offsets shifted back.
+const maxChunkSize = 100000
+
+// HTTPProvider calls an NLP sidecar exposing
+//
+//	POST <url> {"text": "...", "languages": ["por"]}
+//	-> {"entities": [{"label": "ORG", "text": "...", "start": 0, "end": 10, "score": 0.98}]}
+//
+// with start and end counted in characters, as spaCy and Python
+// transformers pipelines report them.
+type HTTPProvider struct {
+	url  string
+	http *http.Client
+}
+
+type httpRequest struct {
+	Text      string   `json:"text"`
+	Languages []string `json:"languages,omitempty"`
+}
+
+type httpResponse struct {
+	Entities []struct {
+		Label string  `json:"label"`
+		Text  string  `json:"text"`
+		Start int     `json:"start"`
+		End   int     `json:"end"`
+		Score float64 `json:"score"`
+	} `json:"entities"`
+}
+
+func NewHTTPProvider(url string, timeout time.Duration) *HTTPProvider {
+	return &HTTPProvider{url: url, http: &http.Client{Timeout: timeout}}
+}
+
+func (p *HTTPProvider) Extract(ctx context.Context, text string, languages []string) ([]Entity, error) {
+	var entities []Entity
+	for offset := 0; offset < len(text); {
+		chunk := nextChunk(text[offset:])
+		found, err := p.extractChunk(ctx, chunk, languages)
+		if err != nil {
+			return nil, err
+		}
+		for _, entity := range found {
+			entity.Start += offset
+			entity.End += offset
+			entities = append(entities, entity)
+		}
+		offset += len(chunk)
+	}
+	return entities, nil
+}
+
+func (p *HTTPProvider) extractChunk(ctx context.Context, text string, languages []string) ([]Entity, error) {
+	body, err := json.Marshal(httpRequest{Text: text, Languages: languages})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("NER request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("NER provider returned status %d", resp.StatusCode)
+	}
+
+	var decoded httpResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode NER response: %w", err)
+	}
+
+	offsets := newRuneOffsets(text)
+	entities := make([]Entity, 0, len(decoded.Entities))
+	for _, e := range decoded.Entities {
+		start, end := offsets.byteOffset(e.Start), offsets.byteOffset(e.End)
+		if start < 0 || end < start {
+			continue
+		}
+		entities = append(entities, Entity{
+			Type:  EntityType(e.Label),
+			Text:  text[start:end],
+			Start: start,
+			End:   end,
+			Score: e.Score,
+		})
+	}
+	return entities, nil
+}
+
+// nextChunk returns the longest prefix of text up to maxChunkSize bytes that
+// ends at a line break, or at a rune boundary if there is none.
+func nextChunk(text string) string {
+	if len(text) <= maxChunkSize {
+		return text
+	}
+	if i := strings.LastIndexByte(text[:maxChunkSize], '\n'); i > 0 {
+		return text[:i+1]
+	}
+	end := maxChunkSize
+	for end > 0 && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	return text[:end]
+}
+
+// runeOffsets converts character offsets to byte offsets.
+type runeOffsets []int
+
+func newRuneOffsets(text string) runeOffsets {
+	offsets := make(runeOffsets, 0, len(text)+1)
+	for i := range text {
+		offsets = append(offsets, i)
+	}
+	return append(offsets, len(text))
+}
+
+// byteOffset returns -1 for offsets past the end of the text.
+func (o runeOffsets) byteOffset(chars int) int {
+	if chars < 0 || chars >= len(o) {
+		return -1
+	}
+	return o[chars]
+}
@@ -0,0 +1,83 @@
+package ner
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"cotai-pdf-processor/internal/config"
+)
+
+// Entity is a named entity found by a provider. Start and End are byte
+// offsets into the text that was sent.
+type Entity struct {
+	Type  string
+	Text  string
+	Start int
+	End   int
+	Score float64
+}
+
+// Provider recognizes named entities (people, organizations, locations...)
+// in text. Implementations talk to an external NLP service.
+type Provider interface {
+	Extract(ctx context.Context, text string, languages []string) ([]Entity, error)
+}
+
+// labelTypes maps common spaCy and transformers labels to entity types.
+var labelTypes = map[string]string{
+	"PER":    "PERSON",
+	"PERSON": "PERSON",
+	"ORG":    "ORGANIZATION",
+	"LOC":    "LOCATION",
+	"GPE":    "LOCATION",
+	"MISC":   "MISC",
+}
+
+// EntityType maps a provider label to the entity type reported in results.
+// Labels may carry a BIO prefix such as "B-ORG".
+func EntityType(label string) string {
+	label = strings.ToUpper(label)
+	if len(label) > 2 && label[1] == '-' {
+		label = label[2:]
+	}
+	if entityType, ok := labelTypes[label]; ok {
+		return entityType
+	}
+	return label
+}
+
+// Registry holds the providers configured for the service, by name.
+type Registry struct {
+	providers map[string]Provider
+}
+
+// NewRegistry creates an HTTP provider for each name=url pair in
+// cfg.NERProviders. Malformed entries are logged and skipped.
+func NewRegistry(cfg *config.Config) *Registry {
+	r := &Registry{providers: make(map[string]Provider)}
+	for _, entry := range strings.Split(cfg.NERProviders, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, url, ok := strings.Cut(entry, "=")
+		if !ok || name == "" || url == "" {
+			log.Printf("Ignoring invalid NER provider %q", entry)
+			continue
+		}
+		r.providers[strings.TrimSpace(name)] = NewHTTPProvider(strings.TrimSpace(url), cfg.NERTimeout)
+	}
+	return r
+}
+
+// Get returns the named provider.
+func (r *Registry) Get(name string) (Provider, error) {
+	if r != nil {
+		if provider, ok := r.providers[name]; ok {
+			return provider, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown NER provider %q", name)
+}
@@ -0,0 +1,60 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestPublic(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.0.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+	}
+
+	for _, tt := range tests {
+		if got := Public(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("Public(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestControl(t *testing.T) {
+	if err := Control("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("Control() of a public address error = %v", err)
+	}
+	for _, address := range []string{"127.0.0.1:80", "[::1]:80", "10.0.0.1:443", "169.254.169.254:80"} {
+		if err := Control("tcp", address, nil); !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("Control(%s) error = %v, want %v", address, err, ErrForbiddenAddress)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	if err := CheckHost(context.Background(), "93.184.216.34"); err != nil {
+		t.Errorf("CheckHost() of a public address error = %v", err)
+	}
+	for _, host := range []string{"127.0.0.1", "::1", "192.168.1.1", "localhost"} {
+		if err := CheckHost(context.Background(), host); !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("CheckHost(%s) error = %v, want %v", host, err, ErrForbiddenAddress)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// azurePollInterval is how often the result of a Read operation is checked.
+const azurePollInterval = 500 * time.Millisecond
+
+// AzureReadProvider calls Azure AI Vision's asynchronous Read API: the image
+// is submitted, then the operation is polled until it completes.
+type AzureReadProvider struct {
+	endpoint string
+	key      string
+	timeout  time.Duration
+	http     *http.Client
+}
+
+type azureReadResponse struct {
+	Status        string `json:"status"`
+	AnalyzeResult struct {
+		ReadResults []struct {
+			Lines []struct {
+				Text  string `json:"text"`
+				Words []struct {
+					Text        string    `json:"text"`
+					BoundingBox []float64 `json:"boundingBox"`
+					Confidence  float64   `json:"confidence"`
+				} `json:"words"`
+			} `json:"lines"`
+		} `json:"readResults"`
+	} `json:"analyzeResult"`
+}
+
+func NewAzureReadProvider(endpoint, key string, timeout time.Duration) *AzureReadProvider {
+	return &AzureReadProvider{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		key:      key,
+		timeout:  timeout,
+		http:     &http.Client{Timeout: timeout},
+	}
+}
+
+func (a *AzureReadProvider) Recognize(ctx context.Context, imagePath string, options Options) (*Result, error) {
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// The whole operation, polling included, is bounded by the timeout
+	if a.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.timeout)
+		defer cancel()
+	}
+
+	analyzeURL := a.endpoint + "/vision/v3.2/read/analyze"
+	if hints := isoLanguages(options.Languages); len(hints) > 0 {
+		// Read accepts a single language; without one it detects it
+		analyzeURL += "?language=" + url.QueryEscape(hints[0])
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, analyzeURL, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Ocp-Apim-Subscription-Key", a.key)
+
+	resp, err := a.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Azure Read request failed: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, fmt.Errorf("Azure Read returned status %d", resp.StatusCode)
+	}
+	operation := resp.Header.Get("Operation-Location")
+	if operation == "" {
+		return nil, fmt.Errorf("Azure Read returned no operation location")
+	}
+
+	decoded, err := a.poll(ctx, operation)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	var words []Word
+	for _, page := range decoded.AnalyzeResult.ReadResults {
+		for _, line := range page.Lines {
+			lines = append(lines, line.Text)
+			for _, w := range line.Words {
+				x, y, width, height := boundingBox(w.BoundingBox)
+				words = append(words, Word{
+					Text:       w.Text,
+					X:          x,
+					Y:          y,
+					Width:      width,
+					Height:     height,
+					Confidence: w.Confidence * 100,
+				})
+			}
+		}
+	}
+
+	return &Result{Text: strings.Join(lines, "\n"), Words: words, Confidence: meanConfidence(words)}, nil
+}
+
+// poll waits for the Read operation to succeed.
+func (a *AzureReadProvider) poll(ctx context.Context, operation string) (*azureReadResponse, error) {
+	ticker := time.NewTicker(azurePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("Azure Read did not complete: %w", ctx.Err())
+		case <-ticker.C:
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, operation, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Ocp-Apim-Subscription-Key", a.key)
+
+		resp, err := a.http.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("Azure Read request failed: %w", err)
+		}
+		var decoded azureReadResponse
+		err = json.NewDecoder(io.LimitReader(resp.Body, 32<<20)).Decode(&decoded)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("Azure Read returned status %d", resp.StatusCode)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode Azure Read response: %w", err)
+		}
+
+		switch decoded.Status {
+		case "succeeded":
+			return &decoded, nil
+		case "failed":
+			return nil, fmt.Errorf("Azure Read failed")
+		}
+	}
+}
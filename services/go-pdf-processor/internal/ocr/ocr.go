@@ -0,0 +1,163 @@
+package ocr
+
+import (
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"os"
+	"strings"
+
+	"cotai-pdf-processor/internal/config"
+)
+
+// Provider names
+const (
+	Tesseract    = "tesseract"
+	Textract     = "textract"
+	GoogleVision = "google-vision"
+	AzureRead    = "azure-read"
+)
+
+// Word is a recognized word, located in pixels from the top left corner of
+// the image. Confidence is from 0 to 100 whatever the provider.
+type Word struct {
+	Text       string
+	X          int
+	Y          int
+	Width      int
+	Height     int
+	Confidence float64
+}
+
+// Result is the text recognized in an image, with its words in reading
+// order. Confidence is the provider's mean confidence, from 0 to 100.
+type Result struct {
+	Text       string
+	Words      []Word
+	Confidence float64
+}
+
+// Options tune recognition. Languages are Tesseract language codes, such as
+// "por"; DPI is the resolution of the image.
+type Options struct {
+	Languages []string
+	DPI       float64
+}
+
+// Provider recognizes the text in page images. Implementations must be safe
+// for concurrent use.
+type Provider interface {
+	Recognize(ctx context.Context, imagePath string, options Options) (*Result, error)
+}
+
+// Registry holds the OCR engines configured for the service, by name.
+// Tesseract is always available; cloud engines are added when their
+// credentials are set.
+type Registry struct {
+	providers   map[string]Provider
+	defaultName string
+}
+
+func NewRegistry(cfg *config.Config) *Registry {
+	r := &Registry{
+		providers:   map[string]Provider{Tesseract: NewTesseractProvider()},
+		defaultName: cfg.OCRProvider,
+	}
+	if cfg.TextractAccessKey != "" && cfg.TextractSecretKey != "" {
+		r.providers[Textract] = NewTextractProvider(cfg.TextractRegion, cfg.TextractAccessKey, cfg.TextractSecretKey, cfg.OCRProviderTimeout)
+	}
+	if cfg.GoogleVisionAPIKey != "" {
+		r.providers[GoogleVision] = NewGoogleVisionProvider(cfg.GoogleVisionAPIKey, cfg.OCRProviderTimeout)
+	}
+	if cfg.AzureReadEndpoint != "" && cfg.AzureReadKey != "" {
+		r.providers[AzureRead] = NewAzureReadProvider(cfg.AzureReadEndpoint, cfg.AzureReadKey, cfg.OCRProviderTimeout)
+	}
+
+	if _, ok := r.providers[r.defaultName]; !ok {
+		if r.defaultName != "" {
+			log.Printf("OCR provider %q is not configured, using %s", r.defaultName, Tesseract)
+		}
+		r.defaultName = Tesseract
+	}
+	return r
+}
+
+// Get returns the named provider, or the default one (OCR_PROVIDER) when
+// name is empty.
+func (r *Registry) Get(name string) (Provider, error) {
+	if r == nil {
+		return nil, fmt.Errorf("unknown OCR provider %q", name)
+	}
+	if name == "" {
+		name = r.defaultName
+	}
+	if provider, ok := r.providers[name]; ok {
+		return provider, nil
+	}
+	return nil, fmt.Errorf("unknown OCR provider %q", name)
+}
+
+// languageCodes maps Tesseract language codes to the ISO 639-1 codes cloud
+// engines take as hints. Unknown codes are left out.
+var languageCodes = map[string]string{
+	"por": "pt",
+	"eng": "en",
+	"spa": "es",
+	"fra": "fr",
+	"deu": "de",
+	"ita": "it",
+}
+
+func isoLanguages(languages []string) []string {
+	codes := make([]string, 0, len(languages))
+	for _, language := range languages {
+		if code, ok := languageCodes[strings.ToLower(language)]; ok {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// imageSize reads the dimensions of a PNG or JPEG image, which cloud
+// engines reporting relative coordinates need to locate words in pixels.
+func imageSize(path string) (int, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read image size: %w", err)
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// meanConfidence averages the confidence of the words.
+func meanConfidence(words []Word) float64 {
+	if len(words) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, word := range words {
+		sum += word.Confidence
+	}
+	return sum / float64(len(words))
+}
+
+// boundingBox is the rectangle around the points, given as x, y pairs.
+func boundingBox(points []float64) (x, y, width, height int) {
+	if len(points) < 2 {
+		return 0, 0, 0, 0
+	}
+	minX, minY, maxX, maxY := points[0], points[1], points[0], points[1]
+	for i := 2; i+1 < len(points); i += 2 {
+		minX, maxX = min(minX, points[i]), max(maxX, points[i])
+		minY, maxY = min(minY, points[i+1]), max(maxY, points[i+1])
+	}
+	return int(minX), int(minY), int(maxX - minX + 0.5), int(maxY - minY + 0.5)
+}
@@ -0,0 +1,70 @@
+package ocr
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/otiai10/gosseract/v2"
+)
+
+// TesseractProvider runs Tesseract in process. Each call uses its own
+// client, since clients cannot be shared between goroutines.
+type TesseractProvider struct{}
+
+func NewTesseractProvider() *TesseractProvider {
+	return &TesseractProvider{}
+}
+
+func (t *TesseractProvider) Recognize(ctx context.Context, imagePath string, options Options) (*Result, error) {
+	client := gosseract.NewClient()
+	defer client.Close()
+
+	// Set languages
+	if len(options.Languages) > 0 {
+		client.SetLanguage(strings.Join(options.Languages, "+"))
+	} else {
+		client.SetLanguage("por+eng") // Portuguese and English by default
+	}
+
+	// Configure OCR settings for better accuracy
+	client.SetPageSegMode(gosseract.PSM_AUTO)
+	client.SetConfigFile("pdf")
+
+	// Tesseract sizes its filters by the resolution, which page images
+	// often do not record
+	if options.DPI > 0 {
+		client.SetVariable("user_defined_dpi", fmt.Sprintf("%.0f", options.DPI))
+	}
+
+	// Set image source
+	client.SetImage(imagePath)
+
+	// Get text
+	text, err := client.Text()
+	if err != nil {
+		return nil, fmt.Errorf("OCR failed: %w", err)
+	}
+
+	var words []Word
+	if boxes, err := client.GetBoundingBoxes(gosseract.RIL_WORD); err == nil {
+		words = make([]Word, 0, len(boxes))
+		for _, box := range boxes {
+			words = append(words, Word{
+				Text:       box.Word,
+				X:          box.Box.Min.X,
+				Y:          box.Box.Min.Y,
+				Width:      box.Box.Dx(),
+				Height:     box.Box.Dy(),
+				Confidence: box.Confidence,
+			})
+		}
+	} else {
+		log.Printf("Failed to get OCR word boxes: %v", err)
+	}
+
+	// The page's confidence is the mean of its words', as Tesseract's own
+	// MeanTextConf
+	return &Result{Text: text, Words: words, Confidence: meanConfidence(words)}, nil
+}
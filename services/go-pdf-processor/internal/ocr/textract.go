@@ -0,0 +1,166 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// TextractProvider calls AWS Textract's DetectDocumentText, signing the
+// requests with AWS Signature Version 4.
+type TextractProvider struct {
+	region    string
+	accessKey string
+	secretKey string
+	http      *http.Client
+}
+
+type textractResponse struct {
+	Blocks []struct {
+		BlockType  string  `json:"BlockType"`
+		Text       string  `json:"Text"`
+		Confidence float64 `json:"Confidence"`
+		Geometry   struct {
+			BoundingBox struct {
+				Left   float64 `json:"Left"`
+				Top    float64 `json:"Top"`
+				Width  float64 `json:"Width"`
+				Height float64 `json:"Height"`
+			} `json:"BoundingBox"`
+		} `json:"Geometry"`
+	} `json:"Blocks"`
+}
+
+func NewTextractProvider(region, accessKey, secretKey string, timeout time.Duration) *TextractProvider {
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &TextractProvider{
+		region:    region,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		http:      &http.Client{Timeout: timeout},
+	}
+}
+
+func (t *TextractProvider) Recognize(ctx context.Context, imagePath string, options Options) (*Result, error) {
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	width, height, err := imageSize(imagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Textract infers the language, it takes no hints
+	body, err := json.Marshal(map[string]any{"Document": map[string]any{"Bytes": data}})
+	if err != nil {
+		return nil, err
+	}
+
+	host := fmt.Sprintf("textract.%s.amazonaws.com", t.region)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://"+host+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Textract.DetectDocumentText")
+	t.sign(req, host, body, time.Now().UTC())
+
+	resp, err := t.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Textract request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Textract returned status %d", resp.StatusCode)
+	}
+
+	var decoded textractResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 32<<20)).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode Textract response: %w", err)
+	}
+
+	var lines []string
+	var words []Word
+	for _, block := range decoded.Blocks {
+		box := block.Geometry.BoundingBox
+		switch block.BlockType {
+		case "LINE":
+			lines = append(lines, block.Text)
+		case "WORD":
+			words = append(words, Word{
+				Text:       block.Text,
+				X:          int(box.Left * float64(width)),
+				Y:          int(box.Top * float64(height)),
+				Width:      int(box.Width*float64(width) + 0.5),
+				Height:     int(box.Height*float64(height) + 0.5),
+				Confidence: block.Confidence,
+			})
+		}
+	}
+
+	return &Result{Text: strings.Join(lines, "\n"), Words: words, Confidence: meanConfidence(words)}, nil
+}
+
+// sign adds the Signature Version 4 headers to the request.
+func (t *TextractProvider) sign(req *http.Request, host string, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("Host", host)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	signedHeaders := "content-type;host;x-amz-date;x-amz-target"
+	canonicalRequest := strings.Join([]string{
+		http.MethodPost,
+		"/",
+		"",
+		"content-type:" + req.Header.Get("Content-Type") + "\n" +
+			"host:" + host + "\n" +
+			"x-amz-date:" + amzDate + "\n" +
+			"x-amz-target:" + req.Header.Get("X-Amz-Target") + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + t.region + "/textract/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+t.secretKey), date)
+	key = hmacSHA256(key, t.region)
+	key = hmacSHA256(key, "textract")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		t.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,135 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const googleVisionURL = "https://vision.googleapis.com/v1/images:annotate"
+
+// GoogleVisionProvider calls the Cloud Vision API's document text
+// detection, authenticated with an API key.
+type GoogleVisionProvider struct {
+	apiKey string
+	http   *http.Client
+}
+
+type visionVertex struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type visionResponse struct {
+	Responses []struct {
+		FullTextAnnotation struct {
+			Text  string `json:"text"`
+			Pages []struct {
+				Blocks []struct {
+					Paragraphs []struct {
+						Words []struct {
+							Confidence  float64 `json:"confidence"`
+							BoundingBox struct {
+								Vertices []visionVertex `json:"vertices"`
+							} `json:"boundingBox"`
+							Symbols []struct {
+								Text string `json:"text"`
+							} `json:"symbols"`
+						} `json:"words"`
+					} `json:"paragraphs"`
+				} `json:"blocks"`
+			} `json:"pages"`
+		} `json:"fullTextAnnotation"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	} `json:"responses"`
+}
+
+func NewGoogleVisionProvider(apiKey string, timeout time.Duration) *GoogleVisionProvider {
+	return &GoogleVisionProvider{apiKey: apiKey, http: &http.Client{Timeout: timeout}}
+}
+
+func (g *GoogleVisionProvider) Recognize(ctx context.Context, imagePath string, options Options) (*Result, error) {
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	request := map[string]any{
+		"image":    map[string]any{"content": data},
+		"features": []map[string]any{{"type": "DOCUMENT_TEXT_DETECTION"}},
+	}
+	if hints := isoLanguages(options.Languages); len(hints) > 0 {
+		request["imageContext"] = map[string]any{"languageHints": hints}
+	}
+	body, err := json.Marshal(map[string]any{"requests": []any{request}})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		googleVisionURL+"?key="+url.QueryEscape(g.apiKey), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Google Vision request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Google Vision returned status %d", resp.StatusCode)
+	}
+
+	var decoded visionResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 32<<20)).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode Google Vision response: %w", err)
+	}
+	if len(decoded.Responses) == 0 {
+		return &Result{}, nil
+	}
+	response := decoded.Responses[0]
+	if response.Error != nil {
+		return nil, fmt.Errorf("Google Vision failed: %s", response.Error.Message)
+	}
+
+	var words []Word
+	for _, page := range response.FullTextAnnotation.Pages {
+		for _, block := range page.Blocks {
+			for _, paragraph := range block.Paragraphs {
+				for _, w := range paragraph.Words {
+					var text strings.Builder
+					for _, symbol := range w.Symbols {
+						text.WriteString(symbol.Text)
+					}
+					points := make([]float64, 0, 2*len(w.BoundingBox.Vertices))
+					for _, v := range w.BoundingBox.Vertices {
+						points = append(points, v.X, v.Y)
+					}
+					x, y, width, height := boundingBox(points)
+					words = append(words, Word{
+						Text:       text.String(),
+						X:          x,
+						Y:          y,
+						Width:      width,
+						Height:     height,
+						Confidence: w.Confidence * 100,
+					})
+				}
+			}
+		}
+	}
+
+	return &Result{Text: response.FullTextAnnotation.Text, Words: words, Confidence: meanConfidence(words)}, nil
+}
@@ -0,0 +1,345 @@
+// Package parquet writes flat tables as Parquet files for the data
+// warehouse. It holds to the part of the format readers need: required and
+// optional columns of a few types, PLAIN encoded, each column of a row
+// group in one Snappy-compressed data page.
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+)
+
+const magic = "PAR1"
+
+// RowGroupSize is about how many bytes of values are buffered before they
+// are written as a row group.
+const RowGroupSize = 64 << 20
+
+// Type is the type of a column's values.
+type Type int
+
+const (
+	String    Type = iota // string, as UTF-8
+	Int64                 // int or int64
+	Double                // float64
+	Bool                  // bool
+	Timestamp             // time.Time, to the millisecond in UTC
+)
+
+// Parquet's physical types, converted types and codecs of the columns
+// written.
+const (
+	physicalBoolean   = 0
+	physicalInt64     = 2
+	physicalDouble    = 5
+	physicalByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecSnappy = 1
+
+	pageData = 0
+)
+
+// Column is a column of a table. Optional columns take nil values.
+type Column struct {
+	Name     string
+	Type     Type
+	Optional bool
+}
+
+func (c Column) physicalType() int32 {
+	switch c.Type {
+	case Int64, Timestamp:
+		return physicalInt64
+	case Double:
+		return physicalDouble
+	case Bool:
+		return physicalBoolean
+	default:
+		return physicalByteArray
+	}
+}
+
+// chunk buffers the values of a column for the row group being written.
+type chunk struct {
+	values []byte
+	// bools packs the values of Bool columns, levels whether each value
+	// of an optional column is present
+	bools, levels bits
+}
+
+// bits packs bits LSB first, as Parquet's bit-packed encodings do.
+type bits struct {
+	buf []byte
+	n   int
+}
+
+func (b *bits) append(v bool) {
+	if b.n%8 == 0 {
+		b.buf = append(b.buf, 0)
+	}
+	if v {
+		b.buf[len(b.buf)-1] |= 1 << (b.n % 8)
+	}
+	b.n++
+}
+
+func (b *bits) reset() {
+	b.buf, b.n = b.buf[:0], 0
+}
+
+type columnChunkMeta struct {
+	offset, uncompressed, compressed int64
+}
+
+type rowGroupMeta struct {
+	rows    int64
+	size    int64
+	columns []columnChunkMeta
+}
+
+// Writer writes a table's rows as a Parquet file. Rows are buffered and
+// written by row groups; Close writes the last and the file's footer.
+type Writer struct {
+	out     io.Writer
+	offset  int64
+	columns []Column
+	chunks  []chunk
+	rows    int
+	size    int
+	groups  []rowGroupMeta
+	thrift  compactWriter
+	err     error
+}
+
+// NewWriter starts a Parquet file of a table with the given columns.
+func NewWriter(w io.Writer, columns []Column) *Writer {
+	pw := &Writer{out: w, columns: columns, chunks: make([]chunk, len(columns))}
+	pw.write([]byte(magic))
+	return pw
+}
+
+// Rows returns how many rows were written.
+func (w *Writer) Rows() int64 {
+	rows := int64(w.rows)
+	for _, group := range w.groups {
+		rows += group.rows
+	}
+	return rows
+}
+
+// Write adds a row, a value of each column in order.
+func (w *Writer) Write(row ...interface{}) error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("row has %d values for %d columns", len(row), len(w.columns))
+	}
+	for i, column := range w.columns {
+		if row[i] == nil && !column.Optional {
+			return fmt.Errorf("column %s is required", column.Name)
+		}
+		if err := checkValue(column, row[i]); err != nil {
+			return err
+		}
+	}
+
+	for i, column := range w.columns {
+		c := &w.chunks[i]
+		size := len(c.values) + len(c.bools.buf)
+		if column.Optional {
+			c.levels.append(row[i] != nil)
+		}
+		switch v := row[i].(type) {
+		case nil:
+		case string:
+			c.values = binary.LittleEndian.AppendUint32(c.values, uint32(len(v)))
+			c.values = append(c.values, v...)
+		case int:
+			c.values = binary.LittleEndian.AppendUint64(c.values, uint64(v))
+		case int64:
+			c.values = binary.LittleEndian.AppendUint64(c.values, uint64(v))
+		case float64:
+			c.values = binary.LittleEndian.AppendUint64(c.values, math.Float64bits(v))
+		case bool:
+			c.bools.append(v)
+		case time.Time:
+			c.values = binary.LittleEndian.AppendUint64(c.values, uint64(v.UnixMilli()))
+		}
+		w.size += len(c.values) + len(c.bools.buf) - size
+	}
+	w.rows++
+
+	if w.size >= RowGroupSize {
+		w.flush()
+	}
+	return w.err
+}
+
+func checkValue(column Column, value interface{}) error {
+	ok := true
+	switch value.(type) {
+	case nil:
+	case string:
+		ok = column.Type == String
+	case int, int64:
+		ok = column.Type == Int64
+	case float64:
+		ok = column.Type == Double
+	case bool:
+		ok = column.Type == Bool
+	case time.Time:
+		ok = column.Type == Timestamp
+	default:
+		ok = false
+	}
+	if !ok {
+		return fmt.Errorf("column %s cannot hold %T", column.Name, value)
+	}
+	return nil
+}
+
+// flush writes the buffered rows as a row group.
+func (w *Writer) flush() {
+	if w.rows == 0 || w.err != nil {
+		return
+	}
+	group := rowGroupMeta{rows: int64(w.rows), columns: make([]columnChunkMeta, len(w.columns))}
+	var body []byte
+	for i, column := range w.columns {
+		c := &w.chunks[i]
+
+		body = body[:0]
+		if column.Optional {
+			// Definition levels, as one bit-packed run of the RLE hybrid
+			// encoding, preceded by their length
+			header := binary.AppendUvarint(nil, uint64(len(c.levels.buf))<<1|1)
+			body = binary.LittleEndian.AppendUint32(body, uint32(len(header)+len(c.levels.buf)))
+			body = append(body, header...)
+			body = append(body, c.levels.buf...)
+		}
+		if column.Type == Bool {
+			body = append(body, c.bools.buf...)
+		} else {
+			body = append(body, c.values...)
+		}
+		compressed := snappy.Encode(nil, body)
+
+		header := w.thrift.message(func() {
+			w.thrift.i32(1, pageData)
+			w.thrift.i32(2, int32(len(body)))
+			w.thrift.i32(3, int32(len(compressed)))
+			w.thrift.structField(5, func() {
+				w.thrift.i32(1, int32(w.rows))
+				w.thrift.i32(2, encodingPlain)
+				w.thrift.i32(3, encodingRLE)
+				w.thrift.i32(4, encodingRLE)
+			})
+		})
+		group.columns[i] = columnChunkMeta{
+			offset:       w.offset,
+			uncompressed: int64(len(header) + len(body)),
+			compressed:   int64(len(header) + len(compressed)),
+		}
+		group.size += group.columns[i].uncompressed
+		w.write(header)
+		w.write(compressed)
+
+		c.values = c.values[:0]
+		c.bools.reset()
+		c.levels.reset()
+	}
+	w.groups = append(w.groups, group)
+	w.rows, w.size = 0, 0
+}
+
+// Close writes the buffered rows and the footer of the file. It does not
+// close the underlying writer.
+func (w *Writer) Close() error {
+	w.flush()
+	if w.err != nil {
+		return w.err
+	}
+
+	footer := w.thrift.message(func() {
+		w.thrift.i32(1, 1)
+		w.thrift.structList(2, len(w.columns)+1, func(i int) {
+			if i == 0 {
+				w.thrift.string(4, "schema")
+				w.thrift.i32(5, int32(len(w.columns)))
+				return
+			}
+			column := w.columns[i-1]
+			w.thrift.i32(1, column.physicalType())
+			repetition := int32(repetitionRequired)
+			if column.Optional {
+				repetition = repetitionOptional
+			}
+			w.thrift.i32(3, repetition)
+			w.thrift.string(4, column.Name)
+			switch column.Type {
+			case String:
+				w.thrift.i32(6, convertedUTF8)
+			case Timestamp:
+				w.thrift.i32(6, convertedTimestampMillis)
+			}
+		})
+		w.thrift.i64(3, w.Rows())
+		w.thrift.structList(4, len(w.groups), func(i int) {
+			group := w.groups[i]
+			w.thrift.structList(1, len(group.columns), func(j int) {
+				chunk, column := group.columns[j], w.columns[j]
+				w.thrift.i64(2, chunk.offset)
+				w.thrift.structField(3, func() {
+					w.thrift.i32(1, column.physicalType())
+					w.thrift.i32List(2, encodingPlain, encodingRLE)
+					w.thrift.stringList(3, column.Name)
+					w.thrift.i32(4, codecSnappy)
+					w.thrift.i64(5, group.rows)
+					w.thrift.i64(6, chunk.uncompressed)
+					w.thrift.i64(7, chunk.compressed)
+					w.thrift.i64(9, chunk.offset)
+				})
+			})
+			w.thrift.i64(2, group.size)
+			w.thrift.i64(3, group.rows)
+		})
+		w.thrift.string(6, "cotai-pdf-processor")
+	})
+	w.write(footer)
+	w.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer))))
+	w.write([]byte(magic))
+	if w.err == nil {
+		w.err = errClosed
+		return nil
+	}
+	return w.err
+}
+
+var errClosed = errors.New("parquet writer is closed")
+
+func (w *Writer) write(data []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.out.Write(data)
+	w.offset += int64(n)
+	if err != nil {
+		w.err = err
+	}
+}
@@ -0,0 +1,308 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+)
+
+// compactReader reads the Thrift compact protocol the writer uses into
+// structs of field values by ID.
+type compactReader struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (r *compactReader) byte() byte {
+	if r.pos >= len(r.buf) {
+		r.err = errors.New("unexpected end of thrift data")
+		return 0
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *compactReader) varint() int64 {
+	v, n := binary.Varint(r.buf[r.pos:])
+	if n <= 0 {
+		r.err = errors.New("bad varint")
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *compactReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		r.err = errors.New("bad uvarint")
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *compactReader) structValue() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var id int16
+	for r.err == nil {
+		header := r.byte()
+		if header == 0 {
+			break
+		}
+		if delta := int16(header >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(r.varint())
+		}
+		fields[id] = r.value(header & 0x0f)
+	}
+	return fields
+}
+
+func (r *compactReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftTrue:
+		return true
+	case thriftFalse:
+		return false
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := int(r.uvarint())
+		if r.pos+n > len(r.buf) {
+			r.err = errors.New("string exceeds thrift data")
+			return ""
+		}
+		s := string(r.buf[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case thriftList:
+		header := r.byte()
+		n := int(header >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = r.value(header & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.structValue()
+	}
+	r.err = errors.New("unsupported thrift type")
+	return nil
+}
+
+// readFile reads back the column names and the rows of a file written by
+// Writer, decoding the values as Write takes them.
+func readFile(t *testing.T, data []byte) ([]string, [][]interface{}) {
+	t.Helper()
+	if len(data) < 12 || string(data[:4]) != magic || string(data[len(data)-4:]) != magic {
+		t.Fatalf("file does not start and end with %s", magic)
+	}
+	footerLength := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := &compactReader{buf: data[len(data)-8-footerLength : len(data)-8]}
+	meta := footer.structValue()
+	if footer.err != nil {
+		t.Fatalf("footer: %v", footer.err)
+	}
+
+	type schemaColumn struct {
+		name      string
+		physical  int64
+		optional  bool
+		converted int64
+	}
+	schema := meta[2].([]interface{})
+	if root := schema[0].(map[int16]interface{}); root[5] != int64(len(schema)-1) {
+		t.Fatalf("schema root has %v children for %d columns", root[5], len(schema)-1)
+	}
+	var columns []schemaColumn
+	var names []string
+	for _, element := range schema[1:] {
+		e := element.(map[int16]interface{})
+		converted, ok := e[6].(int64)
+		if !ok {
+			converted = -1
+		}
+		columns = append(columns, schemaColumn{e[4].(string), e[1].(int64), e[3] == int64(repetitionOptional), converted})
+		names = append(names, e[4].(string))
+	}
+
+	var rows [][]interface{}
+	for _, g := range meta[4].([]interface{}) {
+		group := g.(map[int16]interface{})
+		count := int(group[3].(int64))
+		groupRows := make([][]interface{}, count)
+		for i := range groupRows {
+			groupRows[i] = make([]interface{}, len(columns))
+		}
+
+		for j, c := range group[1].([]interface{}) {
+			column := columns[j]
+			chunk := c.(map[int16]interface{})[3].(map[int16]interface{})
+			if chunk[4] != int64(codecSnappy) || chunk[5] != int64(count) {
+				t.Fatalf("column %s: chunk metadata %v", column.name, chunk)
+			}
+
+			page := &compactReader{buf: data, pos: int(chunk[9].(int64))}
+			header := page.structValue()
+			if page.err != nil {
+				t.Fatalf("column %s: page header: %v", column.name, page.err)
+			}
+			compressed := data[page.pos : page.pos+int(header[3].(int64))]
+			body, err := snappy.Decode(nil, compressed)
+			if err != nil {
+				t.Fatalf("column %s: %v", column.name, err)
+			}
+			if len(body) != int(header[2].(int64)) {
+				t.Fatalf("column %s: page of %d bytes, header says %d", column.name, len(body), header[2])
+			}
+
+			present := make([]bool, count)
+			for i := range present {
+				present[i] = true
+			}
+			if column.optional {
+				length := int(binary.LittleEndian.Uint32(body))
+				levels := &compactReader{buf: body[4 : 4+length]}
+				if run := levels.uvarint(); run&1 != 1 {
+					t.Fatalf("column %s: definition levels are not bit-packed", column.name)
+				}
+				for i := range present {
+					present[i] = levels.buf[levels.pos+i/8]&(1<<(i%8)) != 0
+				}
+				body = body[4+length:]
+			}
+
+			n := 0
+			for i := range groupRows {
+				if !present[i] {
+					continue
+				}
+				var value interface{}
+				switch column.physical {
+				case physicalByteArray:
+					length := int(binary.LittleEndian.Uint32(body))
+					value, body = string(body[4:4+length]), body[4+length:]
+				case physicalInt64:
+					v := int64(binary.LittleEndian.Uint64(body))
+					value, body = v, body[8:]
+					if column.converted == convertedTimestampMillis {
+						value = time.UnixMilli(v).UTC()
+					}
+				case physicalDouble:
+					value, body = math.Float64frombits(binary.LittleEndian.Uint64(body)), body[8:]
+				case physicalBoolean:
+					value = body[n/8]&(1<<(n%8)) != 0
+				}
+				groupRows[i][j] = value
+				n++
+			}
+		}
+		rows = append(rows, groupRows...)
+	}
+	if meta[3] != int64(len(rows)) {
+		t.Errorf("footer counts %v rows, read %d", meta[3], len(rows))
+	}
+	return names, rows
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	columns := []Column{
+		{Name: "job_id", Type: String},
+		{Name: "pages", Type: Int64, Optional: true},
+		{Name: "risk_score", Type: Double},
+		{Name: "ocr", Type: Bool},
+		{Name: "finished_at", Type: Timestamp, Optional: true},
+		{Name: "error", Type: String, Optional: true},
+	}
+	finished := time.Date(2024, 3, 5, 14, 30, 15, 250e6, time.UTC)
+	rows := [][]interface{}{
+		{"j1", 12, 0.75, true, finished, nil},
+		{"j2", nil, 0.0, false, nil, "download failed"},
+		{"j3", int64(1 << 40), -1.5, true, finished.Add(time.Hour), ""},
+		{"não-ascii ✓", 0, math.MaxFloat64, false, nil, nil},
+	}
+	for i := 0; i < 9; i++ {
+		rows = append(rows, []interface{}{"bulk", i, float64(i), i%3 == 0, nil, nil})
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, columns)
+	for _, row := range rows {
+		if err := w.Write(row...); err != nil {
+			t.Fatalf("Write(%v) error = %v", row, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if w.Rows() != int64(len(rows)) {
+		t.Errorf("Rows() = %d, want %d", w.Rows(), len(rows))
+	}
+
+	names, got := readFile(t, buf.Bytes())
+	if want := []string{"job_id", "pages", "risk_score", "ocr", "finished_at", "error"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("columns = %v, want %v", names, want)
+	}
+	for i, row := range rows {
+		want := append([]interface{}{}, row...)
+		if v, ok := want[1].(int); ok {
+			want[1] = int64(v)
+		}
+		if !reflect.DeepEqual(got[i], want) {
+			t.Errorf("row %d = %v, want %v", i, got[i], want)
+		}
+	}
+}
+
+func TestWriterRefusesRows(t *testing.T) {
+	columns := []Column{{Name: "id", Type: String}, {Name: "pages", Type: Int64, Optional: true}}
+	tests := []struct {
+		name string
+		row  []interface{}
+	}{
+		{"too few values", []interface{}{"j1"}},
+		{"too many values", []interface{}{"j1", 1, 2}},
+		{"required value missing", []interface{}{nil, 1}},
+		{"wrong type", []interface{}{"j1", "one"}},
+		{"unsupported type", []interface{}{"j1", uint(1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWriter(&bytes.Buffer{}, columns)
+			if err := w.Write(tt.row...); err == nil {
+				t.Errorf("Write(%v) succeeded", tt.row)
+			}
+			if w.Rows() != 0 {
+				t.Errorf("Rows() = %d after a refused row", w.Rows())
+			}
+		})
+	}
+}
+
+func TestWriterClosed(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, []Column{{Name: "id", Type: String}})
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, rows := readFile(t, buf.Bytes()); len(rows) != 0 {
+		t.Errorf("empty file has %d rows", len(rows))
+	}
+	if err := w.Write("j1"); !errors.Is(err, errClosed) {
+		t.Errorf("Write() after Close() error = %v, want %v", err, errClosed)
+	}
+}
@@ -0,0 +1,117 @@
+package parquet
+
+import "encoding/binary"
+
+// Types of the Thrift compact protocol, which Parquet's page headers and
+// footer are written in.
+const (
+	thriftTrue   = 1
+	thriftFalse  = 2
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// compactWriter writes Thrift structs in the compact protocol, as much of
+// it as the structs of Parquet need. Fields are written in order of their
+// IDs, as the structs define them.
+type compactWriter struct {
+	buf    []byte
+	lastID int16
+}
+
+// message writes a struct on its own, as a page header or the footer.
+func (w *compactWriter) message(fields func()) []byte {
+	w.buf, w.lastID = w.buf[:0], 0
+	fields()
+	w.buf = append(w.buf, 0)
+	return w.buf
+}
+
+func (w *compactWriter) field(id int16, typ byte) {
+	if delta := id - w.lastID; delta > 0 && delta <= 15 {
+		w.buf = append(w.buf, byte(delta)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.buf = binary.AppendVarint(w.buf, int64(id))
+	}
+	w.lastID = id
+}
+
+func (w *compactWriter) i32(id int16, v int32) {
+	w.field(id, thriftI32)
+	w.buf = binary.AppendVarint(w.buf, int64(v))
+}
+
+func (w *compactWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	w.buf = binary.AppendVarint(w.buf, v)
+}
+
+func (w *compactWriter) bool(id int16, v bool) {
+	if v {
+		w.field(id, thriftTrue)
+	} else {
+		w.field(id, thriftFalse)
+	}
+}
+
+func (w *compactWriter) string(id int16, s string) {
+	w.field(id, thriftBinary)
+	w.appendString(s)
+}
+
+func (w *compactWriter) appendString(s string) {
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+// structField writes a struct field, whose own fields are written by
+// fields.
+func (w *compactWriter) structField(id int16, fields func()) {
+	w.field(id, thriftStruct)
+	w.structValue(fields)
+}
+
+func (w *compactWriter) structValue(fields func()) {
+	outer := w.lastID
+	w.lastID = 0
+	fields()
+	w.buf = append(w.buf, 0)
+	w.lastID = outer
+}
+
+func (w *compactWriter) listHeader(id int16, elemType byte, n int) {
+	w.field(id, thriftList)
+	if n < 15 {
+		w.buf = append(w.buf, byte(n)<<4|elemType)
+	} else {
+		w.buf = append(w.buf, 0xf0|elemType)
+		w.buf = binary.AppendUvarint(w.buf, uint64(n))
+	}
+}
+
+// structList writes a list of n structs, the fields of the ith written by
+// elem(i).
+func (w *compactWriter) structList(id int16, n int, elem func(i int)) {
+	w.listHeader(id, thriftStruct, n)
+	for i := 0; i < n; i++ {
+		w.structValue(func() { elem(i) })
+	}
+}
+
+func (w *compactWriter) i32List(id int16, values ...int32) {
+	w.listHeader(id, thriftI32, len(values))
+	for _, v := range values {
+		w.buf = binary.AppendVarint(w.buf, int64(v))
+	}
+}
+
+func (w *compactWriter) stringList(id int16, values ...string) {
+	w.listHeader(id, thriftBinary, len(values))
+	for _, s := range values {
+		w.appendString(s)
+	}
+}
@@ -0,0 +1,534 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"cotai-pdf-processor/internal/parquet"
+
+	"github.com/google/uuid"
+)
+
+// Analytics exports write the results of completed jobs as Parquet files
+// to ANALYTICS_BUCKET, for the data warehouse to load. An export covers the
+// jobs completed in [From, To); incremental exports, those of the schedule
+// and those requested without a range, go on from the end of the last that
+// completed, so each job is exported once. Files are partitioned by table
+// and day of completion, Hive-style:
+//
+//	documents/dt=2024-05-02/<export id>.parquet
+//	entities/dt=2024-05-02/<export id>.parquet
+//	risks/dt=2024-05-02/<export id>.parquet
+//
+// Entities and risks carry the job_id of their document.
+
+var (
+	ErrAnalyticsExportNotFound = errors.New("analytics export not found")
+	ErrAnalyticsExportRunning  = errors.New("an incremental analytics export is running already")
+	ErrInvalidAnalyticsExport  = errors.New("invalid analytics export")
+	ErrAnalyticsExportDisabled = errors.New("analytics exports need the object store")
+)
+
+// Statuses of an analytics export
+const (
+	AnalyticsExportRunning   = "running"
+	AnalyticsExportCompleted = "completed"
+	AnalyticsExportFailed    = "failed"
+)
+
+// analyticsExportSettle holds back the end of incremental exports, so jobs
+// completing as an export starts, whose rows are not written yet, are left
+// to the next.
+const analyticsExportSettle = time.Minute
+
+// AnalyticsExport is an export of the results of the jobs completed in
+// [From, To), and its outcome. Files are the keys of the Parquet files
+// written to the analytics bucket.
+type AnalyticsExport struct {
+	ID          string     `json:"id"`
+	Trigger     string     `json:"trigger"`
+	Incremental bool       `json:"incremental"`
+	Status      string     `json:"status"`
+	From        time.Time  `json:"from"`
+	To          time.Time  `json:"to"`
+	Documents   int64      `json:"documents"`
+	Entities    int64      `json:"entities"`
+	Risks       int64      `json:"risks"`
+	Files       []string   `json:"files"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// The tables exported; see exportJob for their rows.
+var (
+	analyticsDocuments = []parquet.Column{
+		{Name: "job_id", Type: parquet.String},
+		{Name: "parent_id", Type: parquet.String, Optional: true},
+		{Name: "tenant_id", Type: parquet.String},
+		{Name: "tender_id", Type: parquet.String},
+		{Name: "user_id", Type: parquet.String},
+		{Name: "filename", Type: parquet.String, Optional: true},
+		{Name: "created_at", Type: parquet.Timestamp},
+		{Name: "completed_at", Type: parquet.Timestamp},
+		{Name: "document_type", Type: parquet.String, Optional: true},
+		{Name: "content_sha256", Type: parquet.String, Optional: true},
+		{Name: "page_count", Type: parquet.Int64},
+		{Name: "file_size", Type: parquet.Int64},
+		{Name: "overall_risk", Type: parquet.String},
+		{Name: "risk_score", Type: parquet.Double},
+		{Name: "relevance_score", Type: parquet.Double},
+		{Name: "text_quality", Type: parquet.Double},
+		{Name: "ocr_confidence", Type: parquet.Double},
+		{Name: "entities", Type: parquet.Int64},
+		{Name: "risks", Type: parquet.Int64},
+		{Name: "text", Type: parquet.String},
+	}
+	analyticsEntities = []parquet.Column{
+		{Name: "job_id", Type: parquet.String},
+		{Name: "tenant_id", Type: parquet.String},
+		{Name: "type", Type: parquet.String},
+		{Name: "value", Type: parquet.String},
+		{Name: "normalized_date", Type: parquet.String, Optional: true},
+		{Name: "normalized_cents", Type: parquet.Int64, Optional: true},
+		{Name: "company", Type: parquet.String, Optional: true},
+		{Name: "confidence", Type: parquet.Double},
+		{Name: "page", Type: parquet.Int64, Optional: true},
+		{Name: "start_pos", Type: parquet.Int64},
+		{Name: "end_pos", Type: parquet.Int64},
+	}
+	analyticsRisks = []parquet.Column{
+		{Name: "job_id", Type: parquet.String},
+		{Name: "tenant_id", Type: parquet.String},
+		{Name: "category", Type: parquet.String},
+		{Name: "severity", Type: parquet.String},
+		{Name: "description", Type: parquet.String},
+		{Name: "impact", Type: parquet.String},
+		{Name: "confidence", Type: parquet.Double},
+		{Name: "page", Type: parquet.Int64, Optional: true},
+		{Name: "section", Type: parquet.String, Optional: true},
+		{Name: "location", Type: parquet.String},
+		{Name: "snippet", Type: parquet.String, Optional: true},
+	}
+)
+
+const analyticsExportColumns = `id, trigger, incremental, status, range_start, range_end,
+	documents, entities, risks, files, error, started_at, finished_at`
+
+func scanAnalyticsExport(row rowScanner) (*AnalyticsExport, error) {
+	var export AnalyticsExport
+	var files []byte
+	var finishedAt sql.NullTime
+	err := row.Scan(&export.ID, &export.Trigger, &export.Incremental, &export.Status, &export.From, &export.To,
+		&export.Documents, &export.Entities, &export.Risks, &files, &export.Error, &export.StartedAt, &finishedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAnalyticsExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(files, &export.Files); err != nil {
+		return nil, fmt.Errorf("invalid files of analytics export %s: %w", export.ID, err)
+	}
+	if finishedAt.Valid {
+		export.FinishedAt = &finishedAt.Time
+	}
+	return &export, nil
+}
+
+// ListAnalyticsExports returns the latest exports first.
+func (p *PDFProcessor) ListAnalyticsExports(ctx context.Context, limit int) ([]AnalyticsExport, error) {
+	rows, err := p.postgres.Query(ctx,
+		`SELECT `+analyticsExportColumns+` FROM analytics_exports ORDER BY started_at DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list analytics exports: %w", err)
+	}
+	defer rows.Close()
+
+	exports := []AnalyticsExport{}
+	for rows.Next() {
+		export, err := scanAnalyticsExport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read analytics export: %w", err)
+		}
+		exports = append(exports, *export)
+	}
+	return exports, rows.Err()
+}
+
+func (p *PDFProcessor) GetAnalyticsExport(ctx context.Context, id string) (*AnalyticsExport, error) {
+	return scanAnalyticsExport(p.postgres.QueryRow(ctx,
+		`SELECT `+analyticsExportColumns+` FROM analytics_exports WHERE id = $1`, id))
+}
+
+// StartAnalyticsExport exports the jobs completed in [from, to) in the
+// background, returning the export as it starts. Without a range the
+// export is incremental.
+func (p *PDFProcessor) StartAnalyticsExport(ctx context.Context, from, to *time.Time) (*AnalyticsExport, error) {
+	if p.objects == nil {
+		return nil, ErrAnalyticsExportDisabled
+	}
+	if (from == nil) != (to == nil) {
+		return nil, fmt.Errorf("%w: from and to go together", ErrInvalidAnalyticsExport)
+	}
+	if from != nil && !from.Before(*to) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidAnalyticsExport)
+	}
+
+	export, err := p.claimAnalyticsExport(ctx, "manual", nil, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if export == nil {
+		return nil, ErrAnalyticsExportRunning
+	}
+	go p.runAnalyticsExport(context.Background(), export)
+	return export, nil
+}
+
+// claimAnalyticsExport records an export as running, the next incremental
+// one when from and to are nil. It returns nil when another replica runs
+// the export of the same scheduled time, or an incremental export runs.
+func (p *PDFProcessor) claimAnalyticsExport(ctx context.Context, trigger string, scheduledFor, from, to *time.Time) (*AnalyticsExport, error) {
+	// Exports run no longer than their timeout; those running for longer
+	// died with their replica
+	err := p.postgres.Exec(ctx, `
+		UPDATE analytics_exports SET status = $1, error = 'interrupted', finished_at = now()
+		WHERE status = $2 AND started_at < $3
+	`, AnalyticsExportFailed, AnalyticsExportRunning, time.Now().Add(-p.cfg.AnalyticsExportTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fail interrupted analytics exports: %w", err)
+	}
+
+	export := &AnalyticsExport{
+		ID:          uuid.New().String(),
+		Trigger:     trigger,
+		Incremental: from == nil,
+		Status:      AnalyticsExportRunning,
+		Files:       []string{},
+		StartedAt:   time.Now(),
+	}
+	if from != nil {
+		export.From, export.To = *from, *to
+	} else {
+		var end sql.NullTime
+		err := p.postgres.QueryRow(ctx,
+			`SELECT max(range_end) FROM analytics_exports WHERE incremental AND status = $1`,
+			AnalyticsExportCompleted).Scan(&end)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the end of the last analytics export: %w", err)
+		}
+		// The first export takes every job there is
+		export.From = time.Unix(0, 0).UTC()
+		if end.Valid {
+			export.From = end.Time
+		}
+		export.To = export.StartedAt.Add(-analyticsExportSettle)
+		if !export.From.Before(export.To) {
+			export.To = export.From
+		}
+	}
+
+	var claimed string
+	err = p.postgres.QueryRow(ctx, `
+		INSERT INTO analytics_exports (id, trigger, incremental, scheduled_for, status, range_start, range_end, started_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT DO NOTHING
+		RETURNING id
+	`, export.ID, export.Trigger, export.Incremental, scheduledFor, export.Status, export.From, export.To,
+		export.StartedAt).Scan(&claimed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to record analytics export: %w", err)
+	}
+	return export, nil
+}
+
+// runAnalyticsExports runs the exports of ANALYTICS_EXPORT_SCHEDULE, until
+// the pool stops. Replicas claim each scheduled time, so an export happens
+// once.
+func (wp *WorkerPool) runAnalyticsExports() {
+	defer wp.wg.Done()
+
+	p := wp.processor
+	schedule, err := parseCron(p.cfg.AnalyticsExportSchedule)
+	if err != nil {
+		log.Printf("Analytics exports disabled: %v", err)
+		return
+	}
+	if p.objects == nil {
+		log.Printf("Analytics exports disabled: %v", ErrAnalyticsExportDisabled)
+		return
+	}
+
+	for {
+		next := schedule.next(time.Now().UTC())
+		if next.IsZero() {
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-wp.ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		export, err := p.claimAnalyticsExport(wp.ctx, "schedule", &next, nil, nil)
+		if err != nil {
+			log.Printf("%v", err)
+			continue
+		}
+		if export != nil {
+			p.runAnalyticsExport(wp.ctx, export)
+		}
+	}
+}
+
+// runAnalyticsExport runs an export claimed, recording its outcome.
+func (p *PDFProcessor) runAnalyticsExport(ctx context.Context, export *AnalyticsExport) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.AnalyticsExportTimeout)
+	defer cancel()
+
+	err := p.exportAnalytics(ctx, export)
+	finishedAt := time.Now()
+	export.FinishedAt = &finishedAt
+	if err != nil {
+		export.Status, export.Error = AnalyticsExportFailed, err.Error()
+		log.Printf("Analytics export %s failed after %d documents: %v", export.ID, export.Documents, err)
+	} else {
+		export.Status = AnalyticsExportCompleted
+		log.Printf("Analytics export %s wrote %d documents, %d entities and %d risks in %d files",
+			export.ID, export.Documents, export.Entities, export.Risks, len(export.Files))
+	}
+
+	saveCtx, cancelSave := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancelSave()
+	if err := p.saveAnalyticsExport(saveCtx, export); err != nil {
+		log.Printf("Failed to save analytics export %s: %v", export.ID, err)
+	}
+}
+
+func (p *PDFProcessor) saveAnalyticsExport(ctx context.Context, export *AnalyticsExport) error {
+	files, err := json.Marshal(export.Files)
+	if err != nil {
+		return err
+	}
+	return p.postgres.Exec(ctx, `
+		UPDATE analytics_exports
+		SET status = $2, documents = $3, entities = $4, risks = $5, files = $6, error = $7, finished_at = $8
+		WHERE id = $1
+	`, export.ID, export.Status, export.Documents, export.Entities, export.Risks, files, export.Error, export.FinishedAt)
+}
+
+// exportAnalytics writes the export's jobs, in order of completion, to a
+// file of each table for each day.
+func (p *PDFProcessor) exportAnalytics(ctx context.Context, export *AnalyticsExport) error {
+	rows, err := p.postgres.Query(ctx, `
+		SELECT id, parent_id, tenant_id, tender_id, user_id, filename, created_at, completed_at, result
+		FROM processing_jobs
+		WHERE status = 'completed' AND result IS NOT NULL AND completed_at >= $1 AND completed_at < $2
+		ORDER BY completed_at, id
+	`, export.From, export.To)
+	if err != nil {
+		return fmt.Errorf("failed to select jobs to export: %w", err)
+	}
+	defer rows.Close()
+
+	exporter := &analyticsExporter{
+		p:         p,
+		export:    export,
+		documents: analyticsTable{name: "documents", columns: analyticsDocuments},
+		entities:  analyticsTable{name: "entities", columns: analyticsEntities},
+		risks:     analyticsTable{name: "risks", columns: analyticsRisks},
+	}
+	defer exporter.discard()
+
+	for rows.Next() {
+		var job JobRecord
+		var completedAt time.Time
+		var data []byte
+		if err := rows.Scan(&job.ID, &job.ParentID, &job.TenantID, &job.TenderID, &job.UserID, &job.Filename,
+			&job.CreatedAt, &completedAt, &data); err != nil {
+			return err
+		}
+		job.CompletedAt = &completedAt
+		var result ProcessingResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			log.Printf("Analytics export %s skipped job %s: invalid result: %v", export.ID, job.ID, err)
+			continue
+		}
+		if err := exporter.exportJob(ctx, &job, &result); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return exporter.finish(ctx)
+}
+
+// analyticsTable is an exported table, and the file of it being written:
+// that of the jobs completed on day.
+type analyticsTable struct {
+	name    string
+	columns []parquet.Column
+
+	day    string
+	file   *os.File
+	writer *parquet.Writer
+}
+
+type analyticsExporter struct {
+	p      *PDFProcessor
+	export *AnalyticsExport
+
+	documents, entities, risks analyticsTable
+}
+
+func (e *analyticsExporter) exportJob(ctx context.Context, job *JobRecord, result *ProcessingResult) error {
+	day := job.CompletedAt.UTC().Format("2006-01-02")
+	var documentType interface{}
+	if result.Classification != nil {
+		documentType = nullable(result.Classification.Type)
+	}
+	risks := result.RiskAnalysis.IdentifiedRisks
+
+	err := e.write(ctx, &e.documents, day,
+		job.ID, nullable(job.ParentID), job.TenantID, job.TenderID, job.UserID, nullable(job.Filename),
+		job.CreatedAt, *job.CompletedAt, documentType, nullable(result.ContentSHA256),
+		result.PageCount, result.FileSize, result.RiskAnalysis.OverallRisk, result.RiskAnalysis.RiskScore,
+		result.RelevanceScore, result.QualityMetrics.TextQuality, result.QualityMetrics.OCRConfidence,
+		len(result.Entities), len(risks), result.ExtractedText)
+	if err != nil {
+		return err
+	}
+	e.export.Documents++
+
+	for _, entity := range result.Entities {
+		var date, cents, company interface{}
+		if entity.Normalized != nil {
+			date = nullable(entity.Normalized.Date)
+			if entity.Normalized.Cents != nil {
+				cents = *entity.Normalized.Cents
+			}
+		}
+		if entity.Company != nil {
+			company = nullable(entity.Company.RazaoSocial)
+		}
+		err := e.write(ctx, &e.entities, day,
+			job.ID, job.TenantID, entity.Type, entity.Value, date, cents, company, entity.Confidence,
+			nullablePage(entity.Page), entity.StartPos, entity.EndPos)
+		if err != nil {
+			return err
+		}
+		e.export.Entities++
+	}
+
+	for _, risk := range risks {
+		err := e.write(ctx, &e.risks, day,
+			job.ID, job.TenantID, risk.Category, risk.Severity, risk.Description, risk.Impact, risk.Confidence,
+			nullablePage(risk.Page), nullable(risk.Section), risk.Location, nullable(risk.Snippet))
+		if err != nil {
+			return err
+		}
+		e.export.Risks++
+	}
+	return nil
+}
+
+// write adds a row to the table's file of day, uploading the file of the
+// previous day first.
+func (e *analyticsExporter) write(ctx context.Context, t *analyticsTable, day string, row ...interface{}) error {
+	if t.file != nil && t.day != day {
+		if err := e.upload(ctx, t); err != nil {
+			return err
+		}
+	}
+	if t.file == nil {
+		file, err := os.CreateTemp(e.p.cfg.TempDir, "analytics-*.parquet")
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		t.day, t.file, t.writer = day, file, parquet.NewWriter(file, t.columns)
+	}
+	if err := t.writer.Write(row...); err != nil {
+		return fmt.Errorf("failed to write %s: %w", t.name, err)
+	}
+	return nil
+}
+
+// upload closes the table's file and uploads it to the analytics bucket.
+func (e *analyticsExporter) upload(ctx context.Context, t *analyticsTable) error {
+	defer e.remove(t)
+	if err := t.writer.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", t.name, err)
+	}
+	info, err := t.file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := t.file.Seek(0, 0); err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%s/dt=%s/%s.parquet", t.name, t.day, e.export.ID)
+	if err := e.p.objects.Put(ctx, e.p.cfg.AnalyticsBucket, key, t.file, info.Size(), "application/vnd.apache.parquet"); err != nil {
+		return err
+	}
+	e.export.Files = append(e.export.Files, key)
+	return nil
+}
+
+// finish uploads the files being written.
+func (e *analyticsExporter) finish(ctx context.Context) error {
+	for _, t := range []*analyticsTable{&e.documents, &e.entities, &e.risks} {
+		if t.file == nil {
+			continue
+		}
+		if err := e.upload(ctx, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discard removes the files of an export that failed.
+func (e *analyticsExporter) discard() {
+	e.remove(&e.documents)
+	e.remove(&e.entities)
+	e.remove(&e.risks)
+}
+
+func (e *analyticsExporter) remove(t *analyticsTable) {
+	if t.file == nil {
+		return
+	}
+	t.file.Close()
+	os.Remove(t.file.Name())
+	t.file, t.writer = nil, nil
+}
+
+// nullable leaves empty strings out of optional columns.
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// nullablePage leaves unknown pages, 0, out.
+func nullablePage(n int) interface{} {
+	if n == 0 {
+		return nil
+	}
+	return n
+}
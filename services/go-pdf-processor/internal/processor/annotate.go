@@ -0,0 +1,274 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf16"
+
+	"cotai-pdf-processor/internal/report"
+)
+
+// The annotated copy is the PDF with each identified risk highlighted where
+// its clause appears, the risk described in the highlight's comment. The
+// annotations are written as pdfmarks that Ghostscript adds while copying
+// the document. Clauses are found among the positioned words of their page;
+// risks whose clause cannot be found there get a note at the top of the
+// page instead.
+
+const annotateTimeout = 10 * time.Minute
+
+// annotationAuthor is the author shown on the annotations.
+const annotationAuthor = "CotAi"
+
+var ErrAnnotatedNotAvailable = errors.New("job has no annotated copy")
+
+// severityColors are the highlight colors of each risk severity.
+var severityColors = map[string]string{
+	"critical": "0.85 0.2 0.2",
+	"high":     "1 0.45 0.45",
+	"medium":   "1 0.75 0.3",
+	"low":      "1 1 0.4",
+}
+
+// attachAnnotatedCopy stores a copy of the job's PDF annotated with its
+// risks. Failures are logged and leave the result without a copy.
+func (p *PDFProcessor) attachAnnotatedCopy(ctx context.Context, job *ProcessingJob, filePath string) {
+	ctx, span := p.tracer.Start(ctx, "annotate_risks")
+	defer span.End()
+
+	if p.reports == nil {
+		log.Printf("Annotated copy requested for job %s but report storage is not configured", job.ID)
+		return
+	}
+
+	marks, err := riskAnnotations(filePath, job.Options.Password, job.Result.RiskAnalysis.IdentifiedRisks)
+	if err != nil {
+		log.Printf("Failed to annotate job %s: %v", job.ID, err)
+		return
+	}
+	pdf, err := p.addPDFMarks(ctx, filePath, job.Options.Password, marks)
+	if err != nil {
+		log.Printf("Failed to annotate job %s: %v", job.ID, err)
+		return
+	}
+
+	artifact, err := p.reports.PublishAnnotated(ctx, job.ID, pdf)
+	if err != nil {
+		log.Printf("Failed to publish annotated copy for job %s: %v", job.ID, err)
+		return
+	}
+	job.Result.Annotated = artifact
+}
+
+// riskAnnotations returns the pdfmarks annotating each risk.
+func riskAnnotations(filePath, password string, risks []IdentifiedRisk) (string, error) {
+	file, reader, err := openPDF(filePath, password)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	pageCount, err := safePageCount(reader)
+	if err != nil {
+		return "", err
+	}
+
+	pages := make(map[int][]*layoutWord)
+	var marks strings.Builder
+	for _, r := range risks {
+		page := r.Page
+		if page < 1 || page > pageCount {
+			page = 1
+		}
+		words, ok := pages[page]
+		if !ok {
+			if content, err := safePageContent(reader, page); err == nil {
+				words = wordsFromGlyphs(content.Text)
+			} else {
+				log.Printf("Failed to read words of page %d: %v", page, err)
+			}
+			pages[page] = words
+		}
+
+		comment := riskComment(r)
+		color, ok := severityColors[strings.ToLower(r.Severity)]
+		if !ok {
+			color = severityColors["medium"]
+		}
+
+		if quads := clauseQuads(words, r.Snippet); len(quads) > 0 {
+			rect := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+			points := make([]string, 0, len(quads))
+			for _, q := range quads {
+				rect = [4]float64{min(rect[0], q[0]), min(rect[1], q[1]), max(rect[2], q[2]), max(rect[3], q[3])}
+				// Top left, top right, bottom left, bottom right
+				points = append(points, fmt.Sprintf("%.2f %.2f %.2f %.2f %.2f %.2f %.2f %.2f",
+					q[0], q[3], q[2], q[3], q[0], q[1], q[2], q[1]))
+			}
+			fmt.Fprintf(&marks, "[ /SrcPg %d /Subtype /Highlight /Rect [%.2f %.2f %.2f %.2f] /QuadPoints [%s] /Color [%s] /T %s /Contents %s /F 4 /ANN pdfmark\n",
+				page, rect[0], rect[1], rect[2], rect[3], strings.Join(points, " "), color, pdfmarkText(annotationAuthor), pdfmarkText(comment))
+			continue
+		}
+
+		geometry, err := pageGeometryOf(reader, page)
+		if err != nil {
+			return "", fmt.Errorf("page %d: %w", page, err)
+		}
+		x, y := geometry.x0+10, geometry.y0+geometry.height-30
+		fmt.Fprintf(&marks, "[ /SrcPg %d /Subtype /Text /Rect [%.2f %.2f %.2f %.2f] /Name /Comment /Open false /Color [%s] /T %s /Contents %s /F 4 /ANN pdfmark\n",
+			page, x, y, x+20, y+20, color, pdfmarkText(annotationAuthor), pdfmarkText(comment))
+	}
+	return marks.String(), nil
+}
+
+// clauseQuads finds the clause among the page's words and returns the
+// rectangles, in PDF coordinates, covering its words on each line. Spacing
+// differs between the extracted text and the glyphs, so both are compared
+// without whitespace.
+func clauseQuads(words []*layoutWord, clause string) [][4]float64 {
+	needle := removeSpaces(clause)
+	if needle == "" || len(words) == 0 {
+		return nil
+	}
+
+	var haystack strings.Builder
+	starts := make([]int, len(words))
+	for i, w := range words {
+		starts[i] = haystack.Len()
+		haystack.WriteString(removeSpaces(w.text))
+	}
+	at := strings.Index(haystack.String(), needle)
+	if at < 0 {
+		return nil
+	}
+	end := at + len(needle)
+
+	var quads [][4]float64
+	var lineY float64
+	for i, w := range words {
+		wordEnd := haystack.Len()
+		if i+1 < len(words) {
+			wordEnd = starts[i+1]
+		}
+		if wordEnd <= at || starts[i] >= end || wordEnd == starts[i] {
+			continue
+		}
+		// layoutWord y coordinates are negated
+		x0, x1, bottom, top := w.x0, estimatedRight(w), -w.y1-w.height()/4, -w.y0
+		if n := len(quads); n > 0 && math.Abs(-w.y1-lineY) < w.height()/2 {
+			q := &quads[n-1]
+			*q = [4]float64{min(q[0], x0), min(q[1], bottom), max(q[2], x1), max(q[3], top)}
+			continue
+		}
+		quads = append(quads, [4]float64{x0, bottom, x1, top})
+		lineY = -w.y1
+	}
+	return quads
+}
+
+func removeSpaces(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func riskComment(r IdentifiedRisk) string {
+	comment := fmt.Sprintf("[%s] %s: %s", strings.ToUpper(r.Severity), r.Category, r.Description)
+	if r.Impact != "" {
+		comment += "\nImpact: " + r.Impact
+	}
+	return comment
+}
+
+// pdfmarkText encodes s as a UTF-16 hex string, which pdfwrite copies into
+// the PDF as a text string.
+func pdfmarkText(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// addPDFMarks copies a PDF with Ghostscript, applying the pdfmarks.
+func (p *PDFProcessor) addPDFMarks(ctx context.Context, filePath, password, marks string) ([]byte, error) {
+	marksFile, err := os.CreateTemp(p.cfg.TempDir, "cotai-marks-*.ps")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(marksFile.Name())
+	_, err = marksFile.WriteString(marks)
+	if closeErr := marksFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := os.CreateTemp(p.cfg.TempDir, "cotai-annotated-*.pdf")
+	if err != nil {
+		return nil, err
+	}
+	out.Close()
+	defer os.Remove(out.Name())
+
+	ctx, cancel := context.WithTimeout(ctx, annotateTimeout)
+	defer cancel()
+
+	args := []string{
+		"-dBATCH",
+		"-dNOPAUSE",
+		"-dSAFER",
+		"-dQUIET",
+		"-sDEVICE=pdfwrite",
+		// Annotations are placed in the pages' own coordinates
+		"-dAutoRotatePages=/None",
+		"-sOutputFile=" + out.Name(),
+	}
+	if password != "" {
+		arg, remove, err := p.ghostscriptPassword(password)
+		if err != nil {
+			return nil, err
+		}
+		defer remove()
+		args = append(args, arg)
+	}
+	// The marks follow the document so the pages they refer to exist
+	args = append(args, filePath, marksFile.Name())
+
+	if _, err := p.runGhostscript(ctx, args...); err != nil {
+		return nil, err
+	}
+
+	pdf, err := os.ReadFile(out.Name())
+	if err != nil {
+		return nil, err
+	}
+	if len(pdf) == 0 {
+		return nil, errors.New("ghostscript produced no output")
+	}
+	return pdf, nil
+}
+
+// AnnotatedURL returns a fresh download URL for a job's annotated copy.
+func (p *PDFProcessor) AnnotatedURL(ctx context.Context, jobID string) (*report.Artifact, error) {
+	job, err := p.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if p.reports == nil || job.Result == nil || job.Result.Annotated == nil {
+		return nil, ErrAnnotatedNotAvailable
+	}
+	return p.reports.Sign(ctx, job.Result.Annotated.Key)
+}
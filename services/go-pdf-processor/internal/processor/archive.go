@@ -0,0 +1,371 @@
+package processor
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"cotai-pdf-processor/internal/download"
+
+	"github.com/google/uuid"
+)
+
+// maxArchiveEntries caps how many documents a single archive may expand to.
+const maxArchiveEntries = 100
+
+// parentClaimTTL bounds how long one child's update may hold its parent.
+const parentClaimTTL = 30 * time.Second
+
+// errArchiveTooLarge is returned for archives whose documents add up to more
+// than MaxArchiveSize bytes.
+var errArchiveTooLarge = fmt.Errorf("archive documents exceed the maximum total size: %w", download.ErrFileTooLarge)
+
+// archiveEntryTypes maps the extensions expanded from archives to the
+// content type recorded on the child job.
+var archiveEntryTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".tif":  "image/tiff",
+	".tiff": "image/tiff",
+	".docx": docxMimeType,
+	".odt":  odtMimeType,
+	".xlsx": xlsxMimeType,
+	".csv":  "text/csv",
+}
+
+func isArchiveEntry(job *ProcessingJob) bool {
+	entry, _ := job.Metadata["archive_entry"].(bool)
+	return job.ParentID != "" && entry
+}
+
+func removeArchiveEntry(filePath string) {
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove archive entry %s: %v", filePath, err)
+	}
+}
+
+// expandArchive extracts the supported documents in a ZIP file and creates a
+// child job for each of them. The parent job stays in "expanded" state until
+// every child has finished (see updateParentJob).
+func (p *PDFProcessor) expandArchive(ctx context.Context, job *ProcessingJob, file *download.File) error {
+	ctx, span := p.tracer.Start(ctx, "expand_archive")
+	defer span.End()
+
+	archive, err := zip.OpenReader(file.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer archive.Close()
+
+	var children []*ProcessingJob
+	var unpacked int64
+	for _, entry := range archive.File {
+		name := entry.Name
+		base := path.Base(name)
+		if entry.FileInfo().IsDir() || strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(base, ".") {
+			continue
+		}
+
+		contentType, ok := archiveEntryTypes[strings.ToLower(path.Ext(base))]
+		if !ok {
+			log.Printf("Job %s: skipping unsupported archive entry %s", job.ID, name)
+			continue
+		}
+		if len(children) == maxArchiveEntries {
+			p.removeChildFiles(children)
+			return fmt.Errorf("archive contains more than %d documents", maxArchiveEntries)
+		}
+
+		childID := uuid.New().String()
+		entryPath, written, err := p.extractArchiveEntry(entry, childID, unpacked)
+		if err != nil {
+			p.removeChildFiles(children)
+			return fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+		unpacked += written
+
+		child := &ProcessingJob{
+			ID:        childID,
+			ParentID:  job.ID,
+			FileURL:   entryPath,
+			TenderID:  job.TenderID,
+			UserID:    job.UserID,
+			TenantID:  job.TenantID,
+			Profile:   job.Profile,
+			Priority:  job.Priority,
+			Options:   job.Options,
+			Status:    "queued",
+			CreatedAt: time.Now(),
+			Metadata: map[string]interface{}{
+				"original_filename": name,
+				"content_type":      contentType,
+				"archive_entry":     true,
+			},
+		}
+		if id, ok := job.Metadata["interest_profile_id"]; ok {
+			child.Metadata["interest_profile_id"] = id
+		}
+		children = append(children, child)
+	}
+
+	if len(children) == 0 {
+		return fmt.Errorf("archive contains no supported documents")
+	}
+
+	job.Status = "expanded"
+	job.ChildIDs = make([]string, len(children))
+	for i, child := range children {
+		job.ChildIDs[i] = child.ID
+		if err := p.updateJobStatus(ctx, child); err != nil {
+			log.Printf("Failed to save child job %s: %v", child.ID, err)
+		}
+	}
+	job.children = children
+
+	if err := p.updateJobStatus(ctx, job); err != nil {
+		log.Printf("Failed to update job status: %v", err)
+	}
+
+	log.Printf("Job %s: archive expanded into %d documents", job.ID, len(children))
+	return nil
+}
+
+// extractArchiveEntry writes entry to a temporary file, given the bytes
+// already extracted from its archive, and returns the file and its size.
+func (p *PDFProcessor) extractArchiveEntry(entry *zip.File, childID string, unpacked int64) (string, int64, error) {
+	size := int64(entry.UncompressedSize64)
+	if p.cfg.MaxFileSize > 0 && size > p.cfg.MaxFileSize {
+		return "", 0, download.ErrFileTooLarge
+	}
+	if p.cfg.MaxArchiveSize > 0 && size > p.cfg.MaxArchiveSize-unpacked {
+		return "", 0, errArchiveTooLarge
+	}
+
+	src, err := entry.Open()
+	if err != nil {
+		return "", 0, err
+	}
+	defer src.Close()
+
+	out, err := os.CreateTemp(p.cfg.TempDir, "cotai-entry-"+childID+"-*"+strings.ToLower(path.Ext(entry.Name)))
+	if err != nil {
+		return "", 0, err
+	}
+
+	// The header size can lie, so cap the actual bytes written as well.
+	limit := p.cfg.MaxFileSize
+	if p.cfg.MaxArchiveSize > 0 && (limit <= 0 || p.cfg.MaxArchiveSize-unpacked < limit) {
+		limit = p.cfg.MaxArchiveSize - unpacked
+	}
+	reader := io.Reader(src)
+	if limit > 0 {
+		reader = io.LimitReader(src, limit+1)
+	}
+	written, err := io.Copy(out, reader)
+	closeErr := out.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil && p.cfg.MaxFileSize > 0 && written > p.cfg.MaxFileSize {
+		err = download.ErrFileTooLarge
+	}
+	if err == nil && p.cfg.MaxArchiveSize > 0 && written > p.cfg.MaxArchiveSize-unpacked {
+		err = errArchiveTooLarge
+	}
+	if err != nil {
+		removeArchiveEntry(out.Name())
+		return "", 0, err
+	}
+
+	return out.Name(), written, nil
+}
+
+func (p *PDFProcessor) removeChildFiles(children []*ProcessingJob) {
+	for _, child := range children {
+		removeArchiveEntry(child.FileURL)
+	}
+}
+
+// updateParentJob recomputes the aggregate status of job's parent, if any.
+// Once every child has finished the parent gets a combined result; it is
+// "completed" if at least one child succeeded and "failed" otherwise.
+// Children finishing together update their parent in turn, under its claim,
+// so each counts the others and the result is combined only once.
+func (p *PDFProcessor) updateParentJob(ctx context.Context, job *ProcessingJob) {
+	if job.ParentID == "" {
+		return
+	}
+
+	claimCtx, cancel := context.WithTimeout(ctx, parentClaimTTL)
+	defer cancel()
+	release, err := p.awaitJobClaim(claimCtx, job.ParentID, parentClaimTTL)
+	if err != nil {
+		log.Printf("Failed to claim parent job %s: %v", job.ParentID, err)
+		return
+	}
+	defer release()
+
+	parent, err := p.loadJob(ctx, job.ParentID)
+	if err != nil {
+		log.Printf("Failed to load parent job %s: %v", job.ParentID, err)
+		return
+	}
+	// A finished archive or tender was already combined by another child
+	if parent.Status == "cancelled" || (isFinished(parent.Status) && !isAttachmentJob(job)) {
+		return
+	}
+
+	if parent.Metadata == nil {
+		parent.Metadata = make(map[string]interface{})
+	}
+
+	children := make([]*ProcessingJob, 0, len(parent.ChildIDs))
+	finished := 0
+	for _, childID := range parent.ChildIDs {
+		child := job
+		if childID != job.ID {
+			if child, err = p.loadJob(ctx, childID); err != nil {
+				log.Printf("Failed to load child job %s: %v", childID, err)
+				return
+			}
+		}
+		if isFinished(child.Status) {
+			finished++
+		}
+		children = append(children, child)
+	}
+
+	parent.Metadata["children_finished"] = finished
+	parent.Metadata["children_total"] = len(children)
+
+	// The parent of attachments keeps its own result
+	combine := finished == len(children) && !isAttachmentJob(job)
+	if combine {
+		parent.Result = combineChildResults(children)
+		completedAt := time.Now()
+		parent.CompletedAt = &completedAt
+		parent.Status = "failed"
+		for _, child := range children {
+			if child.Status == "completed" {
+				parent.Status = "completed"
+				break
+			}
+		}
+		switch {
+		case parent.Status == "failed" && isTenderJob(parent):
+			parent.Error = "all files of the tender failed to process"
+		case parent.Status == "failed":
+			parent.Error = "all documents in the archive failed to process"
+		case isTenderJob(parent):
+			p.consolidateTender(ctx, parent, children)
+		}
+		if parent.Status == "completed" && parent.Options.GenerateReport {
+			p.attachReport(ctx, parent)
+		}
+	}
+
+	if err := p.updateJobStatus(ctx, parent); err != nil {
+		log.Printf("Failed to update parent job %s: %v", parent.ID, err)
+		return
+	}
+
+	if combine {
+		if err := p.storeResults(ctx, parent); err != nil {
+			log.Printf("Failed to store results: %v", err)
+		}
+	}
+}
+
+// combineChildResults merges the results of an archive's documents into a
+// single result for the parent job.
+func combineChildResults(children []*ProcessingJob) *ProcessingResult {
+	combined := &ProcessingResult{
+		Entities: []ExtractedEntity{},
+		RiskAnalysis: RiskAnalysis{
+			OverallRisk:     "low",
+			IdentifiedRisks: []IdentifiedRisk{},
+			Recommendations: []string{},
+		},
+		Metadata: make(map[string]interface{}),
+	}
+
+	var texts []string
+	var documents []map[string]interface{}
+	seenRecommendations := make(map[string]bool)
+	succeeded := 0
+
+	for _, child := range children {
+		documents = append(documents, map[string]interface{}{
+			"job_id":   child.ID,
+			"filename": child.Metadata["original_filename"],
+			"status":   child.Status,
+			"error":    child.Error,
+		})
+
+		result := child.Result
+		if child.Status != "completed" || result == nil {
+			continue
+		}
+		succeeded++
+
+		texts = append(texts, fmt.Sprintf("=== %v ===\n%s", child.Metadata["original_filename"], result.ExtractedText))
+		combined.PageCount += result.PageCount
+		combined.FileSize += result.FileSize
+		combined.ProcessingTime += result.ProcessingTime
+		combined.Entities = append(combined.Entities, result.Entities...)
+		combined.Tables = append(combined.Tables, result.Tables...)
+
+		risk := result.RiskAnalysis
+		combined.RiskAnalysis.IdentifiedRisks = append(combined.RiskAnalysis.IdentifiedRisks, risk.IdentifiedRisks...)
+		if risk.RiskScore > combined.RiskAnalysis.RiskScore {
+			combined.RiskAnalysis.RiskScore = risk.RiskScore
+			combined.RiskAnalysis.OverallRisk = risk.OverallRisk
+		}
+		combined.RiskAnalysis.Confidence += risk.Confidence
+		if risk.ProfileID != "" {
+			// Entries inherit the archive's options, so they share a profile
+			combined.RiskAnalysis.Profile = risk.Profile
+			combined.RiskAnalysis.ProfileID = risk.ProfileID
+		}
+		for _, rec := range risk.Recommendations {
+			if !seenRecommendations[rec] {
+				seenRecommendations[rec] = true
+				combined.RiskAnalysis.Recommendations = append(combined.RiskAnalysis.Recommendations, rec)
+			}
+		}
+
+		if result.RelevanceScore > combined.RelevanceScore {
+			combined.RelevanceScore = result.RelevanceScore
+		}
+
+		q := result.QualityMetrics
+		combined.QualityMetrics.TextQuality += q.TextQuality
+		combined.QualityMetrics.OCRConfidence += q.OCRConfidence
+		combined.QualityMetrics.DocumentClarity += q.DocumentClarity
+		combined.QualityMetrics.Completeness += q.Completeness
+		combined.QualityMetrics.Readability += q.Readability
+		combined.QualityMetrics.OCRCorrections += q.OCRCorrections
+	}
+
+	if succeeded > 0 {
+		n := float64(succeeded)
+		combined.RiskAnalysis.Confidence /= n
+		combined.QualityMetrics.TextQuality /= n
+		combined.QualityMetrics.OCRConfidence /= n
+		combined.QualityMetrics.DocumentClarity /= n
+		combined.QualityMetrics.Completeness /= n
+		combined.QualityMetrics.Readability /= n
+	}
+
+	combined.ExtractedText = strings.Join(texts, "\n\n")
+	combined.Metadata["documents"] = documents
+	return combined
+}
@@ -0,0 +1,245 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"cotai-pdf-processor/internal/download"
+
+	"github.com/google/uuid"
+	"github.com/ledongthuc/pdf"
+)
+
+// maxAttachments caps how many embedded files are extracted from a PDF.
+const maxAttachments = 50
+
+// Attachment is a file embedded in a PDF, such as the planilha of an edital,
+// stored in the attachment bucket. JobID is the child job processing it.
+type Attachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Page        int    `json:"page,omitempty"` // set for file attachment annotations
+	Bucket      string `json:"bucket"`
+	Key         string `json:"key"`
+	JobID       string `json:"job_id,omitempty"`
+}
+
+// embeddedFile is a file specification found in a PDF.
+type embeddedFile struct {
+	name string
+	page int
+	spec pdf.Value
+}
+
+func isAttachmentJob(job *ProcessingJob) bool {
+	attachment, _ := job.Metadata["attachment"].(bool)
+	return job.ParentID != "" && attachment
+}
+
+// extractAttachments stores the files embedded in a PDF and, when the job
+// asks for it, creates a child job for each one the processor can read.
+// Attachments are secondary to the document, so failures are logged and
+// skip the file.
+func (p *PDFProcessor) extractAttachments(ctx context.Context, job *ProcessingJob, file *download.File) {
+	ctx, span := p.tracer.Start(ctx, "extract_attachments")
+	defer span.End()
+
+	if p.objects == nil {
+		log.Printf("Job %s: attachment storage is not configured", job.ID)
+		return
+	}
+
+	pdfFile, reader, err := openPDF(file.Path, job.Options.Password)
+	if err != nil {
+		log.Printf("Job %s: failed to open PDF for attachments: %v", job.ID, err)
+		return
+	}
+	defer pdfFile.Close()
+
+	files := embeddedFiles(reader)
+	if len(files) > maxAttachments {
+		log.Printf("Job %s: extracting the first %d of %d attachments", job.ID, maxAttachments, len(files))
+		files = files[:maxAttachments]
+	}
+
+	var children []*ProcessingJob
+	for i, embedded := range files {
+		data, err := readEmbeddedFile(embedded.spec, p.cfg.MaxFileSize)
+		if err != nil {
+			log.Printf("Job %s: failed to read attachment %s: %v", job.ID, embedded.name, err)
+			continue
+		}
+
+		contentType, supported := archiveEntryTypes[strings.ToLower(path.Ext(embedded.name))]
+		if !supported {
+			contentType = sniffBytes(data)
+		}
+		attachment := Attachment{
+			Name:        embedded.name,
+			ContentType: contentType,
+			Size:        int64(len(data)),
+			Page:        embedded.page,
+			Bucket:      p.cfg.AttachmentBucket,
+			Key:         fmt.Sprintf("attachments/%s/%d-%s", job.ID, i+1, path.Base(embedded.name)),
+		}
+		if err := p.objects.Put(ctx, attachment.Bucket, attachment.Key, bytes.NewReader(data), attachment.Size, contentType); err != nil {
+			log.Printf("Job %s: failed to store attachment %s: %v", job.ID, embedded.name, err)
+			continue
+		}
+
+		if job.Options.ProcessAttachments && supported {
+			child := p.attachmentJob(job, attachment)
+			attachment.JobID = child.ID
+			children = append(children, child)
+		}
+		job.Result.Attachments = append(job.Result.Attachments, attachment)
+	}
+
+	for _, child := range children {
+		job.ChildIDs = append(job.ChildIDs, child.ID)
+		if err := p.updateJobStatus(ctx, child); err != nil {
+			log.Printf("Failed to save child job %s: %v", child.ID, err)
+		}
+	}
+	job.children = append(job.children, children...)
+
+	if len(job.Result.Attachments) > 0 {
+		log.Printf("Job %s: extracted %d attachments, %d queued for processing", job.ID, len(job.Result.Attachments), len(children))
+	}
+}
+
+// attachmentJob creates the child job processing a stored attachment.
+// Attachments nested in it are stored but not processed.
+func (p *PDFProcessor) attachmentJob(job *ProcessingJob, attachment Attachment) *ProcessingJob {
+	options := job.Options
+	options.ProcessAttachments = false
+	child := &ProcessingJob{
+		ID:        uuid.New().String(),
+		ParentID:  job.ID,
+		FileURL:   fmt.Sprintf("s3://%s/%s", attachment.Bucket, attachment.Key),
+		TenderID:  job.TenderID,
+		UserID:    job.UserID,
+		TenantID:  job.TenantID,
+		Profile:   job.Profile,
+		Priority:  job.Priority,
+		Options:   options,
+		Status:    "queued",
+		CreatedAt: time.Now(),
+		Metadata: map[string]interface{}{
+			"original_filename": attachment.Name,
+			"content_type":      attachment.ContentType,
+			"attachment":        true,
+		},
+	}
+	if id, ok := job.Metadata["interest_profile_id"]; ok {
+		child.Metadata["interest_profile_id"] = id
+	}
+	return child
+}
+
+// embeddedFiles lists the files of the document's EmbeddedFiles name tree
+// and of its file attachment annotations. The pdf package panics on
+// malformed objects, in which case the files found so far are returned.
+func embeddedFiles(reader *pdf.Reader) (files []embeddedFile) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Stopped reading attachments at malformed object: %v", r)
+		}
+	}()
+
+	seen := make(map[string]bool)
+	add := func(name string, page int, spec pdf.Value) {
+		stream := spec.Key("EF").Key("F")
+		if stream.Kind() != pdf.Stream {
+			return
+		}
+		if name == "" {
+			name = fileSpecName(spec)
+		}
+		if name == "" {
+			name = fmt.Sprintf("attachment-%d", len(files)+1)
+		}
+		// The same file is often both in the name tree and annotated
+		key := fmt.Sprintf("%s/%d", name, stream.Key("Length").Int64())
+		if !seen[key] {
+			seen[key] = true
+			files = append(files, embeddedFile{name: name, page: page, spec: spec})
+		}
+	}
+
+	var walk func(node pdf.Value, depth int)
+	walk = func(node pdf.Value, depth int) {
+		if depth > 32 {
+			return
+		}
+		names := node.Key("Names")
+		for i := 0; i+1 < names.Len(); i += 2 {
+			spec := names.Index(i + 1)
+			name := fileSpecName(spec)
+			if name == "" {
+				name = names.Index(i).Text()
+			}
+			add(name, 0, spec)
+		}
+		kids := node.Key("Kids")
+		for i := 0; i < kids.Len(); i++ {
+			walk(kids.Index(i), depth+1)
+		}
+	}
+	walk(reader.Trailer().Key("Root").Key("Names").Key("EmbeddedFiles"), 0)
+
+	for num := 1; num <= reader.NumPage(); num++ {
+		annots := reader.Page(num).V.Key("Annots")
+		for i := 0; i < annots.Len(); i++ {
+			annot := annots.Index(i)
+			if annot.Key("Subtype").Name() == "FileAttachment" {
+				add("", num, annot.Key("FS"))
+			}
+		}
+	}
+	return files
+}
+
+// fileSpecName is the file name of a file specification, preferring the
+// Unicode one.
+func fileSpecName(spec pdf.Value) string {
+	for _, key := range []string{"UF", "F"} {
+		if name := strings.TrimSpace(spec.Key(key).Text()); name != "" {
+			return path.Base(strings.ReplaceAll(name, "\\", "/"))
+		}
+	}
+	return ""
+}
+
+// readEmbeddedFile decodes an embedded file stream, up to limit bytes when
+// limit is positive.
+func readEmbeddedFile(spec pdf.Value, limit int64) (data []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed stream: %v", r)
+		}
+	}()
+
+	rc := spec.Key("EF").Key("F").Reader()
+	defer rc.Close()
+
+	reader := io.Reader(rc)
+	if limit > 0 {
+		reader = io.LimitReader(rc, limit+1)
+	}
+	data, err = io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && int64(len(data)) > limit {
+		return nil, download.ErrFileTooLarge
+	}
+	return data, nil
+}
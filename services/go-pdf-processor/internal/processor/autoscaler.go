@@ -0,0 +1,165 @@
+package processor
+
+import (
+	"context"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// With WORKER_AUTOSCALE, the worker pool sizes itself for the job queue:
+// enough workers for the jobs running and for the queued ones to start
+// within AUTOSCALE_TARGET_WAIT, at the pool's average processing time.
+// More workers are added at once; fewer are kept only once they have been
+// enough for AUTOSCALE_COOLDOWN, so a burst does not make the pool shrink
+// and grow again. Resizing the pool by hand holds until the next decision.
+// The queue is shared, so every replica sizes itself for all of it within
+// its bounds.
+
+// maxScalingDecisions is how many of the latest scaling decisions are
+// kept for the pool stats.
+const maxScalingDecisions = 20
+
+// ScalingDecision is a change of the worker count made by the autoscaler.
+type ScalingDecision struct {
+	At          time.Time `json:"at"`
+	From        int       `json:"from"`
+	To          int       `json:"to"`
+	Reason      string    `json:"reason"`
+	QueuedJobs  int       `json:"queued_jobs"`
+	ActiveJobs  int       `json:"active_jobs"`
+	AverageTime float64   `json:"average_processing_time"`
+}
+
+// AutoscalerStats are the bounds and decisions of the autoscaler.
+type AutoscalerStats struct {
+	MinWorkers int   `json:"min_workers"`
+	MaxWorkers int   `json:"max_workers"`
+	ScaleUps   int64 `json:"scale_ups"`
+	ScaleDowns int64 `json:"scale_downs"`
+
+	// Decisions are the latest first
+	Decisions []ScalingDecision `json:"decisions"`
+}
+
+type autoscaler struct {
+	pool                 *WorkerPool
+	minWorkers           int
+	maxWorkers           int
+	interval, targetWait time.Duration
+	cooldown             time.Duration
+
+	mu    sync.Mutex
+	stats AutoscalerStats
+
+	// fewerSince is when fewer workers first became enough
+	fewerSince time.Time
+}
+
+func newAutoscaler(pool *WorkerPool) *autoscaler {
+	cfg := pool.processor.cfg
+	maxWorkers := min(cfg.AutoscaleMaxWorkers, cfg.MaxWorkerCount)
+	minWorkers := min(max(cfg.AutoscaleMinWorkers, 1), maxWorkers)
+	return &autoscaler{
+		pool:       pool,
+		minWorkers: minWorkers,
+		maxWorkers: maxWorkers,
+		interval:   cfg.AutoscaleInterval,
+		targetWait: cfg.AutoscaleTargetWait,
+		cooldown:   cfg.AutoscaleCooldown,
+		stats:      AutoscalerStats{MinWorkers: minWorkers, MaxWorkers: maxWorkers},
+	}
+}
+
+// run sizes the pool every interval until ctx is done.
+func (a *autoscaler) run(ctx context.Context) {
+	log.Printf("Autoscaling workers between %d and %d", a.minWorkers, a.maxWorkers)
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		a.evaluate(ctx, a.pool.GetStats())
+	}
+}
+
+// desired is how many workers the pool needs for its current load.
+func (a *autoscaler) desired(stats PoolStats) int {
+	wait := a.targetWait.Seconds()
+	average := stats.AverageTime
+	if average <= 0 {
+		// No job finished yet: one worker for each queued job
+		average = wait
+	}
+	needed := stats.ActiveJobs + int(math.Ceil(float64(stats.QueuedJobs)*average/wait))
+	return min(max(needed, a.minWorkers), a.maxWorkers)
+}
+
+func (a *autoscaler) evaluate(ctx context.Context, stats PoolStats) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	target := a.desired(stats)
+	current := stats.TotalWorkers
+	now := time.Now()
+
+	var reason string
+	switch {
+	case target > current:
+		reason = "queue backlog"
+	case target < current:
+		if a.fewerSince.IsZero() {
+			a.fewerSince = now
+		}
+		if now.Sub(a.fewerSince) < a.cooldown {
+			return
+		}
+		reason = "idle workers"
+	default:
+		a.fewerSince = time.Time{}
+		return
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+	if err := a.pool.Resize(target); err != nil {
+		log.Printf("Autoscaler failed to resize the worker pool to %d: %v", target, err)
+		return
+	}
+	a.fewerSince = time.Time{}
+
+	decision := ScalingDecision{
+		At:          now,
+		From:        current,
+		To:          target,
+		Reason:      reason,
+		QueuedJobs:  stats.QueuedJobs,
+		ActiveJobs:  stats.ActiveJobs,
+		AverageTime: stats.AverageTime,
+	}
+	if target > current {
+		a.stats.ScaleUps++
+	} else {
+		a.stats.ScaleDowns++
+	}
+	a.stats.Decisions = append([]ScalingDecision{decision}, a.stats.Decisions...)
+	if len(a.stats.Decisions) > maxScalingDecisions {
+		a.stats.Decisions = a.stats.Decisions[:maxScalingDecisions]
+	}
+	log.Printf("Autoscaler scaled workers from %d to %d (%s): %d queued, %d active, %.1fs average",
+		current, target, reason, stats.QueuedJobs, stats.ActiveJobs, stats.AverageTime)
+}
+
+func (a *autoscaler) Stats() *AutoscalerStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	stats := a.stats
+	stats.Decisions = append([]ScalingDecision(nil), a.stats.Decisions...)
+	return &stats
+}
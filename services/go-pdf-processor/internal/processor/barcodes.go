@@ -0,0 +1,139 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Digitally issued documents such as certidões and notas fiscais carry QR
+// codes and barcodes, often holding the URL their authenticity is checked
+// at. Pages are rendered and read with ZBar's zbarimg command, and each
+// code found becomes a BARCODE entity whose value is the payload.
+
+const (
+	// barcodeDPI is the resolution pages are rendered at for barcode
+	// detection, enough for the small QR codes in page footers.
+	barcodeDPI = 200
+
+	// barcodeTimeout bounds the detection on all the pages of a document.
+	barcodeTimeout = 2 * time.Minute
+
+	// zbarNoSymbols is zbarimg's exit status when it finds no barcode.
+	zbarNoSymbols = 4
+)
+
+// zbarOutput is zbarimg's --xml report, one source per image read.
+type zbarOutput struct {
+	Sources []struct {
+		Indexes []struct {
+			Symbols []struct {
+				Type string `xml:"type,attr"`
+				Data struct {
+					Format string `xml:"format,attr"`
+					Text   string `xml:",chardata"`
+				} `xml:"data"`
+			} `xml:"symbol"`
+		} `xml:"index"`
+	} `xml:"source"`
+}
+
+// detectBarcodes returns the barcodes on the pages of a PDF, rendered for
+// the purpose, or on an image. Failures are logged and yield no barcodes.
+func (p *PDFProcessor) detectBarcodes(ctx context.Context, job *ProcessingJob, format documentFormat, filePath string) []ExtractedEntity {
+	ctx, span := p.tracer.Start(ctx, "detect_barcodes")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, barcodeTimeout)
+	defer cancel()
+
+	images := []string{filePath}
+	switch format {
+	case formatImage:
+	case formatPDF:
+		dir, rendered, err := p.rasterizePDF(ctx, filePath, job.Options.Password, "pnggray", barcodeDPI, job.Options.MaxPages)
+		if err != nil {
+			log.Printf("Failed to render pages of job %s for barcode detection: %v", job.ID, err)
+			return nil
+		}
+		defer os.RemoveAll(dir)
+		images = rendered
+	default:
+		return nil
+	}
+
+	var entities []ExtractedEntity
+	for i, image := range images {
+		symbols, err := p.readBarcodes(ctx, image)
+		if err != nil {
+			log.Printf("Barcode detection failed on page %d of job %s: %v", i+1, job.ID, err)
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		for _, symbol := range symbols {
+			entities = append(entities, ExtractedEntity{
+				Type:       "BARCODE",
+				Value:      symbol.payload,
+				Symbology:  symbol.symbology,
+				Confidence: 1,
+				Page:       i + 1,
+			})
+		}
+	}
+	return entities
+}
+
+// barcode is a code read from an image.
+type barcode struct {
+	symbology string
+	payload   string
+}
+
+// readBarcodes runs zbarimg on one image.
+func (p *PDFProcessor) readBarcodes(ctx context.Context, path string) ([]barcode, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.cfg.ZBarPath, "--quiet", "--xml", path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == zbarNoSymbols {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("zbarimg failed: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	var output zbarOutput
+	if err := xml.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return nil, fmt.Errorf("invalid zbarimg output: %w", err)
+	}
+
+	var codes []barcode
+	for _, source := range output.Sources {
+		for _, index := range source.Indexes {
+			for _, symbol := range index.Symbols {
+				payload := symbol.Data.Text
+				// Binary payloads are reported in base64
+				if symbol.Data.Format == "base64" {
+					decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(payload))
+					if err != nil {
+						continue
+					}
+					payload = string(decoded)
+				}
+				codes = append(codes, barcode{symbology: symbol.Type, payload: strings.TrimSpace(payload)})
+			}
+		}
+	}
+	return codes, nil
+}
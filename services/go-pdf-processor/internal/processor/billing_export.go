@@ -0,0 +1,401 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Billing exports write the usage records of metered jobs (see usage.go)
+// as CSV, for finance to attribute processing costs per tenant and tender.
+// Each file goes to BILLING_BUCKET, as
+//
+//	usage/dt=2024-05-02/<export id>.csv
+//
+// dated by the end of the range it covers, and is posted to
+// BILLING_WEBHOOK_URL, signed as processing hooks are with
+// BILLING_WEBHOOK_SECRET. Like analytics exports, an export covers the
+// jobs metered in [From, To), and incremental ones go on from the end of
+// the last that completed, so each record is exported once; an export that
+// fails after uploading is retried whole, so consumers should take records
+// by job_id, which is unique. Exports with no records write and post
+// nothing.
+
+var (
+	ErrBillingExportNotFound = errors.New("billing export not found")
+	ErrBillingExportRunning  = errors.New("an incremental billing export is running already")
+	ErrInvalidBillingExport  = errors.New("invalid billing export")
+	ErrBillingExportDisabled = errors.New("billing exports need the object store or BILLING_WEBHOOK_URL")
+)
+
+// Statuses of a billing export
+const (
+	BillingExportRunning   = "running"
+	BillingExportCompleted = "completed"
+	BillingExportFailed    = "failed"
+)
+
+// billingExportEvent names the calls of the billing webhook.
+const billingExportEvent = "billing.usage_export"
+
+// billingColumns are the columns of the exported CSV, in order.
+var billingColumns = []string{
+	"job_id", "tenant_id", "tender_id", "user_id", "month", "metered_at",
+	"pages", "ocr_pages", "ocr_seconds", "processing_seconds", "storage_bytes",
+}
+
+// BillingExport is an export of the usage records of the jobs metered in
+// [From, To), and its outcome. File is the key of the CSV written to the
+// billing bucket; Delivered tells whether it was posted to the billing
+// webhook.
+type BillingExport struct {
+	ID          string     `json:"id"`
+	Trigger     string     `json:"trigger"`
+	Incremental bool       `json:"incremental"`
+	Status      string     `json:"status"`
+	From        time.Time  `json:"from"`
+	To          time.Time  `json:"to"`
+	Records     int64      `json:"records"`
+	File        string     `json:"file,omitempty"`
+	Delivered   bool       `json:"delivered"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+const billingExportColumns = `id, trigger, incremental, status, range_start, range_end,
+	records, file, delivered, error, started_at, finished_at`
+
+func scanBillingExport(row rowScanner) (*BillingExport, error) {
+	var export BillingExport
+	var finishedAt sql.NullTime
+	err := row.Scan(&export.ID, &export.Trigger, &export.Incremental, &export.Status, &export.From, &export.To,
+		&export.Records, &export.File, &export.Delivered, &export.Error, &export.StartedAt, &finishedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBillingExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if finishedAt.Valid {
+		export.FinishedAt = &finishedAt.Time
+	}
+	return &export, nil
+}
+
+// ListBillingExports returns the latest exports first.
+func (p *PDFProcessor) ListBillingExports(ctx context.Context, limit int) ([]BillingExport, error) {
+	rows, err := p.postgres.Query(ctx,
+		`SELECT `+billingExportColumns+` FROM billing_exports ORDER BY started_at DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list billing exports: %w", err)
+	}
+	defer rows.Close()
+
+	exports := []BillingExport{}
+	for rows.Next() {
+		export, err := scanBillingExport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read billing export: %w", err)
+		}
+		exports = append(exports, *export)
+	}
+	return exports, rows.Err()
+}
+
+func (p *PDFProcessor) GetBillingExport(ctx context.Context, id string) (*BillingExport, error) {
+	return scanBillingExport(p.postgres.QueryRow(ctx,
+		`SELECT `+billingExportColumns+` FROM billing_exports WHERE id = $1`, id))
+}
+
+// billingExportEnabled tells whether exports have somewhere to go.
+func (p *PDFProcessor) billingExportEnabled() bool {
+	return p.objects != nil || p.cfg.BillingWebhookURL != ""
+}
+
+// StartBillingExport exports the records of the jobs metered in [from, to)
+// in the background, returning the export as it starts. Without a range
+// the export is incremental.
+func (p *PDFProcessor) StartBillingExport(ctx context.Context, from, to *time.Time) (*BillingExport, error) {
+	if !p.billingExportEnabled() {
+		return nil, ErrBillingExportDisabled
+	}
+	if (from == nil) != (to == nil) {
+		return nil, fmt.Errorf("%w: from and to go together", ErrInvalidBillingExport)
+	}
+	if from != nil && !from.Before(*to) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidBillingExport)
+	}
+
+	export, err := p.claimBillingExport(ctx, "manual", nil, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if export == nil {
+		return nil, ErrBillingExportRunning
+	}
+	go p.runBillingExport(context.Background(), export)
+	return export, nil
+}
+
+// claimBillingExport records an export as running, the next incremental
+// one when from and to are nil. It returns nil when another replica runs
+// the export of the same scheduled time, or an incremental export runs.
+func (p *PDFProcessor) claimBillingExport(ctx context.Context, trigger string, scheduledFor, from, to *time.Time) (*BillingExport, error) {
+	// Exports run no longer than their timeout; those running for longer
+	// died with their replica
+	err := p.postgres.Exec(ctx, `
+		UPDATE billing_exports SET status = $1, error = 'interrupted', finished_at = now()
+		WHERE status = $2 AND started_at < $3
+	`, BillingExportFailed, BillingExportRunning, time.Now().Add(-p.cfg.BillingExportTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fail interrupted billing exports: %w", err)
+	}
+
+	export := &BillingExport{
+		ID:          uuid.New().String(),
+		Trigger:     trigger,
+		Incremental: from == nil,
+		Status:      BillingExportRunning,
+		StartedAt:   time.Now(),
+	}
+	if from != nil {
+		export.From, export.To = *from, *to
+	} else {
+		var end sql.NullTime
+		err := p.postgres.QueryRow(ctx,
+			`SELECT max(range_end) FROM billing_exports WHERE incremental AND status = $1`,
+			BillingExportCompleted).Scan(&end)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the end of the last billing export: %w", err)
+		}
+		// The first export takes every record there is
+		export.From = time.Unix(0, 0).UTC()
+		if end.Valid {
+			export.From = end.Time
+		}
+		// Jobs are metered as they complete, so the same settling time
+		// leaves those being metered to the next export
+		export.To = export.StartedAt.Add(-analyticsExportSettle)
+		if !export.From.Before(export.To) {
+			export.To = export.From
+		}
+	}
+
+	var claimed string
+	err = p.postgres.QueryRow(ctx, `
+		INSERT INTO billing_exports (id, trigger, incremental, scheduled_for, status, range_start, range_end, started_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT DO NOTHING
+		RETURNING id
+	`, export.ID, export.Trigger, export.Incremental, scheduledFor, export.Status, export.From, export.To,
+		export.StartedAt).Scan(&claimed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to record billing export: %w", err)
+	}
+	return export, nil
+}
+
+// runBillingExports runs the exports of BILLING_EXPORT_SCHEDULE, until the
+// pool stops. Replicas claim each scheduled time, so an export happens
+// once.
+func (wp *WorkerPool) runBillingExports() {
+	defer wp.wg.Done()
+
+	p := wp.processor
+	schedule, err := parseCron(p.cfg.BillingExportSchedule)
+	if err != nil {
+		log.Printf("Billing exports disabled: %v", err)
+		return
+	}
+	if !p.billingExportEnabled() {
+		log.Printf("Billing exports disabled: %v", ErrBillingExportDisabled)
+		return
+	}
+
+	for {
+		next := schedule.next(time.Now().UTC())
+		if next.IsZero() {
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-wp.ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		export, err := p.claimBillingExport(wp.ctx, "schedule", &next, nil, nil)
+		if err != nil {
+			log.Printf("%v", err)
+			continue
+		}
+		if export != nil {
+			p.runBillingExport(wp.ctx, export)
+		}
+	}
+}
+
+// runBillingExport runs an export claimed, recording its outcome.
+func (p *PDFProcessor) runBillingExport(ctx context.Context, export *BillingExport) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.BillingExportTimeout)
+	defer cancel()
+
+	err := p.exportBilling(ctx, export)
+	finishedAt := time.Now()
+	export.FinishedAt = &finishedAt
+	if err != nil {
+		export.Status, export.Error = BillingExportFailed, err.Error()
+		log.Printf("Billing export %s failed after %d records: %v", export.ID, export.Records, err)
+	} else {
+		export.Status = BillingExportCompleted
+		log.Printf("Billing export %s exported %d records", export.ID, export.Records)
+	}
+
+	saveCtx, cancelSave := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancelSave()
+	err = p.postgres.Exec(saveCtx, `
+		UPDATE billing_exports
+		SET status = $2, records = $3, file = $4, delivered = $5, error = $6, finished_at = $7
+		WHERE id = $1
+	`, export.ID, export.Status, export.Records, export.File, export.Delivered, export.Error, export.FinishedAt)
+	if err != nil {
+		log.Printf("Failed to save billing export %s: %v", export.ID, err)
+	}
+}
+
+// exportBilling writes the export's records, in order of metering, to a
+// CSV file, then uploads and posts it.
+func (p *PDFProcessor) exportBilling(ctx context.Context, export *BillingExport) error {
+	file, err := os.CreateTemp(p.cfg.TempDir, "billing-*.csv")
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if err := p.writeBillingRecords(ctx, export, file); err != nil {
+		return err
+	}
+	if export.Records == 0 {
+		return nil
+	}
+
+	if p.objects != nil {
+		info, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		key := fmt.Sprintf("usage/dt=%s/%s.csv", export.To.UTC().Format("2006-01-02"), export.ID)
+		if err := p.objects.Put(ctx, p.cfg.BillingBucket, key, file, info.Size(), "text/csv"); err != nil {
+			return err
+		}
+		export.File = key
+	}
+
+	if p.cfg.BillingWebhookURL != "" {
+		if err := p.postBillingExport(ctx, export, file); err != nil {
+			return fmt.Errorf("failed to post billing export: %w", err)
+		}
+		export.Delivered = true
+	}
+	return nil
+}
+
+func (p *PDFProcessor) writeBillingRecords(ctx context.Context, export *BillingExport, out io.Writer) error {
+	rows, err := p.postgres.Query(ctx, `
+		SELECT job_id, tenant_id, tender_id, user_id, month, metered_at,
+			pages, ocr_pages, ocr_seconds, processing_seconds, storage_bytes
+		FROM metered_jobs
+		WHERE metered_at >= $1 AND metered_at < $2
+		ORDER BY metered_at, job_id
+	`, export.From, export.To)
+	if err != nil {
+		return fmt.Errorf("failed to select usage records to export: %w", err)
+	}
+	defer rows.Close()
+
+	w := csv.NewWriter(out)
+	if err := w.Write(billingColumns); err != nil {
+		return err
+	}
+	for rows.Next() {
+		var jobID, tenantID, tenderID, userID string
+		var month, meteredAt time.Time
+		var pages, ocrPages, storageBytes int64
+		var ocrSeconds, processingSeconds float64
+		if err := rows.Scan(&jobID, &tenantID, &tenderID, &userID, &month, &meteredAt,
+			&pages, &ocrPages, &ocrSeconds, &processingSeconds, &storageBytes); err != nil {
+			return err
+		}
+		err := w.Write([]string{
+			jobID, tenantID, tenderID, userID, month.Format("2006-01"), meteredAt.UTC().Format(time.RFC3339),
+			strconv.FormatInt(pages, 10), strconv.FormatInt(ocrPages, 10),
+			strconv.FormatFloat(ocrSeconds, 'f', 3, 64), strconv.FormatFloat(processingSeconds, 'f', 3, 64),
+			strconv.FormatInt(storageBytes, 10),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write usage record of job %s: %w", jobID, err)
+		}
+		export.Records++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// postBillingExport posts the export's file to the billing webhook, which
+// must answer 2xx.
+func (p *PDFProcessor) postBillingExport(ctx context.Context, export *BillingExport, file *os.File) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	body, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.BillingWebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/csv")
+	req.Header.Set("User-Agent", p.cfg.ServiceName)
+	req.Header.Set("X-Cotai-Event", billingExportEvent)
+	req.Header.Set("X-Cotai-Delivery", export.ID)
+	if p.cfg.BillingWebhookSecret != "" {
+		req.Header.Set(hookSignatureHeader, signHook(time.Now(), body, p.cfg.BillingWebhookSecret))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxHookResponse))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package processor
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"cotai-pdf-processor/internal/ocr"
+)
+
+// BoundingBox locates an entity on its page, in pixels of the OCR image with
+// the origin at the top-left corner.
+type BoundingBox struct {
+	Page   int `json:"page"`
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// wordBox ties an OCR word to its byte range in the OCR text.
+type wordBox struct {
+	Start int
+	End   int
+	Box   BoundingBox
+
+	// Confidence is the OCR engine's, from 0 to 100; zero for words of the
+	// PDF's own text
+	Confidence float64
+}
+
+type ocrResult struct {
+	Text        string
+	Confidence  float64
+	Words       []wordBox
+	Pages       []ocrPage
+	PageOffsets []int // byte offset of each page in Text
+
+	// LowConfidencePages are the numbers of the pages whose confidence is
+	// below OCR_LOW_CONFIDENCE
+	LowConfidencePages []int
+
+	// Corrections are the pages turned upright or deskewed before OCR
+	Corrections []PageCorrection
+
+	// SpellCorrections is how many misread words were corrected
+	SpellCorrections int
+
+	// dir holds the page images rendered from a PDF or preprocessed
+	dir string
+}
+
+// Cleanup removes the page images rendered for OCR.
+func (r *ocrResult) Cleanup() {
+	if r.dir != "" {
+		os.RemoveAll(r.dir)
+	}
+}
+
+// ocrPage is a page image OCR ran on, kept for the searchable PDF.
+type ocrPage struct {
+	number int
+	path   string
+	dpi    float64
+}
+
+// ocrRenderTimeout bounds rendering a PDF's pages for OCR.
+const ocrRenderTimeout = 10 * time.Minute
+
+// ocrReadingOrder rebuilds the OCR text column by column when the page has
+// several text columns, returning the boxes in the new reading order.
+func ocrReadingOrder(boxes []ocr.Word) (string, []ocr.Word, bool) {
+	words := make([]*layoutWord, 0, len(boxes))
+	index := make(map[*layoutWord]int, len(boxes))
+	for i, box := range boxes {
+		word := strings.TrimSpace(box.Text)
+		if word == "" {
+			continue
+		}
+		w := &layoutWord{
+			x0: float64(box.X), y0: float64(box.Y),
+			x1: float64(box.X + box.Width), y1: float64(box.Y + box.Height),
+			text: word,
+		}
+		index[w] = i
+		words = append(words, w)
+	}
+
+	layout := analyzeLayout(words)
+	if layout.columns < 2 {
+		return "", nil, false
+	}
+	text, _ := layout.text(1)
+	ordered := make([]ocr.Word, 0, len(words))
+	for _, w := range layout.words() {
+		ordered = append(ordered, boxes[index[w]])
+	}
+	return text, ordered, true
+}
+
+// wordBoxesFromOCR locates each recognized word in text. OCR providers
+// report words in reading order, so each is searched for after the previous
+// one.
+func wordBoxesFromOCR(text string, boxes []ocr.Word, page int) []wordBox {
+	words := make([]wordBox, 0, len(boxes))
+	cursor := 0
+	for _, box := range boxes {
+		word := strings.TrimSpace(box.Text)
+		if word == "" {
+			continue
+		}
+		idx := strings.Index(text[cursor:], word)
+		if idx < 0 {
+			continue
+		}
+
+		start := cursor + idx
+		cursor = start + len(word)
+		words = append(words, wordBox{
+			Start: start,
+			End:   cursor,
+			Box: BoundingBox{
+				Page:   page,
+				X:      box.X,
+				Y:      box.Y,
+				Width:  box.Width,
+				Height: box.Height,
+			},
+			Confidence: box.Confidence,
+		})
+	}
+	return words
+}
+
+// attachBoundingBoxes sets each entity's box to the union of the words it
+// overlaps on its first page. words must be ordered by Start.
+func attachBoundingBoxes(entities []ExtractedEntity, words []wordBox) {
+	if len(words) == 0 {
+		return
+	}
+
+	for i := range entities {
+		entity := &entities[i]
+		first := sort.Search(len(words), func(j int) bool { return words[j].End > entity.StartPos })
+
+		var union *BoundingBox
+		for _, word := range words[first:] {
+			if word.Start >= entity.EndPos {
+				break
+			}
+			if union == nil {
+				box := word.Box
+				union = &box
+				continue
+			}
+			if word.Box.Page != union.Page {
+				break
+			}
+			union.Width = max(union.X+union.Width, word.Box.X+word.Box.Width) - min(union.X, word.Box.X)
+			union.Height = max(union.Y+union.Height, word.Box.Y+word.Box.Height) - min(union.Y, word.Box.Y)
+			union.X = min(union.X, word.Box.X)
+			union.Y = min(union.Y, word.Box.Y)
+		}
+		entity.BoundingBox = union
+	}
+}
@@ -0,0 +1,119 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Jobs are cancelled by any replica: scheduled and waiting jobs are taken
+// off the schedule, a marker in Redis keeps queued and retrying jobs from
+// starting, and a message on a Redis channel reaches the replica running
+// the job, which cancels its context. Stages stop at their next context
+// check and the job ends "cancelled".
+
+const (
+	// cancelChannel carries the IDs of cancelled jobs to all replicas.
+	cancelChannel = "job-cancellations"
+
+	// cancelMarkerTTL matches the lifetime of the job status.
+	cancelMarkerTTL = 24 * time.Hour
+)
+
+var (
+	ErrJobCancelled = errors.New("job was cancelled")
+	ErrJobFinished  = errors.New("job has already finished")
+)
+
+func cancelMarkerKey(id string) string {
+	return fmt.Sprintf("job-cancel:%s", id)
+}
+
+// isFinished reports whether a job status is final.
+func isFinished(status string) bool {
+	return status == "completed" || status == "failed" || status == "cancelled"
+}
+
+// CancelJob cancels a job and the jobs spawned from it. Jobs not started
+// are cancelled at once; running ones stop shortly, on whichever replica
+// runs them. Finished jobs fail with ErrJobFinished.
+func (p *PDFProcessor) CancelJob(ctx context.Context, id string) (*ProcessingJob, error) {
+	job, err := p.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if isFinished(job.Status) {
+		return job, ErrJobFinished
+	}
+
+	if err := p.redis.Set(ctx, cancelMarkerKey(id), "1", cancelMarkerTTL); err != nil {
+		return nil, fmt.Errorf("failed to cancel job %s: %w", id, err)
+	}
+	if err := p.redis.Client().Publish(ctx, cancelChannel, id).Err(); err != nil {
+		return nil, fmt.Errorf("failed to cancel job %s: %w", id, err)
+	}
+
+	// Running jobs record their cancellation when they stop. Parents are
+	// marked before their children, which then leave them as they are
+	if job.Status == "scheduled" || job.Status == "waiting" {
+		if err := p.unscheduleJob(ctx, id); err != nil {
+			log.Printf("Failed to unschedule cancelled job %s: %v", id, err)
+		}
+	}
+	if job.Status != "processing" {
+		p.markCancelled(ctx, job)
+	}
+
+	for _, childID := range job.ChildIDs {
+		if _, err := p.CancelJob(ctx, childID); err != nil && !errors.Is(err, ErrJobFinished) && !errors.Is(err, ErrJobNotFound) {
+			log.Printf("Failed to cancel child job %s of %s: %v", childID, id, err)
+		}
+	}
+	return job, nil
+}
+
+// cancelRequested reports whether the job was cancelled before it started.
+func (p *PDFProcessor) cancelRequested(ctx context.Context, id string) bool {
+	n, err := p.redis.Client().Exists(ctx, cancelMarkerKey(id)).Result()
+	if err != nil {
+		log.Printf("Failed to check cancellation of job %s: %v", id, err)
+		return false
+	}
+	return n > 0
+}
+
+// markCancelled records the job as cancelled, on it and its parent.
+func (p *PDFProcessor) markCancelled(ctx context.Context, job *ProcessingJob) {
+	now := time.Now()
+	job.Status = "cancelled"
+	job.CompletedAt = &now
+	job.NextRetryAt = nil
+	job.Error = ""
+	job.ErrorCode = ""
+	if err := p.updateJobStatus(ctx, job); err != nil {
+		log.Printf("Failed to update cancelled job status: %v", err)
+	}
+	p.updateParentJob(ctx, job)
+}
+
+// watchCancellations calls cancel with the ID of each job cancelled, on
+// any replica, until ctx is done.
+func (p *PDFProcessor) watchCancellations(ctx context.Context, cancel func(id string)) {
+	sub := p.redis.Client().Subscribe(ctx, cancelChannel)
+	defer sub.Close()
+
+	messages := sub.Channel()
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			cancel(msg.Payload)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package processor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strconv"
+)
+
+// Jobs checkpoint each page OCR recognizes to a Redis hash of their own,
+// so a job whose replica crashed, or that was interrupted by a shutdown or
+// is retried, recognizes only the pages it had not done yet. The hash is
+// keyed by the document's digest and the job's options as well as the
+// job, and removed once the job has finished. The checkpoint travels in
+// the job's context, as the progress tracker does.
+
+// ocrCheckpoint keeps the recognized pages of one job. A nil checkpoint
+// keeps nothing.
+type ocrCheckpoint struct {
+	p     *PDFProcessor
+	jobID string
+	key   string
+}
+
+// checkpointPage is a recognized page as checkpointed.
+type checkpointPage struct {
+	Text        string         `json:"text"`
+	Words       []wordBox      `json:"words,omitempty"`
+	Confidence  float64        `json:"confidence"`
+	Corrections int            `json:"corrections,omitempty"`
+	Correction  PageCorrection `json:"correction"`
+}
+
+type checkpointKeyType struct{}
+
+func withCheckpoint(ctx context.Context, c *ocrCheckpoint) context.Context {
+	return context.WithValue(ctx, checkpointKeyType{}, c)
+}
+
+func checkpointFrom(ctx context.Context) *ocrCheckpoint {
+	c, _ := ctx.Value(checkpointKeyType{}).(*ocrCheckpoint)
+	return c
+}
+
+// ocrCheckpoint returns the checkpoint of the job's OCR of the document
+// with the digest, or nil when checkpoints are disabled.
+func (p *PDFProcessor) ocrCheckpoint(job *ProcessingJob, digest string) *ocrCheckpoint {
+	if p.cfg.OCRCheckpointTTL <= 0 || digest == "" {
+		return nil
+	}
+	options := job.Options
+	options.Password = ""
+	options.TimeoutSeconds = 0
+	options.OCRParallelism = 0
+	options.Reprocess = false
+	data, err := json.Marshal(options)
+	if err != nil {
+		return nil
+	}
+	fingerprint := sha256.Sum256(append([]byte(digest), data...))
+	return &ocrCheckpoint{
+		p:     p,
+		jobID: job.ID,
+		key:   fmt.Sprintf("ocr-checkpoint:%s:%s", job.ID, hex.EncodeToString(fingerprint[:])),
+	}
+}
+
+// load returns the checkpointed pages by page number. Failures are logged
+// and the pages recognized again.
+func (c *ocrCheckpoint) load(ctx context.Context) map[int]pageOCR {
+	if c == nil {
+		return nil
+	}
+	fields, err := c.p.redis.Client().HGetAll(ctx, c.key).Result()
+	if err != nil {
+		log.Printf("Failed to load OCR checkpoint of job %s: %v", c.jobID, err)
+		return nil
+	}
+
+	pages := make(map[int]pageOCR, len(fields))
+	for field, value := range fields {
+		number, err := strconv.Atoi(field)
+		if err != nil {
+			continue
+		}
+		var page checkpointPage
+		if err := json.Unmarshal([]byte(value), &page); err != nil {
+			log.Printf("Dropping undecodable OCR checkpoint of page %d of job %s: %v", number, c.jobID, err)
+			continue
+		}
+		pages[number] = pageOCR{
+			text:        page.Text,
+			words:       page.Words,
+			confidence:  page.Confidence,
+			correction:  page.Correction,
+			corrections: page.Corrections,
+		}
+	}
+	if len(pages) > 0 {
+		log.Printf("Job %s resumes OCR after %d checkpointed pages", c.jobID, len(pages))
+	}
+	return pages
+}
+
+// save checkpoints a recognized page. Failures are logged; the page is
+// then recognized again if the job is resumed.
+func (c *ocrCheckpoint) save(ctx context.Context, number int, page pageOCR) {
+	if c == nil {
+		return
+	}
+	data, err := json.Marshal(checkpointPage{
+		Text:        page.text,
+		Words:       page.words,
+		Confidence:  page.confidence,
+		Corrections: page.corrections,
+		Correction:  page.correction,
+	})
+	if err != nil {
+		return
+	}
+
+	pipe := c.p.redis.Client().TxPipeline()
+	pipe.HSet(ctx, c.key, strconv.Itoa(number), data)
+	pipe.Expire(ctx, c.key, c.p.cfg.OCRCheckpointTTL)
+	if _, err := pipe.Exec(ctx); err != nil && ctx.Err() == nil {
+		log.Printf("Failed to checkpoint OCR of page %d of job %s: %v", number, c.jobID, err)
+	}
+}
+
+// clear removes the checkpoint of a finished job.
+func (c *ocrCheckpoint) clear(ctx context.Context) {
+	if c == nil {
+		return
+	}
+	if err := c.p.redis.Client().Del(ctx, c.key).Err(); err != nil {
+		log.Printf("Failed to remove OCR checkpoint of job %s: %v", c.jobID, err)
+	}
+}
+
+// restorePage redoes the cheap part of recognizePage for a checkpointed
+// page: its image is turned upright as it was and preprocessed again, for
+// the outputs drawn on the page images.
+func restorePage(result *ocrResult, i int, correction PageCorrection, options ProcessingOptions) {
+	page := &result.Pages[i]
+	if correction.Rotation != 0 {
+		src, err := decodeImageFile(page.path)
+		if err != nil {
+			log.Printf("Failed to rotate checkpointed page %d: %v", page.number, err)
+			return
+		}
+		upright := filepath.Join(result.dir, fmt.Sprintf("upright-%d.png", page.number))
+		if err := writePNG(upright, rotateQuarter(src, correction.Rotation)); err != nil {
+			log.Printf("Failed to rotate checkpointed page %d: %v", page.number, err)
+			return
+		}
+		page.path = upright
+	}
+
+	if options.PreprocessImages {
+		cleaned, _, _, err := preprocessPage(page.path, result.dir, page.number, page.dpi)
+		if err != nil {
+			log.Printf("Failed to preprocess checkpointed page %d: %v", page.number, err)
+			return
+		}
+		page.path = cleaned
+	}
+}
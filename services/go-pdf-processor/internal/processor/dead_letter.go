@@ -0,0 +1,135 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Jobs that failed for good, permanently or out of attempts, are kept in
+// the dead-letter store until replayed or deleted: a Redis hash of the
+// jobs by ID, and a sorted set of the IDs by failure time for listing.
+// Like the job status, entries never hold the document password, so
+// password-protected documents are submitted again rather than replayed.
+
+const (
+	deadLetterJobs  = "dead-letter:jobs"
+	deadLetterIndex = "dead-letter:index"
+)
+
+var ErrDeadLetterNotFound = errors.New("dead-letter job not found")
+
+// deadLetter adds a failed job to the dead-letter store.
+func (p *PDFProcessor) deadLetter(ctx context.Context, job *ProcessingJob) error {
+	persisted := *job
+	persisted.Options.Password = ""
+	data, err := json.Marshal(&persisted)
+	if err != nil {
+		return err
+	}
+
+	failedAt := time.Now()
+	if job.CompletedAt != nil {
+		failedAt = *job.CompletedAt
+	}
+	_, err = p.redis.Client().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, deadLetterJobs, job.ID, data)
+		pipe.ZAdd(ctx, deadLetterIndex, redis.Z{Score: float64(failedAt.UnixMilli()), Member: job.ID})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+// ListDeadLetters returns dead-letter jobs, latest failures first, and
+// their total count.
+func (p *PDFProcessor) ListDeadLetters(ctx context.Context, offset, limit int) ([]ProcessingJob, int64, error) {
+	client := p.redis.Client()
+	total, err := client.ZCard(ctx, deadLetterIndex).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count dead-letter jobs: %w", err)
+	}
+	ids, err := client.ZRevRange(ctx, deadLetterIndex, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list dead-letter jobs: %w", err)
+	}
+
+	jobs := []ProcessingJob{}
+	if len(ids) == 0 {
+		return jobs, total, nil
+	}
+	values, err := client.HMGet(ctx, deadLetterJobs, ids...).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load dead-letter jobs: %w", err)
+	}
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			// Replayed or deleted since listed
+			continue
+		}
+		var job ProcessingJob
+		if err := json.Unmarshal([]byte(data), &job); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode dead-letter job %s: %w", ids[i], err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, total, nil
+}
+
+func (p *PDFProcessor) GetDeadLetter(ctx context.Context, id string) (*ProcessingJob, error) {
+	data, err := p.redis.Client().HGet(ctx, deadLetterJobs, id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dead-letter job %s: %w", id, err)
+	}
+
+	var job ProcessingJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("failed to decode dead-letter job %s: %w", id, err)
+	}
+	return &job, nil
+}
+
+func (p *PDFProcessor) DeleteDeadLetter(ctx context.Context, id string) error {
+	var deleted *redis.IntCmd
+	_, err := p.redis.Client().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		deleted = pipe.HDel(ctx, deadLetterJobs, id)
+		pipe.ZRem(ctx, deadLetterIndex, id)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete dead-letter job %s: %w", id, err)
+	}
+	if deleted.Val() == 0 {
+		return ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+// DeadLetterReplay returns a dead-letter job reset to be queued again, with
+// its ID and attempts counted afresh. Callers delete it from the store once
+// queued.
+func (p *PDFProcessor) DeadLetterReplay(ctx context.Context, id string) (*ProcessingJob, error) {
+	job, err := p.GetDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	job.Status = "queued"
+	job.Attempts = 0
+	job.StartedAt = nil
+	job.CompletedAt = nil
+	job.NextRetryAt = nil
+	job.Result = nil
+	job.Error = ""
+	job.ErrorCode = ""
+	return job, nil
+}
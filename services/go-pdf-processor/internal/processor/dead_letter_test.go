@@ -0,0 +1,99 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"cotai-pdf-processor/internal/storage"
+)
+
+// testRedis connects to the Redis at TEST_REDIS_URL, skipping the test
+// without one, and deletes the keys when the test ends.
+func testRedis(t *testing.T, keys ...string) *storage.RedisClient {
+	t.Helper()
+	url := os.Getenv("TEST_REDIS_URL")
+	if url == "" {
+		t.Skip("TEST_REDIS_URL not set")
+	}
+	client := storage.NewRedisClient(url)
+	ctx := context.Background()
+	if err := client.Client().Ping(ctx).Err(); err != nil {
+		t.Fatalf("cannot reach the test Redis: %v", err)
+	}
+	client.Del(ctx, keys...)
+	t.Cleanup(func() {
+		client.Del(ctx, keys...)
+		client.Close()
+	})
+	return client
+}
+
+func TestDeadLetter(t *testing.T) {
+	p := &PDFProcessor{redis: testRedis(t, deadLetterJobs, deadLetterIndex)}
+	ctx := context.Background()
+
+	failedAt := time.Now().Truncate(time.Millisecond)
+	for i, id := range []string{"j1", "j2", "j3"} {
+		completedAt := failedAt.Add(time.Duration(i) * time.Minute)
+		job := &ProcessingJob{
+			ID:          id,
+			Status:      "failed",
+			Attempts:    3,
+			Error:       "download failed",
+			ErrorCode:   ErrCodeFileUnavailable,
+			CompletedAt: &completedAt,
+			Options:     ProcessingOptions{Password: "secret"},
+		}
+		if err := p.deadLetter(ctx, job); err != nil {
+			t.Fatalf("deadLetter(%s) error = %v", id, err)
+		}
+		if job.Options.Password != "secret" {
+			t.Errorf("deadLetter() cleared the password of the job it was given")
+		}
+	}
+
+	// Latest failures first
+	jobs, total, err := p.ListDeadLetters(ctx, 0, 2)
+	if err != nil || total != 3 || len(jobs) != 2 || jobs[0].ID != "j3" || jobs[1].ID != "j2" {
+		t.Fatalf("ListDeadLetters(0, 2) = %v, %d, %v, want j3, j2 of 3", jobs, total, err)
+	}
+	if jobs, _, err := p.ListDeadLetters(ctx, 2, 2); err != nil || len(jobs) != 1 || jobs[0].ID != "j1" {
+		t.Fatalf("ListDeadLetters(2, 2) = %v, %v, want j1", jobs, err)
+	}
+
+	job, err := p.GetDeadLetter(ctx, "j1")
+	if err != nil {
+		t.Fatalf("GetDeadLetter() error = %v", err)
+	}
+	if job.Options.Password != "" {
+		t.Errorf("dead-letter job kept its password")
+	}
+	if job.ErrorCode != ErrCodeFileUnavailable || job.Attempts != 3 {
+		t.Errorf("GetDeadLetter() = %+v", job)
+	}
+
+	replay, err := p.DeadLetterReplay(ctx, "j1")
+	if err != nil {
+		t.Fatalf("DeadLetterReplay() error = %v", err)
+	}
+	if replay.ID != "j1" || replay.Status != "queued" || replay.Attempts != 0 || replay.CompletedAt != nil ||
+		replay.Error != "" || replay.ErrorCode != "" {
+		t.Errorf("DeadLetterReplay() = %+v, want the job reset", replay)
+	}
+
+	if err := p.DeleteDeadLetter(ctx, "j1"); err != nil {
+		t.Fatalf("DeleteDeadLetter() error = %v", err)
+	}
+	if err := p.DeleteDeadLetter(ctx, "j1"); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Errorf("DeleteDeadLetter() of a deleted job error = %v, want %v", err, ErrDeadLetterNotFound)
+	}
+	if _, err := p.GetDeadLetter(ctx, "j1"); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Errorf("GetDeadLetter() of a deleted job error = %v, want %v", err, ErrDeadLetterNotFound)
+	}
+	if _, total, _ := p.ListDeadLetters(ctx, 0, 10); total != 2 {
+		t.Errorf("ListDeadLetters() counts %d jobs after a delete, want 2", total)
+	}
+}
@@ -0,0 +1,205 @@
+package processor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"cotai-pdf-processor/internal/risk"
+	"cotai-pdf-processor/internal/storage"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Results are cached by the digest of the document and a fingerprint of
+// what else shapes them, so a document submitted again, by another tender
+// or another source, is not processed again. The fingerprint covers the
+// risk rules, entity patterns and profiles in force, so results are not
+// reused once those change. The keys of a document's results are listed in
+// a set under its digest, for invalidation.
+
+// ErrInvalidContentHash is returned for digests that are not hex SHA-256.
+var ErrInvalidContentHash = errors.New("content hash must be a hex-encoded SHA-256 digest")
+
+func resultCacheIndex(digest string) string {
+	return "result-cache:" + digest
+}
+
+// cachedResultEntry is the result of a job as kept for reuse.
+type cachedResultEntry struct {
+	JobID  string            `json:"job_id"`
+	Result *ProcessingResult `json:"result"`
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// resultCacheKey identifies the results of a document processed for the
+// job: the options, except those that leave the result alone, and the
+// tenant's risk rules and entity patterns and the job's risk and interest
+// profiles as they stand. It is empty when results are not cached.
+func (p *PDFProcessor) resultCacheKey(ctx context.Context, job *ProcessingJob, digest string) string {
+	if p.cfg.ResultCacheTTL <= 0 || digest == "" {
+		return ""
+	}
+
+	options := job.Options
+	options.Password = ""
+	options.TimeoutSeconds = 0
+	options.OCRParallelism = 0
+	options.Reprocess = false
+
+	var rules []risk.Rule
+	if p.riskRules != nil {
+		rules = p.riskRules.Rules(job.TenantID)
+	}
+	var patterns []EntityPattern
+	if job.TenantID != "" {
+		var err error
+		if patterns, err = p.ListEntityPatterns(ctx, job.TenantID); err != nil {
+			log.Printf("Not caching the result of job %s: %v", job.ID, err)
+			return ""
+		}
+	}
+
+	data, err := json.Marshal(struct {
+		Options         ProcessingOptions `json:"options"`
+		TenantID        string            `json:"tenant_id"`
+		Rules           []risk.Rule       `json:"rules"`
+		Patterns        []EntityPattern   `json:"patterns"`
+		RiskProfile     *RiskProfile      `json:"risk_profile"`
+		InterestProfile *InterestProfile  `json:"interest_profile"`
+	}{options, job.TenantID, rules, patterns, p.jobRiskProfile(ctx, job), p.jobInterestProfile(ctx, job)})
+	if err != nil {
+		return ""
+	}
+	fingerprint := sha256.Sum256(data)
+	return fmt.Sprintf("%s:%s", resultCacheIndex(digest), hex.EncodeToString(fingerprint[:]))
+}
+
+// cachedResult returns a copy of the result cached under key by an earlier
+// job, or nil when there is none. Cache failures are logged and the
+// document processed.
+func (p *PDFProcessor) cachedResult(ctx context.Context, job *ProcessingJob, key string) *ProcessingResult {
+	if key == "" || job.Options.Reprocess {
+		return nil
+	}
+
+	data, err := p.redis.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		log.Printf("Failed to look up a cached result for job %s: %v", job.ID, err)
+		return nil
+	}
+	var entry cachedResultEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Result == nil || entry.JobID == job.ID {
+		return nil
+	}
+
+	result := entry.Result
+	result.Deduplicated = true
+	result.DeduplicatedFrom = entry.JobID
+	return result
+}
+
+// cacheResult keeps the job's result under key for reuse by later jobs of
+// the same document and options; reprocessed documents replace the cached
+// result.
+func (p *PDFProcessor) cacheResult(ctx context.Context, job *ProcessingJob, key string, result *ProcessingResult) {
+	if key == "" {
+		return
+	}
+
+	data, err := json.Marshal(cachedResultEntry{JobID: job.ID, Result: result})
+	if err == nil {
+		index := resultCacheIndex(result.ContentSHA256)
+		_, err = p.redis.Client().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, p.cfg.ResultCacheTTL)
+			pipe.SAdd(ctx, index, key)
+			pipe.Expire(ctx, index, p.cfg.ResultCacheTTL)
+			return nil
+		})
+	}
+	if err != nil {
+		log.Printf("Failed to cache the result of job %s: %v", job.ID, err)
+	}
+}
+
+// InvalidateResults removes the cached results of a document, whatever
+// the options, returning how many there were.
+func (p *PDFProcessor) InvalidateResults(ctx context.Context, digest string) (int, error) {
+	digest = strings.ToLower(digest)
+	if decoded, err := hex.DecodeString(digest); err != nil || len(decoded) != sha256.Size {
+		return 0, ErrInvalidContentHash
+	}
+
+	client := p.redis.Client()
+	index := resultCacheIndex(digest)
+	keys, err := client.SMembers(ctx, index).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list cached results: %w", err)
+	}
+	removed, err := client.Del(ctx, append(keys, index)...).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to invalidate cached results: %w", err)
+	}
+	if removed > 0 && len(keys) > 0 {
+		removed-- // the index
+	}
+	return int(removed), nil
+}
+
+// InvalidateAllResults empties the result cache, e.g. after the service
+// was upgraded, returning how many results it held.
+func (p *PDFProcessor) InvalidateAllResults(ctx context.Context) (int, error) {
+	client := p.redis.Client()
+	removed := 0
+	iter := client.Scan(ctx, 0, resultCacheIndex("*"), 500).Iterator()
+	var batch []string
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := client.Unlink(ctx, batch...).Err(); err != nil {
+			return fmt.Errorf("failed to invalidate cached results: %w", err)
+		}
+		batch = batch[:0]
+		return nil
+	}
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if strings.Count(key, ":") == 2 {
+			removed++
+		}
+		batch = append(batch, key)
+		if len(batch) == 500 {
+			if err := flush(); err != nil {
+				return removed, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return removed, fmt.Errorf("failed to list cached results: %w", err)
+	}
+	return removed, flush()
+}
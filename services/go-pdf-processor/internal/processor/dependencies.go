@@ -0,0 +1,174 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// A job may depend on other jobs, e.g. an anexo on its edital. A job
+// submitted with depends_on is held "waiting", off the queue, until every
+// job it depends on has completed, and fails once any of them fails or is
+// cancelled. Held jobs are kept with the scheduled ones (see schedule.go)
+// and released to the schedule, to be queued at once or at their run_at.
+// The jobs waiting on a job are listed in a set of its own, checked as it
+// finishes.
+
+// maxDependencies bounds the jobs a job may depend on.
+const maxDependencies = 50
+
+// dependentsTTL is how long the jobs waiting on a job are listed for; a
+// job still waiting then has lost its dependency's status anyway.
+const dependentsTTL = 7 * 24 * time.Hour
+
+// ErrInvalidDependencies is returned for depends_on lists naming unknown
+// jobs, or too many.
+var ErrInvalidDependencies = errors.New("invalid job dependencies")
+
+func jobDependentsKey(id string) string {
+	return fmt.Sprintf("job-dependents:%s", id)
+}
+
+// ValidateDependencies checks that the jobs a job is to depend on exist.
+func (p *PDFProcessor) ValidateDependencies(ctx context.Context, ids []string) error {
+	if len(ids) > maxDependencies {
+		return fmt.Errorf("%w: a job may depend on up to %d jobs", ErrInvalidDependencies, maxDependencies)
+	}
+	for _, id := range ids {
+		if _, err := p.GetJob(ctx, id); errors.Is(err, ErrJobNotFound) {
+			return fmt.Errorf("%w: job %s not found", ErrInvalidDependencies, id)
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// holdJob keeps a job with dependencies off the queue until they have
+// completed, releasing it at once if they already have.
+func (p *PDFProcessor) holdJob(ctx context.Context, job *ProcessingJob) error {
+	job.Status = "waiting"
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.redis.Client().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, scheduledJobs, job.ID, body)
+		for _, dep := range job.DependsOn {
+			pipe.SAdd(ctx, jobDependentsKey(dep), job.ID)
+			pipe.Expire(ctx, jobDependentsKey(dep), dependentsTTL)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to hold job %s: %w", job.ID, err)
+	}
+	if err := p.updateJobStatus(ctx, job); err != nil {
+		return fmt.Errorf("failed to update status of job %s: %w", job.ID, err)
+	}
+
+	// A dependency may have finished before the job was listed. The job is
+	// held either way, and checked again as its other dependencies finish
+	if err := p.checkDependencies(ctx, job.ID); err != nil {
+		log.Printf("Failed to check the dependencies of job %s: %v", job.ID, err)
+	}
+	return nil
+}
+
+// releaseDependents checks the jobs waiting on a job that just finished.
+func (p *PDFProcessor) releaseDependents(ctx context.Context, job *ProcessingJob) {
+	client := p.redis.Client()
+	key := jobDependentsKey(job.ID)
+	ids, err := client.SMembers(ctx, key).Result()
+	if err != nil {
+		log.Printf("Failed to list the jobs waiting on job %s: %v", job.ID, err)
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	failed := false
+	for _, id := range ids {
+		if err := p.checkDependencies(ctx, id); err != nil {
+			log.Printf("Failed to check the dependencies of job %s: %v", id, err)
+			failed = true
+		}
+	}
+	if !failed {
+		client.Del(ctx, key)
+	}
+}
+
+// checkDependencies releases a waiting job whose dependencies have all
+// completed, or fails it when one of them failed or was cancelled.
+func (p *PDFProcessor) checkDependencies(ctx context.Context, id string) error {
+	client := p.redis.Client()
+	body, err := client.HGet(ctx, scheduledJobs, id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// Released already, or cancelled
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var job ProcessingJob
+	if err := json.Unmarshal(body, &job); err != nil {
+		return fmt.Errorf("undecodable waiting job %s: %w", id, err)
+	}
+	if job.Status != "waiting" {
+		return nil
+	}
+
+	for _, dep := range job.DependsOn {
+		upstream, err := p.loadJob(ctx, dep)
+		switch {
+		case errors.Is(err, ErrJobNotFound):
+			return p.failWaiting(ctx, &job, fmt.Errorf("dependency %s not found", dep))
+		case err != nil:
+			return err
+		case upstream.Status == "completed":
+			continue
+		case isFinished(upstream.Status):
+			return p.failWaiting(ctx, &job, fmt.Errorf("dependency %s %s", dep, upstream.Status))
+		default:
+			return nil
+		}
+	}
+
+	log.Printf("Dependencies of job %s completed, releasing it", id)
+	if scheduledFor(&job) {
+		return p.scheduleJob(ctx, &job)
+	}
+	err = client.ZAdd(ctx, jobSchedule, redis.Z{Score: float64(time.Now().UnixMilli()), Member: id}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to release job %s: %w", id, err)
+	}
+	return nil
+}
+
+// failWaiting fails a waiting job whose dependency did not complete,
+// unless another replica did already.
+func (p *PDFProcessor) failWaiting(ctx context.Context, job *ProcessingJob, reason error) error {
+	removed, err := p.redis.Client().HDel(ctx, scheduledJobs, job.ID).Result()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	job.Status = "failed"
+	job.Error = reason.Error()
+	job.ErrorCode = ErrCodeDependencyFailed
+	job.CompletedAt = &now
+	log.Printf("Job %s failed: %v", job.ID, reason)
+	return p.updateJobStatus(ctx, job)
+}
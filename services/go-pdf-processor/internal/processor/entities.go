@@ -0,0 +1,154 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"sort"
+
+	"cotai-pdf-processor/internal/enrichment"
+)
+
+// invalidEntityConfidence is reported for CNPJs and CPFs whose check digits
+// do not match; they are kept since typos are common in tender documents.
+const invalidEntityConfidence = 0.4
+
+type entityPattern struct {
+	entityType string
+	re         *regexp.Regexp
+	confidence float64
+	validate   func(value string) bool
+}
+
+// currencyPattern matches amounts in reais, e.g. R$ 1.234,56.
+var currencyPattern = regexp.MustCompile(`R\$\s*(?:\d{1,3}(?:\.\d{3})+|\d+)(?:,\d{2})?`)
+
+// entityPatterns are applied in order; each yields every non-overlapping match.
+var entityPatterns = []entityPattern{
+	{"CNPJ", regexp.MustCompile(`\b\d{2}\.\d{3}\.\d{3}/\d{4}-\d{2}\b`), 0.95, validCNPJ},
+	{"CPF", regexp.MustCompile(`\b\d{3}\.\d{3}\.\d{3}-\d{2}\b`), 0.95, validCPF},
+	{"EMAIL", regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`), 0.9, nil},
+	{"PHONE", regexp.MustCompile(`\(\d{2}\)\s*\d{4,5}-\d{4}\b`), 0.85, nil},
+	{"CURRENCY", currencyPattern, 0.9, nil},
+	{"DATE", regexp.MustCompile(`\b\d{1,2}/\d{1,2}/\d{4}\b`), 0.85, nil},
+	{"DATE", regexp.MustCompile(`(?i)\b\d{1,2}º?\s+de\s+(?:janeiro|fevereiro|março|marco|abril|maio|junho|julho|agosto|setembro|outubro|novembro|dezembro)\s+de\s+\d{4}\b`), 0.85, nil},
+}
+
+// pageForOffset returns the 1-based page containing the byte offset pos.
+func pageForOffset(pageOffsets []int, pos int) int {
+	if len(pageOffsets) == 0 {
+		return 0
+	}
+	return sort.Search(len(pageOffsets), func(i int) bool { return pageOffsets[i] > pos })
+}
+
+// extractNamedEntities runs the job's NER provider over text. Failures are
+// logged and yield no entities, leaving the pattern-based ones.
+func (p *PDFProcessor) extractNamedEntities(ctx context.Context, options ProcessingOptions, text string, pageOffsets []int) []ExtractedEntity {
+	ctx, span := p.tracer.Start(ctx, "extract_named_entities")
+	defer span.End()
+
+	provider, err := p.recognizers.Get(options.NERProvider)
+	if err != nil {
+		log.Printf("Named entity extraction skipped: %v", err)
+		return nil
+	}
+
+	found, err := provider.Extract(ctx, text, options.Languages)
+	if err != nil {
+		log.Printf("Named entity extraction with %s failed: %v", options.NERProvider, err)
+		return nil
+	}
+
+	entities := make([]ExtractedEntity, 0, len(found))
+	for _, e := range found {
+		entities = append(entities, ExtractedEntity{
+			Type:       e.Type,
+			Value:      e.Text,
+			Confidence: e.Score,
+			StartPos:   e.Start,
+			EndPos:     e.End,
+			Page:       pageForOffset(pageOffsets, e.Start),
+		})
+	}
+	return entities
+}
+
+// enrichEntities attaches registry data to valid CNPJs, looking each distinct
+// CNPJ up once. Lookup failures leave the entity as extracted.
+func (p *PDFProcessor) enrichEntities(ctx context.Context, entities []ExtractedEntity) {
+	ctx, span := p.tracer.Start(ctx, "enrich_entities")
+	defer span.End()
+
+	companies := make(map[string]*enrichment.Company)
+	for i := range entities {
+		entity := &entities[i]
+		if entity.Type != "CNPJ" || !validCNPJ(entity.Value) {
+			continue
+		}
+
+		company, seen := companies[entity.Value]
+		if !seen {
+			var err error
+			company, err = p.companies.Lookup(ctx, entity.Value)
+			if errors.Is(err, enrichment.ErrLookupDisabled) {
+				return
+			}
+			if err != nil && !errors.Is(err, enrichment.ErrCompanyNotFound) {
+				log.Printf("Failed to enrich CNPJ %s: %v", entity.Value, err)
+			}
+			companies[entity.Value] = company
+		}
+		entity.Company = company
+	}
+}
+
+func validCNPJ(value string) bool {
+	d := digits(value)
+	if len(d) != 14 || allSame(d) {
+		return false
+	}
+	weights := []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}
+	return checkDigit(d[:12], weights[1:]) == d[12] && checkDigit(d[:13], weights) == d[13]
+}
+
+func validCPF(value string) bool {
+	d := digits(value)
+	if len(d) != 11 || allSame(d) {
+		return false
+	}
+	weights := []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2}
+	return checkDigit(d[:9], weights[1:]) == d[9] && checkDigit(d[:10], weights) == d[10]
+}
+
+// checkDigit computes the modulo-11 check digit shared by CPF and CNPJ.
+func checkDigit(d []int, weights []int) int {
+	sum := 0
+	for i, digit := range d {
+		sum += digit * weights[i]
+	}
+	if rest := sum % 11; rest >= 2 {
+		return 11 - rest
+	}
+	return 0
+}
+
+func digits(value string) []int {
+	d := make([]int, 0, len(value))
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			d = append(d, int(r-'0'))
+		}
+	}
+	return d
+}
+
+func allSame(d []int) bool {
+	for _, digit := range d[1:] {
+		if digit != d[0] {
+			return false
+		}
+	}
+	return true
+}
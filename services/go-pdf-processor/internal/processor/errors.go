@@ -0,0 +1,69 @@
+package processor
+
+import (
+	"errors"
+	"net/http"
+
+	"cotai-pdf-processor/internal/download"
+	"cotai-pdf-processor/internal/netguard"
+)
+
+// Error codes reported in ProcessingJob.ErrorCode so clients can react to
+// specific failures.
+const (
+	ErrCodeEncryptedPDF    = "encrypted_pdf"
+	ErrCodeFileTooLarge    = "file_too_large"
+	ErrCodeUnsupportedType = "unsupported_type"
+	ErrCodeFileUnavailable = "file_unavailable"
+	ErrCodeTimeout         = "timeout"
+
+	// ErrCodeDependencyFailed fails jobs depending on a job that did not
+	// complete
+	ErrCodeDependencyFailed = "dependency_failed"
+
+	// ErrCodeResultVetoed fails jobs whose result the post-processing hook
+	// vetoed
+	ErrCodeResultVetoed = "result_vetoed"
+)
+
+// ProcessingError is a processing failure with a machine-readable code.
+type ProcessingError struct {
+	Code string
+	Err  error
+}
+
+func (e *ProcessingError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ProcessingError) Unwrap() error {
+	return e.Err
+}
+
+// errorCode returns the code of the first ProcessingError in err's chain.
+func errorCode(err error) string {
+	var procErr *ProcessingError
+	if errors.As(err, &procErr) {
+		return procErr.Code
+	}
+	if errors.Is(err, download.ErrFileTooLarge) {
+		return ErrCodeFileTooLarge
+	}
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return ErrCodeTimeout
+	}
+	if errors.Is(err, download.ErrUnsupportedScheme) || errors.Is(err, download.ErrForbiddenURL) ||
+		errors.Is(err, netguard.ErrForbiddenAddress) {
+		return ErrCodeFileUnavailable
+	}
+
+	// Client errors other than timeouts and rate limiting will not go
+	// away by asking again
+	var statusErr *download.StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode >= 400 && statusErr.StatusCode < 500 &&
+		statusErr.StatusCode != http.StatusRequestTimeout && statusErr.StatusCode != http.StatusTooManyRequests {
+		return ErrCodeFileUnavailable
+	}
+	return ""
+}
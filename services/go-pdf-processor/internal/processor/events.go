@@ -0,0 +1,118 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// JobEvents is told about jobs that finished, completed, failed or
+// cancelled, so other services can act on their results without polling
+// for them.
+type JobEvents interface {
+	JobFinished(ctx context.Context, job *ProcessingJob) error
+}
+
+// notifyFinished tells the JobEvents about a job whose final status was
+// just stored. Failures are logged; the job status stays the reference.
+func (p *PDFProcessor) notifyFinished(ctx context.Context, job *ProcessingJob) {
+	if p.events == nil || !isFinished(job.Status) {
+		return
+	}
+	if err := p.events.JobFinished(ctx, job); err != nil {
+		log.Printf("Failed to publish the %s status of job %s: %v", job.Status, job.ID, err)
+	}
+}
+
+// RetryEvents may be implemented by JobEvents that also want to be told
+// about failed attempts scheduled for retry.
+type RetryEvents interface {
+	JobRetrying(ctx context.Context, job *ProcessingJob) error
+}
+
+// notifyRetrying tells the JobEvents about a job whose next attempt was
+// just scheduled.
+func (p *PDFProcessor) notifyRetrying(ctx context.Context, job *ProcessingJob) {
+	events, ok := p.events.(RetryEvents)
+	if !ok {
+		return
+	}
+	if err := events.JobRetrying(ctx, job); err != nil {
+		log.Printf("Failed to publish the retry of job %s: %v", job.ID, err)
+	}
+}
+
+// MultiJobEvents tells each of its JobEvents about finished jobs, and
+// those implementing RetryEvents about retries.
+type MultiJobEvents []JobEvents
+
+func (m MultiJobEvents) JobFinished(ctx context.Context, job *ProcessingJob) error {
+	var errs []error
+	for _, events := range m {
+		if err := events.JobFinished(ctx, job); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m MultiJobEvents) JobRetrying(ctx context.Context, job *ProcessingJob) error {
+	var errs []error
+	for _, events := range m {
+		if retries, ok := events.(RetryEvents); ok {
+			if err := retries.JobRetrying(ctx, job); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// JobSummary is what other services are told about a finished or retrying
+// job. It summarizes the result; the full result is read from the jobs API.
+type JobSummary struct {
+	JobID       string     `json:"job_id"`
+	ParentID    string     `json:"parent_id,omitempty"`
+	TenderID    string     `json:"tender_id"`
+	TenantID    string     `json:"tenant_id,omitempty"`
+	UserID      string     `json:"user_id,omitempty"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	ErrorCode   string     `json:"error_code,omitempty"`
+	Attempts    int        `json:"attempts,omitempty"`
+	NextRetryAt *time.Time `json:"next_retry_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	PageCount      int     `json:"page_count,omitempty"`
+	DocumentType   string  `json:"document_type,omitempty"`
+	OverallRisk    string  `json:"overall_risk,omitempty"`
+	RiskScore      float64 `json:"risk_score,omitempty"`
+	RelevanceScore float64 `json:"relevance_score,omitempty"`
+}
+
+func SummarizeJob(job *ProcessingJob) JobSummary {
+	summary := JobSummary{
+		JobID:       job.ID,
+		ParentID:    job.ParentID,
+		TenderID:    job.TenderID,
+		TenantID:    job.TenantID,
+		UserID:      job.UserID,
+		Status:      job.Status,
+		Error:       job.Error,
+		ErrorCode:   job.ErrorCode,
+		Attempts:    job.Attempts,
+		NextRetryAt: job.NextRetryAt,
+		CompletedAt: job.CompletedAt,
+	}
+	if result := job.Result; result != nil {
+		summary.PageCount = result.PageCount
+		summary.OverallRisk = result.RiskAnalysis.OverallRisk
+		summary.RiskScore = result.RiskAnalysis.RiskScore
+		summary.RelevanceScore = result.RelevanceScore
+		if result.Classification != nil {
+			summary.DocumentType = result.Classification.Type
+		}
+	}
+	return summary
+}
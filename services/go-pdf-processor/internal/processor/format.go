@@ -0,0 +1,191 @@
+package processor
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// documentFormat identifies which extraction path a file takes.
+type documentFormat string
+
+const (
+	formatPDF     documentFormat = "pdf"
+	formatDOCX    documentFormat = "docx"
+	formatODT     documentFormat = "odt"
+	formatXLSX    documentFormat = "xlsx"
+	formatCSV     documentFormat = "csv"
+	formatImage   documentFormat = "image"
+	formatArchive documentFormat = "zip"
+	formatUnknown documentFormat = "unknown"
+)
+
+const (
+	docxMimeType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	odtMimeType  = "application/vnd.oasis.opendocument.text"
+	xlsxMimeType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// documentContent is what an extractor produces for a document.
+type documentContent struct {
+	Text               string
+	PageCount          int
+	PageOffsets        []int     // byte offset of each page in Text, when known
+	WordBoxes          []wordBox // OCR word positions, ordered by offset in Text
+	Tables             []ExtractedTable
+	Blocks             []TextBlock // layout blocks, positioned in Text
+	OCRPages           []ocrPage   // page images OCR ran on
+	OCRApplied         bool
+	OCRConfidence      float64
+	LowConfidencePages []int
+	SpellCorrections   int
+	Metadata           map[string]interface{}
+}
+
+// detectFormat inspects the file's leading bytes. DOCX and ODT files are
+// ZIP containers too, so ZIPs are told apart by their well-known members.
+func detectFormat(filePath string) documentFormat {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return formatUnknown
+	}
+	defer f.Close()
+
+	header := make([]byte, sniffLen)
+	n, _ := io.ReadFull(f, header)
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, []byte("%PDF-")):
+		return formatPDF
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")):
+		return detectZipFormat(filePath)
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")),
+		bytes.HasPrefix(header, []byte("\xff\xd8\xff")),
+		bytes.HasPrefix(header, []byte("II*\x00")),
+		bytes.HasPrefix(header, []byte("MM\x00*")):
+		return formatImage
+	case bytes.Contains(header, []byte("%PDF-")):
+		// Some producers put junk before the header, which readers tolerate
+		return formatPDF
+	default:
+		return formatUnknown
+	}
+}
+
+func detectZipFormat(filePath string) documentFormat {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return formatUnknown
+	}
+	defer archive.Close()
+
+	for _, entry := range archive.File {
+		switch entry.Name {
+		case "word/document.xml":
+			return formatDOCX
+		case "xl/workbook.xml":
+			return formatXLSX
+		case "mimetype":
+			if mimeType, err := readZipEntry(entry, 256); err == nil && strings.TrimSpace(string(mimeType)) == odtMimeType {
+				return formatODT
+			}
+		}
+	}
+	return formatArchive
+}
+
+// formatFromHint classifies files without a recognizable signature (such as
+// CSV) from their declared content type or file name.
+func formatFromHint(contentType, name string) documentFormat {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch {
+	case strings.TrimSpace(mediaType) == "text/csv", strings.EqualFold(path.Ext(name), ".csv"):
+		return formatCSV
+	default:
+		return formatUnknown
+	}
+}
+
+// extractDocument runs the extractor for the document's format. Spreadsheets
+// yield tables whose rows also make up the document text; images have no
+// text layer and go straight to OCR.
+func (p *PDFProcessor) extractDocument(ctx context.Context, format documentFormat, filePath string, options ProcessingOptions) (*documentContent, error) {
+	var tables []ExtractedTable
+	var err error
+
+	switch format {
+	case formatImage:
+		ocr, err := p.performOCR(ctx, filePath, options, nil)
+		if err != nil {
+			return nil, err
+		}
+		content := &documentContent{
+			Text:               ocr.Text,
+			PageCount:          1,
+			PageOffsets:        []int{0},
+			WordBoxes:          ocr.Words,
+			OCRPages:           ocr.Pages,
+			OCRApplied:         true,
+			OCRConfidence:      ocr.Confidence,
+			LowConfidencePages: ocr.LowConfidencePages,
+			SpellCorrections:   ocr.SpellCorrections,
+		}
+		if len(ocr.Corrections) > 0 {
+			content.Metadata = map[string]interface{}{"page_corrections": ocr.Corrections}
+		}
+		if options.DetectTables {
+			content.Tables = ocrTables(ocr.Text, ocr.Words)
+		}
+		return content, nil
+	case formatXLSX:
+		tables, err = p.extractTablesFromXLSX(ctx, filePath)
+	case formatCSV:
+		tables, err = p.extractTablesFromCSV(ctx, filePath)
+	case formatDOCX, formatODT:
+		extract := p.extractTextFromDOCX
+		if format == formatODT {
+			extract = p.extractTextFromODT
+		}
+		text, pageCount, err := extract(ctx, filePath)
+		if err != nil {
+			return nil, err
+		}
+		return &documentContent{Text: text, PageCount: pageCount}, nil
+	default:
+		return p.extractTextFromPDF(ctx, filePath, options)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	text, offsets := tablesToText(tables)
+	return &documentContent{Text: text, PageCount: len(tables), PageOffsets: offsets, Tables: tables}, nil
+}
+
+// supportsOCR reports whether the format can be rasterized for OCR.
+func (f documentFormat) supportsOCR() bool {
+	return f == formatPDF || f == formatUnknown
+}
+
+func readZipEntry(entry *zip.File, limit int64) ([]byte, error) {
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s exceeds %d bytes", entry.Name, limit)
+	}
+	return data, nil
+}
@@ -0,0 +1,168 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Processing hooks let integrations take part in processing without
+// forking the service. The pre-processing hook (PRE_PROCESSING_HOOK_URL)
+// is posted each job as it starts and may answer with options to change,
+// a pipeline among them; the post-processing hook
+// (POST_PROCESSING_HOOK_URL) is posted the job with its result and may
+// answer with metadata and entities to add to the result, or veto it,
+// failing the job. An empty answer changes nothing. Calls are signed as
+// webhooks are (see webhook.Sign), with HOOK_SECRET. A hook that cannot be
+// reached, or answers other than 2xx, fails the attempt, to be retried.
+
+const (
+	hookPreProcess  = "job.pre_process"
+	hookPostProcess = "job.post_process"
+
+	// hookSignatureHeader carries the signature of hook calls
+	hookSignatureHeader = "X-Cotai-Signature"
+
+	// maxHookResponse bounds the answers read from hooks.
+	maxHookResponse = 10 << 20
+)
+
+type hookRequest struct {
+	Event string         `json:"event"`
+	Job   *ProcessingJob `json:"job"`
+}
+
+// hookResponse is what hooks answer with; each event uses its own fields.
+type hookResponse struct {
+	// Options are ProcessingOptions fields to set before processing
+	Options json.RawMessage `json:"options,omitempty"`
+
+	// Metadata is added to the result's metadata, Entities to its entities
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Entities []ExtractedEntity      `json:"entities,omitempty"`
+
+	// Veto fails the job with Reason instead of completing it
+	Veto   bool   `json:"veto,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// runPreProcessingHook lets the pre-processing hook change the options of
+// a job about to be processed.
+func (p *PDFProcessor) runPreProcessingHook(ctx context.Context, job *ProcessingJob) error {
+	if p.cfg.PreProcessingHookURL == "" {
+		return nil
+	}
+	response, err := p.callHook(ctx, p.cfg.PreProcessingHookURL, hookPreProcess, job)
+	if err != nil || len(response.Options) == 0 {
+		return err
+	}
+
+	pipeline := job.Options.Pipeline
+	if err := json.Unmarshal(response.Options, &job.Options); err != nil {
+		return fmt.Errorf("pre-processing hook returned invalid options: %w", err)
+	}
+	if job.Options.Pipeline != pipeline && job.Options.Pipeline != "" {
+		stages, err := p.pipelineStagesNamed(ctx, job.TenantID, job.Options.Pipeline)
+		if err != nil {
+			return fmt.Errorf("pre-processing hook returned invalid options: %w", err)
+		}
+		if err := applyPipeline(&job.Options, stages); err != nil {
+			return fmt.Errorf("pre-processing hook returned invalid options: pipeline %q: %w", job.Options.Pipeline, err)
+		}
+	}
+	if err := validateStageOrder(job.Options.Stages); err != nil {
+		return fmt.Errorf("pre-processing hook returned invalid options: %w", err)
+	}
+	return nil
+}
+
+// runPostProcessingHook lets the post-processing hook add to the result of
+// a job, or veto it.
+func (p *PDFProcessor) runPostProcessingHook(ctx context.Context, job *ProcessingJob) error {
+	if p.cfg.PostProcessingHookURL == "" {
+		return nil
+	}
+	response, err := p.callHook(ctx, p.cfg.PostProcessingHookURL, hookPostProcess, job)
+	if err != nil {
+		return err
+	}
+
+	if response.Veto {
+		reason := response.Reason
+		if reason == "" {
+			reason = "result vetoed by the post-processing hook"
+		}
+		return &ProcessingError{Code: ErrCodeResultVetoed, Err: errors.New(reason)}
+	}
+	if job.Result.Metadata == nil {
+		job.Result.Metadata = make(map[string]interface{})
+	}
+	for key, value := range response.Metadata {
+		job.Result.Metadata[key] = value
+	}
+	job.Result.Entities = append(job.Result.Entities, response.Entities...)
+	return nil
+}
+
+// callHook posts a job to a hook and reads its answer.
+func (p *PDFProcessor) callHook(ctx context.Context, url, event string, job *ProcessingJob) (*hookResponse, error) {
+	// As in storage, the document password stays out of the call
+	sent := *job
+	sent.Options.Password = ""
+	body, err := json.Marshal(hookRequest{Event: event, Job: &sent})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Cotai-Event", event)
+	if p.cfg.HookSecret != "" {
+		req.Header.Set(hookSignatureHeader, signHook(time.Now(), body, p.cfg.HookSecret))
+	}
+
+	resp, err := p.hooks.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s hook: %w", event, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s hook: unexpected status %s", event, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHookResponse))
+	if err != nil {
+		return nil, fmt.Errorf("%s hook: %w", event, err)
+	}
+	var response hookResponse
+	if len(bytes.TrimSpace(data)) == 0 {
+		return &response, nil
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("%s hook: invalid response: %w", event, err)
+	}
+	return &response, nil
+}
+
+// signHook signs a hook call body sent at t in the format of webhook.Sign,
+// which this package cannot import.
+func signHook(t time.Time, body []byte, secret string) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,228 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tenants describe what they bid on in interest profiles; a job referencing
+// one in its interest_profile_id metadata is scored against it instead of
+// the generic procurement keywords.
+
+var (
+	ErrInterestProfileNotFound = errors.New("interest profile not found")
+	ErrInvalidInterestProfile  = errors.New("invalid interest profile")
+)
+
+// InterestProfile lists the keywords, activities (CNAE codes), regions and
+// contract value range a tenant is interested in. Values are in cents; zero
+// leaves that end of the range open.
+type InterestProfile struct {
+	ID            string    `json:"id"`
+	TenantID      string    `json:"tenant_id"`
+	Name          string    `json:"name"`
+	Keywords      []string  `json:"keywords"`
+	CNAECodes     []string  `json:"cnae_codes"`
+	Regions       []string  `json:"regions"`
+	MinValueCents int64     `json:"min_value_cents"`
+	MaxValueCents int64     `json:"max_value_cents"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// validate normalizes the profile's fields and checks it has a criterion.
+func (ip *InterestProfile) validate() error {
+	ip.Name = strings.TrimSpace(ip.Name)
+	if ip.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidInterestProfile)
+	}
+
+	ip.Keywords = trimmedNonEmpty(ip.Keywords)
+	ip.Regions = trimmedNonEmpty(ip.Regions)
+	codes := make([]string, 0, len(ip.CNAECodes))
+	for _, code := range ip.CNAECodes {
+		d := onlyDigits(code)
+		if len(d) < 2 || len(d) > 7 {
+			return fmt.Errorf("%w: invalid CNAE code %q", ErrInvalidInterestProfile, code)
+		}
+		codes = append(codes, d)
+	}
+	ip.CNAECodes = codes
+
+	switch {
+	case ip.MinValueCents < 0 || ip.MaxValueCents < 0:
+		return fmt.Errorf("%w: values must not be negative", ErrInvalidInterestProfile)
+	case ip.MaxValueCents > 0 && ip.MinValueCents > ip.MaxValueCents:
+		return fmt.Errorf("%w: min_value_cents exceeds max_value_cents", ErrInvalidInterestProfile)
+	case len(ip.Keywords) == 0 && len(ip.CNAECodes) == 0 && len(ip.Regions) == 0 && !ip.hasValueRange():
+		return fmt.Errorf("%w: at least one keyword, CNAE code, region or value bound is required", ErrInvalidInterestProfile)
+	}
+	return nil
+}
+
+func (ip *InterestProfile) hasValueRange() bool {
+	return ip.MinValueCents > 0 || ip.MaxValueCents > 0
+}
+
+func trimmedNonEmpty(values []string) []string {
+	trimmed := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			trimmed = append(trimmed, v)
+		}
+	}
+	return trimmed
+}
+
+const interestProfileColumns = `id, tenant_id, name, keywords, cnae_codes, regions, min_value_cents, max_value_cents, created_at, updated_at`
+
+func scanInterestProfile(row rowScanner) (*InterestProfile, error) {
+	var ip InterestProfile
+	var keywords, codes, regions []byte
+	err := row.Scan(&ip.ID, &ip.TenantID, &ip.Name, &keywords, &codes, &regions,
+		&ip.MinValueCents, &ip.MaxValueCents, &ip.CreatedAt, &ip.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInterestProfileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range []struct {
+		raw  []byte
+		dest *[]string
+	}{{keywords, &ip.Keywords}, {codes, &ip.CNAECodes}, {regions, &ip.Regions}} {
+		if err := json.Unmarshal(field.raw, field.dest); err != nil {
+			return nil, fmt.Errorf("invalid interest profile %s: %w", ip.ID, err)
+		}
+	}
+	return &ip, nil
+}
+
+// ListInterestProfiles returns a tenant's interest profiles by name.
+func (p *PDFProcessor) ListInterestProfiles(ctx context.Context, tenantID string) ([]InterestProfile, error) {
+	rows, err := p.postgres.Query(ctx,
+		`SELECT `+interestProfileColumns+` FROM interest_profiles WHERE tenant_id = $1 ORDER BY name`,
+		tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interest profiles: %w", err)
+	}
+	defer rows.Close()
+
+	profiles := []InterestProfile{}
+	for rows.Next() {
+		ip, err := scanInterestProfile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read interest profile: %w", err)
+		}
+		profiles = append(profiles, *ip)
+	}
+	return profiles, rows.Err()
+}
+
+func (p *PDFProcessor) GetInterestProfile(ctx context.Context, tenantID, id string) (*InterestProfile, error) {
+	return scanInterestProfile(p.postgres.QueryRow(ctx,
+		`SELECT `+interestProfileColumns+` FROM interest_profiles WHERE tenant_id = $1 AND id = $2`,
+		tenantID, id))
+}
+
+func (p *PDFProcessor) CreateInterestProfile(ctx context.Context, ip *InterestProfile) error {
+	if err := ip.validate(); err != nil {
+		return err
+	}
+	keywords, codes, regions, err := marshalInterestLists(ip)
+	if err != nil {
+		return err
+	}
+
+	ip.ID = uuid.New().String()
+	ip.CreatedAt = time.Now()
+	ip.UpdatedAt = ip.CreatedAt
+
+	err = p.postgres.Exec(ctx, `
+		INSERT INTO interest_profiles (`+interestProfileColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, ip.ID, ip.TenantID, ip.Name, keywords, codes, regions, ip.MinValueCents, ip.MaxValueCents, ip.CreatedAt, ip.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create interest profile: %w", err)
+	}
+	return nil
+}
+
+func (p *PDFProcessor) UpdateInterestProfile(ctx context.Context, ip *InterestProfile) error {
+	if err := ip.validate(); err != nil {
+		return err
+	}
+	keywords, codes, regions, err := marshalInterestLists(ip)
+	if err != nil {
+		return err
+	}
+
+	updated, err := scanInterestProfile(p.postgres.QueryRow(ctx, `
+		UPDATE interest_profiles
+		SET name = $3, keywords = $4, cnae_codes = $5, regions = $6,
+			min_value_cents = $7, max_value_cents = $8, updated_at = $9
+		WHERE tenant_id = $1 AND id = $2
+		RETURNING `+interestProfileColumns,
+		ip.TenantID, ip.ID, ip.Name, keywords, codes, regions, ip.MinValueCents, ip.MaxValueCents, time.Now()))
+	if err != nil {
+		return err
+	}
+
+	*ip = *updated
+	return nil
+}
+
+func (p *PDFProcessor) DeleteInterestProfile(ctx context.Context, tenantID, id string) error {
+	var deleted string
+	err := p.postgres.QueryRow(ctx,
+		`DELETE FROM interest_profiles WHERE tenant_id = $1 AND id = $2 RETURNING id`,
+		tenantID, id).Scan(&deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInterestProfileNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete interest profile: %w", err)
+	}
+	return nil
+}
+
+func marshalInterestLists(ip *InterestProfile) ([]byte, []byte, []byte, error) {
+	keywords, err := json.Marshal(ip.Keywords)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	codes, err := json.Marshal(ip.CNAECodes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	regions, err := json.Marshal(ip.Regions)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return keywords, codes, regions, nil
+}
+
+// jobInterestProfile loads the profile named by the job's
+// interest_profile_id metadata. A missing profile is logged and the
+// generic scoring is used instead.
+func (p *PDFProcessor) jobInterestProfile(ctx context.Context, job *ProcessingJob) *InterestProfile {
+	id, _ := job.Metadata["interest_profile_id"].(string)
+	if id == "" {
+		return nil
+	}
+
+	ip, err := p.GetInterestProfile(ctx, job.TenantID, id)
+	if err != nil {
+		log.Printf("Failed to load interest profile %s for job %s: %v", id, job.ID, err)
+		return nil
+	}
+	return ip
+}
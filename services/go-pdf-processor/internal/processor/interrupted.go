@@ -0,0 +1,60 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Jobs a stopping worker pool interrupts, and those left in a queue held
+// in the process, are kept in Redis until a worker pool next starts, on
+// this replica or another, and queues them again.
+
+// interruptedJobs is the hash of interrupted jobs by ID, as queued: with
+// their password.
+const interruptedJobs = "interrupted-jobs"
+
+// ErrJobInterrupted stops the jobs still running when the worker pool's
+// shutdown timeout passes.
+var ErrJobInterrupted = errors.New("job interrupted by shutdown")
+
+func (p *PDFProcessor) saveInterrupted(ctx context.Context, job *ProcessingJob) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	if err := p.redis.Client().HSet(ctx, interruptedJobs, job.ID, body).Err(); err != nil {
+		return fmt.Errorf("failed to keep interrupted job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+func (p *PDFProcessor) interruptedJobIDs(ctx context.Context) ([]string, error) {
+	return p.redis.Client().HKeys(ctx, interruptedJobs).Result()
+}
+
+// takeInterrupted removes an interrupted job for the caller to queue; it
+// returns nil when another worker pool took it first.
+func (p *PDFProcessor) takeInterrupted(ctx context.Context, id string) (*ProcessingJob, error) {
+	var body *redis.StringCmd
+	_, err := p.redis.Client().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		body = pipe.HGet(ctx, interruptedJobs, id)
+		pipe.HDel(ctx, interruptedJobs, id)
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var job ProcessingJob
+	if err := json.Unmarshal([]byte(body.Val()), &job); err != nil {
+		return nil, fmt.Errorf("failed to decode interrupted job %s: %w", id, err)
+	}
+	return &job, nil
+}
@@ -0,0 +1,260 @@
+package processor
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// BiddingItem is a row of the item table (planilha de itens) of an edital or
+// termo de referência. Amounts are in cents; the estimated total is computed
+// from the unit value when the table has no total column.
+type BiddingItem struct {
+	Item                string  `json:"item"`
+	Description         string  `json:"description"`
+	Unit                string  `json:"unit,omitempty"`
+	Quantity            float64 `json:"quantity"`
+	EstimatedUnitCents  int64   `json:"estimated_unit_cents,omitempty"`
+	EstimatedTotalCents int64   `json:"estimated_total_cents,omitempty"`
+	Page                int     `json:"page,omitempty"`
+	Source              string  `json:"source"` // "table" or "text"
+}
+
+// itemUnits are the units of supply written in item tables.
+const itemUnits = `un|und|unid|unidades?|cx|caixas?|pct|pcts|pacotes?|p[çc]|p[çc]s|pe[çc]as?|kg|g|mg|l|lt|litros?|ml|m|m2|m²|m3|m³|km|fr|frascos?|gl|gal[ãa]o|gal[õo]es|sv|serv|servi[çc]os?|m[êe]s|meses|h|hr|horas?|di[áa]rias?|kits?|jg|jogos?|pares?|rl|rolos?|tb|tubos?|sc|sacos?|dz|d[úu]zias?|cj|conjuntos?|fd|fardos?|resmas?|amp|ampolas?|cp|comp|comprimidos?|bl|blocos?|lote|vb|verba|un/mês`
+
+// Item rows as extracted from text: the item number, its description, then
+// the unit and quantity in either order, optionally followed by the unit and
+// total values.
+var (
+	itemStart       = regexp.MustCompile(`^\d{1,4}(?:\.\d{1,3})?[.)\-–]?\s`)
+	itemRowUnitQty  = regexp.MustCompile(`(?i)^(\d{1,4}(?:\.\d{1,3})?)[.)\-–]?\s+(.+?)\s+(` + itemUnits + `)\.?\s+(\d{1,3}(?:\.\d{3})+(?:,\d+)?|\d+(?:,\d+)?)` + itemValues + `$`)
+	itemRowQtyUnit  = regexp.MustCompile(`(?i)^(\d{1,4}(?:\.\d{1,3})?)[.)\-–]?\s+(.+?)\s+(\d{1,3}(?:\.\d{3})+(?:,\d+)?|\d+(?:,\d+)?)\s+(` + itemUnits + `)\.?` + itemValues + `$`)
+	itemValueAmount = regexp.MustCompile(`^(?:\d{1,3}(?:\.\d{3})*|\d+),\d{2}$`)
+)
+
+const itemValues = `(?:\s+(?:R\$\s*)?(\d{1,3}(?:\.\d{3})*,\d{2}|\d+,\d{2}))?(?:\s+(?:R\$\s*)?(\d{1,3}(?:\.\d{3})*,\d{2}|\d+,\d{2}))?`
+
+// maxItemRowLines bounds how many lines a text row with a wrapped
+// description may span.
+const maxItemRowLines = 3
+
+// extractBiddingItems returns the item rows of a document, from its tables
+// when it has an item table, otherwise from its text.
+func extractBiddingItems(tables []ExtractedTable, text string, pageOffsets []int) []BiddingItem {
+	var items []BiddingItem
+	for _, table := range tables {
+		items = append(items, itemsFromTable(table)...)
+	}
+	if len(items) == 0 {
+		items = itemsFromText(text, pageOffsets)
+	}
+	return dedupeItems(items)
+}
+
+// itemColumns locates the item table columns by their headers; -1 marks a
+// missing column.
+type itemColumns struct {
+	item, description, unit, quantity, unitValue, totalValue int
+}
+
+func findItemColumns(headers []string) (itemColumns, bool) {
+	cols := itemColumns{-1, -1, -1, -1, -1, -1}
+	for i, header := range headers {
+		h := strings.ToLower(strings.Join(strings.Fields(header), " "))
+		switch {
+		case cols.item < 0 && (h == "item" || h == "it" || h == "itens" || strings.HasPrefix(h, "n°") || strings.HasPrefix(h, "nº") || h == "seq"):
+			cols.item = i
+		case cols.description < 0 && (strings.Contains(h, "descri") || strings.Contains(h, "especifica") || h == "produto" || h == "objeto"):
+			cols.description = i
+		case cols.quantity < 0 && (strings.HasPrefix(h, "quant") || strings.HasPrefix(h, "qtd") || strings.HasPrefix(h, "qde")):
+			cols.quantity = i
+		case cols.unit < 0 && (strings.HasPrefix(h, "un") || strings.Contains(h, "medida")) && !strings.Contains(h, "unit"):
+			cols.unit = i
+		case strings.Contains(h, "total"):
+			if cols.totalValue < 0 {
+				cols.totalValue = i
+			}
+		case cols.unitValue < 0 && strings.Contains(h, "unit"):
+			cols.unitValue = i
+		}
+	}
+	return cols, cols.description >= 0 && cols.quantity >= 0
+}
+
+// itemsFromTable reads a table whose headers name at least the description
+// and quantity columns.
+func itemsFromTable(table ExtractedTable) []BiddingItem {
+	cols, ok := findItemColumns(table.Headers)
+	if !ok {
+		return nil
+	}
+
+	cell := func(row []string, i int) string {
+		if i < 0 || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	var items []BiddingItem
+	for n, row := range table.Rows {
+		quantity, ok := parseQuantity(cell(row, cols.quantity))
+		description := strings.Join(strings.Fields(cell(row, cols.description)), " ")
+		if !ok || description == "" {
+			continue // subtotal, lot heading or blank row
+		}
+		item := BiddingItem{
+			Item:        cell(row, cols.item),
+			Description: description,
+			Unit:        cell(row, cols.unit),
+			Quantity:    quantity,
+			Page:        table.Page,
+			Source:      "table",
+		}
+		if item.Item == "" {
+			item.Item = strconv.Itoa(n + 1)
+		}
+		item.EstimatedUnitCents, _ = parseAmountCents(cell(row, cols.unitValue))
+		item.EstimatedTotalCents, _ = parseAmountCents(cell(row, cols.totalValue))
+		items = append(items, completeItemTotal(item))
+	}
+	return items
+}
+
+// itemsFromText finds item rows in text lines. A row whose description wraps
+// is matched on its lines joined, up to maxItemRowLines.
+func itemsFromText(text string, pageOffsets []int) []BiddingItem {
+	type line struct {
+		text string
+		pos  int
+	}
+	var lines []line
+	for start := 0; start < len(text); {
+		end := strings.IndexByte(text[start:], '\n')
+		if end < 0 {
+			end = len(text)
+		} else {
+			end += start
+		}
+		if trimmed := strings.Join(strings.Fields(text[start:end]), " "); trimmed != "" {
+			lines = append(lines, line{trimmed, start})
+		}
+		start = end + 1
+	}
+
+	var items []BiddingItem
+	for i := 0; i < len(lines); i++ {
+		if !itemStart.MatchString(lines[i].text) {
+			continue
+		}
+		joined := lines[i].text
+		for n := 1; n <= maxItemRowLines && i+n <= len(lines); n++ {
+			if n > 1 {
+				if itemStart.MatchString(lines[i+n-1].text) {
+					break
+				}
+				joined += " " + lines[i+n-1].text
+			}
+			if item, ok := parseItemRow(joined); ok {
+				item.Page = pageForOffset(pageOffsets, lines[i].pos)
+				items = append(items, item)
+				i += n - 1
+				break
+			}
+		}
+	}
+	return items
+}
+
+func parseItemRow(line string) (BiddingItem, bool) {
+	var number, description, unit, quantity, unitValue, totalValue string
+	// Quantity first, so that in "12 MÊS 3.000,00" the value is not read as
+	// the quantity
+	if m := itemRowQtyUnit.FindStringSubmatch(line); m != nil {
+		number, description, quantity, unit, unitValue, totalValue = m[1], m[2], m[3], m[4], m[5], m[6]
+	} else if m := itemRowUnitQty.FindStringSubmatch(line); m != nil {
+		number, description, unit, quantity, unitValue, totalValue = m[1], m[2], m[3], m[4], m[5], m[6]
+	} else {
+		return BiddingItem{}, false
+	}
+
+	qty, ok := parseQuantity(quantity)
+	if !ok || !strings.ContainsFunc(description, unicode.IsLetter) {
+		return BiddingItem{}, false
+	}
+	item := BiddingItem{
+		Item:        number,
+		Description: strings.TrimSpace(strings.TrimRight(description, "-–")),
+		Unit:        unit,
+		Quantity:    qty,
+		Source:      "text",
+	}
+	item.EstimatedUnitCents, _ = parseAmountCents(unitValue)
+	item.EstimatedTotalCents, _ = parseAmountCents(totalValue)
+	return completeItemTotal(item), true
+}
+
+func completeItemTotal(item BiddingItem) BiddingItem {
+	if item.EstimatedTotalCents == 0 && item.EstimatedUnitCents > 0 {
+		item.EstimatedTotalCents = int64(math.Round(float64(item.EstimatedUnitCents) * item.Quantity))
+	}
+	return item
+}
+
+// parseQuantity reads "1.000", "2,5" and, as stored in spreadsheets, "2.5".
+func parseQuantity(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if strings.Contains(value, ",") || thousandsOnly.MatchString(value) {
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.Replace(value, ",", ".", 1)
+	}
+	qty, err := strconv.ParseFloat(value, 64)
+	if err != nil || qty <= 0 {
+		return 0, false
+	}
+	return qty, true
+}
+
+var thousandsOnly = regexp.MustCompile(`^\d{1,3}(?:\.\d{3})+$`)
+
+// parseAmountCents reads currency amounts written "R$ 1.234,56" or, as
+// stored in spreadsheets, "1234.56".
+func parseAmountCents(value string) (int64, bool) {
+	value = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(value), "R$"))
+	if value == "" {
+		return 0, false
+	}
+	if itemValueAmount.MatchString(value) || thousandsOnly.MatchString(value) {
+		return normalizeCurrency(value)
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount < 0 {
+		return 0, false
+	}
+	return int64(math.Round(amount * 100)), true
+}
+
+// dedupeItems drops rows repeated in the document, e.g. the item table of
+// the termo de referência copied into the proposal model.
+func dedupeItems(items []BiddingItem) []BiddingItem {
+	type key struct {
+		item, description string
+		quantity          float64
+	}
+	seen := make(map[key]bool, len(items))
+	unique := items[:0]
+	for _, item := range items {
+		k := key{item.Item, strings.ToLower(item.Description), item.Quantity}
+		if !seen[k] {
+			seen[k] = true
+			unique = append(unique, item)
+		}
+	}
+	return unique
+}
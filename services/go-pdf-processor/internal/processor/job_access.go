@@ -0,0 +1,66 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"cotai-pdf-processor/internal/storage"
+)
+
+// JobAccess restricts the jobs a request may see to those of a tenant, of
+// a user, or both; empty fields do not restrict. Jobs outside it are not
+// found by GetJob, JobStatuses, JobResult and ListJobs, and so by what is
+// built on them. Contexts without one, those of the service's own work,
+// see every job.
+type JobAccess struct {
+	TenantID string
+	UserID   string
+}
+
+// jobKey is the key of a job's status. Those of tenants' jobs are under
+// the tenant's prefix, for Redis ACLs to confine them and for requests of
+// a tenant to only ever read its keys; jobTenantKey indexes the tenant of
+// each job for lookups by ID alone.
+func jobKey(tenantID, id string) string {
+	if tenantID == "" {
+		return fmt.Sprintf("job:%s", id)
+	}
+	return fmt.Sprintf("tenant:%s:job:%s", tenantID, id)
+}
+
+func jobTenantKey(id string) string {
+	return fmt.Sprintf("job-tenant:%s", id)
+}
+
+type jobAccessKey struct{}
+
+// WithJobAccess returns a context restricted to the jobs access allows.
+// Restricted to a tenant, its queries only see the tenant's rows too, and
+// its jobs are read from the tenant's keys.
+func WithJobAccess(ctx context.Context, access JobAccess) context.Context {
+	if access.TenantID != "" {
+		ctx = storage.WithTenant(ctx, access.TenantID)
+	}
+	return context.WithValue(ctx, jobAccessKey{}, access)
+}
+
+func jobAccessOf(ctx context.Context) (JobAccess, bool) {
+	access, ok := ctx.Value(jobAccessKey{}).(JobAccess)
+	return access, ok
+}
+
+// AccessAllows reports whether the context may see what a tenant and user
+// own, as resumable uploads, alike to their jobs.
+func AccessAllows(ctx context.Context, tenantID, userID string) bool {
+	return jobAllowed(ctx, tenantID, userID)
+}
+
+// jobAllowed reports whether the context may see a job of a tenant and
+// user.
+func jobAllowed(ctx context.Context, tenantID, userID string) bool {
+	access, ok := jobAccessOf(ctx)
+	if !ok {
+		return true
+	}
+	return (access.TenantID == "" || tenantID == access.TenantID) && (access.UserID == "" || userID == access.UserID)
+}
@@ -0,0 +1,100 @@
+package processor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestJobAllowed(t *testing.T) {
+	tests := []struct {
+		name     string
+		access   *JobAccess
+		tenantID string
+		userID   string
+		want     bool
+	}{
+		{"unrestricted", nil, "t1", "u1", true},
+		{"all jobs", &JobAccess{}, "t1", "u1", true},
+		{"job of the tenant", &JobAccess{TenantID: "t1"}, "t1", "u2", true},
+		{"job of another tenant", &JobAccess{TenantID: "t1"}, "t2", "u1", false},
+		{"job of no tenant", &JobAccess{TenantID: "t1"}, "", "u1", false},
+		{"own job", &JobAccess{TenantID: "t1", UserID: "u1"}, "t1", "u1", true},
+		{"job of another user", &JobAccess{TenantID: "t1", UserID: "u1"}, "t1", "u2", false},
+		{"own job of another tenant", &JobAccess{TenantID: "t1", UserID: "u1"}, "t2", "u1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.access != nil {
+				ctx = WithJobAccess(ctx, *tt.access)
+			}
+			if got := jobAllowed(ctx, tt.tenantID, tt.userID); got != tt.want {
+				t.Errorf("jobAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobKey(t *testing.T) {
+	if got := jobKey("", "j1"); got != "job:j1" {
+		t.Errorf("jobKey() = %q", got)
+	}
+	if got := jobKey("t1", "j1"); got != "tenant:t1:job:j1" {
+		t.Errorf("jobKey() = %q", got)
+	}
+}
+
+func TestJobConditionsTenantFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		access     *JobAccess
+		filter     JobFilter
+		wantClause string
+		wantArgs   []interface{}
+	}{
+		{
+			name: "unrestricted",
+		},
+		{
+			name:       "unrestricted filtered by tenant",
+			filter:     JobFilter{TenantID: "t2"},
+			wantClause: "WHERE tenant_id = $1",
+			wantArgs:   []interface{}{"t2"},
+		},
+		{
+			name:       "tenant",
+			access:     &JobAccess{TenantID: "t1"},
+			wantClause: "WHERE tenant_id = $1",
+			wantArgs:   []interface{}{"t1"},
+		},
+		{
+			name:       "tenant filtering another tenant",
+			access:     &JobAccess{TenantID: "t1"},
+			filter:     JobFilter{TenantID: "t2"},
+			wantClause: "WHERE tenant_id = $1 AND tenant_id = $2",
+			wantArgs:   []interface{}{"t2", "t1"},
+		},
+		{
+			name:       "user",
+			access:     &JobAccess{TenantID: "t1", UserID: "u1"},
+			filter:     JobFilter{UserID: "u2"},
+			wantClause: "WHERE user_id = $1 AND tenant_id = $2 AND user_id = $3",
+			wantArgs:   []interface{}{"u2", "t1", "u1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.access != nil {
+				ctx = WithJobAccess(ctx, *tt.access)
+			}
+			clause, args := jobConditions(ctx, tt.filter)
+			if clause != tt.wantClause || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("jobConditions() = %q, %v, want %q, %v", clause, args, tt.wantClause, tt.wantArgs)
+			}
+		})
+	}
+}
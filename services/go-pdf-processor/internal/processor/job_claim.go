@@ -0,0 +1,70 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// A job whose status more than one request or worker may change at once,
+// as a presigned upload completed by its client and by the bucket
+// notification, or an archive whose entries finish together, is changed
+// under a short Redis claim: its holder reads the job, changes it and saves
+// it before releasing the claim, for the next holder to see the change.
+
+// ErrJobClaimed is returned for jobs another request is changing.
+var ErrJobClaimed = errors.New("job is being changed by another request")
+
+func jobClaimKey(id string) string {
+	return fmt.Sprintf("job-claim:%s", id)
+}
+
+// The claim is released only by its holder, not once it has expired and
+// been claimed again.
+var releaseJobClaim = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// ClaimJob claims a job for the caller to change it alone, until released
+// or for ttl at most, failing with ErrJobClaimed while another holds it.
+func (p *PDFProcessor) ClaimJob(ctx context.Context, id string, ttl time.Duration) (func(), error) {
+	token := uuid.New().String()
+	claimed, err := p.redis.Client().SetNX(ctx, jobClaimKey(id), token, ttl).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job %s: %w", id, err)
+	}
+	if !claimed {
+		return nil, ErrJobClaimed
+	}
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := releaseJobClaim.Run(ctx, p.redis.Client(), []string{jobClaimKey(id)}, token).Err(); err != nil {
+			log.Printf("Failed to release claim of job %s: %v", id, err)
+		}
+	}, nil
+}
+
+// awaitJobClaim claims a job as ClaimJob does, waiting while another holds
+// the claim.
+func (p *PDFProcessor) awaitJobClaim(ctx context.Context, id string, ttl time.Duration) (func(), error) {
+	for {
+		release, err := p.ClaimJob(ctx, id, ttl)
+		if !errors.Is(err, ErrJobClaimed) {
+			return release, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
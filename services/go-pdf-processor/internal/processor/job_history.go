@@ -0,0 +1,220 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Jobs expire from Redis a day after their last change; their history is
+// kept in the processing_jobs table, updated with every status change, for
+// operators to browse (see ListJobs).
+
+// JobRecord is a job as kept in the history, without its result.
+type JobRecord struct {
+	ID          string     `json:"id"`
+	ParentID    string     `json:"parent_id,omitempty"`
+	TenderID    string     `json:"tender_id"`
+	TenantID    string     `json:"tenant_id,omitempty"`
+	UserID      string     `json:"user_id"`
+	Status      string     `json:"status"`
+	Priority    string     `json:"priority,omitempty"`
+	Filename    string     `json:"filename,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	ErrorCode   string     `json:"error_code,omitempty"`
+	Attempts    int        `json:"attempts"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// JobFilter selects jobs of the history; empty fields match any job.
+// Statuses are alternatives, jobs are created in [From, To), and Text is
+// looked for in their error message or file name, ignoring case.
+type JobFilter struct {
+	Statuses  []string
+	TenderID  string
+	TenantID  string
+	UserID    string
+	ErrorCode string
+	Text      string
+	From      *time.Time
+	To        *time.Time
+}
+
+// jobSorts maps the sort orders of ListJobs to their ORDER BY clauses; the
+// ID breaks ties so pages do not overlap.
+var jobSorts = map[string]string{
+	"created_at":    "created_at, id",
+	"-created_at":   "created_at DESC, id",
+	"completed_at":  "completed_at NULLS LAST, id",
+	"-completed_at": "completed_at DESC NULLS LAST, id",
+	"status":        "status, created_at DESC, id",
+}
+
+var ErrInvalidJobFilter = errors.New("invalid job filter")
+
+// DefaultJobSort lists the latest jobs first.
+const DefaultJobSort = "-created_at"
+
+// ValidateJobSort checks a sort order of ListJobs.
+func ValidateJobSort(sort string) error {
+	if _, ok := jobSorts[sort]; !ok {
+		return fmt.Errorf("%w: sort must be one of created_at, -created_at, completed_at, -completed_at or status", ErrInvalidJobFilter)
+	}
+	return nil
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+const jobRecordColumns = `id, parent_id, tender_id, tenant_id, user_id, status, priority, filename,
+	error, error_code, attempts, created_at, started_at, completed_at, updated_at`
+
+// recordJob stores the job's current state in the history, and where its
+// document came from for reprocessing policies to fetch it again.
+func (p *PDFProcessor) recordJob(ctx context.Context, job *ProcessingJob) error {
+	filename, _ := job.Metadata["original_filename"].(string)
+	return p.postgres.Exec(ctx, `
+		INSERT INTO processing_jobs (`+jobRecordColumns+`, file_url)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			error = EXCLUDED.error,
+			error_code = EXCLUDED.error_code,
+			attempts = EXCLUDED.attempts,
+			started_at = EXCLUDED.started_at,
+			completed_at = EXCLUDED.completed_at,
+			updated_at = EXCLUDED.updated_at
+	`, job.ID, job.ParentID, job.TenderID, job.TenantID, job.UserID, job.Status, job.Priority, filename,
+		job.Error, job.ErrorCode, job.Attempts, job.CreatedAt, job.StartedAt, job.CompletedAt, time.Now(), job.FileURL)
+}
+
+// ListJobs pages through the jobs of the history matching filter, and
+// the context's JobAccess, in the given sort order, returning the total
+// number of matching jobs along with the page.
+func (p *PDFProcessor) ListJobs(ctx context.Context, filter JobFilter, sort string, offset, limit int) ([]JobRecord, int, error) {
+	if err := ValidateJobSort(sort); err != nil {
+		return nil, 0, err
+	}
+
+	clause, args := jobConditions(ctx, filter)
+	var total int
+	if err := p.postgres.QueryRow(ctx, `SELECT count(*) FROM processing_jobs `+clause, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count jobs: %w", err)
+	}
+
+	args = append(args, offset, limit)
+	rows, err := p.postgres.Query(ctx, fmt.Sprintf(`
+		SELECT `+jobRecordColumns+`
+		FROM processing_jobs
+		%s
+		ORDER BY %s
+		OFFSET $%d LIMIT $%d
+	`, clause, jobSorts[sort], len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []JobRecord{}
+	for rows.Next() {
+		var job JobRecord
+		err := rows.Scan(&job.ID, &job.ParentID, &job.TenderID, &job.TenantID, &job.UserID, &job.Status,
+			&job.Priority, &job.Filename, &job.Error, &job.ErrorCode, &job.Attempts, &job.CreatedAt,
+			&job.StartedAt, &job.CompletedAt, &job.UpdatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, total, rows.Err()
+}
+
+// jobConditions returns the WHERE clause of the jobs matching filter and
+// the context's JobAccess, with its arguments.
+func jobConditions(ctx context.Context, filter JobFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if len(filter.Statuses) > 0 {
+		where("status = ANY($%d)", pq.Array(filter.Statuses))
+	}
+	if filter.TenderID != "" {
+		where("tender_id = $%d", filter.TenderID)
+	}
+	if filter.TenantID != "" {
+		where("tenant_id = $%d", filter.TenantID)
+	}
+	if filter.UserID != "" {
+		where("user_id = $%d", filter.UserID)
+	}
+	if filter.ErrorCode != "" {
+		where("error_code = $%d", filter.ErrorCode)
+	}
+	if filter.Text != "" {
+		where("(error ILIKE $%[1]d OR filename ILIKE $%[1]d)", "%"+likeEscaper.Replace(filter.Text)+"%")
+	}
+	if filter.From != nil {
+		where("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		where("created_at < $%d", *filter.To)
+	}
+	if access, ok := jobAccessOf(ctx); ok {
+		if access.TenantID != "" {
+			where("tenant_id = $%d", access.TenantID)
+		}
+		if access.UserID != "" {
+			where("user_id = $%d", access.UserID)
+		}
+	}
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// JobResult returns the result of a job, from its status while it is kept
+// and from the history after. It is nil for jobs without a result.
+func (p *PDFProcessor) JobResult(ctx context.Context, id string) (*ProcessingResult, error) {
+	job, err := p.GetJob(ctx, id)
+	if err == nil {
+		return job.Result, nil
+	}
+	if !errors.Is(err, ErrJobNotFound) {
+		return nil, err
+	}
+
+	var data []byte
+	var tenantID, userID string
+	err = p.postgres.QueryRow(ctx,
+		`SELECT result, tenant_id, user_id FROM processing_jobs WHERE id = $1`, id).Scan(&data, &tenantID, &userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load result of job %s: %w", id, err)
+	}
+	if !jobAllowed(ctx, tenantID, userID) {
+		return nil, ErrJobNotFound
+	}
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	var result ProcessingResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid result for job %s: %w", id, err)
+	}
+	return &result, nil
+}
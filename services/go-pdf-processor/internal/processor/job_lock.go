@@ -0,0 +1,129 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// A job is processed under a Redis lock of its own, so a job delivered to
+// two replicas sharing the queue is processed once. The lock is a lease
+// the worker renews while the job runs: a replica that dies stops renewing
+// it, and the job's next delivery takes it over once it has expired. A
+// worker that finds its lock taken over stops the job and leaves its
+// status to the new holder.
+
+var (
+	// ErrJobLocked is returned for jobs another worker is processing.
+	ErrJobLocked = errors.New("job is being processed by another worker")
+
+	// ErrJobLockLost stops jobs whose lock another worker took over.
+	ErrJobLockLost = errors.New("job lock taken over by another worker")
+)
+
+func jobLockKey(id string) string {
+	return fmt.Sprintf("job-lock:%s", id)
+}
+
+// The lock is renewed and released only by the worker holding it.
+var (
+	renewJobLock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	releaseJobLock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// jobLock is the lock of a job held by this worker. A nil lock holds
+// nothing.
+type jobLock struct {
+	p     *PDFProcessor
+	id    string
+	token string
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// lockJob takes the job's lock and renews it until released, calling
+// cancel with ErrJobLockLost if it is lost. It fails with ErrJobLocked
+// while another worker holds the lock. With a zero JOB_LOCK_LEASE jobs are
+// not locked and the lock is nil.
+func (p *PDFProcessor) lockJob(ctx context.Context, id string, cancel context.CancelCauseFunc) (*jobLock, error) {
+	lease := p.cfg.JobLockLease
+	if lease <= 0 {
+		return nil, nil
+	}
+
+	lock := &jobLock{
+		p:     p,
+		id:    id,
+		token: uuid.New().String(),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	locked, err := p.redis.Client().SetNX(ctx, jobLockKey(id), lock.token, lease).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock job %s: %w", id, err)
+	}
+	if !locked {
+		return nil, ErrJobLocked
+	}
+
+	go lock.renew(lease, cancel)
+	return lock, nil
+}
+
+func (l *jobLock) renew(lease time.Duration, cancel context.CancelCauseFunc) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-l.stop:
+			return
+		}
+
+		ctx, cancelRenew := context.WithTimeout(context.Background(), lease/3)
+		renewed, err := renewJobLock.Run(ctx, l.p.redis.Client(), []string{jobLockKey(l.id)}, l.token, lease.Milliseconds()).Int()
+		cancelRenew()
+		if err != nil {
+			// Redis may be back before the lease ends
+			log.Printf("Failed to renew lock of job %s: %v", l.id, err)
+			continue
+		}
+		if renewed == 0 {
+			log.Printf("Lock of job %s lost, stopping the job", l.id)
+			cancel(ErrJobLockLost)
+			return
+		}
+	}
+}
+
+// release stops renewing the lock and removes it, unless another worker
+// has taken it over.
+func (l *jobLock) release() {
+	if l == nil {
+		return
+	}
+	close(l.stop)
+	<-l.done
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := releaseJobLock.Run(ctx, l.p.redis.Client(), []string{jobLockKey(l.id)}, l.token).Err(); err != nil {
+		log.Printf("Failed to release lock of job %s: %v", l.id, err)
+	}
+}
@@ -0,0 +1,105 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// JobStatuses returns the status of each job of ids, in order, reading them
+// in a single Redis round trip, after one for the tenants of the jobs unless
+// the context is of a tenant; the progress of processing jobs is included.
+// IDs of unknown jobs, and of those the context may not see, are returned
+// apart.
+func (p *PDFProcessor) JobStatuses(ctx context.Context, ids []string) ([]JobUpdate, []string, error) {
+	keys, err := p.jobKeys(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pipe := p.redis.Client().Pipeline()
+	jobs := make([]*redis.StringCmd, len(ids))
+	legacy := make([]*redis.StringCmd, len(ids))
+	progress := make([]*redis.StringCmd, len(ids))
+	for i, id := range ids {
+		jobs[i] = pipe.Get(ctx, keys[i])
+		if keys[i] != jobKey("", id) {
+			legacy[i] = pipe.Get(ctx, jobKey("", id))
+		}
+		progress[i] = pipe.Get(ctx, progressKey(id))
+	}
+	// Missing keys fail their command, and so the pipeline; each command is
+	// checked below
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, nil, fmt.Errorf("failed to load job statuses: %w", err)
+	}
+
+	statuses := []JobUpdate{}
+	notFound := []string{}
+	for i, id := range ids {
+		data, err := jobs[i].Bytes()
+		if errors.Is(err, redis.Nil) && legacy[i] != nil {
+			// Saved before their tenant's keys, see readJob
+			data, err = legacy[i].Bytes()
+		}
+		if errors.Is(err, redis.Nil) {
+			notFound = append(notFound, id)
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load job %s: %w", id, err)
+		}
+
+		var job ProcessingJob
+		if err := json.Unmarshal(data, &job); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode job %s: %w", id, err)
+		}
+		if !jobAllowed(ctx, job.TenantID, job.UserID) {
+			notFound = append(notFound, id)
+			continue
+		}
+		status := jobUpdateOf(&job)
+		if job.Status == "processing" {
+			if data, err := progress[i].Bytes(); err == nil {
+				var jp JobProgress
+				if json.Unmarshal(data, &jp) == nil {
+					status.Progress = &jp
+				}
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, notFound, nil
+}
+
+// jobKeys returns the keys of the jobs of ids: those of the context's
+// tenant, or of the tenants indexed for them.
+func (p *PDFProcessor) jobKeys(ctx context.Context, ids []string) ([]string, error) {
+	keys := make([]string, len(ids))
+	if access, ok := jobAccessOf(ctx); ok && access.TenantID != "" {
+		for i, id := range ids {
+			keys[i] = jobKey(access.TenantID, id)
+		}
+		return keys, nil
+	}
+
+	pipe := p.redis.Client().Pipeline()
+	tenants := make([]*redis.StringCmd, len(ids))
+	for i, id := range ids {
+		tenants[i] = pipe.Get(ctx, jobTenantKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to load job tenants: %w", err)
+	}
+	for i, id := range ids {
+		tenantID, err := tenants[i].Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, fmt.Errorf("failed to load tenant of job %s: %w", id, err)
+		}
+		keys[i] = jobKey(tenantID, id)
+	}
+	return keys, nil
+}
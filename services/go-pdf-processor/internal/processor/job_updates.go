@@ -0,0 +1,112 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Changes of a job's status and progress are published on a Redis channel
+// of the job, so clients following it see them as they happen, whichever
+// replica runs the job and whichever serves the client.
+
+// JobUpdate is a change of a job's status or progress.
+type JobUpdate struct {
+	JobID       string       `json:"job_id"`
+	Status      string       `json:"status"`
+	Progress    *JobProgress `json:"progress,omitempty"`
+	Error       string       `json:"error,omitempty"`
+	ErrorCode   string       `json:"error_code,omitempty"`
+	NextRetryAt *time.Time   `json:"next_retry_at,omitempty"`
+}
+
+func jobUpdatesChannel(id string) string {
+	return fmt.Sprintf("job-updates:%s", id)
+}
+
+func jobUpdateOf(job *ProcessingJob) JobUpdate {
+	return JobUpdate{
+		JobID:       job.ID,
+		Status:      job.Status,
+		Error:       job.Error,
+		ErrorCode:   job.ErrorCode,
+		NextRetryAt: job.NextRetryAt,
+	}
+}
+
+// publishUpdate tells the job's followers about the update. Failures are
+// logged; followers still see the final status.
+func (p *PDFProcessor) publishUpdate(ctx context.Context, update JobUpdate) {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return
+	}
+	if err := p.redis.Client().Publish(ctx, jobUpdatesChannel(update.JobID), data).Err(); err != nil && ctx.Err() == nil {
+		log.Printf("Failed to publish update of job %s: %v", update.JobID, err)
+	}
+}
+
+// WatchJob follows a job's updates, starting with its current state. The
+// channel is closed once the job has finished or ctx is done.
+func (p *PDFProcessor) WatchJob(ctx context.Context, id string) (<-chan JobUpdate, error) {
+	// Subscribing before reading the job leaves no gap for updates to fall
+	// into
+	sub := p.redis.Client().Subscribe(ctx, jobUpdatesChannel(id))
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, fmt.Errorf("failed to follow job %s: %w", id, err)
+	}
+
+	job, err := p.GetJob(ctx, id)
+	if err != nil {
+		sub.Close()
+		return nil, err
+	}
+	current := jobUpdateOf(job)
+	if job.Status == "processing" {
+		if current.Progress, err = p.JobProgress(ctx, id); err != nil {
+			log.Printf("Failed to load progress of job %s: %v", id, err)
+		}
+	}
+
+	updates := make(chan JobUpdate)
+	go func() {
+		defer close(updates)
+		defer sub.Close()
+
+		send := func(update JobUpdate) bool {
+			select {
+			case updates <- update:
+				return !isFinished(update.Status)
+			case <-ctx.Done():
+				return false
+			}
+		}
+		if !send(current) {
+			return
+		}
+
+		messages := sub.Channel()
+		for {
+			select {
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var update JobUpdate
+				if err := json.Unmarshal([]byte(msg.Payload), &update); err != nil {
+					log.Printf("Dropping undecodable update of job %s: %v", id, err)
+					continue
+				}
+				if !send(update) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return updates, nil
+}
@@ -0,0 +1,381 @@
+package processor
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Layout analysis rebuilds a page's text in reading order from the
+// positioned words of its content stream: lines are split where words are a
+// column gap apart, text columns are found from the gutters between them and
+// read one after the other, and lines spanning the columns, such as titles,
+// separate the regions read this way.
+
+// Layout thresholds, relative to the text height.
+const (
+	// minGutterWidth is the narrowest gap taken as a gutter between columns.
+	minGutterWidth = 1.0
+	// paragraphGap is the vertical gap between lines from which they are in
+	// separate blocks.
+	paragraphGap = 0.8
+	// headingSize is the size, relative to the page's body text, from which
+	// a short block is a heading.
+	headingSize = 1.15
+)
+
+// minColumnLines and minColumnWords tell text columns from table columns: a
+// column holds several lines of running text, not a few words each.
+const (
+	minColumnLines = 5
+	minColumnWords = 4
+)
+
+// Block types
+const (
+	BlockHeading   = "heading"
+	BlockParagraph = "paragraph"
+	BlockList      = "list"
+)
+
+// TextBlock is a heading, paragraph or list item of a page, in reading
+// order. Column is the 1-based text column, or 0 for text spanning the page.
+// StartPos and EndPos locate it in the extracted text, or, for streamed
+// documents, in the page texts joined by newlines.
+type TextBlock struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Page     int    `json:"page"`
+	Column   int    `json:"column,omitempty"`
+	StartPos int    `json:"start_pos"`
+	EndPos   int    `json:"end_pos"`
+}
+
+// listMarker matches the bullets and numbering list items start with.
+var listMarker = regexp.MustCompile(`^(?:[-•*–▪◦]|\d{1,3}[.)]|[a-z][.)]|[IVX]{1,5}\s*[.)–-])\s`)
+
+// textFragment is the part of a line between column gaps.
+type textFragment struct {
+	x0, y0, x1, y1 float64
+	row            int
+	column         int // 1-based, 0 when spanning the gutters
+	words          []*layoutWord
+}
+
+func (f *textFragment) text() string {
+	texts := make([]string, len(f.words))
+	for i, w := range f.words {
+		texts[i] = w.text
+	}
+	return strings.Join(texts, " ")
+}
+
+func (f *textFragment) height() float64 { return f.y1 - f.y0 }
+
+func (f *textFragment) bold() bool {
+	for _, w := range f.words {
+		if !w.bold {
+			return false
+		}
+	}
+	return true
+}
+
+// pageLayout is the lines of a page in reading order.
+type pageLayout struct {
+	lines    []*textLine
+	columns  int
+	bodySize float64
+}
+
+func analyzeLayout(words []*layoutWord) *pageLayout {
+	fragments := splitFragments(textRows(words))
+	if len(fragments) == 0 {
+		return &pageLayout{}
+	}
+	gutters := findGutters(fragments)
+	assignColumns(fragments, gutters)
+	return &pageLayout{
+		lines:    readingOrder(fragments),
+		columns:  len(gutters) + 1,
+		bodySize: bodyTextHeight(fragments),
+	}
+}
+
+// words returns the page's words in reading order.
+func (l *pageLayout) words() []*layoutWord {
+	var words []*layoutWord
+	for _, line := range l.lines {
+		for _, f := range line.fragments {
+			words = append(words, f.words...)
+		}
+	}
+	return words
+}
+
+// text returns the page's text and its blocks, with positions relative to
+// the page text. Blocks are separated by blank lines.
+func (l *pageLayout) text(page int) (string, []TextBlock) {
+	var text strings.Builder
+	var blocks []TextBlock
+	var block []*textLine
+	flush := func() {
+		if len(block) == 0 {
+			return
+		}
+		if text.Len() > 0 {
+			text.WriteString("\n\n")
+		}
+		texts := make([]string, len(block))
+		for i, line := range block {
+			texts[i] = line.text
+		}
+		blockText := strings.Join(texts, "\n")
+		start := text.Len()
+		text.WriteString(blockText)
+		blocks = append(blocks, TextBlock{
+			Type:     blockType(block, l.bodySize),
+			Text:     blockText,
+			Page:     page,
+			Column:   block[0].column,
+			StartPos: start,
+			EndPos:   text.Len(),
+		})
+		block = nil
+	}
+
+	for _, line := range l.lines {
+		if n := len(block); n > 0 && startsBlock(block[n-1], line) {
+			flush()
+		}
+		block = append(block, line)
+	}
+	flush()
+	return text.String(), blocks
+}
+
+// splitFragments splits each line where words are a column gap apart.
+func splitFragments(rows [][]*layoutWord) []*textFragment {
+	var fragments []*textFragment
+	for r, row := range rows {
+		var current *textFragment
+		for _, w := range row {
+			if current != nil && w.x0-current.x1 < columnGap*w.height() {
+				current.words = append(current.words, w)
+				current.x1 = max(current.x1, w.x1)
+				current.y0, current.y1 = min(current.y0, w.y0), max(current.y1, w.y1)
+				continue
+			}
+			current = &textFragment{x0: w.x0, y0: w.y0, x1: w.x1, y1: w.y1, row: r, words: []*layoutWord{w}}
+			fragments = append(fragments, current)
+		}
+	}
+	return fragments
+}
+
+// findGutters returns the x positions of the gutters between text columns:
+// vertical strips no line crosses, besides a few spanning ones, with running
+// text on both sides.
+func findGutters(fragments []*textFragment) []float64 {
+	left, right := fragments[0].x0, fragments[0].x1
+	for _, f := range fragments {
+		left, right = min(left, f.x0), max(right, f.x1)
+	}
+	width := int(right-left) + 1
+	coverage := make([]int, width)
+	for _, f := range fragments {
+		for x := int(f.x0 - left); x < int(f.x1-left) && x < width; x++ {
+			coverage[x]++
+		}
+	}
+
+	crossing := max(1, len(fragments)/20)
+	minWidth := int(minGutterWidth*medianHeight(fragments)) + 1
+	var gutters []float64
+	lastBoundary := left
+	for x := 0; x < width; {
+		if coverage[x] > crossing {
+			x++
+			continue
+		}
+		start := x
+		for x < width && coverage[x] <= crossing {
+			x++
+		}
+		if start == 0 || x == width || x-start < minWidth {
+			continue
+		}
+		center := left + float64(start+x)/2
+		if isTextColumn(fragments, lastBoundary, center) && isTextColumn(fragments, center, right) {
+			gutters = append(gutters, center)
+			lastBoundary = center
+		}
+	}
+	return gutters
+}
+
+func isTextColumn(fragments []*textFragment, from, to float64) bool {
+	var wordCounts []int
+	for _, f := range fragments {
+		if f.x0 >= from && f.x1 <= to {
+			wordCounts = append(wordCounts, len(f.words))
+		}
+	}
+	if len(wordCounts) < minColumnLines {
+		return false
+	}
+	sort.Ints(wordCounts)
+	return wordCounts[len(wordCounts)/2] >= minColumnWords
+}
+
+func assignColumns(fragments []*textFragment, gutters []float64) {
+	if len(gutters) == 0 {
+		return
+	}
+	for _, f := range fragments {
+		first := sort.SearchFloat64s(gutters, f.x0)
+		last := sort.SearchFloat64s(gutters, f.x1)
+		if first == last {
+			f.column = first + 1
+		}
+	}
+}
+
+// textLine is the fragments of a row within one column, tab-separated.
+type textLine struct {
+	text           string
+	column         int
+	y0, y1, height float64
+	bold           bool
+	fragments      []*textFragment
+}
+
+// readingOrder lists the lines column by column within each region between
+// spanning lines.
+func readingOrder(fragments []*textFragment) []*textLine {
+	var lines []*textLine
+	region := make(map[int][]*textFragment)
+	var columns []int
+	inColumns := false
+	flush := func() {
+		sort.Ints(columns)
+		for _, column := range columns {
+			lines = append(lines, joinRows(region[column])...)
+		}
+		region = make(map[int][]*textFragment)
+		columns = nil
+		inColumns = false
+	}
+
+	for _, f := range fragments {
+		if f.column == 0 && inColumns {
+			flush()
+		}
+		inColumns = inColumns || f.column > 0
+		if _, ok := region[f.column]; !ok {
+			columns = append(columns, f.column)
+		}
+		region[f.column] = append(region[f.column], f)
+	}
+	flush()
+	return lines
+}
+
+// joinRows joins the fragments of each row, which come ordered by row and
+// position.
+func joinRows(fragments []*textFragment) []*textLine {
+	var lines []*textLine
+	lastRow := -1
+	for _, f := range fragments {
+		if n := len(lines); n > 0 && f.row == lastRow {
+			line := lines[n-1]
+			line.text += "\t" + f.text()
+			line.y0, line.y1 = min(line.y0, f.y0), max(line.y1, f.y1)
+			line.height = max(line.height, f.height())
+			line.bold = line.bold && f.bold()
+			line.fragments = append(line.fragments, f)
+			continue
+		}
+		lines = append(lines, &textLine{
+			text:      f.text(),
+			column:    f.column,
+			y0:        f.y0,
+			y1:        f.y1,
+			height:    f.height(),
+			bold:      f.bold(),
+			fragments: []*textFragment{f},
+		})
+		lastRow = f.row
+	}
+	return lines
+}
+
+// startsBlock reports whether line begins a new block after prev: on a
+// column change, a paragraph gap, a change of size or weight, or a list
+// item.
+func startsBlock(prev, line *textLine) bool {
+	height := max(prev.height, line.height)
+	switch {
+	case line.column != prev.column:
+		return true
+	case line.y0-prev.y1 > paragraphGap*height || line.y0 < prev.y0:
+		return true
+	case line.height > prev.height*headingSize || prev.height > line.height*headingSize:
+		return true
+	case line.bold != prev.bold:
+		return true
+	}
+	return listMarker.MatchString(line.text)
+}
+
+func blockType(block []*textLine, bodySize float64) string {
+	first := block[0]
+	if listMarker.MatchString(first.text) {
+		return BlockList
+	}
+	if len(block) <= 2 && len(first.text) <= maxHeadingLength {
+		if first.height >= bodySize*headingSize || first.bold || isUpperText(first.text) {
+			return BlockHeading
+		}
+	}
+	return BlockParagraph
+}
+
+func isUpperText(s string) bool {
+	letters := false
+	for _, r := range s {
+		if unicode.IsLower(r) {
+			return false
+		}
+		letters = letters || unicode.IsLetter(r)
+	}
+	return letters
+}
+
+// bodyTextHeight is the height of most of the page's text.
+func bodyTextHeight(fragments []*textFragment) float64 {
+	chars := make(map[float64]int)
+	for _, f := range fragments {
+		for _, w := range f.words {
+			chars[math.Round(w.height())] += len(w.text)
+		}
+	}
+	body, most := 0.0, -1
+	for height, n := range chars {
+		if n > most || (n == most && height < body) {
+			body, most = height, n
+		}
+	}
+	return body
+}
+
+func medianHeight(fragments []*textFragment) float64 {
+	heights := make([]float64, len(fragments))
+	for i, f := range fragments {
+		heights[i] = f.height()
+	}
+	sort.Float64s(heights)
+	return heights[len(heights)/2]
+}
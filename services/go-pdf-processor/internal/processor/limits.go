@@ -0,0 +1,57 @@
+package processor
+
+import (
+	"fmt"
+	"mime"
+)
+
+// genericContentTypes say nothing about the file, so they are not checked
+// against the allowed types; format detection decides instead.
+var genericContentTypes = map[string]bool{
+	"":                         true,
+	"application/octet-stream": true,
+	"binary/octet-stream":      true,
+}
+
+// checkFileLimits rejects files that exceed MaxFileSize or whose content type
+// is not in AllowedTypes. A size of -1 means the size is not known yet.
+func (p *PDFProcessor) checkFileLimits(size int64, contentType string) error {
+	if p.cfg.MaxFileSize > 0 && size > p.cfg.MaxFileSize {
+		return &ProcessingError{
+			Code: ErrCodeFileTooLarge,
+			Err:  fmt.Errorf("file size %d exceeds maximum of %d bytes", size, p.cfg.MaxFileSize),
+		}
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+	if genericContentTypes[mediaType] || len(p.cfg.AllowedTypes) == 0 {
+		return nil
+	}
+	for _, allowed := range p.cfg.AllowedTypes {
+		if mediaType == allowed {
+			return nil
+		}
+	}
+	return &ProcessingError{
+		Code: ErrCodeUnsupportedType,
+		Err:  fmt.Errorf("file type %q is not allowed", mediaType),
+	}
+}
+
+// checkJobLimits validates what the job declares about its file so oversized
+// or disallowed files are rejected before they are downloaded.
+func (p *PDFProcessor) checkJobLimits(job *ProcessingJob) error {
+	size := int64(-1)
+	switch v := job.Metadata["file_size"].(type) {
+	case int64:
+		size = v
+	case float64: // numbers decoded from Redis
+		size = int64(v)
+	}
+
+	contentType, _ := job.Metadata["content_type"].(string)
+	return p.checkFileLimits(size, contentType)
+}
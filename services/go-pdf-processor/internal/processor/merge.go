@@ -0,0 +1,151 @@
+package processor
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Mixed PDFs carry a text layer on most pages and scanned annexes on others,
+// so OCR is run on the pages whose own text is missing or garbled, and each
+// of those pages takes whichever text, native or recognized, reads better.
+// Page boundaries are kept, so the merged text still has page offsets.
+
+const (
+	// minPageTextScore is the page text score, about ten words, below which
+	// a page is recognized.
+	minPageTextScore = 50
+
+	// ocrPreference is how much better than the native text of a page its
+	// recognized text must score to replace it, since native text is exact
+	// when it is readable at all.
+	ocrPreference = 1.1
+)
+
+// pageTextScore rates how readable a text is: the letters in words of two
+// letters or more, less a penalty for characters that come from broken font
+// encodings.
+func pageTextScore(text string) int {
+	score, word := 0, 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			word++
+			continue
+		}
+		if word > 1 {
+			score += word
+		}
+		word = 0
+		if isGarbledRune(r) {
+			score -= 3
+		}
+	}
+	if word > 1 {
+		score += word
+	}
+	return score
+}
+
+func isGarbledRune(r rune) bool {
+	switch r {
+	case utf8.RuneError, '□', '◯', '●':
+		return true
+	case '\n', '\r', '\t':
+		return false
+	}
+	return unicode.Is(unicode.Co, r) || unicode.IsControl(r)
+}
+
+// pageText returns page i of a text split at offsets.
+func pageText(text string, offsets []int, i int) string {
+	end := len(text)
+	if i+1 < len(offsets) {
+		end = offsets[i+1]
+	}
+	return text[offsets[i]:end]
+}
+
+// pagesNeedingOCR returns the pages, numbered from 1, whose native text
+// needs OCR, and whether any does. Without page offsets the document is
+// judged as a whole, and all of it is recognized: the pages are nil.
+func (p *PDFProcessor) pagesNeedingOCR(content *documentContent) ([]int, bool) {
+	if len(content.PageOffsets) == 0 {
+		return nil, len(content.Text) < 100 || p.hasLowTextQuality(content.Text)
+	}
+
+	var pages []int
+	for i := range content.PageOffsets {
+		if pageTextScore(pageText(content.Text, content.PageOffsets, i)) < minPageTextScore {
+			pages = append(pages, i+1)
+		}
+	}
+	if len(pages) == len(content.PageOffsets) {
+		return nil, true
+	}
+	return pages, len(pages) > 0
+}
+
+// mergedText is a document's text with some pages replaced by their OCR.
+type mergedText struct {
+	text    string
+	offsets []int
+	words   []wordBox // of the recognized pages
+	pages   []ocrPage // the recognized pages used
+}
+
+// mergePageTexts replaces the native text of each recognized page with its
+// OCR when that reads better. Without native page offsets the whole OCR
+// text replaces the native one when it reads better. pages is empty when
+// the native text is kept throughout, which is then returned unchanged.
+func mergePageTexts(native *documentContent, ocr *ocrResult) mergedText {
+	if len(native.PageOffsets) == 0 {
+		if float64(pageTextScore(ocr.Text)) > float64(pageTextScore(native.Text))*ocrPreference {
+			return mergedText{text: ocr.Text, offsets: ocr.PageOffsets, words: ocr.Words, pages: ocr.Pages}
+		}
+		return mergedText{text: native.Text, offsets: native.PageOffsets}
+	}
+
+	recognized := make(map[int]int, len(ocr.Pages))
+	for i, page := range ocr.Pages {
+		recognized[page.number] = i
+	}
+
+	var b strings.Builder
+	merged := mergedText{offsets: make([]int, len(native.PageOffsets))}
+	for i := range native.PageOffsets {
+		merged.offsets[i] = b.Len()
+		nativePage := pageText(native.Text, native.PageOffsets, i)
+
+		j, ok := recognized[i+1]
+		if !ok {
+			b.WriteString(nativePage)
+			continue
+		}
+		ocrStart := ocr.PageOffsets[j]
+		ocrPage := strings.TrimRightFunc(pageText(ocr.Text, ocr.PageOffsets, j), unicode.IsSpace)
+		if float64(pageTextScore(ocrPage)) <= float64(pageTextScore(nativePage))*ocrPreference {
+			b.WriteString(nativePage)
+			continue
+		}
+
+		// The recognized page keeps the native page's line breaks after it
+		offset := b.Len()
+		b.WriteString(ocrPage)
+		b.WriteString(nativePage[len(strings.TrimRightFunc(nativePage, unicode.IsSpace)):])
+		for _, word := range ocr.Words {
+			if word.Start < ocrStart || word.End > ocrStart+len(ocrPage) {
+				continue
+			}
+			word.Start += offset - ocrStart
+			word.End += offset - ocrStart
+			merged.words = append(merged.words, word)
+		}
+		merged.pages = append(merged.pages, ocr.Pages[j])
+	}
+
+	if len(merged.pages) == 0 {
+		return mergedText{text: native.Text, offsets: native.PageOffsets}
+	}
+	merged.text = b.String()
+	return merged
+}
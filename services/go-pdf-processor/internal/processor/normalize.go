@@ -0,0 +1,104 @@
+package processor
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NormalizedValue is the machine-readable form of an entity's value.
+type NormalizedValue struct {
+	Date  string `json:"date,omitempty"`  // ISO 8601 (YYYY-MM-DD)
+	Cents *int64 `json:"cents,omitempty"` // currency amount in centavos
+}
+
+var portugueseMonths = map[string]time.Month{
+	"janeiro":   time.January,
+	"fevereiro": time.February,
+	"março":     time.March,
+	"marco":     time.March,
+	"abril":     time.April,
+	"maio":      time.May,
+	"junho":     time.June,
+	"julho":     time.July,
+	"agosto":    time.August,
+	"setembro":  time.September,
+	"outubro":   time.October,
+	"novembro":  time.November,
+	"dezembro":  time.December,
+}
+
+var (
+	numericDateRe = regexp.MustCompile(`^(\d{1,2})/(\d{1,2})/(\d{4})$`)
+	longDateRe    = regexp.MustCompile(`(?i)^(\d{1,2})º?\s+de\s+(\pL+)\s+de\s+(\d{4})$`)
+)
+
+// normalizeEntities fills in the normalized value of dates and currency
+// amounts. Values that cannot be interpreted are left raw only.
+func normalizeEntities(entities []ExtractedEntity) {
+	for i := range entities {
+		entity := &entities[i]
+		switch entity.Type {
+		case "DATE":
+			if date, ok := normalizeDate(entity.Value); ok {
+				entity.Normalized = &NormalizedValue{Date: date}
+			}
+		case "CURRENCY":
+			if cents, ok := normalizeCurrency(entity.Value); ok {
+				entity.Normalized = &NormalizedValue{Cents: &cents}
+			}
+		}
+	}
+}
+
+// normalizeDate converts "15/03/2024" and "15 de março de 2024" to
+// "2024-03-15", rejecting dates that do not exist.
+func normalizeDate(value string) (string, bool) {
+	var day, year int
+	var month time.Month
+
+	if m := numericDateRe.FindStringSubmatch(value); m != nil {
+		day, _ = strconv.Atoi(m[1])
+		monthNum, _ := strconv.Atoi(m[2])
+		month = time.Month(monthNum)
+		year, _ = strconv.Atoi(m[3])
+	} else if m := longDateRe.FindStringSubmatch(value); m != nil {
+		var ok bool
+		if month, ok = portugueseMonths[strings.ToLower(m[2])]; !ok {
+			return "", false
+		}
+		day, _ = strconv.Atoi(m[1])
+		year, _ = strconv.Atoi(m[3])
+	} else {
+		return "", false
+	}
+
+	date := fmt.Sprintf("%04d-%02d-%02d", year, month, day)
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return "", false
+	}
+	return date, true
+}
+
+// normalizeCurrency converts "R$ 1.234.567,89" to 123456789 centavos. Dots
+// group thousands and the comma separates centavos.
+func normalizeCurrency(value string) (int64, bool) {
+	amount := strings.TrimSpace(strings.TrimPrefix(value, "R$"))
+	amount = strings.ReplaceAll(amount, ".", "")
+
+	reais, centavos, hasCents := strings.Cut(amount, ",")
+	if !hasCents {
+		centavos = "00"
+	}
+	if reais == "" || len(centavos) != 2 {
+		return 0, false
+	}
+
+	cents, err := strconv.ParseInt(reais+centavos, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return cents, true
+}
@@ -0,0 +1,310 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"image"
+	"log"
+	"os"
+	"strings"
+
+	"cotai-pdf-processor/internal/report"
+)
+
+// The OCR layout keeps what the OCR engine saw on each page of a scan: the
+// words with their boxes, in pixels of the page image, and confidences. It
+// is stored as hOCR, ALTO or Tesseract's TSV, so highlighting, table
+// extraction and redaction tools downstream can work on the scan itself.
+
+// OCR layout formats
+const (
+	OCRLayoutHOCR = "hocr"
+	OCRLayoutALTO = "alto"
+	OCRLayoutTSV  = "tsv"
+)
+
+var ErrOCRLayoutNotAvailable = errors.New("job has no OCR layout")
+
+var ocrLayoutContentTypes = map[string]string{
+	OCRLayoutHOCR: "text/html; charset=utf-8",
+	OCRLayoutALTO: "application/xml",
+	OCRLayoutTSV:  "text/tab-separated-values; charset=utf-8",
+}
+
+// layoutPage is a page image with its recognized words grouped in lines.
+type layoutPage struct {
+	number int
+	width  int
+	height int
+	lines  [][]layoutEntry
+}
+
+type layoutEntry struct {
+	text       string
+	box        BoundingBox
+	confidence float64
+}
+
+// attachOCRLayout stores the words recognized on the pages in the given
+// format. Failures are logged and leave the result without it.
+func (p *PDFProcessor) attachOCRLayout(ctx context.Context, jobID string, result *ProcessingResult, format string, pages []ocrPage, words []wordBox) {
+	ctx, span := p.tracer.Start(ctx, "generate_ocr_layout")
+	defer span.End()
+
+	if p.reports == nil {
+		log.Printf("OCR layout requested for job %s but report storage is not configured", jobID)
+		return
+	}
+	contentType, ok := ocrLayoutContentTypes[format]
+	if !ok {
+		log.Printf("Unknown OCR layout format %q for job %s", format, jobID)
+		return
+	}
+
+	layout := ocrLayoutPages(result.ExtractedText, pages, words)
+	var data []byte
+	var err error
+	switch format {
+	case OCRLayoutHOCR:
+		data = hocrLayout(layout)
+	case OCRLayoutALTO:
+		data, err = altoLayout(layout)
+	case OCRLayoutTSV:
+		data = tsvLayout(layout)
+	}
+	if err != nil {
+		log.Printf("Failed to build OCR layout for job %s: %v", jobID, err)
+		return
+	}
+
+	artifact, err := p.reports.PublishOCRLayout(ctx, jobID, format, contentType, data)
+	if err != nil {
+		log.Printf("Failed to publish OCR layout for job %s: %v", jobID, err)
+		return
+	}
+	result.OCRLayout = artifact
+}
+
+// ocrLayoutPages groups the words of each page in lines. Words come in
+// reading order, so a word starting left of the previous one, or below it,
+// starts a new line.
+func ocrLayoutPages(text string, pages []ocrPage, words []wordBox) []layoutPage {
+	layout := make([]layoutPage, 0, len(pages))
+	for _, page := range pages {
+		lp := layoutPage{number: page.number}
+		lp.width, lp.height = imageDimensions(page.path)
+
+		var line []layoutEntry
+		for _, word := range words {
+			if word.Box.Page != page.number || word.Start < 0 || word.End > len(text) {
+				continue
+			}
+			if n := len(line); n > 0 {
+				prev := line[n-1].box
+				if word.Box.X < prev.X || word.Box.Y >= prev.Y+prev.Height {
+					lp.lines = append(lp.lines, line)
+					line = nil
+				}
+			}
+			line = append(line, layoutEntry{
+				text:       text[word.Start:word.End],
+				box:        word.Box,
+				confidence: word.Confidence,
+			})
+			lp.width = max(lp.width, word.Box.X+word.Box.Width)
+			lp.height = max(lp.height, word.Box.Y+word.Box.Height)
+		}
+		if len(line) > 0 {
+			lp.lines = append(lp.lines, line)
+		}
+		layout = append(layout, lp)
+	}
+	return layout
+}
+
+// imageDimensions returns the size of a page image, or zero when it cannot
+// be read, in which case the words' extent is used.
+func imageDimensions(path string) (int, int) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0
+	}
+	return cfg.Width, cfg.Height
+}
+
+// lineBox is the union of the boxes of a line's words.
+func lineBox(line []layoutEntry) (x0, y0, x1, y1 int) {
+	x0, y0 = line[0].box.X, line[0].box.Y
+	x1, y1 = x0+line[0].box.Width, y0+line[0].box.Height
+	for _, word := range line[1:] {
+		x0, y0 = min(x0, word.box.X), min(y0, word.box.Y)
+		x1, y1 = max(x1, word.box.X+word.box.Width), max(y1, word.box.Y+word.box.Height)
+	}
+	return x0, y0, x1, y1
+}
+
+// hocrLayout renders the pages as hOCR 1.2, with x_wconf from 0 to 100.
+func hocrLayout(pages []layoutPage) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head>
+<meta charset="utf-8"/>
+<meta name="ocr-system" content="cotai-pdf-processor"/>
+<meta name="ocr-capabilities" content="ocr_page ocr_line ocrx_word"/>
+<title></title>
+</head>
+<body>
+`)
+	for _, page := range pages {
+		fmt.Fprintf(&b, "<div class=\"ocr_page\" id=\"page_%d\" title=\"bbox 0 0 %d %d; ppageno %d\">\n",
+			page.number, page.width, page.height, page.number-1)
+		for l, line := range page.lines {
+			x0, y0, x1, y1 := lineBox(line)
+			fmt.Fprintf(&b, " <span class=\"ocr_line\" id=\"line_%d_%d\" title=\"bbox %d %d %d %d\">",
+				page.number, l+1, x0, y0, x1, y1)
+			for w, word := range line {
+				if w > 0 {
+					b.WriteByte(' ')
+				}
+				fmt.Fprintf(&b, "<span class=\"ocrx_word\" id=\"word_%d_%d_%d\" title=\"bbox %d %d %d %d; x_wconf %.0f\">%s</span>",
+					page.number, l+1, w+1, word.box.X, word.box.Y, word.box.X+word.box.Width, word.box.Y+word.box.Height,
+					word.confidence, html.EscapeString(word.text))
+			}
+			b.WriteString("</span>\n")
+		}
+		b.WriteString("</div>\n")
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.Bytes()
+}
+
+// ALTO v4 elements; WC is the word confidence from 0 to 1.
+type altoDocument struct {
+	XMLName     xml.Name   `xml:"alto"`
+	Namespace   string     `xml:"xmlns,attr"`
+	Description altoDesc   `xml:"Description"`
+	Pages       []altoPage `xml:"Layout>Page"`
+}
+
+type altoDesc struct {
+	MeasurementUnit string `xml:"MeasurementUnit"`
+}
+
+type altoPage struct {
+	ID         string     `xml:"ID,attr"`
+	PhysicalNr int        `xml:"PHYSICAL_IMG_NR,attr"`
+	Width      int        `xml:"WIDTH,attr"`
+	Height     int        `xml:"HEIGHT,attr"`
+	Lines      []altoLine `xml:"PrintSpace>TextBlock>TextLine"`
+}
+
+type altoLine struct {
+	ID      string       `xml:"ID,attr"`
+	HPos    int          `xml:"HPOS,attr"`
+	VPos    int          `xml:"VPOS,attr"`
+	Width   int          `xml:"WIDTH,attr"`
+	Height  int          `xml:"HEIGHT,attr"`
+	Strings []altoString `xml:"String"`
+}
+
+type altoString struct {
+	ID      string `xml:"ID,attr"`
+	Content string `xml:"CONTENT,attr"`
+	HPos    int    `xml:"HPOS,attr"`
+	VPos    int    `xml:"VPOS,attr"`
+	Width   int    `xml:"WIDTH,attr"`
+	Height  int    `xml:"HEIGHT,attr"`
+	WC      string `xml:"WC,attr"`
+}
+
+// altoLayout renders the pages as ALTO v4, measured in pixels. All of a
+// page's lines go in a single text block.
+func altoLayout(pages []layoutPage) ([]byte, error) {
+	doc := altoDocument{
+		Namespace:   "http://www.loc.gov/standards/alto/ns-v4#",
+		Description: altoDesc{MeasurementUnit: "pixel"},
+	}
+	for _, page := range pages {
+		ap := altoPage{
+			ID:         fmt.Sprintf("page_%d", page.number),
+			PhysicalNr: page.number,
+			Width:      page.width,
+			Height:     page.height,
+		}
+		for l, line := range page.lines {
+			x0, y0, x1, y1 := lineBox(line)
+			al := altoLine{
+				ID:   fmt.Sprintf("line_%d_%d", page.number, l+1),
+				HPos: x0, VPos: y0, Width: x1 - x0, Height: y1 - y0,
+			}
+			for w, word := range line {
+				al.Strings = append(al.Strings, altoString{
+					ID:      fmt.Sprintf("word_%d_%d_%d", page.number, l+1, w+1),
+					Content: word.text,
+					HPos:    word.box.X,
+					VPos:    word.box.Y,
+					Width:   word.box.Width,
+					Height:  word.box.Height,
+					WC:      fmt.Sprintf("%.2f", word.confidence/100),
+				})
+			}
+			ap.Lines = append(ap.Lines, al)
+		}
+		doc.Pages = append(doc.Pages, ap)
+	}
+
+	data, err := xml.MarshalIndent(doc, "", " ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// tsvLayout renders the pages in the columns of Tesseract's TSV output,
+// with a page row, one block and paragraph per page, and the line and word
+// rows; conf is -1 on rows other than words.
+func tsvLayout(pages []layoutPage) []byte {
+	var b bytes.Buffer
+	b.WriteString("level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n")
+	for _, page := range pages {
+		fmt.Fprintf(&b, "1\t%d\t0\t0\t0\t0\t0\t0\t%d\t%d\t-1\t\n", page.number, page.width, page.height)
+		for l, line := range page.lines {
+			x0, y0, x1, y1 := lineBox(line)
+			fmt.Fprintf(&b, "4\t%d\t1\t1\t%d\t0\t%d\t%d\t%d\t%d\t-1\t\n", page.number, l+1, x0, y0, x1-x0, y1-y0)
+			for w, word := range line {
+				text := strings.Map(func(r rune) rune {
+					if r == '\t' || r == '\n' || r == '\r' {
+						return ' '
+					}
+					return r
+				}, word.text)
+				fmt.Fprintf(&b, "5\t%d\t1\t1\t%d\t%d\t%d\t%d\t%d\t%d\t%.2f\t%s\n",
+					page.number, l+1, w+1, word.box.X, word.box.Y, word.box.Width, word.box.Height, word.confidence, text)
+			}
+		}
+	}
+	return b.Bytes()
+}
+
+// OCRLayoutURL returns a fresh download URL for a job's OCR layout.
+func (p *PDFProcessor) OCRLayoutURL(ctx context.Context, jobID string) (*report.Artifact, error) {
+	job, err := p.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if p.reports == nil || job.Result == nil || job.Result.OCRLayout == nil {
+		return nil, ErrOCRLayoutNotAvailable
+	}
+	return p.reports.Sign(ctx, job.Result.OCRLayout.Key)
+}
@@ -0,0 +1,235 @@
+package processor
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Namespaces of the elements we read from DOCX and ODT documents.
+const (
+	wordprocessingNS = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	odfTextNS        = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	odfMetaNS        = "urn:oasis:names:tc:opendocument:xmlns:meta:1.0"
+)
+
+// extractTextFromDOCX reads the text runs of word/document.xml, keeping
+// paragraph, tab and line breaks. The page count comes from docProps/app.xml
+// when Word recorded it, otherwise from explicit page breaks.
+func (p *PDFProcessor) extractTextFromDOCX(ctx context.Context, filePath string) (string, int, error) {
+	ctx, span := p.tracer.Start(ctx, "extract_text_docx")
+	defer span.End()
+
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open DOCX: %w", err)
+	}
+	defer archive.Close()
+
+	document := findZipEntry(archive, "word/document.xml")
+	if document == nil {
+		return "", 0, fmt.Errorf("invalid DOCX: missing word/document.xml")
+	}
+
+	rc, err := document.Open()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read DOCX body: %w", err)
+	}
+	defer rc.Close()
+
+	var text strings.Builder
+	pageBreaks := 0
+	inText := false
+
+	decoder := xml.NewDecoder(rc)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to parse DOCX body: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space != wordprocessingNS {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				text.WriteString("\t")
+			case "br", "cr":
+				if xmlAttr(t, "type") == "page" {
+					pageBreaks++
+				}
+				text.WriteString("\n")
+			case "lastRenderedPageBreak":
+				pageBreaks++
+			}
+		case xml.EndElement:
+			if t.Name.Space != wordprocessingNS {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(t)
+			}
+		}
+	}
+
+	pageCount := pageBreaks + 1
+	if app := findZipEntry(archive, "docProps/app.xml"); app != nil {
+		if pages := readDOCXPageCount(app); pages > 0 {
+			pageCount = pages
+		}
+	}
+
+	return text.String(), pageCount, nil
+}
+
+// extractTextFromODT reads content.xml, turning text:p and text:h into lines
+// and expanding text:s, text:tab and text:line-break.
+func (p *PDFProcessor) extractTextFromODT(ctx context.Context, filePath string) (string, int, error) {
+	ctx, span := p.tracer.Start(ctx, "extract_text_odt")
+	defer span.End()
+
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open ODT: %w", err)
+	}
+	defer archive.Close()
+
+	content := findZipEntry(archive, "content.xml")
+	if content == nil {
+		return "", 0, fmt.Errorf("invalid ODT: missing content.xml")
+	}
+
+	rc, err := content.Open()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read ODT content: %w", err)
+	}
+	defer rc.Close()
+
+	var text strings.Builder
+	depth := 0 // nesting of text:p/text:h, outside of which char data is markup whitespace
+
+	decoder := xml.NewDecoder(rc)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to parse ODT content: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space != odfTextNS {
+				continue
+			}
+			switch t.Name.Local {
+			case "p", "h":
+				depth++
+			case "s":
+				spaces, _ := strconv.Atoi(xmlAttr(t, "c"))
+				if spaces < 1 {
+					spaces = 1
+				}
+				text.WriteString(strings.Repeat(" ", spaces))
+			case "tab":
+				text.WriteString("\t")
+			case "line-break":
+				text.WriteString("\n")
+			}
+		case xml.EndElement:
+			if t.Name.Space == odfTextNS && (t.Name.Local == "p" || t.Name.Local == "h") {
+				depth--
+				text.WriteString("\n")
+			}
+		case xml.CharData:
+			if depth > 0 {
+				text.Write(t)
+			}
+		}
+	}
+
+	pageCount := 1
+	if meta := findZipEntry(archive, "meta.xml"); meta != nil {
+		if pages := readODTPageCount(meta); pages > 0 {
+			pageCount = pages
+		}
+	}
+
+	return text.String(), pageCount, nil
+}
+
+func readDOCXPageCount(entry *zip.File) int {
+	data, err := readZipEntry(entry, 1<<20)
+	if err != nil {
+		return 0
+	}
+
+	var props struct {
+		Pages int `xml:"Pages"`
+	}
+	if err := xml.Unmarshal(data, &props); err != nil {
+		return 0
+	}
+	return props.Pages
+}
+
+func readODTPageCount(entry *zip.File) int {
+	data, err := readZipEntry(entry, 1<<20)
+	if err != nil {
+		return 0
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(string(data)))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return 0
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Space == odfMetaNS && start.Name.Local == "document-statistic" {
+			for _, attr := range start.Attr {
+				if attr.Name.Space == odfMetaNS && attr.Name.Local == "page-count" {
+					pages, _ := strconv.Atoi(attr.Value)
+					return pages
+				}
+			}
+			return 0
+		}
+	}
+}
+
+func findZipEntry(archive *zip.ReadCloser, name string) *zip.File {
+	for _, entry := range archive.File {
+		if entry.Name == name {
+			return entry
+		}
+	}
+	return nil
+}
+
+func xmlAttr(start xml.StartElement, local string) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,147 @@
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scanned annexes are often sideways or upside down, and Tesseract reads
+// garbage from them. Before OCR each page goes through Tesseract's
+// orientation and script detection (OSD), run with the tesseract command
+// since the client library does not expose it, and is turned upright when
+// OSD is confident enough.
+
+const (
+	// minOrientationConfidence is the OSD confidence below which a page is
+	// left as it is; Tesseract reports values around 1 for pages it cannot
+	// tell.
+	minOrientationConfidence = 2.0
+
+	// osdTimeout bounds the orientation detection of one page.
+	osdTimeout = 30 * time.Second
+)
+
+// PageCorrection records how a page image was straightened before OCR:
+// Rotation is the clockwise turn in degrees, a multiple of 90, and Skew the
+// angle, in degrees, its text lines were leveled by.
+type PageCorrection struct {
+	Page     int     `json:"page"`
+	Rotation int     `json:"rotation,omitempty"`
+	Skew     float64 `json:"skew,omitempty"`
+}
+
+// detectOrientation returns the clockwise rotation that turns the image
+// upright and OSD's confidence in it.
+func (p *PDFProcessor) detectOrientation(ctx context.Context, path string) (int, float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, osdTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.cfg.TesseractPath, path, "stdout", "--psm", "0")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return 0, 0, fmt.Errorf("orientation detection failed: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	rotation, confidence := -1, 0.0
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "Rotate":
+			rotation, _ = strconv.Atoi(value)
+		case "Orientation confidence":
+			confidence, _ = strconv.ParseFloat(value, 64)
+		}
+	}
+	if rotation < 0 {
+		return 0, 0, fmt.Errorf("orientation detection returned no rotation")
+	}
+	return rotation % 360, confidence, nil
+}
+
+// orientPage writes an upright copy of the page image into dir when OSD
+// finds it rotated, returning the image to use and the rotation applied.
+// Pages OSD cannot read, such as those with little text, are left as they
+// are.
+func (p *PDFProcessor) orientPage(ctx context.Context, page ocrPage, dir string) (string, int, error) {
+	rotation, confidence, err := p.detectOrientation(ctx, page.path)
+	if err != nil {
+		log.Printf("Orientation of page %d not detected: %v", page.number, err)
+		return page.path, 0, nil
+	}
+	if rotation == 0 || confidence < minOrientationConfidence {
+		return page.path, 0, nil
+	}
+
+	src, err := decodeImageFile(page.path)
+	if err != nil {
+		return "", 0, err
+	}
+	upright := filepath.Join(dir, fmt.Sprintf("upright-%d.png", page.number))
+	if err := writePNG(upright, rotateQuarter(src, rotation)); err != nil {
+		return "", 0, err
+	}
+	return upright, rotation, nil
+}
+
+// rotateQuarter turns the image clockwise by 90, 180 or 270 degrees.
+// Grayscale images, as pages are rendered, stay grayscale.
+func rotateQuarter(src image.Image, degrees int) image.Image {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	size := image.Rect(0, 0, h, w)
+	if degrees == 180 {
+		size = image.Rect(0, 0, w, h)
+	}
+
+	// dest maps a source pixel to its place in the rotated image
+	dest := func(x, y int) (int, int) {
+		switch degrees {
+		case 90:
+			return h - 1 - y, x
+		case 180:
+			return w - 1 - x, h - 1 - y
+		default:
+			return y, w - 1 - x
+		}
+	}
+
+	if gray, ok := src.(*image.Gray); ok && gray.Rect.Min == (image.Point{}) {
+		out := image.NewGray(size)
+		for y := 0; y < h; y++ {
+			row := gray.Pix[y*gray.Stride : y*gray.Stride+w]
+			for x, v := range row {
+				dx, dy := dest(x, y)
+				out.Pix[dy*out.Stride+dx] = v
+			}
+		}
+		return out
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(rgba, rgba.Rect, src, bounds.Min, draw.Src)
+	out := image.NewRGBA(size)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := dest(x, y)
+			copy(out.Pix[dy*out.Stride+dx*4:dy*out.Stride+dx*4+4], rgba.Pix[y*rgba.Stride+x*4:y*rgba.Stride+x*4+4])
+		}
+	}
+	return out
+}
@@ -0,0 +1,244 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tenants can register their own entity patterns (process numbers, edital
+// numbers, state registrations...) which are applied after the built-ins to
+// documents of their jobs.
+
+const (
+	// maxPatternLength bounds the size of a custom regular expression.
+	maxPatternLength = 1024
+
+	// customPatternCacheTTL is how long a tenant's compiled patterns are
+	// reused before being reloaded, so changes made through other
+	// instances are picked up.
+	customPatternCacheTTL = time.Minute
+)
+
+var (
+	ErrPatternNotFound = errors.New("entity pattern not found")
+	ErrInvalidPattern  = errors.New("invalid entity pattern")
+)
+
+// EntityPattern is a tenant-defined regular expression for an entity type.
+type EntityPattern struct {
+	ID         string    `json:"id"`
+	TenantID   string    `json:"tenant_id"`
+	Name       string    `json:"name"`
+	EntityType string    `json:"entity_type"`
+	Pattern    string    `json:"pattern"`
+	Confidence float64   `json:"confidence"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type cachedPatterns struct {
+	patterns []entityPattern
+	loadedAt time.Time
+}
+
+// patternCache holds compiled custom patterns per tenant.
+type patternCache struct {
+	mu      sync.RWMutex
+	tenants map[string]cachedPatterns
+}
+
+func (c *patternCache) get(tenantID string) ([]entityPattern, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cached, ok := c.tenants[tenantID]
+	if !ok || time.Since(cached.loadedAt) > customPatternCacheTTL {
+		return nil, false
+	}
+	return cached.patterns, true
+}
+
+func (c *patternCache) set(tenantID string, patterns []entityPattern) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tenants == nil {
+		c.tenants = make(map[string]cachedPatterns)
+	}
+	c.tenants[tenantID] = cachedPatterns{patterns: patterns, loadedAt: time.Now()}
+}
+
+func (c *patternCache) invalidate(tenantID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.tenants, tenantID)
+}
+
+// validate normalizes the pattern's fields and checks that it compiles.
+func (ep *EntityPattern) validate() error {
+	ep.Name = strings.TrimSpace(ep.Name)
+	ep.EntityType = strings.ToUpper(strings.TrimSpace(ep.EntityType))
+	switch {
+	case ep.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidPattern)
+	case ep.EntityType == "":
+		return fmt.Errorf("%w: entity_type is required", ErrInvalidPattern)
+	case ep.Pattern == "":
+		return fmt.Errorf("%w: pattern is required", ErrInvalidPattern)
+	case len(ep.Pattern) > maxPatternLength:
+		return fmt.Errorf("%w: pattern exceeds %d characters", ErrInvalidPattern, maxPatternLength)
+	}
+	if _, err := regexp.Compile(ep.Pattern); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPattern, err)
+	}
+
+	if ep.Confidence == 0 {
+		ep.Confidence = 0.8
+	}
+	if ep.Confidence < 0 || ep.Confidence > 1 {
+		return fmt.Errorf("%w: confidence must be between 0 and 1", ErrInvalidPattern)
+	}
+	return nil
+}
+
+const entityPatternColumns = `id, tenant_id, name, entity_type, pattern, confidence, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanEntityPattern(row rowScanner) (*EntityPattern, error) {
+	var ep EntityPattern
+	err := row.Scan(&ep.ID, &ep.TenantID, &ep.Name, &ep.EntityType, &ep.Pattern, &ep.Confidence, &ep.CreatedAt, &ep.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPatternNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ep, nil
+}
+
+// ListEntityPatterns returns a tenant's custom patterns, oldest first.
+func (p *PDFProcessor) ListEntityPatterns(ctx context.Context, tenantID string) ([]EntityPattern, error) {
+	rows, err := p.postgres.Query(ctx,
+		`SELECT `+entityPatternColumns+` FROM entity_patterns WHERE tenant_id = $1 ORDER BY created_at`,
+		tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list entity patterns: %w", err)
+	}
+	defer rows.Close()
+
+	patterns := []EntityPattern{}
+	for rows.Next() {
+		ep, err := scanEntityPattern(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read entity pattern: %w", err)
+		}
+		patterns = append(patterns, *ep)
+	}
+	return patterns, rows.Err()
+}
+
+func (p *PDFProcessor) GetEntityPattern(ctx context.Context, tenantID, id string) (*EntityPattern, error) {
+	return scanEntityPattern(p.postgres.QueryRow(ctx,
+		`SELECT `+entityPatternColumns+` FROM entity_patterns WHERE tenant_id = $1 AND id = $2`,
+		tenantID, id))
+}
+
+func (p *PDFProcessor) CreateEntityPattern(ctx context.Context, ep *EntityPattern) error {
+	if err := ep.validate(); err != nil {
+		return err
+	}
+
+	ep.ID = uuid.New().String()
+	ep.CreatedAt = time.Now()
+	ep.UpdatedAt = ep.CreatedAt
+
+	err := p.postgres.Exec(ctx, `
+		INSERT INTO entity_patterns (`+entityPatternColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, ep.ID, ep.TenantID, ep.Name, ep.EntityType, ep.Pattern, ep.Confidence, ep.CreatedAt, ep.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create entity pattern: %w", err)
+	}
+
+	p.patterns.invalidate(ep.TenantID)
+	return nil
+}
+
+func (p *PDFProcessor) UpdateEntityPattern(ctx context.Context, ep *EntityPattern) error {
+	if err := ep.validate(); err != nil {
+		return err
+	}
+
+	ep.UpdatedAt = time.Now()
+	updated, err := scanEntityPattern(p.postgres.QueryRow(ctx, `
+		UPDATE entity_patterns
+		SET name = $3, entity_type = $4, pattern = $5, confidence = $6, updated_at = $7
+		WHERE tenant_id = $1 AND id = $2
+		RETURNING `+entityPatternColumns,
+		ep.TenantID, ep.ID, ep.Name, ep.EntityType, ep.Pattern, ep.Confidence, ep.UpdatedAt))
+	if err != nil {
+		return err
+	}
+
+	*ep = *updated
+	p.patterns.invalidate(ep.TenantID)
+	return nil
+}
+
+func (p *PDFProcessor) DeleteEntityPattern(ctx context.Context, tenantID, id string) error {
+	var deleted string
+	err := p.postgres.QueryRow(ctx,
+		`DELETE FROM entity_patterns WHERE tenant_id = $1 AND id = $2 RETURNING id`,
+		tenantID, id).Scan(&deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPatternNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete entity pattern: %w", err)
+	}
+
+	p.patterns.invalidate(tenantID)
+	return nil
+}
+
+// tenantPatterns returns the compiled custom patterns of a tenant. Failures
+// are logged and yield no patterns, so extraction falls back to built-ins.
+func (p *PDFProcessor) tenantPatterns(ctx context.Context, tenantID string) []entityPattern {
+	if tenantID == "" {
+		return nil
+	}
+	if patterns, ok := p.patterns.get(tenantID); ok {
+		return patterns
+	}
+
+	stored, err := p.ListEntityPatterns(ctx, tenantID)
+	if err != nil {
+		log.Printf("Failed to load entity patterns for tenant %s: %v", tenantID, err)
+		return nil
+	}
+
+	patterns := make([]entityPattern, 0, len(stored))
+	for _, ep := range stored {
+		re, err := regexp.Compile(ep.Pattern)
+		if err != nil {
+			log.Printf("Skipping entity pattern %s of tenant %s: %v", ep.ID, tenantID, err)
+			continue
+		}
+		patterns = append(patterns, entityPattern{entityType: ep.EntityType, re: re, confidence: ep.Confidence})
+	}
+
+	p.patterns.set(tenantID, patterns)
+	return patterns
+}
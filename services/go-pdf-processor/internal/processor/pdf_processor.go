@@ -3,30 +3,73 @@ package processor
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
+	"net/http"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
+	"cotai-pdf-processor/internal/classify"
+	"cotai-pdf-processor/internal/config"
+	"cotai-pdf-processor/internal/download"
+	"cotai-pdf-processor/internal/enrichment"
+	"cotai-pdf-processor/internal/ner"
+	"cotai-pdf-processor/internal/ocr"
+	"cotai-pdf-processor/internal/report"
+	"cotai-pdf-processor/internal/risk"
+	"cotai-pdf-processor/internal/signature"
+	"cotai-pdf-processor/internal/spellcheck"
 	"cotai-pdf-processor/internal/storage"
 
 	"github.com/ledongthuc/pdf"
-	"github.com/otiai10/gosseract/v2"
 	"go.opentelemetry.io/otel/trace"
 )
 
 type PDFProcessor struct {
-	redis    *storage.RedisClient
-	postgres *storage.PostgresClient
-	tracer   trace.Tracer
+	cfg         *config.Config
+	redis       *storage.RedisClient
+	postgres    *storage.PostgresClient
+	downloader  *download.Downloader
+	companies   *enrichment.CNPJClient
+	recognizers *ner.Registry
+	ocrEngines  *ocr.Registry
+	dictionary  *spellcheck.Dictionary
+	classifier  *classify.Classifier
+	riskRules   *risk.Engine
+	reports     *report.Publisher
+	objects     *storage.ObjectStore
+	signatures  *signature.Verifier
+	events      JobEvents
+	tracer      trace.Tracer
+	patterns    patternCache
+
+	// hooks calls the processing hooks (see hooks.go)
+	hooks       *http.Client
+
+	// ocrSlots bounds the pages being recognized across all jobs
+	ocrSlots    chan struct{}
 }
 
 type ProcessingJob struct {
 	ID          string                 `json:"id"`
+	ParentID    string                 `json:"parent_id,omitempty"`
+	ChildIDs    []string               `json:"child_ids,omitempty"`
 	FileURL     string                 `json:"file_url"`
 	TenderID    string                 `json:"tender_id"`
 	UserID      string                 `json:"user_id"`
+	TenantID    string                 `json:"tenant_id,omitempty"`
+	Profile     string                 `json:"profile,omitempty"`
+
+	// Priority is "high", "normal" or "low"; empty is normal
+	Priority    string                 `json:"priority,omitempty"`
+
+	// CallbackURL is posted the job summary once the job finishes, as are
+	// the webhook endpoints of its tenant (see the webhook package)
+	CallbackURL string                 `json:"callback_url,omitempty"`
+
 	Options     ProcessingOptions      `json:"options"`
 	Status      string                 `json:"status"`
 	CreatedAt   time.Time              `json:"created_at"`
@@ -34,7 +77,28 @@ type ProcessingJob struct {
 	CompletedAt *time.Time             `json:"completed_at,omitempty"`
 	Result      *ProcessingResult      `json:"result,omitempty"`
 	Error       string                 `json:"error,omitempty"`
+	ErrorCode   string                 `json:"error_code,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata"`
+
+	// RunAt delays processing: the job is "scheduled" until then
+	RunAt       *time.Time             `json:"run_at,omitempty"`
+
+	// DependsOn holds the job "waiting" until these jobs have completed;
+	// it fails if one of them does not
+	DependsOn   []string               `json:"depends_on,omitempty"`
+
+	// Attempts counts the times processing started; failed attempts are
+	// retried at NextRetryAt while the job is "retrying"
+	Attempts    int                    `json:"attempts,omitempty"`
+	NextRetryAt *time.Time             `json:"next_retry_at,omitempty"`
+
+	// Progress is shown while the job is processing; it is kept apart
+	// from the job status (see JobProgress)
+	Progress    *JobProgress           `json:"progress,omitempty"`
+
+	// children holds jobs created while processing (e.g. archive entries)
+	// for the worker pool to enqueue.
+	children []*ProcessingJob
 }
 
 type ProcessingOptions struct {
@@ -43,8 +107,122 @@ type ProcessingOptions struct {
 	ExtractEntities  bool     `json:"extract_entities"`
 	AnalyzeRisks     bool     `json:"analyze_risks"`
 	GenerateScore    bool     `json:"generate_score"`
+	EnrichEntities   bool     `json:"enrich_entities"`
+	ClassifyDocument bool     `json:"classify_document"`
+	SegmentSections  bool     `json:"segment_sections"`
+	ExtractItems     bool     `json:"extract_items"`
+	DetectTables     bool     `json:"detect_tables"`
+
+	// LayoutText orders PDF text by column and region instead of content
+	// stream order; TextBlocks also returns it as headings, paragraphs and
+	// list items.
+	LayoutText       bool     `json:"layout_text"`
+	TextBlocks       bool     `json:"text_blocks,omitempty"`
+
+	// NERProvider names the NER sidecar (see NER_PROVIDERS) whose entities
+	// are added to the pattern-based ones; empty disables it.
+	NERProvider      string   `json:"ner_provider,omitempty"`
+
+	// RiskProfile names the tenant's risk weighting profile used for the
+	// RiskScore; empty uses the rules' own weights.
+	RiskProfile      string   `json:"risk_profile,omitempty"`
+
+	// GenerateReport renders the results into a PDF summary stored in
+	// object storage (see REPORT_BUCKET).
+	GenerateReport   bool     `json:"generate_report,omitempty"`
+
+	// SearchablePDF stores a copy of OCRed documents with an invisible text
+	// layer over the page images.
+	SearchablePDF    bool     `json:"searchable_pdf,omitempty"`
+
+	// PreprocessImages deskews, despeckles and binarizes page images before
+	// OCR.
+	PreprocessImages bool     `json:"preprocess_images"`
+
+	// AutoRotate turns sideways and upside-down pages upright before OCR,
+	// using Tesseract's orientation detection.
+	AutoRotate       bool     `json:"auto_rotate"`
+
+	// CorrectSpelling fixes words OCR misread, such as "rn" for "m", against
+	// a Portuguese dictionary (see OCR_DICTIONARY_PATH).
+	CorrectSpelling  bool     `json:"correct_spelling"`
+
+	// ReflowText rejoins words hyphenated across lines and joins the lines
+	// of a sentence, so ExtractedText holds continuous sentences.
+	ReflowText       bool     `json:"reflow_text"`
+
+	// OCRParallelism is how many pages are recognized at once; 0 uses
+	// OCR_PAGE_PARALLELISM.
+	OCRParallelism   int      `json:"ocr_parallelism,omitempty"`
+
+	// OCRProvider names the OCR engine, such as "tesseract" or "textract";
+	// empty uses OCR_PROVIDER.
+	OCRProvider      string   `json:"ocr_provider,omitempty"`
+
+	// OCRLayout stores the words recognized on scans with their boxes and
+	// confidences as "hocr", "alto" or "tsv"; empty stores none.
+	OCRLayout        string   `json:"ocr_layout,omitempty"`
+
+	// OCRZones are page regions, given or detected, whose text is read on
+	// its own, such as stamps and tables.
+	OCRZones         []OCRZone `json:"ocr_zones,omitempty"`
+
+	// DetectBarcodes adds the QR codes and barcodes found on the pages to
+	// the entities, as BARCODE entities (see ZBARIMG_PATH).
+	DetectBarcodes   bool     `json:"detect_barcodes,omitempty"`
+
+	// ArchivePDFA stores a PDF/A-2b copy of PDF documents alongside the
+	// report, for long-term retention.
+	ArchivePDFA      bool     `json:"archive_pdfa,omitempty"`
+
+	// RedactPII stores a copy of the document with CPFs, phone numbers and
+	// e-mail addresses removed.
+	RedactPII        bool     `json:"redact_pii,omitempty"`
+
+	// AnnotateRisks stores a copy of PDF documents with the clauses of the
+	// identified risks highlighted and commented.
+	AnnotateRisks    bool     `json:"annotate_risks,omitempty"`
 	MaxPages         int      `json:"max_pages"`
+
+	// DPI is the resolution PDF pages are rendered at for OCR; 300 when
+	// unset.
 	DPI              int      `json:"dpi"`
+
+	// TimeoutSeconds bounds the whole job, up to MAX_JOB_TIMEOUT; 0 uses
+	// JOB_TIMEOUT.
+	TimeoutSeconds   int      `json:"timeout_seconds,omitempty"`
+
+	// StreamPages forces page-by-page processing regardless of page count.
+	StreamPages      bool     `json:"stream_pages,omitempty"`
+
+	// RecoverCorrupted rebuilds damaged cross-reference tables and skips
+	// unreadable pages instead of failing the whole job.
+	RecoverCorrupted bool     `json:"recover_corrupted"`
+
+	// Password decrypts protected PDFs. It is never persisted with the job
+	// status, so it must be sent with the submission that enqueues the job;
+	// it stays in the queue message until the job is handled.
+	Password         string   `json:"password,omitempty"`
+
+	// ExtractAttachments stores the files embedded in PDFs (see
+	// ATTACHMENT_BUCKET); ProcessAttachments also processes each one in a
+	// child job.
+	ExtractAttachments bool `json:"extract_attachments,omitempty"`
+	ProcessAttachments bool `json:"process_attachments,omitempty"`
+
+	// VerifySignatures validates PDF signatures against the ICP-Brasil roots
+	// (see ICP_BRASIL_ROOTS).
+	VerifySignatures bool     `json:"verify_signatures"`
+
+	// Reprocess processes the document even when an identical one was
+	// processed with the same options (see RESULT_CACHE_TTL).
+	Reprocess        bool     `json:"reprocess,omitempty"`
+
+	// Pipeline names the tenant's pipeline, or a built-in one, whose stages
+	// the job runs (see pipelines.go); Stages keeps their order once the
+	// options are resolved, and empty runs the default order.
+	Pipeline         string   `json:"pipeline,omitempty"`
+	Stages           []string `json:"stages,omitempty"`
 }
 
 type ProcessingResult struct {
@@ -53,10 +231,64 @@ type ProcessingResult struct {
 	FileSize        int64                  `json:"file_size"`
 	ProcessingTime  time.Duration          `json:"processing_time"`
 	Entities        []ExtractedEntity      `json:"entities"`
+	Tables          []ExtractedTable       `json:"tables,omitempty"`
 	RiskAnalysis    RiskAnalysis           `json:"risk_analysis"`
 	RelevanceScore  float64                `json:"relevance_score"`
 	QualityMetrics  QualityMetrics         `json:"quality_metrics"`
 	Metadata        map[string]interface{} `json:"metadata"`
+
+	// Classification is the document type, e.g. edital or contrato
+	Classification  *classify.Result       `json:"classification,omitempty"`
+
+	// Sections are the canonical edital sections found in the text
+	Sections        []Section              `json:"sections,omitempty"`
+
+	// Items are the rows of the document's item table (planilha de itens)
+	Items           []BiddingItem          `json:"items,omitempty"`
+
+	// Blocks is the layout of the text when TextBlocks is set
+	Blocks          []TextBlock            `json:"blocks,omitempty"`
+
+	// PageOffsets is the byte offset of each page in ExtractedText, when
+	// the pages are known
+	PageOffsets     []int                  `json:"page_offsets,omitempty"`
+
+	// Relevance explains RelevanceScore when an interest profile was used
+	Relevance       *RelevanceMatch        `json:"relevance,omitempty"`
+
+	// Report is the PDF summary generated when requested
+	Report          *report.Artifact       `json:"report,omitempty"`
+
+	// Archive is the PDF/A archival copy of the document, when requested
+	Archive         *report.Artifact       `json:"archive,omitempty"`
+
+	// Searchable is the scanned document with its OCR text layer
+	Searchable      *report.Artifact       `json:"searchable_pdf,omitempty"`
+
+	// OCRLayout is the scan's OCR words with their boxes, when requested
+	OCRLayout       *report.Artifact       `json:"ocr_layout,omitempty"`
+
+	// Zones is the text read in the requested OCR zones
+	Zones           []ZoneText             `json:"zones,omitempty"`
+
+	// Redacted is the copy of the document with its PII removed
+	Redacted        *report.Artifact       `json:"redacted_pdf,omitempty"`
+
+	// Annotated is the PDF with its risks highlighted, when requested
+	Annotated       *report.Artifact       `json:"annotated_pdf,omitempty"`
+
+	// Attachments are the files embedded in the PDF, when extracted
+	Attachments     []Attachment           `json:"attachments,omitempty"`
+
+	// Signatures are the PDF's digital signatures and their validation
+	Signatures      []signature.Signature  `json:"signatures,omitempty"`
+
+	// ContentSHA256 is the digest of the document. Deduplicated results
+	// are those of DeduplicatedFrom, an earlier job of an identical
+	// document with the same options
+	ContentSHA256    string                `json:"content_sha256,omitempty"`
+	Deduplicated     bool                  `json:"deduplicated,omitempty"`
+	DeduplicatedFrom string                `json:"deduplicated_from,omitempty"`
 }
 
 type ExtractedEntity struct {
@@ -66,6 +298,20 @@ type ExtractedEntity struct {
 	StartPos   int     `json:"start_pos"`
 	EndPos     int     `json:"end_pos"`
 	Page       int     `json:"page"`
+
+	// Normalized holds the parsed value of dates and currency amounts;
+	// Value keeps the text as found in the document
+	Normalized *NormalizedValue `json:"normalized,omitempty"`
+
+	// Company holds registry data for CNPJs when enrichment is enabled
+	Company *enrichment.Company `json:"company,omitempty"`
+
+	// BoundingBox is set when the entity was read by OCR
+	BoundingBox *BoundingBox `json:"bounding_box,omitempty"`
+
+	// Symbology is the kind of code BARCODE entities were read from, such
+	// as QR-Code or EAN-13; they have no position in the text
+	Symbology string `json:"symbology,omitempty"`
 }
 
 type RiskAnalysis struct {
@@ -74,6 +320,11 @@ type RiskAnalysis struct {
 	IdentifiedRisks []IdentifiedRisk   `json:"identified_risks"`
 	Recommendations []string           `json:"recommendations"`
 	Confidence      float64            `json:"confidence"`
+
+	// Profile and ProfileID name the tenant weighting profile applied to
+	// the score; empty when the rules' own weights were used
+	Profile   string `json:"profile,omitempty"`
+	ProfileID string `json:"profile_id,omitempty"`
 }
 
 type IdentifiedRisk struct {
@@ -83,6 +334,16 @@ type IdentifiedRisk struct {
 	Impact      string  `json:"impact"`
 	Confidence  float64 `json:"confidence"`
 	Location    string  `json:"location"`
+
+	// Snippet is the clause the risk was found in, and StartPos/EndPos its
+	// byte range in the analyzed text (the page text for streamed documents)
+	Snippet  string `json:"snippet,omitempty"`
+	Page     int    `json:"page,omitempty"`
+	StartPos int    `json:"start_pos"`
+	EndPos   int    `json:"end_pos"`
+
+	// Section is the edital section the clause is in, when segmented
+	Section  string `json:"section,omitempty"`
 }
 
 type QualityMetrics struct {
@@ -91,13 +352,57 @@ type QualityMetrics struct {
 	DocumentClarity float64 `json:"document_clarity"`
 	Completeness   float64 `json:"completeness"`
 	Readability    float64 `json:"readability"`
+
+	// LowConfidencePages are the OCRed pages worth checking by hand
+	LowConfidencePages []int `json:"low_confidence_pages,omitempty"`
+
+	// OCRCorrections is how many misread words were spell-corrected
+	OCRCorrections int `json:"ocr_corrections,omitempty"`
 }
 
-func NewPDFProcessor(redis *storage.RedisClient, postgres *storage.PostgresClient, tracer trace.Tracer) *PDFProcessor {
+// DefaultProcessingOptions returns the options used when a submission does
+// not specify any.
+func DefaultProcessingOptions() ProcessingOptions {
+	return ProcessingOptions{
+		EnableOCR:        true,
+		Languages:        []string{"por", "eng"},
+		ExtractEntities:  true,
+		AnalyzeRisks:     true,
+		GenerateScore:    true,
+		ClassifyDocument: true,
+		SegmentSections:  true,
+		ExtractItems:     true,
+		DetectTables:     true,
+		LayoutText:       true,
+		RecoverCorrupted: true,
+		VerifySignatures: true,
+		SearchablePDF:    true,
+		PreprocessImages: true,
+		AutoRotate:       true,
+		CorrectSpelling:  true,
+		ReflowText:       true,
+	}
+}
+
+func NewPDFProcessor(cfg *config.Config, redis *storage.RedisClient, postgres *storage.PostgresClient, downloader *download.Downloader, companies *enrichment.CNPJClient, recognizers *ner.Registry, ocrEngines *ocr.Registry, dictionary *spellcheck.Dictionary, classifier *classify.Classifier, riskRules *risk.Engine, reports *report.Publisher, objects *storage.ObjectStore, signatures *signature.Verifier, events JobEvents, tracer trace.Tracer) *PDFProcessor {
 	return &PDFProcessor{
-		redis:    redis,
-		postgres: postgres,
-		tracer:   tracer,
+		cfg:         cfg,
+		redis:       redis,
+		postgres:    postgres,
+		downloader:  downloader,
+		companies:   companies,
+		recognizers: recognizers,
+		ocrEngines:  ocrEngines,
+		dictionary:  dictionary,
+		classifier:  classifier,
+		riskRules:   riskRules,
+		reports:     reports,
+		objects:     objects,
+		signatures:  signatures,
+		events:      events,
+		tracer:      tracer,
+		hooks:       &http.Client{Timeout: cfg.HookTimeout},
+		ocrSlots:    make(chan struct{}, max(1, cfg.OCRMaxPageWorkers)),
 	}
 }
 
@@ -108,19 +413,129 @@ func (p *PDFProcessor) ProcessDocument(ctx context.Context, job *ProcessingJob)
 	startTime := time.Now()
 	job.StartedAt = &startTime
 	job.Status = "processing"
+	job.Attempts++
+	job.NextRetryAt = nil
+	job.Error = ""
+	job.ErrorCode = ""
 
 	// Update job status in Redis
 	if err := p.updateJobStatus(ctx, job); err != nil {
 		log.Printf("Failed to update job status: %v", err)
 	}
+	progress := &progressTracker{p: p, jobID: job.ID}
+	ctx = withProgress(ctx, progress)
+	progress.stage(ctx, progressDownloading)
+
+	// Files expanded from an archive are only needed for this job, and
+	// its retries
+	if isArchiveEntry(job) {
+		defer func() {
+			if job.Status != "retrying" {
+				removeArchiveEntry(job.FileURL)
+			}
+		}()
+	}
 
-	// Download and process the file
-	result, err := p.processFile(ctx, job)
+	// Reject files the job already declares as oversized or disallowed
+	if err := p.checkJobLimits(job); err != nil {
+		p.failJob(ctx, job, err)
+		return fmt.Errorf("file rejected: %w", err)
+	}
+
+	// The pre-processing hook may change the job's options
+	if err := p.runPreProcessingHook(ctx, job); err != nil {
+		p.failJob(ctx, job, err)
+		return fmt.Errorf("pre-processing hook failed: %w", err)
+	}
+
+	// Download file to a local temp path (local paths are used as-is)
+	file, err := p.downloader.Fetch(ctx, job.FileURL)
 	if err != nil {
-		job.Status = "failed"
-		job.Error = err.Error()
-		p.updateJobStatus(ctx, job)
-		return fmt.Errorf("failed to process file: %w", err)
+		p.failJob(ctx, job, err)
+		return fmt.Errorf("failed to download file: %w", err)
+	}
+	defer file.Cleanup()
+
+	if err := p.checkFileLimits(file.Size, file.ContentType); err != nil {
+		p.failJob(ctx, job, err)
+		return fmt.Errorf("file rejected: %w", err)
+	}
+
+	format := detectFormat(file.Path)
+	if format == formatUnknown {
+		contentType, _ := job.Metadata["content_type"].(string)
+		if contentType == "" {
+			contentType = file.ContentType
+		}
+		format = formatFromHint(contentType, job.FileURL)
+	}
+
+	// Reject content no extractor can read, such as executables or HTML
+	// error pages served in place of the document
+	sniffed, err := SniffContentType(file.Path)
+	if err == nil {
+		err = checkContent(format, sniffed)
+	}
+	if err != nil {
+		p.failJob(ctx, job, err)
+		return fmt.Errorf("file rejected: %w", err)
+	}
+
+	// Archives are expanded into one child job per contained document
+	if format == formatArchive {
+		if err := p.expandArchive(ctx, job, file); err != nil {
+			p.failJob(ctx, job, err)
+			return fmt.Errorf("failed to expand archive: %w", err)
+		}
+		return nil
+	}
+
+	// Identical documents processed with the same options reuse the
+	// earlier result
+	digest, err := fileSHA256(file.Path)
+	if err != nil {
+		log.Printf("Failed to hash the document of job %s: %v", job.ID, err)
+	}
+	// OCR checkpoints outlive the attempts of the job, not the job
+	checkpoint := p.ocrCheckpoint(job, digest)
+	ctx = withCheckpoint(ctx, checkpoint)
+	defer func() {
+		if isFinished(job.Status) {
+			checkpoint.clear(context.WithoutCancel(ctx))
+		}
+	}()
+
+	// The result is cached under the rules and profiles it was computed with
+	cacheKey := p.resultCacheKey(ctx, job, digest)
+	result := p.cachedResult(ctx, job, cacheKey)
+	if result != nil {
+		log.Printf("Job %s reuses the result of job %s", job.ID, result.DeduplicatedFrom)
+		progress.stage(ctx, progressFinishing)
+	} else {
+		// Process the file
+		result, err = p.processFile(ctx, job, file, format)
+		if err == nil && ctx.Err() != nil {
+			// Stages that degrade on errors may have carried on
+			err = context.Cause(ctx)
+		}
+		if err != nil {
+			p.failJob(ctx, job, err)
+			return fmt.Errorf("failed to process file: %w", err)
+		}
+		progress.stage(ctx, progressFinishing)
+		if format == formatPDF && job.Options.VerifySignatures {
+			result.Signatures = p.verifySignatures(ctx, file.Path)
+		}
+		result.ContentSHA256 = digest
+		p.cacheResult(ctx, job, cacheKey, result)
+	}
+
+	// The post-processing hook may add to the result, or veto it
+	job.Result = result
+	if err := p.runPostProcessingHook(ctx, job); err != nil {
+		job.Result = nil
+		p.failJob(ctx, job, err)
+		return fmt.Errorf("post-processing hook failed: %w", err)
 	}
 
 	// Calculate processing time
@@ -130,6 +545,20 @@ func (p *PDFProcessor) ProcessDocument(ctx context.Context, job *ProcessingJob)
 	job.Result = result
 	job.Status = "completed"
 
+	if format == formatPDF && (job.Options.ExtractAttachments || job.Options.ProcessAttachments) {
+		p.extractAttachments(ctx, job, file)
+	}
+	if format == formatPDF && job.Options.ArchivePDFA {
+		p.attachArchivalCopy(ctx, job, file.Path)
+	}
+	if format == formatPDF && job.Options.AnnotateRisks && len(result.RiskAnalysis.IdentifiedRisks) > 0 {
+		p.attachAnnotatedCopy(ctx, job, file.Path)
+	}
+
+	if job.Options.GenerateReport {
+		p.attachReport(ctx, job)
+	}
+
 	// Update final status
 	if err := p.updateJobStatus(ctx, job); err != nil {
 		log.Printf("Failed to update final job status: %v", err)
@@ -140,6 +569,8 @@ func (p *PDFProcessor) ProcessDocument(ctx context.Context, job *ProcessingJob)
 		log.Printf("Failed to store results: %v", err)
 	}
 
+	p.updateParentJob(ctx, job)
+
 	// Trigger AI analysis if requested
 	if job.Options.ExtractEntities || job.Options.AnalyzeRisks || job.Options.GenerateScore {
 		go p.triggerAIAnalysis(context.Background(), job)
@@ -148,13 +579,54 @@ func (p *PDFProcessor) ProcessDocument(ctx context.Context, job *ProcessingJob)
 	return nil
 }
 
-func (p *PDFProcessor) processFile(ctx context.Context, job *ProcessingJob) (*ProcessingResult, error) {
+// failJob records a processing failure on the job and its parent. Jobs
+// with attempts left after a failure that may pass are left "retrying" for
+// the worker pool to schedule, which leaves the parent as it is; jobs
+// stopped by CancelJob are recorded as cancelled, and those interrupted by
+// a shutdown as queued again, the attempt not counted.
+func (p *PDFProcessor) failJob(ctx context.Context, job *ProcessingJob, err error) {
+	cancelled := errors.Is(context.Cause(ctx), ErrJobCancelled)
+	interrupted := errors.Is(context.Cause(ctx), ErrJobInterrupted)
+	if errors.Is(context.Cause(ctx), ErrJobLockLost) {
+		// The worker that took the job over records its status
+		return
+	}
+	if timeout := timedOut(ctx); timeout != nil && !errors.Is(err, timeout) {
+		// Report the timeout rather than what it interrupted
+		err = fmt.Errorf("%w: %v", timeout, err)
+	}
+
+	// The status is recorded even when ctx is what stopped the job
+	ctx = context.WithoutCancel(ctx)
+	if cancelled {
+		p.markCancelled(ctx, job)
+		return
+	}
+	if interrupted {
+		job.Status = "queued"
+		job.Attempts--
+		job.StartedAt = nil
+		p.updateJobStatus(ctx, job)
+		return
+	}
+
+	job.Error = err.Error()
+	job.ErrorCode = errorCode(err)
+	if p.retryable(job, err) {
+		job.Status = "retrying"
+		p.updateJobStatus(ctx, job)
+		return
+	}
+	job.Status = "failed"
+	p.updateJobStatus(ctx, job)
+	p.updateParentJob(ctx, job)
+}
+
+func (p *PDFProcessor) processFile(ctx context.Context, job *ProcessingJob, file *download.File, format documentFormat) (*ProcessingResult, error) {
 	ctx, span := p.tracer.Start(ctx, "process_file")
 	defer span.End()
 
-	// Download file (simplified - in real implementation, download from URL)
-	// For now, assume we have the file path
-	filePath := job.FileURL
+	filePath := file.Path
 
 	result := &ProcessingResult{
 		QualityMetrics: QualityMetrics{},
@@ -162,122 +634,572 @@ func (p *PDFProcessor) processFile(ctx context.Context, job *ProcessingJob) (*Pr
 		Metadata:       make(map[string]interface{}),
 	}
 
-	// Extract text from PDF
-	text, pageCount, err := p.extractTextFromPDF(ctx, filePath)
+	progressFrom(ctx).stage(ctx, progressExtracting)
+
+	// Very large PDFs are processed page by page to keep memory bounded
+	if format == formatPDF && p.shouldStream(filePath, job.Options) {
+		return p.processStreaming(ctx, job, file)
+	}
+
+	// Extract text using the extractor for the document's format
+	extractCtx, cancelExtract := p.stageContext(ctx, stageExtraction)
+	content, err := p.extractDocument(extractCtx, format, filePath, job.Options)
+	if timeout := timedOut(extractCtx); timeout != nil {
+		err = timeout
+	}
+	cancelExtract()
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract text: %w", err)
 	}
+	// Spreadsheet rows are lines of their own
+	reflowed := 0
+	if job.Options.ReflowText && format != formatXLSX && format != formatCSV {
+		reflowed = p.reflowContent(content)
+	}
+	text := content.Text
+	for key, value := range content.Metadata {
+		result.Metadata[key] = value
+	}
 
 	result.ExtractedText = text
-	result.PageCount = pageCount
-
-	// OCR processing if enabled and text is insufficient
-	if job.Options.EnableOCR && (len(text) < 100 || p.hasLowTextQuality(text)) {
-		ocrText, confidence, err := p.performOCR(ctx, filePath, job.Options)
+	result.PageCount = content.PageCount
+	result.Tables = content.Tables
+	result.FileSize = file.Size
+	result.Metadata["format"] = string(format)
+
+	ocrApplied, ocrConfidence := content.OCRApplied, content.OCRConfidence
+	lowConfidencePages, spellCorrections := content.LowConfidencePages, content.SpellCorrections
+	wordBoxes, ocrPages := content.WordBoxes, content.OCRPages
+	var ocrOffsets []int
+
+	// OCR processing if enabled, on the pages whose text is insufficient.
+	// Mixed documents get their scanned pages recognized, and the
+	// image-based outputs below cover only those pages
+	pagesToOCR, needsOCR := p.pagesNeedingOCR(content)
+	if job.Options.EnableOCR && format.supportsOCR() && needsOCR {
+		progressFrom(ctx).stage(ctx, progressOCR)
+		ocrCtx, cancelOCR := p.stageContext(ctx, stageOCR)
+		ocrStart := time.Now()
+		ocr, err := p.performOCR(ocrCtx, filePath, job.Options, pagesToOCR)
+		// Billed whether OCR recognized anything or not
+		result.Metadata["ocr_seconds"] = time.Since(ocrStart).Seconds()
+		timeout := timedOut(ocrCtx)
+		cancelOCR()
+		if timeout != nil {
+			// Falling back to the native text would hide the timeout
+			if ocr != nil {
+				ocr.Cleanup()
+			}
+			return nil, fmt.Errorf("OCR failed: %w", timeout)
+		}
 		if err != nil {
 			log.Printf("OCR failed: %v", err)
 		} else {
-			result.ExtractedText = p.combineTexts(text, ocrText)
-			result.QualityMetrics.OCRConfidence = confidence
+			defer ocr.Cleanup()
+			if job.Options.ReflowText {
+				reflowed += p.reflowOCR(ocr)
+			}
+			merged := mergePageTexts(content, ocr)
+			result.ExtractedText = merged.text
+			ocrApplied, ocrConfidence = true, ocr.Confidence
+			lowConfidencePages, spellCorrections = ocr.LowConfidencePages, ocr.SpellCorrections
+			if len(ocr.Corrections) > 0 {
+				result.Metadata["page_corrections"] = ocr.Corrections
+			}
+			if len(merged.pages) > 0 {
+				wordBoxes, ocrPages, ocrOffsets = merged.words, merged.pages, merged.offsets
+				if job.Options.DetectTables && len(result.Tables) == 0 {
+					result.Tables = ocrTables(merged.text, merged.words)
+				}
+				numbers := make([]int, len(merged.pages))
+				for i, page := range merged.pages {
+					numbers[i] = page.number
+				}
+				result.Metadata["ocr_pages"] = numbers
+			}
 		}
 	}
 
 	// Calculate quality metrics
 	result.QualityMetrics = p.calculateQualityMetrics(result.ExtractedText, result.PageCount)
-
-	// Basic entity extraction (simplified)
-	if job.Options.ExtractEntities {
-		result.Entities = p.extractBasicEntities(result.ExtractedText)
+	if ocrApplied {
+		result.QualityMetrics.OCRConfidence = ocrConfidence / 100
+		result.QualityMetrics.LowConfidencePages = lowConfidencePages
+		result.QualityMetrics.OCRCorrections = spellCorrections
 	}
-
-	// Basic risk analysis (simplified)
-	if job.Options.AnalyzeRisks {
-		result.RiskAnalysis = p.performBasicRiskAnalysis(result.ExtractedText)
+	result.Metadata["ocr_applied"] = ocrApplied
+	if reflowed > 0 {
+		result.Metadata["reflowed_lines"] = reflowed
 	}
 
-	// Generate relevance score
-	if job.Options.GenerateScore {
-		result.RelevanceScore = p.generateRelevanceScore(result.ExtractedText, job.Metadata)
+	// Scans get a copy with the recognized text laid over the page images
+	if job.Options.SearchablePDF && len(ocrPages) > 0 {
+		p.attachSearchablePDF(ctx, job.ID, result, ocrPages, wordBoxes)
+	}
+	if job.Options.OCRLayout != "" && len(ocrPages) > 0 {
+		p.attachOCRLayout(ctx, job.ID, result, job.Options.OCRLayout, ocrPages, wordBoxes)
+	}
+	if len(job.Options.OCRZones) > 0 {
+		p.recognizeZones(ctx, job, result, format, filePath, ocrPages)
+	}
+	if job.Options.RedactPII {
+		p.attachRedactedCopy(ctx, job, result, format, filePath, ocrPages, wordBoxes, result.ExtractedText == text)
 	}
 
+	pageOffsets := content.PageOffsets
+	if result.ExtractedText != text {
+		pageOffsets = ocrOffsets
+	} else if job.Options.TextBlocks {
+		result.Blocks = content.Blocks
+	}
+	result.PageOffsets = pageOffsets
+
+	// The analysis stages degrade rather than fail, so their timeout is
+	// checked once they are done
+	ctx, cancelAnalysis := p.stageContext(ctx, stageAnalysis)
+	defer cancelAnalysis()
+	progressFrom(ctx).stage(ctx, progressAnalyzing)
+
+	// Classification, sections, items, entities, risks and the score, in
+	// the order of the job's pipeline
+	p.runAnalysisStages(ctx, &analysisInput{
+		job:         job,
+		result:      result,
+		format:      format,
+		filePath:    filePath,
+		pageOffsets: pageOffsets,
+		wordBoxes:   wordBoxes,
+	})
+
+	if timeout := timedOut(ctx); timeout != nil {
+		return nil, fmt.Errorf("analysis failed: %w", timeout)
+	}
 	return result, nil
 }
 
-func (p *PDFProcessor) extractTextFromPDF(ctx context.Context, filePath string) (string, int, error) {
+func (p *PDFProcessor) extractTextFromPDF(ctx context.Context, filePath string, options ProcessingOptions) (*documentContent, error) {
 	ctx, span := p.tracer.Start(ctx, "extract_text_pdf")
 	defer span.End()
 
-	// Open PDF file
-	file, reader, err := pdf.Open(filePath)
+	content := &documentContent{Metadata: make(map[string]interface{})}
+
+	reader, closePDF, err := p.openPDFWithRecovery(filePath, options, content.Metadata)
 	if err != nil {
-		return "", 0, fmt.Errorf("failed to open PDF: %w", err)
+		return nil, err
 	}
-	defer file.Close()
+	defer closePDF()
 
 	var textBuilder strings.Builder
-	pageCount := reader.NumPage()
+	offsets := []int{}
+	pageCount, unrecoverable, err := readPDFPages(ctx, reader, options, func(page pdfPage) error {
+		// Skipped pages start where the next readable page does
+		for len(offsets) < page.number {
+			offsets = append(offsets, textBuilder.Len())
+		}
+		for _, block := range page.blocks {
+			block.StartPos += textBuilder.Len()
+			block.EndPos += textBuilder.Len()
+			content.Blocks = append(content.Blocks, block)
+		}
+		textBuilder.WriteString(page.text)
+		textBuilder.WriteString("\n")
+		content.Tables = append(content.Tables, page.tables...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(unrecoverable) > 0 {
+		content.Metadata["unrecoverable_pages"] = unrecoverable
+	}
+	content.Text = textBuilder.String()
+	content.PageCount = pageCount
+	content.PageOffsets = offsets
+	return content, nil
+}
+
+// openPDFWithRecovery opens a PDF, decrypting it if needed. In recovery mode
+// a PDF that fails to open gets its cross-reference table rebuilt first, which
+// is noted in metadata. The returned function releases the file and any
+// repaired copy.
+func (p *PDFProcessor) openPDFWithRecovery(filePath string, options ProcessingOptions, metadata map[string]interface{}) (*pdf.Reader, func(), error) {
+	file, reader, err := openPDF(filePath, options.Password)
+	if err == nil {
+		return reader, func() { file.Close() }, nil
+	}
+	if !options.RecoverCorrupted || errorCode(err) != "" {
+		return nil, nil, err
+	}
+
+	// Rebuild the cross-reference table and try again
+	repairedPath, repairErr := repairPDF(filePath, p.cfg.TempDir)
+	if repairErr != nil {
+		return nil, nil, fmt.Errorf("%w (recovery failed: %v)", err, repairErr)
+	}
+
+	log.Printf("Rebuilt cross-reference table for %s after: %v", filePath, err)
+	file, reader, err = openPDF(repairedPath, options.Password)
+	if err != nil {
+		os.Remove(repairedPath)
+		return nil, nil, err
+	}
+	metadata["xref_rebuilt"] = true
+
+	return reader, func() {
+		file.Close()
+		os.Remove(repairedPath)
+	}, nil
+}
+
+// pdfPage is what was read from one page of a PDF.
+type pdfPage struct {
+	number int
+	text   string
+	blocks []TextBlock      // with LayoutText, positions relative to text
+	tables []ExtractedTable // with DetectTables
+}
 
-	// Extract text from each page
+// readPDFPages extracts each page's text in order and hands it to fn, so
+// callers decide whether to accumulate or persist it. Pages that cannot be
+// read are returned as unrecoverable in recovery mode and fail otherwise.
+// Reading stops with ctx's cause when ctx is done.
+func readPDFPages(ctx context.Context, reader *pdf.Reader, options ProcessingOptions, fn func(page pdfPage) error) (int, []int, error) {
+	pageCount, err := safePageCount(reader)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	progress := progressFrom(ctx)
+	progress.pages(ctx, pageCount)
+
+	unrecoverable := []int{}
 	for i := 1; i <= pageCount; i++ {
-		page := reader.Page(i)
-		if page.V.IsNull() {
-			continue
+		if ctx.Err() != nil {
+			return 0, nil, context.Cause(ctx)
 		}
 
-		text, err := page.GetPlainText(nil)
+		page, err := readPDFPage(reader, i, options)
+		progress.pageDone(ctx)
 		if err != nil {
+			if !options.RecoverCorrupted && !errors.Is(err, errPageMissing) {
+				return 0, nil, fmt.Errorf("failed to extract text from page %d: %w", i, err)
+			}
 			log.Printf("Failed to extract text from page %d: %v", i, err)
+			unrecoverable = append(unrecoverable, i)
 			continue
 		}
 
-		textBuilder.WriteString(text)
-		textBuilder.WriteString("\n")
+		if err := fn(page); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	return pageCount, unrecoverable, nil
+}
+
+// readPDFPage reads a page's text. Layout analysis and table detection work
+// on the positioned words of the page's content stream; when the page has
+// none, e.g. a scan, or they cannot be read, the plain text is used.
+func readPDFPage(reader *pdf.Reader, num int, options ProcessingOptions) (pdfPage, error) {
+	page := pdfPage{number: num}
+	layout := options.LayoutText || options.TextBlocks
+	if layout || options.DetectTables {
+		content, err := safePageContent(reader, num)
+		if err != nil {
+			log.Printf("Failed to read layout of page %d: %v", num, err)
+		} else {
+			words := wordsFromGlyphs(content.Text)
+			if layout {
+				page.text, page.blocks = analyzeLayout(words).text(num)
+			}
+			if options.DetectTables {
+				hs, vs := rulingsFromRects(content.Rect)
+				page.tables = detectTables(words, hs, vs, num, "pdf")
+			}
+		}
 	}
 
-	return textBuilder.String(), pageCount, nil
+	if page.text == "" {
+		text, err := safePageText(reader, num)
+		if err != nil {
+			return page, err
+		}
+		page.text, page.blocks = text, nil
+	}
+	return page, nil
+}
+
+// openPDF opens a PDF, trying password when the document is encrypted.
+// Encrypted documents that cannot be opened fail with ErrCodeEncryptedPDF.
+func openPDF(filePath, password string) (*os.File, *pdf.Reader, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+
+	tried := false
+	reader, err := pdf.NewReaderEncrypted(file, info.Size(), func() string {
+		if tried {
+			return ""
+		}
+		tried = true
+		return password
+	})
+	if err != nil {
+		file.Close()
+		switch {
+		case errors.Is(err, pdf.ErrInvalidPassword) && password == "":
+			return nil, nil, &ProcessingError{Code: ErrCodeEncryptedPDF, Err: errors.New("PDF is password-protected")}
+		case errors.Is(err, pdf.ErrInvalidPassword):
+			return nil, nil, &ProcessingError{Code: ErrCodeEncryptedPDF, Err: errors.New("PDF password is incorrect")}
+		case strings.Contains(err.Error(), "encryption"):
+			return nil, nil, &ProcessingError{Code: ErrCodeEncryptedPDF, Err: fmt.Errorf("PDF encryption not supported: %w", err)}
+		}
+		return nil, nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+
+	return file, reader, nil
 }
 
-func (p *PDFProcessor) performOCR(ctx context.Context, filePath string, options ProcessingOptions) (string, float64, error) {
+// performOCR recognizes the text of an image, or of each page of a PDF,
+// with the job's OCR provider; only the given pages, numbered from 1, when
+// there are any. OCR engines read images, so PDF pages are
+// rendered first. Page texts are joined by blank lines; the caller must Cleanup the
+// result to remove the rendered pages.
+func (p *PDFProcessor) performOCR(ctx context.Context, filePath string, options ProcessingOptions, pageNumbers []int) (*ocrResult, error) {
 	ctx, span := p.tracer.Start(ctx, "perform_ocr")
 	defer span.End()
 
-	client := gosseract.NewClient()
-	defer client.Close()
-
-	// Set languages
-	if len(options.Languages) > 0 {
-		client.SetLanguage(strings.Join(options.Languages, "+"))
-	} else {
-		client.SetLanguage("por+eng") // Portuguese and English by default
+	provider, err := p.ocrEngines.Get(options.OCRProvider)
+	if err != nil {
+		return nil, err
 	}
 
-	// Configure OCR settings for better accuracy
-	client.SetPageSegMode(gosseract.PSM_AUTO)
-	client.SetConfigFile("pdf")
-	
-	// Set DPI if specified
+	dpi := float64(defaultScanDPI)
 	if options.DPI > 0 {
-		client.SetVariable("tessedit_pageseg_mode", fmt.Sprintf("%d", options.DPI))
+		dpi = float64(options.DPI)
 	}
 
-	// Set image source
-	client.SetImage(filePath)
+	result := &ocrResult{Pages: []ocrPage{{number: 1, path: filePath, dpi: dpi}}}
+	if detectFormat(filePath) == formatPDF {
+		renderCtx, cancel := context.WithTimeout(ctx, ocrRenderTimeout)
+		var dir string
+		var images []string
+		if len(pageNumbers) > 0 {
+			dir, images, err = p.rasterizePDFPages(renderCtx, filePath, options.Password, "pnggray", dpi, pageNumbers)
+		} else {
+			dir, images, err = p.rasterizePDF(renderCtx, filePath, options.Password, "pnggray", dpi, options.MaxPages)
+		}
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to render pages for OCR: %w", err)
+		}
+		result.dir = dir
+		result.Pages = make([]ocrPage, len(images))
+		for i, image := range images {
+			number := i + 1
+			if len(pageNumbers) > 0 {
+				number = pageNumbers[i]
+			}
+			result.Pages[i] = ocrPage{number: number, path: image, dpi: dpi}
+		}
+		if len(images) == 0 {
+			result.Cleanup()
+			return nil, errors.New("no pages rendered for OCR")
+		}
+	}
 
-	// Get text
-	text, err := client.Text()
+	if (options.PreprocessImages || options.AutoRotate) && result.dir == "" {
+		dir, err := os.MkdirTemp(p.cfg.TempDir, "cotai-pages-*")
+		if err != nil {
+			return nil, err
+		}
+		result.dir = dir
+	}
+
+	// Pages checkpointed by an earlier attempt of the job are not
+	// recognized again
+	pages := make([]pageOCR, len(result.Pages))
+	progress := progressFrom(ctx)
+	progress.pages(ctx, len(result.Pages))
+	checkpoint := checkpointFrom(ctx)
+	checkpointed := checkpoint.load(ctx)
+	for i := range result.Pages {
+		if page, ok := checkpointed[result.Pages[i].number]; ok {
+			restorePage(result, i, page.correction, options)
+			pages[i] = page
+			progress.pageDone(ctx)
+		}
+	}
+
+	// Pages are recognized by parallel workers; every page also takes one
+	// of the processor-wide OCR slots
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < p.ocrParallelism(options, len(result.Pages)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				pages[i] = p.recognizePage(ctx, provider, result, i, options)
+				if pages[i].err == nil {
+					checkpoint.save(ctx, result.Pages[i].number, pages[i])
+				}
+				progress.pageDone(ctx)
+			}
+		}()
+	}
+	for i := range result.Pages {
+		if _, ok := checkpointed[result.Pages[i].number]; ok {
+			continue
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	// Blank pages have no words and no confidence, and are left out of the
+	// document's
+	var text strings.Builder
+	var confidence float64
+	recognized := 0
+	for i, page := range pages {
+		if page.err != nil {
+			result.Cleanup()
+			return nil, page.err
+		}
+
+		if text.Len() > 0 {
+			text.WriteString("\n\n")
+		}
+		offset := text.Len()
+		text.WriteString(page.text)
+		for _, word := range page.words {
+			word.Start += offset
+			word.End += offset
+			result.Words = append(result.Words, word)
+		}
+		result.PageOffsets = append(result.PageOffsets, offset)
+		result.SpellCorrections += page.corrections
+		if page.correction.Rotation != 0 || page.correction.Skew != 0 {
+			result.Corrections = append(result.Corrections, page.correction)
+		}
+		if len(page.words) == 0 {
+			continue
+		}
+		confidence += page.confidence
+		recognized++
+		if page.confidence < p.cfg.OCRLowConfidence {
+			result.LowConfidencePages = append(result.LowConfidencePages, result.Pages[i].number)
+		}
+	}
+
+	result.Text = text.String()
+	if recognized > 0 {
+		result.Confidence = confidence / float64(recognized)
+	}
+	return result, nil
+}
+
+// pageOCR is the text recognized on one page.
+type pageOCR struct {
+	text       string
+	words      []wordBox
+	confidence float64
+	correction PageCorrection
+
+	// corrections is how many misread words were corrected
+	corrections int
+	err        error
+}
+
+// ocrParallelism is how many pages of a job are recognized at once: the
+// job's OCRParallelism, or OCR_PAGE_PARALLELISM, within the processor-wide
+// limit.
+func (p *PDFProcessor) ocrParallelism(options ProcessingOptions, pages int) int {
+	n := options.OCRParallelism
+	if n <= 0 {
+		n = p.cfg.OCRPageParallelism
+	}
+	return max(1, min(n, pages, cap(p.ocrSlots)))
+}
+
+// recognizePage turns upright, preprocesses and recognizes result.Pages[i]
+// once an OCR slot is free. Pages that cannot be decoded for correction,
+// such as TIFF scans, are read as they are.
+func (p *PDFProcessor) recognizePage(ctx context.Context, provider ocr.Provider, result *ocrResult, i int, options ProcessingOptions) pageOCR {
+	select {
+	case p.ocrSlots <- struct{}{}:
+		defer func() { <-p.ocrSlots }()
+	case <-ctx.Done():
+		return pageOCR{err: ctx.Err()}
+	}
+
+	page := result.Pages[i]
+	correction := PageCorrection{Page: page.number}
+	if options.AutoRotate {
+		upright, rotation, err := p.orientPage(ctx, page, result.dir)
+		if err != nil {
+			log.Printf("Failed to rotate page %d for OCR: %v", page.number, err)
+		} else {
+			page.path, correction.Rotation = upright, rotation
+			result.Pages[i].path = upright
+		}
+	}
+
+	image := page.path
+	if options.PreprocessImages {
+		cleaned, binarized, skew, err := preprocessPage(page.path, result.dir, page.number, page.dpi)
+		if err != nil {
+			log.Printf("Failed to preprocess page %d for OCR: %v", page.number, err)
+		} else {
+			result.Pages[i].path = cleaned
+			image = binarized
+			correction.Skew = skew
+		}
+	}
+
+	recognized := p.ocrPageImage(ctx, provider, page, image, options)
+	recognized.correction = correction
+	return recognized
+}
+
+// ocrPageImage recognizes the text of one page from the image at path.
+func (p *PDFProcessor) ocrPageImage(ctx context.Context, provider ocr.Provider, page ocrPage, path string, options ProcessingOptions) pageOCR {
+	recognized, err := provider.Recognize(ctx, path, ocr.Options{Languages: options.Languages, DPI: page.dpi})
 	if err != nil {
-		return "", 0, fmt.Errorf("OCR failed: %w", err)
+		return pageOCR{err: fmt.Errorf("OCR failed on page %d: %w", page.number, err)}
 	}
 
-	// Get confidence score
-	confidence := 85.0 // Default confidence
-	if confidenceStr, err := client.GetMeanConfidence(); err == nil {
-		if conf, parseErr := fmt.Sscanf(confidenceStr, "%f", &confidence); parseErr == nil && conf == 1 {
-			// Successfully parsed confidence
+	// Word boxes let entities be highlighted on the page image
+	text, boxes := recognized.Text, recognized.Words
+
+	// Words are corrected one by one, so each box's word is corrected as in
+	// the text and is still found there
+	corrections := 0
+	if options.CorrectSpelling {
+		text, corrections = p.dictionary.Correct(text)
+		for i := range boxes {
+			boxes[i].Text, _ = p.dictionary.Correct(boxes[i].Text)
 		}
 	}
 
-	return text, confidence, nil
+	// OCR engines may read lines across the columns of a page
+	if options.LayoutText {
+		if ordered, orderedBoxes, ok := ocrReadingOrder(boxes); ok {
+			text, boxes = ordered, orderedBoxes
+		}
+	}
+	words := wordBoxesFromOCR(text, boxes, page.number)
+
+	return pageOCR{text: text, words: words, confidence: recognized.Confidence, corrections: corrections}
 }
 
 func (p *PDFProcessor) hasLowTextQuality(text string) bool {
@@ -291,17 +1213,11 @@ func (p *PDFProcessor) hasLowTextQuality(text string) bool {
 	return float64(specialChars)/float64(len(text)) > 0.1
 }
 
-func (p *PDFProcessor) combineTexts(originalText, ocrText string) string {
-	// Simple text combination logic
-	if len(originalText) > len(ocrText) {
-		return originalText
-	}
-	return ocrText
+func (p *PDFProcessor) calculateQualityMetrics(text string, pageCount int) QualityMetrics {
+	return qualityMetricsForLength(len(text), pageCount)
 }
 
-func (p *PDFProcessor) calculateQualityMetrics(text string, pageCount int) QualityMetrics {
-	textLength := len(text)
-	
+func qualityMetricsForLength(textLength, pageCount int) QualityMetrics {
 	// Simple quality calculations
 	textQuality := float64(textLength) / float64(pageCount*500) // Assume 500 chars per page is good
 	if textQuality > 1.0 {
@@ -317,66 +1233,104 @@ func (p *PDFProcessor) calculateQualityMetrics(text string, pageCount int) Quali
 	}
 }
 
-func (p *PDFProcessor) extractBasicEntities(text string) []ExtractedEntity {
+// extractBasicEntities scans text with the entityPatterns followed by the
+// tenant's custom patterns. pageOffsets holds the byte offset at which each
+// page starts; without it the page is unknown and reported as 0.
+func (p *PDFProcessor) extractBasicEntities(text string, pageOffsets []int, custom []entityPattern) []ExtractedEntity {
 	entities := []ExtractedEntity{}
 
-	// Simple entity extraction patterns
-	patterns := map[string]string{
-		"CNPJ":      `\d{2}\.\d{3}\.\d{3}/\d{4}-\d{2}`,
-		"CPF":       `\d{3}\.\d{3}\.\d{3}-\d{2}`,
-		"EMAIL":     `[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`,
-		"PHONE":     `\(\d{2}\)\s*\d{4,5}-\d{4}`,
-		"CURRENCY":  `R\$\s*\d{1,3}(?:\.\d{3})*(?:,\d{2})?`,
-		"DATE":      `\d{1,2}/\d{1,2}/\d{4}`,
-	}
+	patterns := append(entityPatterns[:len(entityPatterns):len(entityPatterns)], custom...)
+	for _, pattern := range patterns {
+		for _, loc := range pattern.re.FindAllStringIndex(text, -1) {
+			value := text[loc[0]:loc[1]]
+			confidence := pattern.confidence
+			if pattern.validate != nil && !pattern.validate(value) {
+				confidence = invalidEntityConfidence
+			}
 
-	// This is a simplified implementation
-	// In production, use proper regex and NLP libraries
-	for entityType, pattern := range patterns {
-		if strings.Contains(text, "CNPJ") && entityType == "CNPJ" {
 			entities = append(entities, ExtractedEntity{
-				Type:       entityType,
-				Value:      "XX.XXX.XXX/XXXX-XX", // Placeholder
-				Confidence: 0.85,
-				StartPos:   0,
-				EndPos:     20,
-				Page:       1,
+				Type:       pattern.entityType,
+				Value:      value,
+				Confidence: confidence,
+				StartPos:   loc[0],
+				EndPos:     loc[1],
+				Page:       pageForOffset(pageOffsets, loc[0]),
 			})
 		}
 	}
 
+	normalizeEntities(entities)
 	return entities
 }
 
-func (p *PDFProcessor) performBasicRiskAnalysis(text string) RiskAnalysis {
+func (p *PDFProcessor) performBasicRiskAnalysis(text string, pageOffsets []int, sections []Section, tenantID string, profile *RiskProfile) RiskAnalysis {
+	matches := p.riskRules.Evaluate(tenantID, text)
+	return buildRiskAnalysis(findRisks(matches, text, pageOffsets, sections), profile)
+}
+
+// DryRunRiskRules evaluates a rule set against the text of a processed job
+// without changing the job. With no rules, the rules currently applied to
+// the job's tenant are used. The job's risk profile, if any, is applied.
+func (p *PDFProcessor) DryRunRiskRules(ctx context.Context, jobID string, rules []risk.Rule) (RiskAnalysis, []risk.Match, error) {
+	job, err := p.GetJob(ctx, jobID)
+	if err != nil {
+		return RiskAnalysis{}, nil, err
+	}
+	if job.Result == nil {
+		return RiskAnalysis{}, nil, ErrJobNotProcessed
+	}
+
+	var matches []risk.Match
+	if rules == nil {
+		matches = p.riskRules.Evaluate(job.TenantID, job.Result.ExtractedText)
+	} else if matches, err = risk.EvaluateRules(rules, job.Result.ExtractedText); err != nil {
+		return RiskAnalysis{}, nil, err
+	}
+	text := job.Result.ExtractedText
+	profile := p.jobRiskProfile(ctx, job)
+	return buildRiskAnalysis(findRisks(matches, text, nil, job.Result.Sections), profile), matches, nil
+}
+
+// buildRiskAnalysis turns the matched risk rules into the job's analysis.
+// The score is the sum of the rule weights, scaled by the profile when one
+// is given, capped at 1.
+func buildRiskAnalysis(findings []riskFinding, profile *RiskProfile) RiskAnalysis {
 	risks := []IdentifiedRisk{}
 	riskScore := 0.0
+	recommendations := []string{}
+	seenRecommendations := make(map[string]bool)
 
-	// Simple risk detection
-	riskKeywords := map[string]float64{
-		"multa":           0.3,
-		"penalidade":      0.4,
-		"rescisão":        0.5,
-		"garantia":        0.2,
-		"caução":          0.3,
-		"prazo":           0.1,
-		"inexequível":     0.8,
-		"impugnação":      0.6,
-		"exclusivo":       0.4,
-	}
-
-	textLower := strings.ToLower(text)
-	for keyword, weight := range riskKeywords {
-		if strings.Contains(textLower, keyword) {
-			riskScore += weight
-			risks = append(risks, IdentifiedRisk{
-				Category:    "contractual",
-				Description: fmt.Sprintf("Detected keyword: %s", keyword),
-				Severity:    "medium",
-				Impact:      "financial",
-				Confidence:  0.7,
-				Location:    "document",
-			})
+	for _, finding := range findings {
+		rule := finding.match.Rule
+		riskScore += profile.weight(rule)
+
+		location := "document"
+		if finding.page > 0 {
+			location = fmt.Sprintf("page %d", finding.page)
+		}
+		if label, ok := sectionLabels[finding.section]; ok {
+			if finding.page > 0 {
+				location = fmt.Sprintf("%s section, page %d", label, finding.page)
+			} else {
+				location = label + " section"
+			}
+		}
+		risks = append(risks, IdentifiedRisk{
+			Category:    rule.Category,
+			Description: rule.Name,
+			Severity:    rule.Severity,
+			Impact:      rule.Impact,
+			Confidence:  0.7,
+			Location:    location,
+			Snippet:     finding.snippet,
+			Page:        finding.page,
+			StartPos:    finding.start,
+			EndPos:      finding.end,
+			Section:     finding.section,
+		})
+		if rule.Recommendation != "" && !seenRecommendations[rule.Recommendation] {
+			seenRecommendations[rule.Recommendation] = true
+			recommendations = append(recommendations, rule.Recommendation)
 		}
 	}
 
@@ -392,31 +1346,42 @@ func (p *PDFProcessor) performBasicRiskAnalysis(text string) RiskAnalysis {
 		overallRisk = "medium"
 	}
 
-	return RiskAnalysis{
+	if len(recommendations) == 0 {
+		recommendations = []string{"Review contract terms carefully", "Consult legal team"}
+	}
+
+	analysis := RiskAnalysis{
 		OverallRisk:     overallRisk,
 		RiskScore:       riskScore,
 		IdentifiedRisks: risks,
-		Recommendations: []string{"Review contract terms carefully", "Consult legal team"},
+		Recommendations: recommendations,
 		Confidence:      0.75,
 	}
+	if profile != nil {
+		analysis.Profile = profile.Name
+		analysis.ProfileID = profile.ID
+	}
+	return analysis
 }
 
-func (p *PDFProcessor) generateRelevanceScore(text string, metadata map[string]interface{}) float64 {
-	// Simple relevance scoring
-	score := 0.5 // Base score
+// Check for relevant keywords
+var relevantKeywords = []string{
+	"licitação", "pregão", "concorrência", "convite",
+	"serviços", "fornecimento", "obras", "compras",
+}
 
-	// Check for relevant keywords
-	relevantKeywords := []string{
-		"licitação", "pregão", "concorrência", "convite",
-		"serviços", "fornecimento", "obras", "compras",
-	}
+// generateRelevanceScore scores the result against the interest profile
+// referenced in the job's metadata, or the generic keywords without one.
+func (p *PDFProcessor) generateRelevanceScore(ctx context.Context, job *ProcessingJob, result *ProcessingResult) (float64, *RelevanceMatch) {
+	matcher := newRelevanceMatcher(p.jobInterestProfile(ctx, job))
+	matcher.add(result.ExtractedText)
+	matcher.addEntities(result.Entities)
+	return matcher.score(), matcher.details()
+}
 
-	textLower := strings.ToLower(text)
-	for _, keyword := range relevantKeywords {
-		if strings.Contains(textLower, keyword) {
-			score += 0.1
-		}
-	}
+func relevanceScoreFromHits(hits map[string]bool) float64 {
+	// Simple relevance scoring
+	score := 0.5 + 0.1*float64(len(hits)) // Base score plus keyword hits
 
 	if score > 1.0 {
 		score = 1.0
@@ -425,13 +1390,102 @@ func (p *PDFProcessor) generateRelevanceScore(text string, metadata map[string]i
 	return score
 }
 
+// SaveJob persists a job's current state so its status can be queried
+// before a worker picks it up.
+func (p *PDFProcessor) SaveJob(ctx context.Context, job *ProcessingJob) error {
+	return p.updateJobStatus(ctx, job)
+}
+
+// RejectJob records a job saved as queued that could not be submitted as
+// failed, so it is not left waiting for a worker.
+func (p *PDFProcessor) RejectJob(ctx context.Context, job *ProcessingJob, err error) {
+	job.Status = "failed"
+	job.Error = fmt.Sprintf("job could not be queued: %v", err)
+	job.ErrorCode = errorCode(err)
+	if err := p.updateJobStatus(ctx, job); err != nil {
+		log.Printf("Failed to record job %s as not queued: %v", job.ID, err)
+	}
+}
+
+// GetJob loads a job's last persisted state, if the context may see it
+// (see JobAccess).
+func (p *PDFProcessor) GetJob(ctx context.Context, id string) (*ProcessingJob, error) {
+	var job *ProcessingJob
+	var err error
+	if access, ok := jobAccessOf(ctx); ok && access.TenantID != "" {
+		job, err = p.readJob(ctx, jobKey(access.TenantID, id), id)
+	} else {
+		job, err = p.loadJob(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !jobAllowed(ctx, job.TenantID, job.UserID) {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// loadJob loads a job whatever the context may see, for the updates jobs
+// make to the jobs they depend on or belong to.
+func (p *PDFProcessor) loadJob(ctx context.Context, id string) (*ProcessingJob, error) {
+	tenantID, err := p.redis.Get(ctx, jobTenantKey(id))
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+	return p.readJob(ctx, jobKey(string(tenantID), id), id)
+}
+
+func (p *PDFProcessor) readJob(ctx context.Context, key, id string) (*ProcessingJob, error) {
+	data, err := p.redis.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) && key != jobKey("", id) {
+		// Jobs saved before their tenant's keys are kept until they expire
+		data, err = p.redis.Get(ctx, jobKey("", id))
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var job ProcessingJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("failed to decode job %s: %w", id, err)
+	}
+	return &job, nil
+}
+
 func (p *PDFProcessor) updateJobStatus(ctx context.Context, job *ProcessingJob) error {
-	jobData, err := json.Marshal(job)
+	// Marshal a copy so the document password never reaches storage
+	persisted := *job
+	persisted.Options.Password = ""
+
+	jobData, err := json.Marshal(&persisted)
 	if err != nil {
 		return err
 	}
 
-	return p.redis.Set(ctx, fmt.Sprintf("job:%s", job.ID), jobData, 24*time.Hour)
+	if err := p.redis.Set(ctx, jobKey(job.TenantID, job.ID), jobData, jobStatusTTL(job)); err != nil {
+		return err
+	}
+	if job.TenantID != "" {
+		if err := p.redis.Set(ctx, jobTenantKey(job.ID), job.TenantID, jobStatusTTL(job)); err != nil {
+			return err
+		}
+	}
+	if err := p.recordJob(ctx, job); err != nil {
+		log.Printf("Failed to record job %s in the history: %v", job.ID, err)
+	}
+	if err := p.meterJob(ctx, job); err != nil {
+		log.Printf("Failed to meter job %s: %v", job.ID, err)
+	}
+	p.publishUpdate(ctx, jobUpdateOf(job))
+	p.notifyFinished(ctx, job)
+	if isFinished(job.Status) {
+		p.releaseDependents(ctx, job)
+	}
+	return nil
 }
 
 func (p *PDFProcessor) storeResults(ctx context.Context, job *ProcessingJob) error {
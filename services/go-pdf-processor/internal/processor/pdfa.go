@@ -0,0 +1,105 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"cotai-pdf-processor/internal/report"
+)
+
+// pdfaTimeout bounds a Ghostscript conversion; large scans take minutes.
+const pdfaTimeout = 10 * time.Minute
+
+var ErrArchiveNotAvailable = errors.New("job has no archival copy")
+
+// attachArchivalCopy converts the job's PDF to PDF/A-2b and stores it next
+// to the report. Failures are logged and leave the result without a copy.
+func (p *PDFProcessor) attachArchivalCopy(ctx context.Context, job *ProcessingJob, filePath string) {
+	ctx, span := p.tracer.Start(ctx, "convert_pdfa")
+	defer span.End()
+
+	if p.reports == nil {
+		log.Printf("Archival copy requested for job %s but report storage is not configured", job.ID)
+		return
+	}
+
+	pdf, err := p.convertToPDFA(ctx, filePath, job.Options.Password)
+	if err != nil {
+		log.Printf("Failed to convert job %s to PDF/A: %v", job.ID, err)
+		return
+	}
+
+	artifact, err := p.reports.PublishArchive(ctx, job.ID, pdf)
+	if err != nil {
+		log.Printf("Failed to publish archival copy for job %s: %v", job.ID, err)
+		return
+	}
+	job.Result.Archive = artifact
+}
+
+// convertToPDFA rewrites a PDF as PDF/A-2b with Ghostscript. PDFA_DEF_PATH
+// names the PostScript definition file setting the output intent; without
+// it Ghostscript uses its default sRGB profile.
+func (p *PDFProcessor) convertToPDFA(ctx context.Context, filePath, password string) ([]byte, error) {
+	out, err := os.CreateTemp(p.cfg.TempDir, "cotai-pdfa-*.pdf")
+	if err != nil {
+		return nil, err
+	}
+	out.Close()
+	defer os.Remove(out.Name())
+
+	ctx, cancel := context.WithTimeout(ctx, pdfaTimeout)
+	defer cancel()
+
+	args := []string{
+		"-dPDFA=2",
+		"-dBATCH",
+		"-dNOPAUSE",
+		"-dSAFER",
+		"-dQUIET",
+		"-sDEVICE=pdfwrite",
+		"-sColorConversionStrategy=RGB",
+		"-dPDFACompatibilityPolicy=1",
+		"-sOutputFile=" + out.Name(),
+	}
+	if password != "" {
+		arg, remove, err := p.ghostscriptPassword(password)
+		if err != nil {
+			return nil, err
+		}
+		defer remove()
+		args = append(args, arg)
+	}
+	if p.cfg.PDFADefinition != "" {
+		args = append(args, p.cfg.PDFADefinition)
+	}
+	args = append(args, filePath)
+
+	if _, err := p.runGhostscript(ctx, args...); err != nil {
+		return nil, err
+	}
+
+	pdf, err := os.ReadFile(out.Name())
+	if err != nil {
+		return nil, err
+	}
+	if len(pdf) == 0 {
+		return nil, errors.New("ghostscript produced no output")
+	}
+	return pdf, nil
+}
+
+// ArchiveURL returns a fresh download URL for a job's archival copy.
+func (p *PDFProcessor) ArchiveURL(ctx context.Context, jobID string) (*report.Artifact, error) {
+	job, err := p.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if p.reports == nil || job.Result == nil || job.Result.Archive == nil {
+		return nil, ErrArchiveNotAvailable
+	}
+	return p.reports.Sign(ctx, job.Result.Archive.Key)
+}
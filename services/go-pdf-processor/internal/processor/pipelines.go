@@ -0,0 +1,414 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tenants compose processing pipelines: named, ordered lists of stages,
+// each with options of its own. A job submitted with the "pipeline" option
+// runs the stages of that pipeline only, in its order, which it keeps as
+// its Stages option. Stages are registered in pipelineStages; extraction
+// and OCR always run first, and streamed documents are analyzed page by
+// page in the default order.
+
+var (
+	ErrPipelineNotFound = errors.New("pipeline not found")
+	ErrInvalidPipeline  = errors.New("invalid pipeline")
+)
+
+// Pipeline is a named sequence of processing stages of a tenant.
+type Pipeline struct {
+	ID          string          `json:"id"`
+	TenantID    string          `json:"tenant_id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Stages      []PipelineStage `json:"stages"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// PipelineStage is a stage of a pipeline and the processing options it is
+// run with, among those the stage accepts.
+type PipelineStage struct {
+	Name    string          `json:"name"`
+	Options json.RawMessage `json:"options,omitempty"`
+}
+
+// analysisInput is what the analysis stages of a document work from.
+type analysisInput struct {
+	job         *ProcessingJob
+	result      *ProcessingResult
+	format      documentFormat
+	filePath    string
+	pageOffsets []int
+	wordBoxes   []wordBox
+}
+
+// pipelineStage is a stage pipelines may use.
+type pipelineStage struct {
+	// flag is the option that turns the stage on, nil for extraction
+	flag func(*ProcessingOptions) *bool
+
+	// options are the processing options the stage may be given
+	options []string
+
+	// run analyzes the document; extraction and OCR have none, as they
+	// run before the analysis stages
+	run func(ctx context.Context, p *PDFProcessor, in *analysisInput)
+}
+
+const (
+	stageNameExtract = "extract"
+	stageNameOCR     = "ocr"
+)
+
+var pipelineStages = map[string]pipelineStage{
+	stageNameExtract: {
+		options: []string{"max_pages", "layout_text", "text_blocks", "reflow_text", "detect_tables", "stream_pages", "recover_corrupted"},
+	},
+	stageNameOCR: {
+		flag: func(o *ProcessingOptions) *bool { return &o.EnableOCR },
+		options: []string{"languages", "dpi", "preprocess_images", "auto_rotate", "correct_spelling", "ocr_parallelism",
+			"ocr_provider", "ocr_layout", "ocr_zones", "searchable_pdf"},
+	},
+	"classify": {
+		flag: func(o *ProcessingOptions) *bool { return &o.ClassifyDocument },
+		run: func(ctx context.Context, p *PDFProcessor, in *analysisInput) {
+			classification := p.classifier.Classify(ctx, in.result.ExtractedText)
+			in.result.Classification = &classification
+		},
+	},
+	"sections": {
+		flag: func(o *ProcessingOptions) *bool { return &o.SegmentSections },
+		run: func(ctx context.Context, p *PDFProcessor, in *analysisInput) {
+			in.result.Sections = segmentSections(in.result.ExtractedText, in.pageOffsets)
+		},
+	},
+	"items": {
+		flag: func(o *ProcessingOptions) *bool { return &o.ExtractItems },
+		run: func(ctx context.Context, p *PDFProcessor, in *analysisInput) {
+			in.result.Items = extractBiddingItems(in.result.Tables, in.result.ExtractedText, in.pageOffsets)
+		},
+	},
+	"entities": {
+		flag:    func(o *ProcessingOptions) *bool { return &o.ExtractEntities },
+		options: []string{"ner_provider", "enrich_entities"},
+		run: func(ctx context.Context, p *PDFProcessor, in *analysisInput) {
+			job, result := in.job, in.result
+			custom := p.tenantPatterns(ctx, job.TenantID)
+			result.Entities = p.extractBasicEntities(result.ExtractedText, in.pageOffsets, custom)
+			if job.Options.NERProvider != "" {
+				named := p.extractNamedEntities(ctx, job.Options, result.ExtractedText, in.pageOffsets)
+				result.Entities = append(result.Entities, named...)
+			}
+			attachBoundingBoxes(result.Entities, in.wordBoxes)
+			if job.Options.EnrichEntities {
+				p.enrichEntities(ctx, result.Entities)
+			}
+		},
+	},
+	"barcodes": {
+		flag: func(o *ProcessingOptions) *bool { return &o.DetectBarcodes },
+		run: func(ctx context.Context, p *PDFProcessor, in *analysisInput) {
+			in.result.Entities = append(in.result.Entities, p.detectBarcodes(ctx, in.job, in.format, in.filePath)...)
+		},
+	},
+	"risks": {
+		flag:    func(o *ProcessingOptions) *bool { return &o.AnalyzeRisks },
+		options: []string{"risk_profile"},
+		run: func(ctx context.Context, p *PDFProcessor, in *analysisInput) {
+			profile := p.jobRiskProfile(ctx, in.job)
+			in.result.RiskAnalysis = p.performBasicRiskAnalysis(in.result.ExtractedText, in.pageOffsets, in.result.Sections, in.job.TenantID, profile)
+		},
+	},
+	"score": {
+		flag: func(o *ProcessingOptions) *bool { return &o.GenerateScore },
+		run: func(ctx context.Context, p *PDFProcessor, in *analysisInput) {
+			in.result.RelevanceScore, in.result.Relevance = p.generateRelevanceScore(ctx, in.job, in.result)
+		},
+	},
+}
+
+// defaultStages is the order stages run in for jobs without a pipeline,
+// each when its option is on.
+var defaultStages = []string{stageNameExtract, stageNameOCR, "classify", "sections", "items", "entities", "barcodes", "risks", "score"}
+
+// builtinPipelines are available to every tenant; a tenant pipeline with
+// the same name takes precedence.
+func builtinPipelines() map[string][]PipelineStage {
+	stages := func(names ...string) []PipelineStage {
+		s := make([]PipelineStage, len(names))
+		for i, name := range names {
+			s[i] = PipelineStage{Name: name}
+		}
+		return s
+	}
+	return map[string][]PipelineStage{
+		"standard":      stages(stageNameExtract, stageNameOCR, "classify", "sections", "items", "entities", "risks", "score"),
+		"text-only":     stages(stageNameExtract, stageNameOCR),
+		"risk-screen":   stages(stageNameExtract, stageNameOCR, "sections", "risks"),
+		"entities-only": stages(stageNameExtract, "entities"),
+	}
+}
+
+// runAnalysisStages runs the analysis stages of a job in its order.
+func (p *PDFProcessor) runAnalysisStages(ctx context.Context, in *analysisInput) {
+	stages := in.job.Options.Stages
+	if len(stages) == 0 {
+		stages = defaultStages
+	}
+	for _, name := range stages {
+		stage, ok := pipelineStages[name]
+		if !ok || stage.run == nil || !*stage.flag(&in.job.Options) {
+			continue
+		}
+		stage.run(ctx, p, in)
+	}
+}
+
+// validateStageOrder checks the stage names of a pipeline: known, listed
+// once, starting with extraction and followed by OCR when it is used.
+func validateStageOrder(names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	if names[0] != stageNameExtract {
+		return fmt.Errorf("the first stage must be %q", stageNameExtract)
+	}
+	seen := make(map[string]bool)
+	for i, name := range names {
+		if _, ok := pipelineStages[name]; !ok {
+			return fmt.Errorf("unknown stage %q", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("stage %q is listed twice", name)
+		}
+		seen[name] = true
+		if name == stageNameOCR && i != 1 {
+			return fmt.Errorf("stage %q must follow %q", stageNameOCR, stageNameExtract)
+		}
+	}
+	return nil
+}
+
+// validate normalizes the pipeline and checks its stages and their options.
+func (pl *Pipeline) validate() error {
+	pl.Name = strings.TrimSpace(pl.Name)
+	if pl.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPipeline)
+	}
+	if len(pl.Stages) == 0 {
+		return fmt.Errorf("%w: stages are required", ErrInvalidPipeline)
+	}
+	names := make([]string, len(pl.Stages))
+	for i, stage := range pl.Stages {
+		names[i] = stage.Name
+	}
+	if err := validateStageOrder(names); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPipeline, err)
+	}
+	var options ProcessingOptions
+	for _, stage := range pl.Stages {
+		if err := applyStageOptions(&options, stage); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPipeline, err)
+		}
+	}
+	return nil
+}
+
+// applyStageOptions sets the options a stage is given, refusing those the
+// stage does not accept.
+func applyStageOptions(options *ProcessingOptions, stage PipelineStage) error {
+	if len(stage.Options) == 0 || string(stage.Options) == "null" {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(stage.Options, &fields); err != nil {
+		return fmt.Errorf("options of stage %q: %v", stage.Name, err)
+	}
+	allowed := pipelineStages[stage.Name].options
+	for field := range fields {
+		if !containsString(allowed, field) {
+			return fmt.Errorf("stage %q does not accept option %q (accepted: %s)", stage.Name, field, strings.Join(allowed, ", "))
+		}
+	}
+	if err := json.Unmarshal(stage.Options, options); err != nil {
+		return fmt.Errorf("options of stage %q: %v", stage.Name, err)
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// applyPipeline turns on the stages of a pipeline, and only those, with
+// their options, and keeps their order in the options.
+func applyPipeline(options *ProcessingOptions, stages []PipelineStage) error {
+	for _, stage := range pipelineStages {
+		if stage.flag != nil {
+			*stage.flag(options) = false
+		}
+	}
+	options.Stages = make([]string, len(stages))
+	for i, stage := range stages {
+		if flag := pipelineStages[stage.Name].flag; flag != nil {
+			*flag(options) = true
+		}
+		if err := applyStageOptions(options, stage); err != nil {
+			return err
+		}
+		options.Stages[i] = stage.Name
+	}
+	return nil
+}
+
+// StageNames lists the stages pipelines may use.
+func StageNames() []string {
+	names := make([]string, 0, len(pipelineStages))
+	for name := range pipelineStages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+const pipelineColumns = `id, tenant_id, name, description, stages, created_at, updated_at`
+
+func scanPipeline(row rowScanner) (*Pipeline, error) {
+	var pl Pipeline
+	var stages []byte
+	err := row.Scan(&pl.ID, &pl.TenantID, &pl.Name, &pl.Description, &stages, &pl.CreatedAt, &pl.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPipelineNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(stages, &pl.Stages); err != nil {
+		return nil, fmt.Errorf("invalid stages for pipeline %s: %w", pl.ID, err)
+	}
+	return &pl, nil
+}
+
+// ListPipelines returns a tenant's pipelines by name.
+func (p *PDFProcessor) ListPipelines(ctx context.Context, tenantID string) ([]Pipeline, error) {
+	rows, err := p.postgres.Query(ctx,
+		`SELECT `+pipelineColumns+` FROM pipelines WHERE tenant_id = $1 ORDER BY name`,
+		tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pipelines: %w", err)
+	}
+	defer rows.Close()
+
+	pipelines := []Pipeline{}
+	for rows.Next() {
+		pl, err := scanPipeline(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pipeline: %w", err)
+		}
+		pipelines = append(pipelines, *pl)
+	}
+	return pipelines, rows.Err()
+}
+
+func (p *PDFProcessor) GetPipeline(ctx context.Context, tenantID, id string) (*Pipeline, error) {
+	return scanPipeline(p.postgres.QueryRow(ctx,
+		`SELECT `+pipelineColumns+` FROM pipelines WHERE tenant_id = $1 AND id = $2`,
+		tenantID, id))
+}
+
+func (p *PDFProcessor) CreatePipeline(ctx context.Context, pl *Pipeline) error {
+	if err := pl.validate(); err != nil {
+		return err
+	}
+	stages, err := json.Marshal(pl.Stages)
+	if err != nil {
+		return err
+	}
+
+	pl.ID = uuid.New().String()
+	pl.CreatedAt = time.Now()
+	pl.UpdatedAt = pl.CreatedAt
+
+	err = p.postgres.Exec(ctx, `
+		INSERT INTO pipelines (`+pipelineColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, pl.ID, pl.TenantID, pl.Name, pl.Description, stages, pl.CreatedAt, pl.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create pipeline: %w", err)
+	}
+	return nil
+}
+
+func (p *PDFProcessor) UpdatePipeline(ctx context.Context, pl *Pipeline) error {
+	if err := pl.validate(); err != nil {
+		return err
+	}
+	stages, err := json.Marshal(pl.Stages)
+	if err != nil {
+		return err
+	}
+
+	updated, err := scanPipeline(p.postgres.QueryRow(ctx, `
+		UPDATE pipelines
+		SET name = $3, description = $4, stages = $5, updated_at = $6
+		WHERE tenant_id = $1 AND id = $2
+		RETURNING `+pipelineColumns,
+		pl.TenantID, pl.ID, pl.Name, pl.Description, stages, time.Now()))
+	if err != nil {
+		return err
+	}
+
+	*pl = *updated
+	return nil
+}
+
+func (p *PDFProcessor) DeletePipeline(ctx context.Context, tenantID, id string) error {
+	var deleted string
+	err := p.postgres.QueryRow(ctx,
+		`DELETE FROM pipelines WHERE tenant_id = $1 AND id = $2 RETURNING id`,
+		tenantID, id).Scan(&deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPipelineNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete pipeline: %w", err)
+	}
+	return nil
+}
+
+// pipelineStagesNamed loads the stages of the named pipeline of a tenant,
+// or of a built-in one.
+func (p *PDFProcessor) pipelineStagesNamed(ctx context.Context, tenantID, name string) ([]PipelineStage, error) {
+	if tenantID != "" {
+		pl, err := scanPipeline(p.postgres.QueryRow(ctx,
+			`SELECT `+pipelineColumns+` FROM pipelines WHERE tenant_id = $1 AND name = $2`,
+			tenantID, name))
+		if err == nil {
+			return pl.Stages, nil
+		}
+		if !errors.Is(err, ErrPipelineNotFound) {
+			return nil, fmt.Errorf("failed to load pipeline %q: %w", name, err)
+		}
+	}
+
+	if stages, ok := builtinPipelines()[name]; ok {
+		return stages, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrPipelineNotFound, name)
+}
@@ -0,0 +1,295 @@
+package processor
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// Page images are cleaned up before OCR: contrast is stretched, specks are
+// removed with a median filter and the page is deskewed, then binarized
+// with Sauvola's adaptive threshold, which copes with uneven lighting and
+// stained paper better than a global one. The cleaned grayscale image
+// replaces the page image, so word boxes found on the binarized image, which
+// has the same geometry, line up with it in the searchable PDF.
+
+const (
+	// contrastClip is the fraction of the darkest and of the lightest
+	// pixels clipped when stretching the contrast.
+	contrastClip = 0.01
+
+	// maxSkewAngle bounds the skew looked for, in degrees; minSkewAngle is
+	// the smallest corrected.
+	maxSkewAngle = 5.0
+	minSkewAngle = 0.1
+
+	// skewSampleWidth is the width the page is reduced to for finding the
+	// skew angle.
+	skewSampleWidth = 800
+
+	// sauvolaK weighs the local deviation in Sauvola's threshold and
+	// sauvolaWindow is the window width in inches.
+	sauvolaK      = 0.34
+	sauvolaWindow = 1.0 / 12
+)
+
+// preprocessPage writes the cleaned and the binarized versions of a page
+// image into dir, and returns the skew corrected, in degrees.
+func preprocessPage(path, dir string, number int, dpi float64) (cleaned, binarized string, skew float64, err error) {
+	src, err := decodeImageFile(path)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	gray := toGray(src)
+	normalizeContrast(gray)
+	gray = medianFilter(gray)
+	if angle := detectSkew(gray); math.Abs(angle) >= minSkewAngle {
+		gray = rotateGray(gray, angle)
+		skew = angle
+	}
+
+	cleaned = filepath.Join(dir, fmt.Sprintf("clean-%d.png", number))
+	if err := writePNG(cleaned, gray); err != nil {
+		return "", "", 0, err
+	}
+	binarized = filepath.Join(dir, fmt.Sprintf("binary-%d.png", number))
+	if err := writePNG(binarized, sauvolaBinarize(gray, int(dpi*sauvolaWindow))); err != nil {
+		return "", "", 0, err
+	}
+	return cleaned, binarized, skew, nil
+}
+
+func toGray(src image.Image) *image.Gray {
+	if gray, ok := src.(*image.Gray); ok && gray.Rect.Min == (image.Point{}) {
+		return gray
+	}
+	bounds := src.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(gray, gray.Rect, src, bounds.Min, draw.Src)
+	return gray
+}
+
+// normalizeContrast stretches the pixel values so the darkest and lightest
+// contrastClip of the pixels become black and white.
+func normalizeContrast(img *image.Gray) {
+	var hist [256]int
+	for _, v := range img.Pix {
+		hist[v]++
+	}
+	clip := int(float64(len(img.Pix)) * contrastClip)
+	lo, hi := 0, 255
+	for count := 0; lo < 255 && count+hist[lo] <= clip; lo++ {
+		count += hist[lo]
+	}
+	for count := 0; hi > 0 && count+hist[hi] <= clip; hi-- {
+		count += hist[hi]
+	}
+	if hi-lo < 16 {
+		return // a blank page, nothing to stretch
+	}
+
+	var lut [256]uint8
+	for v := range lut {
+		scaled := (v - lo) * 255 / (hi - lo)
+		lut[v] = uint8(min(255, max(0, scaled)))
+	}
+	for i, v := range img.Pix {
+		img.Pix[i] = lut[v]
+	}
+}
+
+// medianFilter replaces each pixel with the median of its 3x3 neighborhood,
+// removing isolated specks while keeping edges.
+func medianFilter(img *image.Gray) *image.Gray {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	out := image.NewGray(img.Rect)
+	var window [9]uint8
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			n := 0
+			for dy := -1; dy <= 1; dy++ {
+				yy := min(h-1, max(0, y+dy))
+				for dx := -1; dx <= 1; dx++ {
+					xx := min(w-1, max(0, x+dx))
+					window[n] = img.Pix[yy*img.Stride+xx]
+					n++
+				}
+			}
+			// Insertion sort is fastest for nine values
+			for i := 1; i < 9; i++ {
+				for j := i; j > 0 && window[j] < window[j-1]; j-- {
+					window[j], window[j-1] = window[j-1], window[j]
+				}
+			}
+			out.Pix[y*out.Stride+x] = window[4]
+		}
+	}
+	return out
+}
+
+// detectSkew returns the angle, in degrees, the text lines descend by from
+// left to right. The dark pixels of a reduced copy are projected onto rows
+// at each candidate angle; the angle aligning the rows with the text lines
+// gives the sharpest profile.
+func detectSkew(img *image.Gray) float64 {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	step := max(1, w/skewSampleWidth)
+	threshold := otsuThreshold(img)
+
+	var xs, ys []float64
+	for y := 0; y < h; y += step {
+		for x := 0; x < w; x += step {
+			if img.Pix[y*img.Stride+x] < threshold {
+				xs = append(xs, float64(x/step))
+				ys = append(ys, float64(y/step))
+			}
+		}
+	}
+	if len(xs) < 100 {
+		return 0
+	}
+
+	rows := make(map[int]int)
+	score := func(angle float64) float64 {
+		clear(rows)
+		sin, cos := math.Sincos(angle * math.Pi / 180)
+		for i := range xs {
+			rows[int(math.Round(ys[i]*cos-xs[i]*sin))]++
+		}
+		var sum float64
+		for _, n := range rows {
+			sum += float64(n) * float64(n)
+		}
+		return sum
+	}
+
+	best, bestScore := 0.0, score(0)
+	search := func(from, to, by float64) {
+		for angle := from; angle <= to+by/2; angle += by {
+			if s := score(angle); s > bestScore {
+				best, bestScore = angle, s
+			}
+		}
+	}
+	search(-maxSkewAngle, maxSkewAngle, 0.5)
+	search(best-0.5, best+0.5, 0.1)
+	return best
+}
+
+// otsuThreshold returns the gray level best separating ink from paper.
+func otsuThreshold(img *image.Gray) uint8 {
+	var hist [256]float64
+	for _, v := range img.Pix {
+		hist[v]++
+	}
+	total := float64(len(img.Pix))
+	var sum float64
+	for v, n := range hist {
+		sum += float64(v) * n
+	}
+
+	var sumBack, weightBack, bestVariance float64
+	threshold := 128
+	for v, n := range hist {
+		weightBack += n
+		if weightBack == 0 {
+			continue
+		}
+		weightFore := total - weightBack
+		if weightFore == 0 {
+			break
+		}
+		sumBack += float64(v) * n
+		meanBack, meanFore := sumBack/weightBack, (sum-sumBack)/weightFore
+		if variance := weightBack * weightFore * (meanBack - meanFore) * (meanBack - meanFore); variance > bestVariance {
+			bestVariance, threshold = variance, v+1
+		}
+	}
+	return uint8(min(255, threshold))
+}
+
+// rotateGray rotates the image about its center so lines descending by
+// angle degrees become level. Uncovered corners are white.
+func rotateGray(img *image.Gray, angle float64) *image.Gray {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	out := image.NewGray(img.Rect)
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	cx, cy := float64(w)/2, float64(h)/2
+	at := func(x, y int) float64 {
+		if x < 0 || y < 0 || x >= w || y >= h {
+			return 255
+		}
+		return float64(img.Pix[y*img.Stride+x])
+	}
+
+	for y := 0; y < h; y++ {
+		dy := float64(y) - cy
+		for x := 0; x < w; x++ {
+			dx := float64(x) - cx
+			sx, sy := dx*cos-dy*sin+cx, dx*sin+dy*cos+cy
+			x0, y0 := int(math.Floor(sx)), int(math.Floor(sy))
+			fx, fy := sx-float64(x0), sy-float64(y0)
+			top := at(x0, y0)*(1-fx) + at(x0+1, y0)*fx
+			bottom := at(x0, y0+1)*(1-fx) + at(x0+1, y0+1)*fx
+			out.Pix[y*out.Stride+x] = uint8(math.Round(top*(1-fy) + bottom*fy))
+		}
+	}
+	return out
+}
+
+// sauvolaBinarize thresholds each pixel at mean*(1+k*(stddev/128-1)) of
+// the window around it, using integral images of the values and their
+// squares.
+func sauvolaBinarize(img *image.Gray, window int) *image.Gray {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	window = max(window, 15)
+	half := window / 2
+
+	stride := w + 1
+	sum := make([]float64, stride*(h+1))
+	sumSq := make([]float64, stride*(h+1))
+	for y := 0; y < h; y++ {
+		var rowSum, rowSumSq float64
+		for x := 0; x < w; x++ {
+			v := float64(img.Pix[y*img.Stride+x])
+			rowSum += v
+			rowSumSq += v * v
+			sum[(y+1)*stride+x+1] = sum[y*stride+x+1] + rowSum
+			sumSq[(y+1)*stride+x+1] = sumSq[y*stride+x+1] + rowSumSq
+		}
+	}
+
+	out := image.NewGray(img.Rect)
+	for y := 0; y < h; y++ {
+		y0, y1 := max(0, y-half), min(h, y+half+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := max(0, x-half), min(w, x+half+1)
+			n := float64((x1 - x0) * (y1 - y0))
+			s := sum[y1*stride+x1] - sum[y0*stride+x1] - sum[y1*stride+x0] + sum[y0*stride+x0]
+			sq := sumSq[y1*stride+x1] - sumSq[y0*stride+x1] - sumSq[y1*stride+x0] + sumSq[y0*stride+x0]
+			mean := s / n
+			deviation := math.Sqrt(max(0, sq/n-mean*mean))
+			if float64(img.Pix[y*img.Stride+x]) > mean*(1+sauvolaK*(deviation/128-1)) {
+				out.Pix[y*out.Stride+x] = 255
+			}
+		}
+	}
+	return out
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
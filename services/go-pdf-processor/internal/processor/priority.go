@@ -0,0 +1,38 @@
+package processor
+
+import (
+	"errors"
+
+	"cotai-pdf-processor/internal/queue"
+)
+
+// Job priorities. Queued jobs are picked in priority order, so an urgent
+// edital is processed ahead of a backfill; an empty priority is normal.
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
+
+// ErrInvalidPriority is returned for priorities other than high, normal and
+// low.
+var ErrInvalidPriority = errors.New("priority must be high, normal or low")
+
+// ValidatePriority checks a job priority; empty is allowed.
+func ValidatePriority(priority string) error {
+	switch priority {
+	case "", PriorityHigh, PriorityNormal, PriorityLow:
+		return nil
+	}
+	return ErrInvalidPriority
+}
+
+func queuePriority(priority string) queue.Priority {
+	switch priority {
+	case PriorityHigh:
+		return queue.PriorityHigh
+	case PriorityLow:
+		return queue.PriorityLow
+	}
+	return queue.PriorityNormal
+}
@@ -0,0 +1,276 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tenants save option presets as processing profiles and submit jobs with
+// a profile name instead of the full options. Options sent alongside the
+// profile override only the fields they set.
+
+// maxProfileDPI bounds the rendering resolution a profile may ask for.
+const maxProfileDPI = 1200
+
+var (
+	ErrProcessingProfileNotFound = errors.New("processing profile not found")
+	ErrInvalidProcessingProfile  = errors.New("invalid processing profile")
+	ErrInvalidOptions            = errors.New("invalid options")
+)
+
+// ProcessingProfile is a named set of processing options of a tenant.
+type ProcessingProfile struct {
+	ID        string            `json:"id"`
+	TenantID  string            `json:"tenant_id"`
+	Name      string            `json:"name"`
+	Options   ProcessingOptions `json:"options"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// builtinProcessingProfiles are available to every tenant; a tenant profile
+// with the same name takes precedence.
+func builtinProcessingProfiles() map[string]ProcessingOptions {
+	full := DefaultProcessingOptions()
+	full.EnrichEntities = true
+	full.GenerateReport = true
+	full.TextBlocks = true
+
+	return map[string]ProcessingOptions{
+		"full-analysis": full,
+		"ocr-only": {
+			EnableOCR:        true,
+			Languages:        []string{"por", "eng"},
+			DPI:              300,
+			RecoverCorrupted: true,
+			SearchablePDF:    true,
+			PreprocessImages: true,
+			AutoRotate:       true,
+			CorrectSpelling:  true,
+			ReflowText:       true,
+		},
+		"quick-scan": {
+			Languages:        []string{"por"},
+			ExtractEntities:  true,
+			AnalyzeRisks:     true,
+			GenerateScore:    true,
+			ClassifyDocument: true,
+			SegmentSections:  true,
+			ExtractItems:     true,
+			DetectTables:     true,
+			LayoutText:       true,
+			ReflowText:       true,
+			MaxPages:         20,
+			RecoverCorrupted: true,
+		},
+	}
+}
+
+// validate normalizes the profile and checks its options. Passwords are
+// document-specific and never stored in a profile.
+func (pp *ProcessingProfile) validate(p *PDFProcessor) error {
+	pp.Name = strings.TrimSpace(pp.Name)
+	pp.Options.Password = ""
+	switch {
+	case pp.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidProcessingProfile)
+	case pp.Options.MaxPages < 0:
+		return fmt.Errorf("%w: max_pages must not be negative", ErrInvalidProcessingProfile)
+	case pp.Options.DPI < 0 || pp.Options.DPI > maxProfileDPI:
+		return fmt.Errorf("%w: dpi must be between 0 and %d", ErrInvalidProcessingProfile, maxProfileDPI)
+	case pp.Options.OCRParallelism < 0:
+		return fmt.Errorf("%w: ocr_parallelism must not be negative", ErrInvalidProcessingProfile)
+	case pp.Options.TimeoutSeconds < 0:
+		return fmt.Errorf("%w: timeout_seconds must not be negative", ErrInvalidProcessingProfile)
+	}
+	if err := validateStageOrder(pp.Options.Stages); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProcessingProfile, err)
+	}
+	if _, ok := ocrLayoutContentTypes[pp.Options.OCRLayout]; pp.Options.OCRLayout != "" && !ok {
+		return fmt.Errorf("%w: ocr_layout must be hocr, alto or tsv", ErrInvalidProcessingProfile)
+	}
+	for _, zone := range pp.Options.OCRZones {
+		if err := zone.validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidProcessingProfile, err)
+		}
+	}
+	if pp.Options.NERProvider != "" {
+		if _, err := p.recognizers.Get(pp.Options.NERProvider); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidProcessingProfile, err)
+		}
+	}
+	if pp.Options.OCRProvider != "" {
+		if _, err := p.ocrEngines.Get(pp.Options.OCRProvider); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidProcessingProfile, err)
+		}
+	}
+	return nil
+}
+
+const processingProfileColumns = `id, tenant_id, name, options, created_at, updated_at`
+
+func scanProcessingProfile(row rowScanner) (*ProcessingProfile, error) {
+	var pp ProcessingProfile
+	var options []byte
+	err := row.Scan(&pp.ID, &pp.TenantID, &pp.Name, &options, &pp.CreatedAt, &pp.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProcessingProfileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(options, &pp.Options); err != nil {
+		return nil, fmt.Errorf("invalid options for processing profile %s: %w", pp.ID, err)
+	}
+	return &pp, nil
+}
+
+// ListProcessingProfiles returns a tenant's processing profiles by name.
+func (p *PDFProcessor) ListProcessingProfiles(ctx context.Context, tenantID string) ([]ProcessingProfile, error) {
+	rows, err := p.postgres.Query(ctx,
+		`SELECT `+processingProfileColumns+` FROM processing_profiles WHERE tenant_id = $1 ORDER BY name`,
+		tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processing profiles: %w", err)
+	}
+	defer rows.Close()
+
+	profiles := []ProcessingProfile{}
+	for rows.Next() {
+		pp, err := scanProcessingProfile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read processing profile: %w", err)
+		}
+		profiles = append(profiles, *pp)
+	}
+	return profiles, rows.Err()
+}
+
+func (p *PDFProcessor) GetProcessingProfile(ctx context.Context, tenantID, id string) (*ProcessingProfile, error) {
+	return scanProcessingProfile(p.postgres.QueryRow(ctx,
+		`SELECT `+processingProfileColumns+` FROM processing_profiles WHERE tenant_id = $1 AND id = $2`,
+		tenantID, id))
+}
+
+func (p *PDFProcessor) CreateProcessingProfile(ctx context.Context, pp *ProcessingProfile) error {
+	if err := pp.validate(p); err != nil {
+		return err
+	}
+	options, err := json.Marshal(pp.Options)
+	if err != nil {
+		return err
+	}
+
+	pp.ID = uuid.New().String()
+	pp.CreatedAt = time.Now()
+	pp.UpdatedAt = pp.CreatedAt
+
+	err = p.postgres.Exec(ctx, `
+		INSERT INTO processing_profiles (`+processingProfileColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, pp.ID, pp.TenantID, pp.Name, options, pp.CreatedAt, pp.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create processing profile: %w", err)
+	}
+	return nil
+}
+
+func (p *PDFProcessor) UpdateProcessingProfile(ctx context.Context, pp *ProcessingProfile) error {
+	if err := pp.validate(p); err != nil {
+		return err
+	}
+	options, err := json.Marshal(pp.Options)
+	if err != nil {
+		return err
+	}
+
+	updated, err := scanProcessingProfile(p.postgres.QueryRow(ctx, `
+		UPDATE processing_profiles
+		SET name = $3, options = $4, updated_at = $5
+		WHERE tenant_id = $1 AND id = $2
+		RETURNING `+processingProfileColumns,
+		pp.TenantID, pp.ID, pp.Name, options, time.Now()))
+	if err != nil {
+		return err
+	}
+
+	*pp = *updated
+	return nil
+}
+
+func (p *PDFProcessor) DeleteProcessingProfile(ctx context.Context, tenantID, id string) error {
+	var deleted string
+	err := p.postgres.QueryRow(ctx,
+		`DELETE FROM processing_profiles WHERE tenant_id = $1 AND id = $2 RETURNING id`,
+		tenantID, id).Scan(&deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrProcessingProfileNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete processing profile: %w", err)
+	}
+	return nil
+}
+
+// ResolveOptions sets a submitted job's options from the named profile of
+// its tenant, or a built-in one, and then applies overrides, a JSON object
+// of ProcessingOptions fields. Without a profile the overrides apply to the
+// default options.
+func (p *PDFProcessor) ResolveOptions(ctx context.Context, job *ProcessingJob, profile string, overrides []byte) error {
+	if profile != "" {
+		options, err := p.processingProfileOptions(ctx, job.TenantID, profile)
+		if err != nil {
+			return err
+		}
+		job.Options = options
+		job.Profile = profile
+	}
+
+	if len(overrides) > 0 {
+		if err := json.Unmarshal(overrides, &job.Options); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidOptions, err)
+		}
+	}
+
+	// The pipeline's stages, with their options, take the place of the
+	// stages turned on by the profile and overrides
+	if job.Options.Pipeline != "" {
+		stages, err := p.pipelineStagesNamed(ctx, job.TenantID, job.Options.Pipeline)
+		if err != nil {
+			return err
+		}
+		if err := applyPipeline(&job.Options, stages); err != nil {
+			return fmt.Errorf("%w: pipeline %q: %v", ErrInvalidOptions, job.Options.Pipeline, err)
+		}
+	}
+	if err := validateStageOrder(job.Options.Stages); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidOptions, err)
+	}
+	return nil
+}
+
+func (p *PDFProcessor) processingProfileOptions(ctx context.Context, tenantID, name string) (ProcessingOptions, error) {
+	if tenantID != "" {
+		pp, err := scanProcessingProfile(p.postgres.QueryRow(ctx,
+			`SELECT `+processingProfileColumns+` FROM processing_profiles WHERE tenant_id = $1 AND name = $2`,
+			tenantID, name))
+		if err == nil {
+			return pp.Options, nil
+		}
+		if !errors.Is(err, ErrProcessingProfileNotFound) {
+			return ProcessingOptions{}, fmt.Errorf("failed to load processing profile %q: %w", name, err)
+		}
+	}
+
+	if options, ok := builtinProcessingProfiles()[name]; ok {
+		return options, nil
+	}
+	return ProcessingOptions{}, fmt.Errorf("%w: %q", ErrProcessingProfileNotFound, name)
+}
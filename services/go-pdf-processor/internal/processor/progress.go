@@ -0,0 +1,152 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"cotai-pdf-processor/internal/storage"
+)
+
+// Running jobs report their progress, the current stage and the pages it
+// has done, to a Redis key of their own, so pages finishing on parallel
+// OCR workers do not rewrite the job status. The job status endpoint shows
+// it while the job is processing. The tracker travels in the job's
+// context, where stages that go page by page find it.
+
+// Processing stages reported in JobProgress.
+const (
+	progressDownloading = "downloading"
+	progressExtracting  = "extracting"
+	progressOCR         = "ocr"
+	progressAnalyzing   = "analyzing"
+	progressFinishing   = "finishing"
+)
+
+// progressSpans are the share of the job each stage covers, in percent.
+var progressSpans = map[string][2]float64{
+	progressDownloading: {0, 5},
+	progressExtracting:  {5, 30},
+	progressOCR:         {30, 85},
+	progressAnalyzing:   {85, 95},
+	progressFinishing:   {95, 100},
+}
+
+// progressInterval bounds how often page progress is written.
+const progressInterval = time.Second
+
+// JobProgress is how far a running job has got.
+type JobProgress struct {
+	Stage      string    `json:"stage"`
+	PagesDone  int       `json:"pages_done,omitempty"`
+	PagesTotal int       `json:"pages_total,omitempty"`
+	Percent    int       `json:"percent"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func progressKey(jobID string) string {
+	return fmt.Sprintf("job-progress:%s", jobID)
+}
+
+// progressTracker records the progress of one job. A nil tracker records
+// nothing.
+type progressTracker struct {
+	p     *PDFProcessor
+	jobID string
+
+	mu        sync.Mutex
+	progress  JobProgress
+	lastWrite time.Time
+}
+
+type progressKeyType struct{}
+
+func withProgress(ctx context.Context, t *progressTracker) context.Context {
+	return context.WithValue(ctx, progressKeyType{}, t)
+}
+
+func progressFrom(ctx context.Context) *progressTracker {
+	t, _ := ctx.Value(progressKeyType{}).(*progressTracker)
+	return t
+}
+
+// stage starts a stage with no pages done.
+func (t *progressTracker) stage(ctx context.Context, stage string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.progress = JobProgress{Stage: stage}
+	t.write(ctx)
+}
+
+// pages sets the number of pages the current stage goes through.
+func (t *progressTracker) pages(ctx context.Context, total int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.progress.PagesDone, t.progress.PagesTotal = 0, total
+	t.write(ctx)
+}
+
+// pageDone counts a page of the current stage, writing the progress at
+// most every progressInterval and after the last page.
+func (t *progressTracker) pageDone(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.progress.PagesDone++
+	if t.progress.PagesDone >= t.progress.PagesTotal || time.Since(t.lastWrite) >= progressInterval {
+		t.write(ctx)
+	}
+}
+
+func (t *progressTracker) write(ctx context.Context) {
+	span := progressSpans[t.progress.Stage]
+	percent := span[0]
+	if t.progress.PagesTotal > 0 {
+		done := min(t.progress.PagesDone, t.progress.PagesTotal)
+		percent += (span[1] - span[0]) * float64(done) / float64(t.progress.PagesTotal)
+	}
+	t.progress.Percent = int(math.Floor(percent))
+	t.progress.UpdatedAt = time.Now()
+	t.lastWrite = t.progress.UpdatedAt
+
+	data, err := json.Marshal(t.progress)
+	if err != nil {
+		return
+	}
+	if err := t.p.redis.Set(ctx, progressKey(t.jobID), data, 24*time.Hour); err != nil && ctx.Err() == nil {
+		log.Printf("Failed to record progress of job %s: %v", t.jobID, err)
+	}
+	progress := t.progress
+	t.p.publishUpdate(ctx, JobUpdate{JobID: t.jobID, Status: "processing", Progress: &progress})
+}
+
+// JobProgress returns the last progress recorded for a job, or nil if none
+// was.
+func (p *PDFProcessor) JobProgress(ctx context.Context, id string) (*JobProgress, error) {
+	data, err := p.redis.Get(ctx, progressKey(id))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var progress JobProgress
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil, fmt.Errorf("failed to decode progress of job %s: %w", id, err)
+	}
+	return &progress, nil
+}
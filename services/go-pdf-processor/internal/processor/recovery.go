@@ -0,0 +1,143 @@
+package processor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/ledongthuc/pdf"
+)
+
+var (
+	objectHeaderRe = regexp.MustCompile(`(?m)(?:^|[\r\n\s])(\d{1,10})\s+(\d{1,5})\s+obj\b`)
+	catalogRe      = regexp.MustCompile(`/Type\s*/Catalog\b`)
+)
+
+// repairPDF writes a copy of a damaged PDF with a freshly built
+// cross-reference table appended. Objects are located by scanning for
+// "N G obj" headers (the last definition wins, as with incremental updates)
+// and the catalog becomes the trailer's /Root. Returns the repaired file's
+// path; the caller removes it.
+func repairPDF(filePath, tempDir string) (string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\r\n\t "), []byte("%PDF-")) {
+		return "", errors.New("missing PDF header")
+	}
+
+	type objectRef struct {
+		offset     int
+		generation int
+	}
+	objects := make(map[int]objectRef)
+	maxObject := 0
+	root := ""
+
+	matches := objectHeaderRe.FindAllSubmatchIndex(data, -1)
+	for i, m := range matches {
+		num, _ := strconv.Atoi(string(data[m[2]:m[3]]))
+		gen, _ := strconv.Atoi(string(data[m[4]:m[5]]))
+		objects[num] = objectRef{offset: m[2], generation: gen}
+		if num > maxObject {
+			maxObject = num
+		}
+
+		end := len(data)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		body := data[m[1]:end]
+		if idx := bytes.Index(body, []byte("endobj")); idx >= 0 {
+			body = body[:idx]
+		}
+		if catalogRe.Match(body) {
+			root = fmt.Sprintf("%d %d R", num, gen)
+		}
+	}
+
+	if len(objects) == 0 {
+		return "", errors.New("no objects found")
+	}
+	if root == "" {
+		return "", errors.New("document catalog not found")
+	}
+
+	var out bytes.Buffer
+	out.Write(data)
+	out.WriteString("\n")
+
+	xrefOffset := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n", maxObject+1)
+	out.WriteString("0000000000 65535 f\r\n")
+	for num := 1; num <= maxObject; num++ {
+		if obj, ok := objects[num]; ok {
+			fmt.Fprintf(&out, "%010d %05d n\r\n", obj.offset, obj.generation)
+		} else {
+			out.WriteString("0000000000 00000 f\r\n")
+		}
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %s >>\nstartxref\n%d\n%%%%EOF\n", maxObject+1, root, xrefOffset)
+
+	tmp, err := os.CreateTemp(tempDir, "cotai-repaired-*.pdf")
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.Write(out.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+
+	return tmp.Name(), nil
+}
+
+var errPageMissing = errors.New("page object missing")
+
+// The pdf package panics on malformed objects, so page access is wrapped to
+// turn those panics into errors.
+
+func safePageCount(reader *pdf.Reader) (count int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed page tree: %v", r)
+		}
+	}()
+	return reader.NumPage(), nil
+}
+
+func safePageText(reader *pdf.Reader, num int) (text string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed page: %v", r)
+		}
+	}()
+
+	page := reader.Page(num)
+	if page.V.IsNull() {
+		return "", errPageMissing
+	}
+	return page.GetPlainText(nil)
+}
+
+func safePageContent(reader *pdf.Reader, num int) (content pdf.Content, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed page: %v", r)
+		}
+	}()
+
+	page := reader.Page(num)
+	if page.V.IsNull() {
+		return pdf.Content{}, errPageMissing
+	}
+	return page.Content(), nil
+}
@@ -0,0 +1,320 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cotai-pdf-processor/internal/report"
+)
+
+// Redaction removes CPFs, phone numbers and e-mail addresses from a copy of
+// the document. Rewriting content streams would leave the text in fonts,
+// annotations and metadata, so the copy is rebuilt instead: each page is
+// rasterized, the words holding PII are painted over, and the remaining
+// words are laid over the page images as invisible text, as in the
+// searchable PDF. Only PII whose words can be placed on the page is found:
+// the text layer of digital PDFs and the OCR words of scanned pages.
+
+const (
+	// redactionDPI is the resolution digital PDF pages are rasterized at.
+	redactionDPI = 150
+
+	// redactionMargin pads painted boxes, in pixels, to cover antialiased
+	// glyph edges.
+	redactionMargin = 2
+
+	// minGlyphWidth is the narrowest a glyph is taken to be, relative to
+	// the text height, for fonts whose widths cannot be read.
+	minGlyphWidth = 0.6
+
+	redactionTimeout = 10 * time.Minute
+)
+
+// piiEntityTypes are the entity patterns whose matches are redacted. CPFs
+// with wrong check digits are redacted too.
+var piiEntityTypes = map[string]bool{"CPF": true, "PHONE": true, "EMAIL": true}
+
+var ErrRedactedNotAvailable = errors.New("job has no redacted copy")
+
+// attachRedactedCopy stores a copy of the document with its PII removed.
+// digitalText is whether the result text is the PDF's own text layer rather
+// than OCR output. Failures are logged and leave the result without a copy.
+func (p *PDFProcessor) attachRedactedCopy(ctx context.Context, job *ProcessingJob, result *ProcessingResult, format documentFormat, filePath string, ocrPages []ocrPage, words []wordBox, digitalText bool) {
+	ctx, span := p.tracer.Start(ctx, "redact_pii")
+	defer span.End()
+
+	if p.reports == nil {
+		log.Printf("Redacted copy requested for job %s but report storage is not configured", job.ID)
+		return
+	}
+
+	var pages []report.ScannedPage
+	var redactions int
+	var err error
+	switch {
+	case len(ocrPages) > 0:
+		pages, redactions, err = redactScans(result.ExtractedText, ocrPages, words)
+	case format == formatPDF && digitalText:
+		pages, redactions, err = p.redactPDF(ctx, filePath, job.Options.Password)
+	default:
+		log.Printf("Redacted copy of job %s skipped: PII cannot be located on its pages", job.ID)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to redact job %s: %v", job.ID, err)
+		return
+	}
+
+	pdf, err := report.SearchablePDF(pages)
+	if err != nil {
+		log.Printf("Failed to build redacted copy for job %s: %v", job.ID, err)
+		return
+	}
+	artifact, err := p.reports.PublishRedacted(ctx, job.ID, pdf)
+	if err != nil {
+		log.Printf("Failed to publish redacted copy for job %s: %v", job.ID, err)
+		return
+	}
+	result.Redacted = artifact
+	result.Metadata["pii_redactions"] = redactions
+}
+
+// redactScans redacts the page images OCR ran on, using the OCR words.
+// The page images of scanned PDFs are the ones rendered for OCR.
+func redactScans(text string, pages []ocrPage, words []wordBox) ([]report.ScannedPage, int, error) {
+	spans := piiSpans(text)
+	scanned := make([]report.ScannedPage, 0, len(pages))
+	redacted := make(map[int]bool)
+	for _, page := range pages {
+		img, err := decodeImageFile(page.path)
+		if err != nil {
+			return nil, 0, fmt.Errorf("page %d: %w", page.number, err)
+		}
+		var pageWords []wordBox
+		for _, word := range words {
+			if word.Box.Page == page.number {
+				pageWords = append(pageWords, word)
+			}
+		}
+		scannedPage, err := redactPage(img, page.dpi, text, pageWords, spans, redacted)
+		if err != nil {
+			return nil, 0, fmt.Errorf("page %d: %w", page.number, err)
+		}
+		scanned = append(scanned, scannedPage)
+	}
+	return scanned, len(redacted), nil
+}
+
+// redactPDF rasterizes every page of a digital PDF with Ghostscript and
+// redacts it using the words of its content stream. A page whose words
+// cannot be read fails the redaction rather than leak what it holds.
+func (p *PDFProcessor) redactPDF(ctx context.Context, filePath, password string) ([]report.ScannedPage, int, error) {
+	file, reader, err := openPDF(filePath, password)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+	pageCount, err := safePageCount(reader)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, redactionTimeout)
+	defer cancel()
+
+	dir, images, err := p.rasterizePDF(ctx, filePath, password, "png16m", redactionDPI, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer os.RemoveAll(dir)
+	if len(images) != pageCount {
+		return nil, 0, fmt.Errorf("ghostscript rendered %d of %d pages", len(images), pageCount)
+	}
+
+	scanned := make([]report.ScannedPage, 0, pageCount)
+	redactions := 0
+	for n := 1; n <= pageCount; n++ {
+		img, err := decodeImageFile(images[n-1])
+		if err != nil {
+			return nil, 0, fmt.Errorf("page %d: %w", n, err)
+		}
+		geometry, err := pageGeometryOf(reader, n)
+		if err != nil {
+			return nil, 0, fmt.Errorf("page %d: %w", n, err)
+		}
+		content, err := safePageContent(reader, n)
+		if err != nil {
+			return nil, 0, fmt.Errorf("page %d: %w", n, err)
+		}
+
+		text, words := placeWords(wordsFromGlyphs(content.Text), geometry, n)
+		redacted := make(map[int]bool)
+		scannedPage, err := redactPage(img, redactionDPI, text, words, piiSpans(text), redacted)
+		if err != nil {
+			return nil, 0, fmt.Errorf("page %d: %w", n, err)
+		}
+		scanned = append(scanned, scannedPage)
+		redactions += len(redacted)
+	}
+	return scanned, redactions, nil
+}
+
+// placeWords joins a page's words into its text and locates each one on the
+// page rasterized at redactionDPI, as Ghostscript renders it: from the top
+// left corner of the media box, turned by the page rotation.
+func placeWords(words []*layoutWord, g pageGeometry, page int) (string, []wordBox) {
+	scale := float64(redactionDPI) / 72
+	width, height := g.width*scale, g.height*scale
+	top := g.y0 + g.height
+
+	var text strings.Builder
+	boxes := make([]wordBox, 0, len(words))
+	for _, w := range words {
+		if text.Len() > 0 {
+			text.WriteByte(' ')
+		}
+		start := text.Len()
+		text.WriteString(w.text)
+
+		// layoutWord y coordinates are negated; y1 is the baseline, below
+		// which a quarter of the text height is left for descenders
+		descent := (w.y1 - w.y0) / 4
+		u0, v0 := (w.x0-g.x0)*scale, (top+w.y0)*scale
+		u1, v1 := (estimatedRight(w)-g.x0)*scale, (top+w.y1+descent)*scale
+		u0, v0 = rotatePoint(u0, v0, width, height, g.rotate)
+		u1, v1 = rotatePoint(u1, v1, width, height, g.rotate)
+
+		x0, y0 := int(min(u0, u1)), int(min(v0, v1))
+		x1, y1 := int(max(u0, u1)+1), int(max(v0, v1)+1)
+		boxes = append(boxes, wordBox{
+			Start: start,
+			End:   text.Len(),
+			Box:   BoundingBox{Page: page, X: x0, Y: y0, Width: x1 - x0, Height: y1 - y0},
+		})
+	}
+	return text.String(), boxes
+}
+
+// estimatedRight is the right edge of a word, widened to minGlyphWidth per
+// character when its glyph widths could not be read.
+func estimatedRight(w *layoutWord) float64 {
+	return max(w.x1, w.x0+float64(utf8.RuneCountInString(w.text))*minGlyphWidth*w.height())
+}
+
+// rotatePoint maps a point of an unrotated page width by height pixels onto
+// the page turned clockwise by rotate degrees.
+func rotatePoint(u, v, width, height float64, rotate int) (float64, float64) {
+	switch rotate {
+	case 90:
+		return height - v, u
+	case 180:
+		return width - u, height - v
+	case 270:
+		return v, width - u
+	default:
+		return u, v
+	}
+}
+
+// redactPage paints over the words overlapping a PII span and returns the
+// page image with the remaining words. The index of each span found is
+// added to redacted.
+func redactPage(src image.Image, dpi float64, text string, words []wordBox, spans [][2]int, redacted map[int]bool) (report.ScannedPage, error) {
+	bounds := src.Bounds()
+	img := image.NewRGBA(bounds)
+	draw.Draw(img, bounds, src, bounds.Min, draw.Src)
+
+	page := report.ScannedPage{DPI: dpi}
+	for _, word := range words {
+		if word.Start < 0 || word.End > len(text) {
+			continue
+		}
+		if i := overlappingSpan(spans, word.Start, word.End); i >= 0 {
+			box := image.Rect(word.Box.X, word.Box.Y, word.Box.X+word.Box.Width, word.Box.Y+word.Box.Height)
+			box = box.Add(bounds.Min).Inset(-redactionMargin)
+			draw.Draw(img, box, image.Black, image.Point{}, draw.Src)
+			redacted[i] = true
+			continue
+		}
+		page.Words = append(page.Words, report.ScannedWord{
+			Text:   text[word.Start:word.End],
+			X:      word.Box.X,
+			Y:      word.Box.Y,
+			Width:  word.Box.Width,
+			Height: word.Box.Height,
+		})
+	}
+
+	var out bytes.Buffer
+	if err := png.Encode(&out, img); err != nil {
+		return page, err
+	}
+	page.Image = out.Bytes()
+	return page, nil
+}
+
+// piiSpans returns the byte ranges of the PII in text, ordered by start.
+func piiSpans(text string) [][2]int {
+	var spans [][2]int
+	for _, pattern := range entityPatterns {
+		if !piiEntityTypes[pattern.entityType] {
+			continue
+		}
+		for _, loc := range pattern.re.FindAllStringIndex(text, -1) {
+			spans = append(spans, [2]int{loc[0], loc[1]})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	return spans
+}
+
+// overlappingSpan returns the index of a span overlapping start to end, or
+// -1.
+func overlappingSpan(spans [][2]int, start, end int) int {
+	for i, span := range spans {
+		if span[0] >= end {
+			break
+		}
+		if span[1] > start {
+			return i
+		}
+	}
+	return -1
+}
+
+func decodeImageFile(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported page image: %w", err)
+	}
+	return img, nil
+}
+
+// RedactedURL returns a fresh download URL for a job's redacted copy.
+func (p *PDFProcessor) RedactedURL(ctx context.Context, jobID string) (*report.Artifact, error) {
+	job, err := p.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if p.reports == nil || job.Result == nil || job.Result.Redacted == nil {
+		return nil, ErrRedactedNotAvailable
+	}
+	return p.reports.Sign(ctx, job.Result.Redacted.Key)
+}
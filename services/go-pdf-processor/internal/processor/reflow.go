@@ -0,0 +1,200 @@
+package processor
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PDF text keeps the printed line breaks, so words hyphenated at the end of
+// a line come out split ("licita-\nção") and sentences broken in pieces,
+// which patterns and keyword searches miss. Reflowing joins a line to the
+// next when the next one continues it, starting in lower case:
+// hyphenated words are rejoined, keeping the hyphen of compounds such as
+// "econômico-financeiro", and other breaks become spaces. Blank lines, and
+// lines ending a sentence or clause, are kept.
+
+// offsetMap maps byte offsets in a text to offsets in its reflowed
+// version. Each segment is a run of bytes copied from oldStart to
+// newStart; the bytes between segments were dropped.
+type offsetMap struct {
+	oldStarts []int
+	newStarts []int
+	length    int // of the reflowed text
+}
+
+func (m *offsetMap) add(oldStart, newStart int) {
+	m.oldStarts = append(m.oldStarts, oldStart)
+	m.newStarts = append(m.newStarts, newStart)
+}
+
+// offset maps an offset of the original text. Offsets in dropped bytes map
+// to where the next kept byte went.
+func (m *offsetMap) offset(old int) int {
+	i := sort.SearchInts(m.oldStarts, old+1) - 1
+	if i < 0 {
+		return 0
+	}
+	n := m.newStarts[i] + old - m.oldStarts[i]
+	if i+1 < len(m.newStarts) {
+		return min(n, m.newStarts[i+1])
+	}
+	return min(n, m.length)
+}
+
+// end maps the end offset of a span of the original text. Ends in dropped
+// bytes map to the end of the kept bytes before them.
+func (m *offsetMap) end(old int) int {
+	i := sort.SearchInts(m.oldStarts, old) - 1
+	if i < 0 {
+		return 0
+	}
+	n := m.newStarts[i] + old - m.oldStarts[i]
+	if i+1 < len(m.newStarts) {
+		return min(n, m.newStarts[i+1])
+	}
+	return min(n, m.length)
+}
+
+// reflowText joins the lines of text that continue on the next, returning
+// the new text, the map of the old offsets and the number of lines joined.
+func (p *PDFProcessor) reflowText(text string) (string, *offsetMap, int) {
+	out := make([]byte, 0, len(text))
+	m := &offsetMap{}
+	m.add(0, 0)
+	joined := 0
+
+	copied := 0 // text[:copied] is in out, save for dropped bytes
+	for copied < len(text) {
+		nl := strings.IndexByte(text[copied:], '\n')
+		if nl < 0 {
+			break
+		}
+		nl += copied
+
+		lineEnd := nl
+		for lineEnd > copied && (text[lineEnd-1] == ' ' || text[lineEnd-1] == '\t' || text[lineEnd-1] == '\r') {
+			lineEnd--
+		}
+		next := nl + 1
+		for next < len(text) && (text[next] == ' ' || text[next] == '\t') {
+			next++
+		}
+
+		first, _ := utf8.DecodeRuneInString(text[next:])
+		last, lastSize := utf8.DecodeLastRuneInString(text[copied:lineEnd])
+		if lineEnd == copied || next >= len(text) || !unicode.IsLower(first) {
+			out = append(out, text[copied:nl+1]...)
+			copied = nl + 1
+			continue
+		}
+
+		switch {
+		case last == '-' && lineEnd-lastSize > copied:
+			hyphen := lineEnd - lastSize
+			before, _ := utf8.DecodeLastRuneInString(text[:hyphen])
+			if !unicode.IsLetter(before) {
+				out = append(out, text[copied:nl+1]...)
+				copied = nl + 1
+				continue
+			}
+			// The hyphen stays when both halves are words and the whole is
+			// not
+			keep := hyphen
+			if p.keepsHyphen(wordBefore(text[:hyphen]), wordAfter(text[next:])) {
+				keep = lineEnd
+			}
+			out = append(out, text[copied:keep]...)
+		case unicode.IsLetter(last) || unicode.IsDigit(last) || last == ',':
+			// The line break becomes a space, in its place
+			out = append(out, text[copied:lineEnd+1]...)
+			out[len(out)-1] = ' '
+		default:
+			out = append(out, text[copied:nl+1]...)
+			copied = nl + 1
+			continue
+		}
+		joined++
+		copied = next
+		m.add(next, len(out))
+	}
+	out = append(out, text[copied:]...)
+	m.length = len(out)
+	return string(out), m, joined
+}
+
+// keepsHyphen reports whether a word hyphenated across lines is a compound.
+func (p *PDFProcessor) keepsHyphen(left, right string) bool {
+	if p.dictionary == nil || left == "" || right == "" {
+		return false
+	}
+	return !p.dictionary.Contains(left+right) && p.dictionary.Contains(left) && p.dictionary.Contains(right)
+}
+
+func wordBefore(text string) string {
+	start := len(text)
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(text[:start])
+		if !unicode.IsLetter(r) {
+			break
+		}
+		start -= size
+	}
+	return text[start:]
+}
+
+func wordAfter(text string) string {
+	end := 0
+	for end < len(text) {
+		r, size := utf8.DecodeRuneInString(text[end:])
+		if !unicode.IsLetter(r) {
+			break
+		}
+		end += size
+	}
+	return text[:end]
+}
+
+// reflowContent reflows an extracted document's text, moving its page
+// offsets, word boxes and blocks along.
+func (p *PDFProcessor) reflowContent(content *documentContent) int {
+	text, m, joined := p.reflowText(content.Text)
+	if joined == 0 {
+		return 0
+	}
+	content.Text = text
+	remapOffsets(m, content.PageOffsets)
+	remapWordBoxes(m, content.WordBoxes)
+	for i := range content.Blocks {
+		block := &content.Blocks[i]
+		block.StartPos, block.EndPos = m.offset(block.StartPos), m.end(block.EndPos)
+	}
+	return joined
+}
+
+// reflowOCR reflows OCR text, moving its page offsets and word boxes along.
+func (p *PDFProcessor) reflowOCR(result *ocrResult) int {
+	text, m, joined := p.reflowText(result.Text)
+	if joined == 0 {
+		return 0
+	}
+	result.Text = text
+	remapOffsets(m, result.PageOffsets)
+	remapWordBoxes(m, result.Words)
+	return joined
+}
+
+func remapOffsets(m *offsetMap, offsets []int) {
+	for i, offset := range offsets {
+		offsets[i] = m.offset(offset)
+	}
+}
+
+// remapWordBoxes moves the words along; a word losing its hyphen ends
+// before it.
+func remapWordBoxes(m *offsetMap, words []wordBox) {
+	for i := range words {
+		words[i].Start, words[i].End = m.offset(words[i].Start), m.end(words[i].End)
+	}
+}
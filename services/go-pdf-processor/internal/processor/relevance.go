@@ -0,0 +1,231 @@
+package processor
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Weights of the interest profile criteria in the relevance score. Only the
+// criteria a profile sets are counted, so a keywords-only profile can still
+// reach 1.
+const (
+	keywordRelevanceWeight = 0.4
+	cnaeRelevanceWeight    = 0.2
+	regionRelevanceWeight  = 0.2
+	valueRelevanceWeight   = 0.2
+
+	// keywordSaturation is how many profile keywords a document must
+	// mention for the keyword criterion to score fully.
+	keywordSaturation = 3
+)
+
+// cnaeCodePattern matches CNAE classes and subclasses as written in editais,
+// e.g. 47.51-2 or 4751-2/01.
+var cnaeCodePattern = regexp.MustCompile(`\b\d{2}\.?\d{2}-\d(?:/\d{2})?\b`)
+
+// RelevanceMatch explains a relevance score computed against an interest
+// profile.
+type RelevanceMatch struct {
+	ProfileID   string   `json:"profile_id"`
+	ProfileName string   `json:"profile_name"`
+	Keywords    []string `json:"keywords"`
+	CNAECodes   []string `json:"cnae_codes"`
+	Regions     []string `json:"regions"`
+
+	// EstimatedValueCents is the largest amount found in the document,
+	// taken as the contract's estimated value
+	EstimatedValueCents int64 `json:"estimated_value_cents,omitempty"`
+	ValueInRange        bool  `json:"value_in_range"`
+}
+
+// relevanceMatcher accumulates what a document has in common with an
+// interest profile, page by page for streamed documents. Without a profile
+// it counts the generic procurement keywords.
+type relevanceMatcher struct {
+	profile *InterestProfile
+
+	keywords map[string]bool
+	codes    map[string]bool
+	regions  map[string]bool
+	maxValue int64
+}
+
+func newRelevanceMatcher(profile *InterestProfile) *relevanceMatcher {
+	return &relevanceMatcher{
+		profile:  profile,
+		keywords: make(map[string]bool),
+		codes:    make(map[string]bool),
+		regions:  make(map[string]bool),
+	}
+}
+
+// add scans a piece of the document's text.
+func (m *relevanceMatcher) add(text string) {
+	lower := strings.ToLower(text)
+	if m.profile == nil {
+		for _, keyword := range relevantKeywords {
+			if strings.Contains(lower, keyword) {
+				m.keywords[keyword] = true
+			}
+		}
+		return
+	}
+
+	for _, keyword := range m.profile.Keywords {
+		if containsWord(lower, strings.ToLower(keyword)) {
+			m.keywords[keyword] = true
+		}
+	}
+	for _, region := range m.profile.Regions {
+		// State abbreviations (SP, MG...) only match in capitals
+		if len(region) == 2 && strings.ToUpper(region) == region {
+			if containsWord(text, region) {
+				m.regions[region] = true
+			}
+		} else if containsWord(lower, strings.ToLower(region)) {
+			m.regions[region] = true
+		}
+	}
+	if len(m.profile.CNAECodes) > 0 {
+		for _, code := range cnaeCodePattern.FindAllString(text, -1) {
+			m.addCNAE(code)
+		}
+	}
+	if m.profile.hasValueRange() {
+		for _, amount := range currencyPattern.FindAllString(text, -1) {
+			if cents, ok := normalizeCurrency(amount); ok && cents > m.maxValue {
+				m.maxValue = cents
+			}
+		}
+	}
+}
+
+// addEntities takes the activities of companies found by CNPJ enrichment
+// into account.
+func (m *relevanceMatcher) addEntities(entities []ExtractedEntity) {
+	if m.profile == nil {
+		return
+	}
+	for _, e := range entities {
+		if e.Company != nil && e.Company.CNAE != "" {
+			m.addCNAE(e.Company.CNAE)
+		}
+	}
+}
+
+func (m *relevanceMatcher) addCNAE(code string) {
+	found := onlyDigits(code)
+	for _, wanted := range m.profile.CNAECodes {
+		// Profile codes may name a whole division, group or class
+		if strings.HasPrefix(found, wanted) {
+			m.codes[wanted] = true
+		}
+	}
+}
+
+func (m *relevanceMatcher) valueInRange() bool {
+	if m.maxValue == 0 {
+		return false
+	}
+	return m.maxValue >= m.profile.MinValueCents &&
+		(m.profile.MaxValueCents == 0 || m.maxValue <= m.profile.MaxValueCents)
+}
+
+// score returns the relevance of what was added, between 0 and 1.
+func (m *relevanceMatcher) score() float64 {
+	if m.profile == nil {
+		return relevanceScoreFromHits(m.keywords)
+	}
+
+	var score, total float64
+	if n := len(m.profile.Keywords); n > 0 {
+		total += keywordRelevanceWeight
+		score += keywordRelevanceWeight * min(1, float64(len(m.keywords))/float64(min(n, keywordSaturation)))
+	}
+	if len(m.profile.CNAECodes) > 0 {
+		total += cnaeRelevanceWeight
+		if len(m.codes) > 0 {
+			score += cnaeRelevanceWeight
+		}
+	}
+	if len(m.profile.Regions) > 0 {
+		total += regionRelevanceWeight
+		if len(m.regions) > 0 {
+			score += regionRelevanceWeight
+		}
+	}
+	if m.profile.hasValueRange() {
+		total += valueRelevanceWeight
+		if m.valueInRange() {
+			score += valueRelevanceWeight
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return score / total
+}
+
+// details describes the profile criteria the document met; nil without a
+// profile.
+func (m *relevanceMatcher) details() *RelevanceMatch {
+	if m.profile == nil {
+		return nil
+	}
+	return &RelevanceMatch{
+		ProfileID:           m.profile.ID,
+		ProfileName:         m.profile.Name,
+		Keywords:            sortedKeys(m.keywords),
+		CNAECodes:           sortedKeys(m.codes),
+		Regions:             sortedKeys(m.regions),
+		EstimatedValueCents: m.maxValue,
+		ValueInRange:        m.profile.hasValueRange() && m.valueInRange(),
+	}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// containsWord reports whether word occurs in text not surrounded by other
+// letters or digits.
+func containsWord(text, word string) bool {
+	if word == "" {
+		return false
+	}
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], word)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(word)
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
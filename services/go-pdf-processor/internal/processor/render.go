@@ -0,0 +1,337 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"cotai-pdf-processor/internal/storage"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Page images are rendered on demand for the document viewer: PDF pages are
+// rasterized with Ghostscript and image documents, which have a single page,
+// are scaled. Rendered images are cached in Redis
+// (job:<id>:page:<n>:image:<width>.<format>).
+
+// Page image formats
+const (
+	PageImagePNG  = "png"
+	PageImageJPEG = "jpeg"
+)
+
+const (
+	DefaultPageImageWidth = 1000
+	minPageImageWidth     = 50
+	maxPageImageWidth     = 3000
+
+	pageImageTTL = 24 * time.Hour
+
+	// jpegQuality is the quality of JPEG page images.
+	jpegQuality = 85
+)
+
+var (
+	ErrPageNotFound         = errors.New("page not found")
+	ErrInvalidPageWidth     = fmt.Errorf("width must be between %d and %d", minPageImageWidth, maxPageImageWidth)
+	ErrRenderingUnsupported = errors.New("page images are not available for this document format")
+)
+
+// PageImage returns page n of a job's document as a PNG or JPEG image width
+// pixels wide.
+func (p *PDFProcessor) PageImage(ctx context.Context, jobID string, n, width int, format string) ([]byte, error) {
+	ctx, span := p.tracer.Start(ctx, "render_page")
+	defer span.End()
+
+	if width < minPageImageWidth || width > maxPageImageWidth {
+		return nil, ErrInvalidPageWidth
+	}
+	if format != PageImageJPEG {
+		format = PageImagePNG
+	}
+
+	job, err := p.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := fmt.Sprintf("%s:page:%d:image:%d.%s", jobKey(job.TenantID, jobID), n, width, format)
+	if data, err := p.redis.Get(ctx, cacheKey); err == nil {
+		return data, nil
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("Failed to read cached page image %s: %v", cacheKey, err)
+	}
+
+	file, err := p.downloader.Fetch(ctx, job.FileURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer file.Cleanup()
+
+	var data []byte
+	switch detectFormat(file.Path) {
+	case formatPDF:
+		data, err = p.renderPDFPage(ctx, file.Path, n, width, format)
+	case formatImage:
+		data, err = scalePageImage(file.Path, n, width, format)
+	default:
+		return nil, ErrRenderingUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.redis.Set(ctx, cacheKey, data, pageImageTTL); err != nil {
+		log.Printf("Failed to cache page image %s: %v", cacheKey, err)
+	}
+	return data, nil
+}
+
+// renderPDFPage rasterizes one page at the resolution that makes it width
+// pixels wide.
+func (p *PDFProcessor) renderPDFPage(ctx context.Context, filePath string, n, width int, format string) ([]byte, error) {
+	file, reader, err := openPDF(filePath, "")
+	if err != nil {
+		return nil, err
+	}
+	pageCount, err := safePageCount(reader)
+	if err == nil && (n < 1 || n > pageCount) {
+		err = ErrPageNotFound
+	}
+	var geometry pageGeometry
+	if err == nil {
+		geometry, err = pageGeometryOf(reader, n)
+	}
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{
+		"-dSAFER", "-dBATCH", "-dNOPAUSE", "-dQUIET",
+		"-sDEVICE=png16m",
+		fmt.Sprintf("-r%.2f", float64(width)*72/geometry.displayWidth()),
+		fmt.Sprintf("-dFirstPage=%d", n),
+		fmt.Sprintf("-dLastPage=%d", n),
+		"-dTextAlphaBits=4", "-dGraphicsAlphaBits=4",
+		"-sOutputFile=-",
+	}
+	if format == PageImageJPEG {
+		args[4] = "-sDEVICE=jpeg"
+		args = append(args, fmt.Sprintf("-dJPEGQ=%d", jpegQuality))
+	}
+	return p.runGhostscript(ctx, append(args, filePath)...)
+}
+
+// runGhostscript runs Ghostscript and returns what it wrote to stdout.
+func (p *PDFProcessor) runGhostscript(ctx context.Context, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.cfg.GhostscriptPath, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ghostscript failed: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// ghostscriptPassword writes the option giving Ghostscript a PDF's password
+// to a file only the service can read, so the password is not on the
+// command line where other processes may see it, and returns the argument
+// reading the file and a function removing it.
+func (p *PDFProcessor) ghostscriptPassword(password string) (string, func(), error) {
+	f, err := os.CreateTemp(p.cfg.TempDir, "cotai-gs-args-*")
+	if err != nil {
+		return "", nil, err
+	}
+	remove := func() { os.Remove(f.Name()) }
+
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(password)
+	_, err = f.WriteString(`-sPDFPassword="` + escaped + `"`)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		remove()
+		return "", nil, err
+	}
+	return "@" + f.Name(), remove, nil
+}
+
+// rasterizePDF renders the pages of a PDF with a Ghostscript image device
+// into a new temporary directory, at most maxPages of them when positive,
+// and returns the directory, which the caller removes, and the page images
+// in page order.
+func (p *PDFProcessor) rasterizePDF(ctx context.Context, filePath, password, device string, dpi float64, maxPages int) (string, []string, error) {
+	var selection []string
+	if maxPages > 0 {
+		selection = append(selection, fmt.Sprintf("-dLastPage=%d", maxPages))
+	}
+	return p.rasterize(ctx, filePath, password, device, dpi, selection)
+}
+
+// rasterizePDFPages renders the given pages, numbered from 1, as
+// rasterizePDF does; the images are in the order of pages.
+func (p *PDFProcessor) rasterizePDFPages(ctx context.Context, filePath, password, device string, dpi float64, pages []int) (string, []string, error) {
+	list := make([]string, len(pages))
+	for i, n := range pages {
+		list[i] = strconv.Itoa(n)
+	}
+	return p.rasterize(ctx, filePath, password, device, dpi, []string{"-sPageList=" + strings.Join(list, ",")})
+}
+
+func (p *PDFProcessor) rasterize(ctx context.Context, filePath, password, device string, dpi float64, selection []string) (string, []string, error) {
+	dir, err := os.MkdirTemp(p.cfg.TempDir, "cotai-pages-*")
+	if err != nil {
+		return "", nil, err
+	}
+
+	args := []string{
+		"-dSAFER", "-dBATCH", "-dNOPAUSE", "-dQUIET",
+		"-sDEVICE=" + device,
+		fmt.Sprintf("-r%.0f", dpi),
+		"-dTextAlphaBits=4", "-dGraphicsAlphaBits=4",
+		"-sOutputFile=" + filepath.Join(dir, "page-%d.png"),
+	}
+	args = append(args, selection...)
+	if password != "" {
+		arg, remove, err := p.ghostscriptPassword(password)
+		if err != nil {
+			os.RemoveAll(dir)
+			return "", nil, err
+		}
+		defer remove()
+		args = append(args, arg)
+	}
+	if _, err := p.runGhostscript(ctx, append(args, filePath)...); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+
+	var paths []string
+	for n := 1; ; n++ {
+		path := filepath.Join(dir, fmt.Sprintf("page-%d.png", n))
+		if _, err := os.Stat(path); err != nil {
+			break
+		}
+		paths = append(paths, path)
+	}
+	return dir, paths, nil
+}
+
+// pageGeometry is a page's media box, in points, and its clockwise
+// rotation in degrees: 0, 90, 180 or 270.
+type pageGeometry struct {
+	x0, y0, width, height float64
+	rotate                int
+}
+
+// displayWidth is the width of the page as shown: the width of its media
+// box, or its height when the page is rotated a quarter turn.
+func (g pageGeometry) displayWidth() float64 {
+	if g.rotate%180 != 0 {
+		return g.height
+	}
+	return g.width
+}
+
+// pageGeometryOf reads a page's media box and rotation, both of which may be
+// inherited from the page tree.
+func pageGeometryOf(reader *pdf.Reader, n int) (geometry pageGeometry, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", errPageMissing, r)
+		}
+	}()
+
+	inherited := func(v pdf.Value, key string) pdf.Value {
+		for depth := 0; depth < 32 && !v.IsNull(); depth++ {
+			if value := v.Key(key); !value.IsNull() {
+				return value
+			}
+			v = v.Key("Parent")
+		}
+		return pdf.Value{}
+	}
+
+	page := reader.Page(n).V
+	// US Letter, the default media box
+	geometry = pageGeometry{width: 612, height: 792}
+	if box := inherited(page, "MediaBox"); box.Len() == 4 {
+		x0, y0 := box.Index(0).Float64(), box.Index(1).Float64()
+		x1, y1 := box.Index(2).Float64(), box.Index(3).Float64()
+		if x1 != x0 && y1 != y0 {
+			geometry.x0, geometry.y0 = min(x0, x1), min(y0, y1)
+			geometry.width, geometry.height = max(x0, x1)-geometry.x0, max(y0, y1)-geometry.y0
+		}
+	}
+	if rotate := int(inherited(page, "Rotate").Int64() % 360); rotate%90 == 0 {
+		geometry.rotate = (rotate + 360) % 360
+	}
+	return geometry, nil
+}
+
+// scalePageImage scales an image document down to width pixels, averaging
+// the source pixels each target pixel covers. Images are never enlarged.
+func scalePageImage(filePath string, n, width int, format string) ([]byte, error) {
+	if n != 1 {
+		return nil, ErrPageNotFound
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	src, _, err := image.Decode(f)
+	if err != nil {
+		// TIFF scans have no decoder here
+		return nil, fmt.Errorf("%w: %v", ErrRenderingUnsupported, err)
+	}
+
+	bounds := src.Bounds()
+	width = min(width, bounds.Dx())
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*bounds.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*bounds.Dx()/width)
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					count++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{uint16(r / count), uint16(g / count), uint16(b / count), uint16(a / count)})
+		}
+	}
+
+	var out bytes.Buffer
+	if format == PageImageJPEG {
+		err = jpeg.Encode(&out, dst, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		err = png.Encode(&out, dst)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
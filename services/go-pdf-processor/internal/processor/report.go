@@ -0,0 +1,217 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"cotai-pdf-processor/internal/report"
+)
+
+// maxReportEntities bounds the entity table of a report; the full list stays
+// in the job result.
+const maxReportEntities = 200
+
+// maxReportItems bounds the item table of a report likewise.
+const maxReportItems = 200
+
+// reportColor is the header color of generated reports.
+var reportColor = [3]float64{0.11, 0.27, 0.53}
+
+var ErrReportNotAvailable = errors.New("job has no report")
+
+// attachReport renders the job's results into a PDF summary and stores it.
+// Failures are logged and leave the result without a report.
+func (p *PDFProcessor) attachReport(ctx context.Context, job *ProcessingJob) {
+	ctx, span := p.tracer.Start(ctx, "generate_report")
+	defer span.End()
+
+	if p.reports == nil {
+		log.Printf("Report requested for job %s but report storage is not configured", job.ID)
+		return
+	}
+
+	artifact, err := p.reports.Publish(ctx, job.ID, renderReport(p.reports.Brand(), job))
+	if err != nil {
+		log.Printf("Failed to publish report for job %s: %v", job.ID, err)
+		return
+	}
+	job.Result.Report = artifact
+}
+
+// ReportURL returns a fresh download URL for a job's report, since the one
+// in the result expires.
+func (p *PDFProcessor) ReportURL(ctx context.Context, jobID string) (*report.Artifact, error) {
+	job, err := p.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if p.reports == nil || job.Result == nil || job.Result.Report == nil {
+		return nil, ErrReportNotAvailable
+	}
+	return p.reports.Sign(ctx, job.Result.Report.Key)
+}
+
+func renderReport(brand string, job *ProcessingJob) []byte {
+	result := job.Result
+	filename, _ := job.Metadata["original_filename"].(string)
+	if filename == "" {
+		filename = job.FileURL
+	}
+
+	doc := report.NewDocument(brand, "Risk report: "+filename, reportColor)
+
+	doc.Heading("Summary")
+	doc.Field("Document", filename)
+	doc.Field("Job", job.ID)
+	doc.Field("Generated at", time.Now().Format("02/01/2006 15:04"))
+	doc.Field("Pages", fmt.Sprintf("%d", result.PageCount))
+	if result.Classification != nil {
+		doc.Field("Document type", result.Classification.Label)
+	}
+	if job.Options.AnalyzeRisks {
+		doc.Field("Overall risk", strings.ToUpper(result.RiskAnalysis.OverallRisk))
+		doc.Field("Risk score", fmt.Sprintf("%.0f%%", result.RiskAnalysis.RiskScore*100))
+		if result.RiskAnalysis.Profile != "" {
+			doc.Field("Weighting profile", result.RiskAnalysis.Profile)
+		}
+	}
+	if job.Options.GenerateScore {
+		doc.Field("Relevance score", fmt.Sprintf("%.0f%%", result.RelevanceScore*100))
+	}
+
+	if len(result.Signatures) > 0 {
+		doc.Heading("Signatures")
+		rows := make([][]string, 0, len(result.Signatures))
+		for _, s := range result.Signatures {
+			signer := s.SignerName
+			if s.SignerCNPJ != "" {
+				signer += " (" + s.SignerCNPJ + ")"
+			}
+			signedAt := ""
+			if s.SigningTime != nil {
+				signedAt = s.SigningTime.Format("02/01/2006 15:04")
+			}
+			status := "Valid"
+			if !s.Valid {
+				status = "Invalid: " + strings.Join(s.Errors, "; ")
+			}
+			rows = append(rows, []string{signer, s.Issuer, signedAt, status})
+		}
+		doc.Table([]string{"Signer", "Issuer", "Signed at", "Status"}, []float64{0.3, 0.22, 0.15, 0.33}, rows)
+	}
+
+	if len(result.Sections) > 0 {
+		doc.Heading("Sections")
+		rows := make([][]string, 0, len(result.Sections))
+		for _, s := range result.Sections {
+			pages := ""
+			if s.StartPage > 0 {
+				pages = fmt.Sprintf("%d-%d", s.StartPage, s.EndPage)
+			}
+			rows = append(rows, []string{sectionLabels[s.Type], s.Title, pages})
+		}
+		doc.Table([]string{"Section", "Heading", "Pages"}, []float64{0.25, 0.6, 0.15}, rows)
+	}
+
+	if job.Options.AnalyzeRisks {
+		doc.Heading("Identified risks")
+		if len(result.RiskAnalysis.IdentifiedRisks) == 0 {
+			doc.Paragraph("No risks were identified.")
+		} else {
+			rows := make([][]string, 0, len(result.RiskAnalysis.IdentifiedRisks))
+			for _, r := range result.RiskAnalysis.IdentifiedRisks {
+				rows = append(rows, []string{r.Severity, r.Category, r.Description, r.Location, r.Snippet})
+			}
+			doc.Table([]string{"Severity", "Category", "Description", "Location", "Clause"},
+				[]float64{0.1, 0.14, 0.22, 0.1, 0.44}, rows)
+		}
+
+		doc.Heading("Recommendations")
+		for _, rec := range result.RiskAnalysis.Recommendations {
+			doc.Paragraph("• " + rec)
+		}
+	}
+
+	if len(result.Items) > 0 {
+		doc.Heading("Items")
+		items := result.Items
+		if len(items) > maxReportItems {
+			items = items[:maxReportItems]
+		}
+		rows := make([][]string, 0, len(items))
+		for _, item := range items {
+			rows = append(rows, []string{item.Item, item.Description, item.Unit,
+				strconv.FormatFloat(item.Quantity, 'f', -1, 64), formatCents(item.EstimatedTotalCents)})
+		}
+		doc.Table([]string{"Item", "Description", "Unit", "Quantity", "Estimated total"},
+			[]float64{0.08, 0.52, 0.1, 0.12, 0.18}, rows)
+		if omitted := len(result.Items) - len(items); omitted > 0 {
+			doc.Note(fmt.Sprintf("%d more items are available in the job result.", omitted))
+		}
+	}
+
+	if job.Options.ExtractEntities && len(result.Entities) > 0 {
+		doc.Heading("Entities")
+		entities := result.Entities
+		if len(entities) > maxReportEntities {
+			entities = entities[:maxReportEntities]
+		}
+		rows := make([][]string, 0, len(entities))
+		for _, e := range entities {
+			value := e.Value
+			if e.Company != nil {
+				value += " (" + e.Company.RazaoSocial + ")"
+			}
+			page := ""
+			if e.Page > 0 {
+				page = fmt.Sprintf("%d", e.Page)
+			}
+			rows = append(rows, []string{e.Type, value, page, fmt.Sprintf("%.0f%%", e.Confidence*100)})
+		}
+		doc.Table([]string{"Type", "Value", "Page", "Confidence"}, []float64{0.15, 0.6, 0.1, 0.15}, rows)
+		if omitted := len(result.Entities) - len(entities); omitted > 0 {
+			doc.Note(fmt.Sprintf("%d more entities are available in the job result.", omitted))
+		}
+	}
+
+	q := result.QualityMetrics
+	doc.Heading("Extraction quality")
+	doc.Field("Text quality", fmt.Sprintf("%.0f%%", q.TextQuality*100))
+	doc.Field("OCR confidence", fmt.Sprintf("%.0f%%", q.OCRConfidence*100))
+	doc.Field("Document clarity", fmt.Sprintf("%.0f%%", q.DocumentClarity*100))
+	doc.Field("Completeness", fmt.Sprintf("%.0f%%", q.Completeness*100))
+	doc.Field("Readability", fmt.Sprintf("%.0f%%", q.Readability*100))
+	if len(q.LowConfidencePages) > 0 {
+		pages := make([]string, len(q.LowConfidencePages))
+		for i, n := range q.LowConfidencePages {
+			pages[i] = strconv.Itoa(n)
+		}
+		doc.Note(fmt.Sprintf("OCR confidence is low on pages %s; check them against the original.", strings.Join(pages, ", ")))
+	}
+
+	doc.Note("This report was generated automatically from the extracted text. Verify each finding against the original document before acting on it.")
+
+	return doc.Bytes()
+}
+
+// formatCents writes an amount in cents as Brazilian currency, e.g.
+// "R$ 1.234,56"; zero is left blank.
+func formatCents(cents int64) string {
+	if cents <= 0 {
+		return ""
+	}
+	reais := strconv.FormatInt(cents/100, 10)
+	var grouped strings.Builder
+	for i, digit := range reais {
+		if i > 0 && (len(reais)-i)%3 == 0 {
+			grouped.WriteByte('.')
+		}
+		grouped.WriteRune(digit)
+	}
+	return fmt.Sprintf("R$ %s,%02d", grouped.String(), cents%100)
+}
@@ -0,0 +1,524 @@
+package processor
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cotai-pdf-processor/internal/risk"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Reprocessing policies re-run the analysis of a tenant's completed
+// documents on a cron schedule, e.g. every edital once the risk rules
+// changed. A run queues the latest job of each document, by content,
+// matching the policy's filter, with the policy's options and bypassing
+// the result cache. Documents already reprocessed by the policy are left
+// alone until the options or the tenant's risk rules change. Replicas
+// claim each run in Postgres, so a run happens once.
+
+var (
+	ErrReprocessingPolicyNotFound = errors.New("reprocessing policy not found")
+	ErrInvalidReprocessingPolicy  = errors.New("invalid reprocessing policy")
+)
+
+// ReprocessingFilter selects the documents of a policy; empty fields match
+// any document.
+type ReprocessingFilter struct {
+	// DocumentTypes are the classification types, e.g. edital
+	DocumentTypes []string   `json:"document_types,omitempty"`
+	TenderID      string     `json:"tender_id,omitempty"`
+	Since         *time.Time `json:"since,omitempty"`
+}
+
+// ReprocessingRun is the outcome of a run of a policy.
+type ReprocessingRun struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Queued     int       `json:"queued"`
+
+	// Truncated runs reached REPROCESSING_MAX_JOBS; the next run goes on
+	Truncated bool   `json:"truncated,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ReprocessingPolicy reprocesses the documents matching Filter at the
+// times of Schedule, a cron expression in Timezone (UTC when empty), with
+// the options of Profile and Options as jobs are submitted with.
+type ReprocessingPolicy struct {
+	ID        string             `json:"id"`
+	TenantID  string             `json:"tenant_id"`
+	Name      string             `json:"name"`
+	Schedule  string             `json:"schedule"`
+	Timezone  string             `json:"timezone,omitempty"`
+	Filter    ReprocessingFilter `json:"filter"`
+	Profile   string             `json:"profile,omitempty"`
+	Options   json.RawMessage    `json:"options,omitempty"`
+	Priority  string             `json:"priority,omitempty"`
+	Enabled   bool               `json:"enabled"`
+	NextRunAt *time.Time         `json:"next_run_at,omitempty"`
+	LastRun   *ReprocessingRun   `json:"last_run,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+func (rp *ReprocessingPolicy) validate(ctx context.Context, p *PDFProcessor) error {
+	rp.Name = strings.TrimSpace(rp.Name)
+	if rp.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidReprocessingPolicy)
+	}
+	if _, err := parseCron(rp.Schedule); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidReprocessingPolicy, err)
+	}
+	if _, err := time.LoadLocation(rp.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidReprocessingPolicy, rp.Timezone)
+	}
+	if err := ValidatePriority(rp.Priority); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidReprocessingPolicy, err)
+	}
+	if _, err := rp.options(ctx, p); errors.Is(err, ErrProcessingProfileNotFound) || errors.Is(err, ErrInvalidOptions) {
+		return fmt.Errorf("%w: %v", ErrInvalidReprocessingPolicy, err)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// options returns a job of the policy's tenant with the policy's options.
+func (rp *ReprocessingPolicy) options(ctx context.Context, p *PDFProcessor) (*ProcessingJob, error) {
+	job := &ProcessingJob{TenantID: rp.TenantID, Options: DefaultProcessingOptions()}
+	if err := p.ResolveOptions(ctx, job, rp.Profile, rp.Options); err != nil {
+		return nil, err
+	}
+	job.Options.Reprocess = true
+	return job, nil
+}
+
+// nextRun is the first time after t the policy runs at, nil when it never
+// does or is disabled.
+func (rp *ReprocessingPolicy) nextRun(t time.Time) *time.Time {
+	if !rp.Enabled {
+		return nil
+	}
+	schedule, err := parseCron(rp.Schedule)
+	if err != nil {
+		return nil
+	}
+	loc, err := time.LoadLocation(rp.Timezone)
+	if err != nil {
+		return nil
+	}
+	next := schedule.next(t.In(loc))
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+const reprocessingPolicyColumns = `id, tenant_id, name, schedule, timezone, filter, profile, options, priority,
+	enabled, next_run_at, last_run, created_at, updated_at`
+
+func scanReprocessingPolicy(row rowScanner) (*ReprocessingPolicy, error) {
+	var rp ReprocessingPolicy
+	var filter, options, lastRun []byte
+	var nextRunAt sql.NullTime
+	err := row.Scan(&rp.ID, &rp.TenantID, &rp.Name, &rp.Schedule, &rp.Timezone, &filter, &rp.Profile, &options, &rp.Priority,
+		&rp.Enabled, &nextRunAt, &lastRun, &rp.CreatedAt, &rp.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReprocessingPolicyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(filter, &rp.Filter); err != nil {
+		return nil, fmt.Errorf("invalid filter for reprocessing policy %s: %w", rp.ID, err)
+	}
+	if len(options) > 0 {
+		rp.Options = options
+	}
+	if nextRunAt.Valid {
+		rp.NextRunAt = &nextRunAt.Time
+	}
+	if len(lastRun) > 0 {
+		if err := json.Unmarshal(lastRun, &rp.LastRun); err != nil {
+			return nil, fmt.Errorf("invalid last run for reprocessing policy %s: %w", rp.ID, err)
+		}
+	}
+	return &rp, nil
+}
+
+// ListReprocessingPolicies returns a tenant's reprocessing policies by
+// name.
+func (p *PDFProcessor) ListReprocessingPolicies(ctx context.Context, tenantID string) ([]ReprocessingPolicy, error) {
+	rows, err := p.postgres.Query(ctx,
+		`SELECT `+reprocessingPolicyColumns+` FROM reprocessing_policies WHERE tenant_id = $1 ORDER BY name`,
+		tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reprocessing policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []ReprocessingPolicy{}
+	for rows.Next() {
+		rp, err := scanReprocessingPolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read reprocessing policy: %w", err)
+		}
+		policies = append(policies, *rp)
+	}
+	return policies, rows.Err()
+}
+
+func (p *PDFProcessor) GetReprocessingPolicy(ctx context.Context, tenantID, id string) (*ReprocessingPolicy, error) {
+	return scanReprocessingPolicy(p.postgres.QueryRow(ctx,
+		`SELECT `+reprocessingPolicyColumns+` FROM reprocessing_policies WHERE tenant_id = $1 AND id = $2`,
+		tenantID, id))
+}
+
+func (p *PDFProcessor) CreateReprocessingPolicy(ctx context.Context, rp *ReprocessingPolicy) error {
+	if err := rp.validate(ctx, p); err != nil {
+		return err
+	}
+	filter, options, err := marshalReprocessingPolicy(rp)
+	if err != nil {
+		return err
+	}
+
+	rp.ID = uuid.New().String()
+	rp.CreatedAt = time.Now()
+	rp.UpdatedAt = rp.CreatedAt
+	rp.NextRunAt = rp.nextRun(rp.CreatedAt)
+	rp.LastRun = nil
+
+	err = p.postgres.Exec(ctx, `
+		INSERT INTO reprocessing_policies (`+reprocessingPolicyColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULL, $12, $13)
+	`, rp.ID, rp.TenantID, rp.Name, rp.Schedule, rp.Timezone, filter, rp.Profile, options, rp.Priority,
+		rp.Enabled, rp.NextRunAt, rp.CreatedAt, rp.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create reprocessing policy: %w", err)
+	}
+	return nil
+}
+
+// UpdateReprocessingPolicy replaces a policy, which next runs at the first
+// time of its schedule from now.
+func (p *PDFProcessor) UpdateReprocessingPolicy(ctx context.Context, rp *ReprocessingPolicy) error {
+	if err := rp.validate(ctx, p); err != nil {
+		return err
+	}
+	filter, options, err := marshalReprocessingPolicy(rp)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	updated, err := scanReprocessingPolicy(p.postgres.QueryRow(ctx, `
+		UPDATE reprocessing_policies
+		SET name = $3, schedule = $4, timezone = $5, filter = $6, profile = $7, options = $8, priority = $9,
+			enabled = $10, next_run_at = $11, updated_at = $12
+		WHERE tenant_id = $1 AND id = $2
+		RETURNING `+reprocessingPolicyColumns,
+		rp.TenantID, rp.ID, rp.Name, rp.Schedule, rp.Timezone, filter, rp.Profile, options, rp.Priority,
+		rp.Enabled, rp.nextRun(now), now))
+	if err != nil {
+		return err
+	}
+
+	*rp = *updated
+	return nil
+}
+
+func (p *PDFProcessor) DeleteReprocessingPolicy(ctx context.Context, tenantID, id string) error {
+	var deleted string
+	err := p.postgres.QueryRow(ctx,
+		`DELETE FROM reprocessing_policies WHERE tenant_id = $1 AND id = $2 RETURNING id`,
+		tenantID, id).Scan(&deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrReprocessingPolicyNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete reprocessing policy: %w", err)
+	}
+	return nil
+}
+
+func marshalReprocessingPolicy(rp *ReprocessingPolicy) ([]byte, []byte, error) {
+	filter, err := json.Marshal(rp.Filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	var options []byte
+	if len(rp.Options) > 0 {
+		options = rp.Options
+	}
+	return filter, options, nil
+}
+
+// dueReprocessingPolicies returns the enabled policies whose next run has
+// come, of every tenant.
+func (p *PDFProcessor) dueReprocessingPolicies(ctx context.Context) ([]ReprocessingPolicy, error) {
+	rows, err := p.postgres.Query(ctx,
+		`SELECT `+reprocessingPolicyColumns+` FROM reprocessing_policies WHERE enabled AND next_run_at <= $1`,
+		time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []ReprocessingPolicy
+	for rows.Next() {
+		rp, err := scanReprocessingPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, *rp)
+	}
+	return policies, rows.Err()
+}
+
+// claimReprocessingRun moves a due policy's next run to the following time
+// of its schedule, reporting whether this replica did and so is to run it.
+func (p *PDFProcessor) claimReprocessingRun(ctx context.Context, rp *ReprocessingPolicy) (bool, error) {
+	var claimed string
+	err := p.postgres.QueryRow(ctx, `
+		UPDATE reprocessing_policies SET next_run_at = $3
+		WHERE id = $1 AND next_run_at = $2 AND enabled
+		RETURNING id
+	`, rp.ID, rp.NextRunAt, rp.nextRun(time.Now())).Scan(&claimed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim run of reprocessing policy %s: %w", rp.ID, err)
+	}
+	return true, nil
+}
+
+// reprocessingCandidate is the latest job of a document to reprocess.
+type reprocessingCandidate struct {
+	jobID, tenderID, userID, filename, fileURL, digest string
+}
+
+// reprocessingCandidates returns the documents matching the policy's
+// filter that it did not reprocess with fingerprint yet, up to limit
+// (0 for all).
+func (p *PDFProcessor) reprocessingCandidates(ctx context.Context, rp *ReprocessingPolicy, fingerprint string, limit int) ([]reprocessingCandidate, error) {
+	conditions := []string{
+		"tenant_id = $1",
+		"status = 'completed'",
+		"parent_id = ''",
+		"file_url <> ''",
+		"result->>'content_sha256' <> ''",
+		`NOT EXISTS (
+			SELECT 1 FROM reprocessed_documents r
+			WHERE r.policy_id = $2 AND r.content_sha256 = processing_jobs.result->>'content_sha256' AND r.fingerprint = $3
+		)`,
+	}
+	args := []interface{}{rp.TenantID, rp.ID, fingerprint}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if len(rp.Filter.DocumentTypes) > 0 {
+		where("result->'classification'->>'type' = ANY($%d)", pq.Array(rp.Filter.DocumentTypes))
+	}
+	if rp.Filter.TenderID != "" {
+		where("tender_id = $%d", rp.Filter.TenderID)
+	}
+	if rp.Filter.Since != nil {
+		where("created_at >= $%d", *rp.Filter.Since)
+	}
+
+	query := `
+		SELECT DISTINCT ON (result->>'content_sha256') id, tender_id, user_id, filename, file_url, result->>'content_sha256'
+		FROM processing_jobs
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY result->>'content_sha256', created_at DESC`
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	rows, err := p.postgres.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select documents to reprocess: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []reprocessingCandidate
+	for rows.Next() {
+		var c reprocessingCandidate
+		if err := rows.Scan(&c.jobID, &c.tenderID, &c.userID, &c.filename, &c.fileURL, &c.digest); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// reprocessingFingerprint identifies what a reprocessed document was
+// analysed with: the options and the risk rules of its tenant.
+func reprocessingFingerprint(options ProcessingOptions, rules []risk.Rule) (string, error) {
+	options.Password = ""
+	options.TimeoutSeconds = 0
+	options.OCRParallelism = 0
+	data, err := json.Marshal(struct {
+		Options ProcessingOptions `json:"options"`
+		Rules   []risk.Rule       `json:"rules"`
+	}{options, rules})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (p *PDFProcessor) recordReprocessed(ctx context.Context, rp *ReprocessingPolicy, digest, fingerprint, jobID string) error {
+	return p.postgres.Exec(ctx, `
+		INSERT INTO reprocessed_documents (policy_id, content_sha256, fingerprint, job_id, reprocessed_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (policy_id, content_sha256) DO UPDATE SET
+			fingerprint = EXCLUDED.fingerprint,
+			job_id = EXCLUDED.job_id,
+			reprocessed_at = EXCLUDED.reprocessed_at
+	`, rp.ID, digest, fingerprint, jobID, time.Now())
+}
+
+func (p *PDFProcessor) saveReprocessingRun(ctx context.Context, rp *ReprocessingPolicy, run *ReprocessingRun) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	return p.postgres.Exec(ctx, `UPDATE reprocessing_policies SET last_run = $2 WHERE id = $1`, rp.ID, data)
+}
+
+// runReprocessingPolicies runs the policies due every
+// REPROCESSING_POLL_INTERVAL, until the pool stops.
+func (wp *WorkerPool) runReprocessingPolicies() {
+	defer wp.wg.Done()
+
+	ticker := time.NewTicker(wp.processor.cfg.ReprocessingPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-wp.ctx.Done():
+			return
+		}
+
+		policies, err := wp.processor.dueReprocessingPolicies(wp.ctx)
+		if err != nil {
+			if wp.ctx.Err() == nil {
+				log.Printf("Failed to read reprocessing policies due: %v", err)
+			}
+			continue
+		}
+		for i := range policies {
+			claimed, err := wp.processor.claimReprocessingRun(wp.ctx, &policies[i])
+			if err != nil {
+				log.Printf("%v", err)
+				continue
+			}
+			if claimed {
+				wp.RunReprocessingPolicy(wp.ctx, &policies[i])
+			}
+		}
+	}
+}
+
+// RunReprocessingPolicy queues the policy's documents to reprocess now,
+// recording the run as the policy's last. A run stops at the first job the
+// pool refuses, leaving the rest to the next run.
+func (wp *WorkerPool) RunReprocessingPolicy(ctx context.Context, rp *ReprocessingPolicy) (*ReprocessingRun, error) {
+	run := &ReprocessingRun{StartedAt: time.Now()}
+	err := wp.reprocess(ctx, rp, run)
+	run.FinishedAt = time.Now()
+	if err != nil {
+		run.Error = err.Error()
+		log.Printf("Reprocessing policy %s stopped after queueing %d jobs: %v", rp.ID, run.Queued, err)
+	} else {
+		log.Printf("Reprocessing policy %s queued %d jobs", rp.ID, run.Queued)
+	}
+
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if err := wp.processor.saveReprocessingRun(saveCtx, rp, run); err != nil {
+		log.Printf("Failed to save run of reprocessing policy %s: %v", rp.ID, err)
+	}
+	rp.LastRun = run
+	return run, err
+}
+
+func (wp *WorkerPool) reprocess(ctx context.Context, rp *ReprocessingPolicy, run *ReprocessingRun) error {
+	p := wp.processor
+	template, err := rp.options(ctx, p)
+	if err != nil {
+		return err
+	}
+	var rules []risk.Rule
+	if p.riskRules != nil {
+		rules = p.riskRules.Rules(rp.TenantID)
+	}
+	fingerprint, err := reprocessingFingerprint(template.Options, rules)
+	if err != nil {
+		return err
+	}
+
+	limit := p.cfg.ReprocessingMaxJobs
+	candidates, err := p.reprocessingCandidates(ctx, rp, fingerprint, limit)
+	if err != nil {
+		return err
+	}
+	run.Truncated = limit > 0 && len(candidates) == limit
+
+	priority := rp.Priority
+	if priority == "" {
+		priority = PriorityLow
+	}
+	for _, candidate := range candidates {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		job := &ProcessingJob{
+			ID:        uuid.New().String(),
+			FileURL:   candidate.fileURL,
+			TenderID:  candidate.tenderID,
+			UserID:    candidate.userID,
+			TenantID:  rp.TenantID,
+			Profile:   template.Profile,
+			Priority:  priority,
+			Options:   template.Options,
+			Status:    "queued",
+			CreatedAt: time.Now(),
+			Metadata: map[string]interface{}{
+				"reprocessing_policy_id": rp.ID,
+				"reprocessed_from":       candidate.jobID,
+			},
+		}
+		if candidate.filename != "" {
+			job.Metadata["original_filename"] = candidate.filename
+		}
+
+		if err := p.SaveJob(ctx, job); err != nil {
+			log.Printf("Failed to save job %s: %v", job.ID, err)
+		}
+		if err := wp.SubmitJob(job); err != nil {
+			return fmt.Errorf("failed to queue job for document %s: %w", candidate.digest, err)
+		}
+		run.Queued++
+		if err := p.recordReprocessed(ctx, rp, candidate.digest, fingerprint, job.ID); err != nil {
+			log.Printf("Failed to record document %s reprocessed by policy %s: %v", candidate.digest, rp.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package processor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"cotai-pdf-processor/internal/config"
+)
+
+// The worker pool samples the memory and CPU the service uses and the disk
+// left for its temporary files, and holds work back while they run short,
+// as OCR of large scans can take more memory than the pod has. Memory is
+// the container's working set, from its cgroup, where there is one, and
+// the process's resident set otherwise.
+
+// ResourceUsage is a sample of the resources the service uses.
+type ResourceUsage struct {
+	RSSBytes         int64     `json:"rss_bytes"`
+	MemoryBytes      int64     `json:"memory_bytes"`
+	MemoryLimitBytes int64     `json:"memory_limit_bytes,omitempty"`
+	MemoryPercent    float64   `json:"memory_percent"`
+	CPUPercent       float64   `json:"cpu_percent"`
+	FreeDiskBytes    int64     `json:"free_disk_bytes"`
+	SampledAt        time.Time `json:"sampled_at"`
+}
+
+// resourceMonitor keeps the latest resource sample. A nil monitor holds
+// nothing back.
+type resourceMonitor struct {
+	cfg *config.Config
+
+	mu    sync.RWMutex
+	usage *ResourceUsage
+
+	// CPU time used up to lastSample
+	lastCPU    time.Duration
+	lastSample time.Time
+}
+
+func newResourceMonitor(cfg *config.Config) *resourceMonitor {
+	if cfg.ResourceCheckInterval <= 0 {
+		return nil
+	}
+	return &resourceMonitor{cfg: cfg}
+}
+
+// run samples the resources every RESOURCE_CHECK_INTERVAL until ctx is
+// done.
+func (m *resourceMonitor) run(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	ticker := time.NewTicker(m.cfg.ResourceCheckInterval)
+	defer ticker.Stop()
+	for {
+		m.sample()
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *resourceMonitor) sample() {
+	now := time.Now()
+	usage := &ResourceUsage{SampledAt: now, RSSBytes: processRSS()}
+	usage.MemoryBytes, usage.MemoryLimitBytes = memoryUsage(usage.RSSBytes)
+	if usage.MemoryLimitBytes > 0 {
+		usage.MemoryPercent = 100 * float64(usage.MemoryBytes) / float64(usage.MemoryLimitBytes)
+	}
+
+	var disk syscall.Statfs_t
+	if err := syscall.Statfs(m.cfg.TempDir, &disk); err != nil {
+		log.Printf("Failed to read free disk of %s: %v", m.cfg.TempDir, err)
+	} else {
+		usage.FreeDiskBytes = int64(uint64(disk.Bavail) * uint64(disk.Bsize))
+	}
+
+	cpu := processCPU()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.lastSample.IsZero() {
+		wall := now.Sub(m.lastSample) * time.Duration(runtime.NumCPU())
+		usage.CPUPercent = 100 * float64(cpu-m.lastCPU) / float64(wall)
+	}
+	m.lastCPU, m.lastSample = cpu, now
+	m.usage = usage
+}
+
+// Usage returns the latest sample, or nil when there is none.
+func (m *resourceMonitor) Usage() *ResourceUsage {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.usage == nil {
+		return nil
+	}
+	usage := *m.usage
+	return &usage
+}
+
+// refuseJobs returns why new jobs are refused, or nil when they are taken:
+// memory or disk running short.
+func (m *resourceMonitor) refuseJobs() error {
+	usage := m.Usage()
+	if usage == nil {
+		return nil
+	}
+	if limit := m.cfg.ResourceMaxMemoryPercent; limit > 0 && usage.MemoryPercent >= limit {
+		return fmt.Errorf("%w: memory at %.0f%%", ErrResourcesExhausted, usage.MemoryPercent)
+	}
+	if limit := m.cfg.ResourceMinFreeDisk; limit > 0 && usage.FreeDiskBytes < limit {
+		return fmt.Errorf("%w: %d bytes of disk free", ErrResourcesExhausted, usage.FreeDiskBytes)
+	}
+	return nil
+}
+
+// deferOCR returns why jobs with OCR are deferred, or nil when they run:
+// memory, disk or CPU running short.
+func (m *resourceMonitor) deferOCR() error {
+	if err := m.refuseJobs(); err != nil {
+		return err
+	}
+	usage := m.Usage()
+	if usage == nil {
+		return nil
+	}
+	if limit := m.cfg.ResourceMaxCPUPercent; limit > 0 && usage.CPUPercent >= limit {
+		return fmt.Errorf("%w: CPU at %.0f%%", ErrResourcesExhausted, usage.CPUPercent)
+	}
+	return nil
+}
+
+// processCPU is the CPU time used by the process and its finished
+// children, such as pdftoppm.
+func processCPU() time.Duration {
+	var total time.Duration
+	for _, who := range []int{syscall.RUSAGE_SELF, syscall.RUSAGE_CHILDREN} {
+		var usage syscall.Rusage
+		if err := syscall.Getrusage(who, &usage); err != nil {
+			continue
+		}
+		total += time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+	}
+	return total
+}
+
+// processRSS is the resident set of the process, 0 where /proc is missing.
+func processRSS() int64 {
+	data, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0
+	}
+	pages, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return pages * int64(os.Getpagesize())
+}
+
+// memoryUsage returns the working set of the container and its memory
+// limit, from cgroup v2 or v1, or the process's rss and the machine's
+// memory outside of a cgroup. The working set leaves out the page cache
+// that can be reclaimed, as the OOM killer does.
+func memoryUsage(rss int64) (used, limit int64) {
+	machine := machineMemory()
+	cgroups := []struct{ usage, limit, stat, inactive string }{
+		{"/sys/fs/cgroup/memory.current", "/sys/fs/cgroup/memory.max", "/sys/fs/cgroup/memory.stat", "inactive_file"},
+		{"/sys/fs/cgroup/memory/memory.usage_in_bytes", "/sys/fs/cgroup/memory/memory.limit_in_bytes", "/sys/fs/cgroup/memory/memory.stat", "total_inactive_file"},
+	}
+	for _, cgroup := range cgroups {
+		current, err := readIntFile(cgroup.usage)
+		if err != nil {
+			continue
+		}
+		used = current - statValue(cgroup.stat, cgroup.inactive)
+		// No limit reads "max" on v2 and a huge number on v1
+		limit, err = readIntFile(cgroup.limit)
+		if err != nil || limit <= 0 || (machine > 0 && limit > machine) {
+			limit = machine
+		}
+		return max(used, 0), limit
+	}
+	return rss, machine
+}
+
+// machineMemory is the MemTotal of /proc/meminfo in bytes, 0 where it is
+// missing.
+func machineMemory() int64 {
+	return statValue("/proc/meminfo", "MemTotal:") * 1024
+}
+
+func readIntFile(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// statValue is the number after key in a file of "key value" lines, 0
+// when there is none.
+func statValue(path, key string) int64 {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == key {
+			value, _ := strconv.ParseInt(fields[1], 10, 64)
+			return value
+		}
+	}
+	return 0
+}
@@ -0,0 +1,116 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Failed jobs are attempted again while they have attempts left (see
+// JOB_RETRY_MAX_ATTEMPTS), unless their error code marks the failure as
+// permanent, as for an encrypted or oversized document. A retry waits in a
+// Redis sorted set scored by its due time until the worker pool queues it
+// again. The set holds jobs as the queue does, password included.
+
+// retrySchedule is the sorted set of jobs waiting to be retried.
+const retrySchedule = "job-retries"
+
+// retryable reports whether the job is to be attempted again after failing
+// with err.
+func (p *PDFProcessor) retryable(job *ProcessingJob, err error) bool {
+	if job.Attempts >= p.cfg.JobRetryMaxAttempts {
+		return false
+	}
+	code := errorCode(err)
+	if code == "" {
+		return true
+	}
+	for _, permanent := range strings.Split(p.cfg.JobRetryPermanentCodes, ",") {
+		if strings.TrimSpace(permanent) == code {
+			return false
+		}
+	}
+	return true
+}
+
+// retryBackoff is the wait before retrying a job that failed its attempt-th
+// attempt: JOB_RETRY_BACKOFF doubled for each earlier attempt, up to
+// JOB_RETRY_MAX_BACKOFF.
+func (p *PDFProcessor) retryBackoff(attempt int) time.Duration {
+	delay, limit := p.cfg.JobRetryBackoff, p.cfg.JobRetryMaxBackoff
+	for i := 1; i < attempt && delay < limit; i++ {
+		delay *= 2
+	}
+	if limit > 0 && delay > limit {
+		delay = limit
+	}
+	return delay
+}
+
+// scheduleRetry schedules the next attempt of a "retrying" job after its
+// backoff and tells the JobEvents.
+func (p *PDFProcessor) scheduleRetry(ctx context.Context, job *ProcessingJob) error {
+	if err := p.scheduleRetryAt(ctx, job, time.Now().Add(p.retryBackoff(job.Attempts))); err != nil {
+		return err
+	}
+	p.notifyRetrying(ctx, job)
+	return nil
+}
+
+func (p *PDFProcessor) scheduleRetryAt(ctx context.Context, job *ProcessingJob, at time.Time) error {
+	job.Status = "retrying"
+	job.NextRetryAt = &at
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	err = p.redis.Client().ZAdd(ctx, retrySchedule, redis.Z{Score: float64(at.UnixMilli()), Member: body}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to schedule retry of job %s: %w", job.ID, err)
+	}
+	if err := p.updateJobStatus(ctx, job); err != nil {
+		return fmt.Errorf("failed to update status of job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+// takeDueRetries removes up to limit jobs due for retry from the schedule
+// and returns them. Each is taken by one replica only.
+func (p *PDFProcessor) takeDueRetries(ctx context.Context, limit int) ([]*ProcessingJob, error) {
+	client := p.redis.Client()
+	members, err := client.ZRangeByScore(ctx, retrySchedule, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*ProcessingJob
+	for _, member := range members {
+		removed, err := client.ZRem(ctx, retrySchedule, member).Result()
+		if err != nil {
+			return jobs, err
+		}
+		if removed == 0 {
+			// Taken by another replica
+			continue
+		}
+
+		var job ProcessingJob
+		if err := json.Unmarshal([]byte(member), &job); err != nil {
+			log.Printf("Dropping undecodable job retry: %v", err)
+			continue
+		}
+		jobs = append(jobs, &job)
+	}
+	return jobs, nil
+}
@@ -0,0 +1,53 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"cotai-pdf-processor/internal/config"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	p := &PDFProcessor{cfg: &config.Config{JobRetryBackoff: 30 * time.Second, JobRetryMaxBackoff: 3 * time.Minute}}
+	for attempt, want := range map[int]time.Duration{
+		1: 30 * time.Second,
+		2: time.Minute,
+		3: 2 * time.Minute,
+		4: 3 * time.Minute,
+		9: 3 * time.Minute,
+	} {
+		if got := p.retryBackoff(attempt); got != want {
+			t.Errorf("retryBackoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	p := &PDFProcessor{cfg: &config.Config{
+		JobRetryMaxAttempts:    3,
+		JobRetryPermanentCodes: "encrypted_pdf, file_too_large",
+	}}
+	tests := []struct {
+		name     string
+		attempts int
+		err      error
+		want     bool
+	}{
+		{"transient failure", 1, errors.New("connection reset"), true},
+		{"last attempt left", 2, errors.New("connection reset"), true},
+		{"out of attempts", 3, errors.New("connection reset"), false},
+		{"permanent code", 1, &ProcessingError{Code: ErrCodeEncryptedPDF, Err: errors.New("encrypted")}, false},
+		{"wrapped permanent code", 1, fmt.Errorf("processing: %w", &ProcessingError{Code: ErrCodeFileTooLarge, Err: errors.New("too large")}), false},
+		{"other code", 1, &ProcessingError{Code: ErrCodeTimeout, Err: errors.New("timed out")}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.retryable(&ProcessingJob{Attempts: tt.attempts}, tt.err); got != tt.want {
+				t.Errorf("retryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
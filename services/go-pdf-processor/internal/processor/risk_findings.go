@@ -0,0 +1,86 @@
+package processor
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"cotai-pdf-processor/internal/risk"
+)
+
+// maxClauseContext bounds how far, in bytes, a clause is followed on each
+// side of a match when the text has no sentence breaks nearby.
+const maxClauseContext = 400
+
+// riskFinding is a matched rule together with the clause it matched in.
+type riskFinding struct {
+	match   risk.Match
+	page    int
+	section string
+	snippet string
+	start   int
+	end     int
+}
+
+// findRisks locates each match in the text it was evaluated against, and in
+// its sections when the text was segmented.
+func findRisks(matches []risk.Match, text string, pageOffsets []int, sections []Section) []riskFinding {
+	findings := make([]riskFinding, 0, len(matches))
+	for _, match := range matches {
+		finding := riskFinding{match: match}
+		if match.Position >= 0 && match.End <= len(text) && match.Position < match.End {
+			finding.start, finding.end = clauseAround(text, match.Position, match.End)
+			finding.snippet = strings.Join(strings.Fields(text[finding.start:finding.end]), " ")
+			finding.page = pageForOffset(pageOffsets, match.Position)
+			finding.section = sectionAt(sections, match.Position)
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// clauseAround widens text[start:end] to the sentence or paragraph holding
+// it, without leading or trailing whitespace.
+func clauseAround(text string, start, end int) (int, int) {
+	lo, limit := start, max(0, start-maxClauseContext)
+	for lo > limit && !endsClause(text, lo-1) {
+		lo--
+	}
+	for lo < start && !utf8.RuneStart(text[lo]) {
+		lo++
+	}
+
+	hi, limit := end, min(len(text), end+maxClauseContext)
+	for hi < limit && !endsClause(text, hi-1) {
+		hi++
+	}
+	for hi < len(text) && hi > end && !utf8.RuneStart(text[hi]) {
+		hi--
+	}
+
+	for lo < start && isSpace(text[lo]) {
+		lo++
+	}
+	for hi > end && isSpace(text[hi-1]) {
+		hi--
+	}
+	return lo, hi
+}
+
+// endsClause reports whether the byte at i closes a sentence, i.e. it is
+// punctuation followed by whitespace, or a paragraph break.
+func endsClause(text string, i int) bool {
+	if i < 0 || i+1 >= len(text) {
+		return false
+	}
+	switch text[i] {
+	case '.', ';', '!', '?':
+		return isSpace(text[i+1])
+	case '\n':
+		return text[i+1] == '\n'
+	}
+	return false
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
@@ -0,0 +1,212 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cotai-pdf-processor/internal/risk"
+
+	"github.com/google/uuid"
+)
+
+// Tenants can keep weighting profiles that scale how much each risk rule
+// contributes to the RiskScore, selected per job with the risk_profile
+// option.
+
+// maxProfileMultiplier bounds a single weight multiplier.
+const maxProfileMultiplier = 10
+
+var (
+	ErrProfileNotFound = errors.New("risk profile not found")
+	ErrInvalidProfile  = errors.New("invalid risk profile")
+)
+
+// RiskProfile multiplies rule weights by category, with per-rule overrides.
+// Rules without an entry keep their own weight.
+type RiskProfile struct {
+	ID         string             `json:"id"`
+	TenantID   string             `json:"tenant_id"`
+	Name       string             `json:"name"`
+	Categories map[string]float64 `json:"categories"`
+	Rules      map[string]float64 `json:"rules"` // by rule ID
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+}
+
+// weight returns the contribution of rule under the profile.
+func (rp *RiskProfile) weight(rule *risk.Rule) float64 {
+	if rp == nil {
+		return rule.Weight
+	}
+	if multiplier, ok := rp.Rules[rule.ID]; ok {
+		return rule.Weight * multiplier
+	}
+	if multiplier, ok := rp.Categories[rule.Category]; ok {
+		return rule.Weight * multiplier
+	}
+	return rule.Weight
+}
+
+func (rp *RiskProfile) validate() error {
+	rp.Name = strings.TrimSpace(rp.Name)
+	if rp.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidProfile)
+	}
+	if rp.Categories == nil {
+		rp.Categories = map[string]float64{}
+	}
+	if rp.Rules == nil {
+		rp.Rules = map[string]float64{}
+	}
+	for _, multipliers := range []map[string]float64{rp.Categories, rp.Rules} {
+		for key, multiplier := range multipliers {
+			if multiplier < 0 || multiplier > maxProfileMultiplier {
+				return fmt.Errorf("%w: multiplier for %q must be between 0 and %d", ErrInvalidProfile, key, maxProfileMultiplier)
+			}
+		}
+	}
+	return nil
+}
+
+const riskProfileColumns = `id, tenant_id, name, categories, rules, created_at, updated_at`
+
+func scanRiskProfile(row rowScanner) (*RiskProfile, error) {
+	var rp RiskProfile
+	var categories, rules []byte
+	err := row.Scan(&rp.ID, &rp.TenantID, &rp.Name, &categories, &rules, &rp.CreatedAt, &rp.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProfileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(categories, &rp.Categories); err != nil {
+		return nil, fmt.Errorf("invalid categories for risk profile %s: %w", rp.ID, err)
+	}
+	if err := json.Unmarshal(rules, &rp.Rules); err != nil {
+		return nil, fmt.Errorf("invalid rules for risk profile %s: %w", rp.ID, err)
+	}
+	return &rp, nil
+}
+
+// ListRiskProfiles returns a tenant's weighting profiles by name.
+func (p *PDFProcessor) ListRiskProfiles(ctx context.Context, tenantID string) ([]RiskProfile, error) {
+	rows, err := p.postgres.Query(ctx,
+		`SELECT `+riskProfileColumns+` FROM risk_profiles WHERE tenant_id = $1 ORDER BY name`,
+		tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list risk profiles: %w", err)
+	}
+	defer rows.Close()
+
+	profiles := []RiskProfile{}
+	for rows.Next() {
+		rp, err := scanRiskProfile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read risk profile: %w", err)
+		}
+		profiles = append(profiles, *rp)
+	}
+	return profiles, rows.Err()
+}
+
+func (p *PDFProcessor) GetRiskProfile(ctx context.Context, tenantID, id string) (*RiskProfile, error) {
+	return scanRiskProfile(p.postgres.QueryRow(ctx,
+		`SELECT `+riskProfileColumns+` FROM risk_profiles WHERE tenant_id = $1 AND id = $2`,
+		tenantID, id))
+}
+
+func (p *PDFProcessor) CreateRiskProfile(ctx context.Context, rp *RiskProfile) error {
+	if err := rp.validate(); err != nil {
+		return err
+	}
+	categories, rules, err := marshalProfileWeights(rp)
+	if err != nil {
+		return err
+	}
+
+	rp.ID = uuid.New().String()
+	rp.CreatedAt = time.Now()
+	rp.UpdatedAt = rp.CreatedAt
+
+	err = p.postgres.Exec(ctx, `
+		INSERT INTO risk_profiles (`+riskProfileColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, rp.ID, rp.TenantID, rp.Name, categories, rules, rp.CreatedAt, rp.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create risk profile: %w", err)
+	}
+	return nil
+}
+
+func (p *PDFProcessor) UpdateRiskProfile(ctx context.Context, rp *RiskProfile) error {
+	if err := rp.validate(); err != nil {
+		return err
+	}
+	categories, rules, err := marshalProfileWeights(rp)
+	if err != nil {
+		return err
+	}
+
+	updated, err := scanRiskProfile(p.postgres.QueryRow(ctx, `
+		UPDATE risk_profiles
+		SET name = $3, categories = $4, rules = $5, updated_at = $6
+		WHERE tenant_id = $1 AND id = $2
+		RETURNING `+riskProfileColumns,
+		rp.TenantID, rp.ID, rp.Name, categories, rules, time.Now()))
+	if err != nil {
+		return err
+	}
+
+	*rp = *updated
+	return nil
+}
+
+func (p *PDFProcessor) DeleteRiskProfile(ctx context.Context, tenantID, id string) error {
+	var deleted string
+	err := p.postgres.QueryRow(ctx,
+		`DELETE FROM risk_profiles WHERE tenant_id = $1 AND id = $2 RETURNING id`,
+		tenantID, id).Scan(&deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrProfileNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete risk profile: %w", err)
+	}
+	return nil
+}
+
+func marshalProfileWeights(rp *RiskProfile) ([]byte, []byte, error) {
+	categories, err := json.Marshal(rp.Categories)
+	if err != nil {
+		return nil, nil, err
+	}
+	rules, err := json.Marshal(rp.Rules)
+	if err != nil {
+		return nil, nil, err
+	}
+	return categories, rules, nil
+}
+
+// jobRiskProfile loads the profile named in the job's options. A missing
+// profile is logged and the rules' own weights are used instead.
+func (p *PDFProcessor) jobRiskProfile(ctx context.Context, job *ProcessingJob) *RiskProfile {
+	if job.Options.RiskProfile == "" {
+		return nil
+	}
+
+	rp, err := scanRiskProfile(p.postgres.QueryRow(ctx,
+		`SELECT `+riskProfileColumns+` FROM risk_profiles WHERE tenant_id = $1 AND name = $2`,
+		job.TenantID, job.Options.RiskProfile))
+	if err != nil {
+		log.Printf("Failed to load risk profile %q for job %s: %v", job.Options.RiskProfile, job.ID, err)
+		return nil
+	}
+	return rp
+}
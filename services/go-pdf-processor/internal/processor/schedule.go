@@ -0,0 +1,142 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Jobs submitted with a run_at in the future are "scheduled": their IDs
+// wait in a Redis sorted set scored by run_at, and the jobs themselves, as
+// the queue holds them with their password, in a hash, until the worker
+// pool queues them once due. Keeping the jobs apart from the set lets a
+// cancelled job be taken off the schedule by its ID.
+
+const (
+	// jobSchedule is the sorted set of the IDs of scheduled jobs.
+	jobSchedule = "job-schedule"
+
+	// scheduledJobs is the hash of scheduled jobs by ID.
+	scheduledJobs = "scheduled-jobs"
+
+	// maxScheduleDelay is how far ahead jobs may be scheduled.
+	maxScheduleDelay = 30 * 24 * time.Hour
+)
+
+// ErrInvalidRunAt is returned for run_at values that are not RFC 3339
+// times or are too far ahead.
+var ErrInvalidRunAt = errors.New("run_at must be an RFC 3339 time within 30 days")
+
+// ParseRunAt parses the time a job is to run at; empty runs it at once, as
+// does a time already past.
+func ParseRunAt(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	runAt, err := time.Parse(time.RFC3339, value)
+	if err != nil || time.Until(runAt) > maxScheduleDelay {
+		return nil, ErrInvalidRunAt
+	}
+	return &runAt, nil
+}
+
+// scheduledFor reports whether the job is to wait for its run_at.
+func scheduledFor(job *ProcessingJob) bool {
+	return job.RunAt != nil && job.RunAt.After(time.Now())
+}
+
+// jobStatusTTL is how long the job status is kept: a day, and until their
+// run_at for scheduled jobs.
+func jobStatusTTL(job *ProcessingJob) time.Duration {
+	ttl := 24 * time.Hour
+	if job.Status == "scheduled" && job.RunAt != nil {
+		ttl += max(time.Until(*job.RunAt), 0)
+	}
+	return ttl
+}
+
+// scheduleJob keeps the job off the queue until its RunAt.
+func (p *PDFProcessor) scheduleJob(ctx context.Context, job *ProcessingJob) error {
+	job.Status = "scheduled"
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.redis.Client().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, scheduledJobs, job.ID, body)
+		pipe.ZAdd(ctx, jobSchedule, redis.Z{Score: float64(job.RunAt.UnixMilli()), Member: job.ID})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to schedule job %s: %w", job.ID, err)
+	}
+	if err := p.updateJobStatus(ctx, job); err != nil {
+		return fmt.Errorf("failed to update status of job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+// unscheduleJob takes a job off the schedule, if it is on it.
+func (p *PDFProcessor) unscheduleJob(ctx context.Context, id string) error {
+	_, err := p.redis.Client().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, jobSchedule, id)
+		pipe.HDel(ctx, scheduledJobs, id)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unschedule job %s: %w", id, err)
+	}
+	return nil
+}
+
+// takeDueScheduled removes up to limit jobs whose run_at has come from the
+// schedule and returns them. Each is taken by one replica only.
+func (p *PDFProcessor) takeDueScheduled(ctx context.Context, limit int) ([]*ProcessingJob, error) {
+	client := p.redis.Client()
+	ids, err := client.ZRangeByScore(ctx, jobSchedule, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*ProcessingJob
+	for _, id := range ids {
+		removed, err := client.ZRem(ctx, jobSchedule, id).Result()
+		if err != nil {
+			return jobs, err
+		}
+		if removed == 0 {
+			// Taken by another replica, or cancelled
+			continue
+		}
+
+		body, err := client.HGet(ctx, scheduledJobs, id).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return jobs, err
+		}
+		if err := client.HDel(ctx, scheduledJobs, id).Err(); err != nil {
+			log.Printf("Failed to remove scheduled job %s: %v", id, err)
+		}
+
+		var job ProcessingJob
+		if err := json.Unmarshal(body, &job); err != nil {
+			log.Printf("Dropping undecodable scheduled job %s: %v", id, err)
+			continue
+		}
+		jobs = append(jobs, &job)
+	}
+	return jobs, nil
+}
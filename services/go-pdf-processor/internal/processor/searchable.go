@@ -0,0 +1,76 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+
+	"cotai-pdf-processor/internal/report"
+)
+
+// defaultScanDPI is the resolution assumed for page images when the job
+// sets none; it only affects the page size of the searchable PDF.
+const defaultScanDPI = 300
+
+var ErrSearchableNotAvailable = errors.New("job has no searchable PDF")
+
+// attachSearchablePDF lays the OCR words over the page images they were
+// recognized on and stores the resulting PDF. Failures are logged and leave
+// the result without it.
+func (p *PDFProcessor) attachSearchablePDF(ctx context.Context, jobID string, result *ProcessingResult, pages []ocrPage, words []wordBox) {
+	ctx, span := p.tracer.Start(ctx, "generate_searchable_pdf")
+	defer span.End()
+
+	if p.reports == nil {
+		log.Printf("Searchable PDF requested for job %s but report storage is not configured", jobID)
+		return
+	}
+
+	scanned := make([]report.ScannedPage, 0, len(pages))
+	for _, page := range pages {
+		image, err := os.ReadFile(page.path)
+		if err != nil {
+			log.Printf("Failed to read page %d image of job %s: %v", page.number, jobID, err)
+			return
+		}
+		scannedPage := report.ScannedPage{Image: image, DPI: page.dpi}
+		for _, word := range words {
+			if word.Box.Page != page.number || word.Start < 0 || word.End > len(result.ExtractedText) {
+				continue
+			}
+			scannedPage.Words = append(scannedPage.Words, report.ScannedWord{
+				Text:   result.ExtractedText[word.Start:word.End],
+				X:      word.Box.X,
+				Y:      word.Box.Y,
+				Width:  word.Box.Width,
+				Height: word.Box.Height,
+			})
+		}
+		scanned = append(scanned, scannedPage)
+	}
+
+	pdf, err := report.SearchablePDF(scanned)
+	if err != nil {
+		log.Printf("Failed to build searchable PDF for job %s: %v", jobID, err)
+		return
+	}
+	artifact, err := p.reports.PublishSearchable(ctx, jobID, pdf)
+	if err != nil {
+		log.Printf("Failed to publish searchable PDF for job %s: %v", jobID, err)
+		return
+	}
+	result.Searchable = artifact
+}
+
+// SearchableURL returns a fresh download URL for a job's searchable PDF.
+func (p *PDFProcessor) SearchableURL(ctx context.Context, jobID string) (*report.Artifact, error) {
+	job, err := p.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if p.reports == nil || job.Result == nil || job.Result.Searchable == nil {
+		return nil, ErrSearchableNotAvailable
+	}
+	return p.reports.Sign(ctx, job.Result.Searchable.Key)
+}
@@ -0,0 +1,194 @@
+package processor
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Canonical sections of an edital
+const (
+	SectionObjeto       = "objeto"
+	SectionParticipacao = "participacao"
+	SectionHabilitacao  = "habilitacao"
+	SectionProposta     = "proposta"
+	SectionJulgamento   = "julgamento"
+	SectionSancoes      = "sancoes"
+	SectionAnexos       = "anexos"
+)
+
+var sectionLabels = map[string]string{
+	SectionObjeto:       "Objeto",
+	SectionParticipacao: "Condições de participação",
+	SectionHabilitacao:  "Habilitação",
+	SectionProposta:     "Proposta",
+	SectionJulgamento:   "Julgamento",
+	SectionSancoes:      "Sanções",
+	SectionAnexos:       "Anexos",
+}
+
+// maxHeadingLength bounds the length of a line taken as a section heading;
+// longer lines are clauses mentioning the section's subject.
+const maxHeadingLength = 120
+
+// Section is a part of the document starting at a recognized heading.
+// StartPos and EndPos are byte offsets in the extracted text, or, for
+// streamed documents, in the page texts joined by newlines.
+type Section struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	StartPage int    `json:"start_page,omitempty"`
+	EndPage   int    `json:"end_page,omitempty"`
+	StartPos  int    `json:"start_pos"`
+	EndPos    int    `json:"end_pos"`
+}
+
+// headingNumbering matches the numbering headings start with: "1.", "2 -",
+// "IV -", "CAPÍTULO II -", "CLÁUSULA PRIMEIRA –". Sub-items such as "1.1"
+// are not headings.
+var headingNumbering = regexp.MustCompile(`(?i)^(?:(?:cap[íi]tulo|se[çc][ãa]o|t[íi]tulo|cl[áa]usula)\s+\S+\s*[-–—.:]?\s*|[IVXL]{1,6}\s*[-–—.)]\s*|\d{1,2}(?:\s*[-–—.)]\s*|\s+))`)
+
+// tocEntry matches table of contents lines, which end in a page number,
+// often after dot leaders. annexNumber matches the headings of numbered
+// annexes, which end in a number too.
+var (
+	tocEntry    = regexp.MustCompile(`(?:\.{3,}|…|\s)\s*\d{1,3}$`)
+	annexNumber = regexp.MustCompile(`(?i)^anexo\s+(?:n[º°o.]\s*)?\d+$`)
+)
+
+var sectionHeadings = []struct {
+	sectionType string
+	pattern     *regexp.Regexp
+}{
+	{SectionObjeto, regexp.MustCompile(`(?i)^(?:d[oa]s?\s+)?objeto\b`)},
+	{SectionParticipacao, regexp.MustCompile(`(?i)^(?:d[oa]s?\s+)?(?:condi[çc][õo]es\s+(?:gerais\s+)?(?:de|para)\s+(?:a\s+)?)?participa[çc][ãa]o\b`)},
+	{SectionHabilitacao, regexp.MustCompile(`(?i)^(?:d[oa]s?\s+)?(?:(?:documentos?|documenta[çc][ãa]o|exig[êe]ncias)\s+(?:de|para|da)\s+)?habilita[çc][ãa]o\b`)},
+	{SectionJulgamento, regexp.MustCompile(`(?i)^(?:d[oa]s?\s+)?(?:(?:crit[ée]rios?\s+de|abertura\s+e)\s+)?julgamento\b`)},
+	{SectionProposta, regexp.MustCompile(`(?i)^(?:d[oa]s?\s+)?(?:(?:apresenta[çc][ãa]o|envio|elabora[çc][ãa]o|recebimento)\s+d[ao]s?\s+)?propostas?\b`)},
+	{SectionSancoes, regexp.MustCompile(`(?i)^(?:d[oa]s?\s+)?(?:san[çc][õo]es|penalidades|infra[çc][õo]es)\b`)},
+	{SectionAnexos, regexp.MustCompile(`(?i)^(?:d[oa]s?\s+)?anexos?\b`)},
+}
+
+// headingType returns the section a line is the heading of. Headings are
+// numbered or written in capitals, so that clauses starting with the same
+// words are not taken for them.
+func headingType(line string) (string, bool) {
+	if len(line) > maxHeadingLength {
+		return "", false
+	}
+
+	rest := line
+	numbered := false
+	if loc := headingNumbering.FindStringIndex(line); loc != nil {
+		rest, numbered = line[loc[1]:], true
+	}
+
+	for _, heading := range sectionHeadings {
+		loc := heading.pattern.FindStringIndex(rest)
+		if loc == nil {
+			continue
+		}
+		if !numbered && strings.ToUpper(rest[:loc[1]]) != rest[:loc[1]] {
+			return "", false
+		}
+		if tocEntry.MatchString(rest) && !annexNumber.MatchString(rest) {
+			return "", false
+		}
+		return heading.sectionType, true
+	}
+	return "", false
+}
+
+// sectionSegmenter splits text into sections as it is read, page by page
+// for streamed documents.
+type sectionSegmenter struct {
+	sections []Section
+
+	// hasBody tells whether the last section has text besides its heading.
+	// A heading directly followed by one of another section is a table of
+	// contents entry.
+	hasBody bool
+}
+
+// add reads text starting at byte offset offset of the document.
+func (s *sectionSegmenter) add(text string, offset int) {
+	for start := 0; start < len(text); {
+		end := strings.IndexByte(text[start:], '\n')
+		if end < 0 {
+			end = len(text)
+		} else {
+			end += start
+		}
+		s.addLine(text[start:end], offset+start)
+		start = end + 1
+	}
+}
+
+func (s *sectionSegmenter) addLine(line string, pos int) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return
+	}
+
+	sectionType, ok := headingType(trimmed)
+	last := len(s.sections) - 1
+	switch {
+	case !ok:
+		s.hasBody = last >= 0
+		return
+	case last >= 0 && s.sections[last].Type == SectionAnexos:
+		// Annexes come last and have headings of their own, e.g. a termo
+		// de referência with its own objeto
+		return
+	case last >= 0 && s.sections[last].Type == sectionType:
+		// A subheading, e.g. "DA APRESENTAÇÃO DA PROPOSTA" under "DA PROPOSTA"
+		return
+	case last >= 0 && !s.hasBody:
+		s.sections = s.sections[:last]
+	}
+
+	if n := len(s.sections); n > 0 {
+		s.sections[n-1].EndPos = pos
+	}
+	s.sections = append(s.sections, Section{
+		Type:     sectionType,
+		Title:    strings.Join(strings.Fields(trimmed), " "),
+		StartPos: pos + strings.Index(line, trimmed),
+	})
+	s.hasBody = false
+}
+
+// finish closes the last section at end, the length of the text read, and
+// sets the sections' page ranges.
+func (s *sectionSegmenter) finish(end int, pageOffsets []int) []Section {
+	if n := len(s.sections); n > 0 && !s.hasBody {
+		s.sections = s.sections[:n-1]
+	}
+	if n := len(s.sections); n > 0 {
+		s.sections[n-1].EndPos = end
+	}
+	for i := range s.sections {
+		section := &s.sections[i]
+		section.StartPage = pageForOffset(pageOffsets, section.StartPos)
+		section.EndPage = pageForOffset(pageOffsets, max(section.StartPos, section.EndPos-1))
+	}
+	return s.sections
+}
+
+// segmentSections splits text into the canonical edital sections.
+// pageOffsets holds the byte offset at which each page starts, if known.
+func segmentSections(text string, pageOffsets []int) []Section {
+	var s sectionSegmenter
+	s.add(text, 0)
+	return s.finish(len(text), pageOffsets)
+}
+
+// sectionAt returns the type of the section holding byte offset pos, or ""
+// if it is in none. The last section may still be open, with no EndPos.
+func sectionAt(sections []Section, pos int) string {
+	i := sort.Search(len(sections), func(i int) bool { return sections[i].StartPos > pos }) - 1
+	if i < 0 || (sections[i].EndPos > 0 && pos >= sections[i].EndPos) {
+		return ""
+	}
+	return sections[i].Type
+}
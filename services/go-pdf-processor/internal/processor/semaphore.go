@@ -0,0 +1,70 @@
+package processor
+
+import (
+	"context"
+	"sync"
+)
+
+// workerSemaphore bounds the jobs a worker pool processes at once, as a
+// weighted semaphore whose size can change while it is held. Shrinking it
+// below the weight held lets the holders finish; nothing more is acquired
+// until they have released enough.
+type workerSemaphore struct {
+	mu   sync.Mutex
+	size int64
+	held int64
+
+	// changed is closed, and replaced, whenever weight is released or the
+	// size changes
+	changed chan struct{}
+}
+
+func newWorkerSemaphore(size int64) *workerSemaphore {
+	return &workerSemaphore{size: size, changed: make(chan struct{})}
+}
+
+// Acquire waits for n of the semaphore's weight, or fails with ctx.Err()
+// once ctx is done.
+func (s *workerSemaphore) Acquire(ctx context.Context, n int64) error {
+	for {
+		s.mu.Lock()
+		if s.held+n <= s.size {
+			s.held += n
+			s.mu.Unlock()
+			return nil
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *workerSemaphore) Release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.held -= n
+	s.notify()
+}
+
+func (s *workerSemaphore) Resize(size int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.size = size
+	s.notify()
+}
+
+func (s *workerSemaphore) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+func (s *workerSemaphore) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
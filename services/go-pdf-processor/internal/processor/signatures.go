@@ -0,0 +1,29 @@
+package processor
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"cotai-pdf-processor/internal/signature"
+)
+
+// verifySignatures checks the digital signatures of a PDF. They are verified
+// on the downloaded file, since the bytes they cover must be unchanged.
+// Failures are logged and leave the result without signatures.
+func (p *PDFProcessor) verifySignatures(ctx context.Context, filePath string) []signature.Signature {
+	ctx, span := p.tracer.Start(ctx, "verify_signatures")
+	defer span.End()
+
+	if p.signatures == nil {
+		log.Printf("Signature verification is not configured")
+		return nil
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		log.Printf("Failed to read %s for signature verification: %v", filePath, err)
+		return nil
+	}
+	return p.signatures.Verify(ctx, data)
+}
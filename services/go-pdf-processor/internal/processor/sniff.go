@@ -0,0 +1,78 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// sniffLen is how much of a file is inspected; PDF readers accept a header
+// anywhere in the first 1024 bytes.
+const sniffLen = 1024
+
+// SniffContentType identifies a file from its leading bytes. It covers what
+// http.DetectContentType recognizes plus the PDF, TIFF and executable
+// signatures it lacks.
+func SniffContentType(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	header := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	return sniffBytes(header[:n]), nil
+}
+
+func sniffBytes(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte("II*\x00")), bytes.HasPrefix(header, []byte("MM\x00*")):
+		return "image/tiff"
+	case bytes.HasPrefix(header, []byte("MZ")):
+		return "application/x-msdownload"
+	case bytes.HasPrefix(header, []byte("\x7fELF")):
+		return "application/x-executable"
+	case bytes.Contains(header, []byte("%PDF-")):
+		return "application/pdf"
+	}
+
+	mediaType, _, _ := strings.Cut(http.DetectContentType(header), ";")
+	return mediaType
+}
+
+// VerifyContent checks that a file's content is a document the processor
+// can handle, whatever its name or declared type says. It returns the sniffed
+// content type so callers can report it.
+func VerifyContent(filePath, declaredType string) (string, error) {
+	sniffed, err := SniffContentType(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect file: %w", err)
+	}
+
+	format := detectFormat(filePath)
+	if format == formatUnknown {
+		format = formatFromHint(declaredType, filePath)
+	}
+	return sniffed, checkContent(format, sniffed)
+}
+
+// checkContent rejects files whose bytes do not match a supported format.
+// CSV has no signature, so it only has to look like plain text.
+func checkContent(format documentFormat, sniffed string) error {
+	switch {
+	case format == formatUnknown,
+		format == formatCSV && sniffed != "text/plain":
+		return &ProcessingError{
+			Code: ErrCodeUnsupportedType,
+			Err:  fmt.Errorf("file content is %s, not a supported document", sniffed),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,372 @@
+package processor
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// maxTableRows caps the rows kept per sheet; larger sheets are truncated.
+const maxTableRows = 10000
+
+const spreadsheetNS = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+
+type ExtractedTable struct {
+	Name      string     `json:"name"`
+	Page      int        `json:"page"`
+	Source    string     `json:"source"`
+	Headers   []string   `json:"headers"`
+	Rows      [][]string `json:"rows"`
+	Truncated bool       `json:"truncated,omitempty"`
+}
+
+// tablesToText renders tables as tab-separated lines so spreadsheets flow
+// through the same entity and risk analysis as text documents. Each table
+// counts as a page; their start offsets are returned alongside the text.
+func tablesToText(tables []ExtractedTable) (string, []int) {
+	var text strings.Builder
+	offsets := make([]int, 0, len(tables))
+	for _, table := range tables {
+		offsets = append(offsets, text.Len())
+		text.WriteString(table.Name)
+		text.WriteString("\n")
+		text.WriteString(strings.Join(table.Headers, "\t"))
+		text.WriteString("\n")
+		for _, row := range table.Rows {
+			text.WriteString(strings.Join(row, "\t"))
+			text.WriteString("\n")
+		}
+		text.WriteString("\n")
+	}
+	return text.String(), offsets
+}
+
+// newTable splits raw rows into headers (the first non-empty row) and data.
+func newTable(name string, page int, source string, rows [][]string, truncated bool) ExtractedTable {
+	table := ExtractedTable{Name: name, Page: page, Source: source, Rows: [][]string{}, Truncated: truncated}
+	for i, row := range rows {
+		if !isEmptyRow(row) {
+			table.Headers = row
+			table.Rows = rows[i+1:]
+			break
+		}
+	}
+	return table
+}
+
+func isEmptyRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *PDFProcessor) extractTablesFromCSV(ctx context.Context, filePath string) ([]ExtractedTable, error) {
+	ctx, span := p.tracer.Start(ctx, "extract_tables_csv")
+	defer span.End()
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV: %w", err)
+	}
+	defer f.Close()
+
+	buffered := bufio.NewReader(f)
+	sample, _ := buffered.Peek(4096)
+
+	reader := csv.NewReader(buffered)
+	reader.Comma = detectCSVDelimiter(sample)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var rows [][]string
+	truncated := false
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		if len(rows) == maxTableRows {
+			truncated = true
+			break
+		}
+		rows = append(rows, record)
+	}
+
+	return []ExtractedTable{newTable(path.Base(filePath), 1, "csv", rows, truncated)}, nil
+}
+
+// detectCSVDelimiter picks the most frequent of the usual separators in the
+// first line; Brazilian exports commonly use ';' because ',' is the decimal
+// separator.
+func detectCSVDelimiter(sample []byte) rune {
+	firstLine, _, _ := bytes.Cut(sample, []byte("\n"))
+
+	best, bestCount := ',', 0
+	for _, candidate := range []rune{';', ',', '\t', '|'} {
+		if count := bytes.Count(firstLine, []byte(string(candidate))); count > bestCount {
+			best, bestCount = candidate, count
+		}
+	}
+	return best
+}
+
+// extractTablesFromXLSX reads every worksheet of an XLSX workbook, resolving
+// shared strings and inline strings. Numbers are kept as stored.
+func (p *PDFProcessor) extractTablesFromXLSX(ctx context.Context, filePath string) ([]ExtractedTable, error) {
+	ctx, span := p.tracer.Start(ctx, "extract_tables_xlsx")
+	defer span.End()
+
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX: %w", err)
+	}
+	defer archive.Close()
+
+	sheets, err := readXLSXSheets(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	var sharedStrings []string
+	if entry := findZipEntry(archive, "xl/sharedStrings.xml"); entry != nil {
+		if sharedStrings, err = readXLSXSharedStrings(entry); err != nil {
+			return nil, err
+		}
+	}
+
+	tables := make([]ExtractedTable, 0, len(sheets))
+	for i, sheet := range sheets {
+		entry := findZipEntry(archive, sheet.path)
+		if entry == nil {
+			continue
+		}
+		rows, truncated, err := readXLSXRows(entry, sharedStrings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sheet %q: %w", sheet.name, err)
+		}
+		tables = append(tables, newTable(sheet.name, i+1, "xlsx", rows, truncated))
+	}
+
+	return tables, nil
+}
+
+type xlsxSheet struct {
+	name string
+	path string
+}
+
+// readXLSXSheets lists worksheets in workbook order with their part paths.
+func readXLSXSheets(archive *zip.ReadCloser) ([]xlsxSheet, error) {
+	workbookEntry := findZipEntry(archive, "xl/workbook.xml")
+	relsEntry := findZipEntry(archive, "xl/_rels/workbook.xml.rels")
+	if workbookEntry == nil || relsEntry == nil {
+		return nil, fmt.Errorf("invalid XLSX: missing workbook")
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	for _, part := range []struct {
+		entry *zip.File
+		dest  interface{}
+	}{{workbookEntry, &workbook}, {relsEntry, &rels}} {
+		data, err := readZipEntry(part.entry, 16<<20)
+		if err != nil {
+			return nil, err
+		}
+		if err := xml.Unmarshal(data, part.dest); err != nil {
+			return nil, fmt.Errorf("invalid XLSX %s: %w", part.entry.Name, err)
+		}
+	}
+
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		target := rel.Target
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join("xl", target)
+		}
+		targets[rel.ID] = target
+	}
+
+	sheets := make([]xlsxSheet, 0, len(workbook.Sheets))
+	for _, sheet := range workbook.Sheets {
+		if target, ok := targets[sheet.RID]; ok {
+			sheets = append(sheets, xlsxSheet{name: sheet.Name, path: target})
+		}
+	}
+	return sheets, nil
+}
+
+func readXLSXSharedStrings(entry *zip.File) ([]string, error) {
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var strs []string
+	var current strings.Builder
+	inItem, inText := false, false
+
+	decoder := xml.NewDecoder(rc)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid XLSX shared strings: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				inItem = true
+				current.Reset()
+			case "t":
+				inText = inItem
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				inItem = false
+				strs = append(strs, current.String())
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				current.Write(t)
+			}
+		}
+	}
+	return strs, nil
+}
+
+// readXLSXRows streams a worksheet into rows of cell text, placing each cell
+// at the column given by its reference so sparse rows keep their alignment.
+func readXLSXRows(entry *zip.File, sharedStrings []string) ([][]string, bool, error) {
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, false, err
+	}
+	defer rc.Close()
+
+	var rows [][]string
+	var row []string
+	var value strings.Builder
+	cellType, cellCol := "", 0
+	inValue := false
+
+	decoder := xml.NewDecoder(rc)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space != spreadsheetNS {
+				continue
+			}
+			switch t.Name.Local {
+			case "row":
+				if len(rows) == maxTableRows {
+					return rows, true, nil
+				}
+				row = []string{}
+			case "c":
+				cellType = xmlAttr(t, "t")
+				cellCol = columnIndex(xmlAttr(t, "r"), len(row))
+				value.Reset()
+			case "v", "t":
+				inValue = true
+			}
+		case xml.EndElement:
+			if t.Name.Space != spreadsheetNS {
+				continue
+			}
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				for len(row) < cellCol {
+					row = append(row, "")
+				}
+				row = append(row, cellText(cellType, value.String(), sharedStrings))
+			case "row":
+				rows = append(rows, row)
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(t)
+			}
+		}
+	}
+	return rows, false, nil
+}
+
+func cellText(cellType, raw string, sharedStrings []string) string {
+	switch cellType {
+	case "s":
+		var idx int
+		if _, err := fmt.Sscanf(raw, "%d", &idx); err == nil && idx >= 0 && idx < len(sharedStrings) {
+			return sharedStrings[idx]
+		}
+		return ""
+	case "b":
+		if raw == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	default:
+		return raw
+	}
+}
+
+// columnIndex converts the letters of a cell reference such as "AB12" to a
+// zero-based column, falling back to next when the reference is missing.
+func columnIndex(ref string, next int) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	if col == 0 {
+		return next
+	}
+	return col - 1
+}
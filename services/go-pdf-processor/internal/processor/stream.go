@@ -0,0 +1,216 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"cotai-pdf-processor/internal/download"
+	"cotai-pdf-processor/internal/risk"
+)
+
+// Streaming mode keeps memory bounded for very large PDFs: each page's text is
+// persisted as soon as it is extracted and only per-page analysis results are
+// kept. The job result carries a preview of the text; the full text lives in
+// Redis (job:<id>:page:<n>, under the prefix of the job's tenant) and in the
+// processing_job_pages table.
+
+const (
+	// maxStreamPreview caps the text kept in the result of a streamed job.
+	maxStreamPreview = 1 << 20
+
+	// streamProgressInterval is how often, in pages, progress is saved.
+	streamProgressInterval = 50
+)
+
+// shouldStream reports whether a PDF is processed page by page, either because
+// the job asks for it or because it reaches the configured page threshold.
+func (p *PDFProcessor) shouldStream(filePath string, options ProcessingOptions) bool {
+	if options.StreamPages {
+		return true
+	}
+	if p.cfg.StreamingPageThreshold <= 0 {
+		return false
+	}
+
+	// Files that fail to open are left to the regular path, which reports
+	// the error or attempts recovery.
+	file, reader, err := openPDF(filePath, options.Password)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	pageCount, err := safePageCount(reader)
+	return err == nil && pageCount >= p.cfg.StreamingPageThreshold
+}
+
+// processStreaming extracts and analyzes a PDF one page at a time. OCR is not
+// applied in this mode since it works on the whole document.
+func (p *PDFProcessor) processStreaming(ctx context.Context, job *ProcessingJob, file *download.File) (*ProcessingResult, error) {
+	ctx, span := p.tracer.Start(ctx, "process_streaming")
+	defer span.End()
+
+	result := &ProcessingResult{
+		Entities: []ExtractedEntity{},
+		Metadata: make(map[string]interface{}),
+	}
+
+	reader, closePDF, err := p.openPDFWithRecovery(file.Path, job.Options, result.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract text: %w", err)
+	}
+	defer closePDF()
+
+	if job.Metadata == nil {
+		job.Metadata = make(map[string]interface{})
+	}
+
+	preview := make([]byte, 0, 64*1024)
+	textLength := 0
+	customPatterns := p.tenantPatterns(ctx, job.TenantID)
+	var riskFindings []riskFinding
+	matchedRules := make(map[*risk.Rule]bool)
+	var sections sectionSegmenter
+	var pageStarts []int
+	var relevance *relevanceMatcher
+	if job.Options.GenerateScore {
+		relevance = newRelevanceMatcher(p.jobInterestProfile(ctx, job))
+	}
+
+	pageCount, unrecoverable, err := readPDFPages(ctx, reader, job.Options, func(read pdfPage) error {
+		page, text := read.number, read.text
+		if err := p.storePageText(ctx, job, page, text); err != nil {
+			return err
+		}
+
+		offset := textLength
+		textLength += len(text) + 1
+		if len(preview) < maxStreamPreview {
+			pageText, remaining := text, maxStreamPreview-len(preview)
+			if len(pageText) > remaining {
+				for remaining > 0 && !utf8.RuneStart(pageText[remaining]) {
+					remaining--
+				}
+				pageText = pageText[:remaining]
+			}
+			preview = append(preview, pageText...)
+			preview = append(preview, '\n')
+		}
+
+		if job.Options.SegmentSections {
+			pageStarts = append(pageStarts, offset)
+			sections.add(text, offset)
+		}
+		result.Tables = append(result.Tables, read.tables...)
+		if job.Options.TextBlocks {
+			for _, block := range read.blocks {
+				block.StartPos += offset
+				block.EndPos += offset
+				result.Blocks = append(result.Blocks, block)
+			}
+		}
+		if job.Options.ExtractItems {
+			for _, item := range itemsFromText(text, nil) {
+				item.Page = page
+				result.Items = append(result.Items, item)
+			}
+		}
+		if job.Options.ExtractEntities {
+			entities := p.extractBasicEntities(text, nil, customPatterns)
+			if job.Options.NERProvider != "" {
+				entities = append(entities, p.extractNamedEntities(ctx, job.Options, text, nil)...)
+			}
+			for _, entity := range entities {
+				entity.Page = page
+				result.Entities = append(result.Entities, entity)
+			}
+		}
+		if job.Options.AnalyzeRisks {
+			// A rule counts once, at its first matching page
+			for _, finding := range findRisks(p.riskRules.Evaluate(job.TenantID, text), text, nil, nil) {
+				if !matchedRules[finding.match.Rule] {
+					matchedRules[finding.match.Rule] = true
+					finding.page = page
+					finding.section = sectionAt(sections.sections, offset+finding.start)
+					riskFindings = append(riskFindings, finding)
+				}
+			}
+		}
+		if job.Options.GenerateScore {
+			relevance.add(text)
+		}
+
+		if page%streamProgressInterval == 0 {
+			job.Metadata["pages_processed"] = page
+			if err := p.updateJobStatus(ctx, job); err != nil {
+				log.Printf("Failed to update job progress: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract text: %w", err)
+	}
+
+	if len(unrecoverable) > 0 {
+		result.Metadata["unrecoverable_pages"] = unrecoverable
+	}
+	result.ExtractedText = string(preview)
+	result.PageCount = pageCount
+	result.FileSize = file.Size
+	result.Metadata["format"] = string(formatPDF)
+	result.Metadata["ocr_applied"] = false
+	result.Metadata["text_streamed"] = true
+	result.Metadata["text_length"] = textLength
+	result.Metadata["text_truncated"] = textLength > len(preview)
+
+	// Quality is derived from the text length, which is tracked in full
+	result.QualityMetrics = qualityMetricsForLength(textLength, pageCount)
+
+	// A document's type shows in its first pages, which the preview holds
+	if job.Options.ClassifyDocument {
+		classification := p.classifier.Classify(ctx, result.ExtractedText)
+		result.Classification = &classification
+	}
+	if job.Options.SegmentSections {
+		result.Sections = sections.finish(textLength, pageStarts)
+	}
+	if job.Options.ExtractItems {
+		result.Items = dedupeItems(result.Items)
+	}
+	if job.Options.EnrichEntities {
+		p.enrichEntities(ctx, result.Entities)
+	}
+	if job.Options.AnalyzeRisks {
+		result.RiskAnalysis = buildRiskAnalysis(riskFindings, p.jobRiskProfile(ctx, job))
+	}
+	if job.Options.GenerateScore {
+		relevance.addEntities(result.Entities)
+		result.RelevanceScore, result.Relevance = relevance.score(), relevance.details()
+	}
+
+	return result, nil
+}
+
+// storePageText persists one page of a streamed document. Redis holds it for
+// the job's lifetime; Postgres keeps it alongside the job's results.
+func (p *PDFProcessor) storePageText(ctx context.Context, job *ProcessingJob, page int, text string) error {
+	key := fmt.Sprintf("%s:page:%d", jobKey(job.TenantID, job.ID), page)
+	if err := p.redis.Set(ctx, key, []byte(text), 24*time.Hour); err != nil {
+		log.Printf("Failed to cache page %d of job %s: %v", page, job.ID, err)
+	}
+
+	query := `
+		INSERT INTO processing_job_pages (job_id, page_number, text)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (job_id, page_number) DO UPDATE SET
+			text = EXCLUDED.text
+	`
+	if err := p.postgres.Exec(ctx, query, job.ID, page, text); err != nil {
+		return fmt.Errorf("failed to store page %d: %w", page, err)
+	}
+	return nil
+}
@@ -0,0 +1,393 @@
+package processor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Tables are detected on the positioned words of a page: from the text of
+// the PDF content stream, or from OCR word boxes for scans. Ruled tables are
+// read off the grid their lines form; the remaining words are searched for
+// runs of lines whose words line up in columns.
+
+// Thresholds of the table detection, relative to the text height where
+// noted.
+const (
+	// maxRuleThickness and minRuleLength tell drawn lines from other
+	// rectangles, in points.
+	maxRuleThickness = 3.0
+	minRuleLength    = 10.0
+	// ruleTolerance is how far apart, in points, line ends may be and
+	// still meet.
+	ruleTolerance = 2.0
+
+	// wordGap is the gap between glyphs, relative to the font size, from
+	// which they are separate words.
+	wordGap = 0.3
+	// columnGap is the gap between words from which they are in separate
+	// columns.
+	columnGap = 1.0
+	// maxRowGap is the vertical gap between lines from which they are not
+	// in the same table.
+	maxRowGap = 3.0
+
+	// minTableRows and minTableColumns are the smallest tables found by
+	// alignment, counting the header row. Ruled tables need only two of
+	// each.
+	minTableRows    = 3
+	minTableColumns = 3
+)
+
+// layoutWord is a word with its box, in a coordinate space where y grows
+// downwards.
+type layoutWord struct {
+	x0, y0, x1, y1 float64
+	text           string
+	bold           bool
+	used           bool
+}
+
+func (w *layoutWord) height() float64 { return w.y1 - w.y0 }
+
+// ruling is a drawn line: pos is its y for horizontal lines and x for
+// vertical ones, from and to its extent along the other axis.
+type ruling struct {
+	pos, from, to float64
+}
+
+// ocrTables detects tables among OCR word boxes, which have no ruling.
+func ocrTables(text string, boxes []wordBox) []ExtractedTable {
+	byPage := make(map[int][]*layoutWord)
+	var pages []int
+	for _, box := range boxes {
+		if box.Start < 0 || box.End > len(text) || box.Start >= box.End {
+			continue
+		}
+		b := box.Box
+		if _, ok := byPage[b.Page]; !ok {
+			pages = append(pages, b.Page)
+		}
+		byPage[b.Page] = append(byPage[b.Page], &layoutWord{
+			x0: float64(b.X), y0: float64(b.Y),
+			x1: float64(b.X + b.Width), y1: float64(b.Y + b.Height),
+			text: text[box.Start:box.End],
+		})
+	}
+	sort.Ints(pages)
+
+	var tables []ExtractedTable
+	for _, page := range pages {
+		tables = append(tables, detectTables(byPage[page], nil, nil, page, "ocr")...)
+	}
+	return tables
+}
+
+// wordsFromGlyphs joins the glyphs of a content stream into words. PDF y
+// coordinates grow upwards, so they are negated.
+func wordsFromGlyphs(glyphs []pdf.Text) []*layoutWord {
+	var words []*layoutWord
+	var current *layoutWord
+	var lastY, lastSize float64
+	for _, g := range glyphs {
+		if strings.TrimFunc(g.S, unicode.IsSpace) == "" {
+			current = nil
+			continue
+		}
+		size := max(g.FontSize, 1)
+		if current != nil && math.Abs(g.Y-lastY) < size/2 && g.X >= current.x1-size && g.X-current.x1 < wordGap*max(size, lastSize) {
+			current.text += g.S
+			current.x1 = max(current.x1, g.X+g.W)
+			current.y0 = min(current.y0, -(g.Y + size))
+		} else {
+			current = &layoutWord{x0: g.X, y0: -(g.Y + size), x1: g.X + g.W, y1: -g.Y, text: g.S, bold: isBoldFont(g.Font)}
+			words = append(words, current)
+		}
+		lastY, lastSize = g.Y, size
+	}
+	return words
+}
+
+func isBoldFont(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "bold") || strings.Contains(name, "black") || strings.Contains(name, "heavy")
+}
+
+// rulingsFromRects takes thin rectangles as lines and the edges of larger
+// ones, such as cell borders, as four lines.
+func rulingsFromRects(rects []pdf.Rect) (hs, vs []ruling) {
+	for _, r := range rects {
+		x0, x1 := min(r.Min.X, r.Max.X), max(r.Min.X, r.Max.X)
+		y0, y1 := -max(r.Min.Y, r.Max.Y), -min(r.Min.Y, r.Max.Y)
+		w, h := x1-x0, y1-y0
+		switch {
+		case h <= maxRuleThickness && w >= minRuleLength:
+			hs = append(hs, ruling{(y0 + y1) / 2, x0, x1})
+		case w <= maxRuleThickness && h >= minRuleLength:
+			vs = append(vs, ruling{(x0 + x1) / 2, y0, y1})
+		case w >= minRuleLength && h > maxRuleThickness:
+			hs = append(hs, ruling{y0, x0, x1}, ruling{y1, x0, x1})
+			vs = append(vs, ruling{x0, y0, y1}, ruling{x1, y0, y1})
+		}
+	}
+	return hs, vs
+}
+
+// detectTables finds the ruled tables among the words, then the aligned
+// ones among the words left.
+func detectTables(words []*layoutWord, hs, vs []ruling, page int, source string) []ExtractedTable {
+	var grids [][][]string
+	for _, grid := range ruledGrids(hs, vs) {
+		if rows := fillGrid(grid, words); rows != nil {
+			grids = append(grids, rows)
+		}
+	}
+	grids = append(grids, alignedTables(textRows(words))...)
+
+	tables := make([]ExtractedTable, 0, len(grids))
+	for i, rows := range grids {
+		name := fmt.Sprintf("Page %d table %d", page, i+1)
+		tables = append(tables, newTable(name, page, source, rows, false))
+	}
+	return tables
+}
+
+// grid is the column and row boundaries of a ruled table.
+type grid struct {
+	xs, ys []float64
+}
+
+// ruledGrids groups horizontal lines joined by vertical ones into tables.
+func ruledGrids(hs, vs []ruling) []grid {
+	if len(hs) == 0 || len(vs) == 0 {
+		return nil
+	}
+	ys := clusterPositions(hs)
+
+	var grids []grid
+	start := 0
+	for i := 1; i <= len(ys); i++ {
+		if i < len(ys) && spanned(vs, ys[i-1], ys[i]) {
+			continue
+		}
+		if g, ok := gridBetween(ys[start:i], vs); ok {
+			grids = append(grids, g)
+		}
+		start = i
+	}
+	return grids
+}
+
+func gridBetween(ys []float64, vs []ruling) (grid, bool) {
+	if len(ys) < 3 {
+		return grid{}, false
+	}
+	top, bottom := ys[0], ys[len(ys)-1]
+	var inside []ruling
+	for _, v := range vs {
+		if v.from < bottom-ruleTolerance && v.to > top+ruleTolerance {
+			inside = append(inside, v)
+		}
+	}
+	xs := clusterPositions(inside)
+	if len(xs) < 3 {
+		return grid{}, false
+	}
+	return grid{xs: xs, ys: ys}, true
+}
+
+// spanned reports whether a vertical line joins the horizontal lines at y0
+// and y1.
+func spanned(vs []ruling, y0, y1 float64) bool {
+	for _, v := range vs {
+		if v.from <= y0+ruleTolerance && v.to >= y1-ruleTolerance {
+			return true
+		}
+	}
+	return false
+}
+
+// clusterPositions returns the distinct positions of lines, merging those
+// within ruleTolerance.
+func clusterPositions(lines []ruling) []float64 {
+	positions := make([]float64, len(lines))
+	for i, l := range lines {
+		positions[i] = l.pos
+	}
+	sort.Float64s(positions)
+
+	var clustered []float64
+	for _, pos := range positions {
+		if n := len(clustered); n > 0 && pos-clustered[n-1] <= ruleTolerance {
+			continue
+		}
+		clustered = append(clustered, pos)
+	}
+	return clustered
+}
+
+// fillGrid places the words inside the grid into its cells, marking them
+// used. Grids with fewer than two filled columns are not tables, e.g. a
+// frame around a paragraph.
+func fillGrid(g grid, words []*layoutWord) [][]string {
+	cells := make([][][]string, len(g.ys)-1)
+	for i := range cells {
+		cells[i] = make([][]string, len(g.xs)-1)
+	}
+	var inside []*layoutWord
+	for _, w := range words {
+		cx, cy := (w.x0+w.x1)/2, (w.y0+w.y1)/2
+		col := sort.SearchFloat64s(g.xs, cx) - 1
+		row := sort.SearchFloat64s(g.ys, cy) - 1
+		if w.used || col < 0 || col >= len(g.xs)-1 || row < 0 || row >= len(g.ys)-1 {
+			continue
+		}
+		inside = append(inside, w)
+	}
+
+	filledColumns := make(map[int]bool)
+	for _, line := range textRows(inside) {
+		for _, w := range line {
+			col := sort.SearchFloat64s(g.xs, (w.x0+w.x1)/2) - 1
+			row := sort.SearchFloat64s(g.ys, (w.y0+w.y1)/2) - 1
+			cells[row][col] = append(cells[row][col], w.text)
+			filledColumns[col] = true
+		}
+	}
+	if len(filledColumns) < 2 {
+		return nil
+	}
+
+	var rows [][]string
+	for _, cellRow := range cells {
+		row := make([]string, len(cellRow))
+		for i, cell := range cellRow {
+			row[i] = strings.Join(cell, " ")
+		}
+		if !isEmptyRow(row) {
+			rows = append(rows, row)
+		}
+	}
+	for _, w := range inside {
+		w.used = true
+	}
+	return rows
+}
+
+// textRows groups unused words into lines, top to bottom and each left to
+// right.
+func textRows(words []*layoutWord) [][]*layoutWord {
+	var free []*layoutWord
+	for _, w := range words {
+		if !w.used {
+			free = append(free, w)
+		}
+	}
+	sort.SliceStable(free, func(i, j int) bool { return free[i].y1 < free[j].y1 })
+
+	var rows [][]*layoutWord
+	for _, w := range free {
+		n := len(rows)
+		if n > 0 {
+			first := rows[n-1][0]
+			if math.Abs(w.y1-first.y1) < max(first.height(), w.height())/2 {
+				rows[n-1] = append(rows[n-1], w)
+				continue
+			}
+		}
+		rows = append(rows, []*layoutWord{w})
+	}
+	for _, row := range rows {
+		sort.Slice(row, func(i, j int) bool { return row[i].x0 < row[j].x0 })
+	}
+	return rows
+}
+
+// segment is a run of words of a line not separated by a column gap.
+type segment struct {
+	x0, x1 float64
+	text   string
+}
+
+func lineSegments(line []*layoutWord) []segment {
+	var segments []segment
+	for _, w := range line {
+		n := len(segments)
+		if n > 0 && w.x0-segments[n-1].x1 < columnGap*w.height() {
+			segments[n-1].x1 = max(segments[n-1].x1, w.x1)
+			segments[n-1].text += " " + w.text
+			continue
+		}
+		segments = append(segments, segment{w.x0, w.x1, w.text})
+	}
+	return segments
+}
+
+// alignedTables finds runs of consecutive lines split into at least
+// minTableColumns segments, and reads their columns off the union of the
+// segments' extents.
+func alignedTables(lines [][]*layoutWord) [][][]string {
+	var tables [][][]string
+	var run [][]segment
+	var lastY, lastHeight float64
+	flush := func() {
+		if len(run) >= minTableRows {
+			if rows := alignColumns(run); rows != nil {
+				tables = append(tables, rows)
+			}
+		}
+		run = nil
+	}
+
+	for _, line := range lines {
+		segments := lineSegments(line)
+		y, height := line[0].y1, line[0].height()
+		if len(segments) < minTableColumns || (len(run) > 0 && y-lastY > maxRowGap*max(height, lastHeight)) {
+			flush()
+		}
+		if len(segments) >= minTableColumns {
+			run = append(run, segments)
+		}
+		lastY, lastHeight = y, height
+	}
+	flush()
+	return tables
+}
+
+func alignColumns(lines [][]segment) [][]string {
+	var extents []segment
+	for _, line := range lines {
+		extents = append(extents, line...)
+	}
+	sort.Slice(extents, func(i, j int) bool { return extents[i].x0 < extents[j].x0 })
+
+	var columns []segment
+	for _, e := range extents {
+		if n := len(columns); n > 0 && e.x0 <= columns[n-1].x1 {
+			columns[n-1].x1 = max(columns[n-1].x1, e.x1)
+			continue
+		}
+		columns = append(columns, segment{x0: e.x0, x1: e.x1})
+	}
+	if len(columns) < minTableColumns {
+		return nil
+	}
+
+	rows := make([][]string, 0, len(lines))
+	for _, line := range lines {
+		row := make([]string, len(columns))
+		for _, s := range line {
+			col := sort.Search(len(columns), func(i int) bool { return columns[i].x1 >= s.x0 })
+			if row[col] != "" {
+				row[col] += " "
+			}
+			row[col] += s.text
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
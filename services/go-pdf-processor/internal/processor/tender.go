@@ -0,0 +1,194 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"cotai-pdf-processor/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// A tender can be submitted as the set of its files, processed as one
+// unit: a tender job with a child job for each file. Once every file has
+// finished, the tender job gets a consolidated result as archives do (see
+// updateParentJob), with the entities found across the files merged and
+// the risk analysis and relevance score computed over all of their text.
+// The latest tender job of a tender is found by its tender_id.
+
+// maxTenderFiles caps the files of a tender job.
+const maxTenderFiles = 100
+
+var ErrInvalidTender = errors.New("invalid tender")
+
+// TenderFile is a file of a tender, fetched from its URL by the downloader.
+type TenderFile struct {
+	URL  string `json:"url"`
+	Name string `json:"name,omitempty"`
+}
+
+func isTenderJob(job *ProcessingJob) bool {
+	tender, _ := job.Metadata["tender"].(bool)
+	return tender
+}
+
+func tenderJobKey(tenantID, tenderID string) string {
+	return fmt.Sprintf("tender-job:%s:%s", tenantID, tenderID)
+}
+
+// CreateTenderJob turns tender into the tender job of files, saving it and
+// a child job for each file, and returns the children for the worker pool
+// to queue.
+func (p *PDFProcessor) CreateTenderJob(ctx context.Context, tender *ProcessingJob, files []TenderFile) ([]*ProcessingJob, error) {
+	if strings.TrimSpace(tender.TenderID) == "" {
+		return nil, fmt.Errorf("%w: tender_id is required", ErrInvalidTender)
+	}
+	if len(files) == 0 || len(files) > maxTenderFiles {
+		return nil, fmt.Errorf("%w: a tender has 1 to %d files", ErrInvalidTender, maxTenderFiles)
+	}
+
+	children := make([]*ProcessingJob, len(files))
+	tender.ChildIDs = make([]string, len(files))
+	for i, file := range files {
+		if file.URL == "" {
+			return nil, fmt.Errorf("%w: file %d has no url", ErrInvalidTender, i)
+		}
+		child := &ProcessingJob{
+			ID:        uuid.New().String(),
+			ParentID:  tender.ID,
+			FileURL:   file.URL,
+			TenderID:  tender.TenderID,
+			UserID:    tender.UserID,
+			TenantID:  tender.TenantID,
+			Profile:   tender.Profile,
+			Priority:  tender.Priority,
+			Options:   tender.Options,
+			RunAt:     tender.RunAt,
+			Status:    "queued",
+			CreatedAt: time.Now(),
+			Metadata:  map[string]interface{}{"tender_file": true},
+		}
+		if file.Name != "" {
+			child.Metadata["original_filename"] = file.Name
+		}
+		if id, ok := tender.Metadata["interest_profile_id"]; ok {
+			child.Metadata["interest_profile_id"] = id
+		}
+		children[i] = child
+		tender.ChildIDs[i] = child.ID
+	}
+
+	tender.Status = "expanded"
+	tender.Metadata["tender"] = true
+	tender.Metadata["children_finished"] = 0
+	tender.Metadata["children_total"] = len(children)
+	if err := p.updateJobStatus(ctx, tender); err != nil {
+		return nil, fmt.Errorf("failed to save tender job %s: %w", tender.ID, err)
+	}
+	for _, child := range children {
+		if err := p.updateJobStatus(ctx, child); err != nil {
+			return nil, fmt.Errorf("failed to save job %s: %w", child.ID, err)
+		}
+	}
+	if err := p.redis.Set(ctx, tenderJobKey(tender.TenantID, tender.TenderID), tender.ID, jobStatusTTL(tender)); err != nil {
+		return nil, fmt.Errorf("failed to index tender job %s: %w", tender.ID, err)
+	}
+	return children, nil
+}
+
+// GetTenderJob loads the latest tender job of a tender.
+func (p *PDFProcessor) GetTenderJob(ctx context.Context, tenantID, tenderID string) (*ProcessingJob, error) {
+	id, err := p.redis.Get(ctx, tenderJobKey(tenantID, tenderID))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.GetJob(ctx, string(id))
+}
+
+// consolidateTender completes the combined result of a tender job whose
+// files have all finished.
+func (p *PDFProcessor) consolidateTender(ctx context.Context, tender *ProcessingJob, children []*ProcessingJob) {
+	result := tender.Result
+	result.Entities = mergeEntities(result.Entities)
+
+	// Clauses of the edital are read together with those of its anexos
+	if tender.Options.AnalyzeRisks {
+		profile := p.jobRiskProfile(ctx, tender)
+		result.RiskAnalysis = p.performBasicRiskAnalysis(result.ExtractedText, nil, nil, tender.TenantID, profile)
+	}
+	if tender.Options.GenerateScore {
+		result.RelevanceScore, result.Relevance = p.generateRelevanceScore(ctx, tender, result)
+	}
+
+	documents := make([]map[string]interface{}, len(children))
+	for i, child := range children {
+		document := map[string]interface{}{
+			"job_id":   child.ID,
+			"filename": child.Metadata["original_filename"],
+			"url":      child.FileURL,
+			"status":   child.Status,
+			"error":    child.Error,
+		}
+		if child.Result != nil {
+			document["page_count"] = child.Result.PageCount
+			document["entity_count"] = len(child.Result.Entities)
+			document["risk_score"] = child.Result.RiskAnalysis.RiskScore
+			document["overall_risk"] = child.Result.RiskAnalysis.OverallRisk
+			document["relevance_score"] = child.Result.RelevanceScore
+			if child.Result.Classification != nil {
+				document["document_type"] = child.Result.Classification.Type
+			}
+		}
+		documents[i] = document
+	}
+	result.Metadata["documents"] = documents
+}
+
+// mergeEntities drops entities found again in another file, keeping the
+// first with the highest confidence. Dates and amounts are compared by
+// their normalized value, CNPJs and CPFs by their digits.
+func mergeEntities(entities []ExtractedEntity) []ExtractedEntity {
+	merged := []ExtractedEntity{}
+	index := make(map[string]int)
+	for _, entity := range entities {
+		key := entity.Type + "\x00" + entityIdentity(entity)
+		if i, ok := index[key]; ok {
+			if entity.Confidence > merged[i].Confidence {
+				merged[i].Confidence = entity.Confidence
+			}
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, entity)
+	}
+	return merged
+}
+
+func entityIdentity(entity ExtractedEntity) string {
+	if n := entity.Normalized; n != nil {
+		if n.Date != "" {
+			return n.Date
+		}
+		if n.Cents != nil {
+			return strconv.FormatInt(*n.Cents, 10)
+		}
+	}
+	switch entity.Type {
+	case "cnpj", "cpf":
+		var b strings.Builder
+		for _, r := range entity.Value {
+			if r >= '0' && r <= '9' {
+				b.WriteRune(r)
+			}
+		}
+		return b.String()
+	}
+	return strings.ToLower(strings.Join(strings.Fields(entity.Value), " "))
+}
@@ -0,0 +1,80 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Jobs are bounded by JOB_TIMEOUT, or their own TimeoutSeconds up to
+// MAX_JOB_TIMEOUT, and the extraction, OCR and analysis stages within
+// them by their own timeouts. A timeout fails the job with a TimeoutError
+// naming what ran out of time. Documents processed page by page are
+// bounded by the job timeout only.
+
+// Processing stages with a timeout of their own.
+const (
+	stageExtraction = "extraction"
+	stageOCR        = "ocr"
+	stageAnalysis   = "analysis"
+)
+
+// TimeoutError is the cause of a job or stage running out of time.
+type TimeoutError struct {
+	// Stage is empty when the whole job timed out
+	Stage   string
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Stage == "" {
+		return fmt.Sprintf("job timed out after %v", e.Timeout)
+	}
+	return fmt.Sprintf("%s stage timed out after %v", e.Stage, e.Timeout)
+}
+
+// jobTimeout is how long the job may run.
+func (p *PDFProcessor) jobTimeout(job *ProcessingJob) time.Duration {
+	timeout := p.cfg.JobTimeout
+	if job.Options.TimeoutSeconds > 0 {
+		timeout = time.Duration(job.Options.TimeoutSeconds) * time.Second
+	}
+	if p.cfg.MaxJobTimeout > 0 && timeout > p.cfg.MaxJobTimeout {
+		timeout = p.cfg.MaxJobTimeout
+	}
+	return timeout
+}
+
+// jobContext bounds a job by its timeout.
+func (p *PDFProcessor) jobContext(ctx context.Context, job *ProcessingJob) (context.Context, context.CancelFunc) {
+	timeout := p.jobTimeout(job)
+	return context.WithTimeoutCause(ctx, timeout, &TimeoutError{Timeout: timeout})
+}
+
+// stageContext bounds a stage by its configured timeout, if any.
+func (p *PDFProcessor) stageContext(ctx context.Context, stage string) (context.Context, context.CancelFunc) {
+	var timeout time.Duration
+	switch stage {
+	case stageExtraction:
+		timeout = p.cfg.ExtractionTimeout
+	case stageOCR:
+		timeout = p.cfg.OCRTimeout
+	case stageAnalysis:
+		timeout = p.cfg.AnalysisTimeout
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, timeout, &TimeoutError{Stage: stage, Timeout: timeout})
+}
+
+// timedOut returns the TimeoutError that stopped ctx, of its stage or of
+// the job, or nil if none did.
+func timedOut(ctx context.Context) error {
+	var timeout *TimeoutError
+	if ctx.Err() != nil && errors.As(context.Cause(ctx), &timeout) {
+		return timeout
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package processor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Completed jobs of tenants are metered once, in metered_jobs: their
+// pages, those OCR ran on and how long it took, and the bytes of their
+// documents, with the tender they were submitted for. Submissions are
+// refused once a tenant has used up a monthly quota (see CheckQuota); the
+// records are exported for billing (see billing_export.go).
+
+var (
+	ErrQuotaExceeded = errors.New("monthly quota exceeded")
+	ErrInvalidQuota  = errors.New("invalid quota")
+)
+
+// Metrics quotas limit.
+const (
+	MetricPages        = "pages"
+	MetricOCRPages     = "ocr_pages"
+	MetricStorageBytes = "storage_bytes"
+)
+
+// QuotaError is the quota a submission was refused for, until ResetsAt.
+type QuotaError struct {
+	Metric   string
+	Used     int64
+	Limit    int64
+	ResetsAt time.Time
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("monthly quota of %d %s exceeded", e.Limit, strings.ReplaceAll(e.Metric, "_", " "))
+}
+
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// Usage is what a tenant processed in a month.
+type Usage struct {
+	Documents    int64 `json:"documents"`
+	Pages        int64 `json:"pages"`
+	OCRPages     int64 `json:"ocr_pages"`
+	StorageBytes int64 `json:"storage_bytes"`
+}
+
+// Quota is what a tenant may process a month; zero does not limit.
+type Quota struct {
+	Pages        int64 `json:"pages"`
+	OCRPages     int64 `json:"ocr_pages"`
+	StorageBytes int64 `json:"storage_bytes"`
+}
+
+// TenantUsage is a tenant's usage of a month, against its quota.
+type TenantUsage struct {
+	TenantID string    `json:"tenant_id"`
+	Month    string    `json:"month"`
+	Usage    Usage     `json:"usage"`
+	Quota    Quota     `json:"quota"`
+	ResetsAt time.Time `json:"resets_at"`
+}
+
+// TenderUsage is what a tenant processed for a tender in a month; jobs
+// submitted without one are under an empty TenderID.
+type TenderUsage struct {
+	TenderID   string  `json:"tender_id"`
+	OCRSeconds float64 `json:"ocr_seconds"`
+	Usage
+}
+
+// TenantQuota is the quota set for a tenant; nil fields are left to the
+// service's defaults.
+type TenantQuota struct {
+	TenantID     string     `json:"tenant_id"`
+	Pages        *int64     `json:"pages"`
+	OCRPages     *int64     `json:"ocr_pages"`
+	StorageBytes *int64     `json:"storage_bytes"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+}
+
+func (q *TenantQuota) validate() error {
+	for metric, limit := range map[string]*int64{MetricPages: q.Pages, MetricOCRPages: q.OCRPages, MetricStorageBytes: q.StorageBytes} {
+		if limit != nil && *limit < 0 {
+			return fmt.Errorf("%w: %s must not be negative", ErrInvalidQuota, metric)
+		}
+	}
+	return nil
+}
+
+// MonthOf returns the first instant of the month of t, in UTC.
+func MonthOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// GetTenantQuota returns the quota set for a tenant, empty when none is.
+func (p *PDFProcessor) GetTenantQuota(ctx context.Context, tenantID string) (*TenantQuota, error) {
+	q := TenantQuota{TenantID: tenantID}
+	err := p.postgres.QueryRow(ctx,
+		`SELECT pages, ocr_pages, storage_bytes, updated_at FROM tenant_quotas WHERE tenant_id = $1`,
+		tenantID).Scan(&q.Pages, &q.OCRPages, &q.StorageBytes, &q.UpdatedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to load quota of tenant %s: %w", tenantID, err)
+	}
+	return &q, nil
+}
+
+// SetTenantQuota replaces the quota set for a tenant.
+func (p *PDFProcessor) SetTenantQuota(ctx context.Context, q *TenantQuota) error {
+	if err := q.validate(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err := p.postgres.Exec(ctx, `
+		INSERT INTO tenant_quotas (tenant_id, pages, ocr_pages, storage_bytes, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			pages = EXCLUDED.pages,
+			ocr_pages = EXCLUDED.ocr_pages,
+			storage_bytes = EXCLUDED.storage_bytes,
+			updated_at = EXCLUDED.updated_at
+	`, q.TenantID, q.Pages, q.OCRPages, q.StorageBytes, now)
+	if err != nil {
+		return fmt.Errorf("failed to set quota of tenant %s: %w", q.TenantID, err)
+	}
+	q.UpdatedAt = &now
+	return nil
+}
+
+// tenantQuota returns the quota a tenant is held to: its own, over the
+// defaults.
+func (p *PDFProcessor) tenantQuota(ctx context.Context, tenantID string) (Quota, error) {
+	quota := Quota{
+		Pages:        p.cfg.TenantPageQuota,
+		OCRPages:     p.cfg.TenantOCRPageQuota,
+		StorageBytes: p.cfg.TenantStorageQuota,
+	}
+	set, err := p.GetTenantQuota(ctx, tenantID)
+	if err != nil {
+		return Quota{}, err
+	}
+	if set.Pages != nil {
+		quota.Pages = *set.Pages
+	}
+	if set.OCRPages != nil {
+		quota.OCRPages = *set.OCRPages
+	}
+	if set.StorageBytes != nil {
+		quota.StorageBytes = *set.StorageBytes
+	}
+	return quota, nil
+}
+
+// TenantUsage returns what a tenant processed in the month of month, and
+// the quota it is held to.
+func (p *PDFProcessor) TenantUsage(ctx context.Context, tenantID string, month time.Time) (*TenantUsage, error) {
+	month = MonthOf(month)
+	usage := &TenantUsage{
+		TenantID: tenantID,
+		Month:    month.Format("2006-01"),
+		ResetsAt: month.AddDate(0, 1, 0),
+	}
+
+	err := p.postgres.QueryRow(ctx, `
+		SELECT count(*), coalesce(sum(pages), 0), coalesce(sum(ocr_pages), 0), coalesce(sum(storage_bytes), 0)
+		FROM metered_jobs
+		WHERE tenant_id = $1 AND month = $2
+	`, tenantID, month).Scan(&usage.Usage.Documents, &usage.Usage.Pages, &usage.Usage.OCRPages, &usage.Usage.StorageBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load usage of tenant %s: %w", tenantID, err)
+	}
+	if usage.Quota, err = p.tenantQuota(ctx, tenantID); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// TenderUsage returns what a tenant processed for each tender in the month
+// of month, the tenders processing the most pages first.
+func (p *PDFProcessor) TenderUsage(ctx context.Context, tenantID string, month time.Time) ([]TenderUsage, error) {
+	rows, err := p.postgres.Query(ctx, `
+		SELECT tender_id, count(*), sum(pages), sum(ocr_pages), sum(ocr_seconds), sum(storage_bytes)
+		FROM metered_jobs
+		WHERE tenant_id = $1 AND month = $2
+		GROUP BY tender_id
+		ORDER BY sum(pages) DESC, tender_id
+	`, tenantID, MonthOf(month))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load usage of tenant %s by tender: %w", tenantID, err)
+	}
+	defer rows.Close()
+
+	tenders := []TenderUsage{}
+	for rows.Next() {
+		var t TenderUsage
+		if err := rows.Scan(&t.TenderID, &t.Documents, &t.Pages, &t.OCRPages, &t.OCRSeconds, &t.StorageBytes); err != nil {
+			return nil, fmt.Errorf("failed to read usage of tenant %s by tender: %w", tenantID, err)
+		}
+		tenders = append(tenders, t)
+	}
+	return tenders, rows.Err()
+}
+
+// CheckQuota refuses, with a QuotaError, a submission of a document of
+// size bytes by a tenant that has used up a quota of the month. Pages are
+// only known once processed, so the page quotas let the submission through
+// until they are reached.
+func (p *PDFProcessor) CheckQuota(ctx context.Context, tenantID string, size int64) error {
+	if tenantID == "" {
+		return nil
+	}
+	usage, err := p.TenantUsage(ctx, tenantID, time.Now())
+	if err != nil {
+		return err
+	}
+	return usage.admit(size)
+}
+
+// admit returns the QuotaError refusing a submission of a document of size
+// bytes on top of the usage, if any.
+func (u *TenantUsage) admit(size int64) error {
+	exceeded := func(metric string, used, limit int64) error {
+		return &QuotaError{Metric: metric, Used: used, Limit: limit, ResetsAt: u.ResetsAt}
+	}
+	switch quota, used := u.Quota, u.Usage; {
+	case quota.Pages > 0 && used.Pages >= quota.Pages:
+		return exceeded(MetricPages, used.Pages, quota.Pages)
+	case quota.OCRPages > 0 && used.OCRPages >= quota.OCRPages:
+		return exceeded(MetricOCRPages, used.OCRPages, quota.OCRPages)
+	case quota.StorageBytes > 0 && used.StorageBytes+size > quota.StorageBytes:
+		return exceeded(MetricStorageBytes, used.StorageBytes, quota.StorageBytes)
+	}
+	return nil
+}
+
+// meterJob records the usage of a completed job of a tenant, once. Jobs
+// made of others, as tenders, are metered by their parts.
+func (p *PDFProcessor) meterJob(ctx context.Context, job *ProcessingJob) error {
+	if job.Status != "completed" || job.TenantID == "" || job.Result == nil || len(job.ChildIDs) > 0 {
+		return nil
+	}
+
+	// Reused results cost no OCR
+	var ocrSeconds float64
+	if job.Result.DeduplicatedFrom == "" {
+		ocrSeconds, _ = job.Result.Metadata["ocr_seconds"].(float64)
+	}
+
+	now := time.Now()
+	return p.postgres.Exec(ctx, `
+		INSERT INTO metered_jobs (job_id, tenant_id, tender_id, user_id, month, pages, ocr_pages, ocr_seconds,
+			processing_seconds, storage_bytes, metered_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (job_id) DO NOTHING
+	`, job.ID, job.TenantID, job.TenderID, job.UserID, MonthOf(now), job.Result.PageCount, ocrPageCount(job.Result),
+		ocrSeconds, job.Result.ProcessingTime.Seconds(), job.Result.FileSize, now)
+}
+
+// ocrPageCount returns how many pages of a result OCR ran on, as listed in
+// its metadata, decoded from storage or not.
+func ocrPageCount(result *ProcessingResult) int {
+	switch pages := result.Metadata["ocr_pages"].(type) {
+	case []int:
+		return len(pages)
+	case []interface{}:
+		return len(pages)
+	}
+	return 0
+}
@@ -0,0 +1,100 @@
+package processor
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTenantUsageAdmit(t *testing.T) {
+	resetsAt := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		usage      Usage
+		quota      Quota
+		size       int64
+		wantMetric string
+	}{
+		{"no quota", Usage{Pages: 1e6, OCRPages: 1e6, StorageBytes: 1e12}, Quota{}, 1e9, ""},
+		{"under all quotas", Usage{Pages: 99, OCRPages: 9, StorageBytes: 900}, Quota{Pages: 100, OCRPages: 10, StorageBytes: 1000}, 100, ""},
+		{"pages reached", Usage{Pages: 100}, Quota{Pages: 100}, 0, MetricPages},
+		{"OCR pages reached", Usage{Pages: 10, OCRPages: 10}, Quota{Pages: 100, OCRPages: 10}, 0, MetricOCRPages},
+		{"document exceeds storage", Usage{StorageBytes: 900}, Quota{StorageBytes: 1000}, 101, MetricStorageBytes},
+		{"document fills storage", Usage{StorageBytes: 900}, Quota{StorageBytes: 1000}, 100, ""},
+		{"pages before storage", Usage{Pages: 100, StorageBytes: 1000}, Quota{Pages: 100, StorageBytes: 1000}, 1, MetricPages},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usage := &TenantUsage{TenantID: "t1", Usage: tt.usage, Quota: tt.quota, ResetsAt: resetsAt}
+			err := usage.admit(tt.size)
+			if tt.wantMetric == "" {
+				if err != nil {
+					t.Errorf("admit() error = %v", err)
+				}
+				return
+			}
+
+			var quotaErr *QuotaError
+			if !errors.As(err, &quotaErr) || !errors.Is(err, ErrQuotaExceeded) {
+				t.Fatalf("admit() error = %v, want a %v", err, ErrQuotaExceeded)
+			}
+			if quotaErr.Metric != tt.wantMetric || !quotaErr.ResetsAt.Equal(resetsAt) {
+				t.Errorf("admit() = %+v, want the %s quota until %v", quotaErr, tt.wantMetric, resetsAt)
+			}
+		})
+	}
+}
+
+func TestQuotaError(t *testing.T) {
+	err := &QuotaError{Metric: MetricOCRPages, Used: 510, Limit: 500}
+	if got, want := err.Error(), "monthly quota of 500 ocr pages exceeded"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestTenantQuotaValidate(t *testing.T) {
+	zero, negative := int64(0), int64(-1)
+	if err := (&TenantQuota{Pages: &zero}).validate(); err != nil {
+		t.Errorf("validate() of a zero quota error = %v", err)
+	}
+	if err := (&TenantQuota{}).validate(); err != nil {
+		t.Errorf("validate() of the defaults error = %v", err)
+	}
+	if err := (&TenantQuota{StorageBytes: &negative}).validate(); !errors.Is(err, ErrInvalidQuota) {
+		t.Errorf("validate() of a negative quota error = %v, want %v", err, ErrInvalidQuota)
+	}
+}
+
+func TestMonthOf(t *testing.T) {
+	saoPaulo := time.FixedZone("BRT", -3*60*60)
+	for _, tt := range []struct {
+		t    time.Time
+		want time.Time
+	}{
+		{time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		// The last evening of March in São Paulo is April in UTC
+		{time.Date(2024, 3, 31, 22, 0, 0, 0, saoPaulo), time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		if got := MonthOf(tt.t); !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("MonthOf(%v) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestOCRPageCount(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		metadata map[string]interface{}
+		want     int
+	}{
+		{"processed", map[string]interface{}{"ocr_pages": []int{1, 4}}, 2},
+		{"decoded from storage", map[string]interface{}{"ocr_pages": []interface{}{1.0, 2.0, 3.0}}, 3},
+		{"no OCR", map[string]interface{}{}, 0},
+	} {
+		if got := ocrPageCount(&ProcessingResult{Metadata: tt.metadata}); got != tt.want {
+			t.Errorf("ocrPageCount() %s = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -3,42 +3,94 @@ package processor
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"golang.org/x/sync/semaphore"
+	"cotai-pdf-processor/internal/queue"
 )
 
+// retryDelay is how long workers wait after failing to receive a job, and
+// before queueing a child job or a job retry again.
+const retryDelay = time.Second
+
+// retryPoll is how often jobs due for retry are looked for.
+const retryPoll = time.Second
+
+// averageWeight is the weight of the latest job in the average processing
+// time, a moving average.
+const averageWeight = 0.2
+
 type WorkerPool struct {
 	workers     int
 	processor   *PDFProcessor
-	jobQueue    chan *ProcessingJob
-	quit        chan bool
+	jobQueue    queue.Queue
+	ctx         context.Context
+	cancel      context.CancelFunc
 	wg          sync.WaitGroup
-	semaphore   *semaphore.Weighted
+	semaphore   *workerSemaphore
 	active      bool
+	activeJobs  int64
 	mu          sync.RWMutex
+
+	// running cancels the jobs being processed, by ID
+	running     map[string]context.CancelCauseFunc
+	runningMu   sync.Mutex
+
+	// retire stops each running worker, the latest started last
+	retire      []context.CancelFunc
+	nextWorker  int
+
+	// A draining pool has retired its workers and takes no jobs
+	draining       bool
+	drainStart     time.Time
+	runningWorkers int64
+
+	// autoscaler resizes the pool with WORKER_AUTOSCALE
+	autoscaler  *autoscaler
+	resources   *resourceMonitor
+
+	processedJobs int64
+	failedJobs    int64
+	statsMu       sync.Mutex
+	averageTime   float64 // seconds
+	lastProcessed time.Time
 }
 
 type PoolStats struct {
-	TotalWorkers    int       `json:"total_workers"`
-	ActiveJobs      int       `json:"active_jobs"`
-	QueuedJobs      int       `json:"queued_jobs"`
-	ProcessedJobs   int64     `json:"processed_jobs"`
-	FailedJobs      int64     `json:"failed_jobs"`
-	AverageTime     float64   `json:"average_processing_time"`
-	LastProcessed   time.Time `json:"last_processed"`
+	TotalWorkers    int              `json:"total_workers"`
+	ActiveJobs      int              `json:"active_jobs"`
+	QueuedJobs      int              `json:"queued_jobs"`
+	ProcessedJobs   int64            `json:"processed_jobs"`
+	FailedJobs      int64            `json:"failed_jobs"`
+	AverageTime     float64          `json:"average_processing_time"`
+	LastProcessed   time.Time        `json:"last_processed"`
+	Autoscaler      *AutoscalerStats `json:"autoscaler,omitempty"`
+	Resources       *ResourceUsage   `json:"resources,omitempty"`
 }
 
-func NewWorkerPool(workers int, processor *PDFProcessor) *WorkerPool {
-	return &WorkerPool{
+// NewWorkerPool creates a pool of workers taking jobs from jobQueue.
+func NewWorkerPool(workers int, processor *PDFProcessor, jobQueue queue.Queue) *WorkerPool {
+	wp := &WorkerPool{
 		workers:   workers,
 		processor: processor,
-		jobQueue:  make(chan *ProcessingJob, workers*2), // Buffer size
-		quit:      make(chan bool),
-		semaphore: semaphore.NewWeighted(int64(workers)),
+		jobQueue:  jobQueue,
+		semaphore: newWorkerSemaphore(int64(workers)),
+		running:   make(map[string]context.CancelCauseFunc),
+		resources: newResourceMonitor(processor.cfg),
+	}
+	cfg := processor.cfg
+	if cfg.WorkerAutoscale {
+		if cfg.AutoscaleInterval > 0 && cfg.AutoscaleTargetWait > 0 {
+			wp.autoscaler = newAutoscaler(wp)
+		} else {
+			log.Printf("Worker autoscaling disabled: AUTOSCALE_INTERVAL and AUTOSCALE_TARGET_WAIT must be positive")
+		}
 	}
+	return wp
 }
 
 func (wp *WorkerPool) Start() {
@@ -50,11 +102,50 @@ func (wp *WorkerPool) Start() {
 	}
 
 	wp.active = true
+	wp.draining = false
+	wp.ctx, wp.cancel = context.WithCancel(context.Background())
 	
 	// Start worker goroutines
+	wp.retire, wp.nextWorker = nil, 0
 	for i := 0; i < wp.workers; i++ {
+		wp.spawn()
+	}
+	wp.wg.Add(1)
+	go wp.requeueRetries()
+	wp.wg.Add(1)
+	go wp.queueScheduled()
+	if wp.processor.cfg.ReprocessingPollInterval > 0 {
 		wp.wg.Add(1)
-		go wp.worker(i)
+		go wp.runReprocessingPolicies()
+	}
+	if wp.processor.cfg.AnalyticsExportSchedule != "" {
+		wp.wg.Add(1)
+		go wp.runAnalyticsExports()
+	}
+	if wp.processor.cfg.BillingExportSchedule != "" {
+		wp.wg.Add(1)
+		go wp.runBillingExports()
+	}
+	wp.wg.Add(1)
+	go wp.requeueInterrupted()
+	wp.wg.Add(1)
+	go func() {
+		defer wp.wg.Done()
+		wp.processor.watchCancellations(wp.ctx, wp.cancelRunning)
+	}()
+	wp.wg.Add(1)
+	go func() {
+		defer wp.wg.Done()
+		wp.resources.run(wp.ctx)
+	}()
+	if cluster, ok := wp.jobQueue.(queue.Cluster); ok && wp.processor.cfg.InstanceHeartbeat > 0 {
+		wp.wg.Add(1)
+		go wp.heartbeat(cluster)
+	}
+
+	// The autoscaler resizes the pool, so Stop cannot wait for it
+	if wp.autoscaler != nil {
+		go wp.autoscaler.run(wp.ctx)
 	}
 
 	log.Printf("Worker pool started with %d workers", wp.workers)
@@ -69,13 +160,140 @@ func (wp *WorkerPool) Stop() {
 	}
 
 	wp.active = false
-	close(wp.quit)
-	wp.wg.Wait()
-	close(wp.jobQueue)
+	wp.cancel()
+
+	// Running jobs get WORKER_SHUTDOWN_TIMEOUT to finish before they are
+	// interrupted, to be queued again on the next start
+	stopped := make(chan struct{})
+	go func() {
+		wp.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(wp.processor.cfg.WorkerShutdownTimeout):
+		wp.interruptRunning()
+		<-stopped
+	}
+	wp.keepQueued()
+	wp.leave()
 	
 	log.Println("Worker pool stopped")
 }
 
+// spawn starts a worker, stopped by the pool stopping or by retiring it.
+func (wp *WorkerPool) spawn() {
+	ctx, cancel := context.WithCancel(wp.ctx)
+	wp.retire = append(wp.retire, cancel)
+	wp.wg.Add(1)
+	atomic.AddInt64(&wp.runningWorkers, 1)
+	go wp.worker(ctx, wp.nextWorker)
+	wp.nextWorker++
+}
+
+// Resize changes the number of workers, and of jobs processed at once,
+// without restarting the pool. Workers are added at once; retired workers
+// finish the job they are processing first.
+func (wp *WorkerPool) Resize(workers int) error {
+	if workers < 1 || workers > wp.processor.cfg.MaxWorkerCount {
+		return ErrInvalidWorkerCount
+	}
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	previous := wp.workers
+	wp.workers = workers
+	wp.semaphore.Resize(int64(workers))
+	if wp.active && !wp.draining {
+		for len(wp.retire) < workers {
+			wp.spawn()
+		}
+		for len(wp.retire) > workers {
+			last := len(wp.retire) - 1
+			wp.retire[last]()
+			wp.retire = wp.retire[:last]
+		}
+	}
+
+	log.Printf("Worker pool resized from %d to %d workers", previous, workers)
+	return nil
+}
+
+// Drain stops the pool taking jobs, from clients and from the queue, and
+// lets the jobs it is processing finish, for the replica to be taken out
+// of service. DrainProgress follows it; Resume takes jobs again.
+func (wp *WorkerPool) Drain() error {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if !wp.active {
+		return ErrPoolClosed
+	}
+	if wp.draining {
+		return nil
+	}
+
+	wp.draining = true
+	wp.drainStart = time.Now()
+	for _, retire := range wp.retire {
+		retire()
+	}
+	wp.retire = nil
+
+	log.Printf("Draining worker pool, %d jobs running", atomic.LoadInt64(&wp.activeJobs))
+	return nil
+}
+
+// Resume ends a drain, starting the pool's workers again.
+func (wp *WorkerPool) Resume() error {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if !wp.active {
+		return ErrPoolClosed
+	}
+	if !wp.draining {
+		return nil
+	}
+
+	wp.draining = false
+	for len(wp.retire) < wp.workers {
+		wp.spawn()
+	}
+
+	log.Printf("Worker pool resumed with %d workers", wp.workers)
+	return nil
+}
+
+// DrainProgress is how far a drain of the pool has got.
+type DrainProgress struct {
+	Draining bool       `json:"draining"`
+	Drained  bool       `json:"drained"`
+	Since    *time.Time `json:"since,omitempty"`
+
+	// Workers are those still running, each finishing its job
+	Workers    int `json:"workers"`
+	ActiveJobs int `json:"active_jobs"`
+}
+
+func (wp *WorkerPool) DrainProgress() DrainProgress {
+	wp.mu.RLock()
+	defer wp.mu.RUnlock()
+
+	progress := DrainProgress{
+		Draining:   wp.draining,
+		Workers:    int(atomic.LoadInt64(&wp.runningWorkers)),
+		ActiveJobs: int(atomic.LoadInt64(&wp.activeJobs)),
+	}
+	if wp.draining {
+		since := wp.drainStart
+		progress.Since = &since
+		progress.Drained = progress.Workers == 0
+	}
+	return progress
+}
+
 func (wp *WorkerPool) SubmitJob(job *ProcessingJob) error {
 	wp.mu.RLock()
 	defer wp.mu.RUnlock()
@@ -83,35 +301,120 @@ func (wp *WorkerPool) SubmitJob(job *ProcessingJob) error {
 	if !wp.active {
 		return ErrPoolClosed
 	}
+	if wp.draining {
+		return ErrPoolDraining
+	}
 
-	select {
-	case wp.jobQueue <- job:
-		log.Printf("Job %s queued for processing", job.ID)
+	if len(job.DependsOn) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := wp.processor.holdJob(ctx, job); err != nil {
+			return err
+		}
+		log.Printf("Job %s waiting on %d jobs", job.ID, len(job.DependsOn))
+		return nil
+	}
+	if scheduledFor(job) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := wp.processor.scheduleJob(ctx, job); err != nil {
+			return err
+		}
+		log.Printf("Job %s scheduled for %s", job.ID, job.RunAt.Format(time.RFC3339))
 		return nil
-	default:
+	}
+
+	if err := wp.resources.refuseJobs(); err != nil {
+		return err
+	}
+
+	if err := wp.publish(job); err != nil {
+		return err
+	}
+	log.Printf("Job %s queued for processing", job.ID)
+	return nil
+}
+
+// Admit returns ErrPoolDraining or ErrResourcesExhausted while the pool
+// refuses new jobs, for callers to turn them away before taking their
+// documents.
+func (wp *WorkerPool) Admit() error {
+	wp.mu.RLock()
+	draining := wp.draining
+	wp.mu.RUnlock()
+	if draining {
+		return ErrPoolDraining
+	}
+	return wp.resources.refuseJobs()
+}
+
+// publish queues the job with its password, which the job status never
+// holds.
+func (wp *WorkerPool) publish(job *ProcessingJob) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = wp.jobQueue.Publish(ctx, body, queuePriority(job.Priority), job.TenantID)
+	if errors.Is(err, queue.ErrFull) {
 		return ErrQueueFull
 	}
+	if err != nil {
+		return fmt.Errorf("failed to queue job %s: %w", job.ID, err)
+	}
+	return nil
 }
 
-func (wp *WorkerPool) worker(id int) {
+func (wp *WorkerPool) worker(ctx context.Context, id int) {
 	defer wp.wg.Done()
+	defer atomic.AddInt64(&wp.runningWorkers, -1)
 	
 	log.Printf("Worker %d started", id)
 	
 	for {
-		select {
-		case job := <-wp.jobQueue:
-			if job == nil {
-				log.Printf("Worker %d: received nil job, stopping", id)
-				return
-			}
-			
-			wp.processJob(id, job)
-			
-		case <-wp.quit:
+		msg, err := wp.jobQueue.Receive(ctx)
+		if ctx.Err() != nil {
 			log.Printf("Worker %d stopping", id)
 			return
 		}
+		if err != nil {
+			log.Printf("Worker %d: failed to receive job: %v", id, err)
+			select {
+			case <-time.After(retryDelay):
+			case <-ctx.Done():
+			}
+			continue
+		}
+		
+		wp.handleMessage(id, msg)
+	}
+}
+
+// handleMessage processes the job in a queue message and acknowledges it,
+// whatever the outcome. Jobs delivered more than JOB_QUEUE_MAX_DELIVERIES
+// times, whose workers died while processing them, are failed instead.
+func (wp *WorkerPool) handleMessage(workerID int, msg *queue.Message) {
+	var job ProcessingJob
+	if err := json.Unmarshal(msg.Body, &job); err != nil {
+		log.Printf("Worker %d: dropping undecodable message %s: %v", workerID, msg.ID, err)
+	} else if limit := wp.processor.cfg.JobQueueMaxDeliveries; limit > 0 && msg.Deliveries > limit {
+		log.Printf("Worker %d: job %s delivered %d times, giving up", workerID, job.ID, msg.Deliveries)
+		wp.markJobFailed(&job, ErrJobAbandoned)
+	} else {
+		if msg.Deliveries > 1 {
+			log.Printf("Worker %d: job %s delivered again (%d times)", workerID, job.ID, msg.Deliveries)
+		}
+		wp.processJob(workerID, &job)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := wp.jobQueue.Ack(ctx, msg); err != nil {
+		log.Printf("Worker %d: failed to acknowledge message %s: %v", workerID, msg.ID, err)
 	}
 }
 
@@ -119,31 +422,436 @@ func (wp *WorkerPool) processJob(workerID int, job *ProcessingJob) {
 	startTime := time.Now()
 	
 	// Acquire semaphore
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	ctx, cancel := wp.processor.jobContext(context.Background(), job)
 	defer cancel()
+
+	// The job is tracked before the check so a cancellation is not missed
+	// in between
+	ctx, cancelJob := context.WithCancelCause(ctx)
+	defer cancelJob(nil)
+	wp.track(job.ID, cancelJob)
+	defer wp.untrack(job.ID)
+
+	// A job delivered twice is processed by the worker holding its lock,
+	// and not again once finished
+	lock, lockErr := wp.processor.lockJob(ctx, job.ID, cancelJob)
+	if errors.Is(lockErr, ErrJobLocked) {
+		log.Printf("Worker %d: skipping job %s, processed by another worker", workerID, job.ID)
+		return
+	}
+	if lockErr != nil {
+		log.Printf("Worker %d: processing job %s without its lock: %v", workerID, job.ID, lockErr)
+	}
+	defer lock.release()
+
+	if wp.processor.cancelRequested(ctx, job.ID) {
+		log.Printf("Worker %d: skipping cancelled job %s", workerID, job.ID)
+		wp.processor.markCancelled(ctx, job)
+		return
+	}
+	if current, err := wp.processor.GetJob(ctx, job.ID); err == nil && isFinished(current.Status) {
+		log.Printf("Worker %d: skipping job %s, already %s", workerID, job.ID, current.Status)
+		return
+	}
+
+	// Jobs with OCR wait while memory, disk or CPU run short
+	if job.Options.EnableOCR {
+		if reason := wp.resources.deferOCR(); reason != nil && wp.deferJob(workerID, job, reason) {
+			return
+		}
+	}
 	
 	if err := wp.semaphore.Acquire(ctx, 1); err != nil {
+		if errors.Is(context.Cause(ctx), ErrJobInterrupted) {
+			wp.interrupted(workerID, job)
+			return
+		}
+		if errors.Is(context.Cause(ctx), ErrJobLockLost) {
+			log.Printf("Worker %d: job %s taken over by another worker", workerID, job.ID)
+			return
+		}
 		log.Printf("Worker %d: failed to acquire semaphore: %v", workerID, err)
 		wp.markJobFailed(job, err)
 		return
 	}
 	defer wp.semaphore.Release(1)
+
+	atomic.AddInt64(&wp.activeJobs, 1)
+	defer atomic.AddInt64(&wp.activeJobs, -1)
 	
 	log.Printf("Worker %d: processing job %s", workerID, job.ID)
 	
 	// Process the job
-	if err := wp.processor.ProcessDocument(ctx, job); err != nil {
-		log.Printf("Worker %d: job %s failed: %v", workerID, job.ID, err)
-		wp.markJobFailed(job, err)
+	processStart := time.Now()
+	err := wp.processor.ProcessDocument(ctx, job)
+	wp.recordProcessed(time.Since(processStart), err == nil)
+	if err != nil {
+		if job.Status == "retrying" {
+			wp.retryJob(workerID, job, err)
+		} else if job.Status == "cancelled" {
+			log.Printf("Worker %d: job %s cancelled", workerID, job.ID)
+		} else if errors.Is(context.Cause(ctx), ErrJobInterrupted) {
+			wp.interrupted(workerID, job)
+		} else if errors.Is(context.Cause(ctx), ErrJobLockLost) {
+			log.Printf("Worker %d: job %s taken over by another worker", workerID, job.ID)
+		} else {
+			log.Printf("Worker %d: job %s failed: %v", workerID, job.ID, err)
+			wp.markJobFailed(job, err)
+		}
 	} else {
 		duration := time.Since(startTime)
 		log.Printf("Worker %d: job %s completed in %v", workerID, job.ID, duration)
 	}
+
+	if len(job.children) > 0 {
+		wp.enqueueChildren(job.children)
+		job.children = nil
+	}
+}
+
+// enqueueChildren queues jobs spawned while processing another job. The
+// queue may be full, so they are pushed from a separate goroutine that
+// retries until they are queued or the pool stops.
+func (wp *WorkerPool) enqueueChildren(children []*ProcessingJob) {
+	go func() {
+		for _, child := range children {
+			for {
+				err := wp.publish(child)
+				if err == nil {
+					log.Printf("Child job %s queued for processing", child.ID)
+					break
+				}
+				if !errors.Is(err, ErrQueueFull) {
+					log.Printf("Failed to queue child job %s: %v", child.ID, err)
+				}
+				select {
+				case <-time.After(retryDelay):
+				case <-wp.ctx.Done():
+					log.Printf("Worker pool stopped before child job %s was queued", child.ID)
+					return
+				}
+			}
+		}
+	}()
+}
+
+func (wp *WorkerPool) track(id string, cancel context.CancelCauseFunc) {
+	wp.runningMu.Lock()
+	defer wp.runningMu.Unlock()
+	wp.running[id] = cancel
+}
+
+func (wp *WorkerPool) untrack(id string) {
+	wp.runningMu.Lock()
+	defer wp.runningMu.Unlock()
+	delete(wp.running, id)
+}
+
+// cancelRunning stops the job if this replica is processing it.
+func (wp *WorkerPool) cancelRunning(id string) {
+	wp.runningMu.Lock()
+	defer wp.runningMu.Unlock()
+	if cancel, ok := wp.running[id]; ok {
+		log.Printf("Cancelling job %s", id)
+		cancel(ErrJobCancelled)
+	}
+}
+
+// interruptRunning stops the jobs still running at shutdown.
+func (wp *WorkerPool) interruptRunning() {
+	wp.runningMu.Lock()
+	defer wp.runningMu.Unlock()
+	if len(wp.running) > 0 {
+		log.Printf("Interrupting %d running jobs to queue them again on the next start", len(wp.running))
+	}
+	for _, cancel := range wp.running {
+		cancel(ErrJobInterrupted)
+	}
+}
+
+// interrupted keeps a job stopped by interruptRunning for the next worker
+// pool to queue.
+func (wp *WorkerPool) interrupted(workerID int, job *ProcessingJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := wp.processor.saveInterrupted(ctx, job); err != nil {
+		log.Printf("Worker %d: job %s interrupted and lost: %v", workerID, job.ID, err)
+		return
+	}
+	log.Printf("Worker %d: job %s interrupted, it will be queued again on the next start", workerID, job.ID)
+}
+
+// heartbeat tells the replicas sharing the queue that this one is alive,
+// until the pool stops, so the jobs it is processing are not taken over.
+func (wp *WorkerPool) heartbeat(cluster queue.Cluster) {
+	defer wp.wg.Done()
+
+	ticker := time.NewTicker(wp.processor.cfg.InstanceHeartbeat)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(wp.ctx, wp.processor.cfg.InstanceHeartbeat)
+		workers := int(atomic.LoadInt64(&wp.runningWorkers))
+		err := cluster.Heartbeat(ctx, workers, int(atomic.LoadInt64(&wp.activeJobs)))
+		cancel()
+		if err != nil && wp.ctx.Err() == nil {
+			log.Printf("Failed to heartbeat: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-wp.ctx.Done():
+			return
+		}
+	}
+}
+
+// leave removes this replica from those sharing the queue once it stopped.
+func (wp *WorkerPool) leave() {
+	cluster, ok := wp.jobQueue.(queue.Cluster)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := cluster.Leave(ctx); err != nil {
+		log.Printf("Failed to leave the queue's instances: %v", err)
+	}
+}
+
+// Instances lists the replicas sharing the queue, or nil when the queue is
+// not shared.
+func (wp *WorkerPool) Instances(ctx context.Context) ([]queue.Instance, error) {
+	cluster, ok := wp.jobQueue.(queue.Cluster)
+	if !ok {
+		return nil, nil
+	}
+	return cluster.Instances(ctx)
+}
+
+// keepQueued keeps the jobs of a queue that loses them on restart for the
+// next worker pool to queue.
+func (wp *WorkerPool) keepQueued() {
+	drainer, ok := wp.jobQueue.(queue.Drainer)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	messages := drainer.Drain()
+	for _, msg := range messages {
+		var job ProcessingJob
+		if err := json.Unmarshal(msg.Body, &job); err != nil {
+			log.Printf("Dropping undecodable message %s: %v", msg.ID, err)
+			continue
+		}
+		if err := wp.processor.saveInterrupted(ctx, &job); err != nil {
+			log.Printf("Queued job %s lost: %v", job.ID, err)
+		}
+	}
+	if len(messages) > 0 {
+		log.Printf("Kept %d queued jobs for the next start", len(messages))
+	}
+}
+
+// requeueInterrupted queues the jobs interrupted by an earlier shutdown,
+// waiting for room in the queue, until the pool stops.
+func (wp *WorkerPool) requeueInterrupted() {
+	defer wp.wg.Done()
+
+	ids, err := wp.processor.interruptedJobIDs(wp.ctx)
+	if err != nil {
+		log.Printf("Failed to read interrupted jobs: %v", err)
+		return
+	}
+	for _, id := range ids {
+		job, err := wp.processor.takeInterrupted(wp.ctx, id)
+		if err != nil {
+			log.Printf("Failed to take interrupted job %s: %v", id, err)
+			continue
+		}
+		if job == nil {
+			continue
+		}
+
+		for {
+			err := wp.publish(job)
+			if err == nil {
+				log.Printf("Interrupted job %s queued again", job.ID)
+				break
+			}
+			if !errors.Is(err, ErrQueueFull) {
+				log.Printf("Failed to queue interrupted job %s: %v", job.ID, err)
+			}
+			select {
+			case <-time.After(retryDelay):
+				continue
+			case <-wp.ctx.Done():
+			}
+
+			// Left for the next start
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := wp.processor.saveInterrupted(ctx, job); err != nil {
+				log.Printf("Interrupted job %s lost: %v", job.ID, err)
+			}
+			cancel()
+			return
+		}
+	}
+}
+
+// deferJob queues a job again after BACKPRESSURE_RETRY_AFTER without
+// counting an attempt. It reports whether the job was deferred.
+func (wp *WorkerPool) deferJob(workerID int, job *ProcessingJob, reason error) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := wp.processor.scheduleRetryAt(ctx, job, time.Now().Add(wp.processor.cfg.BackpressureRetryAfter)); err != nil {
+		log.Printf("Worker %d: job %s cannot be deferred, processing it: %v", workerID, job.ID, err)
+		return false
+	}
+	log.Printf("Worker %d: job %s deferred until %s: %v", workerID, job.ID, job.NextRetryAt.Format(time.RFC3339), reason)
+	return true
 }
 
+// retryJob schedules the next attempt of a job that failed with err, or
+// fails it if the retry cannot be scheduled.
+func (wp *WorkerPool) retryJob(workerID int, job *ProcessingJob, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if scheduleErr := wp.processor.scheduleRetry(ctx, job); scheduleErr != nil {
+		log.Printf("Worker %d: job %s failed and cannot be retried: %v (%v)", workerID, job.ID, err, scheduleErr)
+		wp.markJobFailed(job, err)
+		return
+	}
+	log.Printf("Worker %d: job %s failed attempt %d, retrying at %s: %v",
+		workerID, job.ID, job.Attempts, job.NextRetryAt.Format(time.RFC3339), err)
+}
+
+// requeueRetries queues jobs again once their retry is due, until the pool
+// stops.
+func (wp *WorkerPool) requeueRetries() {
+	defer wp.wg.Done()
+
+	ticker := time.NewTicker(retryPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-wp.ctx.Done():
+			return
+		}
+
+		jobs, err := wp.processor.takeDueRetries(wp.ctx, int(wp.semaphore.Size()))
+		if err != nil && wp.ctx.Err() == nil {
+			log.Printf("Failed to read job retries: %v", err)
+		}
+		for _, job := range jobs {
+			wp.requeue(job)
+		}
+	}
+}
+
+// requeue queues a job due for retry, scheduling it again shortly when the
+// queue refuses it.
+func (wp *WorkerPool) requeue(job *ProcessingJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	job.Status = "queued"
+	job.NextRetryAt = nil
+	if err := wp.processor.SaveJob(ctx, job); err != nil {
+		log.Printf("Failed to save job %s: %v", job.ID, err)
+	}
+
+	err := wp.publish(job)
+	if err == nil {
+		log.Printf("Job %s queued for attempt %d", job.ID, job.Attempts+1)
+		return
+	}
+	log.Printf("Failed to queue retry of job %s: %v", job.ID, err)
+	if err := wp.processor.scheduleRetryAt(ctx, job, time.Now().Add(retryDelay)); err != nil {
+		log.Printf("Job %s lost its retry: %v", job.ID, err)
+		wp.markJobFailed(job, err)
+	}
+}
+
+// queueScheduled queues scheduled jobs once their run_at has come, until
+// the pool stops.
+func (wp *WorkerPool) queueScheduled() {
+	defer wp.wg.Done()
+
+	ticker := time.NewTicker(retryPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-wp.ctx.Done():
+			return
+		}
+
+		jobs, err := wp.processor.takeDueScheduled(wp.ctx, int(wp.semaphore.Size()))
+		if err != nil && wp.ctx.Err() == nil {
+			log.Printf("Failed to read scheduled jobs: %v", err)
+		}
+		for _, job := range jobs {
+			wp.queueDue(job)
+		}
+	}
+}
+
+// queueDue queues a scheduled job whose run_at has come, scheduling it
+// again shortly when the queue refuses it.
+func (wp *WorkerPool) queueDue(job *ProcessingJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	job.Status = "queued"
+	if err := wp.processor.SaveJob(ctx, job); err != nil {
+		log.Printf("Failed to save job %s: %v", job.ID, err)
+	}
+
+	err := wp.publish(job)
+	if err == nil {
+		log.Printf("Scheduled job %s queued for processing", job.ID)
+		return
+	}
+	log.Printf("Failed to queue scheduled job %s: %v", job.ID, err)
+	runAt := time.Now().Add(retryDelay)
+	job.RunAt = &runAt
+	if err := wp.processor.scheduleJob(ctx, job); err != nil {
+		log.Printf("Scheduled job %s lost: %v", job.ID, err)
+		wp.markJobFailed(job, err)
+	}
+}
+
+// recordProcessed adds an attempt at a job to the processing stats, by
+// which the autoscaler sizes the pool.
+func (wp *WorkerPool) recordProcessed(duration time.Duration, completed bool) {
+	if completed {
+		atomic.AddInt64(&wp.processedJobs, 1)
+	}
+
+	wp.statsMu.Lock()
+	defer wp.statsMu.Unlock()
+	seconds := duration.Seconds()
+	if wp.lastProcessed.IsZero() {
+		wp.averageTime = seconds
+	} else {
+		wp.averageTime += averageWeight * (seconds - wp.averageTime)
+	}
+	wp.lastProcessed = time.Now()
+}
+
+// markJobFailed fails the job for good and adds it to the dead-letter
+// store.
 func (wp *WorkerPool) markJobFailed(job *ProcessingJob, err error) {
+	atomic.AddInt64(&wp.failedJobs, 1)
 	job.Status = "failed"
 	job.Error = err.Error()
+	job.ErrorCode = errorCode(err)
 	now := time.Now()
 	job.CompletedAt = &now
 	
@@ -154,18 +862,38 @@ func (wp *WorkerPool) markJobFailed(job *ProcessingJob, err error) {
 	if updateErr := wp.processor.updateJobStatus(ctx, job); updateErr != nil {
 		log.Printf("Failed to update failed job status: %v", updateErr)
 	}
+	wp.processor.updateParentJob(ctx, job)
+
+	if deadErr := wp.processor.deadLetter(ctx, job); deadErr != nil {
+		log.Printf("Job %s cannot be replayed: %v", job.ID, deadErr)
+	}
 }
 
 func (wp *WorkerPool) GetStats() PoolStats {
-	wp.mu.RLock()
-	defer wp.mu.RUnlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	queued, err := wp.jobQueue.Len(ctx)
+	if err != nil {
+		log.Printf("Failed to read job queue length: %v", err)
+	}
 	
-	return PoolStats{
-		TotalWorkers: wp.workers,
-		ActiveJobs:   int(wp.workers) - int(wp.semaphore.TryAcquire(int64(wp.workers))),
-		QueuedJobs:   len(wp.jobQueue),
-		// Additional stats would be tracked in a real implementation
+	stats := PoolStats{
+		TotalWorkers:  int(wp.semaphore.Size()),
+		ActiveJobs:    int(atomic.LoadInt64(&wp.activeJobs)),
+		QueuedJobs:    int(queued),
+		ProcessedJobs: atomic.LoadInt64(&wp.processedJobs),
+		FailedJobs:    atomic.LoadInt64(&wp.failedJobs),
+	}
+	wp.statsMu.Lock()
+	stats.AverageTime, stats.LastProcessed = wp.averageTime, wp.lastProcessed
+	wp.statsMu.Unlock()
+
+	// Read without holding the pool, which the autoscaler resizes
+	if wp.autoscaler != nil {
+		stats.Autoscaler = wp.autoscaler.Stats()
 	}
+	stats.Resources = wp.resources.Usage()
+	return stats
 }
 
 func (wp *WorkerPool) IsActive() bool {
@@ -182,6 +910,9 @@ func (wp *WorkerPool) HealthCheck() error {
 	if !wp.active {
 		return ErrPoolClosed
 	}
+	if wp.draining {
+		return ErrPoolDraining
+	}
 	
 	// Check if workers are responsive
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -198,9 +929,15 @@ func (wp *WorkerPool) HealthCheck() error {
 
 // Custom errors
 var (
-	ErrPoolClosed     = &PoolError{"worker pool is closed"}
-	ErrQueueFull      = &PoolError{"job queue is full"}
-	ErrPoolOverloaded = &PoolError{"worker pool is overloaded"}
+	ErrPoolClosed         = &PoolError{"worker pool is closed"}
+	ErrPoolDraining       = &PoolError{"worker pool is draining"}
+	ErrQueueFull          = &PoolError{"job queue is full"}
+	ErrPoolOverloaded     = &PoolError{"worker pool is overloaded"}
+	ErrJobNotFound        = &PoolError{"job not found"}
+	ErrJobNotProcessed    = &PoolError{"job has no results yet"}
+	ErrJobAbandoned       = &PoolError{"job was abandoned by its workers too many times"}
+	ErrInvalidWorkerCount = &PoolError{"worker count is out of range"}
+	ErrResourcesExhausted = &PoolError{"not enough resources to process jobs"}
 )
 
 type PoolError struct {
@@ -0,0 +1,364 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cotai-pdf-processor/internal/ocr"
+)
+
+// Zonal OCR reads chosen regions of the pages on their own, so a stamped
+// signature block or a table comes out without the text around it. Regions
+// are given as fractions of the page, or detected: the header and footer
+// bands, ruled tables and compact blocks of ink such as stamps and
+// signatures.
+
+// Detected zone kinds
+const (
+	ZoneHeader = "header"
+	ZoneFooter = "footer"
+	ZoneTables = "tables"
+	ZoneStamps = "stamps"
+)
+
+const (
+	// headerBand and footerBand are the fractions of the page height taken
+	// by the header and footer zones.
+	headerBand = 0.12
+	footerBand = 0.10
+
+	// minRuleSpan is the fraction of the page width a dark run must span
+	// to count as a table rule, and minTableRules how many rules, each
+	// closer than maxRuleGap of the page height to the next, make a table.
+	minRuleSpan   = 0.3
+	minTableRules = 3
+	maxRuleGap    = 0.125
+
+	// Stamps and signatures are blocks of ink between minStampSize and
+	// maxStampSize inches on each side, no more than maxStampAspect times
+	// longer than wide.
+	minStampSize   = 0.8
+	maxStampSize   = 4.0
+	maxStampAspect = 3.0
+
+	// maxZonesPerPage bounds the zones detected on one page.
+	maxZonesPerPage = 20
+)
+
+var errInvalidZone = errors.New("invalid OCR zone")
+
+// OCRZone is a page region to OCR on its own. Either Detect names the kind
+// of zone to look for, or X, Y, Width and Height place the region as
+// fractions of the page size from its top left corner. Page 0 applies the
+// zone to every page.
+type OCRZone struct {
+	Name   string  `json:"name,omitempty"`
+	Page   int     `json:"page,omitempty"`
+	Detect string  `json:"detect,omitempty"`
+	X      float64 `json:"x,omitempty"`
+	Y      float64 `json:"y,omitempty"`
+	Width  float64 `json:"width,omitempty"`
+	Height float64 `json:"height,omitempty"`
+}
+
+// ZoneText is the text read in one zone of a page.
+type ZoneText struct {
+	Name       string      `json:"name,omitempty"`
+	Kind       string      `json:"kind"`
+	Box        BoundingBox `json:"box"`
+	Text       string      `json:"text"`
+	Confidence float64     `json:"confidence"`
+}
+
+func (z OCRZone) validate() error {
+	switch {
+	case z.Page < 0:
+		return fmt.Errorf("%w: page must not be negative", errInvalidZone)
+	case z.Detect != "":
+		switch z.Detect {
+		case ZoneHeader, ZoneFooter, ZoneTables, ZoneStamps:
+			return nil
+		}
+		return fmt.Errorf("%w: unknown zone kind %q", errInvalidZone, z.Detect)
+	case z.X < 0 || z.Y < 0 || z.Width <= 0 || z.Height <= 0 || z.X+z.Width > 1 || z.Y+z.Height > 1:
+		return fmt.Errorf("%w: region must lie within the page", errInvalidZone)
+	}
+	return nil
+}
+
+// pageZone is a zone located on a page image.
+type pageZone struct {
+	name string
+	kind string
+	rect image.Rectangle
+}
+
+// recognizeZones OCRs the job's zones on the page images OCR ran on, or, for
+// PDFs that were not OCRed, on freshly rendered pages. Failures are logged
+// and leave the result without zones.
+func (p *PDFProcessor) recognizeZones(ctx context.Context, job *ProcessingJob, result *ProcessingResult, format documentFormat, filePath string, pages []ocrPage) {
+	ctx, span := p.tracer.Start(ctx, "recognize_zones")
+	defer span.End()
+
+	options := job.Options
+	provider, err := p.ocrEngines.Get(options.OCRProvider)
+	if err != nil {
+		log.Printf("Zonal OCR skipped for job %s: %v", job.ID, err)
+		return
+	}
+
+	dpi := float64(defaultScanDPI)
+	if options.DPI > 0 {
+		dpi = float64(options.DPI)
+	}
+	if len(pages) == 0 {
+		if format != formatPDF {
+			log.Printf("Zonal OCR skipped for job %s: %s documents have no page images", job.ID, format)
+			return
+		}
+		renderCtx, cancel := context.WithTimeout(ctx, ocrRenderTimeout)
+		dir, images, err := p.rasterizePDF(renderCtx, filePath, options.Password, "pnggray", dpi, options.MaxPages)
+		cancel()
+		if err != nil {
+			log.Printf("Failed to render pages of job %s for zonal OCR: %v", job.ID, err)
+			return
+		}
+		defer os.RemoveAll(dir)
+		for i, path := range images {
+			pages = append(pages, ocrPage{number: i + 1, path: path, dpi: dpi})
+		}
+	}
+
+	dir, err := os.MkdirTemp(p.cfg.TempDir, "cotai-zones-*")
+	if err != nil {
+		log.Printf("Zonal OCR skipped for job %s: %v", job.ID, err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	for _, page := range pages {
+		src, err := decodeImageFile(page.path)
+		if err != nil {
+			log.Printf("Zonal OCR skipped on page %d of job %s: %v", page.number, job.ID, err)
+			continue
+		}
+		gray := toGray(src)
+
+		for i, zone := range locateZones(gray, page, options.OCRZones) {
+			text, confidence, err := p.recognizeZone(ctx, provider, gray, zone, page, dir, i, options)
+			if err != nil {
+				log.Printf("Zonal OCR failed on page %d of job %s: %v", page.number, job.ID, err)
+				if ctx.Err() != nil {
+					return
+				}
+				continue
+			}
+			// Detected zones without text were noise
+			if text == "" && zone.kind != "region" {
+				continue
+			}
+			result.Zones = append(result.Zones, ZoneText{
+				Name: zone.name,
+				Kind: zone.kind,
+				Box: BoundingBox{
+					Page:   page.number,
+					X:      zone.rect.Min.X,
+					Y:      zone.rect.Min.Y,
+					Width:  zone.rect.Dx(),
+					Height: zone.rect.Dy(),
+				},
+				Text:       text,
+				Confidence: confidence,
+			})
+		}
+	}
+}
+
+// recognizeZone OCRs one zone, cropped from the page, once an OCR slot is
+// free.
+func (p *PDFProcessor) recognizeZone(ctx context.Context, provider ocr.Provider, page *image.Gray, zone pageZone, info ocrPage, dir string, i int, options ProcessingOptions) (string, float64, error) {
+	select {
+	case p.ocrSlots <- struct{}{}:
+		defer func() { <-p.ocrSlots }()
+	case <-ctx.Done():
+		return "", 0, ctx.Err()
+	}
+
+	crop := filepath.Join(dir, fmt.Sprintf("zone-%d-%d.png", info.number, i))
+	if err := writePNG(crop, page.SubImage(zone.rect)); err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(crop)
+
+	recognized, err := provider.Recognize(ctx, crop, ocr.Options{Languages: options.Languages, DPI: info.dpi})
+	if err != nil {
+		return "", 0, err
+	}
+	return strings.TrimSpace(recognized.Text), recognized.Confidence, nil
+}
+
+// locateZones places the zones that apply to the page on its image.
+func locateZones(img *image.Gray, page ocrPage, zones []OCRZone) []pageZone {
+	bounds := img.Rect
+	w, h := bounds.Dx(), bounds.Dy()
+
+	var located []pageZone
+	for _, zone := range zones {
+		if zone.Page != 0 && zone.Page != page.number {
+			continue
+		}
+		var rects []image.Rectangle
+		kind := zone.Detect
+		switch zone.Detect {
+		case "":
+			kind = "region"
+			rects = []image.Rectangle{image.Rect(
+				int(zone.X*float64(w)), int(zone.Y*float64(h)),
+				int((zone.X+zone.Width)*float64(w)), int((zone.Y+zone.Height)*float64(h)),
+			)}
+		case ZoneHeader:
+			rects = []image.Rectangle{image.Rect(0, 0, w, int(headerBand*float64(h)))}
+		case ZoneFooter:
+			rects = []image.Rectangle{image.Rect(0, h-int(footerBand*float64(h)), w, h)}
+		case ZoneTables:
+			rects = detectTableZones(img)
+		case ZoneStamps:
+			rects = detectStampZones(img, page.dpi)
+		}
+
+		for _, rect := range rects {
+			rect = rect.Add(bounds.Min).Intersect(bounds)
+			if rect.Empty() || len(located) >= maxZonesPerPage {
+				continue
+			}
+			located = append(located, pageZone{name: zone.Name, kind: kind, rect: rect})
+		}
+	}
+	return located
+}
+
+// detectTableZones finds ruled tables: groups of long horizontal rules,
+// each close to the next.
+func detectTableZones(img *image.Gray) []image.Rectangle {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	threshold := otsuThreshold(img)
+	minRun := int(minRuleSpan * float64(w))
+
+	type rule struct{ y0, y1, x0, x1 int }
+	var rules []rule
+	for y := 0; y < h; y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+w]
+		bestStart, bestLen, start := 0, 0, -1
+		for x := 0; x <= w; x++ {
+			if x < w && row[x] < threshold {
+				if start < 0 {
+					start = x
+				}
+				continue
+			}
+			if start >= 0 && x-start > bestLen {
+				bestStart, bestLen = start, x-start
+			}
+			start = -1
+		}
+		if bestLen < minRun {
+			continue
+		}
+		// Thick rules span several rows
+		if n := len(rules); n > 0 && y-rules[n-1].y1 <= 2 {
+			r := &rules[n-1]
+			r.y1 = y
+			r.x0, r.x1 = min(r.x0, bestStart), max(r.x1, bestStart+bestLen)
+			continue
+		}
+		rules = append(rules, rule{y0: y, y1: y, x0: bestStart, x1: bestStart + bestLen})
+	}
+
+	var tables []image.Rectangle
+	maxGap := int(maxRuleGap * float64(h))
+	for i := 0; i < len(rules); {
+		j := i + 1
+		for j < len(rules) && rules[j].y0-rules[j-1].y1 <= maxGap {
+			j++
+		}
+		if j-i >= minTableRules {
+			rect := image.Rect(rules[i].x0, rules[i].y0, rules[i].x1, rules[j-1].y1+1)
+			for _, r := range rules[i:j] {
+				rect.Min.X, rect.Max.X = min(rect.Min.X, r.x0), max(rect.Max.X, r.x1)
+			}
+			tables = append(tables, rect)
+		}
+		i = j
+	}
+	return tables
+}
+
+// detectStampZones finds compact blocks of ink. The page is divided into
+// cells a tenth of an inch wide; inked cells at most one empty cell apart
+// form a block, and blocks of stamp size and shape are kept. Text lines
+// run together into blocks too large to be kept.
+func detectStampZones(img *image.Gray, dpi float64) []image.Rectangle {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	cell := max(8, int(dpi/10))
+	gw, gh := (w+cell-1)/cell, (h+cell-1)/cell
+	threshold := otsuThreshold(img)
+
+	inked := make([]bool, gw*gh)
+	for cy := 0; cy < gh; cy++ {
+		for cx := 0; cx < gw; cx++ {
+			dark, total := 0, 0
+			for y := cy * cell; y < min(h, (cy+1)*cell); y++ {
+				for x := cx * cell; x < min(w, (cx+1)*cell); x++ {
+					total++
+					if img.Pix[y*img.Stride+x] < threshold {
+						dark++
+					}
+				}
+			}
+			inked[cy*gw+cx] = dark*50 > total // over 2% ink
+		}
+	}
+
+	minSize, maxSize := int(minStampSize*dpi), int(maxStampSize*dpi)
+	seen := make([]bool, len(inked))
+	var stamps []image.Rectangle
+	for start := range inked {
+		if !inked[start] || seen[start] {
+			continue
+		}
+		seen[start] = true
+		x0, y0, x1, y1 := start%gw, start/gw, start%gw, start/gw
+		queue := []int{start}
+		for len(queue) > 0 {
+			c := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			cx, cy := c%gw, c/gw
+			x0, y0, x1, y1 = min(x0, cx), min(y0, cy), max(x1, cx), max(y1, cy)
+			for ny := max(0, cy-2); ny <= min(gh-1, cy+2); ny++ {
+				for nx := max(0, cx-2); nx <= min(gw-1, cx+2); nx++ {
+					if n := ny*gw + nx; inked[n] && !seen[n] {
+						seen[n] = true
+						queue = append(queue, n)
+					}
+				}
+			}
+		}
+
+		rect := image.Rect(x0*cell, y0*cell, min(w, (x1+1)*cell), min(h, (y1+1)*cell))
+		bw, bh := rect.Dx(), rect.Dy()
+		if bw < minSize || bh < minSize || bw > maxSize || bh > maxSize {
+			continue
+		}
+		if float64(max(bw, bh)) > maxStampAspect*float64(min(bw, bh)) {
+			continue
+		}
+		stamps = append(stamps, rect)
+	}
+	return stamps
+}
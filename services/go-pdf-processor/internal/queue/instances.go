@@ -0,0 +1,135 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Replicas sharing the Redis queue heartbeat to a sorted set of instances
+// scored by their last heartbeat, with their details in a hash of their
+// own. An instance whose heartbeat is older than INSTANCE_TTL is dead, and
+// the entries pending for it are claimed at once rather than after
+// JOB_QUEUE_CLAIM_IDLE. Consumers that never heartbeat, such as replicas of
+// an earlier release, are only claimed from once idle.
+
+// instanceRetention is how long dead instances stay listed, for their
+// entries to be found dead; past it JOB_QUEUE_CLAIM_IDLE claims them.
+const instanceRetention = 24 * time.Hour
+
+// Instance is a replica consuming the queue.
+type Instance struct {
+	ID         string    `json:"id"`
+	Host       string    `json:"host"`
+	StartedAt  time.Time `json:"started_at"`
+	LastSeen   time.Time `json:"last_seen"`
+	Alive      bool      `json:"alive"`
+	Workers    int       `json:"workers"`
+	ActiveJobs int       `json:"active_jobs"`
+}
+
+// Cluster is implemented by queues shared by replicas, which heartbeat to
+// it.
+type Cluster interface {
+	// Heartbeat records that this instance is alive, with its workers and
+	// the jobs they are processing.
+	Heartbeat(ctx context.Context, workers, activeJobs int) error
+
+	// Leave removes this instance once it stopped consuming.
+	Leave(ctx context.Context) error
+
+	// Instances lists the instances heartbeating, dead ones included,
+	// latest first.
+	Instances(ctx context.Context) ([]Instance, error)
+}
+
+func (q *RedisStream) instancesKey() string {
+	return q.base + ":instances"
+}
+
+func (q *RedisStream) instanceKey(id string) string {
+	return q.base + ":instance:" + id
+}
+
+func (q *RedisStream) Heartbeat(ctx context.Context, workers, activeJobs int) error {
+	now := time.Now()
+	host, _ := os.Hostname()
+	key := q.instanceKey(q.consumer)
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, q.instancesKey(), redis.Z{Score: float64(now.UnixMilli()), Member: q.consumer})
+		pipe.HSet(ctx, key,
+			"host", host,
+			"started_at", q.startedAt.UnixMilli(),
+			"workers", workers,
+			"active_jobs", activeJobs,
+		)
+		pipe.Expire(ctx, key, instanceRetention)
+		pipe.ZRemRangeByScore(ctx, q.instancesKey(), "-inf", strconv.FormatInt(now.Add(-instanceRetention).UnixMilli(), 10))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to heartbeat instance %s: %w", q.consumer, err)
+	}
+	return nil
+}
+
+func (q *RedisStream) Leave(ctx context.Context) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, q.instancesKey(), q.consumer)
+		pipe.Del(ctx, q.instanceKey(q.consumer))
+		return nil
+	})
+	return err
+}
+
+func (q *RedisStream) Instances(ctx context.Context) ([]Instance, error) {
+	members, err := q.client.ZRevRangeWithScores(ctx, q.instancesKey(), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]Instance, 0, len(members))
+	for _, member := range members {
+		id, _ := member.Member.(string)
+		instance := Instance{
+			ID:       id,
+			LastSeen: time.UnixMilli(int64(member.Score)),
+		}
+		instance.Alive = time.Since(instance.LastSeen) < q.instanceTTL
+
+		fields, err := q.client.HGetAll(ctx, q.instanceKey(id)).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+		instance.Host = fields["host"]
+		if ms, err := strconv.ParseInt(fields["started_at"], 10, 64); err == nil {
+			instance.StartedAt = time.UnixMilli(ms)
+		}
+		instance.Workers, _ = strconv.Atoi(fields["workers"])
+		instance.ActiveJobs, _ = strconv.Atoi(fields["active_jobs"])
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// deadInstances returns the listed instances whose heartbeat is older
+// than INSTANCE_TTL.
+func (q *RedisStream) deadInstances(ctx context.Context) (map[string]bool, error) {
+	members, err := q.client.ZRangeByScore(ctx, q.instancesKey(), &redis.ZRangeBy{
+		Min: "-inf",
+		Max: "(" + strconv.FormatInt(time.Now().Add(-q.instanceTTL).UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	dead := make(map[string]bool, len(members))
+	for _, member := range members {
+		dead[member] = true
+	}
+	return dead, nil
+}
@@ -0,0 +1,133 @@
+package queue
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+// Memory is a queue held in the process, for single-instance deployments
+// and development. Messages need no acknowledgment and are lost on
+// restart.
+type Memory struct {
+	capacity int
+
+	mu     sync.Mutex
+	queues []*tenantQueue // by priority
+	nextID int64
+
+	// ready holds a token per message published, waking receivers
+	ready chan struct{}
+}
+
+// tenantQueue holds the messages of one priority by tenant, and the order
+// the tenants are served in.
+type tenantQueue struct {
+	order    []string
+	messages map[string][]*Message
+	len      int
+}
+
+// NewMemory creates a queue holding up to capacity messages of each
+// priority.
+func NewMemory(capacity int) *Memory {
+	q := &Memory{capacity: capacity, ready: make(chan struct{}, capacity*len(priorities))}
+	for range priorities {
+		q.queues = append(q.queues, &tenantQueue{messages: make(map[string][]*Message)})
+	}
+	return q
+}
+
+// Publish queues the body, failing with ErrFull when the queue of its
+// priority is full.
+func (q *Memory) Publish(ctx context.Context, body []byte, priority Priority, tenant string) error {
+	q.mu.Lock()
+	tq := q.queues[priority]
+	if tq.len >= q.capacity {
+		q.mu.Unlock()
+		return ErrFull
+	}
+	q.nextID++
+	msg := &Message{ID: strconv.FormatInt(q.nextID, 10), Body: body, Priority: priority, Tenant: tenant}
+	if _, ok := tq.messages[tenant]; !ok {
+		tq.order = append(tq.order, tenant)
+	}
+	tq.messages[tenant] = append(tq.messages[tenant], msg)
+	tq.len++
+	q.mu.Unlock()
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Receive returns the next message of the highest priority with any,
+// taking the tenants of that priority in turn.
+func (q *Memory) Receive(ctx context.Context) (*Message, error) {
+	for {
+		if msg := q.pop(); msg != nil {
+			msg.Deliveries = 1
+			return msg, nil
+		}
+		select {
+		case <-q.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (q *Memory) pop() *Message {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, tq := range q.queues {
+		if len(tq.order) == 0 {
+			continue
+		}
+		tenant := tq.order[0]
+		messages := tq.messages[tenant]
+		msg := messages[0]
+		if len(messages) == 1 {
+			delete(tq.messages, tenant)
+			tq.order = tq.order[1:]
+		} else {
+			tq.messages[tenant] = messages[1:]
+			tq.order = append(tq.order[1:], tenant)
+		}
+		tq.len--
+		return msg
+	}
+	return nil
+}
+
+// Drain empties the queue, returning its messages by priority.
+func (q *Memory) Drain() []*Message {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var drained []*Message
+	for _, tq := range q.queues {
+		for _, tenant := range tq.order {
+			drained = append(drained, tq.messages[tenant]...)
+		}
+		tq.order = nil
+		tq.messages = make(map[string][]*Message)
+		tq.len = 0
+	}
+	return drained
+}
+
+func (q *Memory) Ack(ctx context.Context, msg *Message) error {
+	return nil
+}
+
+func (q *Memory) Len(ctx context.Context) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var n int64
+	for _, tq := range q.queues {
+		n += int64(tq.len)
+	}
+	return n, nil
+}
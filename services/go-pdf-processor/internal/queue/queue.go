@@ -0,0 +1,82 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cotai-pdf-processor/internal/config"
+	"cotai-pdf-processor/internal/storage"
+)
+
+// ErrFull is returned by Publish when a bounded queue has no room.
+var ErrFull = errors.New("queue: full")
+
+// Priority orders messages: those queued with a higher priority are
+// received first.
+type Priority int
+
+const (
+	PriorityHigh Priority = iota
+	PriorityNormal
+	PriorityLow
+)
+
+// priorities lists the priorities from highest to lowest.
+var priorities = []Priority{PriorityHigh, PriorityNormal, PriorityLow}
+
+// Message is a job received from a queue. It stays with the consumer until
+// acknowledged; messages of consumers that die unacknowledged are
+// delivered again.
+type Message struct {
+	ID       string
+	Body     []byte
+	Priority Priority
+	Tenant   string
+
+	// Deliveries counts the times the message was received, this one
+	// included
+	Deliveries int
+
+	// stream is the Redis stream the message was read from
+	stream string
+}
+
+// Queue carries serialized jobs from the API to the workers.
+type Queue interface {
+	// Publish adds a job of a tenant to the queue; the tenant may be
+	// empty.
+	Publish(ctx context.Context, body []byte, priority Priority, tenant string) error
+
+	// Receive blocks until a message is available or ctx is done. Messages
+	// of a higher priority are received before those of lower ones, and
+	// within a priority the tenants with queued messages are served in
+	// turn, so one tenant's backlog does not hold up the others.
+	Receive(ctx context.Context) (*Message, error)
+
+	// Ack removes a received message from the queue once it was handled.
+	Ack(ctx context.Context, msg *Message) error
+
+	// Len is the number of messages queued or being handled.
+	Len(ctx context.Context) (int64, error)
+}
+
+// Drainer is implemented by queues whose messages are lost on restart. Drain
+// removes and returns the queued messages, for the caller to keep.
+type Drainer interface {
+	Drain() []*Message
+}
+
+// New creates the queue named by JOB_QUEUE: "redis", Redis streams shared
+// by all replicas, or "memory", buffers of capacity messages per priority
+// lost on restart.
+func New(ctx context.Context, cfg *config.Config, redis *storage.RedisClient, capacity int) (Queue, error) {
+	switch cfg.JobQueue {
+	case "redis":
+		return NewRedisStream(ctx, cfg, redis)
+	case "memory":
+		return NewMemory(capacity), nil
+	default:
+		return nil, fmt.Errorf("unknown job queue %q", cfg.JobQueue)
+	}
+}
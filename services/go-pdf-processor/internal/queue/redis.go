@@ -0,0 +1,366 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"cotai-pdf-processor/internal/config"
+	"cotai-pdf-processor/internal/storage"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Jobs are entries of Redis streams, one per priority and tenant, read by a
+// consumer group, so each is handled by one replica. Normal jobs without a
+// tenant are queued on JOB_QUEUE_STREAM, and high and low priority ones on
+// the streams suffixed ":high" and ":low"; the stream of a tenant's jobs is
+// further suffixed ":tenant:<id>".
+//
+// The tenants with queued jobs of each priority are kept in a list, which
+// consumers rotate to pick the tenant of the next job, so tenants are
+// served in turn across all replicas. A tenant leaves the list once its
+// stream is empty.
+//
+// An entry stays pending for its consumer until acknowledged, when it is
+// deleted; entries left pending longer than JOB_QUEUE_CLAIM_IDLE, by a
+// consumer that crashed, or pending for a dead instance (see instances.go),
+// are claimed by the next consumer to look for them.
+
+const (
+	// idlePoll is how often an idle consumer looks for new entries.
+	idlePoll = 500 * time.Millisecond
+
+	// claimInterval is how often a consumer looks for entries to claim.
+	claimInterval = 30 * time.Second
+
+	// bodyField is the stream entry field holding the job.
+	bodyField = "job"
+)
+
+// addTenant appends the tenant ARGV[1] to the list KEYS[1] unless there.
+var addTenant = redis.NewScript(`
+if redis.call('LPOS', KEYS[1], ARGV[1]) == false then
+	redis.call('RPUSH', KEYS[1], ARGV[1])
+end
+return 0`)
+
+// removeTenant removes the tenant ARGV[1] from the list KEYS[1] if its
+// stream KEYS[2] is empty. Publishers add the entry before the tenant, so a
+// tenant is never removed with an entry queued.
+var removeTenant = redis.NewScript(`
+if redis.call('XLEN', KEYS[2]) == 0 then
+	redis.call('LREM', KEYS[1], 0, ARGV[1])
+end
+return 0`)
+
+// RedisStream is a queue on Redis streams.
+type RedisStream struct {
+	client    *redis.Client
+	base      string
+	group     string
+	consumer  string
+	claimIdle time.Duration
+
+	instanceTTL time.Duration
+	startedAt   time.Time
+
+	// groups are the streams known to have the consumer group
+	groups sync.Map
+
+	mu        sync.Mutex
+	lastClaim time.Time
+}
+
+// NewRedisStream creates the queue. The consumer is named INSTANCE_ID.
+func NewRedisStream(ctx context.Context, cfg *config.Config, redisClient *storage.RedisClient) (*RedisStream, error) {
+	q := &RedisStream{
+		client:      redisClient.Client(),
+		base:        cfg.JobQueueStream,
+		group:       cfg.JobQueueGroup,
+		consumer:    cfg.InstanceID,
+		claimIdle:   cfg.JobQueueClaimIdle,
+		instanceTTL: cfg.InstanceTTL,
+		startedAt:   time.Now(),
+	}
+
+	// Jobs without a tenant may have been queued before the tenant lists
+	// existed; listing the empty tenant gets them read, and it leaves the
+	// list again if there are none
+	for _, priority := range priorities {
+		if err := q.ensureGroup(ctx, q.stream(priority, "")); err != nil {
+			return nil, err
+		}
+		if err := addTenant.Run(ctx, q.client, []string{q.tenantsKey(priority)}, "").Err(); err != nil {
+			return nil, fmt.Errorf("failed to list tenants: %w", err)
+		}
+	}
+	return q, nil
+}
+
+func (q *RedisStream) priorityKey(priority Priority) string {
+	switch priority {
+	case PriorityHigh:
+		return q.base + ":high"
+	case PriorityLow:
+		return q.base + ":low"
+	}
+	return q.base
+}
+
+func (q *RedisStream) tenantsKey(priority Priority) string {
+	return q.priorityKey(priority) + ":tenants"
+}
+
+func (q *RedisStream) stream(priority Priority, tenant string) string {
+	if tenant == "" {
+		return q.priorityKey(priority)
+	}
+	return q.priorityKey(priority) + ":tenant:" + tenant
+}
+
+// ensureGroup creates the consumer group on the stream, and the stream,
+// unless they exist. The group starts at the beginning, so entries queued
+// before it existed are read too.
+func (q *RedisStream) ensureGroup(ctx context.Context, stream string) error {
+	if _, ok := q.groups.Load(stream); ok {
+		return nil
+	}
+	err := q.client.XGroupCreateMkStream(ctx, stream, q.group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group %s on %s: %w", q.group, stream, err)
+	}
+	q.groups.Store(stream, true)
+	return nil
+}
+
+func (q *RedisStream) Publish(ctx context.Context, body []byte, priority Priority, tenant string) error {
+	stream := q.stream(priority, tenant)
+	if err := q.ensureGroup(ctx, stream); err != nil {
+		return err
+	}
+	err := q.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		Values: map[string]interface{}{bodyField: body},
+	}).Err()
+	if err != nil {
+		return err
+	}
+	return addTenant.Run(ctx, q.client, []string{q.tenantsKey(priority)}, tenant).Err()
+}
+
+// Receive returns, now and then, an entry abandoned by another consumer,
+// and otherwise the next new entry of the highest priority with any,
+// taking the tenants of that priority in turn.
+func (q *RedisStream) Receive(ctx context.Context) (*Message, error) {
+	for {
+		if q.claimDue() {
+			msg, err := q.claimAny(ctx)
+			if err != nil || msg != nil {
+				return msg, q.readError(ctx, err)
+			}
+		}
+
+		for _, priority := range priorities {
+			msg, err := q.next(ctx, priority)
+			if err != nil || msg != nil {
+				return msg, q.readError(ctx, err)
+			}
+		}
+
+		select {
+		case <-time.After(idlePoll):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// next reads a new entry of the priority from the next tenant with one.
+func (q *RedisStream) next(ctx context.Context, priority Priority) (*Message, error) {
+	key := q.tenantsKey(priority)
+	tenants, err := q.client.LLen(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := int64(0); i < tenants; i++ {
+		// Rotating the list moves the tenant served to its back
+		tenant, err := q.client.LMove(ctx, key, key, "LEFT", "RIGHT").Result()
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		stream := q.stream(priority, tenant)
+		if err := q.ensureGroup(ctx, stream); err != nil {
+			return nil, err
+		}
+		results, err := q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    q.group,
+			Consumer: q.consumer,
+			Streams:  []string{stream, ">"},
+			Count:    1,
+			Block:    -1,
+		}).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+		for _, result := range results {
+			for _, entry := range result.Messages {
+				return entryMessage(entry, stream, priority, tenant, 1), nil
+			}
+		}
+
+		if err := removeTenant.Run(ctx, q.client, []string{key, stream}, tenant).Err(); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// readError reports errors of reads interrupted by ctx as ctx's.
+func (q *RedisStream) readError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func (q *RedisStream) claimDue() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if time.Since(q.lastClaim) < claimInterval {
+		return false
+	}
+	q.lastClaim = time.Now()
+	return true
+}
+
+// claimAny claims an abandoned entry of any listed tenant, by priority.
+// Streams whose last entry is pending stay listed until it is acknowledged,
+// so their entries are found here.
+func (q *RedisStream) claimAny(ctx context.Context) (*Message, error) {
+	dead, err := q.deadInstances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, priority := range priorities {
+		tenants, err := q.client.LRange(ctx, q.tenantsKey(priority), 0, -1).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, tenant := range tenants {
+			msg, err := q.claim(ctx, priority, tenant, dead)
+			if err != nil || msg != nil {
+				return msg, err
+			}
+		}
+	}
+	return nil, nil
+}
+
+// claim takes over the oldest entry of the tenant's stream pending longer
+// than claimIdle, or else the oldest pending for one of the dead
+// instances.
+func (q *RedisStream) claim(ctx context.Context, priority Priority, tenant string, dead map[string]bool) (*Message, error) {
+	stream := q.stream(priority, tenant)
+	pending, err := q.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: stream,
+		Group:  q.group,
+		Idle:   q.claimIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  1,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	for consumer := range dead {
+		if len(pending) > 0 {
+			break
+		}
+		pending, err = q.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream:   stream,
+			Group:    q.group,
+			Start:    "-",
+			End:      "+",
+			Count:    1,
+			Consumer: consumer,
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	// An entry claimed meanwhile has been idle for less
+	entries, err := q.client.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   stream,
+		Group:    q.group,
+		Consumer: q.consumer,
+		MinIdle:  pending[0].Idle,
+		Messages: []string{pending[0].ID},
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		// Claimed by another consumer meanwhile, or deleted
+		return nil, nil
+	}
+	if dead[pending[0].Consumer] {
+		log.Printf("Claimed job entry %s of dead instance %s", pending[0].ID, pending[0].Consumer)
+	}
+	return entryMessage(entries[0], stream, priority, tenant, int(pending[0].RetryCount)+1), nil
+}
+
+func entryMessage(entry redis.XMessage, stream string, priority Priority, tenant string, deliveries int) *Message {
+	body, _ := entry.Values[bodyField].(string)
+	return &Message{
+		ID:         entry.ID,
+		Body:       []byte(body),
+		Priority:   priority,
+		Tenant:     tenant,
+		Deliveries: deliveries,
+		stream:     stream,
+	}
+}
+
+// Ack acknowledges the entry and deletes it from its stream.
+func (q *RedisStream) Ack(ctx context.Context, msg *Message) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAck(ctx, msg.stream, q.group, msg.ID)
+		pipe.XDel(ctx, msg.stream, msg.ID)
+		return nil
+	})
+	return err
+}
+
+// Len is the total length of the listed tenants' streams, which hold only
+// unacknowledged entries.
+func (q *RedisStream) Len(ctx context.Context) (int64, error) {
+	var n int64
+	for _, priority := range priorities {
+		tenants, err := q.client.LRange(ctx, q.tenantsKey(priority), 0, -1).Result()
+		if err != nil {
+			return 0, err
+		}
+		for _, tenant := range tenants {
+			length, err := q.client.XLen(ctx, q.stream(priority, tenant)).Result()
+			if err != nil {
+				return 0, err
+			}
+			n += length
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,185 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"cotai-pdf-processor/internal/config"
+	"cotai-pdf-processor/internal/storage"
+)
+
+// The tests of the Redis queue run against the Redis at TEST_REDIS_URL, and
+// are skipped without one. They only touch keys under a base of their own.
+
+// testRedis connects to the test Redis, skipping the test without one, and
+// returns a stream base for the test's keys, deleted when it ends.
+func testRedis(t *testing.T) (*storage.RedisClient, string) {
+	t.Helper()
+	url := os.Getenv("TEST_REDIS_URL")
+	if url == "" {
+		t.Skip("TEST_REDIS_URL not set")
+	}
+	client := storage.NewRedisClient(url)
+	ctx := context.Background()
+	if err := client.Client().Ping(ctx).Err(); err != nil {
+		t.Fatalf("cannot reach the test Redis: %v", err)
+	}
+
+	base := fmt.Sprintf("test:%s:%d", t.Name(), time.Now().UnixNano())
+	t.Cleanup(func() {
+		keys, _ := client.Client().Keys(ctx, base+"*").Result()
+		if len(keys) > 0 {
+			client.Client().Del(ctx, keys...)
+		}
+		client.Close()
+	})
+	return client, base
+}
+
+// newTestStream creates a consumer of the queue at base.
+func newTestStream(t *testing.T, client *storage.RedisClient, base, consumer string, claimIdle, instanceTTL time.Duration) *RedisStream {
+	t.Helper()
+	cfg := &config.Config{
+		JobQueueStream:    base,
+		JobQueueGroup:     "workers",
+		JobQueueClaimIdle: claimIdle,
+		InstanceID:        consumer,
+		InstanceTTL:       instanceTTL,
+	}
+	q, err := NewRedisStream(context.Background(), cfg, client)
+	if err != nil {
+		t.Fatalf("NewRedisStream() error = %v", err)
+	}
+	return q
+}
+
+func receive(t *testing.T, q *RedisStream) *Message {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	msg, err := q.Receive(ctx)
+	if err != nil {
+		t.Fatalf("Receive() error = %v", err)
+	}
+	return msg
+}
+
+// receiveNone checks that nothing is received for a while.
+func receiveNone(t *testing.T, q *RedisStream) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if msg, err := q.Receive(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Receive() = %+v, %v, want nothing", msg, err)
+	}
+}
+
+func TestRedisStreamOrder(t *testing.T) {
+	client, base := testRedis(t)
+	q := newTestStream(t, client, base, "c1", time.Hour, time.Hour)
+	ctx := context.Background()
+
+	for _, m := range []struct {
+		body     string
+		priority Priority
+		tenant   string
+	}{
+		{"low", PriorityLow, ""},
+		{"t1-a", PriorityNormal, "t1"},
+		{"t1-b", PriorityNormal, "t1"},
+		{"t2-a", PriorityNormal, "t2"},
+		{"high", PriorityHigh, "t2"},
+	} {
+		if err := q.Publish(ctx, []byte(m.body), m.priority, m.tenant); err != nil {
+			t.Fatalf("Publish(%s) error = %v", m.body, err)
+		}
+	}
+	if n, err := q.Len(ctx); err != nil || n != 5 {
+		t.Fatalf("Len() = %d, %v, want 5", n, err)
+	}
+
+	// Higher priorities first, and the tenants of a priority in turn
+	for _, want := range []string{"high", "t1-a", "t2-a", "t1-b", "low"} {
+		msg := receive(t, q)
+		if string(msg.Body) != want || msg.Deliveries != 1 {
+			t.Fatalf("Receive() = %s, delivered %d times, want %s once", msg.Body, msg.Deliveries, want)
+		}
+		if err := q.Ack(ctx, msg); err != nil {
+			t.Fatalf("Ack() error = %v", err)
+		}
+	}
+	receiveNone(t, q)
+	if n, err := q.Len(ctx); err != nil || n != 0 {
+		t.Errorf("Len() after Ack() = %d, %v, want 0", n, err)
+	}
+}
+
+func TestRedisStreamClaimIdle(t *testing.T) {
+	client, base := testRedis(t)
+	first := newTestStream(t, client, base, "c1", time.Hour, time.Hour)
+	second := newTestStream(t, client, base, "c2", 20*time.Millisecond, time.Hour)
+	ctx := context.Background()
+
+	if err := first.Publish(ctx, []byte("job"), PriorityNormal, "t1"); err != nil {
+		t.Fatal(err)
+	}
+	received := receive(t, first)
+
+	// The entry stays pending for the first consumer, which dies with it
+	time.Sleep(50 * time.Millisecond)
+	claimed := receive(t, second)
+	if claimed.ID != received.ID || string(claimed.Body) != "job" || claimed.Tenant != "t1" || claimed.Deliveries != 2 {
+		t.Fatalf("Receive() = %+v, want entry %s delivered twice", claimed, received.ID)
+	}
+
+	// Each claim counts a delivery, for the workers to give up on the job
+	time.Sleep(50 * time.Millisecond)
+	second.lastClaim = time.Time{}
+	if again := receive(t, second); again.ID != received.ID || again.Deliveries != 3 {
+		t.Fatalf("Receive() = %+v, want entry %s delivered 3 times", again, received.ID)
+	}
+
+	if err := second.Ack(ctx, claimed); err != nil {
+		t.Fatalf("Ack() error = %v", err)
+	}
+	second.lastClaim = time.Time{}
+	receiveNone(t, second)
+	if n, err := second.Len(ctx); err != nil || n != 0 {
+		t.Errorf("Len() after Ack() = %d, %v, want 0", n, err)
+	}
+}
+
+func TestRedisStreamClaimDeadInstance(t *testing.T) {
+	client, base := testRedis(t)
+	first := newTestStream(t, client, base, "c1", time.Hour, time.Hour)
+	ctx := context.Background()
+
+	if err := first.Publish(ctx, []byte("job"), PriorityHigh, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Heartbeat(ctx, 1, 1); err != nil {
+		t.Fatalf("Heartbeat() error = %v", err)
+	}
+	received := receive(t, first)
+
+	// A pending entry of a live instance is left to it until idle
+	alive := newTestStream(t, client, base, "c2", time.Hour, time.Hour)
+	receiveNone(t, alive)
+
+	// Once its heartbeat is older than INSTANCE_TTL, it is claimed at once
+	time.Sleep(50 * time.Millisecond)
+	second := newTestStream(t, client, base, "c3", time.Hour, 20*time.Millisecond)
+	claimed := receive(t, second)
+	if claimed.ID != received.ID || claimed.Priority != PriorityHigh || claimed.Deliveries != 2 {
+		t.Fatalf("Receive() = %+v, want entry %s delivered twice", claimed, received.ID)
+	}
+
+	instances, err := second.Instances(ctx)
+	if err != nil || len(instances) != 1 || instances[0].ID != "c1" || instances[0].Alive || instances[0].ActiveJobs != 1 {
+		t.Errorf("Instances() = %+v, %v, want c1 dead", instances, err)
+	}
+}
@@ -0,0 +1,99 @@
+// Package ratelimit limits the rate of requests by token buckets kept in
+// Redis, shared by all replicas.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"cotai-pdf-processor/internal/storage"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// take refills the bucket KEYS[1], of ARGV[2] tokens refilled at ARGV[1]
+// tokens a millisecond, for the time since it was last taken from, by the
+// clock of Redis, and takes a token if there is one. It returns whether it
+// did and the tokens left, as a string to keep their fraction.
+var take = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = time[1] * 1000 + math.floor(time[2] / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(state[1]) or burst
+local at = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - at) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate))
+return {allowed, tostring(tokens)}`)
+
+// Limit is a bucket of Burst requests, refilled at PerMinute a minute.
+type Limit struct {
+	PerMinute float64
+	Burst     int
+}
+
+// Window is how long an empty bucket takes to fill.
+func (l Limit) Window() time.Duration {
+	return time.Duration(float64(l.Burst) / l.PerMinute * float64(time.Minute))
+}
+
+// Result is what a request was allowed, for the RateLimit headers.
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// Reset is how long the bucket takes to fill again
+	Reset time.Duration
+	// RetryAfter is how long a refused request should wait for a token
+	RetryAfter time.Duration
+}
+
+// Limiter takes requests from the buckets of keys.
+type Limiter struct {
+	client *redis.Client
+}
+
+func New(redisClient *storage.RedisClient) *Limiter {
+	return &Limiter{client: redisClient.Client()}
+}
+
+// Allow takes a token for a request from the bucket of key.
+func (l *Limiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	perMillisecond := limit.PerMinute / float64(time.Minute/time.Millisecond)
+	reply, err := take.Run(ctx, l.client, []string{"ratelimit:" + key},
+		strconv.FormatFloat(perMillisecond, 'g', -1, 64), limit.Burst).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to take from rate limit bucket %s: %w", key, err)
+	}
+	if len(reply) != 2 {
+		return Result{}, fmt.Errorf("unexpected reply from rate limit bucket %s", key)
+	}
+	allowed, _ := reply[0].(int64)
+	text, _ := reply[1].(string)
+	tokens, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("unexpected reply from rate limit bucket %s: %w", key, err)
+	}
+
+	perToken := float64(time.Minute) / limit.PerMinute
+	result := Result{
+		Allowed:   allowed == 1,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(limit.Burst) - tokens) * perToken),
+	}
+	if !result.Allowed {
+		result.RetryAfter = time.Duration((1 - tokens) * perToken)
+	}
+	return result, nil
+}
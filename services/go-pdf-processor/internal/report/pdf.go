@@ -0,0 +1,281 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Page geometry, in points (A4).
+const (
+	pageWidth    = 595.0
+	pageHeight   = 842.0
+	margin       = 50.0
+	contentWidth = pageWidth - 2*margin
+	headerHeight = 70.0
+	footerHeight = 30.0
+)
+
+// Font sizes and line spacing.
+const (
+	titleSize   = 18.0
+	headingSize = 13.0
+	textSize    = 10.0
+	smallSize   = 8.0
+	lineSpacing = 1.35
+)
+
+// Helvetica has no embedded metrics here, so line widths are estimated from
+// an average glyph width; the factors err on the wide side to avoid overflow.
+const (
+	regularWidthFactor = 0.53
+	boldWidthFactor    = 0.58
+)
+
+// Document builds a text-only PDF with a branded header on every page, using
+// the standard Helvetica fonts so nothing needs to be embedded.
+type Document struct {
+	brand string
+	title string
+	color [3]float64
+
+	pages []*bytes.Buffer
+	y     float64
+}
+
+// NewDocument starts a document whose pages carry brand and title in a
+// header band of the given RGB color (components between 0 and 1).
+func NewDocument(brand, title string, color [3]float64) *Document {
+	d := &Document{brand: brand, title: title, color: color}
+	d.newPage()
+	return d
+}
+
+func (d *Document) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+func (d *Document) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	page := d.page()
+
+	fmt.Fprintf(page, "%.3f %.3f %.3f rg 0 %.2f %.2f %.2f re f\n",
+		d.color[0], d.color[1], d.color[2], pageHeight-headerHeight, pageWidth, headerHeight)
+	d.textAt(margin, pageHeight-32, "F2", titleSize, [3]float64{1, 1, 1}, d.brand)
+	d.textAt(margin, pageHeight-52, "F1", textSize, [3]float64{1, 1, 1}, d.title)
+	d.y = pageHeight - headerHeight - 30
+}
+
+// ensure starts a new page unless height points fit above the footer.
+func (d *Document) ensure(height float64) {
+	if d.y-height < margin+footerHeight {
+		d.newPage()
+	}
+}
+
+func (d *Document) textAt(x, y float64, font string, size float64, color [3]float64, text string) {
+	fmt.Fprintf(d.page(), "BT %.3f %.3f %.3f rg /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n",
+		color[0], color[1], color[2], font, size, x, y, encodeText(text))
+}
+
+// Heading adds a section title.
+func (d *Document) Heading(text string) {
+	d.y -= 8
+	d.ensure(headingSize * 3)
+	d.y -= headingSize
+	d.textAt(margin, d.y, "F2", headingSize, d.color, text)
+	fmt.Fprintf(d.page(), "%.3f %.3f %.3f RG 0.8 w %.2f %.2f m %.2f %.2f l S\n",
+		d.color[0], d.color[1], d.color[2], margin, d.y-5, pageWidth-margin, d.y-5)
+	d.y -= headingSize
+}
+
+// Paragraph adds wrapped body text.
+func (d *Document) Paragraph(text string) {
+	d.lines(margin, contentWidth, "F1", textSize, [3]float64{0.15, 0.15, 0.15}, text)
+	d.y -= textSize / 2
+}
+
+// Note adds wrapped small print in gray.
+func (d *Document) Note(text string) {
+	d.lines(margin, contentWidth, "F1", smallSize, [3]float64{0.45, 0.45, 0.45}, text)
+	d.y -= smallSize / 2
+}
+
+// Field adds a bold label followed by its value on the same line.
+func (d *Document) Field(label, value string) {
+	labelWidth := 150.0
+	lines := wrap(value, contentWidth-labelWidth, textSize, regularWidthFactor)
+	d.ensure(float64(len(lines)) * textSize * lineSpacing)
+	d.y -= textSize * lineSpacing
+	d.textAt(margin, d.y, "F2", textSize, [3]float64{0.15, 0.15, 0.15}, label)
+	for i, line := range lines {
+		if i > 0 {
+			d.y -= textSize * lineSpacing
+		}
+		d.textAt(margin+labelWidth, d.y, "F1", textSize, [3]float64{0.15, 0.15, 0.15}, line)
+	}
+}
+
+// Table adds rows under a header row. widths are fractions of the content
+// width per column; cells wrap and rows break across pages.
+func (d *Document) Table(headers []string, widths []float64, rows [][]string) {
+	d.y -= textSize / 2
+	d.tableRow(headers, widths, "F2", boldWidthFactor, true)
+	for _, row := range rows {
+		d.tableRow(row, widths, "F1", regularWidthFactor, false)
+	}
+	d.y -= textSize
+}
+
+func (d *Document) tableRow(cells []string, widths []float64, font string, factor float64, header bool) {
+	const padding = 4.0
+	wrapped := make([][]string, len(cells))
+	lineCount := 1
+	for i, cell := range cells {
+		wrapped[i] = wrap(cell, widths[i]*contentWidth-2*padding, smallSize, factor)
+		lineCount = max(lineCount, len(wrapped[i]))
+	}
+	height := float64(lineCount)*smallSize*lineSpacing + 2*padding
+
+	d.ensure(height)
+	top := d.y
+	page := d.page()
+	if header {
+		fmt.Fprintf(page, "0.92 0.92 0.92 rg %.2f %.2f %.2f %.2f re f\n", margin, top-height, contentWidth, height)
+	}
+	fmt.Fprintf(page, "0.75 0.75 0.75 RG 0.4 w %.2f %.2f m %.2f %.2f l S\n", margin, top-height, pageWidth-margin, top-height)
+
+	x := margin
+	for i, lines := range wrapped {
+		y := top - padding
+		for _, line := range lines {
+			y -= smallSize * lineSpacing
+			d.textAt(x+padding, y+2, font, smallSize, [3]float64{0.15, 0.15, 0.15}, line)
+		}
+		x += widths[i] * contentWidth
+	}
+	d.y = top - height
+}
+
+func (d *Document) lines(x, width float64, font string, size float64, color [3]float64, text string) {
+	factor := regularWidthFactor
+	if font == "F2" {
+		factor = boldWidthFactor
+	}
+	for _, line := range wrap(text, width, size, factor) {
+		d.ensure(size * lineSpacing)
+		d.y -= size * lineSpacing
+		d.textAt(x, d.y, font, size, color, line)
+	}
+}
+
+// wrap breaks text into lines estimated to fit width. Words longer than a
+// line are split.
+func wrap(text string, width, size, factor float64) []string {
+	maxChars := max(1, int(width/(size*factor)))
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			for len([]rune(word)) > maxChars {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				runes := []rune(word)
+				lines = append(lines, string(runes[:maxChars]))
+				word = string(runes[maxChars:])
+			}
+			switch {
+			case line == "":
+				line = word
+			case len([]rune(line))+1+len([]rune(word)) <= maxChars:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Bytes lays out the footers and returns the finished PDF.
+func (d *Document) Bytes() []byte {
+	for i, page := range d.pages {
+		footer := fmt.Sprintf("Page %d of %d", i+1, len(d.pages))
+		fmt.Fprintf(page, "BT 0.45 0.45 0.45 rg /F1 %.1f Tf %.2f %.2f Td (%s) Tj ET\n",
+			smallSize, pageWidth-margin-70, margin/2, encodeText(footer))
+		fmt.Fprintf(page, "BT 0.45 0.45 0.45 rg /F1 %.1f Tf %.2f %.2f Td (%s) Tj ET\n",
+			smallSize, margin, margin/2, encodeText(d.brand))
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-4 are fixed; each page then takes a page and a content object
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// winAnsi maps the characters of Windows-1252 outside Latin-1.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91,
+	'’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98,
+	'™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// encodeText converts text to an escaped WinAnsi PDF string body. Characters
+// the encoding lacks are replaced with '?'.
+func encodeText(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		var c byte
+		switch {
+		case r == '\n' || r == '\r' || r == '\t':
+			c = ' '
+		case r < 0x20:
+			continue
+		case r < 0x80 || (r >= 0xa0 && r <= 0xff):
+			c = byte(r)
+		default:
+			mapped, ok := winAnsi[r]
+			if !ok {
+				mapped = '?'
+			}
+			c = mapped
+		}
+		if c == '(' || c == ')' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,106 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"cotai-pdf-processor/internal/config"
+	"cotai-pdf-processor/internal/storage"
+)
+
+// Artifact locates a stored report.
+type Artifact struct {
+	Bucket    string    `json:"bucket"`
+	Key       string    `json:"key"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Publisher stores rendered reports, and other documents generated for a
+// job such as archival copies, in object storage and hands out presigned
+// download URLs for them.
+type Publisher struct {
+	objects *storage.ObjectStore
+	bucket  string
+	brand   string
+	expiry  time.Duration
+}
+
+func NewPublisher(cfg *config.Config) (*Publisher, error) {
+	objects, err := storage.NewObjectStore(storage.ObjectStoreConfig{
+		Endpoint:  cfg.ObjectStoreEndpoint,
+		Region:    cfg.ObjectStoreRegion,
+		AccessKey: cfg.ObjectStoreAccessKey,
+		SecretKey: cfg.ObjectStoreSecretKey,
+		UseSSL:    cfg.ObjectStoreUseSSL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Publisher{
+		objects: objects,
+		bucket:  cfg.ReportBucket,
+		brand:   cfg.ReportBrand,
+		expiry:  cfg.ReportURLExpiry,
+	}, nil
+}
+
+// Brand is the name printed in the header of every report.
+func (p *Publisher) Brand() string {
+	return p.brand
+}
+
+// Publish uploads the report of a job and returns where to download it.
+func (p *Publisher) Publish(ctx context.Context, jobID string, pdf []byte) (*Artifact, error) {
+	return p.put(ctx, fmt.Sprintf("reports/%s.pdf", jobID), "application/pdf", pdf)
+}
+
+// PublishArchive uploads the PDF/A archival copy of a job's document.
+func (p *Publisher) PublishArchive(ctx context.Context, jobID string, pdf []byte) (*Artifact, error) {
+	return p.put(ctx, fmt.Sprintf("archives/%s.pdf", jobID), "application/pdf", pdf)
+}
+
+// PublishSearchable uploads the searchable copy of a job's scanned document.
+func (p *Publisher) PublishSearchable(ctx context.Context, jobID string, pdf []byte) (*Artifact, error) {
+	return p.put(ctx, fmt.Sprintf("searchable/%s.pdf", jobID), "application/pdf", pdf)
+}
+
+// PublishRedacted uploads the copy of a job's document with its PII removed.
+func (p *Publisher) PublishRedacted(ctx context.Context, jobID string, pdf []byte) (*Artifact, error) {
+	return p.put(ctx, fmt.Sprintf("redacted/%s.pdf", jobID), "application/pdf", pdf)
+}
+
+// PublishAnnotated uploads the copy of a job's PDF annotated with its risks.
+func (p *Publisher) PublishAnnotated(ctx context.Context, jobID string, pdf []byte) (*Artifact, error) {
+	return p.put(ctx, fmt.Sprintf("annotated/%s.pdf", jobID), "application/pdf", pdf)
+}
+
+// PublishOCRLayout uploads the OCR words of a job's scan in the given
+// format, which is also the file extension.
+func (p *Publisher) PublishOCRLayout(ctx context.Context, jobID, format, contentType string, data []byte) (*Artifact, error) {
+	return p.put(ctx, fmt.Sprintf("ocr/%s.%s", jobID, format), contentType, data)
+}
+
+func (p *Publisher) put(ctx context.Context, key, contentType string, data []byte) (*Artifact, error) {
+	if err := p.objects.Put(ctx, p.bucket, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return nil, err
+	}
+	return p.Sign(ctx, key)
+}
+
+// Sign returns a fresh download URL for a stored report or document copy.
+func (p *Publisher) Sign(ctx context.Context, key string) (*Artifact, error) {
+	url, err := p.objects.PresignedGet(ctx, p.bucket, key, p.expiry)
+	if err != nil {
+		return nil, err
+	}
+	return &Artifact{
+		Bucket:    p.bucket,
+		Key:       key,
+		URL:       url,
+		ExpiresAt: time.Now().Add(p.expiry),
+	}, nil
+}
@@ -0,0 +1,177 @@
+package report
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+)
+
+// ScannedPage is a page image with the words recognized on it. Word boxes
+// are in pixels from the top left corner of the image.
+type ScannedPage struct {
+	Image []byte // JPEG or PNG
+	DPI   float64
+	Words []ScannedWord
+}
+
+type ScannedWord struct {
+	Text                string
+	X, Y, Width, Height int
+}
+
+// ocrWidthFactor is the average Helvetica glyph width, relative to the font
+// size, used to stretch invisible words over their boxes.
+const ocrWidthFactor = 0.5
+
+// SearchablePDF builds a PDF showing each page image with its words laid
+// over it as invisible text, so viewers can search and select them. JPEG
+// images are embedded as they are; PNG images are decoded and deflated.
+func SearchablePDF(pages []ScannedPage) ([]byte, error) {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body []byte) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n", len(offsets))
+		out.Write(body)
+		out.WriteString("\nendobj\n")
+	}
+	stream := func(dict string, data []byte) []byte {
+		var b bytes.Buffer
+		fmt.Fprintf(&b, "<< %s /Length %d >>\nstream\n", dict, len(data))
+		b.Write(data)
+		b.WriteString("\nendstream")
+		return b.Bytes()
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-3 are fixed; each page then takes a page, a content and an
+	// image object
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+3*i)
+	}
+	object([]byte("<< /Type /Catalog /Pages 2 0 R >>"))
+	object([]byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))))
+	object([]byte("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"))
+
+	for i, page := range pages {
+		imageDict, imageData, width, height, err := pageImage(page.Image)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+		scale := 72 / page.DPI
+		w, h := float64(width)*scale, float64(height)*scale
+
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "q %.2f 0 0 %.2f 0 0 cm /Im0 Do Q\nBT 3 Tr\n", w, h)
+		for _, word := range page.Words {
+			text := encodeText(word.Text)
+			chars := len([]rune(word.Text))
+			if chars == 0 || word.Width <= 0 || word.Height <= 0 {
+				continue
+			}
+			size := float64(word.Height) * scale
+			stretch := 100 * float64(word.Width) * scale / (float64(chars) * ocrWidthFactor * size)
+			// The baseline sits above the descenders at the bottom of the box
+			x, y := float64(word.X)*scale, h-float64(word.Y+word.Height)*scale+0.2*size
+			// The trailing space keeps words apart in extractors that ignore gaps
+			fmt.Fprintf(&content, "/F1 %.2f Tf %.1f Tz 1 0 0 1 %.2f %.2f Tm (%s ) Tj\n", size, stretch, x, y, text)
+		}
+		content.WriteString("ET")
+
+		object([]byte(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R >> /XObject << /Im0 %d 0 R >> >> /Contents %d 0 R >>",
+			w, h, 6+3*i, 5+3*i)))
+		object(stream("", content.Bytes()))
+		object(stream(imageDict, imageData))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes(), nil
+}
+
+// pageImage returns the image XObject dictionary entries and data of a page
+// image, with its size in pixels.
+func pageImage(data []byte) (dict string, encoded []byte, width, height int, err error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, 0, 0, fmt.Errorf("unsupported page image: %w", err)
+	}
+
+	switch format {
+	case "jpeg":
+		colorSpace := "/DeviceRGB"
+		switch cfg.ColorModel {
+		case color.GrayModel:
+			colorSpace = "/DeviceGray"
+		case color.CMYKModel:
+			// Adobe CMYK JPEGs are stored inverted
+			colorSpace = "/DeviceCMYK /Decode [1 0 1 0 1 0 1 0]"
+		}
+		dict = fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode",
+			cfg.Width, cfg.Height, colorSpace)
+		return dict, data, cfg.Width, cfg.Height, nil
+	case "png":
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return "", nil, 0, 0, err
+		}
+		pixels, colorSpace := rawPixels(img)
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		zw.Write(pixels)
+		zw.Close()
+		dict = fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /FlateDecode",
+			cfg.Width, cfg.Height, colorSpace)
+		return dict, compressed.Bytes(), cfg.Width, cfg.Height, nil
+	default:
+		return "", nil, 0, 0, fmt.Errorf("unsupported page image format %s", format)
+	}
+}
+
+// rawPixels returns the image's 8-bit samples, grayscale for gray and
+// bilevel scans and RGB otherwise. Transparency is dropped.
+func rawPixels(img image.Image) ([]byte, string) {
+	bounds := img.Bounds()
+	gray := img.ColorModel() == color.GrayModel || img.ColorModel() == color.Gray16Model
+	if paletted, ok := img.(*image.Paletted); ok {
+		gray = isGrayPalette(paletted.Palette)
+	}
+
+	channels, colorSpace := 3, "/DeviceRGB"
+	if gray {
+		channels, colorSpace = 1, "/DeviceGray"
+	}
+	pixels := make([]byte, 0, bounds.Dx()*bounds.Dy()*channels)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if gray {
+				pixels = append(pixels, byte(r>>8))
+			} else {
+				pixels = append(pixels, byte(r>>8), byte(g>>8), byte(b>>8))
+			}
+		}
+	}
+	return pixels, colorSpace
+}
+
+func isGrayPalette(palette color.Palette) bool {
+	for _, c := range palette {
+		r, g, b, _ := c.RGBA()
+		if r != g || g != b {
+			return false
+		}
+	}
+	return true
+}
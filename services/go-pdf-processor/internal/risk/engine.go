@@ -0,0 +1,183 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Source loads the rule set evaluated by the engine.
+type Source interface {
+	LoadRules(ctx context.Context) ([]Rule, error)
+}
+
+// Engine evaluates documents against the current rule set. Rules are
+// reloaded periodically, so changes apply without a redeploy.
+type Engine struct {
+	source Source
+
+	mu    sync.RWMutex
+	rules map[string][]*compiledRule // by tenant; "" holds the global rules
+}
+
+// NewEngine creates an engine with the rules currently in source. If they
+// cannot be loaded the built-in rules are used until a reload succeeds.
+func NewEngine(ctx context.Context, source Source) *Engine {
+	e := &Engine{source: source}
+	if err := e.Reload(ctx); err != nil {
+		log.Printf("Failed to load risk rules, using built-in rules: %v", err)
+		e.setRules(DefaultRules())
+	}
+	return e
+}
+
+// Reload replaces the rule set with the one in the source. Invalid rules are
+// logged and skipped; a failing source leaves the current rules in place.
+func (e *Engine) Reload(ctx context.Context) error {
+	rules, err := e.source.LoadRules(ctx)
+	if err != nil {
+		return err
+	}
+	e.setRules(rules)
+	return nil
+}
+
+func (e *Engine) setRules(rules []Rule) {
+	compiled := make(map[string][]*compiledRule)
+	for i := range rules {
+		if !rules[i].Enabled {
+			continue
+		}
+		cr, err := compileRule(&rules[i])
+		if err != nil {
+			log.Printf("Skipping risk rule: %v", err)
+			continue
+		}
+		compiled[rules[i].TenantID] = append(compiled[rules[i].TenantID], cr)
+	}
+
+	e.mu.Lock()
+	e.rules = compiled
+	e.mu.Unlock()
+}
+
+// StartReload reloads the rules every interval until ctx is cancelled.
+func (e *Engine) StartReload(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := e.Reload(ctx); err != nil {
+					log.Printf("Failed to reload risk rules: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Evaluate returns the global and tenant rules that match text, in rule
+// order.
+func (e *Engine) Evaluate(tenantID, text string) []Match {
+	e.mu.RLock()
+	rules := e.rules[""]
+	if tenantID != "" {
+		rules = append(rules[:len(rules):len(rules)], e.rules[tenantID]...)
+	}
+	e.mu.RUnlock()
+
+	return evaluate(rules, text)
+}
+
+// Rules returns the enabled rules that apply to a tenant's documents.
+func (e *Engine) Rules(tenantID string) []Rule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var rules []Rule
+	for _, scope := range []string{"", tenantID} {
+		for _, cr := range e.rules[scope] {
+			rules = append(rules, *cr.rule)
+		}
+		if tenantID == "" {
+			break
+		}
+	}
+	return rules
+}
+
+// EvaluateRules evaluates an ad-hoc rule set, such as one being edited,
+// without installing it.
+func EvaluateRules(rules []Rule, text string) ([]Match, error) {
+	compiled := make([]*compiledRule, 0, len(rules))
+	for i := range rules {
+		cr, err := compileRule(&rules[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+		}
+		compiled = append(compiled, cr)
+	}
+	return evaluate(compiled, text), nil
+}
+
+func evaluate(rules []*compiledRule, text string) []Match {
+	doc := newDocument(text)
+	var matches []Match
+	for _, rule := range rules {
+		if start, end, ok := rule.match(doc); ok {
+			matches = append(matches, Match{Rule: rule.rule, Position: start, End: end})
+		}
+	}
+	return matches
+}
+
+// DefaultRules are used when no rule source is configured. They flag the
+// contractual keywords the service has always looked for.
+func DefaultRules() []Rule {
+	keywords := []struct {
+		keyword string
+		weight  float64
+	}{
+		{"caução", 0.3},
+		{"exclusivo", 0.4},
+		{"garantia", 0.2},
+		{"impugnação", 0.6},
+		{"inexequível", 0.8},
+		{"multa", 0.3},
+		{"penalidade", 0.4},
+		{"prazo", 0.1},
+		{"rescisão", 0.5},
+	}
+
+	rules := make([]Rule, 0, len(keywords))
+	for _, k := range keywords {
+		rules = append(rules, Rule{
+			ID:         "builtin-" + k.keyword,
+			Name:       "Detected keyword: " + k.keyword,
+			Category:   "contractual",
+			Severity:   "medium",
+			Impact:     "financial",
+			Weight:     k.weight,
+			Conditions: []Condition{{Type: ConditionKeyword, Value: k.keyword}},
+			Enabled:    true,
+		})
+	}
+	return rules
+}
+
+// builtinSource serves DefaultRules.
+type builtinSource struct{}
+
+func (builtinSource) LoadRules(context.Context) ([]Rule, error) {
+	return DefaultRules(), nil
+}
@@ -0,0 +1,223 @@
+package risk
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Condition types
+const (
+	ConditionKeyword   = "keyword"
+	ConditionRegex     = "regex"
+	ConditionProximity = "proximity"
+)
+
+// Condition is one test a rule applies to the document text. Keywords match
+// case-insensitively anywhere in the text; proximity requires all Terms to
+// appear within Distance words of each other.
+type Condition struct {
+	Type     string   `json:"type" yaml:"type"`
+	Value    string   `json:"value,omitempty" yaml:"value,omitempty"`
+	Terms    []string `json:"terms,omitempty" yaml:"terms,omitempty"`
+	Distance int      `json:"distance,omitempty" yaml:"distance,omitempty"`
+}
+
+// Rule flags a risk when all of its conditions match.
+//
+// Rules without a TenantID apply to every document; tenant rules are added
+// for that tenant's documents. Version counts the edits made through the
+// management API.
+type Rule struct {
+	ID             string      `json:"id" yaml:"id"`
+	TenantID       string      `json:"tenant_id,omitempty" yaml:"tenant_id,omitempty"`
+	Version        int         `json:"version" yaml:"version,omitempty"`
+	Name           string      `json:"name" yaml:"name"`
+	Category       string      `json:"category" yaml:"category"`
+	Severity       string      `json:"severity" yaml:"severity"`
+	Impact         string      `json:"impact" yaml:"impact"`
+	Weight         float64     `json:"weight" yaml:"weight"`
+	Conditions     []Condition `json:"conditions" yaml:"conditions"`
+	Recommendation string      `json:"recommendation,omitempty" yaml:"recommendation,omitempty"`
+	Enabled        bool        `json:"enabled" yaml:"enabled"`
+	UpdatedAt      time.Time   `json:"updated_at" yaml:"-"`
+}
+
+// Match is a rule that matched a document. Position and End are the byte
+// range of its first condition's match.
+type Match struct {
+	Rule     *Rule
+	Position int
+	End      int
+}
+
+type compiledRule struct {
+	rule       *Rule
+	conditions []compiledCondition
+}
+
+type compiledCondition struct {
+	kind     string
+	keyword  string
+	re       *regexp.Regexp
+	terms    []string
+	distance int
+}
+
+// Validate reports the first problem that would prevent the rule from
+// being evaluated.
+func (r *Rule) Validate() error {
+	if _, err := compileRule(r); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	return nil
+}
+
+func compileRule(rule *Rule) (*compiledRule, error) {
+	if rule.Name == "" {
+		return nil, fmt.Errorf("rule %q: name is required", rule.ID)
+	}
+	if len(rule.Conditions) == 0 {
+		return nil, fmt.Errorf("rule %q: at least one condition is required", rule.Name)
+	}
+	if rule.Weight < 0 || rule.Weight > 1 {
+		return nil, fmt.Errorf("rule %q: weight must be between 0 and 1", rule.Name)
+	}
+
+	compiled := &compiledRule{rule: rule}
+	for i, cond := range rule.Conditions {
+		cc := compiledCondition{kind: cond.Type}
+		switch cond.Type {
+		case ConditionKeyword:
+			if cond.Value == "" {
+				return nil, fmt.Errorf("rule %q: condition %d: keyword is empty", rule.Name, i)
+			}
+			cc.keyword = strings.ToLower(cond.Value)
+		case ConditionRegex:
+			re, err := regexp.Compile(cond.Value)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: condition %d: %w", rule.Name, i, err)
+			}
+			cc.re = re
+		case ConditionProximity:
+			if len(cond.Terms) < 2 || cond.Distance <= 0 {
+				return nil, fmt.Errorf("rule %q: condition %d: proximity needs two or more terms and a positive distance", rule.Name, i)
+			}
+			for _, term := range cond.Terms {
+				cc.terms = append(cc.terms, strings.ToLower(term))
+			}
+			cc.distance = cond.Distance
+		default:
+			return nil, fmt.Errorf("rule %q: condition %d: unknown type %q", rule.Name, i, cond.Type)
+		}
+		compiled.conditions = append(compiled.conditions, cc)
+	}
+	return compiled, nil
+}
+
+// match evaluates the rule against a document, returning the range of the
+// first condition's match.
+func (r *compiledRule) match(doc *document) (int, int, bool) {
+	start, end := -1, -1
+	for _, cond := range r.conditions {
+		s, e, ok := cond.match(doc)
+		if !ok {
+			return 0, 0, false
+		}
+		if start < 0 {
+			start, end = s, e
+		}
+	}
+	return start, end, true
+}
+
+func (c *compiledCondition) match(doc *document) (int, int, bool) {
+	switch c.kind {
+	case ConditionKeyword:
+		pos := strings.Index(doc.lower, c.keyword)
+		return pos, pos + len(c.keyword), pos >= 0
+	case ConditionRegex:
+		loc := c.re.FindStringIndex(doc.text)
+		if loc == nil {
+			return 0, 0, false
+		}
+		return loc[0], loc[1], true
+	case ConditionProximity:
+		return doc.near(c.terms, c.distance)
+	}
+	return 0, 0, false
+}
+
+// document caches the derived forms of a text shared by all rules.
+type document struct {
+	text  string
+	lower string
+	words []word
+}
+
+type word struct {
+	text   string
+	offset int
+}
+
+// newDocument lowercases text for keyword matching. Lowercasing keeps the
+// byte layout for the Portuguese alphabet, so offsets apply to both forms.
+func newDocument(text string) *document {
+	return &document{text: text, lower: strings.ToLower(text)}
+}
+
+// near finds a word containing the first term with every other term within
+// distance words, returning that word's range.
+func (d *document) near(terms []string, distance int) (int, int, bool) {
+	if d.words == nil {
+		d.words = splitWords(d.lower)
+	}
+
+	for i, w := range d.words {
+		if !strings.Contains(w.text, terms[0]) {
+			continue
+		}
+		lo, hi := max(0, i-distance), min(len(d.words)-1, i+distance)
+		found := true
+		for _, term := range terms[1:] {
+			if !containsTerm(d.words[lo:hi+1], term) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return w.offset, w.offset + len(w.text), true
+		}
+	}
+	return 0, 0, false
+}
+
+func containsTerm(words []word, term string) bool {
+	for _, w := range words {
+		if strings.Contains(w.text, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitWords(text string) []word {
+	var words []word
+	start := -1
+	for i, r := range text {
+		isWordRune := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWordRune && start < 0:
+			start = i
+		case !isWordRune && start >= 0:
+			words = append(words, word{text: text[start:i], offset: start})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, word{text: text[start:], offset: start})
+	}
+	return words
+}
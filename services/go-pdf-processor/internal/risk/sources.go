@@ -0,0 +1,98 @@
+package risk
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"cotai-pdf-processor/internal/storage"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NewSource picks the rule source named by RISK_RULES_SOURCE: "builtin",
+// "postgres", or the path of a YAML file.
+func NewSource(name string, postgres *storage.PostgresClient) Source {
+	switch name {
+	case "", "builtin":
+		return builtinSource{}
+	case "postgres":
+		return &PostgresSource{postgres: postgres}
+	default:
+		return &FileSource{path: name}
+	}
+}
+
+// FileSource reads rules from a YAML file with a top-level "rules" list.
+type FileSource struct {
+	path string
+}
+
+func (s *FileSource) LoadRules(ctx context.Context) ([]Rule, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read risk rules: %w", err)
+	}
+
+	var file struct {
+		Rules []Rule `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse risk rules %s: %w", s.path, err)
+	}
+	return file.Rules, nil
+}
+
+// PostgresSource reads rules from the risk_rules table, where conditions
+// are stored as a JSON array.
+type PostgresSource struct {
+	postgres *storage.PostgresClient
+}
+
+func (s *PostgresSource) LoadRules(ctx context.Context) ([]Rule, error) {
+	rows, err := s.postgres.Query(ctx, `
+		SELECT `+ruleColumns+`
+		FROM risk_rules
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load risk rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []Rule
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
+
+const ruleColumns = `id, tenant_id, version, name, category, severity, impact, weight, conditions, recommendation, enabled, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRule(row rowScanner) (*Rule, error) {
+	var rule Rule
+	var conditions []byte
+	err := row.Scan(&rule.ID, &rule.TenantID, &rule.Version, &rule.Name, &rule.Category, &rule.Severity,
+		&rule.Impact, &rule.Weight, &conditions, &rule.Recommendation, &rule.Enabled, &rule.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read risk rule: %w", err)
+	}
+	if err := json.Unmarshal(conditions, &rule.Conditions); err != nil {
+		return nil, fmt.Errorf("invalid conditions for risk rule %s: %w", rule.ID, err)
+	}
+	return &rule, nil
+}
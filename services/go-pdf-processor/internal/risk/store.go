@@ -0,0 +1,199 @@
+package risk
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"cotai-pdf-processor/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// Custom errors
+var (
+	ErrRuleNotFound    = errors.New("risk rule not found")
+	ErrInvalidRule     = errors.New("invalid risk rule")
+	ErrVersionConflict = errors.New("risk rule was modified by another request")
+)
+
+// RuleVersion is a snapshot of a rule as saved at a given version.
+type RuleVersion struct {
+	Version   int       `json:"version"`
+	Rule      Rule      `json:"rule"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store manages the rules in Postgres for the management API. Every change
+// is recorded in risk_rule_versions and reloads the engine.
+type Store struct {
+	postgres *storage.PostgresClient
+	engine   *Engine
+}
+
+func NewStore(postgres *storage.PostgresClient, engine *Engine) *Store {
+	return &Store{postgres: postgres, engine: engine}
+}
+
+// List returns the rules of a tenant scope; "" is the global scope.
+func (s *Store) List(ctx context.Context, tenantID string) ([]Rule, error) {
+	rows, err := s.postgres.Query(ctx,
+		`SELECT `+ruleColumns+` FROM risk_rules WHERE tenant_id = $1 ORDER BY name`,
+		tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list risk rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []Rule{}
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
+
+func (s *Store) Get(ctx context.Context, tenantID, id string) (*Rule, error) {
+	return scanRule(s.postgres.QueryRow(ctx,
+		`SELECT `+ruleColumns+` FROM risk_rules WHERE tenant_id = $1 AND id = $2`,
+		tenantID, id))
+}
+
+func (s *Store) Create(ctx context.Context, rule *Rule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+
+	rule.ID = uuid.New().String()
+	rule.Version = 1
+	rule.UpdatedAt = time.Now()
+
+	conditions, err := json.Marshal(rule.Conditions)
+	if err != nil {
+		return err
+	}
+	err = s.postgres.Exec(ctx, `
+		INSERT INTO risk_rules (`+ruleColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, rule.ID, rule.TenantID, rule.Version, rule.Name, rule.Category, rule.Severity, rule.Impact,
+		rule.Weight, conditions, rule.Recommendation, rule.Enabled, rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create risk rule: %w", err)
+	}
+
+	s.recordVersion(ctx, rule)
+	return nil
+}
+
+// Update replaces a rule if it is still at rule.Version, bumping the version.
+func (s *Store) Update(ctx context.Context, rule *Rule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+
+	conditions, err := json.Marshal(rule.Conditions)
+	if err != nil {
+		return err
+	}
+	updated, err := scanRule(s.postgres.QueryRow(ctx, `
+		UPDATE risk_rules
+		SET version = version + 1, name = $4, category = $5, severity = $6, impact = $7,
+			weight = $8, conditions = $9, recommendation = $10, enabled = $11, updated_at = $12
+		WHERE tenant_id = $1 AND id = $2 AND version = $3
+		RETURNING `+ruleColumns,
+		rule.TenantID, rule.ID, rule.Version, rule.Name, rule.Category, rule.Severity, rule.Impact,
+		rule.Weight, conditions, rule.Recommendation, rule.Enabled, time.Now()))
+	if errors.Is(err, ErrRuleNotFound) {
+		// Tell a stale version apart from a missing rule
+		if _, getErr := s.Get(ctx, rule.TenantID, rule.ID); getErr == nil {
+			return ErrVersionConflict
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	*rule = *updated
+	s.recordVersion(ctx, rule)
+	return nil
+}
+
+func (s *Store) Delete(ctx context.Context, tenantID, id string) error {
+	var deleted string
+	err := s.postgres.QueryRow(ctx,
+		`DELETE FROM risk_rules WHERE tenant_id = $1 AND id = $2 RETURNING id`,
+		tenantID, id).Scan(&deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrRuleNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete risk rule: %w", err)
+	}
+
+	s.reloadEngine(ctx)
+	return nil
+}
+
+// Versions returns a rule's saved versions, newest first.
+func (s *Store) Versions(ctx context.Context, tenantID, id string) ([]RuleVersion, error) {
+	rows, err := s.postgres.Query(ctx, `
+		SELECT version, rule, created_at
+		FROM risk_rule_versions
+		WHERE tenant_id = $1 AND rule_id = $2
+		ORDER BY version DESC
+	`, tenantID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list risk rule versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []RuleVersion{}
+	for rows.Next() {
+		var version RuleVersion
+		var snapshot []byte
+		if err := rows.Scan(&version.Version, &snapshot, &version.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to read risk rule version: %w", err)
+		}
+		if err := json.Unmarshal(snapshot, &version.Rule); err != nil {
+			return nil, fmt.Errorf("invalid risk rule version %d: %w", version.Version, err)
+		}
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrRuleNotFound
+	}
+	return versions, nil
+}
+
+// recordVersion snapshots a saved rule and reloads the engine. The rule is
+// already saved, so failures are only logged.
+func (s *Store) recordVersion(ctx context.Context, rule *Rule) {
+	snapshot, err := json.Marshal(rule)
+	if err == nil {
+		err = s.postgres.Exec(ctx, `
+			INSERT INTO risk_rule_versions (rule_id, tenant_id, version, rule, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+		`, rule.ID, rule.TenantID, rule.Version, snapshot, rule.UpdatedAt)
+	}
+	if err != nil {
+		log.Printf("Failed to record version %d of risk rule %s: %v", rule.Version, rule.ID, err)
+	}
+
+	s.reloadEngine(ctx)
+}
+
+func (s *Store) reloadEngine(ctx context.Context) {
+	if err := s.engine.Reload(ctx); err != nil {
+		log.Printf("Failed to reload risk rules: %v", err)
+	}
+}
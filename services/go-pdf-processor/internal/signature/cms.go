@@ -0,0 +1,219 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// The CMS (RFC 5652) structures of a PDF signature, as far as verification
+// needs them.
+
+var (
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	oidRSAPSS        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+)
+
+var digestAlgorithms = map[string]crypto.Hash{
+	"1.3.14.3.2.26":          crypto.SHA1,
+	"2.16.840.1.101.3.4.2.1": crypto.SHA256,
+	"2.16.840.1.101.3.4.2.2": crypto.SHA384,
+	"2.16.840.1.101.3.4.2.3": crypto.SHA512,
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	EncapContentInfo encapContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type encapContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+type issuerAndSerial struct {
+	Issuer asn1.RawValue
+	Serial *big.Int
+}
+
+// cmsSignature is a parsed detached CMS signature.
+type cmsSignature struct {
+	certs       []*x509.Certificate
+	signer      *x509.Certificate
+	info        signerInfo
+	hash        crypto.Hash
+	content     []byte // encapsulated content, for adbe.pkcs7.sha1
+	signingTime *time.Time
+}
+
+func parseCMS(der []byte) (*cmsSignature, error) {
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, fmt.Errorf("invalid CMS content: %w", err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("unexpected CMS content type %v", ci.ContentType)
+	}
+
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("invalid signed data: %w", err)
+	}
+	if len(sd.SignerInfos) == 0 {
+		return nil, errors.New("signature has no signer")
+	}
+
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate: %w", err)
+	}
+
+	// PDF signatures have a single signer
+	sig := &cmsSignature{certs: certs, info: sd.SignerInfos[0]}
+	hash, ok := digestAlgorithms[sig.info.DigestAlgorithm.Algorithm.String()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported digest algorithm %v", sig.info.DigestAlgorithm.Algorithm)
+	}
+	sig.hash = hash
+
+	if sig.signer = findSigner(certs, sig.info.SID); sig.signer == nil {
+		return nil, errors.New("signer certificate not included")
+	}
+
+	if eContent := sd.EncapContentInfo.EContent; len(eContent.Bytes) > 0 {
+		if _, err := asn1.Unmarshal(eContent.FullBytes, &sig.content); err != nil {
+			// Some producers embed the content without its octet string
+			sig.content = eContent.Bytes
+		}
+	}
+	return sig, nil
+}
+
+// findSigner matches the signer identifier, an issuer and serial number or a
+// subject key identifier, against the certificates.
+func findSigner(certs []*x509.Certificate, sid asn1.RawValue) *x509.Certificate {
+	if sid.Class == asn1.ClassContextSpecific && sid.Tag == 0 {
+		for _, cert := range certs {
+			if bytes.Equal(cert.SubjectKeyId, sid.Bytes) {
+				return cert
+			}
+		}
+		return nil
+	}
+
+	var ias issuerAndSerial
+	if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil {
+		return nil
+	}
+	for _, cert := range certs {
+		if cert.SerialNumber.Cmp(ias.Serial) == 0 && bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) {
+			return cert
+		}
+	}
+	return nil
+}
+
+// verify checks the signature over the signed bytes of the document.
+func (s *cmsSignature) verify(signed []byte) error {
+	content := signed
+	if s.content != nil {
+		// adbe.pkcs7.sha1 signs the SHA-1 digest of the document
+		digest := crypto.SHA1.New()
+		digest.Write(signed)
+		if !bytes.Equal(digest.Sum(nil), s.content) {
+			return errors.New("document digest does not match")
+		}
+		content = s.content
+	}
+
+	signedAttrs := s.info.SignedAttrs.FullBytes
+	if len(signedAttrs) == 0 {
+		return s.checkSignature(content)
+	}
+
+	var messageDigest []byte
+	for rest := s.info.SignedAttrs.Bytes; len(rest) > 0; {
+		var attr attribute
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &attr); err != nil {
+			return fmt.Errorf("invalid signed attribute: %w", err)
+		}
+		switch {
+		case attr.Type.Equal(oidMessageDigest):
+			if _, err := asn1.Unmarshal(attr.Values.Bytes, &messageDigest); err != nil {
+				return fmt.Errorf("invalid message digest: %w", err)
+			}
+		case attr.Type.Equal(oidSigningTime):
+			var t time.Time
+			if _, err := asn1.Unmarshal(attr.Values.Bytes, &t); err == nil {
+				s.signingTime = &t
+			}
+		}
+	}
+
+	digest := s.hash.New()
+	digest.Write(content)
+	if !bytes.Equal(digest.Sum(nil), messageDigest) {
+		return errors.New("document digest does not match")
+	}
+
+	// The attributes are signed with their SET OF tag, not the implicit one
+	// they are encoded with
+	attrs := append([]byte{0x31}, signedAttrs[1:]...)
+	return s.checkSignature(attrs)
+}
+
+func (s *cmsSignature) checkSignature(data []byte) error {
+	digest := s.hash.New()
+	digest.Write(data)
+	sum := digest.Sum(nil)
+
+	switch pub := s.signer.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if s.info.SignatureAlgorithm.Algorithm.Equal(oidRSAPSS) {
+			return rsa.VerifyPSS(pub, s.hash, sum, s.info.Signature, nil)
+		}
+		return rsa.VerifyPKCS1v15(pub, s.hash, sum, s.info.Signature)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, sum, s.info.Signature) {
+			return errors.New("ecdsa verification failure")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
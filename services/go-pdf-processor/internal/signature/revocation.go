@@ -0,0 +1,151 @@
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	// maxOCSPResponse and maxCRLSize bound revocation downloads; ICP-Brasil
+	// CRLs of large authorities run to several megabytes.
+	maxOCSPResponse = 1 << 20
+	maxCRLSize      = 64 << 20
+
+	// crlCacheTTL is how long a CRL without a next update time is reused.
+	crlCacheTTL = time.Hour
+)
+
+type cachedCRL struct {
+	crl     *x509.RevocationList
+	expires time.Time
+}
+
+// revocationStatus asks the certificate's OCSP responders, then falls back
+// to its CRLs, which is what most ICP-Brasil authorities publish.
+func (v *Verifier) revocationStatus(ctx context.Context, cert, issuer *x509.Certificate) (string, *time.Time, error) {
+	var errs []error
+	for _, server := range cert.OCSPServer {
+		status, revokedAt, err := v.ocspStatus(ctx, server, cert, issuer)
+		if err == nil {
+			return status, revokedAt, nil
+		}
+		errs = append(errs, err)
+	}
+	for _, url := range cert.CRLDistributionPoints {
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			continue
+		}
+		crl, err := v.fetchCRL(ctx, url, issuer)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, entry := range crl.RevokedCertificateEntries {
+			if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				revokedAt := entry.RevocationTime
+				return RevocationRevoked, &revokedAt, nil
+			}
+		}
+		return RevocationGood, nil, nil
+	}
+
+	if len(errs) == 0 {
+		return RevocationUnknown, nil, errors.New("certificate has no revocation information")
+	}
+	return RevocationUnknown, nil, fmt.Errorf("revocation check failed: %w", errors.Join(errs...))
+}
+
+func (v *Verifier) ocspStatus(ctx context.Context, server string, cert, issuer *x509.Certificate) (string, *time.Time, error) {
+	request, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server, bytes.NewReader(request))
+	if err != nil {
+		return "", nil, err
+	}
+	req.Header.Set("Content-Type", "application/ocsp-request")
+
+	body, err := v.fetch(req, maxOCSPResponse)
+	if err != nil {
+		return "", nil, fmt.Errorf("OCSP request to %s failed: %w", server, err)
+	}
+	resp, err := ocsp.ParseResponseForCert(body, cert, issuer)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid OCSP response from %s: %w", server, err)
+	}
+
+	switch resp.Status {
+	case ocsp.Good:
+		return RevocationGood, nil, nil
+	case ocsp.Revoked:
+		return RevocationRevoked, &resp.RevokedAt, nil
+	default:
+		return RevocationUnknown, nil, nil
+	}
+}
+
+// fetchCRL downloads a CRL signed by issuer, reusing it until its next
+// update.
+func (v *Verifier) fetchCRL(ctx context.Context, url string, issuer *x509.Certificate) (*x509.RevocationList, error) {
+	v.mu.Lock()
+	cached, ok := v.crls[url]
+	v.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.crl, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := v.fetch(req, maxCRLSize)
+	if err != nil {
+		return nil, fmt.Errorf("CRL download from %s failed: %w", url, err)
+	}
+	crl, err := x509.ParseRevocationList(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRL from %s: %w", url, err)
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return nil, fmt.Errorf("CRL from %s not signed by the issuer: %w", url, err)
+	}
+
+	expires := crl.NextUpdate
+	if expires.IsZero() {
+		expires = time.Now().Add(crlCacheTTL)
+	}
+	v.mu.Lock()
+	v.crls[url] = &cachedCRL{crl: crl, expires: expires}
+	v.mu.Unlock()
+	return crl, nil
+}
+
+func (v *Verifier) fetch(req *http.Request, limit int64) ([]byte, error) {
+	resp, err := v.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("response exceeds %d bytes", limit)
+	}
+	return body, nil
+}
@@ -0,0 +1,362 @@
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cotai-pdf-processor/internal/config"
+)
+
+// Revocation statuses
+const (
+	RevocationGood       = "good"
+	RevocationRevoked    = "revoked"
+	RevocationUnknown    = "unknown"
+	RevocationNotChecked = "not_checked"
+)
+
+// Signature is a digital signature of a PDF. ICPBrasil is whether the
+// certificate was issued under an ICP-Brasil policy; Valid means the
+// signature matches the signed bytes, which are the whole document, its
+// certificate chains to an ICP-Brasil root and it was not revoked when
+// signed.
+type Signature struct {
+	SignerName  string     `json:"signer_name"`
+	SignerCNPJ  string     `json:"signer_cnpj,omitempty"`
+	Issuer      string     `json:"issuer"`
+	SigningTime *time.Time `json:"signing_time,omitempty"`
+	SubFilter   string     `json:"sub_filter,omitempty"` // ETSI.CAdES.detached for PAdES
+	PAdES       bool       `json:"pades"`
+
+	// Intact is whether the signature matches the bytes it covers;
+	// CoversDocument whether the document was not changed after signing.
+	Intact         bool `json:"intact"`
+	CoversDocument bool `json:"covers_document"`
+
+	ChainValid bool       `json:"chain_valid"`
+	ICPBrasil  bool       `json:"icp_brasil"`
+	Revocation string     `json:"revocation"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Valid      bool       `json:"valid"`
+	Errors     []string   `json:"errors,omitempty"`
+}
+
+// Verifier checks PDF signatures against the ICP-Brasil roots, which are
+// loaded from ICP_BRASIL_ROOTS: a PEM or DER certificate file or a directory
+// of them.
+type Verifier struct {
+	roots           *x509.CertPool
+	checkRevocation bool
+	http            *http.Client
+
+	mu   sync.Mutex
+	crls map[string]*cachedCRL
+}
+
+func NewVerifier(cfg *config.Config) (*Verifier, error) {
+	v := &Verifier{
+		checkRevocation: cfg.SignatureRevocationCheck,
+		http:            &http.Client{Timeout: cfg.SignatureTimeout},
+		crls:            make(map[string]*cachedCRL),
+	}
+	if cfg.ICPBrasilRoots == "" {
+		return v, nil
+	}
+
+	roots, err := loadRoots(cfg.ICPBrasilRoots)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ICP-Brasil roots from %s: %w", cfg.ICPBrasilRoots, err)
+	}
+	v.roots = roots
+	return v, nil
+}
+
+func loadRoots(path string) (*x509.CertPool, error) {
+	files := []string{path}
+	if info, err := os.Stat(path); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+
+	pool := x509.NewCertPool()
+	loaded := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Contains(data, []byte("-----BEGIN")) {
+			for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+				if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+					pool.AddCert(cert)
+					loaded++
+				}
+			}
+		} else if cert, err := x509.ParseCertificate(data); err == nil {
+			pool.AddCert(cert)
+			loaded++
+		}
+	}
+	if loaded == 0 {
+		return nil, errors.New("no certificates found")
+	}
+	return pool, nil
+}
+
+var (
+	byteRangePattern = regexp.MustCompile(`/ByteRange\s*\[\s*(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s*\]`)
+	subFilterPattern = regexp.MustCompile(`/SubFilter\s*/([A-Za-z0-9.]+)`)
+)
+
+// subFilterWindow is how far around a signature's contents its /SubFilter
+// entry is looked for.
+const subFilterWindow = 2048
+
+// Verify finds the signatures of a PDF and checks each one. Signature
+// dictionaries are located by their byte ranges, which exclude exactly the
+// signature contents, so the raw file is searched rather than parsed.
+func (v *Verifier) Verify(ctx context.Context, data []byte) []Signature {
+	var signatures []Signature
+	seen := make(map[string]bool)
+	for _, m := range byteRangePattern.FindAllSubmatch(data, -1) {
+		var r [4]int
+		for i := range r {
+			r[i], _ = strconv.Atoi(string(m[i+1]))
+		}
+		key := fmt.Sprint(r)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		// Placeholders left by signing tools have no valid range
+		if r[0] != 0 || r[1] <= 0 || r[2] <= r[1] || r[3] < 0 || r[2]+r[3] > len(data) {
+			continue
+		}
+		subFilter := findSubFilter(data, r[1], r[2])
+		if subFilter == "ETSI.RFC3161" {
+			continue // document timestamp, not a signature
+		}
+		signatures = append(signatures, v.verifySignature(ctx, data, r, subFilter))
+	}
+	return signatures
+}
+
+// findSubFilter returns the /SubFilter entry nearest to the signature
+// contents between start and end.
+func findSubFilter(data []byte, start, end int) string {
+	from, to := max(0, start-subFilterWindow), min(len(data), end+subFilterWindow)
+	best, distance := "", -1
+	for _, loc := range subFilterPattern.FindAllSubmatchIndex(data[from:to], -1) {
+		pos := from + loc[0]
+		d := start - pos
+		if pos > end {
+			d = pos - end
+		}
+		if distance < 0 || d < distance {
+			best, distance = string(data[from+loc[2]:from+loc[3]]), d
+		}
+	}
+	return best
+}
+
+func (v *Verifier) verifySignature(ctx context.Context, data []byte, r [4]int, subFilter string) Signature {
+	result := Signature{
+		SubFilter:      subFilter,
+		PAdES:          subFilter == "ETSI.CAdES.detached",
+		CoversDocument: r[2]+r[3] == len(data) || isTrailingWhitespace(data[r[2]+r[3]:]),
+		Revocation:     RevocationNotChecked,
+	}
+	fail := func(err error) Signature {
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+
+	contents := bytes.Join(bytes.Fields(data[r[1]:r[2]]), nil)
+	contents = bytes.TrimSuffix(bytes.TrimPrefix(contents, []byte("<")), []byte(">"))
+	der := make([]byte, hex.DecodedLen(len(contents)))
+	if _, err := hex.Decode(der, contents); err != nil {
+		return fail(fmt.Errorf("invalid signature contents: %w", err))
+	}
+
+	sig, err := parseCMS(der)
+	if err != nil {
+		return fail(err)
+	}
+	result.SignerName, result.SignerCNPJ = signerIdentity(sig.signer)
+	result.Issuer = sig.signer.Issuer.CommonName
+	result.ICPBrasil = hasICPBrasilPolicy(sig.signer)
+
+	signed := make([]byte, 0, r[1]+r[3])
+	signed = append(signed, data[:r[1]]...)
+	signed = append(signed, data[r[2]:r[2]+r[3]]...)
+	if err := sig.verify(signed); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("signature does not match the document: %v", err))
+	} else {
+		result.Intact = true
+	}
+	result.SigningTime = sig.signingTime
+	if !result.CoversDocument {
+		result.Errors = append(result.Errors, "document was updated after this signature")
+	}
+
+	chain, err := v.verifyChain(sig)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	} else {
+		result.ChainValid = true
+	}
+
+	if v.checkRevocation && len(chain) > 1 {
+		status, revokedAt, err := v.revocationStatus(ctx, chain[0], chain[1])
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		}
+		result.Revocation, result.RevokedAt = status, revokedAt
+	}
+
+	revoked := result.Revocation == RevocationRevoked &&
+		(result.SigningTime == nil || result.RevokedAt == nil || !result.SigningTime.Before(*result.RevokedAt))
+	result.Valid = result.Intact && result.CoversDocument && result.ChainValid && !revoked
+	return result
+}
+
+func isTrailingWhitespace(b []byte) bool {
+	return len(bytes.TrimSpace(b)) == 0
+}
+
+// verifyChain builds the signer's chain from the certificates in the
+// signature up to an ICP-Brasil root, as of the signing time when known.
+func (v *Verifier) verifyChain(sig *cmsSignature) ([]*x509.Certificate, error) {
+	if v.roots == nil {
+		return nil, errors.New("no ICP-Brasil roots configured")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range sig.certs {
+		if cert != sig.signer {
+			intermediates.AddCert(cert)
+		}
+	}
+	opts := x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	if sig.signingTime != nil {
+		opts.CurrentTime = *sig.signingTime
+	}
+
+	chains, err := sig.signer.Verify(opts)
+	if err != nil {
+		return nil, fmt.Errorf("certificate not trusted: %w", err)
+	}
+	return chains[0], nil
+}
+
+// ICP-Brasil certificate policies (DOC-ICP-04) and subject alternative
+// names
+var (
+	oidICPBrasilPolicies = asn1.ObjectIdentifier{2, 16, 76, 1, 2}
+	oidSubjectAltName    = asn1.ObjectIdentifier{2, 5, 29, 17}
+	oidICPBrasilCNPJ     = asn1.ObjectIdentifier{2, 16, 76, 1, 3, 3}
+)
+
+// hasICPBrasilPolicy reports whether the certificate was issued under an
+// ICP-Brasil policy, whether or not its chain can be verified.
+func hasICPBrasilPolicy(cert *x509.Certificate) bool {
+	for _, policy := range cert.PolicyIdentifiers {
+		if len(policy) > len(oidICPBrasilPolicies) && policy[:len(oidICPBrasilPolicies)].Equal(oidICPBrasilPolicies) {
+			return true
+		}
+	}
+	return false
+}
+
+type otherName struct {
+	TypeID asn1.ObjectIdentifier
+	Value  asn1.RawValue `asn1:"tag:0"` // explicitly tagged, unwrapped below
+}
+
+// signerIdentity returns the signer's name and, for company certificates,
+// its CNPJ. ICP-Brasil certificates name the holder "NAME:document" in the
+// common name and carry the CNPJ in a subject alternative name.
+func signerIdentity(cert *x509.Certificate) (name, cnpj string) {
+	name = cert.Subject.CommonName
+	if i := strings.LastIndexByte(name, ':'); i > 0 {
+		if document := name[i+1:]; isDigits(document) {
+			name = name[:i]
+			if len(document) == 14 {
+				cnpj = document
+			}
+		}
+	}
+
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidSubjectAltName) {
+			continue
+		}
+		var names asn1.RawValue
+		if _, err := asn1.Unmarshal(ext.Value, &names); err != nil {
+			break
+		}
+		for rest := names.Bytes; len(rest) > 0; {
+			var gn asn1.RawValue
+			var err error
+			if rest, err = asn1.Unmarshal(rest, &gn); err != nil {
+				break
+			}
+			var on otherName
+			if gn.Class != asn1.ClassContextSpecific || gn.Tag != 0 {
+				continue
+			}
+			if _, err := asn1.UnmarshalWithParams(gn.FullBytes, &on, "tag:0"); err != nil {
+				continue
+			}
+			var value asn1.RawValue
+			if _, err := asn1.Unmarshal(on.Value.Bytes, &value); err != nil || !on.TypeID.Equal(oidICPBrasilCNPJ) {
+				continue
+			}
+			if document := strings.TrimSpace(string(value.Bytes)); isDigits(document) && len(document) == 14 {
+				cnpj = document
+			}
+		}
+	}
+	return name, cnpj
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,253 @@
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	oidData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidSHA256        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidECDSAWithSHA2 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+// testIdentity is a key and its certificate.
+type testIdentity struct {
+	key  *ecdsa.PrivateKey
+	cert *x509.Certificate
+}
+
+// newIdentity issues a certificate for a new key, by issuer or self-signed.
+func newIdentity(t *testing.T, name string, issuer *testIdentity) *testIdentity {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	parent, signer := template, key
+	if issuer == nil {
+		template.IsCA, template.BasicConstraintsValid = true, true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		template.PolicyIdentifiers = []asn1.ObjectIdentifier{append(oidICPBrasilPolicies, 3, 1)}
+		parent, signer = issuer.cert, issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testIdentity{key: key, cert: cert}
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	der, err := asn1.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func set(content []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: content}
+}
+
+// signCMS returns a detached CMS signature of content with signed
+// attributes, as PAdES signers make them, by key and naming cert as its
+// signer.
+func signCMS(t *testing.T, content []byte, key *ecdsa.PrivateKey, cert *x509.Certificate, signingTime time.Time) []byte {
+	t.Helper()
+	digest := sha256.Sum256(content)
+
+	var attrs []byte
+	for _, attr := range []attribute{
+		{Type: oidContentType, Values: set(mustMarshal(t, oidData))},
+		{Type: oidSigningTime, Values: set(mustMarshal(t, signingTime.UTC()))},
+		{Type: oidMessageDigest, Values: set(mustMarshal(t, digest[:]))},
+	} {
+		attrs = append(attrs, mustMarshal(t, attr)...)
+	}
+	attrsDigest := sha256.Sum256(mustMarshal(t, set(attrs)))
+	signature, err := ecdsa.SignASN1(rand.Reader, key, attrsDigest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sid := mustMarshal(t, issuerAndSerial{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, Serial: cert.SerialNumber})
+	digestAlgorithm := pkix.AlgorithmIdentifier{Algorithm: oidSHA256}
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: set(mustMarshal(t, digestAlgorithm)),
+		EncapContentInfo: encapContentInfo{EContentType: oidData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: cert.Raw},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                asn1.RawValue{FullBytes: sid},
+			DigestAlgorithm:    digestAlgorithm,
+			SignedAttrs:        asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attrs},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA2},
+			Signature:          signature,
+		}},
+	}
+	return mustMarshal(t, contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: mustMarshal(t, sd)},
+	})
+}
+
+// contentsLength is the number of hex digits reserved for the signature.
+const contentsLength = 8192
+
+// signedPDF returns a PDF with a PAdES signature dictionary whose contents
+// are the signature sign makes of the bytes it covers.
+func signedPDF(t *testing.T, sign func(content []byte) []byte) []byte {
+	t.Helper()
+	head := "%%PDF-1.7\n1 0 obj\n<< /Type /Catalog >>\nendobj\n" +
+		"2 0 obj\n<< /Type /Sig /Filter /Adobe.PPKLite /SubFilter /ETSI.CAdES.detached " +
+		"/ByteRange [0 %010d %010d %010d] /Contents "
+	tail := " >>\nendobj\ntrailer\n<< /Root 1 0 R >>\n%EOF\n"
+
+	start := len(fmt.Sprintf(head, 0, 0, 0))
+	end := start + contentsLength + 2
+	data := []byte(fmt.Sprintf(head, start, end, len(tail)) + "<" + strings.Repeat("0", contentsLength) + ">" + tail)
+
+	signed := append(append([]byte{}, data[:start]...), data[end:]...)
+	contents := hex.EncodeToString(sign(signed))
+	if len(contents) > contentsLength {
+		t.Fatalf("signature of %d hex digits exceeds the %d reserved", len(contents), contentsLength)
+	}
+	copy(data[start+1:], contents)
+	return data
+}
+
+func TestVerify(t *testing.T) {
+	root := newIdentity(t, "AC Raiz Teste", nil)
+	signer := newIdentity(t, "ACME COMPRAS LTDA:12345678000190", root)
+	other := newIdentity(t, "OUTRA EMPRESA LTDA:98765432000110", root)
+	untrusted := newIdentity(t, "SELF SIGNED LTDA:11111111000111", nil)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+	v := &Verifier{roots: roots, crls: make(map[string]*cachedCRL)}
+	signingTime := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	valid := signedPDF(t, func(content []byte) []byte {
+		return signCMS(t, content, signer.key, signer.cert, signingTime)
+	})
+
+	tests := []struct {
+		name       string
+		data       []byte
+		want       Signature
+		wantErrors []string
+	}{
+		{
+			name: "valid",
+			data: valid,
+			want: Signature{Intact: true, CoversDocument: true, ChainValid: true, Valid: true},
+		},
+		{
+			name:       "tampered document",
+			data:       bytes.Replace(valid, []byte("/Type /Catalog"), []byte("/Type /Catalof"), 1),
+			want:       Signature{CoversDocument: true, ChainValid: true},
+			wantErrors: []string{"signature does not match the document: document digest does not match"},
+		},
+		{
+			name:       "updated after signing",
+			data:       append(append([]byte{}, valid...), "3 0 obj\n<< /Type /Annot >>\nendobj\n%EOF\n"...),
+			want:       Signature{Intact: true, ChainValid: true},
+			wantErrors: []string{"document was updated after this signature"},
+		},
+		{
+			name: "wrong signer",
+			data: signedPDF(t, func(content []byte) []byte {
+				return signCMS(t, content, other.key, signer.cert, signingTime)
+			}),
+			want:       Signature{CoversDocument: true, ChainValid: true},
+			wantErrors: []string{"signature does not match the document: ecdsa verification failure"},
+		},
+		{
+			name: "untrusted signer",
+			data: signedPDF(t, func(content []byte) []byte {
+				return signCMS(t, content, untrusted.key, untrusted.cert, signingTime)
+			}),
+			want:       Signature{Intact: true, CoversDocument: true},
+			wantErrors: []string{"certificate not trusted: x509: certificate signed by unknown authority"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signatures := v.Verify(context.Background(), tt.data)
+			if len(signatures) != 1 {
+				t.Fatalf("Verify() found %d signatures, want 1", len(signatures))
+			}
+			got := signatures[0]
+			if got.Intact != tt.want.Intact || got.CoversDocument != tt.want.CoversDocument ||
+				got.ChainValid != tt.want.ChainValid || got.Valid != tt.want.Valid {
+				t.Errorf("Verify() = intact %v, covers %v, chain %v, valid %v, want %v, %v, %v, %v",
+					got.Intact, got.CoversDocument, got.ChainValid, got.Valid,
+					tt.want.Intact, tt.want.CoversDocument, tt.want.ChainValid, tt.want.Valid)
+			}
+			if strings.Join(got.Errors, "; ") != strings.Join(tt.wantErrors, "; ") {
+				t.Errorf("Verify() errors = %q, want %q", got.Errors, tt.wantErrors)
+			}
+			if !got.PAdES || got.Revocation != RevocationNotChecked {
+				t.Errorf("Verify() = PAdES %v, revocation %q", got.PAdES, got.Revocation)
+			}
+		})
+	}
+
+	got := v.Verify(context.Background(), valid)[0]
+	if got.SignerName != "ACME COMPRAS LTDA" || got.SignerCNPJ != "12345678000190" || got.Issuer != "AC Raiz Teste" || !got.ICPBrasil {
+		t.Errorf("Verify() signer = %q, %q, issuer %q, ICP-Brasil %v", got.SignerName, got.SignerCNPJ, got.Issuer, got.ICPBrasil)
+	}
+	if got.SigningTime == nil || !got.SigningTime.Equal(signingTime) {
+		t.Errorf("Verify() signing time = %v, want %v", got.SigningTime, signingTime)
+	}
+}
+
+func TestVerifySkipsPlaceholders(t *testing.T) {
+	v := &Verifier{crls: make(map[string]*cachedCRL)}
+	data := []byte("%PDF-1.7\n<< /Type /Sig /ByteRange [0 0 0 0] /Contents <00> >>\n" +
+		"<< /Type /DocTimeStamp /SubFilter /ETSI.RFC3161 /ByteRange [0 10 20 5] /Contents <00> >>\n%EOF\n")
+	if signatures := v.Verify(context.Background(), data); len(signatures) != 0 {
+		t.Errorf("Verify() = %+v, want no signatures", signatures)
+	}
+}
+
+func TestVerifyCorruptContents(t *testing.T) {
+	v := &Verifier{crls: make(map[string]*cachedCRL)}
+	data := signedPDF(t, func([]byte) []byte { return []byte("not a CMS signature") })
+	signatures := v.Verify(context.Background(), data)
+	if len(signatures) != 1 || signatures[0].Valid || len(signatures[0].Errors) != 1 ||
+		!strings.HasPrefix(signatures[0].Errors[0], "invalid CMS content") {
+		t.Errorf("Verify() = %+v", signatures)
+	}
+}
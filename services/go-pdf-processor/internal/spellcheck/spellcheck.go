@@ -0,0 +1,227 @@
+package spellcheck
+
+import (
+	"bufio"
+	_ "embed"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"cotai-pdf-processor/internal/config"
+)
+
+// OCR misreads letters in predictable ways: "m" comes out as "rn", "l" as
+// "1", and accents and cedillas are dropped. A word the dictionary does not
+// know is corrected when undoing at most two such confusions turns it into
+// exactly one word it knows; words it knows, and words with no unambiguous
+// correction, are left alone.
+
+//go:embed lexicon.txt
+var lexicon string
+
+// maxEdits is how many confusions are undone in one word.
+const maxEdits = 2
+
+// minWordLength is the shortest word, in letters, that is corrected.
+const minWordLength = 3
+
+// confusions maps what OCR reads to what was printed, tried in order.
+var confusions = []struct{ read, printed string }{
+	{"rn", "m"},
+	{"m", "rn"},
+	{"cl", "d"},
+	{"li", "h"},
+	{"vv", "w"},
+	{"1", "l"},
+	{"1", "i"},
+	{"l", "i"},
+	{"i", "l"},
+	{"0", "o"},
+	{"5", "s"},
+	{"c", "ç"},
+	{"a", "ã"},
+	{"o", "õ"},
+	{"a", "á"},
+	{"e", "é"},
+	{"i", "í"},
+	{"o", "ó"},
+	{"u", "ú"},
+	{"a", "â"},
+	{"e", "ê"},
+	{"o", "ô"},
+}
+
+// Dictionary is a set of known words, in lower case.
+type Dictionary struct {
+	words map[string]struct{}
+}
+
+// NewDictionary loads the built-in vocabulary and the word list at
+// OCR_DICTIONARY_PATH, if set. A missing or unreadable list is logged and
+// the built-in vocabulary used alone.
+func NewDictionary(cfg *config.Config) *Dictionary {
+	d := &Dictionary{words: make(map[string]struct{})}
+	d.load(strings.NewReader(lexicon))
+
+	if cfg.OCRDictionaryPath != "" {
+		f, err := os.Open(cfg.OCRDictionaryPath)
+		if err != nil {
+			log.Printf("OCR dictionary %s not loaded: %v", cfg.OCRDictionaryPath, err)
+			return d
+		}
+		defer f.Close()
+		d.load(f)
+	}
+	return d
+}
+
+// load adds the words of a plain list or of a Hunspell .dic file, whose
+// first line is the word count and whose words may carry /FLAGS.
+func (d *Dictionary) load(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		word, _, _ := strings.Cut(line, "/")
+		if strings.IndexFunc(word, unicode.IsLetter) < 0 {
+			continue
+		}
+		d.words[strings.ToLower(word)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("Failed to read OCR dictionary: %v", err)
+	}
+}
+
+// Contains reports whether the word is known, in any case.
+func (d *Dictionary) Contains(word string) bool {
+	_, ok := d.words[strings.ToLower(word)]
+	return ok
+}
+
+// Correct returns the text with its misread words corrected, and how many
+// were.
+func (d *Dictionary) Correct(text string) (string, int) {
+	if d == nil {
+		return text, 0
+	}
+
+	var b strings.Builder
+	corrected := 0
+	for start := 0; start < len(text); {
+		r, size := utf8.DecodeRuneInString(text[start:])
+		if !isWordRune(r) {
+			b.WriteString(text[start : start+size])
+			start += size
+			continue
+		}
+		end := start
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if !isWordRune(r) {
+				break
+			}
+			end += size
+		}
+
+		word := text[start:end]
+		if fixed, ok := d.correctWord(word); ok {
+			b.WriteString(fixed)
+			corrected++
+		} else {
+			b.WriteString(word)
+		}
+		start = end
+	}
+	return b.String(), corrected
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// correctWord returns the one known word the fewest confusions away from
+// word, in word's case.
+func (d *Dictionary) correctWord(word string) (string, bool) {
+	letters := 0
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	lower := strings.ToLower(word)
+	if letters < minWordLength || d.Contains(lower) {
+		return "", false
+	}
+
+	candidates := []string{lower}
+	seen := map[string]bool{lower: true}
+	for edit := 0; edit < maxEdits; edit++ {
+		var next []string
+		found := ""
+		for _, candidate := range candidates {
+			for _, variant := range variants(candidate) {
+				if seen[variant] {
+					continue
+				}
+				seen[variant] = true
+				if _, ok := d.words[variant]; ok {
+					if found != "" && found != variant {
+						return "", false // ambiguous
+					}
+					found = variant
+				}
+				next = append(next, variant)
+			}
+		}
+		if found != "" {
+			return matchCase(found, word), true
+		}
+		candidates = next
+	}
+	return "", false
+}
+
+// variants undoes one confusion, at each place it may have happened.
+func variants(word string) []string {
+	var out []string
+	for _, c := range confusions {
+		for i := 0; ; {
+			j := strings.Index(word[i:], c.read)
+			if j < 0 {
+				break
+			}
+			at := i + j
+			out = append(out, word[:at]+c.printed+word[at+len(c.read):])
+			i = at + len(c.read)
+		}
+	}
+	return out
+}
+
+// matchCase gives the correction the case of the word read: all upper
+// case, capitalized or lower case.
+func matchCase(correction, read string) string {
+	upper, lower := 0, 0
+	for _, r := range read {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+	switch {
+	case upper > 1 && lower == 0:
+		return strings.ToUpper(correction)
+	case unicode.IsUpper([]rune(read)[0]):
+		r, size := utf8.DecodeRuneInString(correction)
+		return string(unicode.ToUpper(r)) + correction[size:]
+	}
+	return correction
+}
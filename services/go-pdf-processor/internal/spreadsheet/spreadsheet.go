@@ -0,0 +1,66 @@
+// Package spreadsheet writes tables as CSV files or XLSX workbooks, for
+// analysts to open findings in Excel.
+package spreadsheet
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// utf8BOM starts CSV files so Excel reads them as UTF-8 rather than in the
+// system code page, which would garble accented text.
+const utf8BOM = "\ufeff"
+
+// Sheet is a table: a row of column names, then rows of cells. Cells are
+// strings, integers or floats; numbers stay numbers in workbooks, and nil
+// cells are left empty.
+type Sheet struct {
+	Name    string
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// WriteCSV writes a sheet as CSV.
+func WriteCSV(w io.Writer, sheet Sheet) error {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return err
+	}
+	out := csv.NewWriter(w)
+	if err := out.Write(sheet.Columns); err != nil {
+		return err
+	}
+	record := make([]string, len(sheet.Columns))
+	for _, row := range sheet.Rows {
+		record = record[:0]
+		for _, cell := range row {
+			record = append(record, cellText(cell))
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// cellText formats a cell as CSV holds it. Text that spreadsheets would
+// take for a formula is quoted with an apostrophe, so a document's text is
+// never evaluated.
+func cellText(cell interface{}) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,180 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxCellText is the most characters Excel holds in a cell.
+	maxCellText = 32767
+
+	// maxSheetName is the longest sheet name Excel accepts.
+	maxSheetName = 31
+)
+
+// The parts of a workbook other than its sheets. Strings are written
+// inline rather than shared, so sheets are written as they go; style 1 is
+// the bold of the column names.
+const (
+	xlsxRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+		`</styleSheet>`
+)
+
+// WriteXLSX writes sheets as the worksheets of an XLSX workbook, their
+// column names in bold and frozen above the rows.
+func WriteXLSX(w io.Writer, sheets []Sheet) error {
+	archive := zip.NewWriter(w)
+	names := sheetNames(sheets)
+
+	var contentTypes, workbook, workbookRels strings.Builder
+	contentTypes.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	workbook.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	workbookRels.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range sheets {
+		n := i + 1
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(names[i]), n, n)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`, len(sheets)+1)
+
+	for _, part := range []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		{"xl/styles.xml", xlsxStyles},
+	} {
+		entry, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(entry, part.content); err != nil {
+			return err
+		}
+	}
+
+	for i, sheet := range sheets {
+		entry, err := archive.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return err
+		}
+		if err := writeWorksheet(entry, sheet); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func writeWorksheet(w io.Writer, sheet Sheet) error {
+	out := bufio.NewWriter(w)
+	out.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>` +
+		`<sheetData>`)
+
+	out.WriteString(`<row r="1">`)
+	for col, name := range sheet.Columns {
+		fmt.Fprintf(out, `<c r="%s1" s="1" t="inlineStr"><is><t>%s</t></is></c>`, columnName(col), escape(name))
+	}
+	out.WriteString(`</row>`)
+
+	for i, row := range sheet.Rows {
+		r := i + 2
+		fmt.Fprintf(out, `<row r="%d">`, r)
+		for col, cell := range row {
+			ref := columnName(col) + strconv.Itoa(r)
+			switch v := cell.(type) {
+			case nil:
+			case string:
+				fmt.Fprintf(out, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, escape(truncate(v)))
+			case float64:
+				fmt.Fprintf(out, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'f', -1, 64))
+			case int, int64:
+				fmt.Fprintf(out, `<c r="%s"><v>%d</v></c>`, ref, v)
+			default:
+				fmt.Fprintf(out, `<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, escape(truncate(fmt.Sprint(v))))
+			}
+		}
+		out.WriteString(`</row>`)
+	}
+
+	out.WriteString(`</sheetData></worksheet>`)
+	return out.Flush()
+}
+
+// columnName returns the letters of column col, from 0: A to Z, then AA.
+func columnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+// sheetNames returns names of the sheets Excel accepts: without the
+// characters it reserves, short enough and unique.
+func sheetNames(sheets []Sheet) []string {
+	names := make([]string, len(sheets))
+	taken := make(map[string]bool)
+	for i, sheet := range sheets {
+		name := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`[]:*?/\`, r) {
+				return '_'
+			}
+			return r
+		}, sheet.Name)
+		if name == "" {
+			name = "Sheet"
+		}
+		base := name
+		name = truncateRunes(base, maxSheetName)
+		for n := 2; taken[strings.ToLower(name)]; n++ {
+			suffix := fmt.Sprintf(" (%d)", n)
+			name = truncateRunes(base, maxSheetName-len(suffix)) + suffix
+		}
+		taken[strings.ToLower(name)] = true
+		names[i] = name
+	}
+	return names
+}
+
+func truncate(s string) string {
+	return truncateRunes(s, maxCellText)
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// escape escapes text for XML, replacing the characters XML cannot hold.
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
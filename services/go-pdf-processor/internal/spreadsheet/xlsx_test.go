@@ -0,0 +1,139 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readWorkbook reads back the sheet names of a workbook and the cells of
+// its worksheets, as text by row.
+func readWorkbook(t *testing.T, data []byte) ([]string, [][][]string) {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("workbook is not a zip file: %v", err)
+	}
+	parts := make(map[string][]byte)
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parts[f.Name], err = io.ReadAll(r); err != nil {
+			t.Fatal(err)
+		}
+		r.Close()
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		if err := xml.Unmarshal(parts[name], new(struct{})); err != nil {
+			t.Errorf("part %s: %v", name, err)
+		}
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(parts["xl/workbook.xml"], &workbook); err != nil {
+		t.Fatalf("workbook: %v", err)
+	}
+
+	var names []string
+	var sheets [][][]string
+	for i, sheet := range workbook.Sheets {
+		names = append(names, sheet.Name)
+		var worksheet struct {
+			Rows []struct {
+				Cells []struct {
+					Ref    string `xml:"r,attr"`
+					Type   string `xml:"t,attr"`
+					Value  string `xml:"v"`
+					Inline string `xml:"is>t"`
+				} `xml:"c"`
+			} `xml:"sheetData>row"`
+		}
+		name := "xl/worksheets/sheet" + string(rune('1'+i)) + ".xml"
+		if err := xml.Unmarshal(parts[name], &worksheet); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var rows [][]string
+		for _, row := range worksheet.Rows {
+			var cells []string
+			for _, cell := range row.Cells {
+				text := cell.Value
+				if cell.Type == "inlineStr" {
+					text = cell.Inline
+				}
+				cells = append(cells, cell.Ref+"="+text)
+			}
+			rows = append(rows, cells)
+		}
+		sheets = append(sheets, rows)
+	}
+	return names, sheets
+}
+
+func TestWriteXLSXRoundTrip(t *testing.T) {
+	sheets := []Sheet{
+		{
+			Name:    "Entities",
+			Columns: []string{"job_id", "type", "value", "confidence", "page"},
+			Rows: [][]interface{}{
+				{"j1", "cnpj", "12.345.678/0001-90", 0.95, 1},
+				{"j1", "value", "R$ 1.500,00 <total> & \"tax\"", 0.8, int64(2)},
+				{"j2", nil, "  spaced  ", nil, 3},
+			},
+		},
+		{Name: "Risks: 2024/01", Columns: []string{"job_id"}},
+		{Name: "entities", Columns: []string{"job_id"}, Rows: [][]interface{}{{"j3"}}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, sheets); err != nil {
+		t.Fatalf("WriteXLSX() error = %v", err)
+	}
+	names, got := readWorkbook(t, buf.Bytes())
+
+	if want := []string{"Entities", "Risks_ 2024_01", "entities (2)"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("sheet names = %q, want %q", names, want)
+	}
+	want := [][][]string{
+		{
+			{"A1=job_id", "B1=type", "C1=value", "D1=confidence", "E1=page"},
+			{"A2=j1", "B2=cnpj", "C2=12.345.678/0001-90", "D2=0.95", "E2=1"},
+			{"A3=j1", "B3=value", `C3=R$ 1.500,00 <total> & "tax"`, "D3=0.8", "E3=2"},
+			{"A4=j2", "C4=  spaced  ", "E4=3"},
+		},
+		{{"A1=job_id"}},
+		{{"A1=job_id"}, {"A2=j3"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sheets = %q, want %q", got, want)
+	}
+}
+
+func TestWriteXLSXTruncatesCells(t *testing.T) {
+	long := strings.Repeat("é", maxCellText+10)
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, []Sheet{{Name: "Text", Columns: []string{"text"}, Rows: [][]interface{}{{long}}}}); err != nil {
+		t.Fatalf("WriteXLSX() error = %v", err)
+	}
+	_, sheets := readWorkbook(t, buf.Bytes())
+	if cell := strings.TrimPrefix(sheets[0][1][0], "A2="); len([]rune(cell)) != maxCellText {
+		t.Errorf("cell has %d characters, want %d", len([]rune(cell)), maxCellText)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for col, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(col); got != want {
+			t.Errorf("columnName(%d) = %q, want %q", col, got, want)
+		}
+	}
+}
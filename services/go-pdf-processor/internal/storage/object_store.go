@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// ObjectStore is a client for S3-compatible object storage (AWS S3, MinIO,
+// and Google Cloud Storage through its interoperability endpoint).
+type ObjectStore struct {
+	client *minio.Client
+}
+
+type ObjectStoreConfig struct {
+	Endpoint  string
+	Region    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+}
+
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+	ETag        string
+}
+
+func NewObjectStore(cfg ObjectStoreConfig) (*ObjectStore, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create object store client for %s: %w", cfg.Endpoint, err)
+	}
+
+	return &ObjectStore{client: client}, nil
+}
+
+// Get opens an object for reading. The caller must close the returned reader.
+func (o *ObjectStore) Get(ctx context.Context, bucket, key string) (io.ReadCloser, ObjectInfo, error) {
+	obj, err := o.client.GetObject(ctx, bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+
+	stat, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, ObjectInfo{}, err
+	}
+
+	return obj, ObjectInfo{Size: stat.Size, ContentType: stat.ContentType, ETag: stat.ETag}, nil
+}
+
+func (o *ObjectStore) Stat(ctx context.Context, bucket, key string) (ObjectInfo, error) {
+	stat, err := o.client.StatObject(ctx, bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return ObjectInfo{}, ErrNotFound
+		}
+		return ObjectInfo{}, err
+	}
+
+	return ObjectInfo{Size: stat.Size, ContentType: stat.ContentType, ETag: stat.ETag}, nil
+}
+
+// Put uploads size bytes from r as an object.
+func (o *ObjectStore) Put(ctx context.Context, bucket, key string, r io.Reader, size int64, contentType string) error {
+	_, err := o.client.PutObject(ctx, bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s/%s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// PresignedGet returns a URL the object can be downloaded from directly,
+// valid for expiry.
+func (o *ObjectStore) PresignedGet(ctx context.Context, bucket, key string, expiry time.Duration) (string, error) {
+	u, err := o.client.PresignedGetObject(ctx, bucket, key, expiry, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign download for %s/%s: %w", bucket, key, err)
+	}
+	return u.String(), nil
+}
+
+// PresignedPut returns a URL the client can PUT the object to directly,
+// valid for expiry.
+func (o *ObjectStore) PresignedPut(ctx context.Context, bucket, key string, expiry time.Duration) (string, error) {
+	u, err := o.client.PresignedPutObject(ctx, bucket, key, expiry)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign upload for %s/%s: %w", bucket, key, err)
+	}
+	return u.String(), nil
+}
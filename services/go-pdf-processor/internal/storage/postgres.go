@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	_ "github.com/lib/pq"
+)
+
+type PostgresClient struct {
+	db *sql.DB
+}
+
+func NewPostgresClient(url string) *PostgresClient {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	return &PostgresClient{db: db}
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose queries only see the rows of a
+// tenant in the tables isolating tenants by row-level security (see
+// migrations/016_tenant_isolation.sql). They run in transactions setting
+// app.tenant_id, which the policies of those tables compare rows to.
+// Queries of contexts without a tenant see every row.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// begin starts the transaction of a tenant's query, nil when the context
+// has no tenant.
+func (p *PostgresClient) begin(ctx context.Context) (*sql.Tx, error) {
+	tenantID, ok := ctx.Value(tenantKey{}).(string)
+	if !ok {
+		return nil, nil
+	}
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT set_config('app.tenant_id', $1, true)`, tenantID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (p *PostgresClient) Exec(ctx context.Context, query string, args ...interface{}) error {
+	tx, err := p.begin(ctx)
+	if err != nil {
+		return err
+	}
+	if tx == nil {
+		_, err := p.db.ExecContext(ctx, query, args...)
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Rows are the rows of Query; closing them ends their transaction.
+type Rows struct {
+	*sql.Rows
+	tx *sql.Tx
+}
+
+func (r *Rows) Close() error {
+	err := r.Rows.Close()
+	if r.tx != nil {
+		if commitErr := r.tx.Commit(); err == nil {
+			err = commitErr
+		}
+		r.tx = nil
+	}
+	return err
+}
+
+func (p *PostgresClient) Query(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
+	tx, err := p.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		rows, err := p.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		return &Rows{Rows: rows}, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return &Rows{Rows: rows, tx: tx}, nil
+}
+
+// Row is the row of QueryRow; scanning it ends its transaction.
+type Row struct {
+	row *sql.Row
+	tx  *sql.Tx
+	err error
+}
+
+func (r *Row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	err := r.row.Scan(dest...)
+	if r.tx != nil {
+		if commitErr := r.tx.Commit(); err == nil {
+			err = commitErr
+		}
+		r.tx = nil
+	}
+	return err
+}
+
+func (p *PostgresClient) QueryRow(ctx context.Context, query string, args ...interface{}) *Row {
+	tx, err := p.begin(ctx)
+	if err != nil {
+		return &Row{err: err}
+	}
+	if tx == nil {
+		return &Row{row: p.db.QueryRowContext(ctx, query, args...)}
+	}
+	return &Row{row: tx.QueryRowContext(ctx, query, args...), tx: tx}
+}
+
+func (p *PostgresClient) Close() error {
+	return p.db.Close()
+}
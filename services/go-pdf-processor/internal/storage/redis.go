@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNotFound is returned when a key does not exist.
+var ErrNotFound = errors.New("storage: not found")
+
+type RedisClient struct {
+	client *redis.Client
+}
+
+func NewRedisClient(url string) *RedisClient {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		log.Fatalf("Invalid Redis URL: %v", err)
+	}
+	return &RedisClient{client: redis.NewClient(opts)}
+}
+
+func (r *RedisClient) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return r.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (r *RedisClient) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (r *RedisClient) Del(ctx context.Context, keys ...string) error {
+	return r.client.Del(ctx, keys...).Err()
+}
+
+// Client exposes the underlying go-redis client for commands not wrapped here.
+func (r *RedisClient) Client() *redis.Client {
+	return r.client
+}
+
+func (r *RedisClient) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,258 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TusStore keeps the state of resumable (tus.io) uploads on local disk: each
+// upload is a data file plus a JSON info file holding its offset and metadata.
+type TusStore struct {
+	dir    string
+	expiry time.Duration
+	locks  sync.Map
+}
+
+type TusUpload struct {
+	ID string `json:"id"`
+
+	// The user and tenant creating the upload, who alone may resume it
+	UserID   string `json:"user_id,omitempty"`
+	TenantID string `json:"tenant_id,omitempty"`
+
+	Length    int64             `json:"length"`
+	Offset    int64             `json:"offset"`
+	Metadata  map[string]string `json:"metadata"`
+	CreatedAt time.Time         `json:"created_at"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+func (u *TusUpload) Complete() bool {
+	return u.Offset == u.Length
+}
+
+func NewTusStore(dir string, expiry time.Duration) (*TusStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create tus upload directory: %w", err)
+	}
+	return &TusStore{dir: dir, expiry: expiry}, nil
+}
+
+func (s *TusStore) Create(length int64, userID, tenantID string, metadata map[string]string) (*TusUpload, error) {
+	now := time.Now()
+	upload := &TusUpload{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		TenantID:  tenantID,
+		Length:    length,
+		Metadata:  metadata,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.expiry),
+	}
+
+	data, err := os.OpenFile(s.dataPath(upload.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload file: %w", err)
+	}
+	data.Close()
+
+	if err := s.saveInfo(upload); err != nil {
+		os.Remove(s.dataPath(upload.ID))
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+// Get returns an upload. Expired uploads are returned along with
+// ErrUploadExpired, for their owner to be checked before deleting them.
+func (s *TusStore) Get(id string) (*TusUpload, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrUploadNotFound
+	}
+
+	raw, err := os.ReadFile(s.infoPath(id))
+	if os.IsNotExist(err) {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var upload TusUpload
+	if err := json.Unmarshal(raw, &upload); err != nil {
+		return nil, fmt.Errorf("corrupt upload info for %s: %w", id, err)
+	}
+	if time.Now().After(upload.ExpiresAt) {
+		return &upload, ErrUploadExpired
+	}
+
+	return &upload, nil
+}
+
+// WriteChunk appends r to the upload, which must currently be at offset.
+// At most Length-offset bytes are consumed; the expiry is extended on every
+// successful write.
+func (s *TusStore) WriteChunk(id string, offset int64, r io.Reader) (*TusUpload, error) {
+	mu := s.lock(id)
+	if !mu.TryLock() {
+		return nil, ErrUploadLocked
+	}
+	defer mu.Unlock()
+
+	upload, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Offset != offset {
+		return upload, ErrOffsetMismatch
+	}
+
+	data, err := os.OpenFile(s.dataPath(id), os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return nil, err
+	}
+
+	written, copyErr := io.Copy(data, io.LimitReader(r, upload.Length-upload.Offset))
+	closeErr := data.Close()
+
+	// Keep whatever arrived before a dropped connection so the client can
+	// resume from there.
+	upload.Offset += written
+	upload.ExpiresAt = time.Now().Add(s.expiry)
+	if err := s.saveInfo(upload); err != nil {
+		return nil, err
+	}
+
+	if copyErr != nil {
+		return upload, copyErr
+	}
+	return upload, closeErr
+}
+
+// Finalize moves a completed upload's data to dest and forgets the upload.
+func (s *TusStore) Finalize(id, dest string) error {
+	mu := s.lock(id)
+	mu.Lock()
+	defer mu.Unlock()
+	defer s.locks.Delete(id)
+
+	if err := os.Rename(s.dataPath(id), dest); err != nil {
+		return fmt.Errorf("failed to move completed upload: %w", err)
+	}
+	return os.Remove(s.infoPath(id))
+}
+
+func (s *TusStore) Delete(id string) error {
+	if _, err := s.Get(id); err != nil && !errors.Is(err, ErrUploadExpired) {
+		return err
+	}
+
+	mu := s.lock(id)
+	mu.Lock()
+	defer mu.Unlock()
+	defer s.locks.Delete(id)
+
+	s.remove(id)
+	return nil
+}
+
+// CleanupExpired removes uploads whose expiry has passed and returns how
+// many were deleted.
+func (s *TusStore) CleanupExpired() int {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		log.Printf("Failed to list tus uploads: %v", err)
+		return 0
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".info")
+		if !ok {
+			continue
+		}
+		if _, err := s.Get(id); errors.Is(err, ErrUploadExpired) {
+			if mu := s.lock(id); mu.TryLock() {
+				s.remove(id)
+				mu.Unlock()
+				s.locks.Delete(id)
+				removed++
+			}
+		}
+	}
+	return removed
+}
+
+// StartCleanup runs CleanupExpired every interval until ctx is done.
+func (s *TusStore) StartCleanup(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if n := s.CleanupExpired(); n > 0 {
+					log.Printf("Removed %d expired tus uploads", n)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (s *TusStore) remove(id string) {
+	for _, path := range []string{s.dataPath(id), s.infoPath(id)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove %s: %v", path, err)
+		}
+	}
+}
+
+func (s *TusStore) saveInfo(upload *TusUpload) error {
+	raw, err := json.Marshal(upload)
+	if err != nil {
+		return err
+	}
+
+	// Write then rename so a crash never leaves a half-written info file.
+	tmp := s.infoPath(upload.ID) + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o640); err != nil {
+		return fmt.Errorf("failed to write upload info: %w", err)
+	}
+	return os.Rename(tmp, s.infoPath(upload.ID))
+}
+
+func (s *TusStore) lock(id string) *sync.Mutex {
+	mu, _ := s.locks.LoadOrStore(id, &sync.Mutex{})
+	return mu.(*sync.Mutex)
+}
+
+func (s *TusStore) dataPath(id string) string {
+	return filepath.Join(s.dir, id+".bin")
+}
+
+func (s *TusStore) infoPath(id string) string {
+	return filepath.Join(s.dir, id+".info")
+}
+
+// Custom errors
+var (
+	ErrUploadNotFound = errors.New("upload not found")
+	ErrUploadExpired  = errors.New("upload expired")
+	ErrUploadLocked   = errors.New("upload is being written by another request")
+	ErrOffsetMismatch = errors.New("upload offset mismatch")
+)
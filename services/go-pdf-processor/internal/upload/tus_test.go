@@ -0,0 +1,169 @@
+package upload
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// droppedReader returns its data, then fails as a dropped connection does.
+type droppedReader struct {
+	r io.Reader
+}
+
+func (d *droppedReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	if err == io.EOF {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func newTestStore(t *testing.T, expiry time.Duration) *TusStore {
+	t.Helper()
+	s, err := NewTusStore(t.TempDir(), expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestTusResume(t *testing.T) {
+	s := newTestStore(t, time.Hour)
+	upload, err := s.Create(10, "u1", "t1", map[string]string{"filename": "edital.pdf"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// The connection drops after the first bytes, which are kept
+	got, err := s.WriteChunk(upload.ID, 0, &droppedReader{strings.NewReader("%PDF")})
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("WriteChunk() error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if got.Offset != 4 || got.Complete() {
+		t.Fatalf("WriteChunk() offset = %d, complete %v", got.Offset, got.Complete())
+	}
+
+	// The client asks where to resume and goes on from there
+	stored, err := s.Get(upload.ID)
+	if err != nil || stored.Offset != 4 {
+		t.Fatalf("Get() = %+v, %v", stored, err)
+	}
+	if stored.UserID != "u1" || stored.TenantID != "t1" || stored.Metadata["filename"] != "edital.pdf" {
+		t.Errorf("Get() = %+v", stored)
+	}
+	got, err = s.WriteChunk(upload.ID, 4, strings.NewReader("-1.7\n"))
+	if err != nil || got.Offset != 9 {
+		t.Fatalf("WriteChunk() = %+v, %v", got, err)
+	}
+	got, err = s.WriteChunk(upload.ID, 9, strings.NewReader("X"))
+	if err != nil || !got.Complete() {
+		t.Fatalf("WriteChunk() = %+v, %v", got, err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "edital.pdf")
+	if err := s.Finalize(upload.ID, dest); err != nil {
+		t.Fatalf("Finalize() error = %v", err)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "%PDF-1.7\nX" {
+		t.Errorf("finalized data = %q", data)
+	}
+	if _, err := s.Get(upload.ID); !errors.Is(err, ErrUploadNotFound) {
+		t.Errorf("Get() after Finalize() error = %v, want %v", err, ErrUploadNotFound)
+	}
+}
+
+func TestTusOffsetMismatch(t *testing.T) {
+	s := newTestStore(t, time.Hour)
+	upload, err := s.Create(8, "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.WriteChunk(upload.ID, 0, strings.NewReader("1234")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, offset := range []int64{0, 2, 6} {
+		got, err := s.WriteChunk(upload.ID, offset, strings.NewReader("5678"))
+		if !errors.Is(err, ErrOffsetMismatch) {
+			t.Errorf("WriteChunk() at %d error = %v, want %v", offset, err, ErrOffsetMismatch)
+		}
+		if got == nil || got.Offset != 4 {
+			t.Errorf("WriteChunk() at %d = %+v, want the upload at offset 4", offset, got)
+		}
+	}
+	if data, _ := os.ReadFile(s.dataPath(upload.ID)); string(data) != "1234" {
+		t.Errorf("data = %q after refused chunks", data)
+	}
+}
+
+func TestTusSizeLimit(t *testing.T) {
+	s := newTestStore(t, time.Hour)
+	upload, err := s.Create(6, "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Bytes beyond the declared length are not consumed
+	rest := strings.NewReader("abcdefghij")
+	got, err := s.WriteChunk(upload.ID, 0, rest)
+	if err != nil || got.Offset != 6 || !got.Complete() {
+		t.Fatalf("WriteChunk() = %+v, %v", got, err)
+	}
+	if rest.Len() != 4 {
+		t.Errorf("WriteChunk() consumed %d bytes, want 6", 10-rest.Len())
+	}
+	if data, _ := os.ReadFile(s.dataPath(upload.ID)); string(data) != "abcdef" {
+		t.Errorf("data = %q", data)
+	}
+
+	got, err = s.WriteChunk(upload.ID, 6, strings.NewReader("more"))
+	if err != nil || got.Offset != 6 {
+		t.Errorf("WriteChunk() to a complete upload = %+v, %v", got, err)
+	}
+}
+
+func TestTusLocked(t *testing.T) {
+	s := newTestStore(t, time.Hour)
+	upload, err := s.Create(4, "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mu := s.lock(upload.ID)
+	mu.Lock()
+	if _, err := s.WriteChunk(upload.ID, 0, strings.NewReader("1234")); !errors.Is(err, ErrUploadLocked) {
+		t.Errorf("WriteChunk() of a locked upload error = %v, want %v", err, ErrUploadLocked)
+	}
+	mu.Unlock()
+}
+
+func TestTusExpiry(t *testing.T) {
+	s := newTestStore(t, -time.Second)
+	upload, err := s.Create(4, "u1", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Get(upload.ID)
+	if !errors.Is(err, ErrUploadExpired) || got == nil || got.UserID != "u1" {
+		t.Fatalf("Get() = %+v, %v, want the upload and %v", got, err, ErrUploadExpired)
+	}
+	if _, err := s.WriteChunk(upload.ID, 0, strings.NewReader("1234")); !errors.Is(err, ErrUploadExpired) {
+		t.Errorf("WriteChunk() error = %v, want %v", err, ErrUploadExpired)
+	}
+
+	if n := s.CleanupExpired(); n != 1 {
+		t.Errorf("CleanupExpired() = %d, want 1", n)
+	}
+	if _, err := s.Get(upload.ID); !errors.Is(err, ErrUploadNotFound) {
+		t.Errorf("Get() after cleanup error = %v, want %v", err, ErrUploadNotFound)
+	}
+	if _, err := s.Get("../../etc/passwd"); !errors.Is(err, ErrUploadNotFound) {
+		t.Errorf("Get() of a path error = %v, want %v", err, ErrUploadNotFound)
+	}
+}
@@ -0,0 +1,218 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Delivery statuses.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+var ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+// Delivery is a webhook call of a job and its outcome so far. Deliveries
+// to job callback URLs have no endpoint.
+type Delivery struct {
+	ID            string          `json:"id"`
+	EndpointID    string          `json:"endpoint_id,omitempty"`
+	TenantID      string          `json:"tenant_id,omitempty"`
+	JobID         string          `json:"job_id"`
+	URL           string          `json:"url"`
+	Event         string          `json:"event"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	StatusCode    int             `json:"status_code,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+	RedeliveryOf  string          `json:"redelivery_of,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	LastAttemptAt *time.Time      `json:"last_attempt_at,omitempty"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at,omitempty"`
+}
+
+func newDelivery(jobID, tenantID, endpointID, target, event string) *Delivery {
+	now := time.Now()
+	return &Delivery{
+		ID:            uuid.New().String(),
+		EndpointID:    endpointID,
+		TenantID:      tenantID,
+		JobID:         jobID,
+		URL:           target,
+		Event:         event,
+		Status:        StatusPending,
+		CreatedAt:     now,
+		NextAttemptAt: &now,
+	}
+}
+
+const deliveryColumns = `id, endpoint_id, tenant_id, job_id, url, event, status, attempts, status_code,
+	error, payload, redelivery_of, created_at, last_attempt_at, next_attempt_at`
+
+func scanDelivery(row rowScanner) (*Delivery, error) {
+	var delivery Delivery
+	var payload []byte
+	err := row.Scan(&delivery.ID, &delivery.EndpointID, &delivery.TenantID, &delivery.JobID, &delivery.URL,
+		&delivery.Event, &delivery.Status, &delivery.Attempts, &delivery.StatusCode, &delivery.Error, &payload,
+		&delivery.RedeliveryOf, &delivery.CreatedAt, &delivery.LastAttemptAt, &delivery.NextAttemptAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = payload
+	return &delivery, nil
+}
+
+func (d *Dispatcher) createDelivery(ctx context.Context, delivery *Delivery) error {
+	return d.postgres.Exec(ctx, `
+		INSERT INTO webhook_deliveries (`+deliveryColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`, delivery.ID, delivery.EndpointID, delivery.TenantID, delivery.JobID, delivery.URL, delivery.Event,
+		delivery.Status, delivery.Attempts, delivery.StatusCode, delivery.Error, []byte(delivery.Payload),
+		delivery.RedeliveryOf, delivery.CreatedAt, delivery.LastAttemptAt, delivery.NextAttemptAt)
+}
+
+// takeDue returns up to limit pending deliveries due by now, moving their
+// next attempt to lease so that no other replica takes them meanwhile.
+func (d *Dispatcher) takeDue(ctx context.Context, lease time.Time, limit int) ([]*Delivery, error) {
+	rows, err := d.postgres.Query(ctx, `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $3
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = $1 AND next_attempt_at <= $2
+			ORDER BY next_attempt_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+deliveryColumns,
+		StatusPending, time.Now(), lease, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*Delivery
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// recordAttempt stores the outcome of an attempt.
+func (d *Dispatcher) recordAttempt(ctx context.Context, delivery *Delivery) error {
+	return d.postgres.Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, status_code = $4, error = $5, last_attempt_at = $6, next_attempt_at = $7
+		WHERE id = $1
+	`, delivery.ID, delivery.Status, delivery.Attempts, delivery.StatusCode, delivery.Error,
+		delivery.LastAttemptAt, delivery.NextAttemptAt)
+}
+
+// purge removes the finished deliveries older than WEBHOOK_RETENTION.
+func (d *Dispatcher) purge(ctx context.Context) {
+	err := d.postgres.Exec(ctx,
+		`DELETE FROM webhook_deliveries WHERE status <> $1 AND created_at < $2`,
+		StatusPending, time.Now().Add(-d.cfg.WebhookRetention))
+	if err != nil && ctx.Err() == nil {
+		log.Printf("Failed to purge webhook deliveries: %v", err)
+	}
+}
+
+func (d *Dispatcher) GetDelivery(ctx context.Context, id string) (*Delivery, error) {
+	return scanDelivery(d.postgres.QueryRow(ctx,
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id))
+}
+
+// JobDeliveries returns the webhook deliveries of a job, oldest first.
+func (d *Dispatcher) JobDeliveries(ctx context.Context, jobID string) ([]Delivery, error) {
+	return d.listDeliveries(ctx,
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE job_id = $1 ORDER BY created_at`,
+		jobID)
+}
+
+// EndpointDeliveries pages through the delivery log of an endpoint, latest
+// first, returning the total number of its deliveries along with the page.
+func (d *Dispatcher) EndpointDeliveries(ctx context.Context, tenantID, endpointID string, offset, limit int) ([]Delivery, int, error) {
+	var total int
+	err := d.postgres.QueryRow(ctx,
+		`SELECT count(*) FROM webhook_deliveries WHERE tenant_id = $1 AND endpoint_id = $2`,
+		tenantID, endpointID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	deliveries, err := d.listDeliveries(ctx, `
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries
+		WHERE tenant_id = $1 AND endpoint_id = $2
+		ORDER BY created_at DESC
+		OFFSET $3 LIMIT $4
+	`, tenantID, endpointID, offset, limit)
+	return deliveries, total, err
+}
+
+func (d *Dispatcher) listDeliveries(ctx context.Context, query string, args ...interface{}) ([]Delivery, error) {
+	rows, err := d.postgres.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []Delivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// Redeliver queues a new delivery of the payload of a logged one, due now.
+// Endpoint deliveries go to the endpoint's current URL.
+func (d *Dispatcher) Redeliver(ctx context.Context, original *Delivery) (*Delivery, error) {
+	target := original.URL
+	if original.EndpointID != "" {
+		endpoint, err := d.endpoint(ctx, original.TenantID, original.EndpointID)
+		if err != nil {
+			return nil, err
+		}
+		target = endpoint.URL
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(original.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook delivery %s: %w", original.ID, err)
+	}
+	delivery := newDelivery(original.JobID, original.TenantID, original.EndpointID, target, original.Event)
+	delivery.RedeliveryOf = original.ID
+	payload.DeliveryID = delivery.ID
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = data
+
+	if err := d.createDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to queue redelivery of %s: %w", original.ID, err)
+	}
+	return delivery, nil
+}
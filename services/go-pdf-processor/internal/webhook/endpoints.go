@@ -0,0 +1,260 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Events endpoints may subscribe to.
+const (
+	EventJobCompleted = "job.completed"
+	EventJobFailed    = "job.failed"
+	EventJobRetrying  = "job.retrying"
+)
+
+var events = []string{EventJobCompleted, EventJobFailed, EventJobRetrying}
+
+var (
+	ErrEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrInvalidEndpoint  = errors.New("invalid webhook endpoint")
+)
+
+// Endpoint is a URL of a tenant posted the events it subscribes to.
+type Endpoint struct {
+	ID          string   `json:"id"`
+	TenantID    string   `json:"tenant_id"`
+	URL         string   `json:"url"`
+	Description string   `json:"description,omitempty"`
+	Events      []string `json:"events"`
+	Enabled     bool     `json:"enabled"`
+
+	// Secret signs the deliveries; it is only shown when the endpoint is
+	// created and when it is rotated
+	Secret          string     `json:"secret,omitempty"`
+	SecretRotatedAt *time.Time `json:"secret_rotated_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// previousSecret still signs until previousSecretExpiresAt, so
+	// receivers can switch over after a rotation
+	previousSecret          string
+	previousSecretExpiresAt *time.Time
+}
+
+// validate normalizes the endpoint's fields; no events means all of them.
+func (e *Endpoint) validate(ctx context.Context) error {
+	if e.URL == "" {
+		return fmt.Errorf("%w: url is required", ErrInvalidEndpoint)
+	}
+	if err := ValidateURL(ctx, e.URL); err != nil {
+		return fmt.Errorf("%w: url: %v", ErrInvalidEndpoint, err)
+	}
+
+	if len(e.Events) == 0 {
+		e.Events = slices.Clone(events)
+	}
+	subscribed := make([]string, 0, len(e.Events))
+	for _, event := range e.Events {
+		event = strings.ToLower(strings.TrimSpace(event))
+		if !slices.Contains(events, event) {
+			return fmt.Errorf("%w: unknown event %q, expected one of %s", ErrInvalidEndpoint, event, strings.Join(events, ", "))
+		}
+		if !slices.Contains(subscribed, event) {
+			subscribed = append(subscribed, event)
+		}
+	}
+	e.Events = subscribed
+	return nil
+}
+
+// secrets returns the secrets deliveries are signed with, the current one
+// first.
+func (e *Endpoint) secrets() []string {
+	secrets := []string{e.Secret}
+	if e.previousSecret != "" && e.previousSecretExpiresAt != nil && time.Now().Before(*e.previousSecretExpiresAt) {
+		secrets = append(secrets, e.previousSecret)
+	}
+	return secrets
+}
+
+func newSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+const endpointColumns = `id, tenant_id, url, description, events, enabled, secret, previous_secret,
+	previous_secret_expires_at, secret_rotated_at, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanEndpoint(row rowScanner) (*Endpoint, error) {
+	var e Endpoint
+	err := row.Scan(&e.ID, &e.TenantID, &e.URL, &e.Description, pq.Array(&e.Events), &e.Enabled, &e.Secret,
+		&e.previousSecret, &e.previousSecretExpiresAt, &e.SecretRotatedAt, &e.CreatedAt, &e.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEndpointNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// ListEndpoints returns a tenant's endpoints, oldest first, without their
+// secrets.
+func (d *Dispatcher) ListEndpoints(ctx context.Context, tenantID string) ([]Endpoint, error) {
+	rows, err := d.postgres.Query(ctx,
+		`SELECT `+endpointColumns+` FROM webhook_endpoints WHERE tenant_id = $1 ORDER BY created_at`,
+		tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	endpoints := []Endpoint{}
+	for rows.Next() {
+		e, err := scanEndpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read webhook endpoint: %w", err)
+		}
+		e.Secret = ""
+		endpoints = append(endpoints, *e)
+	}
+	return endpoints, rows.Err()
+}
+
+// GetEndpoint returns an endpoint without its secret.
+func (d *Dispatcher) GetEndpoint(ctx context.Context, tenantID, id string) (*Endpoint, error) {
+	e, err := d.endpoint(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	e.Secret = ""
+	return e, nil
+}
+
+func (d *Dispatcher) endpoint(ctx context.Context, tenantID, id string) (*Endpoint, error) {
+	return scanEndpoint(d.postgres.QueryRow(ctx,
+		`SELECT `+endpointColumns+` FROM webhook_endpoints WHERE tenant_id = $1 AND id = $2`,
+		tenantID, id))
+}
+
+// subscribed returns the enabled endpoints of a tenant subscribed to event.
+func (d *Dispatcher) subscribed(ctx context.Context, tenantID, event string) ([]Endpoint, error) {
+	rows, err := d.postgres.Query(ctx, `
+		SELECT `+endpointColumns+`
+		FROM webhook_endpoints
+		WHERE tenant_id = $1 AND enabled AND $2 = ANY(events)
+	`, tenantID, event)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var endpoints []Endpoint
+	for rows.Next() {
+		e, err := scanEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, *e)
+	}
+	return endpoints, rows.Err()
+}
+
+// CreateEndpoint saves a new endpoint with a generated secret, returned
+// in e.Secret.
+func (d *Dispatcher) CreateEndpoint(ctx context.Context, e *Endpoint) error {
+	if err := e.validate(ctx); err != nil {
+		return err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return err
+	}
+
+	e.ID = uuid.New().String()
+	e.Secret = secret
+	e.CreatedAt = time.Now()
+	e.UpdatedAt = e.CreatedAt
+
+	err = d.postgres.Exec(ctx, `
+		INSERT INTO webhook_endpoints (`+endpointColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, '', NULL, NULL, $8, $9)
+	`, e.ID, e.TenantID, e.URL, e.Description, pq.Array(e.Events), e.Enabled, e.Secret, e.CreatedAt, e.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// UpdateEndpoint replaces an endpoint's URL, description, events and
+// enabled flag; its secret is kept.
+func (d *Dispatcher) UpdateEndpoint(ctx context.Context, e *Endpoint) error {
+	if err := e.validate(ctx); err != nil {
+		return err
+	}
+
+	updated, err := scanEndpoint(d.postgres.QueryRow(ctx, `
+		UPDATE webhook_endpoints
+		SET url = $3, description = $4, events = $5, enabled = $6, updated_at = $7
+		WHERE tenant_id = $1 AND id = $2
+		RETURNING `+endpointColumns,
+		e.TenantID, e.ID, e.URL, e.Description, pq.Array(e.Events), e.Enabled, time.Now()))
+	if err != nil {
+		return err
+	}
+
+	updated.Secret = ""
+	*e = *updated
+	return nil
+}
+
+func (d *Dispatcher) DeleteEndpoint(ctx context.Context, tenantID, id string) error {
+	var deleted string
+	err := d.postgres.QueryRow(ctx,
+		`DELETE FROM webhook_endpoints WHERE tenant_id = $1 AND id = $2 RETURNING id`,
+		tenantID, id).Scan(&deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrEndpointNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// RotateSecret gives an endpoint a new secret, returned in the endpoint.
+// Deliveries are also signed with the previous secret for
+// WEBHOOK_SECRET_GRACE, or until the next rotation.
+func (d *Dispatcher) RotateSecret(ctx context.Context, tenantID, id string) (*Endpoint, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return scanEndpoint(d.postgres.QueryRow(ctx, `
+		UPDATE webhook_endpoints
+		SET previous_secret = secret, previous_secret_expires_at = $4, secret = $3,
+			secret_rotated_at = $5, updated_at = $5
+		WHERE tenant_id = $1 AND id = $2
+		RETURNING `+endpointColumns,
+		tenantID, id, secret, now.Add(d.cfg.WebhookSecretGrace), now))
+}
@@ -0,0 +1,336 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cotai-pdf-processor/internal/config"
+	"cotai-pdf-processor/internal/netguard"
+	"cotai-pdf-processor/internal/processor"
+	"cotai-pdf-processor/internal/storage"
+)
+
+// Webhooks tell other systems about jobs as they finish or are retried.
+// Tenants register endpoints subscribed to some events, each with its own
+// secret, and jobs may be submitted with a callback URL, signed with
+// WEBHOOK_SECRET, which is posted the job's summary once it finishes.
+//
+// Bodies are signed with HMAC-SHA256: the Signature header holds
+// "t=<unix time>,v1=<hex digest>", the digest being of
+// "<unix time>.<body>", so receivers can reject stale or forged calls.
+// While a rotated secret is still valid a second v1 digest is made with it.
+//
+// Every delivery is logged in Postgres, where the pending ones wait for
+// their next attempt; any replica may take them. Responses other than 2xx
+// are retried with exponential backoff.
+
+const (
+	// SignatureHeader carries the payload signature.
+	SignatureHeader = "X-Cotai-Signature"
+
+	// pollInterval is how often due deliveries are looked for.
+	pollInterval = time.Second
+
+	// purgeInterval is how often deliveries past their retention are
+	// removed.
+	purgeInterval = time.Hour
+
+	// batchSize bounds the deliveries sent at once by a replica.
+	batchSize = 10
+)
+
+var (
+	// ErrInvalidCallbackURL is returned for callback URLs that cannot be
+	// posted to.
+	ErrInvalidCallbackURL = errors.New("callback_url must be an absolute http or https URL")
+
+	// ErrForbiddenCallbackURL is returned for callback URLs of hosts that
+	// are not public, which webhooks are not posted to.
+	ErrForbiddenCallbackURL = errors.New("callback_url must be of a public host")
+)
+
+// ValidateURL checks a callback URL; empty means none. Its host must
+// resolve to public addresses only; deliveries check the addresses they
+// connect to again.
+func ValidateURL(ctx context.Context, raw string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidCallbackURL
+	}
+	if err := netguard.CheckHost(ctx, u.Hostname()); err != nil {
+		return fmt.Errorf("%w: %v", ErrForbiddenCallbackURL, err)
+	}
+	return nil
+}
+
+// Payload is the body posted to a webhook.
+type Payload struct {
+	Event      string               `json:"event"`
+	DeliveryID string               `json:"delivery_id"`
+	Job        processor.JobSummary `json:"job"`
+}
+
+// Dispatcher delivers the webhooks of jobs and manages the endpoints of
+// tenants.
+type Dispatcher struct {
+	cfg      *config.Config
+	postgres *storage.PostgresClient
+	http     *http.Client
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewDispatcher(cfg *config.Config, postgres *storage.PostgresClient) *Dispatcher {
+	return &Dispatcher{
+		cfg:      cfg,
+		postgres: postgres,
+		http:     &http.Client{Timeout: cfg.WebhookTimeout, Transport: netguard.Transport()},
+		done:     make(chan struct{}),
+	}
+}
+
+// JobFinished queues deliveries of a finished job to its callback URL and
+// to the endpoints of its tenant subscribed to its status.
+func (d *Dispatcher) JobFinished(ctx context.Context, job *processor.ProcessingJob) error {
+	event := "job." + job.Status
+
+	var errs []error
+	if job.CallbackURL != "" {
+		if d.cfg.WebhookSecret == "" {
+			log.Printf("Not posting job %s to its callback URL: WEBHOOK_SECRET is not set", job.ID)
+		} else if err := d.enqueue(ctx, job, event, "", job.CallbackURL); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := d.notifyEndpoints(ctx, job, event); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// JobRetrying queues deliveries of a retrying job to the endpoints of its
+// tenant subscribed to retries.
+func (d *Dispatcher) JobRetrying(ctx context.Context, job *processor.ProcessingJob) error {
+	return d.notifyEndpoints(ctx, job, EventJobRetrying)
+}
+
+func (d *Dispatcher) notifyEndpoints(ctx context.Context, job *processor.ProcessingJob, event string) error {
+	if job.TenantID == "" {
+		return nil
+	}
+	endpoints, err := d.subscribed(ctx, job.TenantID, event)
+	if err != nil {
+		return fmt.Errorf("failed to look up the webhook endpoints of tenant %s: %w", job.TenantID, err)
+	}
+
+	var errs []error
+	for _, endpoint := range endpoints {
+		if err := d.enqueue(ctx, job, event, endpoint.ID, endpoint.URL); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// enqueue logs a pending delivery of the job's summary, due now.
+func (d *Dispatcher) enqueue(ctx context.Context, job *processor.ProcessingJob, event, endpointID, target string) error {
+	delivery := newDelivery(job.ID, job.TenantID, endpointID, target, event)
+	payload, err := json.Marshal(Payload{Event: event, DeliveryID: delivery.ID, Job: processor.SummarizeJob(job)})
+	if err != nil {
+		return err
+	}
+	delivery.Payload = payload
+
+	if err := d.createDelivery(ctx, delivery); err != nil {
+		return fmt.Errorf("failed to queue %s webhook of job %s: %w", event, job.ID, err)
+	}
+	return nil
+}
+
+// Start delivers due webhooks until Stop is called.
+func (d *Dispatcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	go func() {
+		defer close(d.done)
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		purge := time.NewTicker(purgeInterval)
+		defer purge.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				d.deliverDue(ctx)
+			case <-purge.C:
+				d.purge(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	log.Println("Webhook dispatcher started")
+}
+
+// Stop stops delivering, after the deliveries under way.
+func (d *Dispatcher) Stop() {
+	if d.cancel == nil {
+		return
+	}
+	d.cancel()
+	<-d.done
+	log.Println("Webhook dispatcher stopped")
+}
+
+// deliverDue takes the due deliveries, each by one replica only, and sends
+// them.
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	// A replica that dies while sending leaves its deliveries to be taken
+	// again once their lease runs out
+	lease := time.Now().Add(d.cfg.WebhookTimeout + time.Minute)
+	deliveries, err := d.takeDue(ctx, lease, batchSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Failed to read due webhooks: %v", err)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery *Delivery) {
+			defer wg.Done()
+			d.attempt(context.WithoutCancel(ctx), delivery)
+		}(delivery)
+	}
+	wg.Wait()
+}
+
+// attempt sends a delivery once and logs the outcome, scheduling the next
+// attempt after a failure while attempts are left.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *Delivery) {
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+
+	secrets, err := d.signingSecrets(ctx, delivery)
+	if err == nil {
+		delivery.StatusCode, err = d.send(ctx, delivery, secrets)
+	}
+	switch {
+	case err == nil:
+		delivery.Status = StatusDelivered
+		delivery.Error = ""
+		delivery.NextAttemptAt = nil
+	case errors.Is(err, errUndeliverable) || delivery.Attempts >= d.cfg.WebhookMaxAttempts:
+		delivery.Status = StatusFailed
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = nil
+		log.Printf("Webhook %s of job %s to %s failed for good after %d attempts: %v",
+			delivery.Event, delivery.JobID, delivery.URL, delivery.Attempts, err)
+	default:
+		delivery.Error = err.Error()
+		next := now.Add(d.backoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+	}
+
+	if err := d.recordAttempt(ctx, delivery); err != nil {
+		log.Printf("Failed to record webhook delivery %s: %v", delivery.ID, err)
+	}
+}
+
+// errUndeliverable fails a delivery without further attempts.
+var errUndeliverable = errors.New("undeliverable")
+
+// signingSecrets returns the secrets a delivery is signed with: those of
+// its endpoint, or WEBHOOK_SECRET for job callback URLs.
+func (d *Dispatcher) signingSecrets(ctx context.Context, delivery *Delivery) ([]string, error) {
+	if delivery.EndpointID == "" {
+		if d.cfg.WebhookSecret == "" {
+			return nil, fmt.Errorf("%w: WEBHOOK_SECRET is not set", errUndeliverable)
+		}
+		return []string{d.cfg.WebhookSecret}, nil
+	}
+
+	endpoint, err := d.endpoint(ctx, delivery.TenantID, delivery.EndpointID)
+	if errors.Is(err, ErrEndpointNotFound) {
+		return nil, fmt.Errorf("%w: endpoint was deleted", errUndeliverable)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !endpoint.Enabled {
+		return nil, fmt.Errorf("%w: endpoint is disabled", errUndeliverable)
+	}
+	return endpoint.secrets(), nil
+}
+
+// backoff is the wait after the attempt-th failed attempt.
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay, limit := d.cfg.WebhookBackoff, d.cfg.WebhookMaxBackoff
+	for i := 1; i < attempt && delay < limit; i++ {
+		delay *= 2
+	}
+	if limit > 0 && delay > limit {
+		delay = limit
+	}
+	return delay
+}
+
+// send posts the payload, returning the response status.
+func (d *Dispatcher) send(ctx context.Context, delivery *Delivery, secrets []string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", d.cfg.ServiceName)
+	req.Header.Set("X-Cotai-Event", delivery.Event)
+	req.Header.Set("X-Cotai-Delivery", delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(time.Now(), delivery.Payload, secrets...))
+
+	resp, err := d.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature header value of a body sent at t, with a
+// digest per secret.
+func Sign(t time.Time, body []byte, secrets ...string) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	parts := []string{"t=" + timestamp}
+	for _, secret := range secrets {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp))
+		mac.Write([]byte("."))
+		mac.Write(body)
+		parts = append(parts, "v1="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	return strings.Join(parts, ",")
+}
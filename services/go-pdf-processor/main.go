@@ -10,10 +10,27 @@ import (
 	"time"
 
 	"cotai-pdf-processor/internal/api"
+	"cotai-pdf-processor/internal/auth"
+	"cotai-pdf-processor/internal/classify"
 	"cotai-pdf-processor/internal/config"
+	"cotai-pdf-processor/internal/download"
+	"cotai-pdf-processor/internal/enrichment"
+	"cotai-pdf-processor/internal/intake"
+	"cotai-pdf-processor/internal/intake/amqp"
+	"cotai-pdf-processor/internal/intake/kafka"
+	"cotai-pdf-processor/internal/intake/nats"
+	"cotai-pdf-processor/internal/ner"
+	"cotai-pdf-processor/internal/ocr"
 	"cotai-pdf-processor/internal/processor"
+	"cotai-pdf-processor/internal/queue"
+	"cotai-pdf-processor/internal/ratelimit"
+	"cotai-pdf-processor/internal/report"
+	"cotai-pdf-processor/internal/risk"
+	"cotai-pdf-processor/internal/signature"
+	"cotai-pdf-processor/internal/spellcheck"
 	"cotai-pdf-processor/internal/storage"
 	"cotai-pdf-processor/internal/telemetry"
+	"cotai-pdf-processor/internal/webhook"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,6 +38,9 @@ import (
 func main() {
 	// Load configuration
 	cfg := config.Load()
+	if cfg.AuthJWKSURL == "" && !cfg.AuthDisabled {
+		log.Fatalf("AUTH_JWKS_URL is required; set AUTH_DISABLED=true to run without authentication")
+	}
 
 	// Initialize telemetry
 	tracer, err := telemetry.InitTracer(cfg.ServiceName)
@@ -35,17 +55,142 @@ func main() {
 	postgres := storage.NewPostgresClient(cfg.DatabaseURL)
 	defer postgres.Close()
 
+	// Initialize document downloader
+	downloader, err := download.NewDownloader(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize downloader: %v", err)
+	}
+
+	// Initialize company registry client for CNPJ enrichment
+	companies := enrichment.NewCNPJClient(cfg, redis)
+
+	// Initialize named-entity recognition sidecars
+	recognizers := ner.NewRegistry(cfg)
+
+	// Initialize OCR engines; cloud engines are added when configured
+	ocrEngines := ocr.NewRegistry(cfg)
+
+	// Initialize the dictionary OCR text is spell-corrected against
+	dictionary := spellcheck.NewDictionary(cfg)
+
+	// Initialize document type classifier
+	classifier := classify.NewClassifier(cfg)
+
+	// Initialize risk rule engine
+	riskRules := risk.NewEngine(context.Background(), risk.NewSource(cfg.RiskRulesSource, postgres))
+	riskRules.StartReload(context.Background(), cfg.RiskRulesReloadInterval)
+
+	// Rules kept in Postgres can be managed through the API
+	var riskRuleStore *risk.Store
+	if cfg.RiskRulesSource == "postgres" {
+		riskRuleStore = risk.NewStore(postgres, riskRules)
+	}
+
+	// Initialize report storage; jobs asking for a report get none without it
+	reports, err := report.NewPublisher(cfg)
+	if err != nil {
+		log.Printf("Report generation disabled: %v", err)
+	}
+
+	// Initialize attachment storage; embedded files are not extracted without it
+	attachments, err := storage.NewObjectStore(storage.ObjectStoreConfig{
+		Endpoint:  cfg.ObjectStoreEndpoint,
+		Region:    cfg.ObjectStoreRegion,
+		AccessKey: cfg.ObjectStoreAccessKey,
+		SecretKey: cfg.ObjectStoreSecretKey,
+		UseSSL:    cfg.ObjectStoreUseSSL,
+	})
+	if err != nil {
+		log.Printf("Attachment extraction disabled: %v", err)
+	}
+
+	// Initialize signature verification; signed PDFs go unverified without it
+	signatures, err := signature.NewVerifier(cfg)
+	if err != nil {
+		log.Printf("Signature verification disabled: %v", err)
+	}
+
+	// Connect to NATS JetStream when configured; finished jobs are published
+	// to it and new-tender events read from it
+	var jobEvents processor.JobEvents
+	var natsClient *nats.Client
+	if cfg.NATSURL != "" {
+		natsClient, err = nats.Connect(context.Background(), cfg)
+		if err != nil {
+			log.Fatalf("Failed to connect to NATS: %v", err)
+		}
+		defer natsClient.Close()
+		jobEvents = nats.NewResultPublisher(natsClient)
+	}
+
+	// Post jobs to the webhook endpoints of their tenants and to their
+	// callback URLs
+	webhooks := webhook.NewDispatcher(cfg, postgres)
+	if jobEvents != nil {
+		jobEvents = processor.MultiJobEvents{jobEvents, webhooks}
+	} else {
+		jobEvents = webhooks
+	}
+	webhooks.Start()
+	defer webhooks.Stop()
+
+	// API keys tenants issue their programs, with the requests made with them
+	apiKeys := auth.NewKeyStore(postgres, cfg.APIKeyRotationGrace)
+	apiKeys.Start()
+	defer apiKeys.Stop()
+
 	// Initialize PDF processor
-	pdfProcessor := processor.NewPDFProcessor(redis, postgres, tracer)
+	pdfProcessor := processor.NewPDFProcessor(cfg, redis, postgres, downloader, companies, recognizers, ocrEngines, dictionary, classifier, riskRules, reports, attachments, signatures, jobEvents, tracer)
+
+	// Initialize the job queue; queued jobs survive restarts on Redis
+	jobQueue, err := queue.New(context.Background(), cfg, redis, cfg.WorkerCount*2)
+	if err != nil {
+		log.Fatalf("Failed to initialize job queue: %v", err)
+	}
 
 	// Start worker pool
-	workerPool := processor.NewWorkerPool(cfg.WorkerCount, pdfProcessor)
+	workerPool := processor.NewWorkerPool(cfg.WorkerCount, pdfProcessor, jobQueue)
 	workerPool.Start()
 	defer workerPool.Stop()
 
+	// Consume new-tender events from Kafka when brokers are configured
+	if cfg.KafkaBrokers != "" {
+		consumer, err := kafka.NewConsumer(cfg, intake.NewSubmitter(cfg, pdfProcessor, workerPool))
+		if err != nil {
+			log.Fatalf("Failed to initialize Kafka intake: %v", err)
+		}
+		consumer.Start()
+		defer consumer.Stop()
+	}
+
+	// Consume new-tender events from NATS JetStream when connected
+	if natsClient != nil && cfg.NATSIntakeSubject != "" {
+		consumer, err := nats.NewConsumer(context.Background(), natsClient, intake.NewSubmitter(cfg, pdfProcessor, workerPool))
+		if err != nil {
+			log.Fatalf("Failed to initialize NATS intake: %v", err)
+		}
+		if err := consumer.Start(); err != nil {
+			log.Fatalf("Failed to start NATS intake: %v", err)
+		}
+		defer consumer.Stop()
+	}
+
+	// Consume new-tender events from RabbitMQ when a broker is configured
+	if cfg.AMQPURL != "" {
+		consumer, err := amqp.NewConsumer(cfg, pdfProcessor, intake.NewSubmitter(cfg, pdfProcessor, workerPool))
+		if err != nil {
+			log.Fatalf("Failed to initialize AMQP intake: %v", err)
+		}
+		consumer.Start()
+		defer consumer.Stop()
+	}
+
 	// Setup HTTP server
 	router := gin.Default()
-	api.SetupRoutes(router, pdfProcessor, workerPool)
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	api.SetupRoutes(router, cfg, pdfProcessor, workerPool, riskRuleStore, webhooks, apiKeys, ratelimit.New(redis))
 
 	server := &http.Server{
 		Addr:    ":" + cfg.Port,
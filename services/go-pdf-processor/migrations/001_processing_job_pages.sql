@@ -0,0 +1,9 @@
+-- Text of the pages of streamed documents (see processor/stream.go), kept
+-- as each page is extracted while the job's result only holds a preview.
+
+CREATE TABLE IF NOT EXISTS processing_job_pages (
+    job_id      TEXT NOT NULL,
+    page_number INTEGER NOT NULL,
+    text        TEXT NOT NULL,
+    PRIMARY KEY (job_id, page_number)
+);
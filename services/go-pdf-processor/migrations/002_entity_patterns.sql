@@ -0,0 +1,15 @@
+-- Tenants' own entity patterns (/api/v1/tenants/:tenant/entity-patterns),
+-- applied after the built-in ones to the documents of their jobs.
+
+CREATE TABLE IF NOT EXISTS entity_patterns (
+    id          TEXT PRIMARY KEY,
+    tenant_id   TEXT NOT NULL,
+    name        TEXT NOT NULL,
+    entity_type TEXT NOT NULL,
+    pattern     TEXT NOT NULL,
+    confidence  DOUBLE PRECISION NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS entity_patterns_tenant_idx ON entity_patterns (tenant_id, created_at);
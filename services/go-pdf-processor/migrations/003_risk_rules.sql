@@ -0,0 +1,14 @@
+-- Rules of the risk engine when RISK_RULES_SOURCE is postgres (see
+-- risk.PostgresSource). Conditions are a JSON array of risk.Condition.
+
+CREATE TABLE IF NOT EXISTS risk_rules (
+    id             TEXT PRIMARY KEY,
+    name           TEXT NOT NULL,
+    category       TEXT NOT NULL,
+    severity       TEXT NOT NULL,
+    impact         TEXT NOT NULL DEFAULT '',
+    weight         DOUBLE PRECISION NOT NULL DEFAULT 0,
+    conditions     JSONB NOT NULL,
+    recommendation TEXT NOT NULL DEFAULT '',
+    enabled        BOOLEAN NOT NULL DEFAULT true
+);
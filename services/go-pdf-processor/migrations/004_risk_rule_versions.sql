@@ -0,0 +1,21 @@
+-- Risk rules managed through /api/v1/risk-rules: each belongs to a tenant,
+-- "" being the global scope, and is updated at its version. Every saved
+-- version is kept as a JSON snapshot of the rule, outliving its deletion.
+
+ALTER TABLE risk_rules
+    ADD COLUMN IF NOT EXISTS tenant_id  TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS version    INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS risk_rules_tenant_idx ON risk_rules (tenant_id, name);
+
+CREATE TABLE IF NOT EXISTS risk_rule_versions (
+    rule_id    TEXT NOT NULL,
+    tenant_id  TEXT NOT NULL,
+    version    INTEGER NOT NULL,
+    rule       JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (rule_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS risk_rule_versions_tenant_idx ON risk_rule_versions (tenant_id, rule_id, version DESC);
@@ -0,0 +1,15 @@
+-- Tenants' risk weighting profiles (/api/v1/tenants/:tenant/risk-profiles),
+-- chosen by name in a job's risk_profile option. Categories and rules map
+-- category names and rule IDs to weight multipliers.
+
+CREATE TABLE IF NOT EXISTS risk_profiles (
+    id         TEXT PRIMARY KEY,
+    tenant_id  TEXT NOT NULL,
+    name       TEXT NOT NULL,
+    categories JSONB NOT NULL DEFAULT '{}',
+    rules      JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS risk_profiles_tenant_idx ON risk_profiles (tenant_id, name);
@@ -0,0 +1,19 @@
+-- Tenants' interest profiles (/api/v1/tenants/:tenant/interest-profiles),
+-- which jobs naming one in their interest_profile_id are scored against.
+-- Keywords, CNAE codes and regions are JSON arrays of strings; a zero
+-- value bound leaves that end open.
+
+CREATE TABLE IF NOT EXISTS interest_profiles (
+    id              TEXT PRIMARY KEY,
+    tenant_id       TEXT NOT NULL,
+    name            TEXT NOT NULL,
+    keywords        JSONB NOT NULL DEFAULT '[]',
+    cnae_codes      JSONB NOT NULL DEFAULT '[]',
+    regions         JSONB NOT NULL DEFAULT '[]',
+    min_value_cents BIGINT NOT NULL DEFAULT 0,
+    max_value_cents BIGINT NOT NULL DEFAULT 0,
+    created_at      TIMESTAMPTZ NOT NULL,
+    updated_at      TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS interest_profiles_tenant_idx ON interest_profiles (tenant_id, name);
@@ -0,0 +1,14 @@
+-- Tenants' processing profiles (/api/v1/tenants/:tenant/processing-profiles):
+-- named sets of processing options, as JSON, chosen by a job's profile. A
+-- tenant's profile takes precedence over a built-in one of the same name.
+
+CREATE TABLE IF NOT EXISTS processing_profiles (
+    id         TEXT PRIMARY KEY,
+    tenant_id  TEXT NOT NULL,
+    name       TEXT NOT NULL,
+    options    JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS processing_profiles_tenant_idx ON processing_profiles (tenant_id, name);
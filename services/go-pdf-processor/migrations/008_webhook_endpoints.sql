@@ -0,0 +1,20 @@
+-- Tenants' webhook endpoints (/api/v1/tenants/:tenant/webhooks), posted the
+-- job events they subscribe to. A rotated secret's predecessor still signs
+-- deliveries until previous_secret_expires_at.
+
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id                         TEXT PRIMARY KEY,
+    tenant_id                  TEXT NOT NULL,
+    url                        TEXT NOT NULL,
+    description                TEXT NOT NULL DEFAULT '',
+    events                     TEXT[] NOT NULL,
+    enabled                    BOOLEAN NOT NULL DEFAULT true,
+    secret                     TEXT NOT NULL,
+    previous_secret            TEXT NOT NULL DEFAULT '',
+    previous_secret_expires_at TIMESTAMPTZ,
+    secret_rotated_at          TIMESTAMPTZ,
+    created_at                 TIMESTAMPTZ NOT NULL,
+    updated_at                 TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS webhook_endpoints_tenant_idx ON webhook_endpoints (tenant_id, created_at);
@@ -0,0 +1,27 @@
+-- Webhook calls of jobs and their outcome, to endpoints and to job
+-- callback URLs (which have no endpoint_id). Pending deliveries are retried
+-- at next_attempt_at by whichever replica takes them first; finished ones
+-- are purged after WEBHOOK_RETENTION.
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              TEXT PRIMARY KEY,
+    endpoint_id     TEXT NOT NULL DEFAULT '',
+    tenant_id       TEXT NOT NULL DEFAULT '',
+    job_id          TEXT NOT NULL,
+    url             TEXT NOT NULL,
+    event           TEXT NOT NULL,
+    status          TEXT NOT NULL,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    status_code     INTEGER NOT NULL DEFAULT 0,
+    error           TEXT NOT NULL DEFAULT '',
+    payload         JSONB NOT NULL,
+    redelivery_of   TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL,
+    last_attempt_at TIMESTAMPTZ,
+    next_attempt_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_job_idx ON webhook_deliveries (job_id, created_at);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_endpoint_idx ON webhook_deliveries (tenant_id, endpoint_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_created_at_idx ON webhook_deliveries (created_at);
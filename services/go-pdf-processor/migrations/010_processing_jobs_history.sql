@@ -0,0 +1,31 @@
+-- History of the PDF processor's jobs, browsed through GET /api/v1/jobs.
+-- Rows are upserted on every status change; result is set on completion.
+
+CREATE TABLE IF NOT EXISTS processing_jobs (
+    id           TEXT PRIMARY KEY,
+    tender_id    TEXT NOT NULL DEFAULT '',
+    user_id      TEXT NOT NULL DEFAULT '',
+    status       TEXT NOT NULL,
+    result       JSONB,
+    created_at   TIMESTAMPTZ NOT NULL,
+    completed_at TIMESTAMPTZ
+);
+
+ALTER TABLE processing_jobs
+    ADD COLUMN IF NOT EXISTS parent_id  TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS tenant_id  TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS priority   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS filename   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS error      TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS error_code TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS attempts   INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS started_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- Every filter is listed latest first by default
+CREATE INDEX IF NOT EXISTS processing_jobs_created_at_idx ON processing_jobs (created_at DESC, id);
+CREATE INDEX IF NOT EXISTS processing_jobs_status_idx ON processing_jobs (status, created_at DESC);
+CREATE INDEX IF NOT EXISTS processing_jobs_tender_idx ON processing_jobs (tender_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS processing_jobs_tenant_idx ON processing_jobs (tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS processing_jobs_user_idx ON processing_jobs (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS processing_jobs_completed_at_idx ON processing_jobs (completed_at DESC NULLS LAST, id);
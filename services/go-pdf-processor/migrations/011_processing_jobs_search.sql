@@ -0,0 +1,8 @@
+-- Text search of the job history's error messages and file names
+-- (GET /api/v1/jobs/search?q=), and lookups by error code.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS processing_jobs_error_trgm_idx ON processing_jobs USING gin (error gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS processing_jobs_filename_trgm_idx ON processing_jobs USING gin (filename gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS processing_jobs_error_code_idx ON processing_jobs (error_code, created_at DESC) WHERE error_code <> '';
@@ -0,0 +1,42 @@
+-- Reprocessing policies re-run the analysis of completed documents on a
+-- cron schedule (/api/v1/tenants/:tenant/reprocessing-policies). The job
+-- history keeps where each document came from to fetch it again.
+
+ALTER TABLE processing_jobs
+    ADD COLUMN IF NOT EXISTS file_url TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS processing_jobs_content_idx
+    ON processing_jobs (tenant_id, (result->>'content_sha256'), created_at DESC)
+    WHERE status = 'completed';
+
+CREATE TABLE IF NOT EXISTS reprocessing_policies (
+    id          TEXT PRIMARY KEY,
+    tenant_id   TEXT NOT NULL,
+    name        TEXT NOT NULL,
+    schedule    TEXT NOT NULL,
+    timezone    TEXT NOT NULL DEFAULT '',
+    filter      JSONB NOT NULL DEFAULT '{}',
+    profile     TEXT NOT NULL DEFAULT '',
+    options     JSONB,
+    priority    TEXT NOT NULL DEFAULT '',
+    enabled     BOOLEAN NOT NULL DEFAULT true,
+    next_run_at TIMESTAMPTZ,
+    last_run    JSONB,
+    created_at  TIMESTAMPTZ NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL,
+    UNIQUE (tenant_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS reprocessing_policies_due_idx ON reprocessing_policies (next_run_at) WHERE enabled;
+
+-- The documents each policy reprocessed, by content, and the fingerprint of
+-- the options and risk rules they were reprocessed with: a document is
+-- reprocessed again only once either changed.
+CREATE TABLE IF NOT EXISTS reprocessed_documents (
+    policy_id      TEXT NOT NULL REFERENCES reprocessing_policies (id) ON DELETE CASCADE,
+    content_sha256 TEXT NOT NULL,
+    fingerprint    TEXT NOT NULL,
+    job_id         TEXT NOT NULL,
+    reprocessed_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (policy_id, content_sha256)
+);
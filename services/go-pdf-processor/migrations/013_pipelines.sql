@@ -0,0 +1,14 @@
+-- Pipelines are a tenant's named, ordered processing stages with options of
+-- their own (/api/v1/tenants/:tenant/pipelines), selected per job with the
+-- "pipeline" option.
+
+CREATE TABLE IF NOT EXISTS pipelines (
+    id          TEXT PRIMARY KEY,
+    tenant_id   TEXT NOT NULL,
+    name        TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    stages      JSONB NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL,
+    UNIQUE (tenant_id, name)
+);
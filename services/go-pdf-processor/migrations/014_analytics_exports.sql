@@ -0,0 +1,27 @@
+-- Exports of completed results as Parquet for the data warehouse
+-- (/api/v1/admin/analytics-exports). Each covers the jobs completed in
+-- [range_start, range_end); incremental exports go on from the end of the
+-- last that completed.
+
+CREATE TABLE IF NOT EXISTS analytics_exports (
+    id            TEXT PRIMARY KEY,
+    trigger       TEXT NOT NULL,
+    incremental   BOOLEAN NOT NULL,
+    scheduled_for TIMESTAMPTZ UNIQUE,
+    status        TEXT NOT NULL,
+    range_start   TIMESTAMPTZ NOT NULL,
+    range_end     TIMESTAMPTZ NOT NULL,
+    documents     BIGINT NOT NULL DEFAULT 0,
+    entities      BIGINT NOT NULL DEFAULT 0,
+    risks         BIGINT NOT NULL DEFAULT 0,
+    files         JSONB NOT NULL DEFAULT '[]',
+    error         TEXT NOT NULL DEFAULT '',
+    started_at    TIMESTAMPTZ NOT NULL,
+    finished_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS analytics_exports_started_at_idx ON analytics_exports (started_at DESC);
+
+-- One incremental export runs at a time, so their ranges do not overlap
+CREATE UNIQUE INDEX IF NOT EXISTS analytics_exports_running_idx ON analytics_exports ((true))
+    WHERE incremental AND status = 'running';
@@ -0,0 +1,32 @@
+-- API keys of machine callers (/api/v1/tenants/:tenant/api-keys), kept as
+-- SHA-256 digests of the keys. A rotated key stays valid until
+-- previous_key_expires_at; revoked keys are kept for their usage.
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id                      TEXT PRIMARY KEY,
+    tenant_id               TEXT NOT NULL,
+    name                    TEXT NOT NULL,
+    prefix                  TEXT NOT NULL,
+    key_hash                TEXT NOT NULL UNIQUE,
+    previous_key_hash       TEXT,
+    previous_key_expires_at TIMESTAMPTZ,
+    scopes                  TEXT[] NOT NULL,
+    created_by              TEXT NOT NULL DEFAULT '',
+    expires_at              TIMESTAMPTZ,
+    rotated_at              TIMESTAMPTZ,
+    revoked_at              TIMESTAMPTZ,
+    last_used_at            TIMESTAMPTZ,
+    created_at              TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_tenant_idx ON api_keys (tenant_id, created_at);
+CREATE INDEX IF NOT EXISTS api_keys_previous_idx ON api_keys (previous_key_hash) WHERE previous_key_hash IS NOT NULL;
+
+-- Requests made with each key by day, submissions among them
+CREATE TABLE IF NOT EXISTS api_key_usage (
+    key_id      TEXT NOT NULL REFERENCES api_keys (id) ON DELETE CASCADE,
+    day         DATE NOT NULL,
+    requests    BIGINT NOT NULL DEFAULT 0,
+    submissions BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (key_id, day)
+);
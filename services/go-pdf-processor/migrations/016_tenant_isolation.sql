@@ -0,0 +1,20 @@
+-- Rows of processing_jobs, which hold the documents extracted, are only
+-- visible to the queries of their tenant when the service sets
+-- app.tenant_id for requests of one (see storage.WithTenant); those of its
+-- own work, which do not set it, see them all. FORCE applies the policy to
+-- the table's owner too, the role the service connects as.
+
+ALTER TABLE processing_jobs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE processing_jobs FORCE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS processing_jobs_tenant_isolation ON processing_jobs;
+CREATE POLICY processing_jobs_tenant_isolation ON processing_jobs
+    USING (coalesce(current_setting('app.tenant_id', true), '') IN ('', tenant_id));
+
+-- Pages of streamed documents are visible along with their jobs
+ALTER TABLE processing_job_pages ENABLE ROW LEVEL SECURITY;
+ALTER TABLE processing_job_pages FORCE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS processing_job_pages_tenant_isolation ON processing_job_pages;
+CREATE POLICY processing_job_pages_tenant_isolation ON processing_job_pages
+    USING (EXISTS (SELECT 1 FROM processing_jobs j WHERE j.id = processing_job_pages.job_id));
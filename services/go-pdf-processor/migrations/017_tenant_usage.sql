@@ -0,0 +1,24 @@
+-- What tenants processed: one row per completed job, metered once, summed
+-- by month for quotas and billing (/api/v1/tenants/:tenant/usage).
+
+CREATE TABLE IF NOT EXISTS metered_jobs (
+    job_id        TEXT PRIMARY KEY,
+    tenant_id     TEXT NOT NULL,
+    month         DATE NOT NULL,
+    pages         BIGINT NOT NULL,
+    ocr_pages     BIGINT NOT NULL,
+    storage_bytes BIGINT NOT NULL,
+    metered_at    TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS metered_jobs_tenant_idx ON metered_jobs (tenant_id, month);
+
+-- Monthly quotas of tenants, replacing the service's defaults; NULL keeps
+-- the default and zero does not limit
+CREATE TABLE IF NOT EXISTS tenant_quotas (
+    tenant_id     TEXT PRIMARY KEY,
+    pages         BIGINT,
+    ocr_pages     BIGINT,
+    storage_bytes BIGINT,
+    updated_at    TIMESTAMPTZ NOT NULL
+);
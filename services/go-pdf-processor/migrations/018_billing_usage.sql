@@ -0,0 +1,36 @@
+-- Usage records for billing: the metered jobs, with the tender and user
+-- they were submitted for and the time they took, so processing costs can
+-- be attributed per tenant and tender.
+
+ALTER TABLE metered_jobs
+    ADD COLUMN IF NOT EXISTS tender_id          TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS user_id            TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS ocr_seconds        DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS processing_seconds DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS metered_jobs_metered_at_idx ON metered_jobs (metered_at);
+
+-- Exports of usage records as CSV (/api/v1/admin/billing-exports). Each
+-- covers the jobs metered in [range_start, range_end); incremental exports
+-- go on from the end of the last that completed.
+CREATE TABLE IF NOT EXISTS billing_exports (
+    id            TEXT PRIMARY KEY,
+    trigger       TEXT NOT NULL,
+    incremental   BOOLEAN NOT NULL,
+    scheduled_for TIMESTAMPTZ UNIQUE,
+    status        TEXT NOT NULL,
+    range_start   TIMESTAMPTZ NOT NULL,
+    range_end     TIMESTAMPTZ NOT NULL,
+    records       BIGINT NOT NULL DEFAULT 0,
+    file          TEXT NOT NULL DEFAULT '',
+    delivered     BOOLEAN NOT NULL DEFAULT false,
+    error         TEXT NOT NULL DEFAULT '',
+    started_at    TIMESTAMPTZ NOT NULL,
+    finished_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS billing_exports_started_at_idx ON billing_exports (started_at DESC);
+
+-- One incremental export runs at a time, so their ranges do not overlap
+CREATE UNIQUE INDEX IF NOT EXISTS billing_exports_running_idx ON billing_exports ((true))
+    WHERE incremental AND status = 'running';
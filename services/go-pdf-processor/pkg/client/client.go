@@ -0,0 +1,188 @@
+// Package client calls the PDF processor API. Its methods and types are
+// generated from the API's OpenAPI document, served at
+// /api/v1/openapi.json; run go generate after changing the API. This file
+// holds what the generated methods share.
+package client
+
+//go:generate go run ./gen -o client_gen.go -ts typescript/client.ts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+)
+
+// maxErrorBody bounds the error responses read.
+const maxErrorBody = 64 << 10
+
+// Client calls the API of a PDF processor.
+type Client struct {
+	baseURL string
+	http    *http.Client
+
+	// Header is sent with every request, credentials for instance.
+	Header http.Header
+}
+
+// New returns a client of the PDF processor at baseURL, such as
+// http://pdf-processor:8080, sending its requests with httpClient, or
+// http.DefaultClient when nil.
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    httpClient,
+		Header:  make(http.Header),
+	}
+}
+
+// APIError is an error the API responded with.
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Message    string `json:"error"`
+	Code       string `json:"code,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("pdf processor: %d %s (%s)", e.StatusCode, e.Message, e.Code)
+	}
+	return fmt.Sprintf("pdf processor: %d %s", e.StatusCode, e.Message)
+}
+
+// File is a document to upload. ContentType may be left empty for the
+// type to be taken from Name.
+type File struct {
+	Name        string
+	ContentType string
+	Content     io.Reader
+}
+
+// do sends in as JSON, when not nil, and decodes the response into out,
+// when not nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	resp, err := c.send(ctx, method, path, query, body, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decode(resp, out)
+}
+
+// stream returns the body of the response, for the caller to close.
+func (c *Client) stream(ctx context.Context, method, path string, query url.Values) (io.ReadCloser, error) {
+	resp, err := c.send(ctx, method, path, query, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// upload posts a file and form fields as multipart/form-data, streaming
+// the file.
+func (c *Client) upload(ctx context.Context, path string, file File, fields map[string]string, out interface{}) error {
+	reader, writer := io.Pipe()
+	defer reader.Close()
+	form := multipart.NewWriter(writer)
+	go func() {
+		writer.CloseWithError(writeForm(form, file, fields))
+	}()
+
+	resp, err := c.send(ctx, http.MethodPost, path, nil, reader, form.FormDataContentType())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decode(resp, out)
+}
+
+func writeForm(form *multipart.Writer, file File, fields map[string]string) error {
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			return err
+		}
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, file.Name))
+	if file.ContentType != "" {
+		header.Set("Content-Type", file.ContentType)
+	}
+	part, err := form.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, file.Content); err != nil {
+		return err
+	}
+	return form.Close()
+}
+
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range c.Header {
+		req.Header[name] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, readError(resp)
+	}
+	return resp, nil
+}
+
+func decode(resp *http.Response, out interface{}) error {
+	if out == nil {
+		_, err := io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("pdf processor: invalid response: %w", err)
+	}
+	return nil
+}
+
+func readError(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if err == nil && json.Unmarshal(data, apiErr) == nil && apiErr.Message != "" {
+		return apiErr
+	}
+	apiErr.Message = strings.TrimSpace(string(data))
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}
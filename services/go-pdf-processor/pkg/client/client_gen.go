@@ -0,0 +1,2334 @@
+// Code generated by gen from the OpenAPI document of the API. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ListAnalyticsExports calls GET /api/v1/admin/analytics-exports: the latest exports of results to the data warehouse.
+func (c *Client) ListAnalyticsExports(ctx context.Context, params *ListAnalyticsExportsParams) (*ListAnalyticsExportsResponse, error) {
+	var out ListAnalyticsExportsResponse
+	if err := c.do(ctx, "GET", "/api/v1/admin/analytics-exports", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StartAnalyticsExport calls POST /api/v1/admin/analytics-exports: export results as Parquet, those completed since the last export or in a range.
+func (c *Client) StartAnalyticsExport(ctx context.Context, body *AnalyticsExportRequest) (*AnalyticsExport, error) {
+	var out AnalyticsExport
+	if err := c.do(ctx, "POST", "/api/v1/admin/analytics-exports", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAnalyticsExport calls GET /api/v1/admin/analytics-exports/{id}: an export of results to the data warehouse.
+func (c *Client) GetAnalyticsExport(ctx context.Context, id string) (*AnalyticsExport, error) {
+	var out AnalyticsExport
+	if err := c.do(ctx, "GET", "/api/v1/admin/analytics-exports/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListBillingExports calls GET /api/v1/admin/billing-exports: the latest exports of usage records for billing.
+func (c *Client) ListBillingExports(ctx context.Context, params *ListBillingExportsParams) (*ListBillingExportsResponse, error) {
+	var out ListBillingExportsResponse
+	if err := c.do(ctx, "GET", "/api/v1/admin/billing-exports", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StartBillingExport calls POST /api/v1/admin/billing-exports: export usage records as CSV, those metered since the last export or in a range.
+func (c *Client) StartBillingExport(ctx context.Context, body *BillingExportRequest) (*BillingExport, error) {
+	var out BillingExport
+	if err := c.do(ctx, "POST", "/api/v1/admin/billing-exports", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetBillingExport calls GET /api/v1/admin/billing-exports/{id}: an export of usage records for billing.
+func (c *Client) GetBillingExport(ctx context.Context, id string) (*BillingExport, error) {
+	var out BillingExport
+	if err := c.do(ctx, "GET", "/api/v1/admin/billing-exports/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListInstances calls GET /api/v1/admin/instances: the replicas sharing the job queue.
+func (c *Client) ListInstances(ctx context.Context) (*ListInstancesResponse, error) {
+	var out ListInstancesResponse
+	if err := c.do(ctx, "GET", "/api/v1/admin/instances", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTenantQuota calls GET /api/v1/admin/tenants/{tenant}/quota: the monthly quota set for a tenant.
+func (c *Client) GetTenantQuota(ctx context.Context, tenant string) (*TenantQuota, error) {
+	var out TenantQuota
+	if err := c.do(ctx, "GET", "/api/v1/admin/tenants/"+url.PathEscape(tenant)+"/quota", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetTenantQuota calls PUT /api/v1/admin/tenants/{tenant}/quota: set the monthly quota of a tenant, null fields keeping the defaults.
+func (c *Client) SetTenantQuota(ctx context.Context, tenant string, body *TenantQuota) (*TenantQuota, error) {
+	var out TenantQuota
+	if err := c.do(ctx, "PUT", "/api/v1/admin/tenants/"+url.PathEscape(tenant)+"/quota", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetWorkers calls GET /api/v1/admin/workers: statistics of the workers of this replica.
+func (c *Client) GetWorkers(ctx context.Context) (*PoolStats, error) {
+	var out PoolStats
+	if err := c.do(ctx, "GET", "/api/v1/admin/workers", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ResizeWorkers calls PATCH /api/v1/admin/workers: change the number of workers of this replica.
+func (c *Client) ResizeWorkers(ctx context.Context, body *ResizeWorkersRequest) (*PoolStats, error) {
+	var out PoolStats
+	if err := c.do(ctx, "PATCH", "/api/v1/admin/workers", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ResumeWorkers calls DELETE /api/v1/admin/workers/drain: take jobs again after draining.
+func (c *Client) ResumeWorkers(ctx context.Context) (*DrainProgress, error) {
+	var out DrainProgress
+	if err := c.do(ctx, "DELETE", "/api/v1/admin/workers/drain", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetDrain calls GET /api/v1/admin/workers/drain: progress of draining this replica.
+func (c *Client) GetDrain(ctx context.Context) (*DrainProgress, error) {
+	var out DrainProgress
+	if err := c.do(ctx, "GET", "/api/v1/admin/workers/drain", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DrainWorkers calls POST /api/v1/admin/workers/drain: stop taking jobs and finish those running.
+func (c *Client) DrainWorkers(ctx context.Context) (*DrainProgress, error) {
+	var out DrainProgress
+	if err := c.do(ctx, "POST", "/api/v1/admin/workers/drain", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListDeadLetters calls GET /api/v1/dead-letters: page through the jobs that exhausted their retries.
+func (c *Client) ListDeadLetters(ctx context.Context, params *ListDeadLettersParams) (*ListDeadLettersResponse, error) {
+	var out ListDeadLettersResponse
+	if err := c.do(ctx, "GET", "/api/v1/dead-letters", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteDeadLetter calls DELETE /api/v1/dead-letters/{id}: remove a dead-lettered job.
+func (c *Client) DeleteDeadLetter(ctx context.Context, id string) error {
+	return c.do(ctx, "DELETE", "/api/v1/dead-letters/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// GetDeadLetter calls GET /api/v1/dead-letters/{id}: a dead-lettered job.
+func (c *Client) GetDeadLetter(ctx context.Context, id string) (*ProcessingJob, error) {
+	var out ProcessingJob
+	if err := c.do(ctx, "GET", "/api/v1/dead-letters/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ReplayDeadLetter calls POST /api/v1/dead-letters/{id}/replay: queue a dead-lettered job again.
+func (c *Client) ReplayDeadLetter(ctx context.Context, id string) (*ReplayDeadLetterResponse, error) {
+	var out ReplayDeadLetterResponse
+	if err := c.do(ctx, "POST", "/api/v1/dead-letters/"+url.PathEscape(id)+"/replay", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UploadDocument calls POST /api/v1/documents: upload a document to be processed.
+func (c *Client) UploadDocument(ctx context.Context, file File, fields map[string]string) (*UploadDocumentResponse, error) {
+	var out UploadDocumentResponse
+	if err := c.upload(ctx, "/api/v1/documents", file, fields, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetGraphQL calls GET /api/v1/graphql: query jobs and results with GraphQL.
+func (c *Client) GetGraphQL(ctx context.Context, params *GetGraphQLParams) (*GetGraphQLResponse, error) {
+	var out GetGraphQLResponse
+	if err := c.do(ctx, "GET", "/api/v1/graphql", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PostGraphQL calls POST /api/v1/graphql: query jobs and results with GraphQL.
+func (c *Client) PostGraphQL(ctx context.Context, body *PostGraphQLRequest) (*PostGraphQLResponse, error) {
+	var out PostGraphQLResponse
+	if err := c.do(ctx, "POST", "/api/v1/graphql", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListJobs calls GET /api/v1/jobs: page through the job history.
+func (c *Client) ListJobs(ctx context.Context, params *ListJobsParams) (*ListJobsResponse, error) {
+	var out ListJobsResponse
+	if err := c.do(ctx, "GET", "/api/v1/jobs", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SearchJobs calls GET /api/v1/jobs/search: find the jobs of a tender or of a time range.
+func (c *Client) SearchJobs(ctx context.Context, params *SearchJobsParams) (*SearchJobsResponse, error) {
+	var out SearchJobsResponse
+	if err := c.do(ctx, "GET", "/api/v1/jobs/search", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetJobStatuses calls POST /api/v1/jobs/status: the status of many jobs at once.
+func (c *Client) GetJobStatuses(ctx context.Context, body *JobStatusRequest) (*GetJobStatusesResponse, error) {
+	var out GetJobStatusesResponse
+	if err := c.do(ctx, "POST", "/api/v1/jobs/status", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CancelJob calls DELETE /api/v1/jobs/{id}: cancel a job.
+func (c *Client) CancelJob(ctx context.Context, id string) (*CancelJobResponse, error) {
+	var out CancelJobResponse
+	if err := c.do(ctx, "DELETE", "/api/v1/jobs/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetJob calls GET /api/v1/jobs/{id}: a job, with its result once completed.
+func (c *Client) GetJob(ctx context.Context, id string) (*ProcessingJob, error) {
+	var out ProcessingJob
+	if err := c.do(ctx, "GET", "/api/v1/jobs/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetJobAnnotated calls GET /api/v1/jobs/{id}/annotated: redirect to a download URL of the job's annotated PDF.
+func (c *Client) GetJobAnnotated(ctx context.Context, id string) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/api/v1/jobs/"+url.PathEscape(id)+"/annotated", nil)
+}
+
+// GetJobArchive calls GET /api/v1/jobs/{id}/archive: redirect to a download URL of the job's archive.
+func (c *Client) GetJobArchive(ctx context.Context, id string) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/api/v1/jobs/"+url.PathEscape(id)+"/archive", nil)
+}
+
+// GetJobEvents calls GET /api/v1/jobs/{id}/events: stream the updates of a job as Server-Sent Events, or over a WebSocket.
+func (c *Client) GetJobEvents(ctx context.Context, id string) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/api/v1/jobs/"+url.PathEscape(id)+"/events", nil)
+}
+
+// ExportJob calls GET /api/v1/jobs/{id}/export/{sheet}: export the entities, risks or items of a job's result, or all of them, for spreadsheets.
+func (c *Client) ExportJob(ctx context.Context, id string, sheet string, params *ExportJobParams) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/api/v1/jobs/"+url.PathEscape(id)+"/export/"+url.PathEscape(sheet), params.values())
+}
+
+// GetJobOCRLayout calls GET /api/v1/jobs/{id}/ocr-layout: redirect to a download URL of the job's OCR layout.
+func (c *Client) GetJobOCRLayout(ctx context.Context, id string) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/api/v1/jobs/"+url.PathEscape(id)+"/ocr-layout", nil)
+}
+
+// GetPageImage calls GET /api/v1/jobs/{id}/pages/{n}/image: render a page of a job's document.
+func (c *Client) GetPageImage(ctx context.Context, id string, n int, params *GetPageImageParams) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/api/v1/jobs/"+url.PathEscape(id)+"/pages/"+strconv.Itoa(n)+"/image", params.values())
+}
+
+// GetJobRedacted calls GET /api/v1/jobs/{id}/redacted: redirect to a download URL of the job's redacted PDF.
+func (c *Client) GetJobRedacted(ctx context.Context, id string) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/api/v1/jobs/"+url.PathEscape(id)+"/redacted", nil)
+}
+
+// GetJobReport calls GET /api/v1/jobs/{id}/report: redirect to a download URL of the job's report.
+func (c *Client) GetJobReport(ctx context.Context, id string) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/api/v1/jobs/"+url.PathEscape(id)+"/report", nil)
+}
+
+// StreamJobResult calls GET /api/v1/jobs/{id}/result/stream: stream the result of a job as newline-delimited JSON records.
+func (c *Client) StreamJobResult(ctx context.Context, id string) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/api/v1/jobs/"+url.PathEscape(id)+"/result/stream", nil)
+}
+
+// GetJobSearchable calls GET /api/v1/jobs/{id}/searchable: redirect to a download URL of the job's searchable PDF.
+func (c *Client) GetJobSearchable(ctx context.Context, id string) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/api/v1/jobs/"+url.PathEscape(id)+"/searchable", nil)
+}
+
+// ListJobWebhooks calls GET /api/v1/jobs/{id}/webhooks: the webhook deliveries of a job.
+func (c *Client) ListJobWebhooks(ctx context.Context, id string) (*ListJobWebhooksResponse, error) {
+	var out ListJobWebhooksResponse
+	if err := c.do(ctx, "GET", "/api/v1/jobs/"+url.PathEscape(id)+"/webhooks", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RedeliverJobWebhook calls POST /api/v1/jobs/{id}/webhooks/{delivery}/redeliver: send a webhook delivery of a job again.
+func (c *Client) RedeliverJobWebhook(ctx context.Context, id string, delivery string) (*Delivery, error) {
+	var out Delivery
+	if err := c.do(ctx, "POST", "/api/v1/jobs/"+url.PathEscape(id)+"/webhooks/"+url.PathEscape(delivery)+"/redeliver", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetOpenAPI calls GET /api/v1/openapi.json: the OpenAPI document of the API.
+func (c *Client) GetOpenAPI(ctx context.Context) (json.RawMessage, error) {
+	var out json.RawMessage
+	if err := c.do(ctx, "GET", "/api/v1/openapi.json", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreatePresignedUpload calls POST /api/v1/presigned-uploads: create a job whose document is uploaded to a presigned URL.
+func (c *Client) CreatePresignedUpload(ctx context.Context, body *PresignRequest) (*CreatePresignedUploadResponse, error) {
+	var out CreatePresignedUploadResponse
+	if err := c.do(ctx, "POST", "/api/v1/presigned-uploads", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CompletePresignedUpload calls POST /api/v1/presigned-uploads/{id}/complete: queue the job of a finished presigned upload.
+func (c *Client) CompletePresignedUpload(ctx context.Context, id string) (*CompletePresignedUploadResponse, error) {
+	var out CompletePresignedUploadResponse
+	if err := c.do(ctx, "POST", "/api/v1/presigned-uploads/"+url.PathEscape(id)+"/complete", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ClearResultCache calls DELETE /api/v1/result-cache: remove all cached results.
+func (c *Client) ClearResultCache(ctx context.Context) (*ClearResultCacheResponse, error) {
+	var out ClearResultCacheResponse
+	if err := c.do(ctx, "DELETE", "/api/v1/result-cache", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// InvalidateCachedResults calls DELETE /api/v1/result-cache/{sha256}: remove the cached results of a document.
+func (c *Client) InvalidateCachedResults(ctx context.Context, sha256 string) (*InvalidateCachedResultsResponse, error) {
+	var out InvalidateCachedResultsResponse
+	if err := c.do(ctx, "DELETE", "/api/v1/result-cache/"+url.PathEscape(sha256), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListRiskRules calls GET /api/v1/risk-rules: the risk rules of a tenant.
+func (c *Client) ListRiskRules(ctx context.Context, params *ListRiskRulesParams) (*ListRiskRulesResponse, error) {
+	var out ListRiskRulesResponse
+	if err := c.do(ctx, "GET", "/api/v1/risk-rules", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateRiskRule calls POST /api/v1/risk-rules: add a risk rule.
+func (c *Client) CreateRiskRule(ctx context.Context, params *CreateRiskRuleParams, body *RiskRuleRequest) (*Rule, error) {
+	var out Rule
+	if err := c.do(ctx, "POST", "/api/v1/risk-rules", params.values(), body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DryRunRiskRules calls POST /api/v1/risk-rules/dry-run: evaluate risk rules against a processed job's text.
+func (c *Client) DryRunRiskRules(ctx context.Context, body *DryRunRequest) (*DryRunRiskRulesResponse, error) {
+	var out DryRunRiskRulesResponse
+	if err := c.do(ctx, "POST", "/api/v1/risk-rules/dry-run", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteRiskRule calls DELETE /api/v1/risk-rules/{id}: remove a risk rule.
+func (c *Client) DeleteRiskRule(ctx context.Context, id string, params *DeleteRiskRuleParams) error {
+	return c.do(ctx, "DELETE", "/api/v1/risk-rules/"+url.PathEscape(id), params.values(), nil, nil)
+}
+
+// GetRiskRule calls GET /api/v1/risk-rules/{id}: a risk rule.
+func (c *Client) GetRiskRule(ctx context.Context, id string, params *GetRiskRuleParams) (*Rule, error) {
+	var out Rule
+	if err := c.do(ctx, "GET", "/api/v1/risk-rules/"+url.PathEscape(id), params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateRiskRule calls PUT /api/v1/risk-rules/{id}: replace a risk rule, given the version it replaces.
+func (c *Client) UpdateRiskRule(ctx context.Context, id string, params *UpdateRiskRuleParams, body *RiskRuleRequest) (*Rule, error) {
+	var out Rule
+	if err := c.do(ctx, "PUT", "/api/v1/risk-rules/"+url.PathEscape(id), params.values(), body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListRiskRuleVersions calls GET /api/v1/risk-rules/{id}/versions: the versions of a risk rule.
+func (c *Client) ListRiskRuleVersions(ctx context.Context, id string, params *ListRiskRuleVersionsParams) (*ListRiskRuleVersionsResponse, error) {
+	var out ListRiskRuleVersionsResponse
+	if err := c.do(ctx, "GET", "/api/v1/risk-rules/"+url.PathEscape(id)+"/versions", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListAPIKeys calls GET /api/v1/tenants/{tenant}/api-keys: the API keys of a tenant, revoked ones included.
+func (c *Client) ListAPIKeys(ctx context.Context, tenant string) (*ListAPIKeysResponse, error) {
+	var out ListAPIKeysResponse
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/api-keys", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// IssueAPIKey calls POST /api/v1/tenants/{tenant}/api-keys: issue an API key, shown only in the response.
+func (c *Client) IssueAPIKey(ctx context.Context, tenant string, body *APIKeyRequest) (*APIKey, error) {
+	var out APIKey
+	if err := c.do(ctx, "POST", "/api/v1/tenants/"+url.PathEscape(tenant)+"/api-keys", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RevokeAPIKey calls DELETE /api/v1/tenants/{tenant}/api-keys/{id}: revoke an API key.
+func (c *Client) RevokeAPIKey(ctx context.Context, tenant string, id string) (*APIKey, error) {
+	var out APIKey
+	if err := c.do(ctx, "DELETE", "/api/v1/tenants/"+url.PathEscape(tenant)+"/api-keys/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAPIKey calls GET /api/v1/tenants/{tenant}/api-keys/{id}: an API key, without its secret.
+func (c *Client) GetAPIKey(ctx context.Context, tenant string, id string) (*APIKey, error) {
+	var out APIKey
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/api-keys/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RotateAPIKey calls POST /api/v1/tenants/{tenant}/api-keys/{id}/rotate: give an API key a new secret, the previous one working for a grace period.
+func (c *Client) RotateAPIKey(ctx context.Context, tenant string, id string) (*APIKey, error) {
+	var out APIKey
+	if err := c.do(ctx, "POST", "/api/v1/tenants/"+url.PathEscape(tenant)+"/api-keys/"+url.PathEscape(id)+"/rotate", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAPIKeyUsage calls GET /api/v1/tenants/{tenant}/api-keys/{id}/usage: the requests made with an API key by day.
+func (c *Client) GetAPIKeyUsage(ctx context.Context, tenant string, id string, params *GetAPIKeyUsageParams) (*GetAPIKeyUsageResponse, error) {
+	var out GetAPIKeyUsageResponse
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/api-keys/"+url.PathEscape(id)+"/usage", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListEntityPatterns calls GET /api/v1/tenants/{tenant}/entity-patterns: the entity patterns of a tenant.
+func (c *Client) ListEntityPatterns(ctx context.Context, tenant string) (*ListEntityPatternsResponse, error) {
+	var out ListEntityPatternsResponse
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/entity-patterns", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateEntityPattern calls POST /api/v1/tenants/{tenant}/entity-patterns: add an entity pattern.
+func (c *Client) CreateEntityPattern(ctx context.Context, tenant string, body *EntityPatternRequest) (*EntityPattern, error) {
+	var out EntityPattern
+	if err := c.do(ctx, "POST", "/api/v1/tenants/"+url.PathEscape(tenant)+"/entity-patterns", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteEntityPattern calls DELETE /api/v1/tenants/{tenant}/entity-patterns/{id}: remove an entity pattern.
+func (c *Client) DeleteEntityPattern(ctx context.Context, tenant string, id string) error {
+	return c.do(ctx, "DELETE", "/api/v1/tenants/"+url.PathEscape(tenant)+"/entity-patterns/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// GetEntityPattern calls GET /api/v1/tenants/{tenant}/entity-patterns/{id}: an entity pattern.
+func (c *Client) GetEntityPattern(ctx context.Context, tenant string, id string) (*EntityPattern, error) {
+	var out EntityPattern
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/entity-patterns/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateEntityPattern calls PUT /api/v1/tenants/{tenant}/entity-patterns/{id}: replace an entity pattern.
+func (c *Client) UpdateEntityPattern(ctx context.Context, tenant string, id string, body *EntityPatternRequest) (*EntityPattern, error) {
+	var out EntityPattern
+	if err := c.do(ctx, "PUT", "/api/v1/tenants/"+url.PathEscape(tenant)+"/entity-patterns/"+url.PathEscape(id), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListInterestProfiles calls GET /api/v1/tenants/{tenant}/interest-profiles: the interest profiles of a tenant.
+func (c *Client) ListInterestProfiles(ctx context.Context, tenant string) (*ListInterestProfilesResponse, error) {
+	var out ListInterestProfilesResponse
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/interest-profiles", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateInterestProfile calls POST /api/v1/tenants/{tenant}/interest-profiles: add an interest profile.
+func (c *Client) CreateInterestProfile(ctx context.Context, tenant string, body *InterestProfileRequest) (*InterestProfile, error) {
+	var out InterestProfile
+	if err := c.do(ctx, "POST", "/api/v1/tenants/"+url.PathEscape(tenant)+"/interest-profiles", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteInterestProfile calls DELETE /api/v1/tenants/{tenant}/interest-profiles/{id}: remove an interest profile.
+func (c *Client) DeleteInterestProfile(ctx context.Context, tenant string, id string) error {
+	return c.do(ctx, "DELETE", "/api/v1/tenants/"+url.PathEscape(tenant)+"/interest-profiles/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// GetInterestProfile calls GET /api/v1/tenants/{tenant}/interest-profiles/{id}: an interest profile.
+func (c *Client) GetInterestProfile(ctx context.Context, tenant string, id string) (*InterestProfile, error) {
+	var out InterestProfile
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/interest-profiles/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateInterestProfile calls PUT /api/v1/tenants/{tenant}/interest-profiles/{id}: replace an interest profile.
+func (c *Client) UpdateInterestProfile(ctx context.Context, tenant string, id string, body *InterestProfileRequest) (*InterestProfile, error) {
+	var out InterestProfile
+	if err := c.do(ctx, "PUT", "/api/v1/tenants/"+url.PathEscape(tenant)+"/interest-profiles/"+url.PathEscape(id), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListPipelines calls GET /api/v1/tenants/{tenant}/pipelines: the pipelines of a tenant, and the stages they can run.
+func (c *Client) ListPipelines(ctx context.Context, tenant string) (*ListPipelinesResponse, error) {
+	var out ListPipelinesResponse
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/pipelines", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreatePipeline calls POST /api/v1/tenants/{tenant}/pipelines: add a pipeline.
+func (c *Client) CreatePipeline(ctx context.Context, tenant string, body *PipelineRequest) (*Pipeline, error) {
+	var out Pipeline
+	if err := c.do(ctx, "POST", "/api/v1/tenants/"+url.PathEscape(tenant)+"/pipelines", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeletePipeline calls DELETE /api/v1/tenants/{tenant}/pipelines/{id}: remove a pipeline.
+func (c *Client) DeletePipeline(ctx context.Context, tenant string, id string) error {
+	return c.do(ctx, "DELETE", "/api/v1/tenants/"+url.PathEscape(tenant)+"/pipelines/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// GetPipeline calls GET /api/v1/tenants/{tenant}/pipelines/{id}: a pipeline.
+func (c *Client) GetPipeline(ctx context.Context, tenant string, id string) (*Pipeline, error) {
+	var out Pipeline
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/pipelines/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdatePipeline calls PUT /api/v1/tenants/{tenant}/pipelines/{id}: replace a pipeline.
+func (c *Client) UpdatePipeline(ctx context.Context, tenant string, id string, body *PipelineRequest) (*Pipeline, error) {
+	var out Pipeline
+	if err := c.do(ctx, "PUT", "/api/v1/tenants/"+url.PathEscape(tenant)+"/pipelines/"+url.PathEscape(id), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListProcessingProfiles calls GET /api/v1/tenants/{tenant}/processing-profiles: the processing profiles of a tenant.
+func (c *Client) ListProcessingProfiles(ctx context.Context, tenant string) (*ListProcessingProfilesResponse, error) {
+	var out ListProcessingProfilesResponse
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/processing-profiles", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateProcessingProfile calls POST /api/v1/tenants/{tenant}/processing-profiles: add a processing profile.
+func (c *Client) CreateProcessingProfile(ctx context.Context, tenant string, body *ProcessingProfileRequest) (*ProcessingProfile, error) {
+	var out ProcessingProfile
+	if err := c.do(ctx, "POST", "/api/v1/tenants/"+url.PathEscape(tenant)+"/processing-profiles", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteProcessingProfile calls DELETE /api/v1/tenants/{tenant}/processing-profiles/{id}: remove a processing profile.
+func (c *Client) DeleteProcessingProfile(ctx context.Context, tenant string, id string) error {
+	return c.do(ctx, "DELETE", "/api/v1/tenants/"+url.PathEscape(tenant)+"/processing-profiles/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// GetProcessingProfile calls GET /api/v1/tenants/{tenant}/processing-profiles/{id}: a processing profile.
+func (c *Client) GetProcessingProfile(ctx context.Context, tenant string, id string) (*ProcessingProfile, error) {
+	var out ProcessingProfile
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/processing-profiles/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateProcessingProfile calls PUT /api/v1/tenants/{tenant}/processing-profiles/{id}: replace a processing profile.
+func (c *Client) UpdateProcessingProfile(ctx context.Context, tenant string, id string, body *ProcessingProfileRequest) (*ProcessingProfile, error) {
+	var out ProcessingProfile
+	if err := c.do(ctx, "PUT", "/api/v1/tenants/"+url.PathEscape(tenant)+"/processing-profiles/"+url.PathEscape(id), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListReprocessingPolicies calls GET /api/v1/tenants/{tenant}/reprocessing-policies: the reprocessing policies of a tenant.
+func (c *Client) ListReprocessingPolicies(ctx context.Context, tenant string) (*ListReprocessingPoliciesResponse, error) {
+	var out ListReprocessingPoliciesResponse
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/reprocessing-policies", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateReprocessingPolicy calls POST /api/v1/tenants/{tenant}/reprocessing-policies: add a reprocessing policy.
+func (c *Client) CreateReprocessingPolicy(ctx context.Context, tenant string, body *ReprocessingPolicyRequest) (*ReprocessingPolicy, error) {
+	var out ReprocessingPolicy
+	if err := c.do(ctx, "POST", "/api/v1/tenants/"+url.PathEscape(tenant)+"/reprocessing-policies", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteReprocessingPolicy calls DELETE /api/v1/tenants/{tenant}/reprocessing-policies/{id}: remove a reprocessing policy.
+func (c *Client) DeleteReprocessingPolicy(ctx context.Context, tenant string, id string) error {
+	return c.do(ctx, "DELETE", "/api/v1/tenants/"+url.PathEscape(tenant)+"/reprocessing-policies/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// GetReprocessingPolicy calls GET /api/v1/tenants/{tenant}/reprocessing-policies/{id}: a reprocessing policy.
+func (c *Client) GetReprocessingPolicy(ctx context.Context, tenant string, id string) (*ReprocessingPolicy, error) {
+	var out ReprocessingPolicy
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/reprocessing-policies/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateReprocessingPolicy calls PUT /api/v1/tenants/{tenant}/reprocessing-policies/{id}: replace a reprocessing policy.
+func (c *Client) UpdateReprocessingPolicy(ctx context.Context, tenant string, id string, body *ReprocessingPolicyRequest) (*ReprocessingPolicy, error) {
+	var out ReprocessingPolicy
+	if err := c.do(ctx, "PUT", "/api/v1/tenants/"+url.PathEscape(tenant)+"/reprocessing-policies/"+url.PathEscape(id), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RunReprocessingPolicy calls POST /api/v1/tenants/{tenant}/reprocessing-policies/{id}/run: run a reprocessing policy now.
+func (c *Client) RunReprocessingPolicy(ctx context.Context, tenant string, id string) (*ReprocessingRun, error) {
+	var out ReprocessingRun
+	if err := c.do(ctx, "POST", "/api/v1/tenants/"+url.PathEscape(tenant)+"/reprocessing-policies/"+url.PathEscape(id)+"/run", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListRiskProfiles calls GET /api/v1/tenants/{tenant}/risk-profiles: the risk profiles of a tenant.
+func (c *Client) ListRiskProfiles(ctx context.Context, tenant string) (*ListRiskProfilesResponse, error) {
+	var out ListRiskProfilesResponse
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/risk-profiles", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateRiskProfile calls POST /api/v1/tenants/{tenant}/risk-profiles: add a risk profile.
+func (c *Client) CreateRiskProfile(ctx context.Context, tenant string, body *RiskProfileRequest) (*RiskProfile, error) {
+	var out RiskProfile
+	if err := c.do(ctx, "POST", "/api/v1/tenants/"+url.PathEscape(tenant)+"/risk-profiles", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteRiskProfile calls DELETE /api/v1/tenants/{tenant}/risk-profiles/{id}: remove a risk profile.
+func (c *Client) DeleteRiskProfile(ctx context.Context, tenant string, id string) error {
+	return c.do(ctx, "DELETE", "/api/v1/tenants/"+url.PathEscape(tenant)+"/risk-profiles/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// GetRiskProfile calls GET /api/v1/tenants/{tenant}/risk-profiles/{id}: a risk profile.
+func (c *Client) GetRiskProfile(ctx context.Context, tenant string, id string) (*RiskProfile, error) {
+	var out RiskProfile
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/risk-profiles/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateRiskProfile calls PUT /api/v1/tenants/{tenant}/risk-profiles/{id}: replace a risk profile.
+func (c *Client) UpdateRiskProfile(ctx context.Context, tenant string, id string, body *RiskProfileRequest) (*RiskProfile, error) {
+	var out RiskProfile
+	if err := c.do(ctx, "PUT", "/api/v1/tenants/"+url.PathEscape(tenant)+"/risk-profiles/"+url.PathEscape(id), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTenantUsage calls GET /api/v1/tenants/{tenant}/usage: what a tenant processed in a month, against its quota.
+func (c *Client) GetTenantUsage(ctx context.Context, tenant string, params *GetTenantUsageParams) (*TenantUsage, error) {
+	var out TenantUsage
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/usage", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTenderUsage calls GET /api/v1/tenants/{tenant}/usage/tenders: what a tenant processed for each tender in a month.
+func (c *Client) GetTenderUsage(ctx context.Context, tenant string, params *GetTenderUsageParams) (*GetTenderUsageResponse, error) {
+	var out GetTenderUsageResponse
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/usage/tenders", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListWebhookEndpoints calls GET /api/v1/tenants/{tenant}/webhooks: the webhook endpoints of a tenant.
+func (c *Client) ListWebhookEndpoints(ctx context.Context, tenant string) (*ListWebhookEndpointsResponse, error) {
+	var out ListWebhookEndpointsResponse
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/webhooks", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateWebhookEndpoint calls POST /api/v1/tenants/{tenant}/webhooks: add a webhook endpoint.
+func (c *Client) CreateWebhookEndpoint(ctx context.Context, tenant string, body *WebhookEndpointRequest) (*Endpoint, error) {
+	var out Endpoint
+	if err := c.do(ctx, "POST", "/api/v1/tenants/"+url.PathEscape(tenant)+"/webhooks", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteWebhookEndpoint calls DELETE /api/v1/tenants/{tenant}/webhooks/{id}: remove a webhook endpoint.
+func (c *Client) DeleteWebhookEndpoint(ctx context.Context, tenant string, id string) error {
+	return c.do(ctx, "DELETE", "/api/v1/tenants/"+url.PathEscape(tenant)+"/webhooks/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// GetWebhookEndpoint calls GET /api/v1/tenants/{tenant}/webhooks/{id}: a webhook endpoint.
+func (c *Client) GetWebhookEndpoint(ctx context.Context, tenant string, id string) (*Endpoint, error) {
+	var out Endpoint
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/webhooks/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateWebhookEndpoint calls PUT /api/v1/tenants/{tenant}/webhooks/{id}: replace a webhook endpoint.
+func (c *Client) UpdateWebhookEndpoint(ctx context.Context, tenant string, id string, body *WebhookEndpointRequest) (*Endpoint, error) {
+	var out Endpoint
+	if err := c.do(ctx, "PUT", "/api/v1/tenants/"+url.PathEscape(tenant)+"/webhooks/"+url.PathEscape(id), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListWebhookDeliveries calls GET /api/v1/tenants/{tenant}/webhooks/{id}/deliveries: page through the deliveries of a webhook endpoint.
+func (c *Client) ListWebhookDeliveries(ctx context.Context, tenant string, id string, params *ListWebhookDeliveriesParams) (*ListWebhookDeliveriesResponse, error) {
+	var out ListWebhookDeliveriesResponse
+	if err := c.do(ctx, "GET", "/api/v1/tenants/"+url.PathEscape(tenant)+"/webhooks/"+url.PathEscape(id)+"/deliveries", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RedeliverWebhook calls POST /api/v1/tenants/{tenant}/webhooks/{id}/deliveries/{delivery}/redeliver: send a delivery of a webhook endpoint again.
+func (c *Client) RedeliverWebhook(ctx context.Context, tenant string, id string, delivery string) (*Delivery, error) {
+	var out Delivery
+	if err := c.do(ctx, "POST", "/api/v1/tenants/"+url.PathEscape(tenant)+"/webhooks/"+url.PathEscape(id)+"/deliveries/"+url.PathEscape(delivery)+"/redeliver", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RotateWebhookSecret calls POST /api/v1/tenants/{tenant}/webhooks/{id}/rotate-secret: give a webhook endpoint a new signing secret.
+func (c *Client) RotateWebhookSecret(ctx context.Context, tenant string, id string) (*Endpoint, error) {
+	var out Endpoint
+	if err := c.do(ctx, "POST", "/api/v1/tenants/"+url.PathEscape(tenant)+"/webhooks/"+url.PathEscape(id)+"/rotate-secret", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SubmitTender calls POST /api/v1/tenders: submit the files of a tender to be processed as one job.
+func (c *Client) SubmitTender(ctx context.Context, body *TenderRequest) (*SubmitTenderResponse, error) {
+	var out SubmitTenderResponse
+	if err := c.do(ctx, "POST", "/api/v1/tenders", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTender calls GET /api/v1/tenders/{tender_id}: the latest tender job of a tender.
+func (c *Client) GetTender(ctx context.Context, tenderID string, params *GetTenderParams) (*ProcessingJob, error) {
+	var out ProcessingJob
+	if err := c.do(ctx, "GET", "/api/v1/tenders/"+url.PathEscape(tenderID), params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTenderJobs calls GET /api/v1/tenders/{tender_id}/jobs: page through the jobs of a tender.
+func (c *Client) ListTenderJobs(ctx context.Context, tenderID string, params *ListTenderJobsParams) (*ListTenderJobsResponse, error) {
+	var out ListTenderJobsResponse
+	if err := c.do(ctx, "GET", "/api/v1/tenders/"+url.PathEscape(tenderID)+"/jobs", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetJobV2 calls GET /api/v2/jobs/{id}: a job, with its result by page once completed.
+func (c *Client) GetJobV2(ctx context.Context, id string) (*JobV2, error) {
+	var out JobV2
+	if err := c.do(ctx, "GET", "/api/v2/jobs/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTenderV2 calls GET /api/v2/tenders/{tender_id}: the latest tender job of a tender, its result by page.
+func (c *Client) GetTenderV2(ctx context.Context, tenderID string, params *GetTenderV2Params) (*JobV2, error) {
+	var out JobV2
+	if err := c.do(ctx, "GET", "/api/v2/tenders/"+url.PathEscape(tenderID), params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+type APIKey struct {
+	CreatedAt  time.Time  `json:"created_at,omitempty"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	ID         string     `json:"id,omitempty"`
+	Key        string     `json:"key,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	Name       string     `json:"name,omitempty"`
+	Prefix     string     `json:"prefix,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	RotatedAt  *time.Time `json:"rotated_at,omitempty"`
+	Scopes     []string   `json:"scopes,omitempty"`
+	TenantID   string     `json:"tenant_id,omitempty"`
+}
+
+type APIKeyRequest struct {
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes,omitempty"`
+}
+
+type APIKeyUsage struct {
+	Day         string `json:"day,omitempty"`
+	Requests    int64  `json:"requests,omitempty"`
+	Submissions int64  `json:"submissions,omitempty"`
+}
+
+type AnalyticsExport struct {
+	Documents   int64      `json:"documents,omitempty"`
+	Entities    int64      `json:"entities,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	Files       []string   `json:"files,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	From        time.Time  `json:"from,omitempty"`
+	ID          string     `json:"id,omitempty"`
+	Incremental bool       `json:"incremental,omitempty"`
+	Risks       int64      `json:"risks,omitempty"`
+	StartedAt   time.Time  `json:"started_at,omitempty"`
+	Status      string     `json:"status,omitempty"`
+	To          time.Time  `json:"to,omitempty"`
+	Trigger     string     `json:"trigger,omitempty"`
+}
+
+type AnalyticsExportRequest struct {
+	From *time.Time `json:"from,omitempty"`
+	To   *time.Time `json:"to,omitempty"`
+}
+
+type Artifact struct {
+	Bucket    string    `json:"bucket,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	Key       string    `json:"key,omitempty"`
+	URL       string    `json:"url,omitempty"`
+}
+
+type ArtifactsV2 struct {
+	AnnotatedPDF  *Artifact `json:"annotated_pdf,omitempty"`
+	Archive       *Artifact `json:"archive,omitempty"`
+	OCRLayout     *Artifact `json:"ocr_layout,omitempty"`
+	RedactedPDF   *Artifact `json:"redacted_pdf,omitempty"`
+	Report        *Artifact `json:"report,omitempty"`
+	SearchablePDF *Artifact `json:"searchable_pdf,omitempty"`
+}
+
+type Attachment struct {
+	Bucket      string `json:"bucket,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	JobID       string `json:"job_id,omitempty"`
+	Key         string `json:"key,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Page        int    `json:"page,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+}
+
+type AutoscalerStats struct {
+	Decisions  []ScalingDecision `json:"decisions,omitempty"`
+	MaxWorkers int               `json:"max_workers,omitempty"`
+	MinWorkers int               `json:"min_workers,omitempty"`
+	ScaleDowns int64             `json:"scale_downs,omitempty"`
+	ScaleUps   int64             `json:"scale_ups,omitempty"`
+}
+
+type BiddingItem struct {
+	Description         string  `json:"description,omitempty"`
+	EstimatedTotalCents int64   `json:"estimated_total_cents,omitempty"`
+	EstimatedUnitCents  int64   `json:"estimated_unit_cents,omitempty"`
+	Item                string  `json:"item,omitempty"`
+	Page                int     `json:"page,omitempty"`
+	Quantity            float64 `json:"quantity,omitempty"`
+	Source              string  `json:"source,omitempty"`
+	Unit                string  `json:"unit,omitempty"`
+}
+
+type BillingExport struct {
+	Delivered   bool       `json:"delivered,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	File        string     `json:"file,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	From        time.Time  `json:"from,omitempty"`
+	ID          string     `json:"id,omitempty"`
+	Incremental bool       `json:"incremental,omitempty"`
+	Records     int64      `json:"records,omitempty"`
+	StartedAt   time.Time  `json:"started_at,omitempty"`
+	Status      string     `json:"status,omitempty"`
+	To          time.Time  `json:"to,omitempty"`
+	Trigger     string     `json:"trigger,omitempty"`
+}
+
+type BillingExportRequest struct {
+	From *time.Time `json:"from,omitempty"`
+	To   *time.Time `json:"to,omitempty"`
+}
+
+type BoundingBox struct {
+	Height int `json:"height,omitempty"`
+	Page   int `json:"page,omitempty"`
+	Width  int `json:"width,omitempty"`
+	X      int `json:"x,omitempty"`
+	Y      int `json:"y,omitempty"`
+}
+
+type CancelJobResponse struct {
+	JobID  string `json:"job_id,omitempty"`
+	Status string `json:"status,omitempty"`
+}
+
+type ClearResultCacheResponse struct {
+	Invalidated int `json:"invalidated,omitempty"`
+}
+
+type Company struct {
+	CNAE              string `json:"cnae,omitempty"`
+	CNAEDescricao     string `json:"cnae_descricao,omitempty"`
+	CNPJ              string `json:"cnpj,omitempty"`
+	NomeFantasia      string `json:"nome_fantasia,omitempty"`
+	RazaoSocial       string `json:"razao_social,omitempty"`
+	SituacaoCadastral string `json:"situacao_cadastral,omitempty"`
+}
+
+type CompletePresignedUploadResponse struct {
+	JobID  string `json:"job_id,omitempty"`
+	Status string `json:"status,omitempty"`
+}
+
+type Condition struct {
+	Distance int      `json:"distance,omitempty"`
+	Terms    []string `json:"terms,omitempty"`
+	Type     string   `json:"type,omitempty"`
+	Value    string   `json:"value,omitempty"`
+}
+
+type CreatePresignedUploadResponse struct {
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	JobID     string    `json:"job_id,omitempty"`
+	Method    string    `json:"method,omitempty"`
+	UploadURL string    `json:"upload_url,omitempty"`
+}
+
+type CreateRiskRuleParams struct {
+	// Operators only, the global rules when omitted; others manage their own tenant's
+	TenantID string
+}
+
+func (p *CreateRiskRuleParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.TenantID != "" {
+		q.Set("tenant_id", p.TenantID)
+	}
+	return q
+}
+
+type DeleteRiskRuleParams struct {
+	// Operators only, the global rules when omitted; others manage their own tenant's
+	TenantID string
+}
+
+func (p *DeleteRiskRuleParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.TenantID != "" {
+		q.Set("tenant_id", p.TenantID)
+	}
+	return q
+}
+
+type Delivery struct {
+	Attempts      int         `json:"attempts,omitempty"`
+	CreatedAt     time.Time   `json:"created_at,omitempty"`
+	EndpointID    string      `json:"endpoint_id,omitempty"`
+	Error         string      `json:"error,omitempty"`
+	Event         string      `json:"event,omitempty"`
+	ID            string      `json:"id,omitempty"`
+	JobID         string      `json:"job_id,omitempty"`
+	LastAttemptAt *time.Time  `json:"last_attempt_at,omitempty"`
+	NextAttemptAt *time.Time  `json:"next_attempt_at,omitempty"`
+	Payload       interface{} `json:"payload,omitempty"`
+	RedeliveryOf  string      `json:"redelivery_of,omitempty"`
+	Status        string      `json:"status,omitempty"`
+	StatusCode    int         `json:"status_code,omitempty"`
+	TenantID      string      `json:"tenant_id,omitempty"`
+	URL           string      `json:"url,omitempty"`
+}
+
+type DrainProgress struct {
+	ActiveJobs int        `json:"active_jobs,omitempty"`
+	Drained    bool       `json:"drained,omitempty"`
+	Draining   bool       `json:"draining,omitempty"`
+	Since      *time.Time `json:"since,omitempty"`
+	Workers    int        `json:"workers,omitempty"`
+}
+
+type DryRunRequest struct {
+	JobID string `json:"job_id"`
+	Rules []Rule `json:"rules,omitempty"`
+}
+
+type DryRunRiskRulesResponse struct {
+	JobID        string                         `json:"job_id,omitempty"`
+	Matches      []DryRunRiskRulesResponseMatch `json:"matches,omitempty"`
+	RiskAnalysis *RiskAnalysis                  `json:"risk_analysis,omitempty"`
+}
+
+type DryRunRiskRulesResponseMatch struct {
+	End      int    `json:"end,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Position int    `json:"position,omitempty"`
+	RuleID   string `json:"rule_id,omitempty"`
+}
+
+type Endpoint struct {
+	CreatedAt       time.Time  `json:"created_at,omitempty"`
+	Description     string     `json:"description,omitempty"`
+	Enabled         bool       `json:"enabled,omitempty"`
+	Events          []string   `json:"events,omitempty"`
+	ID              string     `json:"id,omitempty"`
+	Secret          string     `json:"secret,omitempty"`
+	SecretRotatedAt *time.Time `json:"secret_rotated_at,omitempty"`
+	TenantID        string     `json:"tenant_id,omitempty"`
+	UpdatedAt       time.Time  `json:"updated_at,omitempty"`
+	URL             string     `json:"url,omitempty"`
+}
+
+type EntityPattern struct {
+	Confidence float64   `json:"confidence,omitempty"`
+	CreatedAt  time.Time `json:"created_at,omitempty"`
+	EntityType string    `json:"entity_type,omitempty"`
+	ID         string    `json:"id,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	Pattern    string    `json:"pattern,omitempty"`
+	TenantID   string    `json:"tenant_id,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at,omitempty"`
+}
+
+type EntityPatternRequest struct {
+	Confidence float64 `json:"confidence,omitempty"`
+	EntityType string  `json:"entity_type"`
+	Name       string  `json:"name"`
+	Pattern    string  `json:"pattern"`
+}
+
+type ExportJobParams struct {
+	// csv, the default, or xlsx
+	Format string
+}
+
+func (p *ExportJobParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.Format != "" {
+		q.Set("format", p.Format)
+	}
+	return q
+}
+
+type ExtractedEntity struct {
+	BoundingBox *BoundingBox     `json:"bounding_box,omitempty"`
+	Company     *Company         `json:"company,omitempty"`
+	Confidence  float64          `json:"confidence,omitempty"`
+	EndPos      int              `json:"end_pos,omitempty"`
+	Normalized  *NormalizedValue `json:"normalized,omitempty"`
+	Page        int              `json:"page,omitempty"`
+	StartPos    int              `json:"start_pos,omitempty"`
+	Symbology   string           `json:"symbology,omitempty"`
+	Type        string           `json:"type,omitempty"`
+	Value       string           `json:"value,omitempty"`
+}
+
+type ExtractedTable struct {
+	Headers   []string   `json:"headers,omitempty"`
+	Name      string     `json:"name,omitempty"`
+	Page      int        `json:"page,omitempty"`
+	Rows      [][]string `json:"rows,omitempty"`
+	Source    string     `json:"source,omitempty"`
+	Truncated bool       `json:"truncated,omitempty"`
+}
+
+type GetAPIKeyUsageParams struct {
+	Days int
+}
+
+func (p *GetAPIKeyUsageParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.Days != 0 {
+		q.Set("days", strconv.Itoa(p.Days))
+	}
+	return q
+}
+
+type GetAPIKeyUsageResponse struct {
+	Usage []APIKeyUsage `json:"usage,omitempty"`
+}
+
+type GetGraphQLParams struct {
+	Query         string
+	OperationName string
+	// Variables, as a JSON object
+	Variables string
+}
+
+func (p *GetGraphQLParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.Query != "" {
+		q.Set("query", p.Query)
+	}
+	if p.OperationName != "" {
+		q.Set("operationName", p.OperationName)
+	}
+	if p.Variables != "" {
+		q.Set("variables", p.Variables)
+	}
+	return q
+}
+
+type GetGraphQLResponse struct {
+	Data   interface{}               `json:"data,omitempty"`
+	Errors []GetGraphQLResponseError `json:"errors,omitempty"`
+}
+
+type GetGraphQLResponseError struct {
+	Locations []GetGraphQLResponseErrorLocation `json:"locations,omitempty"`
+	Message   string                            `json:"message,omitempty"`
+	Path      []interface{}                     `json:"path,omitempty"`
+}
+
+type GetGraphQLResponseErrorLocation struct {
+	Column int `json:"column,omitempty"`
+	Line   int `json:"line,omitempty"`
+}
+
+type GetJobStatusesResponse struct {
+	Jobs     []JobUpdate `json:"jobs,omitempty"`
+	NotFound []string    `json:"not_found,omitempty"`
+}
+
+type GetPageImageParams struct {
+	// Width in pixels
+	Width int
+	// png or jpeg
+	Format string
+}
+
+func (p *GetPageImageParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.Width != 0 {
+		q.Set("width", strconv.Itoa(p.Width))
+	}
+	if p.Format != "" {
+		q.Set("format", p.Format)
+	}
+	return q
+}
+
+type GetRiskRuleParams struct {
+	// Operators only, the global rules when omitted; others manage their own tenant's
+	TenantID string
+}
+
+func (p *GetRiskRuleParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.TenantID != "" {
+		q.Set("tenant_id", p.TenantID)
+	}
+	return q
+}
+
+type GetTenantUsageParams struct {
+	// YYYY-MM, the current month by default
+	Month string
+}
+
+func (p *GetTenantUsageParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.Month != "" {
+		q.Set("month", p.Month)
+	}
+	return q
+}
+
+type GetTenderParams struct {
+	// Operators only; others read their own tenant's
+	TenantID string
+}
+
+func (p *GetTenderParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.TenantID != "" {
+		q.Set("tenant_id", p.TenantID)
+	}
+	return q
+}
+
+type GetTenderUsageParams struct {
+	// YYYY-MM, the current month by default
+	Month string
+}
+
+func (p *GetTenderUsageParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.Month != "" {
+		q.Set("month", p.Month)
+	}
+	return q
+}
+
+type GetTenderUsageResponse struct {
+	Month    string        `json:"month,omitempty"`
+	TenantID string        `json:"tenant_id,omitempty"`
+	Tenders  []TenderUsage `json:"tenders,omitempty"`
+}
+
+type GetTenderV2Params struct {
+	// Operators only; others read their own tenant's
+	TenantID string
+}
+
+func (p *GetTenderV2Params) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.TenantID != "" {
+		q.Set("tenant_id", p.TenantID)
+	}
+	return q
+}
+
+type IdentifiedRisk struct {
+	Category    string  `json:"category,omitempty"`
+	Confidence  float64 `json:"confidence,omitempty"`
+	Description string  `json:"description,omitempty"`
+	EndPos      int     `json:"end_pos,omitempty"`
+	Impact      string  `json:"impact,omitempty"`
+	Location    string  `json:"location,omitempty"`
+	Page        int     `json:"page,omitempty"`
+	Section     string  `json:"section,omitempty"`
+	Severity    string  `json:"severity,omitempty"`
+	Snippet     string  `json:"snippet,omitempty"`
+	StartPos    int     `json:"start_pos,omitempty"`
+}
+
+type Instance struct {
+	ActiveJobs int       `json:"active_jobs,omitempty"`
+	Alive      bool      `json:"alive,omitempty"`
+	Host       string    `json:"host,omitempty"`
+	ID         string    `json:"id,omitempty"`
+	LastSeen   time.Time `json:"last_seen,omitempty"`
+	StartedAt  time.Time `json:"started_at,omitempty"`
+	Workers    int       `json:"workers,omitempty"`
+}
+
+type InterestProfile struct {
+	CNAECodes     []string  `json:"cnae_codes,omitempty"`
+	CreatedAt     time.Time `json:"created_at,omitempty"`
+	ID            string    `json:"id,omitempty"`
+	Keywords      []string  `json:"keywords,omitempty"`
+	MaxValueCents int64     `json:"max_value_cents,omitempty"`
+	MinValueCents int64     `json:"min_value_cents,omitempty"`
+	Name          string    `json:"name,omitempty"`
+	Regions       []string  `json:"regions,omitempty"`
+	TenantID      string    `json:"tenant_id,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at,omitempty"`
+}
+
+type InterestProfileRequest struct {
+	CNAECodes     []string `json:"cnae_codes,omitempty"`
+	Keywords      []string `json:"keywords,omitempty"`
+	MaxValueCents int64    `json:"max_value_cents,omitempty"`
+	MinValueCents int64    `json:"min_value_cents,omitempty"`
+	Name          string   `json:"name"`
+	Regions       []string `json:"regions,omitempty"`
+}
+
+type InvalidateCachedResultsResponse struct {
+	Invalidated int `json:"invalidated,omitempty"`
+}
+
+type JobProgress struct {
+	PagesDone  int       `json:"pages_done,omitempty"`
+	PagesTotal int       `json:"pages_total,omitempty"`
+	Percent    int       `json:"percent,omitempty"`
+	Stage      string    `json:"stage,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at,omitempty"`
+}
+
+type JobRecord struct {
+	Attempts    int        `json:"attempts,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	ErrorCode   string     `json:"error_code,omitempty"`
+	Filename    string     `json:"filename,omitempty"`
+	ID          string     `json:"id,omitempty"`
+	ParentID    string     `json:"parent_id,omitempty"`
+	Priority    string     `json:"priority,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	Status      string     `json:"status,omitempty"`
+	TenantID    string     `json:"tenant_id,omitempty"`
+	TenderID    string     `json:"tender_id,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at,omitempty"`
+	UserID      string     `json:"user_id,omitempty"`
+}
+
+type JobStatusRequest struct {
+	JobIDs []string `json:"job_ids"`
+}
+
+type JobUpdate struct {
+	Error       string       `json:"error,omitempty"`
+	ErrorCode   string       `json:"error_code,omitempty"`
+	JobID       string       `json:"job_id,omitempty"`
+	NextRetryAt *time.Time   `json:"next_retry_at,omitempty"`
+	Progress    *JobProgress `json:"progress,omitempty"`
+	Status      string       `json:"status,omitempty"`
+}
+
+type JobV2 struct {
+	Attempts    int                    `json:"attempts,omitempty"`
+	CallbackURL string                 `json:"callback_url,omitempty"`
+	ChildIDs    []string               `json:"child_ids,omitempty"`
+	CompletedAt *time.Time             `json:"completed_at,omitempty"`
+	CreatedAt   time.Time              `json:"created_at,omitempty"`
+	DependsOn   []string               `json:"depends_on,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	ErrorCode   string                 `json:"error_code,omitempty"`
+	FileURL     string                 `json:"file_url,omitempty"`
+	ID          string                 `json:"id,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	NextRetryAt *time.Time             `json:"next_retry_at,omitempty"`
+	Options     *ProcessingOptions     `json:"options,omitempty"`
+	ParentID    string                 `json:"parent_id,omitempty"`
+	Priority    string                 `json:"priority,omitempty"`
+	Profile     string                 `json:"profile,omitempty"`
+	Progress    *JobProgress           `json:"progress,omitempty"`
+	Result      *ResultV2              `json:"result,omitempty"`
+	RunAt       *time.Time             `json:"run_at,omitempty"`
+	StartedAt   *time.Time             `json:"started_at,omitempty"`
+	Status      string                 `json:"status,omitempty"`
+	TenantID    string                 `json:"tenant_id,omitempty"`
+	TenderID    string                 `json:"tender_id,omitempty"`
+	UserID      string                 `json:"user_id,omitempty"`
+}
+
+type ListAPIKeysResponse struct {
+	APIKeys []APIKey `json:"api_keys,omitempty"`
+}
+
+type ListAnalyticsExportsParams struct {
+	Limit int
+}
+
+func (p *ListAnalyticsExportsParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.Limit != 0 {
+		q.Set("limit", strconv.Itoa(p.Limit))
+	}
+	return q
+}
+
+type ListAnalyticsExportsResponse struct {
+	Exports []AnalyticsExport `json:"exports,omitempty"`
+}
+
+type ListBillingExportsParams struct {
+	Limit int
+}
+
+func (p *ListBillingExportsParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.Limit != 0 {
+		q.Set("limit", strconv.Itoa(p.Limit))
+	}
+	return q
+}
+
+type ListBillingExportsResponse struct {
+	Exports []BillingExport `json:"exports,omitempty"`
+}
+
+type ListDeadLettersParams struct {
+	Limit  int
+	Offset int
+}
+
+func (p *ListDeadLettersParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.Limit != 0 {
+		q.Set("limit", strconv.Itoa(p.Limit))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.Itoa(p.Offset))
+	}
+	return q
+}
+
+type ListDeadLettersResponse struct {
+	Jobs  []ProcessingJob `json:"jobs,omitempty"`
+	Total int64           `json:"total,omitempty"`
+}
+
+type ListEntityPatternsResponse struct {
+	Patterns []EntityPattern `json:"patterns,omitempty"`
+}
+
+type ListInstancesResponse struct {
+	InstanceID string     `json:"instance_id,omitempty"`
+	Instances  []Instance `json:"instances,omitempty"`
+}
+
+type ListInterestProfilesResponse struct {
+	Profiles []InterestProfile `json:"profiles,omitempty"`
+}
+
+type ListJobWebhooksResponse struct {
+	Deliveries []Delivery `json:"deliveries,omitempty"`
+}
+
+type ListJobsParams struct {
+	TenderID string
+	// Comma separated statuses
+	Status    string
+	TenantID  string
+	UserID    string
+	ErrorCode string
+	// Text of the error message or file name
+	Q string
+	// Created at or after, RFC 3339 or a date
+	From string
+	// Created before, RFC 3339 or a date, included
+	To string
+	// Field to sort by, descending when prefixed with -
+	Sort string
+	// Page, from 1
+	Page     int
+	PageSize int
+}
+
+func (p *ListJobsParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.TenderID != "" {
+		q.Set("tender_id", p.TenderID)
+	}
+	if p.Status != "" {
+		q.Set("status", p.Status)
+	}
+	if p.TenantID != "" {
+		q.Set("tenant_id", p.TenantID)
+	}
+	if p.UserID != "" {
+		q.Set("user_id", p.UserID)
+	}
+	if p.ErrorCode != "" {
+		q.Set("error_code", p.ErrorCode)
+	}
+	if p.Q != "" {
+		q.Set("q", p.Q)
+	}
+	if p.From != "" {
+		q.Set("from", p.From)
+	}
+	if p.To != "" {
+		q.Set("to", p.To)
+	}
+	if p.Sort != "" {
+		q.Set("sort", p.Sort)
+	}
+	if p.Page != 0 {
+		q.Set("page", strconv.Itoa(p.Page))
+	}
+	if p.PageSize != 0 {
+		q.Set("page_size", strconv.Itoa(p.PageSize))
+	}
+	return q
+}
+
+type ListJobsResponse struct {
+	Jobs     []JobRecord `json:"jobs,omitempty"`
+	Page     int         `json:"page,omitempty"`
+	PageSize int         `json:"page_size,omitempty"`
+	Total    int         `json:"total,omitempty"`
+}
+
+type ListPipelinesResponse struct {
+	Pipelines []Pipeline `json:"pipelines,omitempty"`
+	Stages    []string   `json:"stages,omitempty"`
+}
+
+type ListProcessingProfilesResponse struct {
+	Profiles []ProcessingProfile `json:"profiles,omitempty"`
+}
+
+type ListReprocessingPoliciesResponse struct {
+	Policies []ReprocessingPolicy `json:"policies,omitempty"`
+}
+
+type ListRiskProfilesResponse struct {
+	Profiles []RiskProfile `json:"profiles,omitempty"`
+}
+
+type ListRiskRuleVersionsParams struct {
+	// Operators only, the global rules when omitted; others manage their own tenant's
+	TenantID string
+}
+
+func (p *ListRiskRuleVersionsParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.TenantID != "" {
+		q.Set("tenant_id", p.TenantID)
+	}
+	return q
+}
+
+type ListRiskRuleVersionsResponse struct {
+	Versions []RuleVersion `json:"versions,omitempty"`
+}
+
+type ListRiskRulesParams struct {
+	// Operators only, the global rules when omitted; others manage their own tenant's
+	TenantID string
+}
+
+func (p *ListRiskRulesParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.TenantID != "" {
+		q.Set("tenant_id", p.TenantID)
+	}
+	return q
+}
+
+type ListRiskRulesResponse struct {
+	Rules []Rule `json:"rules,omitempty"`
+}
+
+type ListTenderJobsParams struct {
+	// Comma separated statuses
+	Status    string
+	TenantID  string
+	UserID    string
+	ErrorCode string
+	// Text of the error message or file name
+	Q string
+	// Created at or after, RFC 3339 or a date
+	From string
+	// Created before, RFC 3339 or a date, included
+	To string
+	// Field to sort by, descending when prefixed with -
+	Sort string
+	// Page, from 1
+	Page     int
+	PageSize int
+}
+
+func (p *ListTenderJobsParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.Status != "" {
+		q.Set("status", p.Status)
+	}
+	if p.TenantID != "" {
+		q.Set("tenant_id", p.TenantID)
+	}
+	if p.UserID != "" {
+		q.Set("user_id", p.UserID)
+	}
+	if p.ErrorCode != "" {
+		q.Set("error_code", p.ErrorCode)
+	}
+	if p.Q != "" {
+		q.Set("q", p.Q)
+	}
+	if p.From != "" {
+		q.Set("from", p.From)
+	}
+	if p.To != "" {
+		q.Set("to", p.To)
+	}
+	if p.Sort != "" {
+		q.Set("sort", p.Sort)
+	}
+	if p.Page != 0 {
+		q.Set("page", strconv.Itoa(p.Page))
+	}
+	if p.PageSize != 0 {
+		q.Set("page_size", strconv.Itoa(p.PageSize))
+	}
+	return q
+}
+
+type ListTenderJobsResponse struct {
+	Jobs     []JobRecord `json:"jobs,omitempty"`
+	Page     int         `json:"page,omitempty"`
+	PageSize int         `json:"page_size,omitempty"`
+	Total    int         `json:"total,omitempty"`
+}
+
+type ListWebhookDeliveriesParams struct {
+	Limit  int
+	Offset int
+}
+
+func (p *ListWebhookDeliveriesParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.Limit != 0 {
+		q.Set("limit", strconv.Itoa(p.Limit))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.Itoa(p.Offset))
+	}
+	return q
+}
+
+type ListWebhookDeliveriesResponse struct {
+	Deliveries []Delivery `json:"deliveries,omitempty"`
+	Total      int        `json:"total,omitempty"`
+}
+
+type ListWebhookEndpointsResponse struct {
+	Endpoints []Endpoint `json:"endpoints,omitempty"`
+}
+
+type NormalizedValue struct {
+	Cents *int64 `json:"cents,omitempty"`
+	Date  string `json:"date,omitempty"`
+}
+
+type OCRZone struct {
+	Detect string  `json:"detect,omitempty"`
+	Height float64 `json:"height,omitempty"`
+	Name   string  `json:"name,omitempty"`
+	Page   int     `json:"page,omitempty"`
+	Width  float64 `json:"width,omitempty"`
+	X      float64 `json:"x,omitempty"`
+	Y      float64 `json:"y,omitempty"`
+}
+
+type PageV2 struct {
+	Blocks   []TextBlock       `json:"blocks,omitempty"`
+	Entities []ExtractedEntity `json:"entities,omitempty"`
+	Number   int               `json:"number,omitempty"`
+	Tables   []ExtractedTable  `json:"tables,omitempty"`
+	Text     string            `json:"text,omitempty"`
+}
+
+type Pipeline struct {
+	CreatedAt   time.Time       `json:"created_at,omitempty"`
+	Description string          `json:"description,omitempty"`
+	ID          string          `json:"id,omitempty"`
+	Name        string          `json:"name,omitempty"`
+	Stages      []PipelineStage `json:"stages,omitempty"`
+	TenantID    string          `json:"tenant_id,omitempty"`
+	UpdatedAt   time.Time       `json:"updated_at,omitempty"`
+}
+
+type PipelineRequest struct {
+	Description string          `json:"description,omitempty"`
+	Name        string          `json:"name"`
+	Stages      []PipelineStage `json:"stages"`
+}
+
+type PipelineStage struct {
+	Name    string      `json:"name,omitempty"`
+	Options interface{} `json:"options,omitempty"`
+}
+
+type PoolStats struct {
+	ActiveJobs            int              `json:"active_jobs,omitempty"`
+	Autoscaler            *AutoscalerStats `json:"autoscaler,omitempty"`
+	AverageProcessingTime float64          `json:"average_processing_time,omitempty"`
+	FailedJobs            int64            `json:"failed_jobs,omitempty"`
+	LastProcessed         time.Time        `json:"last_processed,omitempty"`
+	ProcessedJobs         int64            `json:"processed_jobs,omitempty"`
+	QueuedJobs            int              `json:"queued_jobs,omitempty"`
+	Resources             *ResourceUsage   `json:"resources,omitempty"`
+	TotalWorkers          int              `json:"total_workers,omitempty"`
+}
+
+type PostGraphQLRequest struct {
+	OperationName string                 `json:"operationName,omitempty"`
+	Query         string                 `json:"query,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+type PostGraphQLResponse struct {
+	Data   interface{}                `json:"data,omitempty"`
+	Errors []PostGraphQLResponseError `json:"errors,omitempty"`
+}
+
+type PostGraphQLResponseError struct {
+	Locations []PostGraphQLResponseErrorLocation `json:"locations,omitempty"`
+	Message   string                             `json:"message,omitempty"`
+	Path      []interface{}                      `json:"path,omitempty"`
+}
+
+type PostGraphQLResponseErrorLocation struct {
+	Column int `json:"column,omitempty"`
+	Line   int `json:"line,omitempty"`
+}
+
+type PresignRequest struct {
+	CallbackURL       string      `json:"callback_url,omitempty"`
+	ContentType       string      `json:"content_type,omitempty"`
+	DependsOn         []string    `json:"depends_on,omitempty"`
+	Filename          string      `json:"filename"`
+	InterestProfileID string      `json:"interest_profile_id,omitempty"`
+	Options           interface{} `json:"options,omitempty"`
+	Priority          string      `json:"priority,omitempty"`
+	Profile           string      `json:"profile,omitempty"`
+	RunAt             string      `json:"run_at,omitempty"`
+	Size              int64       `json:"size,omitempty"`
+	TenantID          string      `json:"tenant_id,omitempty"`
+	TenderID          string      `json:"tender_id,omitempty"`
+	UserID            string      `json:"user_id,omitempty"`
+}
+
+type ProcessingJob struct {
+	Attempts    int                    `json:"attempts,omitempty"`
+	CallbackURL string                 `json:"callback_url,omitempty"`
+	ChildIDs    []string               `json:"child_ids,omitempty"`
+	CompletedAt *time.Time             `json:"completed_at,omitempty"`
+	CreatedAt   time.Time              `json:"created_at,omitempty"`
+	DependsOn   []string               `json:"depends_on,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	ErrorCode   string                 `json:"error_code,omitempty"`
+	FileURL     string                 `json:"file_url,omitempty"`
+	ID          string                 `json:"id,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	NextRetryAt *time.Time             `json:"next_retry_at,omitempty"`
+	Options     *ProcessingOptions     `json:"options,omitempty"`
+	ParentID    string                 `json:"parent_id,omitempty"`
+	Priority    string                 `json:"priority,omitempty"`
+	Profile     string                 `json:"profile,omitempty"`
+	Progress    *JobProgress           `json:"progress,omitempty"`
+	Result      *ProcessingResult      `json:"result,omitempty"`
+	RunAt       *time.Time             `json:"run_at,omitempty"`
+	StartedAt   *time.Time             `json:"started_at,omitempty"`
+	Status      string                 `json:"status,omitempty"`
+	TenantID    string                 `json:"tenant_id,omitempty"`
+	TenderID    string                 `json:"tender_id,omitempty"`
+	UserID      string                 `json:"user_id,omitempty"`
+}
+
+type ProcessingOptions struct {
+	AnalyzeRisks       bool      `json:"analyze_risks,omitempty"`
+	AnnotateRisks      bool      `json:"annotate_risks,omitempty"`
+	ArchivePdfa        bool      `json:"archive_pdfa,omitempty"`
+	AutoRotate         bool      `json:"auto_rotate,omitempty"`
+	ClassifyDocument   bool      `json:"classify_document,omitempty"`
+	CorrectSpelling    bool      `json:"correct_spelling,omitempty"`
+	DetectBarcodes     bool      `json:"detect_barcodes,omitempty"`
+	DetectTables       bool      `json:"detect_tables,omitempty"`
+	DPI                int       `json:"dpi,omitempty"`
+	EnableOCR          bool      `json:"enable_ocr,omitempty"`
+	EnrichEntities     bool      `json:"enrich_entities,omitempty"`
+	ExtractAttachments bool      `json:"extract_attachments,omitempty"`
+	ExtractEntities    bool      `json:"extract_entities,omitempty"`
+	ExtractItems       bool      `json:"extract_items,omitempty"`
+	GenerateReport     bool      `json:"generate_report,omitempty"`
+	GenerateScore      bool      `json:"generate_score,omitempty"`
+	Languages          []string  `json:"languages,omitempty"`
+	LayoutText         bool      `json:"layout_text,omitempty"`
+	MaxPages           int       `json:"max_pages,omitempty"`
+	NerProvider        string    `json:"ner_provider,omitempty"`
+	OCRLayout          string    `json:"ocr_layout,omitempty"`
+	OCRParallelism     int       `json:"ocr_parallelism,omitempty"`
+	OCRProvider        string    `json:"ocr_provider,omitempty"`
+	OCRZones           []OCRZone `json:"ocr_zones,omitempty"`
+	Password           string    `json:"password,omitempty"`
+	Pipeline           string    `json:"pipeline,omitempty"`
+	PreprocessImages   bool      `json:"preprocess_images,omitempty"`
+	ProcessAttachments bool      `json:"process_attachments,omitempty"`
+	RecoverCorrupted   bool      `json:"recover_corrupted,omitempty"`
+	RedactPii          bool      `json:"redact_pii,omitempty"`
+	ReflowText         bool      `json:"reflow_text,omitempty"`
+	Reprocess          bool      `json:"reprocess,omitempty"`
+	RiskProfile        string    `json:"risk_profile,omitempty"`
+	SearchablePDF      bool      `json:"searchable_pdf,omitempty"`
+	SegmentSections    bool      `json:"segment_sections,omitempty"`
+	Stages             []string  `json:"stages,omitempty"`
+	StreamPages        bool      `json:"stream_pages,omitempty"`
+	TextBlocks         bool      `json:"text_blocks,omitempty"`
+	TimeoutSeconds     int       `json:"timeout_seconds,omitempty"`
+	VerifySignatures   bool      `json:"verify_signatures,omitempty"`
+}
+
+type ProcessingProfile struct {
+	CreatedAt time.Time          `json:"created_at,omitempty"`
+	ID        string             `json:"id,omitempty"`
+	Name      string             `json:"name,omitempty"`
+	Options   *ProcessingOptions `json:"options,omitempty"`
+	TenantID  string             `json:"tenant_id,omitempty"`
+	UpdatedAt time.Time          `json:"updated_at,omitempty"`
+}
+
+type ProcessingProfileRequest struct {
+	Name    string             `json:"name"`
+	Options *ProcessingOptions `json:"options,omitempty"`
+}
+
+type ProcessingResult struct {
+	AnnotatedPDF     *Artifact              `json:"annotated_pdf,omitempty"`
+	Archive          *Artifact              `json:"archive,omitempty"`
+	Attachments      []Attachment           `json:"attachments,omitempty"`
+	Blocks           []TextBlock            `json:"blocks,omitempty"`
+	Classification   *Result                `json:"classification,omitempty"`
+	ContentSHA256    string                 `json:"content_sha256,omitempty"`
+	Deduplicated     bool                   `json:"deduplicated,omitempty"`
+	DeduplicatedFrom string                 `json:"deduplicated_from,omitempty"`
+	Entities         []ExtractedEntity      `json:"entities,omitempty"`
+	ExtractedText    string                 `json:"extracted_text,omitempty"`
+	FileSize         int64                  `json:"file_size,omitempty"`
+	Items            []BiddingItem          `json:"items,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+	OCRLayout        *Artifact              `json:"ocr_layout,omitempty"`
+	PageCount        int                    `json:"page_count,omitempty"`
+	PageOffsets      []int                  `json:"page_offsets,omitempty"`
+	ProcessingTime   int64                  `json:"processing_time,omitempty"`
+	QualityMetrics   *QualityMetrics        `json:"quality_metrics,omitempty"`
+	RedactedPDF      *Artifact              `json:"redacted_pdf,omitempty"`
+	Relevance        *RelevanceMatch        `json:"relevance,omitempty"`
+	RelevanceScore   float64                `json:"relevance_score,omitempty"`
+	Report           *Artifact              `json:"report,omitempty"`
+	RiskAnalysis     *RiskAnalysis          `json:"risk_analysis,omitempty"`
+	SearchablePDF    *Artifact              `json:"searchable_pdf,omitempty"`
+	Sections         []Section              `json:"sections,omitempty"`
+	Signatures       []Signature            `json:"signatures,omitempty"`
+	Tables           []ExtractedTable       `json:"tables,omitempty"`
+	Zones            []ZoneText             `json:"zones,omitempty"`
+}
+
+type QualityMetrics struct {
+	Completeness       float64 `json:"completeness,omitempty"`
+	DocumentClarity    float64 `json:"document_clarity,omitempty"`
+	LowConfidencePages []int   `json:"low_confidence_pages,omitempty"`
+	OCRConfidence      float64 `json:"ocr_confidence,omitempty"`
+	OCRCorrections     int     `json:"ocr_corrections,omitempty"`
+	Readability        float64 `json:"readability,omitempty"`
+	TextQuality        float64 `json:"text_quality,omitempty"`
+}
+
+type Quota struct {
+	OCRPages     int64 `json:"ocr_pages,omitempty"`
+	Pages        int64 `json:"pages,omitempty"`
+	StorageBytes int64 `json:"storage_bytes,omitempty"`
+}
+
+type RelevanceMatch struct {
+	CNAECodes           []string `json:"cnae_codes,omitempty"`
+	EstimatedValueCents int64    `json:"estimated_value_cents,omitempty"`
+	Keywords            []string `json:"keywords,omitempty"`
+	ProfileID           string   `json:"profile_id,omitempty"`
+	ProfileName         string   `json:"profile_name,omitempty"`
+	Regions             []string `json:"regions,omitempty"`
+	ValueInRange        bool     `json:"value_in_range,omitempty"`
+}
+
+type ReplayDeadLetterResponse struct {
+	JobID  string `json:"job_id,omitempty"`
+	Status string `json:"status,omitempty"`
+}
+
+type ReprocessingFilter struct {
+	DocumentTypes []string   `json:"document_types,omitempty"`
+	Since         *time.Time `json:"since,omitempty"`
+	TenderID      string     `json:"tender_id,omitempty"`
+}
+
+type ReprocessingPolicy struct {
+	CreatedAt time.Time           `json:"created_at,omitempty"`
+	Enabled   bool                `json:"enabled,omitempty"`
+	Filter    *ReprocessingFilter `json:"filter,omitempty"`
+	ID        string              `json:"id,omitempty"`
+	LastRun   *ReprocessingRun    `json:"last_run,omitempty"`
+	Name      string              `json:"name,omitempty"`
+	NextRunAt *time.Time          `json:"next_run_at,omitempty"`
+	Options   interface{}         `json:"options,omitempty"`
+	Priority  string              `json:"priority,omitempty"`
+	Profile   string              `json:"profile,omitempty"`
+	Schedule  string              `json:"schedule,omitempty"`
+	TenantID  string              `json:"tenant_id,omitempty"`
+	Timezone  string              `json:"timezone,omitempty"`
+	UpdatedAt time.Time           `json:"updated_at,omitempty"`
+}
+
+type ReprocessingPolicyRequest struct {
+	Enabled  *bool               `json:"enabled,omitempty"`
+	Filter   *ReprocessingFilter `json:"filter,omitempty"`
+	Name     string              `json:"name"`
+	Options  interface{}         `json:"options,omitempty"`
+	Priority string              `json:"priority,omitempty"`
+	Profile  string              `json:"profile,omitempty"`
+	Schedule string              `json:"schedule"`
+	Timezone string              `json:"timezone,omitempty"`
+}
+
+type ReprocessingRun struct {
+	Error      string    `json:"error,omitempty"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+	Queued     int       `json:"queued,omitempty"`
+	StartedAt  time.Time `json:"started_at,omitempty"`
+	Truncated  bool      `json:"truncated,omitempty"`
+}
+
+type ResizeWorkersRequest struct {
+	Workers int `json:"workers"`
+}
+
+type ResourceUsage struct {
+	CpuPercent       float64   `json:"cpu_percent,omitempty"`
+	FreeDiskBytes    int64     `json:"free_disk_bytes,omitempty"`
+	MemoryBytes      int64     `json:"memory_bytes,omitempty"`
+	MemoryLimitBytes int64     `json:"memory_limit_bytes,omitempty"`
+	MemoryPercent    float64   `json:"memory_percent,omitempty"`
+	RssBytes         int64     `json:"rss_bytes,omitempty"`
+	SampledAt        time.Time `json:"sampled_at,omitempty"`
+}
+
+type Result struct {
+	Confidence float64 `json:"confidence,omitempty"`
+	Label      string  `json:"label,omitempty"`
+	Source     string  `json:"source,omitempty"`
+	Type       string  `json:"type,omitempty"`
+}
+
+type ResultV2 struct {
+	Artifacts        *ArtifactsV2           `json:"artifacts,omitempty"`
+	Attachments      []Attachment           `json:"attachments,omitempty"`
+	Classification   *Result                `json:"classification,omitempty"`
+	ContentSHA256    string                 `json:"content_sha256,omitempty"`
+	DeduplicatedFrom string                 `json:"deduplicated_from,omitempty"`
+	FileSize         int64                  `json:"file_size,omitempty"`
+	Items            []BiddingItem          `json:"items,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+	PageCount        int                    `json:"page_count,omitempty"`
+	Pages            []PageV2               `json:"pages,omitempty"`
+	ProcessingTimeMs int64                  `json:"processing_time_ms,omitempty"`
+	QualityMetrics   *QualityMetrics        `json:"quality_metrics,omitempty"`
+	Relevance        *RelevanceMatch        `json:"relevance,omitempty"`
+	RelevanceScore   float64                `json:"relevance_score,omitempty"`
+	RiskAnalysis     *RiskAnalysis          `json:"risk_analysis,omitempty"`
+	Sections         []Section              `json:"sections,omitempty"`
+	Signatures       []Signature            `json:"signatures,omitempty"`
+	Unpaged          *PageV2                `json:"unpaged,omitempty"`
+	Zones            []ZoneText             `json:"zones,omitempty"`
+}
+
+type RiskAnalysis struct {
+	Confidence      float64          `json:"confidence,omitempty"`
+	IdentifiedRisks []IdentifiedRisk `json:"identified_risks,omitempty"`
+	OverallRisk     string           `json:"overall_risk,omitempty"`
+	Profile         string           `json:"profile,omitempty"`
+	ProfileID       string           `json:"profile_id,omitempty"`
+	Recommendations []string         `json:"recommendations,omitempty"`
+	RiskScore       float64          `json:"risk_score,omitempty"`
+}
+
+type RiskProfile struct {
+	Categories map[string]float64 `json:"categories,omitempty"`
+	CreatedAt  time.Time          `json:"created_at,omitempty"`
+	ID         string             `json:"id,omitempty"`
+	Name       string             `json:"name,omitempty"`
+	Rules      map[string]float64 `json:"rules,omitempty"`
+	TenantID   string             `json:"tenant_id,omitempty"`
+	UpdatedAt  time.Time          `json:"updated_at,omitempty"`
+}
+
+type RiskProfileRequest struct {
+	Categories map[string]float64 `json:"categories,omitempty"`
+	Name       string             `json:"name"`
+	Rules      map[string]float64 `json:"rules,omitempty"`
+}
+
+type RiskRuleRequest struct {
+	Category       string      `json:"category,omitempty"`
+	Conditions     []Condition `json:"conditions"`
+	Enabled        *bool       `json:"enabled,omitempty"`
+	Impact         string      `json:"impact,omitempty"`
+	Name           string      `json:"name"`
+	Recommendation string      `json:"recommendation,omitempty"`
+	Severity       string      `json:"severity,omitempty"`
+	Version        int         `json:"version,omitempty"`
+	Weight         float64     `json:"weight,omitempty"`
+}
+
+type Rule struct {
+	Category       string      `json:"category,omitempty"`
+	Conditions     []Condition `json:"conditions,omitempty"`
+	Enabled        bool        `json:"enabled,omitempty"`
+	ID             string      `json:"id,omitempty"`
+	Impact         string      `json:"impact,omitempty"`
+	Name           string      `json:"name,omitempty"`
+	Recommendation string      `json:"recommendation,omitempty"`
+	Severity       string      `json:"severity,omitempty"`
+	TenantID       string      `json:"tenant_id,omitempty"`
+	UpdatedAt      time.Time   `json:"updated_at,omitempty"`
+	Version        int         `json:"version,omitempty"`
+	Weight         float64     `json:"weight,omitempty"`
+}
+
+type RuleVersion struct {
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	Rule      *Rule     `json:"rule,omitempty"`
+	Version   int       `json:"version,omitempty"`
+}
+
+type ScalingDecision struct {
+	ActiveJobs            int       `json:"active_jobs,omitempty"`
+	At                    time.Time `json:"at,omitempty"`
+	AverageProcessingTime float64   `json:"average_processing_time,omitempty"`
+	From                  int       `json:"from,omitempty"`
+	QueuedJobs            int       `json:"queued_jobs,omitempty"`
+	Reason                string    `json:"reason,omitempty"`
+	To                    int       `json:"to,omitempty"`
+}
+
+type SearchJobsParams struct {
+	TenderID string
+	// Comma separated statuses
+	Status    string
+	TenantID  string
+	UserID    string
+	ErrorCode string
+	// Text of the error message or file name
+	Q string
+	// Created at or after, RFC 3339 or a date
+	From string
+	// Created before, RFC 3339 or a date, included
+	To string
+	// Field to sort by, descending when prefixed with -
+	Sort string
+	// Page, from 1
+	Page     int
+	PageSize int
+}
+
+func (p *SearchJobsParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.TenderID != "" {
+		q.Set("tender_id", p.TenderID)
+	}
+	if p.Status != "" {
+		q.Set("status", p.Status)
+	}
+	if p.TenantID != "" {
+		q.Set("tenant_id", p.TenantID)
+	}
+	if p.UserID != "" {
+		q.Set("user_id", p.UserID)
+	}
+	if p.ErrorCode != "" {
+		q.Set("error_code", p.ErrorCode)
+	}
+	if p.Q != "" {
+		q.Set("q", p.Q)
+	}
+	if p.From != "" {
+		q.Set("from", p.From)
+	}
+	if p.To != "" {
+		q.Set("to", p.To)
+	}
+	if p.Sort != "" {
+		q.Set("sort", p.Sort)
+	}
+	if p.Page != 0 {
+		q.Set("page", strconv.Itoa(p.Page))
+	}
+	if p.PageSize != 0 {
+		q.Set("page_size", strconv.Itoa(p.PageSize))
+	}
+	return q
+}
+
+type SearchJobsResponse struct {
+	Jobs     []JobRecord `json:"jobs,omitempty"`
+	Page     int         `json:"page,omitempty"`
+	PageSize int         `json:"page_size,omitempty"`
+	Total    int         `json:"total,omitempty"`
+}
+
+type Section struct {
+	EndPage   int    `json:"end_page,omitempty"`
+	EndPos    int    `json:"end_pos,omitempty"`
+	StartPage int    `json:"start_page,omitempty"`
+	StartPos  int    `json:"start_pos,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Type      string `json:"type,omitempty"`
+}
+
+type Signature struct {
+	ChainValid     bool       `json:"chain_valid,omitempty"`
+	CoversDocument bool       `json:"covers_document,omitempty"`
+	Errors         []string   `json:"errors,omitempty"`
+	IcpBrasil      bool       `json:"icp_brasil,omitempty"`
+	Intact         bool       `json:"intact,omitempty"`
+	Issuer         string     `json:"issuer,omitempty"`
+	Pades          bool       `json:"pades,omitempty"`
+	Revocation     string     `json:"revocation,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	SignerCNPJ     string     `json:"signer_cnpj,omitempty"`
+	SignerName     string     `json:"signer_name,omitempty"`
+	SigningTime    *time.Time `json:"signing_time,omitempty"`
+	SubFilter      string     `json:"sub_filter,omitempty"`
+	Valid          bool       `json:"valid,omitempty"`
+}
+
+type SubmitTenderResponse struct {
+	FileJobIDs []string `json:"file_job_ids,omitempty"`
+	JobID      string   `json:"job_id,omitempty"`
+	Status     string   `json:"status,omitempty"`
+	TenderID   string   `json:"tender_id,omitempty"`
+}
+
+type TenantQuota struct {
+	OCRPages     *int64     `json:"ocr_pages,omitempty"`
+	Pages        *int64     `json:"pages,omitempty"`
+	StorageBytes *int64     `json:"storage_bytes,omitempty"`
+	TenantID     string     `json:"tenant_id,omitempty"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+}
+
+type TenantUsage struct {
+	Month    string    `json:"month,omitempty"`
+	Quota    *Quota    `json:"quota,omitempty"`
+	ResetsAt time.Time `json:"resets_at,omitempty"`
+	TenantID string    `json:"tenant_id,omitempty"`
+	Usage    *Usage    `json:"usage,omitempty"`
+}
+
+type TenderFile struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+type TenderRequest struct {
+	CallbackURL       string       `json:"callback_url,omitempty"`
+	Files             []TenderFile `json:"files"`
+	InterestProfileID string       `json:"interest_profile_id,omitempty"`
+	Options           interface{}  `json:"options,omitempty"`
+	Priority          string       `json:"priority,omitempty"`
+	Profile           string       `json:"profile,omitempty"`
+	RunAt             string       `json:"run_at,omitempty"`
+	TenantID          string       `json:"tenant_id,omitempty"`
+	TenderID          string       `json:"tender_id"`
+	UserID            string       `json:"user_id,omitempty"`
+}
+
+type TenderUsage struct {
+	Documents    int64   `json:"documents,omitempty"`
+	OCRPages     int64   `json:"ocr_pages,omitempty"`
+	OCRSeconds   float64 `json:"ocr_seconds,omitempty"`
+	Pages        int64   `json:"pages,omitempty"`
+	StorageBytes int64   `json:"storage_bytes,omitempty"`
+	TenderID     string  `json:"tender_id,omitempty"`
+}
+
+type TextBlock struct {
+	Column   int    `json:"column,omitempty"`
+	EndPos   int    `json:"end_pos,omitempty"`
+	Page     int    `json:"page,omitempty"`
+	StartPos int    `json:"start_pos,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Type     string `json:"type,omitempty"`
+}
+
+type UpdateRiskRuleParams struct {
+	// Operators only, the global rules when omitted; others manage their own tenant's
+	TenantID string
+}
+
+func (p *UpdateRiskRuleParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	q := make(url.Values)
+	if p.TenantID != "" {
+		q.Set("tenant_id", p.TenantID)
+	}
+	return q
+}
+
+type UploadDocumentResponse struct {
+	JobID  string `json:"job_id,omitempty"`
+	Status string `json:"status,omitempty"`
+}
+
+type Usage struct {
+	Documents    int64 `json:"documents,omitempty"`
+	OCRPages     int64 `json:"ocr_pages,omitempty"`
+	Pages        int64 `json:"pages,omitempty"`
+	StorageBytes int64 `json:"storage_bytes,omitempty"`
+}
+
+type WebhookEndpointRequest struct {
+	Description string   `json:"description,omitempty"`
+	Enabled     *bool    `json:"enabled,omitempty"`
+	Events      []string `json:"events,omitempty"`
+	URL         string   `json:"url"`
+}
+
+type ZoneText struct {
+	Box        *BoundingBox `json:"box,omitempty"`
+	Confidence float64      `json:"confidence,omitempty"`
+	Kind       string       `json:"kind,omitempty"`
+	Name       string       `json:"name,omitempty"`
+	Text       string       `json:"text,omitempty"`
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+)
+
+type goGenerator struct {
+	doc      *document
+	methods  bytes.Buffer
+	declared map[string]string
+	imports  map[string]bool
+}
+
+// generateGo generates the methods of the Go client and the types they
+// use, by name.
+func generateGo(doc *document) ([]byte, error) {
+	g := &goGenerator{doc: doc, declared: make(map[string]string), imports: map[string]bool{"context": true}}
+	for _, op := range doc.operations() {
+		if err := g.method(op); err != nil {
+			return nil, err
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by gen from the OpenAPI document of the API. DO NOT EDIT.\n\n")
+	out.WriteString("package client\n\n")
+	out.WriteString("import (\n")
+	for _, path := range sortedKeys(g.imports) {
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+	out.WriteString(")\n\n")
+	out.Write(g.methods.Bytes())
+	for _, name := range sortedKeys(g.declared) {
+		out.WriteString(g.declared[name])
+	}
+	return format.Source(out.Bytes())
+}
+
+// declare declares a struct of the properties of an object schema.
+func (g *goGenerator) declare(name string, s *schema) {
+	if _, ok := g.declared[name]; ok {
+		return
+	}
+	// Declared before the fields, which may refer to it
+	g.declared[name] = ""
+
+	var fields bytes.Buffer
+	for _, property := range sortedKeys(s.Properties) {
+		field := exported(property)
+		fieldType := g.goType(s.Properties[property], name+field)
+		required := s.required(property)
+		if s.Properties[property].isObject() && !required {
+			fieldType = "*" + fieldType
+		}
+		tag := property
+		if !required {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&fields, "\t%s %s `json:%q`\n", field, fieldType, tag)
+	}
+	g.declared[name] = fmt.Sprintf("type %s struct {\n%s}\n\n", name, fields.String())
+}
+
+// goType returns the Go type of a schema, declaring the structs of the
+// objects it has, named after name.
+func (g *goGenerator) goType(s *schema, name string) string {
+	var t string
+	switch {
+	case s.Ref != "":
+		name := exported(refName(s.Ref))
+		g.declare(name, g.doc.Components.Schemas[refName(s.Ref)])
+		return name
+	case s.Type == "string" && s.Format == "date-time":
+		g.imports["time"] = true
+		t = "time.Time"
+	case s.Type == "string" && s.Format == "byte":
+		return "[]byte"
+	case s.Type == "string":
+		t = "string"
+	case s.Type == "integer" && s.Format == "int64":
+		t = "int64"
+	case s.Type == "integer" && s.Format == "int32":
+		t = "int32"
+	case s.Type == "integer":
+		t = "int"
+	case s.Type == "number" && s.Format == "float":
+		t = "float32"
+	case s.Type == "number":
+		t = "float64"
+	case s.Type == "boolean":
+		t = "bool"
+	case s.Type == "array" && s.Items != nil:
+		return "[]" + g.goType(s.Items, singular(name))
+	case s.Type == "object" && s.AdditionalProperties != nil:
+		return "map[string]" + g.goType(s.AdditionalProperties, name+"Value")
+	case s.Type == "object" && len(s.Properties) > 0:
+		g.declare(name, s)
+		return name
+	case s.Type == "object":
+		return "map[string]interface{}"
+	default:
+		return "interface{}"
+	}
+	if s.Nullable {
+		return "*" + t
+	}
+	return t
+}
+
+// method declares the method of an operation, and the struct of its query
+// parameters.
+func (g *goGenerator) method(op *operation) error {
+	name := exported(op.OperationID)
+	args := []string{"ctx context.Context"}
+
+	// The path, with its parameters escaped
+	path := `"` + op.path + `"`
+	for _, param := range op.params("path") {
+		arg := unexported(param.Name)
+		value, argType := "url.PathEscape("+arg+")", "string"
+		g.imports["net/url"] = true
+		if param.Schema.Type == "integer" {
+			value, argType = "strconv.Itoa("+arg+")", "int"
+			g.imports["strconv"] = true
+		}
+		path = strings.Replace(path, "{"+param.Name+"}", `"+`+value+`+"`, 1)
+		args = append(args, arg+" "+argType)
+	}
+	path = strings.TrimSuffix(path, `+""`)
+
+	query := "nil"
+	if params := op.params("query"); len(params) > 0 {
+		g.params(name+"Params", params)
+		args = append(args, "params *"+name+"Params")
+		query = "params.values()"
+	}
+
+	in, multipart := "nil", false
+	if op.RequestBody != nil {
+		if media := op.RequestBody.Content["application/json"]; media != nil {
+			args = append(args, "body *"+g.goType(media.Schema, name+"Request"))
+			in = "body"
+		} else if op.RequestBody.Content["multipart/form-data"] != nil {
+			args = append(args, "file File", "fields map[string]string")
+			multipart = true
+		} else {
+			return fmt.Errorf("%s: unsupported request body", op.OperationID)
+		}
+	}
+
+	status, resp := op.success()
+	var media *mediaType
+	if resp != nil {
+		media = resp.Content["application/json"]
+	}
+
+	w := &g.methods
+	fmt.Fprintf(w, "// %s calls %s %s: %s.\n", name, op.method, op.path, strings.ToLower(op.Summary[:1])+op.Summary[1:])
+	signature := fmt.Sprintf("func (c *Client) %s(%s)", name, strings.Join(args, ", "))
+	switch {
+	case media != nil && !media.Schema.isObject() && media.Schema.Type == "":
+		g.imports["encoding/json"] = true
+		fmt.Fprintf(w, "%s (json.RawMessage, error) {\n", signature)
+		fmt.Fprintf(w, "\tvar out json.RawMessage\n")
+		fmt.Fprintf(w, "\tif err := c.do(ctx, %q, %s, %s, %s, &out); err != nil {\n\t\treturn nil, err\n\t}\n", op.method, path, query, in)
+		fmt.Fprintf(w, "\treturn out, nil\n}\n\n")
+	case media != nil:
+		outType := g.goType(media.Schema, name+"Response")
+		fmt.Fprintf(w, "%s (*%s, error) {\n", signature, outType)
+		fmt.Fprintf(w, "\tvar out %s\n", outType)
+		if multipart {
+			fmt.Fprintf(w, "\tif err := c.upload(ctx, %s, file, fields, &out); err != nil {\n", path)
+		} else {
+			fmt.Fprintf(w, "\tif err := c.do(ctx, %q, %s, %s, %s, &out); err != nil {\n", op.method, path, query, in)
+		}
+		fmt.Fprintf(w, "\t\treturn nil, err\n\t}\n\treturn &out, nil\n}\n\n")
+	case resp != nil && (len(resp.Content) > 0 || strings.HasPrefix(status, "3")):
+		// Files and streams; redirects are followed to the file
+		g.imports["io"] = true
+		fmt.Fprintf(w, "%s (io.ReadCloser, error) {\n", signature)
+		fmt.Fprintf(w, "\treturn c.stream(ctx, %q, %s, %s)\n}\n\n", op.method, path, query)
+	default:
+		fmt.Fprintf(w, "%s error {\n", signature)
+		fmt.Fprintf(w, "\treturn c.do(ctx, %q, %s, %s, %s, nil)\n}\n\n", op.method, path, query, in)
+	}
+	return nil
+}
+
+// params declares the struct of query parameters, whose zero values are
+// not sent.
+func (g *goGenerator) params(name string, params []parameter) {
+
+	var fields, values bytes.Buffer
+	for _, param := range params {
+		field := exported(param.Name)
+		if param.Description != "" {
+			fmt.Fprintf(&fields, "\t// %s\n", param.Description)
+		}
+		if param.Schema.Type == "integer" {
+			g.imports["strconv"] = true
+			fmt.Fprintf(&fields, "\t%s int\n", field)
+			fmt.Fprintf(&values, "\tif p.%s != 0 {\n\t\tq.Set(%q, strconv.Itoa(p.%s))\n\t}\n", field, param.Name, field)
+		} else {
+			fmt.Fprintf(&fields, "\t%s string\n", field)
+			fmt.Fprintf(&values, "\tif p.%s != \"\" {\n\t\tq.Set(%q, p.%s)\n\t}\n", field, param.Name, field)
+		}
+	}
+	g.imports["net/url"] = true
+	g.declared[name] = fmt.Sprintf("type %s struct {\n%s}\n\n", name, fields.String()) +
+		fmt.Sprintf("func (p *%s) values() url.Values {\n\tif p == nil {\n\t\treturn nil\n\t}\n\tq := make(url.Values)\n%s\treturn q\n}\n\n", name, values.String())
+}
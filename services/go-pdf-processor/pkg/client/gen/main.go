@@ -0,0 +1,235 @@
+// Command gen generates the API clients of pkg/client from the OpenAPI
+// document of the API: the Go client's methods and types, and a
+// TypeScript client for the frontend. Operations marked x-internal are
+// left out.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"cotai-pdf-processor/internal/api"
+)
+
+type document struct {
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components struct {
+		Schemas map[string]*schema `json:"schemas"`
+	} `json:"components"`
+}
+
+type operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Parameters  []parameter          `json:"parameters"`
+	RequestBody *body                `json:"requestBody"`
+	Responses   map[string]*response `json:"responses"`
+	Internal    bool                 `json:"x-internal"`
+
+	method, path string
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description"`
+	Schema      *schema `json:"schema"`
+}
+
+type body struct {
+	Content map[string]*mediaType `json:"content"`
+}
+
+type response struct {
+	Content map[string]*mediaType `json:"content"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Nullable             bool               `json:"nullable"`
+	Items                *schema            `json:"items"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *schema            `json:"additionalProperties"`
+}
+
+// success returns the status and media types of the operation's success.
+// 304 Not Modified answers conditional requests, which clients don't send.
+func (op *operation) success() (string, *response) {
+	for status, resp := range op.Responses {
+		if strings.HasPrefix(status, "2") || strings.HasPrefix(status, "3") && status != "304" {
+			return status, resp
+		}
+	}
+	return "", nil
+}
+
+// params returns the parameters of the operation found in a place.
+func (op *operation) params(in string) []parameter {
+	var params []parameter
+	for _, param := range op.Parameters {
+		if param.In == in {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// operations returns the operations for clients, in the order of their
+// paths and methods.
+func (d *document) operations() []*operation {
+	var ops []*operation
+	for path, methods := range d.Paths {
+		for method, op := range methods {
+			if !op.Internal {
+				op.method, op.path = strings.ToUpper(method), path
+				ops = append(ops, op)
+			}
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].path != ops[j].path {
+			return ops[i].path < ops[j].path
+		}
+		return ops[i].method < ops[j].method
+	})
+	return ops
+}
+
+// isObject reports whether a schema is an object of known properties,
+// which clients declare a type for.
+func (s *schema) isObject() bool {
+	return s.Ref != "" || s.Type == "object" && len(s.Properties) > 0
+}
+
+func (s *schema) required(name string) bool {
+	for _, required := range s.Required {
+		if required == name {
+			return true
+		}
+	}
+	return false
+}
+
+func refName(ref string) string {
+	return strings.TrimPrefix(ref, "#/components/schemas/")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// initialisms are written in capitals in Go names.
+var initialisms = map[string]string{
+	"api": "API", "cnae": "CNAE", "cnpj": "CNPJ", "cpf": "CPF", "dpi": "DPI",
+	"html": "HTML", "http": "HTTP", "id": "ID", "ids": "IDs", "json": "JSON",
+	"ocr": "OCR", "pdf": "PDF", "sha256": "SHA256", "ttl": "TTL", "url": "URL",
+	"urls": "URLs", "uuid": "UUID",
+}
+
+// words splits a snake_case or camelCase name.
+func words(name string) []string {
+	var out []string
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		start := 0
+		for i, r := range part {
+			if i > 0 && unicode.IsUpper(r) && !unicode.IsUpper(rune(part[i-1])) {
+				out = append(out, part[start:i])
+				start = i
+			}
+		}
+		out = append(out, part[start:])
+	}
+	return out
+}
+
+// exported returns the exported Go name of an API name: tender_id is
+// TenderID.
+func exported(name string) string {
+	var b strings.Builder
+	for _, word := range words(name) {
+		if initialism, ok := initialisms[strings.ToLower(word)]; ok {
+			b.WriteString(initialism)
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// unexported returns the unexported Go name of an API name: tender_id is
+// tenderID.
+func unexported(name string) string {
+	parts := words(name)
+	if len(parts) == 0 {
+		return name
+	}
+	first := strings.ToLower(parts[0])
+	return first + exported(strings.Join(parts[1:], "_"))
+}
+
+// singular names an item of a list named name.
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "ches"), strings.HasSuffix(name, "shes"),
+		strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"):
+		return strings.TrimSuffix(name, "es")
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss"):
+		return strings.TrimSuffix(name, "s")
+	}
+	return name
+}
+
+func main() {
+	goOut := flag.String("o", "client_gen.go", "file of the Go client")
+	tsOut := flag.String("ts", "", "file of the TypeScript client, none when empty")
+	flag.Parse()
+
+	data, err := api.OpenAPI()
+	if err != nil {
+		log.Fatalf("Failed to build the OpenAPI document: %v", err)
+	}
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		log.Fatalf("Failed to read the OpenAPI document: %v", err)
+	}
+
+	source, err := generateGo(&doc)
+	if err != nil {
+		log.Fatalf("Failed to generate the Go client: %v", err)
+	}
+	write(*goOut, source)
+	if *tsOut != "" {
+		write(*tsOut, generateTypeScript(&doc))
+	}
+}
+
+func write(path string, data []byte) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Fatalf("Failed to write %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// tsRuntime is the part of the TypeScript client the methods share.
+const tsRuntime = `export class APIError extends Error {
+  constructor(
+    public readonly status: number,
+    message: string,
+    public readonly code?: string,
+  ) {
+    super(message);
+    this.name = "APIError";
+  }
+}
+
+type Query = Record<string, string | number | undefined>;
+
+export class PdfProcessorClient {
+  /**
+   * @param baseURL the PDF processor, such as http://pdf-processor:8080
+   * @param init options of every request, credentials for instance
+   */
+  constructor(
+    private readonly baseURL: string,
+    private readonly init: RequestInit = {},
+  ) {}
+
+  private async send(method: string, path: string, query?: Query, body?: BodyInit, contentType?: string): Promise<Response> {
+    let target = this.baseURL.replace(/\/+$/, "") + path;
+    const search = new URLSearchParams();
+    for (const [name, value] of Object.entries(query ?? {})) {
+      if (value !== undefined && value !== "" && value !== 0) {
+        search.set(name, String(value));
+      }
+    }
+    if (search.toString() !== "") {
+      target += "?" + search.toString();
+    }
+
+    const headers = new Headers(this.init.headers);
+    if (contentType) {
+      headers.set("Content-Type", contentType);
+    }
+    const response = await fetch(target, { ...this.init, method, headers, body });
+    if (!response.ok) {
+      let message = response.statusText;
+      let code: string | undefined;
+      try {
+        const error = await response.json();
+        message = error.error ?? message;
+        code = error.code;
+      } catch {
+        // not a JSON error
+      }
+      throw new APIError(response.status, message, code);
+    }
+    return response;
+  }
+
+  private request(method: string, path: string, query?: Query, body?: unknown): Promise<Response> {
+    return body === undefined
+      ? this.send(method, path, query)
+      : this.send(method, path, query, JSON.stringify(body), "application/json");
+  }
+
+  private async json<T>(method: string, path: string, query?: Query, body?: unknown): Promise<T> {
+    const response = await this.request(method, path, query, body);
+    return (await response.json()) as T;
+  }
+
+  private async upload<T>(path: string, file: Blob, filename: string, fields: Record<string, string>): Promise<T> {
+    const form = new FormData();
+    for (const [name, value] of Object.entries(fields)) {
+      form.append(name, value);
+    }
+    form.append("file", file, filename);
+    const response = await this.send("POST", path, undefined, form);
+    return (await response.json()) as T;
+  }
+`
+
+type tsGenerator struct {
+	doc      *document
+	methods  bytes.Buffer
+	declared map[string]string
+}
+
+// generateTypeScript generates the TypeScript client: the types its
+// methods use, by name, and a class of the methods.
+func generateTypeScript(doc *document) []byte {
+	g := &tsGenerator{doc: doc, declared: make(map[string]string)}
+	for _, op := range doc.operations() {
+		g.methods.WriteString("\n")
+		g.method(op)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by gen from the OpenAPI document of the API. DO NOT EDIT.\n\n")
+	for _, name := range sortedKeys(g.declared) {
+		out.WriteString(g.declared[name])
+	}
+	out.WriteString(tsRuntime)
+	out.Write(g.methods.Bytes())
+	out.WriteString("}\n")
+	return out.Bytes()
+}
+
+// declare declares an interface of the properties of an object schema.
+func (g *tsGenerator) declare(name string, s *schema) string {
+	if _, ok := g.declared[name]; !ok {
+		g.declared[name] = ""
+		g.declared[name] = fmt.Sprintf("export interface %s %s\n\n", name, g.object(s, ""))
+	}
+	return name
+}
+
+// named returns the type of a request or response, declaring it under
+// name when it is an object of its own.
+func (g *tsGenerator) named(s *schema, name string) string {
+	if s.Ref == "" && s.isObject() {
+		return g.declare(name, s)
+	}
+	return g.tsType(s, "")
+}
+
+// object writes an object type literal, indented by indent.
+func (g *tsGenerator) object(s *schema, indent string) string {
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, property := range sortedKeys(s.Properties) {
+		optional := "?"
+		if s.required(property) {
+			optional = ""
+		}
+		fmt.Fprintf(&b, "%s  %s%s: %s;\n", indent, tsProperty(property), optional, g.tsType(s.Properties[property], indent+"  "))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+func (g *tsGenerator) tsType(s *schema, indent string) string {
+	var t string
+	switch {
+	case s.Ref != "":
+		return g.declare(refName(s.Ref), g.doc.Components.Schemas[refName(s.Ref)])
+	case s.Type == "string":
+		t = "string"
+	case s.Type == "integer", s.Type == "number":
+		t = "number"
+	case s.Type == "boolean":
+		t = "boolean"
+	case s.Type == "array" && s.Items != nil:
+		item := g.tsType(s.Items, indent)
+		if strings.Contains(item, " ") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case s.Type == "object" && s.AdditionalProperties != nil:
+		return "Record<string, " + g.tsType(s.AdditionalProperties, indent) + ">"
+	case s.Type == "object" && len(s.Properties) > 0:
+		return g.object(s, indent)
+	case s.Type == "object":
+		return "Record<string, unknown>"
+	default:
+		return "unknown"
+	}
+	if s.Nullable {
+		return t + " | null"
+	}
+	return t
+}
+
+// tsProperty quotes the property names that are not identifiers.
+func tsProperty(name string) string {
+	for i, r := range name {
+		if !(r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return fmt.Sprintf("%q", name)
+		}
+	}
+	return name
+}
+
+func (g *tsGenerator) method(op *operation) {
+	out := &g.methods
+	name := unexported(op.OperationID)
+	var args []string
+
+	path := op.path
+	for _, param := range op.params("path") {
+		arg := unexported(param.Name)
+		args = append(args, arg+": "+g.tsType(param.Schema, ""))
+		path = strings.Replace(path, "{"+param.Name+"}", "${encodeURIComponent("+arg+")}", 1)
+	}
+	path = "`" + path + "`"
+
+	query := "undefined"
+	if params := op.params("query"); len(params) > 0 {
+		var fields strings.Builder
+		for _, param := range params {
+			fmt.Fprintf(&fields, "  %s?: %s;\n", tsProperty(param.Name), g.tsType(param.Schema, ""))
+		}
+		paramsType := exported(op.OperationID) + "Params"
+		g.declared[paramsType] = fmt.Sprintf("export type %s = {\n%s};\n\n", paramsType, fields.String())
+		args = append(args, "params: "+paramsType+" = {}")
+		query = "params"
+	}
+
+	body, multipart := "", false
+	if op.RequestBody != nil {
+		if media := op.RequestBody.Content["application/json"]; media != nil {
+			args = append(args, "body: "+g.named(media.Schema, exported(op.OperationID)+"Request"))
+			body = ", body"
+		} else {
+			args = append(args, "file: Blob", "filename: string", "fields: Record<string, string> = {}")
+			multipart = true
+		}
+	}
+
+	status, resp := op.success()
+	var media *mediaType
+	if resp != nil {
+		media = resp.Content["application/json"]
+	}
+
+	fmt.Fprintf(out, "  /** %s %s: %s. */\n", op.method, op.path, strings.ToLower(op.Summary[:1])+op.Summary[1:])
+	signature := fmt.Sprintf("  %s(%s)", name, strings.Join(args, ", "))
+	switch {
+	case media != nil:
+		result := g.named(media.Schema, exported(op.OperationID)+"Response")
+		if multipart {
+			fmt.Fprintf(out, "%s: Promise<%s> {\n    return this.upload(%s, file, filename, fields);\n  }\n", signature, result, path)
+		} else {
+			fmt.Fprintf(out, "%s: Promise<%s> {\n    return this.json(%q, %s, %s%s);\n  }\n", signature, result, op.method, path, query, body)
+		}
+	case resp != nil && (len(resp.Content) > 0 || strings.HasPrefix(status, "3")):
+		// Files and streams; redirects are followed to the file
+		fmt.Fprintf(out, "%s: Promise<Response> {\n    return this.send(%q, %s, %s);\n  }\n", signature, op.method, path, query)
+	default:
+		fmt.Fprintf(out, "%s: Promise<void> {\n    await this.request(%q, %s, %s%s);\n  }\n", strings.Replace(signature, "  "+name, "  async "+name, 1), op.method, path, query, body)
+	}
+}
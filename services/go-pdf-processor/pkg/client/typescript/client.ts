@@ -0,0 +1,1693 @@
+// Code generated by gen from the OpenAPI document of the API. DO NOT EDIT.
+
+export interface APIKey {
+  created_at?: string;
+  created_by?: string;
+  expires_at?: string | null;
+  id?: string;
+  key?: string;
+  last_used_at?: string | null;
+  name?: string;
+  prefix?: string;
+  revoked_at?: string | null;
+  rotated_at?: string | null;
+  scopes?: string[];
+  tenant_id?: string;
+}
+
+export interface APIKeyUsage {
+  day?: string;
+  requests?: number;
+  submissions?: number;
+}
+
+export interface AnalyticsExport {
+  documents?: number;
+  entities?: number;
+  error?: string;
+  files?: string[];
+  finished_at?: string | null;
+  from?: string;
+  id?: string;
+  incremental?: boolean;
+  risks?: number;
+  started_at?: string;
+  status?: string;
+  to?: string;
+  trigger?: string;
+}
+
+export interface AnalyticsExportRequest {
+  from?: string | null;
+  to?: string | null;
+}
+
+export interface ApiKeyRequest {
+  expires_at?: string | null;
+  name: string;
+  scopes?: string[];
+}
+
+export interface Artifact {
+  bucket?: string;
+  expires_at?: string;
+  key?: string;
+  url?: string;
+}
+
+export interface ArtifactsV2 {
+  annotated_pdf?: Artifact;
+  archive?: Artifact;
+  ocr_layout?: Artifact;
+  redacted_pdf?: Artifact;
+  report?: Artifact;
+  searchable_pdf?: Artifact;
+}
+
+export interface Attachment {
+  bucket?: string;
+  content_type?: string;
+  job_id?: string;
+  key?: string;
+  name?: string;
+  page?: number;
+  size?: number;
+}
+
+export interface AutoscalerStats {
+  decisions?: ScalingDecision[];
+  max_workers?: number;
+  min_workers?: number;
+  scale_downs?: number;
+  scale_ups?: number;
+}
+
+export interface BiddingItem {
+  description?: string;
+  estimated_total_cents?: number;
+  estimated_unit_cents?: number;
+  item?: string;
+  page?: number;
+  quantity?: number;
+  source?: string;
+  unit?: string;
+}
+
+export interface BillingExport {
+  delivered?: boolean;
+  error?: string;
+  file?: string;
+  finished_at?: string | null;
+  from?: string;
+  id?: string;
+  incremental?: boolean;
+  records?: number;
+  started_at?: string;
+  status?: string;
+  to?: string;
+  trigger?: string;
+}
+
+export interface BillingExportRequest {
+  from?: string | null;
+  to?: string | null;
+}
+
+export interface BoundingBox {
+  height?: number;
+  page?: number;
+  width?: number;
+  x?: number;
+  y?: number;
+}
+
+export interface CancelJobResponse {
+  job_id?: string;
+  status?: string;
+}
+
+export interface ClearResultCacheResponse {
+  invalidated?: number;
+}
+
+export interface Company {
+  cnae?: string;
+  cnae_descricao?: string;
+  cnpj?: string;
+  nome_fantasia?: string;
+  razao_social?: string;
+  situacao_cadastral?: string;
+}
+
+export interface CompletePresignedUploadResponse {
+  job_id?: string;
+  status?: string;
+}
+
+export interface Condition {
+  distance?: number;
+  terms?: string[];
+  type?: string;
+  value?: string;
+}
+
+export interface CreatePresignedUploadResponse {
+  expires_at?: string;
+  job_id?: string;
+  method?: string;
+  upload_url?: string;
+}
+
+export type CreateRiskRuleParams = {
+  tenant_id?: string;
+};
+
+export type DeleteRiskRuleParams = {
+  tenant_id?: string;
+};
+
+export interface Delivery {
+  attempts?: number;
+  created_at?: string;
+  endpoint_id?: string;
+  error?: string;
+  event?: string;
+  id?: string;
+  job_id?: string;
+  last_attempt_at?: string | null;
+  next_attempt_at?: string | null;
+  payload?: unknown;
+  redelivery_of?: string;
+  status?: string;
+  status_code?: number;
+  tenant_id?: string;
+  url?: string;
+}
+
+export interface DrainProgress {
+  active_jobs?: number;
+  drained?: boolean;
+  draining?: boolean;
+  since?: string | null;
+  workers?: number;
+}
+
+export interface DryRunRequest {
+  job_id: string;
+  rules?: Rule[];
+}
+
+export interface DryRunRiskRulesResponse {
+  job_id?: string;
+  matches?: ({
+    end?: number;
+    name?: string;
+    position?: number;
+    rule_id?: string;
+  })[];
+  risk_analysis?: RiskAnalysis;
+}
+
+export interface Endpoint {
+  created_at?: string;
+  description?: string;
+  enabled?: boolean;
+  events?: string[];
+  id?: string;
+  secret?: string;
+  secret_rotated_at?: string | null;
+  tenant_id?: string;
+  updated_at?: string;
+  url?: string;
+}
+
+export interface EntityPattern {
+  confidence?: number;
+  created_at?: string;
+  entity_type?: string;
+  id?: string;
+  name?: string;
+  pattern?: string;
+  tenant_id?: string;
+  updated_at?: string;
+}
+
+export interface EntityPatternRequest {
+  confidence?: number;
+  entity_type: string;
+  name: string;
+  pattern: string;
+}
+
+export type ExportJobParams = {
+  format?: string;
+};
+
+export interface ExtractedEntity {
+  bounding_box?: BoundingBox;
+  company?: Company;
+  confidence?: number;
+  end_pos?: number;
+  normalized?: NormalizedValue;
+  page?: number;
+  start_pos?: number;
+  symbology?: string;
+  type?: string;
+  value?: string;
+}
+
+export interface ExtractedTable {
+  headers?: string[];
+  name?: string;
+  page?: number;
+  rows?: string[][];
+  source?: string;
+  truncated?: boolean;
+}
+
+export type GetAPIKeyUsageParams = {
+  days?: number;
+};
+
+export interface GetAPIKeyUsageResponse {
+  usage?: APIKeyUsage[];
+}
+
+export type GetGraphQLParams = {
+  query?: string;
+  operationName?: string;
+  variables?: string;
+};
+
+export interface GetGraphQLResponse {
+  data?: unknown;
+  errors?: ({
+    locations?: ({
+      column?: number;
+      line?: number;
+    })[];
+    message?: string;
+    path?: unknown[];
+  })[];
+}
+
+export interface GetJobStatusesResponse {
+  jobs?: JobUpdate[];
+  not_found?: string[];
+}
+
+export type GetPageImageParams = {
+  width?: number;
+  format?: string;
+};
+
+export type GetRiskRuleParams = {
+  tenant_id?: string;
+};
+
+export type GetTenantUsageParams = {
+  month?: string;
+};
+
+export type GetTenderParams = {
+  tenant_id?: string;
+};
+
+export type GetTenderUsageParams = {
+  month?: string;
+};
+
+export interface GetTenderUsageResponse {
+  month?: string;
+  tenant_id?: string;
+  tenders?: TenderUsage[];
+}
+
+export type GetTenderV2Params = {
+  tenant_id?: string;
+};
+
+export interface IdentifiedRisk {
+  category?: string;
+  confidence?: number;
+  description?: string;
+  end_pos?: number;
+  impact?: string;
+  location?: string;
+  page?: number;
+  section?: string;
+  severity?: string;
+  snippet?: string;
+  start_pos?: number;
+}
+
+export interface Instance {
+  active_jobs?: number;
+  alive?: boolean;
+  host?: string;
+  id?: string;
+  last_seen?: string;
+  started_at?: string;
+  workers?: number;
+}
+
+export interface InterestProfile {
+  cnae_codes?: string[];
+  created_at?: string;
+  id?: string;
+  keywords?: string[];
+  max_value_cents?: number;
+  min_value_cents?: number;
+  name?: string;
+  regions?: string[];
+  tenant_id?: string;
+  updated_at?: string;
+}
+
+export interface InterestProfileRequest {
+  cnae_codes?: string[];
+  keywords?: string[];
+  max_value_cents?: number;
+  min_value_cents?: number;
+  name: string;
+  regions?: string[];
+}
+
+export interface InvalidateCachedResultsResponse {
+  invalidated?: number;
+}
+
+export interface JobProgress {
+  pages_done?: number;
+  pages_total?: number;
+  percent?: number;
+  stage?: string;
+  updated_at?: string;
+}
+
+export interface JobRecord {
+  attempts?: number;
+  completed_at?: string | null;
+  created_at?: string;
+  error?: string;
+  error_code?: string;
+  filename?: string;
+  id?: string;
+  parent_id?: string;
+  priority?: string;
+  started_at?: string | null;
+  status?: string;
+  tenant_id?: string;
+  tender_id?: string;
+  updated_at?: string;
+  user_id?: string;
+}
+
+export interface JobStatusRequest {
+  job_ids: string[];
+}
+
+export interface JobUpdate {
+  error?: string;
+  error_code?: string;
+  job_id?: string;
+  next_retry_at?: string | null;
+  progress?: JobProgress;
+  status?: string;
+}
+
+export interface JobV2 {
+  attempts?: number;
+  callback_url?: string;
+  child_ids?: string[];
+  completed_at?: string | null;
+  created_at?: string;
+  depends_on?: string[];
+  error?: string;
+  error_code?: string;
+  file_url?: string;
+  id?: string;
+  metadata?: Record<string, unknown>;
+  next_retry_at?: string | null;
+  options?: ProcessingOptions;
+  parent_id?: string;
+  priority?: string;
+  profile?: string;
+  progress?: JobProgress;
+  result?: ResultV2;
+  run_at?: string | null;
+  started_at?: string | null;
+  status?: string;
+  tenant_id?: string;
+  tender_id?: string;
+  user_id?: string;
+}
+
+export interface ListAPIKeysResponse {
+  api_keys?: APIKey[];
+}
+
+export type ListAnalyticsExportsParams = {
+  limit?: number;
+};
+
+export interface ListAnalyticsExportsResponse {
+  exports?: AnalyticsExport[];
+}
+
+export type ListBillingExportsParams = {
+  limit?: number;
+};
+
+export interface ListBillingExportsResponse {
+  exports?: BillingExport[];
+}
+
+export type ListDeadLettersParams = {
+  limit?: number;
+  offset?: number;
+};
+
+export interface ListDeadLettersResponse {
+  jobs?: ProcessingJob[];
+  total?: number;
+}
+
+export interface ListEntityPatternsResponse {
+  patterns?: EntityPattern[];
+}
+
+export interface ListInstancesResponse {
+  instance_id?: string;
+  instances?: Instance[];
+}
+
+export interface ListInterestProfilesResponse {
+  profiles?: InterestProfile[];
+}
+
+export interface ListJobWebhooksResponse {
+  deliveries?: Delivery[];
+}
+
+export type ListJobsParams = {
+  tender_id?: string;
+  status?: string;
+  tenant_id?: string;
+  user_id?: string;
+  error_code?: string;
+  q?: string;
+  from?: string;
+  to?: string;
+  sort?: string;
+  page?: number;
+  page_size?: number;
+};
+
+export interface ListJobsResponse {
+  jobs?: JobRecord[];
+  page?: number;
+  page_size?: number;
+  total?: number;
+}
+
+export interface ListPipelinesResponse {
+  pipelines?: Pipeline[];
+  stages?: string[];
+}
+
+export interface ListProcessingProfilesResponse {
+  profiles?: ProcessingProfile[];
+}
+
+export interface ListReprocessingPoliciesResponse {
+  policies?: ReprocessingPolicy[];
+}
+
+export interface ListRiskProfilesResponse {
+  profiles?: RiskProfile[];
+}
+
+export type ListRiskRuleVersionsParams = {
+  tenant_id?: string;
+};
+
+export interface ListRiskRuleVersionsResponse {
+  versions?: RuleVersion[];
+}
+
+export type ListRiskRulesParams = {
+  tenant_id?: string;
+};
+
+export interface ListRiskRulesResponse {
+  rules?: Rule[];
+}
+
+export type ListTenderJobsParams = {
+  status?: string;
+  tenant_id?: string;
+  user_id?: string;
+  error_code?: string;
+  q?: string;
+  from?: string;
+  to?: string;
+  sort?: string;
+  page?: number;
+  page_size?: number;
+};
+
+export interface ListTenderJobsResponse {
+  jobs?: JobRecord[];
+  page?: number;
+  page_size?: number;
+  total?: number;
+}
+
+export type ListWebhookDeliveriesParams = {
+  limit?: number;
+  offset?: number;
+};
+
+export interface ListWebhookDeliveriesResponse {
+  deliveries?: Delivery[];
+  total?: number;
+}
+
+export interface ListWebhookEndpointsResponse {
+  endpoints?: Endpoint[];
+}
+
+export interface NormalizedValue {
+  cents?: number | null;
+  date?: string;
+}
+
+export interface OCRZone {
+  detect?: string;
+  height?: number;
+  name?: string;
+  page?: number;
+  width?: number;
+  x?: number;
+  y?: number;
+}
+
+export interface PageV2 {
+  blocks?: TextBlock[];
+  entities?: ExtractedEntity[];
+  number?: number;
+  tables?: ExtractedTable[];
+  text?: string;
+}
+
+export interface Pipeline {
+  created_at?: string;
+  description?: string;
+  id?: string;
+  name?: string;
+  stages?: PipelineStage[];
+  tenant_id?: string;
+  updated_at?: string;
+}
+
+export interface PipelineRequest {
+  description?: string;
+  name: string;
+  stages: PipelineStage[];
+}
+
+export interface PipelineStage {
+  name?: string;
+  options?: unknown;
+}
+
+export interface PoolStats {
+  active_jobs?: number;
+  autoscaler?: AutoscalerStats;
+  average_processing_time?: number;
+  failed_jobs?: number;
+  last_processed?: string;
+  processed_jobs?: number;
+  queued_jobs?: number;
+  resources?: ResourceUsage;
+  total_workers?: number;
+}
+
+export interface PostGraphQLRequest {
+  operationName?: string;
+  query?: string;
+  variables?: Record<string, unknown>;
+}
+
+export interface PostGraphQLResponse {
+  data?: unknown;
+  errors?: ({
+    locations?: ({
+      column?: number;
+      line?: number;
+    })[];
+    message?: string;
+    path?: unknown[];
+  })[];
+}
+
+export interface PresignRequest {
+  callback_url?: string;
+  content_type?: string;
+  depends_on?: string[];
+  filename: string;
+  interest_profile_id?: string;
+  options?: unknown;
+  priority?: string;
+  profile?: string;
+  run_at?: string;
+  size?: number;
+  tenant_id?: string;
+  tender_id?: string;
+  user_id?: string;
+}
+
+export interface ProcessingJob {
+  attempts?: number;
+  callback_url?: string;
+  child_ids?: string[];
+  completed_at?: string | null;
+  created_at?: string;
+  depends_on?: string[];
+  error?: string;
+  error_code?: string;
+  file_url?: string;
+  id?: string;
+  metadata?: Record<string, unknown>;
+  next_retry_at?: string | null;
+  options?: ProcessingOptions;
+  parent_id?: string;
+  priority?: string;
+  profile?: string;
+  progress?: JobProgress;
+  result?: ProcessingResult;
+  run_at?: string | null;
+  started_at?: string | null;
+  status?: string;
+  tenant_id?: string;
+  tender_id?: string;
+  user_id?: string;
+}
+
+export interface ProcessingOptions {
+  analyze_risks?: boolean;
+  annotate_risks?: boolean;
+  archive_pdfa?: boolean;
+  auto_rotate?: boolean;
+  classify_document?: boolean;
+  correct_spelling?: boolean;
+  detect_barcodes?: boolean;
+  detect_tables?: boolean;
+  dpi?: number;
+  enable_ocr?: boolean;
+  enrich_entities?: boolean;
+  extract_attachments?: boolean;
+  extract_entities?: boolean;
+  extract_items?: boolean;
+  generate_report?: boolean;
+  generate_score?: boolean;
+  languages?: string[];
+  layout_text?: boolean;
+  max_pages?: number;
+  ner_provider?: string;
+  ocr_layout?: string;
+  ocr_parallelism?: number;
+  ocr_provider?: string;
+  ocr_zones?: OCRZone[];
+  password?: string;
+  pipeline?: string;
+  preprocess_images?: boolean;
+  process_attachments?: boolean;
+  recover_corrupted?: boolean;
+  redact_pii?: boolean;
+  reflow_text?: boolean;
+  reprocess?: boolean;
+  risk_profile?: string;
+  searchable_pdf?: boolean;
+  segment_sections?: boolean;
+  stages?: string[];
+  stream_pages?: boolean;
+  text_blocks?: boolean;
+  timeout_seconds?: number;
+  verify_signatures?: boolean;
+}
+
+export interface ProcessingProfile {
+  created_at?: string;
+  id?: string;
+  name?: string;
+  options?: ProcessingOptions;
+  tenant_id?: string;
+  updated_at?: string;
+}
+
+export interface ProcessingProfileRequest {
+  name: string;
+  options?: ProcessingOptions;
+}
+
+export interface ProcessingResult {
+  annotated_pdf?: Artifact;
+  archive?: Artifact;
+  attachments?: Attachment[];
+  blocks?: TextBlock[];
+  classification?: Result;
+  content_sha256?: string;
+  deduplicated?: boolean;
+  deduplicated_from?: string;
+  entities?: ExtractedEntity[];
+  extracted_text?: string;
+  file_size?: number;
+  items?: BiddingItem[];
+  metadata?: Record<string, unknown>;
+  ocr_layout?: Artifact;
+  page_count?: number;
+  page_offsets?: number[];
+  processing_time?: number;
+  quality_metrics?: QualityMetrics;
+  redacted_pdf?: Artifact;
+  relevance?: RelevanceMatch;
+  relevance_score?: number;
+  report?: Artifact;
+  risk_analysis?: RiskAnalysis;
+  searchable_pdf?: Artifact;
+  sections?: Section[];
+  signatures?: Signature[];
+  tables?: ExtractedTable[];
+  zones?: ZoneText[];
+}
+
+export interface QualityMetrics {
+  completeness?: number;
+  document_clarity?: number;
+  low_confidence_pages?: number[];
+  ocr_confidence?: number;
+  ocr_corrections?: number;
+  readability?: number;
+  text_quality?: number;
+}
+
+export interface Quota {
+  ocr_pages?: number;
+  pages?: number;
+  storage_bytes?: number;
+}
+
+export interface RelevanceMatch {
+  cnae_codes?: string[];
+  estimated_value_cents?: number;
+  keywords?: string[];
+  profile_id?: string;
+  profile_name?: string;
+  regions?: string[];
+  value_in_range?: boolean;
+}
+
+export interface ReplayDeadLetterResponse {
+  job_id?: string;
+  status?: string;
+}
+
+export interface ReprocessingFilter {
+  document_types?: string[];
+  since?: string | null;
+  tender_id?: string;
+}
+
+export interface ReprocessingPolicy {
+  created_at?: string;
+  enabled?: boolean;
+  filter?: ReprocessingFilter;
+  id?: string;
+  last_run?: ReprocessingRun;
+  name?: string;
+  next_run_at?: string | null;
+  options?: unknown;
+  priority?: string;
+  profile?: string;
+  schedule?: string;
+  tenant_id?: string;
+  timezone?: string;
+  updated_at?: string;
+}
+
+export interface ReprocessingPolicyRequest {
+  enabled?: boolean | null;
+  filter?: ReprocessingFilter;
+  name: string;
+  options?: unknown;
+  priority?: string;
+  profile?: string;
+  schedule: string;
+  timezone?: string;
+}
+
+export interface ReprocessingRun {
+  error?: string;
+  finished_at?: string;
+  queued?: number;
+  started_at?: string;
+  truncated?: boolean;
+}
+
+export interface ResizeWorkersRequest {
+  workers: number;
+}
+
+export interface ResourceUsage {
+  cpu_percent?: number;
+  free_disk_bytes?: number;
+  memory_bytes?: number;
+  memory_limit_bytes?: number;
+  memory_percent?: number;
+  rss_bytes?: number;
+  sampled_at?: string;
+}
+
+export interface Result {
+  confidence?: number;
+  label?: string;
+  source?: string;
+  type?: string;
+}
+
+export interface ResultV2 {
+  artifacts?: ArtifactsV2;
+  attachments?: Attachment[];
+  classification?: Result;
+  content_sha256?: string;
+  deduplicated_from?: string;
+  file_size?: number;
+  items?: BiddingItem[];
+  metadata?: Record<string, unknown>;
+  page_count?: number;
+  pages?: PageV2[];
+  processing_time_ms?: number;
+  quality_metrics?: QualityMetrics;
+  relevance?: RelevanceMatch;
+  relevance_score?: number;
+  risk_analysis?: RiskAnalysis;
+  sections?: Section[];
+  signatures?: Signature[];
+  unpaged?: PageV2;
+  zones?: ZoneText[];
+}
+
+export interface RiskAnalysis {
+  confidence?: number;
+  identified_risks?: IdentifiedRisk[];
+  overall_risk?: string;
+  profile?: string;
+  profile_id?: string;
+  recommendations?: string[];
+  risk_score?: number;
+}
+
+export interface RiskProfile {
+  categories?: Record<string, number>;
+  created_at?: string;
+  id?: string;
+  name?: string;
+  rules?: Record<string, number>;
+  tenant_id?: string;
+  updated_at?: string;
+}
+
+export interface RiskProfileRequest {
+  categories?: Record<string, number>;
+  name: string;
+  rules?: Record<string, number>;
+}
+
+export interface RiskRuleRequest {
+  category?: string;
+  conditions: Condition[];
+  enabled?: boolean | null;
+  impact?: string;
+  name: string;
+  recommendation?: string;
+  severity?: string;
+  version?: number;
+  weight?: number;
+}
+
+export interface Rule {
+  category?: string;
+  conditions?: Condition[];
+  enabled?: boolean;
+  id?: string;
+  impact?: string;
+  name?: string;
+  recommendation?: string;
+  severity?: string;
+  tenant_id?: string;
+  updated_at?: string;
+  version?: number;
+  weight?: number;
+}
+
+export interface RuleVersion {
+  created_at?: string;
+  rule?: Rule;
+  version?: number;
+}
+
+export interface ScalingDecision {
+  active_jobs?: number;
+  at?: string;
+  average_processing_time?: number;
+  from?: number;
+  queued_jobs?: number;
+  reason?: string;
+  to?: number;
+}
+
+export type SearchJobsParams = {
+  tender_id?: string;
+  status?: string;
+  tenant_id?: string;
+  user_id?: string;
+  error_code?: string;
+  q?: string;
+  from?: string;
+  to?: string;
+  sort?: string;
+  page?: number;
+  page_size?: number;
+};
+
+export interface SearchJobsResponse {
+  jobs?: JobRecord[];
+  page?: number;
+  page_size?: number;
+  total?: number;
+}
+
+export interface Section {
+  end_page?: number;
+  end_pos?: number;
+  start_page?: number;
+  start_pos?: number;
+  title?: string;
+  type?: string;
+}
+
+export interface Signature {
+  chain_valid?: boolean;
+  covers_document?: boolean;
+  errors?: string[];
+  icp_brasil?: boolean;
+  intact?: boolean;
+  issuer?: string;
+  pades?: boolean;
+  revocation?: string;
+  revoked_at?: string | null;
+  signer_cnpj?: string;
+  signer_name?: string;
+  signing_time?: string | null;
+  sub_filter?: string;
+  valid?: boolean;
+}
+
+export interface SubmitTenderResponse {
+  file_job_ids?: string[];
+  job_id?: string;
+  status?: string;
+  tender_id?: string;
+}
+
+export interface TenantQuota {
+  ocr_pages?: number | null;
+  pages?: number | null;
+  storage_bytes?: number | null;
+  tenant_id?: string;
+  updated_at?: string | null;
+}
+
+export interface TenantUsage {
+  month?: string;
+  quota?: Quota;
+  resets_at?: string;
+  tenant_id?: string;
+  usage?: Usage;
+}
+
+export interface TenderFile {
+  name?: string;
+  url?: string;
+}
+
+export interface TenderRequest {
+  callback_url?: string;
+  files: TenderFile[];
+  interest_profile_id?: string;
+  options?: unknown;
+  priority?: string;
+  profile?: string;
+  run_at?: string;
+  tenant_id?: string;
+  tender_id: string;
+  user_id?: string;
+}
+
+export interface TenderUsage {
+  documents?: number;
+  ocr_pages?: number;
+  ocr_seconds?: number;
+  pages?: number;
+  storage_bytes?: number;
+  tender_id?: string;
+}
+
+export interface TextBlock {
+  column?: number;
+  end_pos?: number;
+  page?: number;
+  start_pos?: number;
+  text?: string;
+  type?: string;
+}
+
+export type UpdateRiskRuleParams = {
+  tenant_id?: string;
+};
+
+export interface UploadDocumentResponse {
+  job_id?: string;
+  status?: string;
+}
+
+export interface Usage {
+  documents?: number;
+  ocr_pages?: number;
+  pages?: number;
+  storage_bytes?: number;
+}
+
+export interface WebhookEndpointRequest {
+  description?: string;
+  enabled?: boolean | null;
+  events?: string[];
+  url: string;
+}
+
+export interface ZoneText {
+  box?: BoundingBox;
+  confidence?: number;
+  kind?: string;
+  name?: string;
+  text?: string;
+}
+
+export class APIError extends Error {
+  constructor(
+    public readonly status: number,
+    message: string,
+    public readonly code?: string,
+  ) {
+    super(message);
+    this.name = "APIError";
+  }
+}
+
+type Query = Record<string, string | number | undefined>;
+
+export class PdfProcessorClient {
+  /**
+   * @param baseURL the PDF processor, such as http://pdf-processor:8080
+   * @param init options of every request, credentials for instance
+   */
+  constructor(
+    private readonly baseURL: string,
+    private readonly init: RequestInit = {},
+  ) {}
+
+  private async send(method: string, path: string, query?: Query, body?: BodyInit, contentType?: string): Promise<Response> {
+    let target = this.baseURL.replace(/\/+$/, "") + path;
+    const search = new URLSearchParams();
+    for (const [name, value] of Object.entries(query ?? {})) {
+      if (value !== undefined && value !== "" && value !== 0) {
+        search.set(name, String(value));
+      }
+    }
+    if (search.toString() !== "") {
+      target += "?" + search.toString();
+    }
+
+    const headers = new Headers(this.init.headers);
+    if (contentType) {
+      headers.set("Content-Type", contentType);
+    }
+    const response = await fetch(target, { ...this.init, method, headers, body });
+    if (!response.ok) {
+      let message = response.statusText;
+      let code: string | undefined;
+      try {
+        const error = await response.json();
+        message = error.error ?? message;
+        code = error.code;
+      } catch {
+        // not a JSON error
+      }
+      throw new APIError(response.status, message, code);
+    }
+    return response;
+  }
+
+  private request(method: string, path: string, query?: Query, body?: unknown): Promise<Response> {
+    return body === undefined
+      ? this.send(method, path, query)
+      : this.send(method, path, query, JSON.stringify(body), "application/json");
+  }
+
+  private async json<T>(method: string, path: string, query?: Query, body?: unknown): Promise<T> {
+    const response = await this.request(method, path, query, body);
+    return (await response.json()) as T;
+  }
+
+  private async upload<T>(path: string, file: Blob, filename: string, fields: Record<string, string>): Promise<T> {
+    const form = new FormData();
+    for (const [name, value] of Object.entries(fields)) {
+      form.append(name, value);
+    }
+    form.append("file", file, filename);
+    const response = await this.send("POST", path, undefined, form);
+    return (await response.json()) as T;
+  }
+
+  /** GET /api/v1/admin/analytics-exports: the latest exports of results to the data warehouse. */
+  listAnalyticsExports(params: ListAnalyticsExportsParams = {}): Promise<ListAnalyticsExportsResponse> {
+    return this.json("GET", `/api/v1/admin/analytics-exports`, params);
+  }
+
+  /** POST /api/v1/admin/analytics-exports: export results as Parquet, those completed since the last export or in a range. */
+  startAnalyticsExport(body: AnalyticsExportRequest): Promise<AnalyticsExport> {
+    return this.json("POST", `/api/v1/admin/analytics-exports`, undefined, body);
+  }
+
+  /** GET /api/v1/admin/analytics-exports/{id}: an export of results to the data warehouse. */
+  getAnalyticsExport(id: string): Promise<AnalyticsExport> {
+    return this.json("GET", `/api/v1/admin/analytics-exports/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** GET /api/v1/admin/billing-exports: the latest exports of usage records for billing. */
+  listBillingExports(params: ListBillingExportsParams = {}): Promise<ListBillingExportsResponse> {
+    return this.json("GET", `/api/v1/admin/billing-exports`, params);
+  }
+
+  /** POST /api/v1/admin/billing-exports: export usage records as CSV, those metered since the last export or in a range. */
+  startBillingExport(body: BillingExportRequest): Promise<BillingExport> {
+    return this.json("POST", `/api/v1/admin/billing-exports`, undefined, body);
+  }
+
+  /** GET /api/v1/admin/billing-exports/{id}: an export of usage records for billing. */
+  getBillingExport(id: string): Promise<BillingExport> {
+    return this.json("GET", `/api/v1/admin/billing-exports/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** GET /api/v1/admin/instances: the replicas sharing the job queue. */
+  listInstances(): Promise<ListInstancesResponse> {
+    return this.json("GET", `/api/v1/admin/instances`, undefined);
+  }
+
+  /** GET /api/v1/admin/tenants/{tenant}/quota: the monthly quota set for a tenant. */
+  getTenantQuota(tenant: string): Promise<TenantQuota> {
+    return this.json("GET", `/api/v1/admin/tenants/${encodeURIComponent(tenant)}/quota`, undefined);
+  }
+
+  /** PUT /api/v1/admin/tenants/{tenant}/quota: set the monthly quota of a tenant, null fields keeping the defaults. */
+  setTenantQuota(tenant: string, body: TenantQuota): Promise<TenantQuota> {
+    return this.json("PUT", `/api/v1/admin/tenants/${encodeURIComponent(tenant)}/quota`, undefined, body);
+  }
+
+  /** GET /api/v1/admin/workers: statistics of the workers of this replica. */
+  getWorkers(): Promise<PoolStats> {
+    return this.json("GET", `/api/v1/admin/workers`, undefined);
+  }
+
+  /** PATCH /api/v1/admin/workers: change the number of workers of this replica. */
+  resizeWorkers(body: ResizeWorkersRequest): Promise<PoolStats> {
+    return this.json("PATCH", `/api/v1/admin/workers`, undefined, body);
+  }
+
+  /** DELETE /api/v1/admin/workers/drain: take jobs again after draining. */
+  resumeWorkers(): Promise<DrainProgress> {
+    return this.json("DELETE", `/api/v1/admin/workers/drain`, undefined);
+  }
+
+  /** GET /api/v1/admin/workers/drain: progress of draining this replica. */
+  getDrain(): Promise<DrainProgress> {
+    return this.json("GET", `/api/v1/admin/workers/drain`, undefined);
+  }
+
+  /** POST /api/v1/admin/workers/drain: stop taking jobs and finish those running. */
+  drainWorkers(): Promise<DrainProgress> {
+    return this.json("POST", `/api/v1/admin/workers/drain`, undefined);
+  }
+
+  /** GET /api/v1/dead-letters: page through the jobs that exhausted their retries. */
+  listDeadLetters(params: ListDeadLettersParams = {}): Promise<ListDeadLettersResponse> {
+    return this.json("GET", `/api/v1/dead-letters`, params);
+  }
+
+  /** DELETE /api/v1/dead-letters/{id}: remove a dead-lettered job. */
+  async deleteDeadLetter(id: string): Promise<void> {
+    await this.request("DELETE", `/api/v1/dead-letters/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** GET /api/v1/dead-letters/{id}: a dead-lettered job. */
+  getDeadLetter(id: string): Promise<ProcessingJob> {
+    return this.json("GET", `/api/v1/dead-letters/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** POST /api/v1/dead-letters/{id}/replay: queue a dead-lettered job again. */
+  replayDeadLetter(id: string): Promise<ReplayDeadLetterResponse> {
+    return this.json("POST", `/api/v1/dead-letters/${encodeURIComponent(id)}/replay`, undefined);
+  }
+
+  /** POST /api/v1/documents: upload a document to be processed. */
+  uploadDocument(file: Blob, filename: string, fields: Record<string, string> = {}): Promise<UploadDocumentResponse> {
+    return this.upload(`/api/v1/documents`, file, filename, fields);
+  }
+
+  /** GET /api/v1/graphql: query jobs and results with GraphQL. */
+  getGraphQL(params: GetGraphQLParams = {}): Promise<GetGraphQLResponse> {
+    return this.json("GET", `/api/v1/graphql`, params);
+  }
+
+  /** POST /api/v1/graphql: query jobs and results with GraphQL. */
+  postGraphQL(body: PostGraphQLRequest): Promise<PostGraphQLResponse> {
+    return this.json("POST", `/api/v1/graphql`, undefined, body);
+  }
+
+  /** GET /api/v1/jobs: page through the job history. */
+  listJobs(params: ListJobsParams = {}): Promise<ListJobsResponse> {
+    return this.json("GET", `/api/v1/jobs`, params);
+  }
+
+  /** GET /api/v1/jobs/search: find the jobs of a tender or of a time range. */
+  searchJobs(params: SearchJobsParams = {}): Promise<SearchJobsResponse> {
+    return this.json("GET", `/api/v1/jobs/search`, params);
+  }
+
+  /** POST /api/v1/jobs/status: the status of many jobs at once. */
+  getJobStatuses(body: JobStatusRequest): Promise<GetJobStatusesResponse> {
+    return this.json("POST", `/api/v1/jobs/status`, undefined, body);
+  }
+
+  /** DELETE /api/v1/jobs/{id}: cancel a job. */
+  cancelJob(id: string): Promise<CancelJobResponse> {
+    return this.json("DELETE", `/api/v1/jobs/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** GET /api/v1/jobs/{id}: a job, with its result once completed. */
+  getJob(id: string): Promise<ProcessingJob> {
+    return this.json("GET", `/api/v1/jobs/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** GET /api/v1/jobs/{id}/annotated: redirect to a download URL of the job's annotated PDF. */
+  getJobAnnotated(id: string): Promise<Response> {
+    return this.send("GET", `/api/v1/jobs/${encodeURIComponent(id)}/annotated`, undefined);
+  }
+
+  /** GET /api/v1/jobs/{id}/archive: redirect to a download URL of the job's archive. */
+  getJobArchive(id: string): Promise<Response> {
+    return this.send("GET", `/api/v1/jobs/${encodeURIComponent(id)}/archive`, undefined);
+  }
+
+  /** GET /api/v1/jobs/{id}/events: stream the updates of a job as Server-Sent Events, or over a WebSocket. */
+  getJobEvents(id: string): Promise<Response> {
+    return this.send("GET", `/api/v1/jobs/${encodeURIComponent(id)}/events`, undefined);
+  }
+
+  /** GET /api/v1/jobs/{id}/export/{sheet}: export the entities, risks or items of a job's result, or all of them, for spreadsheets. */
+  exportJob(id: string, sheet: string, params: ExportJobParams = {}): Promise<Response> {
+    return this.send("GET", `/api/v1/jobs/${encodeURIComponent(id)}/export/${encodeURIComponent(sheet)}`, params);
+  }
+
+  /** GET /api/v1/jobs/{id}/ocr-layout: redirect to a download URL of the job's OCR layout. */
+  getJobOCRLayout(id: string): Promise<Response> {
+    return this.send("GET", `/api/v1/jobs/${encodeURIComponent(id)}/ocr-layout`, undefined);
+  }
+
+  /** GET /api/v1/jobs/{id}/pages/{n}/image: render a page of a job's document. */
+  getPageImage(id: string, n: number, params: GetPageImageParams = {}): Promise<Response> {
+    return this.send("GET", `/api/v1/jobs/${encodeURIComponent(id)}/pages/${encodeURIComponent(n)}/image`, params);
+  }
+
+  /** GET /api/v1/jobs/{id}/redacted: redirect to a download URL of the job's redacted PDF. */
+  getJobRedacted(id: string): Promise<Response> {
+    return this.send("GET", `/api/v1/jobs/${encodeURIComponent(id)}/redacted`, undefined);
+  }
+
+  /** GET /api/v1/jobs/{id}/report: redirect to a download URL of the job's report. */
+  getJobReport(id: string): Promise<Response> {
+    return this.send("GET", `/api/v1/jobs/${encodeURIComponent(id)}/report`, undefined);
+  }
+
+  /** GET /api/v1/jobs/{id}/result/stream: stream the result of a job as newline-delimited JSON records. */
+  streamJobResult(id: string): Promise<Response> {
+    return this.send("GET", `/api/v1/jobs/${encodeURIComponent(id)}/result/stream`, undefined);
+  }
+
+  /** GET /api/v1/jobs/{id}/searchable: redirect to a download URL of the job's searchable PDF. */
+  getJobSearchable(id: string): Promise<Response> {
+    return this.send("GET", `/api/v1/jobs/${encodeURIComponent(id)}/searchable`, undefined);
+  }
+
+  /** GET /api/v1/jobs/{id}/webhooks: the webhook deliveries of a job. */
+  listJobWebhooks(id: string): Promise<ListJobWebhooksResponse> {
+    return this.json("GET", `/api/v1/jobs/${encodeURIComponent(id)}/webhooks`, undefined);
+  }
+
+  /** POST /api/v1/jobs/{id}/webhooks/{delivery}/redeliver: send a webhook delivery of a job again. */
+  redeliverJobWebhook(id: string, delivery: string): Promise<Delivery> {
+    return this.json("POST", `/api/v1/jobs/${encodeURIComponent(id)}/webhooks/${encodeURIComponent(delivery)}/redeliver`, undefined);
+  }
+
+  /** GET /api/v1/openapi.json: the OpenAPI document of the API. */
+  getOpenAPI(): Promise<unknown> {
+    return this.json("GET", `/api/v1/openapi.json`, undefined);
+  }
+
+  /** POST /api/v1/presigned-uploads: create a job whose document is uploaded to a presigned URL. */
+  createPresignedUpload(body: PresignRequest): Promise<CreatePresignedUploadResponse> {
+    return this.json("POST", `/api/v1/presigned-uploads`, undefined, body);
+  }
+
+  /** POST /api/v1/presigned-uploads/{id}/complete: queue the job of a finished presigned upload. */
+  completePresignedUpload(id: string): Promise<CompletePresignedUploadResponse> {
+    return this.json("POST", `/api/v1/presigned-uploads/${encodeURIComponent(id)}/complete`, undefined);
+  }
+
+  /** DELETE /api/v1/result-cache: remove all cached results. */
+  clearResultCache(): Promise<ClearResultCacheResponse> {
+    return this.json("DELETE", `/api/v1/result-cache`, undefined);
+  }
+
+  /** DELETE /api/v1/result-cache/{sha256}: remove the cached results of a document. */
+  invalidateCachedResults(sha256: string): Promise<InvalidateCachedResultsResponse> {
+    return this.json("DELETE", `/api/v1/result-cache/${encodeURIComponent(sha256)}`, undefined);
+  }
+
+  /** GET /api/v1/risk-rules: the risk rules of a tenant. */
+  listRiskRules(params: ListRiskRulesParams = {}): Promise<ListRiskRulesResponse> {
+    return this.json("GET", `/api/v1/risk-rules`, params);
+  }
+
+  /** POST /api/v1/risk-rules: add a risk rule. */
+  createRiskRule(params: CreateRiskRuleParams = {}, body: RiskRuleRequest): Promise<Rule> {
+    return this.json("POST", `/api/v1/risk-rules`, params, body);
+  }
+
+  /** POST /api/v1/risk-rules/dry-run: evaluate risk rules against a processed job's text. */
+  dryRunRiskRules(body: DryRunRequest): Promise<DryRunRiskRulesResponse> {
+    return this.json("POST", `/api/v1/risk-rules/dry-run`, undefined, body);
+  }
+
+  /** DELETE /api/v1/risk-rules/{id}: remove a risk rule. */
+  async deleteRiskRule(id: string, params: DeleteRiskRuleParams = {}): Promise<void> {
+    await this.request("DELETE", `/api/v1/risk-rules/${encodeURIComponent(id)}`, params);
+  }
+
+  /** GET /api/v1/risk-rules/{id}: a risk rule. */
+  getRiskRule(id: string, params: GetRiskRuleParams = {}): Promise<Rule> {
+    return this.json("GET", `/api/v1/risk-rules/${encodeURIComponent(id)}`, params);
+  }
+
+  /** PUT /api/v1/risk-rules/{id}: replace a risk rule, given the version it replaces. */
+  updateRiskRule(id: string, params: UpdateRiskRuleParams = {}, body: RiskRuleRequest): Promise<Rule> {
+    return this.json("PUT", `/api/v1/risk-rules/${encodeURIComponent(id)}`, params, body);
+  }
+
+  /** GET /api/v1/risk-rules/{id}/versions: the versions of a risk rule. */
+  listRiskRuleVersions(id: string, params: ListRiskRuleVersionsParams = {}): Promise<ListRiskRuleVersionsResponse> {
+    return this.json("GET", `/api/v1/risk-rules/${encodeURIComponent(id)}/versions`, params);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/api-keys: the API keys of a tenant, revoked ones included. */
+  listAPIKeys(tenant: string): Promise<ListAPIKeysResponse> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/api-keys`, undefined);
+  }
+
+  /** POST /api/v1/tenants/{tenant}/api-keys: issue an API key, shown only in the response. */
+  issueAPIKey(tenant: string, body: ApiKeyRequest): Promise<APIKey> {
+    return this.json("POST", `/api/v1/tenants/${encodeURIComponent(tenant)}/api-keys`, undefined, body);
+  }
+
+  /** DELETE /api/v1/tenants/{tenant}/api-keys/{id}: revoke an API key. */
+  revokeAPIKey(tenant: string, id: string): Promise<APIKey> {
+    return this.json("DELETE", `/api/v1/tenants/${encodeURIComponent(tenant)}/api-keys/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/api-keys/{id}: an API key, without its secret. */
+  getAPIKey(tenant: string, id: string): Promise<APIKey> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/api-keys/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** POST /api/v1/tenants/{tenant}/api-keys/{id}/rotate: give an API key a new secret, the previous one working for a grace period. */
+  rotateAPIKey(tenant: string, id: string): Promise<APIKey> {
+    return this.json("POST", `/api/v1/tenants/${encodeURIComponent(tenant)}/api-keys/${encodeURIComponent(id)}/rotate`, undefined);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/api-keys/{id}/usage: the requests made with an API key by day. */
+  getAPIKeyUsage(tenant: string, id: string, params: GetAPIKeyUsageParams = {}): Promise<GetAPIKeyUsageResponse> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/api-keys/${encodeURIComponent(id)}/usage`, params);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/entity-patterns: the entity patterns of a tenant. */
+  listEntityPatterns(tenant: string): Promise<ListEntityPatternsResponse> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/entity-patterns`, undefined);
+  }
+
+  /** POST /api/v1/tenants/{tenant}/entity-patterns: add an entity pattern. */
+  createEntityPattern(tenant: string, body: EntityPatternRequest): Promise<EntityPattern> {
+    return this.json("POST", `/api/v1/tenants/${encodeURIComponent(tenant)}/entity-patterns`, undefined, body);
+  }
+
+  /** DELETE /api/v1/tenants/{tenant}/entity-patterns/{id}: remove an entity pattern. */
+  async deleteEntityPattern(tenant: string, id: string): Promise<void> {
+    await this.request("DELETE", `/api/v1/tenants/${encodeURIComponent(tenant)}/entity-patterns/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/entity-patterns/{id}: an entity pattern. */
+  getEntityPattern(tenant: string, id: string): Promise<EntityPattern> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/entity-patterns/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** PUT /api/v1/tenants/{tenant}/entity-patterns/{id}: replace an entity pattern. */
+  updateEntityPattern(tenant: string, id: string, body: EntityPatternRequest): Promise<EntityPattern> {
+    return this.json("PUT", `/api/v1/tenants/${encodeURIComponent(tenant)}/entity-patterns/${encodeURIComponent(id)}`, undefined, body);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/interest-profiles: the interest profiles of a tenant. */
+  listInterestProfiles(tenant: string): Promise<ListInterestProfilesResponse> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/interest-profiles`, undefined);
+  }
+
+  /** POST /api/v1/tenants/{tenant}/interest-profiles: add an interest profile. */
+  createInterestProfile(tenant: string, body: InterestProfileRequest): Promise<InterestProfile> {
+    return this.json("POST", `/api/v1/tenants/${encodeURIComponent(tenant)}/interest-profiles`, undefined, body);
+  }
+
+  /** DELETE /api/v1/tenants/{tenant}/interest-profiles/{id}: remove an interest profile. */
+  async deleteInterestProfile(tenant: string, id: string): Promise<void> {
+    await this.request("DELETE", `/api/v1/tenants/${encodeURIComponent(tenant)}/interest-profiles/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/interest-profiles/{id}: an interest profile. */
+  getInterestProfile(tenant: string, id: string): Promise<InterestProfile> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/interest-profiles/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** PUT /api/v1/tenants/{tenant}/interest-profiles/{id}: replace an interest profile. */
+  updateInterestProfile(tenant: string, id: string, body: InterestProfileRequest): Promise<InterestProfile> {
+    return this.json("PUT", `/api/v1/tenants/${encodeURIComponent(tenant)}/interest-profiles/${encodeURIComponent(id)}`, undefined, body);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/pipelines: the pipelines of a tenant, and the stages they can run. */
+  listPipelines(tenant: string): Promise<ListPipelinesResponse> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/pipelines`, undefined);
+  }
+
+  /** POST /api/v1/tenants/{tenant}/pipelines: add a pipeline. */
+  createPipeline(tenant: string, body: PipelineRequest): Promise<Pipeline> {
+    return this.json("POST", `/api/v1/tenants/${encodeURIComponent(tenant)}/pipelines`, undefined, body);
+  }
+
+  /** DELETE /api/v1/tenants/{tenant}/pipelines/{id}: remove a pipeline. */
+  async deletePipeline(tenant: string, id: string): Promise<void> {
+    await this.request("DELETE", `/api/v1/tenants/${encodeURIComponent(tenant)}/pipelines/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/pipelines/{id}: a pipeline. */
+  getPipeline(tenant: string, id: string): Promise<Pipeline> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/pipelines/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** PUT /api/v1/tenants/{tenant}/pipelines/{id}: replace a pipeline. */
+  updatePipeline(tenant: string, id: string, body: PipelineRequest): Promise<Pipeline> {
+    return this.json("PUT", `/api/v1/tenants/${encodeURIComponent(tenant)}/pipelines/${encodeURIComponent(id)}`, undefined, body);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/processing-profiles: the processing profiles of a tenant. */
+  listProcessingProfiles(tenant: string): Promise<ListProcessingProfilesResponse> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/processing-profiles`, undefined);
+  }
+
+  /** POST /api/v1/tenants/{tenant}/processing-profiles: add a processing profile. */
+  createProcessingProfile(tenant: string, body: ProcessingProfileRequest): Promise<ProcessingProfile> {
+    return this.json("POST", `/api/v1/tenants/${encodeURIComponent(tenant)}/processing-profiles`, undefined, body);
+  }
+
+  /** DELETE /api/v1/tenants/{tenant}/processing-profiles/{id}: remove a processing profile. */
+  async deleteProcessingProfile(tenant: string, id: string): Promise<void> {
+    await this.request("DELETE", `/api/v1/tenants/${encodeURIComponent(tenant)}/processing-profiles/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/processing-profiles/{id}: a processing profile. */
+  getProcessingProfile(tenant: string, id: string): Promise<ProcessingProfile> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/processing-profiles/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** PUT /api/v1/tenants/{tenant}/processing-profiles/{id}: replace a processing profile. */
+  updateProcessingProfile(tenant: string, id: string, body: ProcessingProfileRequest): Promise<ProcessingProfile> {
+    return this.json("PUT", `/api/v1/tenants/${encodeURIComponent(tenant)}/processing-profiles/${encodeURIComponent(id)}`, undefined, body);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/reprocessing-policies: the reprocessing policies of a tenant. */
+  listReprocessingPolicies(tenant: string): Promise<ListReprocessingPoliciesResponse> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/reprocessing-policies`, undefined);
+  }
+
+  /** POST /api/v1/tenants/{tenant}/reprocessing-policies: add a reprocessing policy. */
+  createReprocessingPolicy(tenant: string, body: ReprocessingPolicyRequest): Promise<ReprocessingPolicy> {
+    return this.json("POST", `/api/v1/tenants/${encodeURIComponent(tenant)}/reprocessing-policies`, undefined, body);
+  }
+
+  /** DELETE /api/v1/tenants/{tenant}/reprocessing-policies/{id}: remove a reprocessing policy. */
+  async deleteReprocessingPolicy(tenant: string, id: string): Promise<void> {
+    await this.request("DELETE", `/api/v1/tenants/${encodeURIComponent(tenant)}/reprocessing-policies/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/reprocessing-policies/{id}: a reprocessing policy. */
+  getReprocessingPolicy(tenant: string, id: string): Promise<ReprocessingPolicy> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/reprocessing-policies/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** PUT /api/v1/tenants/{tenant}/reprocessing-policies/{id}: replace a reprocessing policy. */
+  updateReprocessingPolicy(tenant: string, id: string, body: ReprocessingPolicyRequest): Promise<ReprocessingPolicy> {
+    return this.json("PUT", `/api/v1/tenants/${encodeURIComponent(tenant)}/reprocessing-policies/${encodeURIComponent(id)}`, undefined, body);
+  }
+
+  /** POST /api/v1/tenants/{tenant}/reprocessing-policies/{id}/run: run a reprocessing policy now. */
+  runReprocessingPolicy(tenant: string, id: string): Promise<ReprocessingRun> {
+    return this.json("POST", `/api/v1/tenants/${encodeURIComponent(tenant)}/reprocessing-policies/${encodeURIComponent(id)}/run`, undefined);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/risk-profiles: the risk profiles of a tenant. */
+  listRiskProfiles(tenant: string): Promise<ListRiskProfilesResponse> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/risk-profiles`, undefined);
+  }
+
+  /** POST /api/v1/tenants/{tenant}/risk-profiles: add a risk profile. */
+  createRiskProfile(tenant: string, body: RiskProfileRequest): Promise<RiskProfile> {
+    return this.json("POST", `/api/v1/tenants/${encodeURIComponent(tenant)}/risk-profiles`, undefined, body);
+  }
+
+  /** DELETE /api/v1/tenants/{tenant}/risk-profiles/{id}: remove a risk profile. */
+  async deleteRiskProfile(tenant: string, id: string): Promise<void> {
+    await this.request("DELETE", `/api/v1/tenants/${encodeURIComponent(tenant)}/risk-profiles/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/risk-profiles/{id}: a risk profile. */
+  getRiskProfile(tenant: string, id: string): Promise<RiskProfile> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/risk-profiles/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** PUT /api/v1/tenants/{tenant}/risk-profiles/{id}: replace a risk profile. */
+  updateRiskProfile(tenant: string, id: string, body: RiskProfileRequest): Promise<RiskProfile> {
+    return this.json("PUT", `/api/v1/tenants/${encodeURIComponent(tenant)}/risk-profiles/${encodeURIComponent(id)}`, undefined, body);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/usage: what a tenant processed in a month, against its quota. */
+  getTenantUsage(tenant: string, params: GetTenantUsageParams = {}): Promise<TenantUsage> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/usage`, params);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/usage/tenders: what a tenant processed for each tender in a month. */
+  getTenderUsage(tenant: string, params: GetTenderUsageParams = {}): Promise<GetTenderUsageResponse> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/usage/tenders`, params);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/webhooks: the webhook endpoints of a tenant. */
+  listWebhookEndpoints(tenant: string): Promise<ListWebhookEndpointsResponse> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/webhooks`, undefined);
+  }
+
+  /** POST /api/v1/tenants/{tenant}/webhooks: add a webhook endpoint. */
+  createWebhookEndpoint(tenant: string, body: WebhookEndpointRequest): Promise<Endpoint> {
+    return this.json("POST", `/api/v1/tenants/${encodeURIComponent(tenant)}/webhooks`, undefined, body);
+  }
+
+  /** DELETE /api/v1/tenants/{tenant}/webhooks/{id}: remove a webhook endpoint. */
+  async deleteWebhookEndpoint(tenant: string, id: string): Promise<void> {
+    await this.request("DELETE", `/api/v1/tenants/${encodeURIComponent(tenant)}/webhooks/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/webhooks/{id}: a webhook endpoint. */
+  getWebhookEndpoint(tenant: string, id: string): Promise<Endpoint> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/webhooks/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** PUT /api/v1/tenants/{tenant}/webhooks/{id}: replace a webhook endpoint. */
+  updateWebhookEndpoint(tenant: string, id: string, body: WebhookEndpointRequest): Promise<Endpoint> {
+    return this.json("PUT", `/api/v1/tenants/${encodeURIComponent(tenant)}/webhooks/${encodeURIComponent(id)}`, undefined, body);
+  }
+
+  /** GET /api/v1/tenants/{tenant}/webhooks/{id}/deliveries: page through the deliveries of a webhook endpoint. */
+  listWebhookDeliveries(tenant: string, id: string, params: ListWebhookDeliveriesParams = {}): Promise<ListWebhookDeliveriesResponse> {
+    return this.json("GET", `/api/v1/tenants/${encodeURIComponent(tenant)}/webhooks/${encodeURIComponent(id)}/deliveries`, params);
+  }
+
+  /** POST /api/v1/tenants/{tenant}/webhooks/{id}/deliveries/{delivery}/redeliver: send a delivery of a webhook endpoint again. */
+  redeliverWebhook(tenant: string, id: string, delivery: string): Promise<Delivery> {
+    return this.json("POST", `/api/v1/tenants/${encodeURIComponent(tenant)}/webhooks/${encodeURIComponent(id)}/deliveries/${encodeURIComponent(delivery)}/redeliver`, undefined);
+  }
+
+  /** POST /api/v1/tenants/{tenant}/webhooks/{id}/rotate-secret: give a webhook endpoint a new signing secret. */
+  rotateWebhookSecret(tenant: string, id: string): Promise<Endpoint> {
+    return this.json("POST", `/api/v1/tenants/${encodeURIComponent(tenant)}/webhooks/${encodeURIComponent(id)}/rotate-secret`, undefined);
+  }
+
+  /** POST /api/v1/tenders: submit the files of a tender to be processed as one job. */
+  submitTender(body: TenderRequest): Promise<SubmitTenderResponse> {
+    return this.json("POST", `/api/v1/tenders`, undefined, body);
+  }
+
+  /** GET /api/v1/tenders/{tender_id}: the latest tender job of a tender. */
+  getTender(tenderID: string, params: GetTenderParams = {}): Promise<ProcessingJob> {
+    return this.json("GET", `/api/v1/tenders/${encodeURIComponent(tenderID)}`, params);
+  }
+
+  /** GET /api/v1/tenders/{tender_id}/jobs: page through the jobs of a tender. */
+  listTenderJobs(tenderID: string, params: ListTenderJobsParams = {}): Promise<ListTenderJobsResponse> {
+    return this.json("GET", `/api/v1/tenders/${encodeURIComponent(tenderID)}/jobs`, params);
+  }
+
+  /** GET /api/v2/jobs/{id}: a job, with its result by page once completed. */
+  getJobV2(id: string): Promise<JobV2> {
+    return this.json("GET", `/api/v2/jobs/${encodeURIComponent(id)}`, undefined);
+  }
+
+  /** GET /api/v2/tenders/{tender_id}: the latest tender job of a tender, its result by page. */
+  getTenderV2(tenderID: string, params: GetTenderV2Params = {}): Promise<JobV2> {
+    return this.json("GET", `/api/v2/tenders/${encodeURIComponent(tenderID)}`, params);
+  }
+}